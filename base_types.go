@@ -171,8 +171,20 @@ func (id ID) Valid() bool {
 	return validIdRegexp.MatchString(string(id))
 }
 
+// OnInvalidID relaxes validation of Id values if not nil.
+//
+// Some servers use Ids that violate the charset or length rules and by
+// default a single such Id makes decoding of the whole response fail. If
+// OnInvalidID is set, ID.UnmarshalJSON accepts invalid Ids and calls
+// OnInvalidID for each of them (e.g. to log it). ID.MarshalText accepts
+// invalid Ids too, so they can be passed back to the server, except for the
+// empty Id.
+//
+// It should be set during initialization, before any decoding is done.
+var OnInvalidID func(id ID)
+
 func (id ID) MarshalText() ([]byte, error) {
-	if !id.Valid() {
+	if !id.Valid() && (OnInvalidID == nil || id == "") {
 		return nil, ErrInvalidId
 	}
 
@@ -185,7 +197,10 @@ func (id *ID) UnmarshalJSON(data []byte) error {
 	}
 
 	if !id.Valid() {
-		return ErrInvalidId
+		if OnInvalidID == nil {
+			return ErrInvalidId
+		}
+		OnInvalidID(*id)
 	}
 	return nil
 }
@@ -88,3 +88,27 @@ func TestIdIsSafe(t *testing.T) {
 	assert.Check(t, !ID("0aaa").Safe())
 	assert.Check(t, !ID("NIL").Safe())
 }
+
+func TestOnInvalidID(t *testing.T) {
+	var ids []ID
+	blob := []byte(`["valid","not:valid"]`)
+
+	var strict []ID
+	err := json.Unmarshal(blob, &strict)
+	assert.Check(t, cmp.Equal(ErrInvalidId, err))
+
+	OnInvalidID = func(id ID) { ids = append(ids, id) }
+	defer func() { OnInvalidID = nil }()
+
+	var relaxed []ID
+	assert.NilError(t, json.Unmarshal(blob, &relaxed))
+	assert.Check(t, cmp.DeepEqual([]ID{"valid", "not:valid"}, relaxed))
+	assert.Check(t, cmp.DeepEqual([]ID{"not:valid"}, ids))
+
+	out, err := json.Marshal(relaxed)
+	assert.NilError(t, err)
+	assert.Check(t, cmp.Equal(string(blob), string(out)))
+
+	_, err = json.Marshal([]ID{""})
+	assert.Check(t, err != nil, "empty ID should be rejected")
+}
@@ -0,0 +1,225 @@
+package jmap
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+// Allocation budgets for the wire-format hot paths, checked by
+// TestAllocBudgets using the same inputs as the benchmarks.
+//
+// The budgets are the allocations per operation measured when they were
+// set (shown in comments) plus about 25% of headroom for differences between
+// Go releases. If a change legitimately needs more allocations, raise the
+// budget in the same commit and explain why.
+const (
+	// Request with 3 calls. Measured: 62.
+	allocsRequestMarshal = 80
+
+	// Response with 3 invocations, arguments kept as json.RawMessage.
+	// Measured: 47, 56 and 52.
+	allocsResponseUnmarshal       = 60
+	allocsResponseUnmarshalStream = 70
+	allocsResponseMarshal         = 65
+
+	// sessionBlob from session_test.go. Measured: 35.
+	allocsSessionUnmarshal = 45
+
+	// Patch with 4 paths on a small object. Measured: 82.
+	allocsApplyPatch = 100
+)
+
+func benchRequest() Request {
+	return Request{
+		Using: []string{CoreCapabilityName, MailCapabilityName},
+		Calls: []Invocation{
+			{Name: "Email/query", CallID: "0", Args: map[string]interface{}{
+				"accountId": "A1",
+				"filter":    map[string]interface{}{"inMailbox": "M1"},
+				"limit":     50,
+			}},
+			{Name: "Email/get", CallID: "1", Args: map[string]interface{}{
+				"accountId": "A1",
+				"#ids": ResultReference{
+					ResultOf: "0",
+					Name:     "Email/query",
+					Path:     "/ids",
+				},
+				"properties": []string{"id", "subject", "from", "receivedAt"},
+			}},
+			{Name: "Mailbox/get", CallID: "2", Args: testArgs{Argument: "foo"}},
+		},
+	}
+}
+
+var benchResponseBlob = []byte(`{
+	"sessionState": "75128aab4b1b",
+	"methodResponses": [
+		["Email/query", {"accountId": "A1", "queryState": "q1", "canCalculateChanges": true, "position": 0, "ids": ["E1", "E2", "E3"]}, "0"],
+		["Email/get", {"accountId": "A1", "state": "s1", "list": [{"id": "E1", "subject": "Hello"}], "notFound": []}, "1"],
+		["error", {"type": "unknownMethod"}, "2"]
+	]
+}`)
+
+var benchUnmarshallers = RawUnmarshallers([]string{"Email/query", "Email/get"})
+
+func benchPatch() PatchObject {
+	return PatchObject{
+		"keywords/$seen":    true,
+		"keywords/$flagged": nil,
+		"mailboxIds/M1":     true,
+		"subject":           "Re: Hello",
+	}
+}
+
+type benchPatchTarget struct {
+	ID         string          `json:"id"`
+	Subject    string          `json:"subject"`
+	Keywords   map[string]bool `json:"keywords"`
+	MailboxIDs map[ID]bool     `json:"mailboxIds"`
+}
+
+func benchPatchObject() benchPatchTarget {
+	return benchPatchTarget{
+		ID:         "E1",
+		Subject:    "Hello",
+		Keywords:   map[string]bool{"$flagged": true},
+		MailboxIDs: map[ID]bool{"M2": true},
+	}
+}
+
+func BenchmarkRequestMarshal(b *testing.B) {
+	req := benchRequest()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := json.Marshal(req); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkResponseUnmarshal(b *testing.B) {
+	b.ReportAllocs()
+	b.SetBytes(int64(len(benchResponseBlob)))
+	for i := 0; i < b.N; i++ {
+		var resp Response
+		if err := resp.Unmarshal(bytes.NewReader(benchResponseBlob), benchUnmarshallers); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkResponseUnmarshalStream(b *testing.B) {
+	b.ReportAllocs()
+	b.SetBytes(int64(len(benchResponseBlob)))
+	for i := 0; i < b.N; i++ {
+		var resp Response
+		if err := resp.UnmarshalStream(bytes.NewReader(benchResponseBlob), benchUnmarshallers); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkResponseMarshal(b *testing.B) {
+	var resp Response
+	if err := resp.Unmarshal(bytes.NewReader(benchResponseBlob), benchUnmarshallers); err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := json.Marshal(resp); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkSessionUnmarshal(b *testing.B) {
+	blob := []byte(sessionBlob)
+	b.ReportAllocs()
+	b.SetBytes(int64(len(blob)))
+	for i := 0; i < b.N; i++ {
+		var s Session
+		if err := json.Unmarshal(blob, &s); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkApplyPatch(b *testing.B) {
+	patch := benchPatch()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		obj := benchPatchObject()
+		if err := ApplyPatch(&obj, patch); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestAllocBudgets(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping allocation budgets in short mode")
+	}
+	if raceEnabled {
+		t.Skip("skipping allocation budgets, race detector changes allocation counts")
+	}
+
+	check := func(t *testing.T, budget float64, f func() error) {
+		t.Helper()
+		if err := f(); err != nil {
+			t.Fatal(err)
+		}
+		allocs := testing.AllocsPerRun(100, func() {
+			f() //nolint:errcheck
+		})
+		if allocs > budget {
+			t.Errorf("%v allocations per run, budget is %v", allocs, budget)
+		}
+	}
+
+	t.Run("Request.MarshalJSON", func(t *testing.T) {
+		req := benchRequest()
+		check(t, allocsRequestMarshal, func() error {
+			_, err := json.Marshal(req)
+			return err
+		})
+	})
+	t.Run("Response.Unmarshal", func(t *testing.T) {
+		check(t, allocsResponseUnmarshal, func() error {
+			var resp Response
+			return resp.Unmarshal(bytes.NewReader(benchResponseBlob), benchUnmarshallers)
+		})
+	})
+	t.Run("Response.UnmarshalStream", func(t *testing.T) {
+		check(t, allocsResponseUnmarshalStream, func() error {
+			var resp Response
+			return resp.UnmarshalStream(bytes.NewReader(benchResponseBlob), benchUnmarshallers)
+		})
+	})
+	t.Run("Response.MarshalJSON", func(t *testing.T) {
+		var resp Response
+		if err := resp.Unmarshal(bytes.NewReader(benchResponseBlob), benchUnmarshallers); err != nil {
+			t.Fatal(err)
+		}
+		check(t, allocsResponseMarshal, func() error {
+			_, err := json.Marshal(resp)
+			return err
+		})
+	})
+	t.Run("Session.UnmarshalJSON", func(t *testing.T) {
+		blob := []byte(sessionBlob)
+		check(t, allocsSessionUnmarshal, func() error {
+			var s Session
+			return json.Unmarshal(blob, &s)
+		})
+	})
+	t.Run("ApplyPatch", func(t *testing.T) {
+		patch := benchPatch()
+		check(t, allocsApplyPatch, func() error {
+			obj := benchPatchObject()
+			return ApplyPatch(&obj, patch)
+		})
+	})
+}
@@ -0,0 +1,125 @@
+// Package calendar implements data types and methods of JMAP for Calendars
+// extension as defined in draft-ietf-jmap-calendars.
+//
+// CalendarEvent objects use JSCalendar format defined in RFC 8984.
+//
+// Documentation strings for most of the protocol objects are taken from (or
+// based on) contents of draft-ietf-jmap-calendars and RFC 8984 and are
+// subject to the IETF Trust Provisions. See
+// https://trustee.ietf.org/trust-legal-provisions.html for details.
+package calendar
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/foxcpp/go-jmap"
+)
+
+const CapabilityName = "urn:ietf:params:jmap:calendars"
+
+var ErrNoCapability = errors.New("jmap/calendar: urn:ietf:params:jmap:calendars capability is not supported for the account")
+
+// Capability is the urn:ietf:params:jmap:calendars account capability
+// object.
+type Capability struct {
+	// The maximum number of Calendars that can be assigned to a single
+	// CalendarEvent object. Nil means no limit.
+	MaxCalendarsPerEvent *jmap.UnsignedInt `json:"maxCalendarsPerEvent"`
+
+	// The earliest date-time value the server is willing to accept for any
+	// date stored in a CalendarEvent.
+	MinDateTime *jmap.UTCDate `json:"minDateTime"`
+
+	// The latest date-time value the server is willing to accept for any
+	// date stored in a CalendarEvent.
+	MaxDateTime *jmap.UTCDate `json:"maxDateTime"`
+
+	// The maximum duration the user may query over when asking the server
+	// to expand recurrences.
+	MaxExpandedQueryDuration Duration `json:"maxExpandedQueryDuration"`
+
+	// The maximum number of participants a single event may have. Nil means
+	// no limit.
+	MaxParticipantsPerEvent *jmap.UnsignedInt `json:"maxParticipantsPerEvent"`
+
+	// If true, the user may create a Calendar in this account.
+	MayCreateCalendar bool `json:"mayCreateCalendar"`
+}
+
+// AccountCapability returns decoded urn:ietf:params:jmap:calendars
+// capability object of the account.
+//
+// ErrNoCapability is returned if the account does not contain calendars
+// data.
+func AccountCapability(acc *jmap.Account) (*Capability, error) {
+	raw, ok := acc.Capabilities[CapabilityName]
+	if !ok {
+		return nil, ErrNoCapability
+	}
+	res := &Capability{}
+	if err := json.Unmarshal(raw, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// ResponseUnmarshallers contains callbacks for decoding responses of all
+// methods implemented by this package.
+//
+// Pass it to client.Enable to make the client decode these responses into
+// corresponding structures.
+var ResponseUnmarshallers = map[string]jmap.FuncArgsUnmarshal{
+	"Calendar/get":     unmarshalCalendarGetResponse,
+	"Calendar/changes": unmarshalCalendarChangesResponse,
+	"Calendar/set":     unmarshalCalendarSetResponse,
+
+	"CalendarEvent/get":     unmarshalCalendarEventGetResponse,
+	"CalendarEvent/changes": unmarshalCalendarEventChangesResponse,
+	"CalendarEvent/query":   unmarshalCalendarEventQueryResponse,
+	"CalendarEvent/set":     unmarshalCalendarEventSetResponse,
+}
+
+// MethodCapabilities maps data types implemented by this package to
+// capabilities that define them.
+//
+// Pass it to client.EnableMethodCapabilities to let the client determine
+// which capabilities can be dropped from requests during capability
+// downgrade.
+var MethodCapabilities = map[string]string{
+	"Calendar":      CapabilityName,
+	"CalendarEvent": CapabilityName,
+}
+
+func init() {
+	// All data types of the package can be used in push type filters.
+	jmap.RegisterPushTypes(MethodCapabilities)
+}
+
+// PropertySchemas contains property lists for all data types implemented by
+// this package.
+//
+// Pass it to client.EnableSchemas to make the client reject requests using
+// unknown properties before sending them.
+var PropertySchemas = jmap.PropertySchemas{
+	"Calendar": {
+		"id", "name", "description", "color", "sortOrder", "isSubscribed",
+		"isVisible", "isDefault", "includeInAvailability",
+		"defaultAlertsWithTime", "defaultAlertsWithoutTime", "timeZone",
+		"shareWith", "myRights",
+	},
+	"CalendarEvent": {
+		"id", "baseEventId", "calendarIds", "isDraft", "isOrigin", "utcStart",
+		"utcEnd", "@type", "uid", "relatedTo", "prodId", "created", "updated",
+		"sequence", "method", "title", "description", "descriptionContentType",
+		"showWithoutTime", "locations", "virtualLocations", "links", "locale",
+		"keywords", "categories", "color", "recurrenceId",
+		"recurrenceIdTimeZone", "recurrenceRules", "excludedRecurrenceRules",
+		"recurrenceOverrides", "excluded", "priority", "freeBusyStatus",
+		"privacy", "replyTo", "sentBy", "participants", "requestStatus",
+		"useDefaultAlerts", "alerts", "localizations", "timeZone", "timeZones",
+		"start", "duration", "status",
+
+		jmap.VendorProperties,
+	},
+}
@@ -0,0 +1,99 @@
+package calendar
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/foxcpp/go-jmap"
+	"gotest.tools/assert"
+	"gotest.tools/assert/cmp"
+)
+
+func TestAccountCapability(t *testing.T) {
+	acc := jmap.Account{Capabilities: map[string]json.RawMessage{
+		CapabilityName: json.RawMessage(`{"maxCalendarsPerEvent":1,"maxExpandedQueryDuration":"P366D","mayCreateCalendar":true}`),
+	}}
+	capa, err := AccountCapability(&acc)
+	assert.NilError(t, err)
+	assert.Check(t, cmp.Equal(jmap.UnsignedInt(1), *capa.MaxCalendarsPerEvent))
+	assert.Check(t, cmp.Equal(Duration("P366D"), capa.MaxExpandedQueryDuration))
+	assert.Check(t, capa.MaxParticipantsPerEvent == nil)
+	assert.Check(t, capa.MayCreateCalendar)
+
+	_, err = AccountCapability(&jmap.Account{})
+	assert.Check(t, cmp.Equal(ErrNoCapability, err))
+}
+
+func TestPushTypes(t *testing.T) {
+	assert.Check(t, cmp.Equal(CapabilityName, jmap.PushTypeCapabilities["Calendar"]))
+	assert.Check(t, cmp.Equal(CapabilityName, jmap.PushTypeCapabilities["CalendarEvent"]))
+}
+
+func TestCalendarEventGetResponse(t *testing.T) {
+	blob := json.RawMessage(`{
+		"accountId": "A1",
+		"state": "s1",
+		"list": [{
+			"id": "E1",
+			"calendarIds": {"CAL1": true},
+			"@type": "Event",
+			"uid": "a8df6573-0474-496d-8496-033ad45d7fea",
+			"title": "Standup",
+			"start": "2020-01-06T09:30:00",
+			"timeZone": "Europe/Berlin",
+			"duration": "PT15M",
+			"recurrenceRules": [{
+				"frequency": "weekly",
+				"byDay": [{"day": "mo"}, {"day": "we"}],
+				"count": 10
+			}],
+			"recurrenceOverrides": {
+				"2020-01-08T09:30:00": {"excluded": true}
+			},
+			"participants": {
+				"p1": {
+					"name": "Jane",
+					"email": "jane@example.com",
+					"roles": {"owner": true, "attendee": true},
+					"participationStatus": "accepted"
+				}
+			},
+			"alerts": {
+				"a1": {"trigger": {"@type": "OffsetTrigger", "offset": "-PT5M"}}
+			}
+		}],
+		"notFound": []
+	}`)
+	args, err := ResponseUnmarshallers["CalendarEvent/get"](blob)
+	assert.NilError(t, err)
+	resp := args.(CalendarEventGetResponse)
+	assert.Equal(t, 1, len(resp.List))
+
+	ev := resp.List[0]
+	assert.Check(t, cmp.DeepEqual(map[jmap.ID]bool{"CAL1": true}, ev.CalendarIDs))
+	assert.Check(t, cmp.Equal(Duration("PT15M"), ev.Duration))
+	assert.Check(t, cmp.DeepEqual([]RecurrenceRule{{
+		Frequency: FrequencyWeekly,
+		ByDay:     []NDay{{Day: "mo"}, {Day: "we"}},
+		Count:     10,
+	}}, ev.RecurrenceRules))
+	assert.Check(t, cmp.DeepEqual(jmap.PatchObject{"excluded": true}, ev.RecurrenceOverrides["2020-01-08T09:30:00"]))
+	assert.Check(t, cmp.Equal(ParticipationAccepted, ev.Participants["p1"].ParticipationStatus))
+	assert.Check(t, ev.Participants["p1"].Roles[RoleOwner])
+	assert.Check(t, cmp.DeepEqual(Trigger{Type: TriggerOffset, Offset: "-PT5M"}, ev.Alerts["a1"].Trigger))
+
+	loc, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("time zone database is not available:", err)
+	}
+	start, err := ev.Start.In(loc)
+	assert.NilError(t, err)
+	assert.Check(t, cmp.Equal(time.Date(2020, 1, 6, 8, 30, 0, 0, time.UTC).Unix(), start.Unix()))
+	assert.Check(t, cmp.Equal(ev.Start, NewLocalDateTime(start)))
+
+	// Unset properties should not be sent in /set calls.
+	out, err := json.Marshal(Event{Title: "Lunch", Start: "2020-01-06T12:00:00"})
+	assert.NilError(t, err)
+	assert.Check(t, cmp.Equal(`{"title":"Lunch","start":"2020-01-06T12:00:00"}`, string(out)))
+}
@@ -0,0 +1,273 @@
+package calendar
+
+import (
+	"encoding/json"
+
+	"github.com/foxcpp/go-jmap"
+)
+
+// CalendarRights describes the set of rights the user has on the Calendar.
+type CalendarRights struct {
+	// The user may read the free-busy information for this Calendar.
+	MayReadFreeBusy bool `json:"mayReadFreeBusy"`
+
+	// The user may fetch the events in this Calendar.
+	MayReadItems bool `json:"mayReadItems"`
+
+	// The user may create, modify or destroy all events in this Calendar.
+	MayWriteAll bool `json:"mayWriteAll"`
+
+	// The user may create, modify or destroy an event on this Calendar if
+	// either they are the owner of the event or the event has no owner.
+	MayWriteOwn bool `json:"mayWriteOwn"`
+
+	// The user may modify per-user properties (keywords, color, alerts,
+	// etc.) on all events in the Calendar.
+	MayUpdatePrivate bool `json:"mayUpdatePrivate"`
+
+	// The user may modify their own participation status on events in the
+	// Calendar.
+	MayRSVP bool `json:"mayRSVP"`
+
+	// The user may modify sharing of the Calendar.
+	MayAdmin bool `json:"mayAdmin"`
+
+	// The user may delete the Calendar itself.
+	MayDelete bool `json:"mayDelete"`
+}
+
+// Values of Calendar.IncludeInAvailability.
+const (
+	AvailabilityAll       = "all"
+	AvailabilityAttending = "attending"
+	AvailabilityNone      = "none"
+)
+
+// Calendar is a named collection of CalendarEvents.
+//
+// See draft-ietf-jmap-calendars, section 4 for details.
+type Calendar struct {
+	// The id of the Calendar.
+	ID jmap.ID `json:"id,omitempty"`
+
+	// The user-visible name of the Calendar.
+	Name string `json:"name,omitempty"`
+
+	// An optional longer-form description of the Calendar.
+	Description string `json:"description,omitempty"`
+
+	// A color to be used when displaying events associated with the
+	// Calendar, as CSS color value.
+	Color string `json:"color,omitempty"`
+
+	// Defines the sort order of Calendars when presented in the client's UI.
+	SortOrder jmap.UnsignedInt `json:"sortOrder,omitempty"`
+
+	// True if the user has indicated they wish to see this Calendar in their
+	// client.
+	IsSubscribed bool `json:"isSubscribed,omitempty"`
+
+	// Should the Calendar's events be displayed to the user at the moment?
+	IsVisible bool `json:"isVisible,omitempty"`
+
+	// True for the Calendar that should be used by clients whenever they
+	// need to choose a Calendar for the user within this account. Set by
+	// server.
+	IsDefault bool `json:"isDefault,omitempty"`
+
+	// Should the Calendar's events be used as part of availability
+	// calculation? One of Availability* constants.
+	IncludeInAvailability string `json:"includeInAvailability,omitempty"`
+
+	// Alerts to use for events with showWithoutTime false and
+	// useDefaultAlerts true.
+	DefaultAlertsWithTime map[string]Alert `json:"defaultAlertsWithTime,omitempty"`
+
+	// Alerts to use for events with showWithoutTime true and
+	// useDefaultAlerts true.
+	DefaultAlertsWithoutTime map[string]Alert `json:"defaultAlertsWithoutTime,omitempty"`
+
+	// The time zone to use for events without a time zone when the server
+	// needs to resolve them into absolute time.
+	TimeZone string `json:"timeZone,omitempty"`
+
+	// A map of principal id to rights for principals this Calendar is
+	// shared with. Nil if the Calendar is not shared.
+	ShareWith map[jmap.ID]CalendarRights `json:"shareWith,omitempty"`
+
+	// The set of access rights the user has in relation to this Calendar.
+	// Set by server.
+	MyRights *CalendarRights `json:"myRights,omitempty"`
+}
+
+// CalendarShareWithPatch returns the Calendar/set patch that changes the
+// shareWith property from current to desired, see jmap.ShareWithDiffPatch.
+//
+// Use jmap.ShareWithPatch and jmap.UnsharePatch to share the Calendar with
+// a single principal or revoke its access.
+func CalendarShareWithPatch(current, desired map[jmap.ID]CalendarRights) jmap.PatchObject {
+	// CalendarRights is always encoded successfully.
+	patch, _ := jmap.ShareWithDiffPatch(current, desired)
+	return patch
+}
+
+// CalendarGetArgs contains arguments for Calendar/get method call.
+type CalendarGetArgs struct {
+	// The id of the account to use.
+	AccountID jmap.ID `json:"accountId"`
+
+	// The ids of the Calendar objects to return. If nil, then all records
+	// are returned.
+	IDs []jmap.ID `json:"ids"`
+
+	// If not nil, only the properties listed in the array are returned for
+	// each Calendar object.
+	Properties []string `json:"properties"`
+}
+
+// CalendarGetResponse contains results of Calendar/get method call.
+type CalendarGetResponse struct {
+	// The id of the account used for the call.
+	AccountID jmap.ID `json:"accountId"`
+
+	// A string representing the state on the server for all the data of this
+	// type in the account.
+	State string `json:"state"`
+
+	// An array of the Calendar objects requested.
+	List []Calendar `json:"list"`
+
+	// This array contains the ids passed to the method for records that do
+	// not exist.
+	NotFound []jmap.ID `json:"notFound"`
+}
+
+// CalendarChangesArgs contains arguments for Calendar/changes method call.
+type CalendarChangesArgs struct {
+	// The id of the account to use.
+	AccountID jmap.ID `json:"accountId"`
+
+	// The current state of the client, as returned by Calendar/get.
+	SinceState string `json:"sinceState"`
+
+	// The maximum number of ids to return in the response. Zero means no
+	// limit.
+	MaxChanges jmap.UnsignedInt `json:"maxChanges,omitempty"`
+}
+
+// CalendarChangesResponse contains results of Calendar/changes method call.
+type CalendarChangesResponse struct {
+	// The id of the account used for the call.
+	AccountID jmap.ID `json:"accountId"`
+
+	// This is the sinceState argument echoed back.
+	OldState string `json:"oldState"`
+
+	// This is the state the client will be in after applying the set of
+	// changes to the old state.
+	NewState string `json:"newState"`
+
+	// If true, the client may call Calendar/changes again with the NewState
+	// returned to get further updates.
+	HasMoreChanges bool `json:"hasMoreChanges"`
+
+	// An array of ids for records that have been created since the old
+	// state.
+	Created []jmap.ID `json:"created"`
+
+	// An array of ids for records that have been updated since the old
+	// state.
+	Updated []jmap.ID `json:"updated"`
+
+	// An array of ids for records that have been destroyed since the old
+	// state.
+	Destroyed []jmap.ID `json:"destroyed"`
+}
+
+// CalendarSetArgs contains arguments for Calendar/set method call.
+type CalendarSetArgs struct {
+	// The id of the account to use.
+	AccountID jmap.ID `json:"accountId"`
+
+	// If not empty, the method will be aborted and a stateMismatch error
+	// returned if the current state does not match this string.
+	IfInState string `json:"ifInState,omitempty"`
+
+	// A map of creation id to Calendar objects.
+	Create map[jmap.ID]Calendar `json:"create,omitempty"`
+
+	// A map of id to a patch object to apply to the current Calendar object
+	// with that id.
+	Update map[jmap.ID]jmap.PatchObject `json:"update,omitempty"`
+
+	// A list of ids for Calendar objects to permanently delete.
+	Destroy []jmap.ID `json:"destroy,omitempty"`
+
+	// If false, any attempt to destroy a Calendar that still has
+	// CalendarEvents in it will be rejected with a calendarHasEvent
+	// SetError. If true, any CalendarEvents that were in the Calendar will
+	// be removed from it, and if in no other Calendars, they will be
+	// destroyed.
+	OnDestroyRemoveEvents bool `json:"onDestroyRemoveEvents,omitempty"`
+
+	// If set, the Calendar with this id (or creation id prefixed with #)
+	// becomes the default one after all creates, updates and destroys
+	// succeed.
+	OnSuccessSetIsDefault jmap.ID `json:"onSuccessSetIsDefault,omitempty"`
+}
+
+// CalendarSetResponse contains results of Calendar/set method call.
+type CalendarSetResponse struct {
+	// The id of the account used for the call.
+	AccountID jmap.ID `json:"accountId"`
+
+	// The state string that would have been returned by Calendar/get before
+	// making the requested changes.
+	OldState string `json:"oldState"`
+
+	// The state string that will now be returned by Calendar/get.
+	NewState string `json:"newState"`
+
+	// A map of the creation id to an object containing any properties of the
+	// created Calendar object that were not sent by the client.
+	Created map[jmap.ID]Calendar `json:"created"`
+
+	// The keys in this map are the ids of all Calendars that were
+	// successfully updated. The value is a Calendar object containing any
+	// property that changed in a way not explicitly requested, or nil if
+	// none.
+	Updated map[jmap.ID]*Calendar `json:"updated"`
+
+	// A list of Calendar ids for records that were successfully destroyed.
+	Destroyed []jmap.ID `json:"destroyed"`
+
+	// A map of creation id to a SetError object for each record that failed
+	// to be created.
+	NotCreated map[jmap.ID]jmap.SetError `json:"notCreated"`
+
+	// A map of Calendar id to a SetError object for each record that failed
+	// to be updated.
+	NotUpdated map[jmap.ID]jmap.SetError `json:"notUpdated"`
+
+	// A map of Calendar id to a SetError object for each record that failed
+	// to be destroyed.
+	NotDestroyed map[jmap.ID]jmap.SetError `json:"notDestroyed"`
+}
+
+func unmarshalCalendarGetResponse(args json.RawMessage) (interface{}, error) {
+	resp := CalendarGetResponse{}
+	err := json.Unmarshal(args, &resp)
+	return resp, err
+}
+
+func unmarshalCalendarChangesResponse(args json.RawMessage) (interface{}, error) {
+	resp := CalendarChangesResponse{}
+	err := json.Unmarshal(args, &resp)
+	return resp, err
+}
+
+func unmarshalCalendarSetResponse(args json.RawMessage) (interface{}, error) {
+	resp := CalendarSetResponse{}
+	err := json.Unmarshal(args, &resp)
+	return resp, err
+}
@@ -0,0 +1,153 @@
+package calendar
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/foxcpp/go-jmap"
+	"github.com/foxcpp/go-jmap/client"
+	"github.com/foxcpp/go-jmap/internal/bulk"
+)
+
+// ICalendarDecoder converts iCalendar data (RFC 5545) to JSCalendar Events,
+// e.g. using the mapping defined in RFC 9253.
+//
+// This package does not implement iCalendar parsing, decoders are provided by
+// the application, usually as wrappers around third-party libraries.
+type ICalendarDecoder interface {
+	// DecodeICalendar reads the iCalendar stream (usually a single VCALENDAR
+	// object) and returns the events it contains. Recurrence overrides of an
+	// event should be merged into RecurrenceOverrides of the master event.
+	DecodeICalendar(r io.Reader) ([]Event, error)
+}
+
+// ICalendarEncoder converts JSCalendar Events to iCalendar data (RFC 5545).
+type ICalendarEncoder interface {
+	// EncodeICalendar writes the events as a single iCalendar stream.
+	EncodeICalendar(w io.Writer, events []Event) error
+}
+
+// ICalendarConverter converts JSCalendar Events to and from iCalendar.
+type ICalendarConverter interface {
+	ICalendarDecoder
+	ICalendarEncoder
+}
+
+var using = []string{jmap.CoreCapabilityName, CapabilityName}
+
+// ImportResult contains results of ImportICalendar.
+type ImportResult struct {
+	// Ids of created CalendarEvents in the order of decoded events. Ids of
+	// events that failed to be created are empty.
+	IDs []jmap.ID
+
+	// Errors for events that failed to be created, keyed by their index in
+	// IDs.
+	Failed map[int]jmap.SetError
+}
+
+// ImportICalendar decodes the iCalendar data using dec and creates the events
+// in the Calendar using as many CalendarEvent/set calls as needed to respect
+// the maxObjectsInSet limit. Ids and CalendarIDs of decoded events are
+// replaced.
+//
+// Properties of decoded events not represented by Event fields must be
+// placed in Event.Extra by dec to be stored on the server.
+//
+// If a call fails as a whole, the error is returned along with results of
+// previous calls.
+//
+// The client must have ResponseUnmarshallers enabled.
+func ImportICalendar(c *client.Client, account, calendar jmap.ID, dec ICalendarDecoder, r io.Reader) (*ImportResult, error) {
+	events, err := dec.DecodeICalendar(r)
+	if err != nil {
+		return nil, err
+	}
+	session, err := c.CurrentSession()
+	if err != nil {
+		return nil, err
+	}
+	chunkSize := int(session.Limits().MaxObjectsInSet)
+
+	for i := range events {
+		events[i].ID = ""
+		events[i].CalendarIDs = map[jmap.ID]bool{calendar: true}
+	}
+
+	res := &ImportResult{}
+	res.IDs, res.Failed, err = bulk.Create(events, chunkSize, func(create map[jmap.ID]Event) (map[jmap.ID]jmap.ID, map[jmap.ID]jmap.SetError, error) {
+		respArgs, err := c.Call(using, "CalendarEvent/set", CalendarEventSetArgs{
+			AccountID: account,
+			Create:    create,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		resp, ok := respArgs.(CalendarEventSetResponse)
+		if !ok {
+			return nil, nil, unexpectedResponse("CalendarEvent/set", respArgs)
+		}
+		created := make(map[jmap.ID]jmap.ID, len(resp.Created))
+		for cid, obj := range resp.Created {
+			created[cid] = obj.ID
+		}
+		return created, resp.NotCreated, nil
+	})
+	return res, err
+}
+
+// ExportICalendar fetches all events in the Calendar and writes them as
+// iCalendar data using enc.
+//
+// Events are requested using CalendarEvent/query and CalendarEvent/get calls
+// respecting the maxObjectsInGet limit, all of them are kept in memory before
+// being passed to enc. Properties not represented by Event fields are
+// available to enc in Event.Extra.
+//
+// The client must have ResponseUnmarshallers enabled.
+func ExportICalendar(c *client.Client, account, calendar jmap.ID, enc ICalendarEncoder, w io.Writer) error {
+	session, err := c.CurrentSession()
+	if err != nil {
+		return err
+	}
+	chunkSize := int(session.Limits().MaxObjectsInGet)
+
+	events, err := bulk.QueryGet(chunkSize, func(position, limit int) ([]jmap.ID, error) {
+		respArgs, err := c.Call(using, "CalendarEvent/query", CalendarEventQueryArgs{
+			AccountID: account,
+			Filter:    CalendarEventFilterCondition{InCalendar: calendar},
+			Position:  jmap.Int(position),
+			Limit:     jmap.UnsignedInt(limit),
+		})
+		if err != nil {
+			return nil, err
+		}
+		query, ok := respArgs.(CalendarEventQueryResponse)
+		if !ok {
+			return nil, unexpectedResponse("CalendarEvent/query", respArgs)
+		}
+		return query.IDs, nil
+	}, func(ids []jmap.ID) ([]Event, error) {
+		respArgs, err := c.Call(using, "CalendarEvent/get", CalendarEventGetArgs{
+			AccountID: account,
+			IDs:       ids,
+		})
+		if err != nil {
+			return nil, err
+		}
+		get, ok := respArgs.(CalendarEventGetResponse)
+		if !ok {
+			return nil, unexpectedResponse("CalendarEvent/get", respArgs)
+		}
+		return get.List, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return enc.EncodeICalendar(w, events)
+}
+
+func unexpectedResponse(methodName string, args interface{}) error {
+	return fmt.Errorf("jmap/calendar: unexpected %s response type %T, is calendar.ResponseUnmarshallers enabled?", methodName, args)
+}
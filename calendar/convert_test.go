@@ -0,0 +1,69 @@
+package calendar
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/foxcpp/go-jmap"
+	"github.com/foxcpp/go-jmap/client"
+	"github.com/foxcpp/go-jmap/internal/bulk/bulktest"
+	"gotest.tools/assert"
+	"gotest.tools/assert/cmp"
+)
+
+// jsonConverter stands in for an iCalendar converter in tests, it uses a
+// JSON array of Events as the "iCalendar" format.
+type jsonConverter struct{}
+
+func (jsonConverter) DecodeICalendar(r io.Reader) ([]Event, error) {
+	var events []Event
+	err := json.NewDecoder(r).Decode(&events)
+	return events, err
+}
+
+func (jsonConverter) EncodeICalendar(w io.Writer, events []Event) error {
+	return json.NewEncoder(w).Encode(events)
+}
+
+func TestImportExportICalendar(t *testing.T) {
+	store := &bulktest.Store{
+		TypeName: "CalendarEvent",
+		Reject:   func(obj map[string]interface{}) bool { return obj["title"] == "invalid" },
+	}
+	srv := bulktest.NewServer(store)
+	defer srv.Close()
+	c, err := client.NewWithClient(srv.Client(), srv.SessionURL(), "")
+	assert.NilError(t, err)
+	c.Enable(ResponseUnmarshallers)
+	// Unknown vendor properties pass validation.
+	c.EnableSchemas(PropertySchemas)
+
+	input := `[{"id":"X","title":"a","example.com:vendor":{"a":1}},{"title":"invalid"},{"title":"b","calendarIds":{"other":true}}]`
+	res, err := ImportICalendar(c, "A1", "C1", jsonConverter{}, strings.NewReader(input))
+	assert.NilError(t, err)
+	assert.Check(t, cmp.DeepEqual([]jmap.ID{"O0", "", "O2"}, res.IDs))
+	assert.Check(t, cmp.Len(res.Failed, 1))
+	assert.Check(t, cmp.Equal(jmap.CodeInvalidProperties, res.Failed[1].Type))
+	stored := store.Objects()
+	for _, obj := range stored {
+		assert.Check(t, cmp.DeepEqual(map[string]interface{}{"C1": true}, obj["calendarIds"]))
+	}
+	// Unknown properties are not lost.
+	assert.Check(t, cmp.DeepEqual(map[string]interface{}{"a": float64(1)}, stored[0]["example.com:vendor"]))
+
+	// Three pages are requested, the last one is empty.
+	store.Add(map[string]interface{}{"id": "O3", "title": "c"})
+	var out bytes.Buffer
+	assert.NilError(t, ExportICalendar(c, "A1", "C1", jsonConverter{}, &out))
+	exported, err := jsonConverter{}.DecodeICalendar(&out)
+	assert.NilError(t, err)
+	var keys []string
+	for _, ev := range exported {
+		keys = append(keys, ev.Title)
+	}
+	assert.Check(t, cmp.DeepEqual([]string{"a", "b", "c"}, keys))
+	assert.Check(t, cmp.DeepEqual(map[string]json.RawMessage{"example.com:vendor": json.RawMessage(`{"a":1}`)}, exported[0].Extra))
+}
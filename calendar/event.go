@@ -0,0 +1,454 @@
+package calendar
+
+import (
+	"encoding/json"
+
+	"github.com/foxcpp/go-jmap"
+	"github.com/foxcpp/go-jmap/internal/jsonprops"
+)
+
+// Values of Event.Status.
+const (
+	StatusConfirmed = "confirmed"
+	StatusCancelled = "cancelled"
+	StatusTentative = "tentative"
+)
+
+// Values of Event.FreeBusyStatus.
+const (
+	FreeBusyFree = "free"
+	FreeBusyBusy = "busy"
+)
+
+// Values of Event.Privacy.
+const (
+	PrivacyPublic  = "public"
+	PrivacyPrivate = "private"
+	PrivacySecret  = "secret"
+)
+
+// Event is the JSCalendar Event object extended with JMAP-specific
+// properties to form the CalendarEvent object.
+//
+// Only the commonly used JSCalendar properties are represented. Maps are
+// keyed by ids local to the Event, assigned by the client.
+//
+// See RFC 8984 and draft-ietf-jmap-calendars, section 5 for details.
+type Event struct {
+	// The id of the CalendarEvent. JMAP-specific.
+	ID jmap.ID `json:"id,omitempty"`
+
+	// The id of the base event for occurrences returned by CalendarEvent/get
+	// or CalendarEvent/query with expanded recurrences. JMAP-specific.
+	BaseEventID jmap.ID `json:"baseEventId,omitempty"`
+
+	// The set of Calendar ids this event belongs to. JMAP-specific.
+	CalendarIDs map[jmap.ID]bool `json:"calendarIds,omitempty"`
+
+	// If true, the event is not yet finished and scheduling messages are not
+	// sent for it. JMAP-specific.
+	IsDraft bool `json:"isDraft,omitempty"`
+
+	// If true, the user is the organizer of the event or the event has no
+	// organizer. Set by server. JMAP-specific.
+	IsOrigin bool `json:"isOrigin,omitempty"`
+
+	// Start and end of the event in UTC, as calculated by the server.
+	// JMAP-specific, only returned if requested explicitly.
+	UTCStart *jmap.UTCDate `json:"utcStart,omitempty"`
+	UTCEnd   *jmap.UTCDate `json:"utcEnd,omitempty"`
+
+	// Must be "Event" if set.
+	Type string `json:"@type,omitempty"`
+
+	// A globally unique identifier used to associate the object as the same
+	// across different systems, calendars and views.
+	UID string `json:"uid,omitempty"`
+
+	// Other objects (keyed by their uids) this Event is related to.
+	RelatedTo map[string]Relation `json:"relatedTo,omitempty"`
+
+	// The identifier for the product that last updated the object.
+	ProdID string `json:"prodId,omitempty"`
+
+	// The date and time this object was initially created.
+	Created *jmap.UTCDate `json:"created,omitempty"`
+
+	// The date and time the data in this object was last modified.
+	Updated *jmap.UTCDate `json:"updated,omitempty"`
+
+	// Initially zero, incremented by one every time a change is made to the
+	// object by the organizer.
+	Sequence jmap.UnsignedInt `json:"sequence,omitempty"`
+
+	// The iTIP method (e.g. "request"), only used for scheduling messages.
+	Method string `json:"method,omitempty"`
+
+	// A short summary of the object.
+	Title string `json:"title,omitempty"`
+
+	// A longer-form text description of the object.
+	Description string `json:"description,omitempty"`
+
+	// The media type of Description, "text/plain" if empty.
+	DescriptionContentType string `json:"descriptionContentType,omitempty"`
+
+	// Indicates that the time is not important to display to the user (e.g.
+	// for all-day events).
+	ShowWithoutTime bool `json:"showWithoutTime,omitempty"`
+
+	// The locations where the event takes place.
+	Locations map[string]Location `json:"locations,omitempty"`
+
+	// The virtual locations (e.g. video conference links) where the event
+	// takes place.
+	VirtualLocations map[string]VirtualLocation `json:"virtualLocations,omitempty"`
+
+	// Links to external resources related to the object.
+	Links map[string]Link `json:"links,omitempty"`
+
+	// The language tag (RFC 5646) of the language used for text values.
+	Locale string `json:"locale,omitempty"`
+
+	// The set of free-text keywords, also known as tags.
+	Keywords map[string]bool `json:"keywords,omitempty"`
+
+	// The set of categories the object belongs to, as URIs.
+	Categories map[string]bool `json:"categories,omitempty"`
+
+	// A color to be used when displaying the object, as CSS color value.
+	Color string `json:"color,omitempty"`
+
+	// Set for occurrences of recurring events, the start of the occurrence
+	// in the time zone of the base event.
+	RecurrenceID LocalDateTime `json:"recurrenceId,omitempty"`
+
+	// The time zone of the RecurrenceID.
+	RecurrenceIDTimeZone string `json:"recurrenceIdTimeZone,omitempty"`
+
+	// The rules defining occurrences of the recurring event.
+	RecurrenceRules []RecurrenceRule `json:"recurrenceRules,omitempty"`
+
+	// The rules defining occurrences excluded from the recurrence.
+	ExcludedRecurrenceRules []RecurrenceRule `json:"excludedRecurrenceRules,omitempty"`
+
+	// Patches to apply to occurrences, keyed by their recurrence ids. The
+	// patch may also add an occurrence or exclude it by setting "excluded"
+	// to true.
+	RecurrenceOverrides map[LocalDateTime]jmap.PatchObject `json:"recurrenceOverrides,omitempty"`
+
+	// Set to true in recurrenceOverrides patches to exclude the occurrence.
+	Excluded bool `json:"excluded,omitempty"`
+
+	// The priority of the event, 1 is the highest and 9 is the lowest. Zero
+	// means undefined.
+	Priority int `json:"priority,omitempty"`
+
+	// How the event should be treated for availability calculation, one of
+	// FreeBusy* constants. "busy" if empty.
+	FreeBusyStatus string `json:"freeBusyStatus,omitempty"`
+
+	// Level of privacy of the event, one of Privacy* constants. "public" if
+	// empty.
+	Privacy string `json:"privacy,omitempty"`
+
+	// Methods (e.g. "imip") and URIs to send replies to the organizer to.
+	ReplyTo map[string]string `json:"replyTo,omitempty"`
+
+	// The email address of the user that made the last change to the
+	// object.
+	SentBy string `json:"sentBy,omitempty"`
+
+	// The participants of the event.
+	Participants map[string]Participant `json:"participants,omitempty"`
+
+	// If true, Calendar default alerts are used instead of Alerts.
+	UseDefaultAlerts bool `json:"useDefaultAlerts,omitempty"`
+
+	// Alerts to display or send to the user.
+	Alerts map[string]Alert `json:"alerts,omitempty"`
+
+	// The IANA time zone name for the Start. If empty, the event is in
+	// floating time.
+	TimeZone string `json:"timeZone,omitempty"`
+
+	// The start of the event in its TimeZone.
+	Start LocalDateTime `json:"start,omitempty"`
+
+	// The duration of the event. Zero if empty.
+	Duration Duration `json:"duration,omitempty"`
+
+	// The scheduling status of the event, one of Status* constants.
+	// "confirmed" if empty.
+	Status string `json:"status,omitempty"`
+
+	// Properties not represented by other fields, e.g. JSCalendar properties
+	// not listed above or vendor extensions. They are kept as is so that
+	// objects can be fetched, converted and stored again without losing
+	// data. Properties set by other fields take precedence. PropertySchemas
+	// accept only vendor-specific names (see jmap.VendorProperties) here.
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+type event Event
+
+func (e Event) MarshalJSON() ([]byte, error) {
+	blob, err := json.Marshal(event(e))
+	if err != nil {
+		return nil, err
+	}
+	return jsonprops.Merge(blob, e.Extra)
+}
+
+func (e *Event) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, (*event)(e)); err != nil {
+		return err
+	}
+
+	var err error
+	e.Extra, err = jsonprops.Unknown(data, e)
+	return err
+}
+
+// Relation describes how the related object is related to the Event.
+type Relation struct {
+	// The relation types (e.g. "first", "next", "parent"). Empty if
+	// unspecified.
+	Relation map[string]bool `json:"relation,omitempty"`
+}
+
+// Location is the physical location of the Event.
+type Location struct {
+	// The human-readable name of the location.
+	Name string `json:"name,omitempty"`
+
+	// Human-readable instructions for accessing the location.
+	Description string `json:"description,omitempty"`
+
+	// Set to "start" or "end" if the location is only used for the start or
+	// end of a travel event.
+	RelativeTo string `json:"relativeTo,omitempty"`
+
+	// The IANA time zone name of the location.
+	TimeZone string `json:"timeZone,omitempty"`
+
+	// A "geo:" URI (RFC 5870) for the location.
+	Coordinates string `json:"coordinates,omitempty"`
+
+	// Links to external resources related to the location.
+	Links map[string]Link `json:"links,omitempty"`
+}
+
+// VirtualLocation is the virtual location (e.g. a video conference) of the
+// Event.
+type VirtualLocation struct {
+	// The human-readable name of the location.
+	Name string `json:"name,omitempty"`
+
+	// Human-readable instructions for accessing the location.
+	Description string `json:"description,omitempty"`
+
+	// The URI to access the location.
+	URI string `json:"uri"`
+
+	// The features supported by the location (e.g. "audio", "video",
+	// "chat").
+	Features map[string]bool `json:"features,omitempty"`
+}
+
+// Link is the external resource related to the Event.
+type Link struct {
+	// The resource URI.
+	Href string `json:"href"`
+
+	// The content id (RFC 2392) of the resource, for iCalendar messages.
+	CID string `json:"cid,omitempty"`
+
+	// The media type (RFC 2046) of the resource.
+	ContentType string `json:"contentType,omitempty"`
+
+	// The size of the resource in octets.
+	Size jmap.UnsignedInt `json:"size,omitempty"`
+
+	// The relation of the resource to the object (e.g. "enclosure").
+	Rel string `json:"rel,omitempty"`
+
+	// The intended purpose of the link for images, e.g. "icon".
+	Display string `json:"display,omitempty"`
+
+	// A human-readable title of the resource.
+	Title string `json:"title,omitempty"`
+}
+
+// Values of Participant.ParticipationStatus.
+const (
+	ParticipationNeedsAction = "needs-action"
+	ParticipationAccepted    = "accepted"
+	ParticipationDeclined    = "declined"
+	ParticipationTentative   = "tentative"
+	ParticipationDelegated   = "delegated"
+)
+
+// Common keys of Participant.Roles.
+const (
+	RoleOwner         = "owner"
+	RoleAttendee      = "attendee"
+	RoleOptional      = "optional"
+	RoleInformational = "informational"
+	RoleChair         = "chair"
+)
+
+// Participant is the participant of the Event.
+type Participant struct {
+	// The display name of the participant.
+	Name string `json:"name,omitempty"`
+
+	// The email address of the participant.
+	Email string `json:"email,omitempty"`
+
+	// A description of the participant.
+	Description string `json:"description,omitempty"`
+
+	// Methods (e.g. "imip") and URIs to send scheduling messages to the
+	// participant.
+	SendTo map[string]string `json:"sendTo,omitempty"`
+
+	// The kind of the participant: "individual", "group", "location" or
+	// "resource".
+	Kind string `json:"kind,omitempty"`
+
+	// The roles of the participant, see Role* constants.
+	Roles map[string]bool `json:"roles,omitempty"`
+
+	// The key of the Location in Event.Locations the participant is
+	// expected to be at.
+	LocationID string `json:"locationId,omitempty"`
+
+	// The participation status, one of Participation* constants.
+	// "needs-action" if empty.
+	ParticipationStatus string `json:"participationStatus,omitempty"`
+
+	// A note from the participant to explain their participation status.
+	ParticipationComment string `json:"participationComment,omitempty"`
+
+	// If true, the organizer is expecting the participant to notify them of
+	// their participation status.
+	ExpectReply bool `json:"expectReply,omitempty"`
+
+	// Who is responsible for sending scheduling messages to the
+	// participant: "server" (the default), "client" or "none".
+	ScheduleAgent string `json:"scheduleAgent,omitempty"`
+
+	// The sequence number of the last response from the participant.
+	ScheduleSequence jmap.UnsignedInt `json:"scheduleSequence,omitempty"`
+
+	// The timestamp of the last response from the participant.
+	ScheduleUpdated *jmap.UTCDate `json:"scheduleUpdated,omitempty"`
+
+	// The keys of participants that invited this one.
+	InvitedBy string `json:"invitedBy,omitempty"`
+
+	// Keys of participants the participation was delegated to or from.
+	DelegatedTo   map[string]bool `json:"delegatedTo,omitempty"`
+	DelegatedFrom map[string]bool `json:"delegatedFrom,omitempty"`
+
+	// Keys of group participants this participant is a member of.
+	MemberOf map[string]bool `json:"memberOf,omitempty"`
+}
+
+// Trigger types used in Trigger.Type.
+const (
+	TriggerOffset   = "OffsetTrigger"
+	TriggerAbsolute = "AbsoluteTrigger"
+)
+
+// Trigger defines when the Alert is triggered, it is either OffsetTrigger
+// or AbsoluteTrigger depending on Type.
+type Trigger struct {
+	// One of Trigger* constants.
+	Type string `json:"@type"`
+
+	// The offset from the start ("start" RelativeTo, the default) or end
+	// ("end") of the event, for OffsetTrigger.
+	Offset     SignedDuration `json:"offset,omitempty"`
+	RelativeTo string         `json:"relativeTo,omitempty"`
+
+	// The time of the alert, for AbsoluteTrigger.
+	When *jmap.UTCDate `json:"when,omitempty"`
+}
+
+// Alert is the reminder for the Event.
+type Alert struct {
+	// When the alert is triggered.
+	Trigger Trigger `json:"trigger"`
+
+	// The time the alert was dismissed by the user, if it was.
+	Acknowledged *jmap.UTCDate `json:"acknowledged,omitempty"`
+
+	// How the alert is presented: "display" (the default) or "email".
+	Action string `json:"action,omitempty"`
+}
+
+// Values of RecurrenceRule.Frequency.
+const (
+	FrequencyYearly   = "yearly"
+	FrequencyMonthly  = "monthly"
+	FrequencyWeekly   = "weekly"
+	FrequencyDaily    = "daily"
+	FrequencyHourly   = "hourly"
+	FrequencyMinutely = "minutely"
+	FrequencySecondly = "secondly"
+)
+
+// NDay is the day of the week, optionally limited to the n-th occurrence
+// within the month or year.
+type NDay struct {
+	// The day of the week: "mo", "tu", "we", "th", "fr", "sa" or "su".
+	Day string `json:"day"`
+
+	// If not zero, the n-th occurrence of the day within the period.
+	// Negative values count from the end of the period.
+	NthOfPeriod int `json:"nthOfPeriod,omitempty"`
+}
+
+// RecurrenceRule defines a set of occurrences of the recurring event. It
+// follows semantics of iCalendar RRULE (RFC 5545, section 3.3.10).
+type RecurrenceRule struct {
+	// The time span covered by each iteration, one of Frequency*
+	// constants.
+	Frequency string `json:"frequency"`
+
+	// The interval of iteration periods at which the recurrence repeats.
+	// One if zero.
+	Interval jmap.UnsignedInt `json:"interval,omitempty"`
+
+	// The calendar system in which the recurrence is expanded, "gregorian"
+	// if empty.
+	RScale string `json:"rscale,omitempty"`
+
+	// Behavior for invalid dates produced by RScale: "omit" (the default),
+	// "backward" or "forward".
+	Skip string `json:"skip,omitempty"`
+
+	// The day on which the week is considered to start, "mo" if empty.
+	FirstDayOfWeek string `json:"firstDayOfWeek,omitempty"`
+
+	// The BY* parts of the rule, see RFC 5545.
+	ByDay         []NDay   `json:"byDay,omitempty"`
+	ByMonthDay    []int    `json:"byMonthDay,omitempty"`
+	ByMonth       []string `json:"byMonth,omitempty"`
+	ByYearDay     []int    `json:"byYearDay,omitempty"`
+	ByWeekNo      []int    `json:"byWeekNo,omitempty"`
+	ByHour        []int    `json:"byHour,omitempty"`
+	ByMinute      []int    `json:"byMinute,omitempty"`
+	BySecond      []int    `json:"bySecond,omitempty"`
+	BySetPosition []int    `json:"bySetPosition,omitempty"`
+
+	// The number of occurrences at which to range-bound the recurrence.
+	// Zero means unbounded, unless Until is set.
+	Count jmap.UnsignedInt `json:"count,omitempty"`
+
+	// The date-time at which to finish recurring, in the time zone of the
+	// event. The last occurrence is on or before this date-time.
+	Until LocalDateTime `json:"until,omitempty"`
+}
@@ -0,0 +1,314 @@
+package calendar
+
+import (
+	"encoding/json"
+
+	"github.com/foxcpp/go-jmap"
+)
+
+// CalendarEventGetArgs contains arguments for CalendarEvent/get method call.
+type CalendarEventGetArgs struct {
+	// The id of the account to use.
+	AccountID jmap.ID `json:"accountId"`
+
+	// The ids of the CalendarEvent objects to return. If nil, then all
+	// records are returned. Ids of occurrences returned by CalendarEvent/query
+	// with ExpandRecurrences can be used too.
+	IDs []jmap.ID `json:"ids"`
+
+	// If not nil, only the properties listed in the array are returned for
+	// each CalendarEvent object.
+	Properties []string `json:"properties"`
+
+	// If set, recurrenceOverrides with recurrence ids before or after
+	// (inclusive) the given time are not returned.
+	RecurrenceOverridesBefore *jmap.UTCDate `json:"recurrenceOverridesBefore,omitempty"`
+	RecurrenceOverridesAfter  *jmap.UTCDate `json:"recurrenceOverridesAfter,omitempty"`
+
+	// If true, only participants with the owner role or corresponding to
+	// the user are returned.
+	ReduceParticipants bool `json:"reduceParticipants,omitempty"`
+
+	// The time zone to use when calculating utcStart and utcEnd of floating
+	// events. If empty, the time zone of the Calendar is used.
+	TimeZone string `json:"timeZone,omitempty"`
+}
+
+// CalendarEventGetResponse contains results of CalendarEvent/get method
+// call.
+type CalendarEventGetResponse struct {
+	// The id of the account used for the call.
+	AccountID jmap.ID `json:"accountId"`
+
+	// A string representing the state on the server for all the data of this
+	// type in the account.
+	State string `json:"state"`
+
+	// An array of the CalendarEvent objects requested.
+	List []Event `json:"list"`
+
+	// This array contains the ids passed to the method for records that do
+	// not exist.
+	NotFound []jmap.ID `json:"notFound"`
+}
+
+// CalendarEventChangesArgs contains arguments for CalendarEvent/changes
+// method call.
+type CalendarEventChangesArgs struct {
+	// The id of the account to use.
+	AccountID jmap.ID `json:"accountId"`
+
+	// The current state of the client, as returned by CalendarEvent/get.
+	SinceState string `json:"sinceState"`
+
+	// The maximum number of ids to return in the response. Zero means no
+	// limit.
+	MaxChanges jmap.UnsignedInt `json:"maxChanges,omitempty"`
+}
+
+// CalendarEventChangesResponse contains results of CalendarEvent/changes
+// method call.
+type CalendarEventChangesResponse struct {
+	// The id of the account used for the call.
+	AccountID jmap.ID `json:"accountId"`
+
+	// This is the sinceState argument echoed back.
+	OldState string `json:"oldState"`
+
+	// This is the state the client will be in after applying the set of
+	// changes to the old state.
+	NewState string `json:"newState"`
+
+	// If true, the client may call CalendarEvent/changes again with the
+	// NewState returned to get further updates.
+	HasMoreChanges bool `json:"hasMoreChanges"`
+
+	// An array of ids for records that have been created since the old
+	// state.
+	Created []jmap.ID `json:"created"`
+
+	// An array of ids for records that have been updated since the old
+	// state.
+	Updated []jmap.ID `json:"updated"`
+
+	// An array of ids for records that have been destroyed since the old
+	// state.
+	Destroyed []jmap.ID `json:"destroyed"`
+}
+
+// CalendarEventFilterCondition is the filter condition object for
+// CalendarEvent/query. Condition matches CalendarEvent only if all
+// non-empty fields match. Text fields match if the corresponding property
+// contains the given string.
+//
+// Conditions can be combined using jmap.FilterOperator.
+//
+// See draft-ietf-jmap-calendars, section 5.9 for details.
+type CalendarEventFilterCondition struct {
+	// The Calendar id the CalendarEvent must be in.
+	InCalendar jmap.ID `json:"inCalendar,omitempty"`
+
+	// The end of the event (or any of its occurrences) must be after this
+	// date-time.
+	After *jmap.UTCDate `json:"after,omitempty"`
+
+	// The start of the event (or any of its occurrences) must be before
+	// this date-time.
+	Before *jmap.UTCDate `json:"before,omitempty"`
+
+	// Looks for the text in title, description, locations, owner and
+	// attendees of the event.
+	Text string `json:"text,omitempty"`
+
+	// Looks for the text in the corresponding properties of the event.
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	Location    string `json:"location,omitempty"`
+	Owner       string `json:"owner,omitempty"`
+	Attendee    string `json:"attendee,omitempty"`
+
+	// The uid property of the event must be equal to the given value.
+	UID string `json:"uid,omitempty"`
+}
+
+// Properties that can be used in CalendarEventComparator.
+const (
+	CalendarEventSortStart        = "start"
+	CalendarEventSortUID          = "uid"
+	CalendarEventSortRecurrenceID = "recurrenceId"
+	CalendarEventSortCreated      = "created"
+	CalendarEventSortUpdated      = "updated"
+)
+
+// CalendarEventComparator is the sort comparator for CalendarEvent/query.
+// Property must be one of CalendarEventSort* constants.
+type CalendarEventComparator struct {
+	jmap.Comparator
+}
+
+// CalendarEventQueryArgs contains arguments for CalendarEvent/query method
+// call.
+type CalendarEventQueryArgs struct {
+	// The id of the account to use.
+	AccountID jmap.ID `json:"accountId"`
+
+	// Determines the set of CalendarEvents returned in the results. Must be
+	// either CalendarEventFilterCondition or jmap.FilterOperator. If nil, no
+	// filtering is performed.
+	Filter interface{} `json:"filter,omitempty"`
+
+	// Lists the names of properties to compare between two CalendarEvent
+	// records, and how to compare them, to determine which comes first in
+	// the sort.
+	Sort []CalendarEventComparator `json:"sort,omitempty"`
+
+	// The zero-based index of the first id in the full list of results to
+	// return. Negative values are offsets from the end of the list.
+	Position jmap.Int `json:"position,omitempty"`
+
+	// A CalendarEvent id. If supplied, the position argument is ignored.
+	Anchor jmap.ID `json:"anchor,omitempty"`
+
+	// The index of the first result to return relative to the index of the
+	// anchor, if an anchor is given.
+	AnchorOffset jmap.Int `json:"anchorOffset,omitempty"`
+
+	// The maximum number of results to return. If zero, no limit is
+	// presumed.
+	Limit jmap.UnsignedInt `json:"limit,omitempty"`
+
+	// Does the client wish to know the total number of results in the
+	// query?
+	CalculateTotal bool `json:"calculateTotal,omitempty"`
+
+	// If true, ids of individual occurrences of recurring events within the
+	// After and Before range of the filter are returned instead of the base
+	// events. The filter must have both After and Before set.
+	ExpandRecurrences bool `json:"expandRecurrences,omitempty"`
+
+	// The time zone to use for floating events when evaluating the filter.
+	// If empty, the time zone of the Calendar is used.
+	TimeZone string `json:"timeZone,omitempty"`
+}
+
+// CalendarEventQueryResponse contains results of CalendarEvent/query method
+// call.
+type CalendarEventQueryResponse struct {
+	// The id of the account used for the call.
+	AccountID jmap.ID `json:"accountId"`
+
+	// A string encoding the current state of the query on the server.
+	QueryState string `json:"queryState"`
+
+	// This is true if the server supports calling
+	// CalendarEvent/queryChanges with these filter/sort parameters.
+	CanCalculateChanges bool `json:"canCalculateChanges"`
+
+	// The zero-based index of the first result in the ids array within the
+	// complete list of query results.
+	Position jmap.UnsignedInt `json:"position"`
+
+	// The list of ids for each CalendarEvent in the query results, starting
+	// at the index given by the position argument of this response and
+	// continuing until it hits the end of the results or reaches the limit
+	// number of ids.
+	IDs []jmap.ID `json:"ids"`
+
+	// The total number of CalendarEvents in the results (given the filter).
+	// Only set if CalculateTotal was requested.
+	Total jmap.UnsignedInt `json:"total,omitempty"`
+
+	// The limit enforced by the server on the maximum number of results to
+	// return. Only set if the server set a limit or used a different limit
+	// than that given in the request.
+	Limit jmap.UnsignedInt `json:"limit,omitempty"`
+}
+
+// CalendarEventSetArgs contains arguments for CalendarEvent/set method
+// call.
+type CalendarEventSetArgs struct {
+	// The id of the account to use.
+	AccountID jmap.ID `json:"accountId"`
+
+	// If not empty, the method will be aborted and a stateMismatch error
+	// returned if the current state does not match this string.
+	IfInState string `json:"ifInState,omitempty"`
+
+	// A map of creation id to CalendarEvent objects.
+	Create map[jmap.ID]Event `json:"create,omitempty"`
+
+	// A map of id to a patch object to apply to the current CalendarEvent
+	// object with that id.
+	Update map[jmap.ID]jmap.PatchObject `json:"update,omitempty"`
+
+	// A list of ids for CalendarEvent objects to permanently delete.
+	Destroy []jmap.ID `json:"destroy,omitempty"`
+
+	// If true, the server sends scheduling messages to participants of the
+	// changed events, if needed.
+	SendSchedulingMessages bool `json:"sendSchedulingMessages,omitempty"`
+}
+
+// CalendarEventSetResponse contains results of CalendarEvent/set method
+// call.
+type CalendarEventSetResponse struct {
+	// The id of the account used for the call.
+	AccountID jmap.ID `json:"accountId"`
+
+	// The state string that would have been returned by CalendarEvent/get
+	// before making the requested changes.
+	OldState string `json:"oldState"`
+
+	// The state string that will now be returned by CalendarEvent/get.
+	NewState string `json:"newState"`
+
+	// A map of the creation id to an object containing any properties of the
+	// created CalendarEvent object that were not sent by the client.
+	Created map[jmap.ID]Event `json:"created"`
+
+	// The keys in this map are the ids of all CalendarEvents that were
+	// successfully updated. The value is a CalendarEvent object containing
+	// any property that changed in a way not explicitly requested, or nil if
+	// none.
+	Updated map[jmap.ID]*Event `json:"updated"`
+
+	// A list of CalendarEvent ids for records that were successfully
+	// destroyed.
+	Destroyed []jmap.ID `json:"destroyed"`
+
+	// A map of creation id to a SetError object for each record that failed
+	// to be created.
+	NotCreated map[jmap.ID]jmap.SetError `json:"notCreated"`
+
+	// A map of CalendarEvent id to a SetError object for each record that
+	// failed to be updated.
+	NotUpdated map[jmap.ID]jmap.SetError `json:"notUpdated"`
+
+	// A map of CalendarEvent id to a SetError object for each record that
+	// failed to be destroyed.
+	NotDestroyed map[jmap.ID]jmap.SetError `json:"notDestroyed"`
+}
+
+func unmarshalCalendarEventGetResponse(args json.RawMessage) (interface{}, error) {
+	resp := CalendarEventGetResponse{}
+	err := json.Unmarshal(args, &resp)
+	return resp, err
+}
+
+func unmarshalCalendarEventChangesResponse(args json.RawMessage) (interface{}, error) {
+	resp := CalendarEventChangesResponse{}
+	err := json.Unmarshal(args, &resp)
+	return resp, err
+}
+
+func unmarshalCalendarEventQueryResponse(args json.RawMessage) (interface{}, error) {
+	resp := CalendarEventQueryResponse{}
+	err := json.Unmarshal(args, &resp)
+	return resp, err
+}
+
+func unmarshalCalendarEventSetResponse(args json.RawMessage) (interface{}, error) {
+	resp := CalendarEventSetResponse{}
+	err := json.Unmarshal(args, &resp)
+	return resp, err
+}
@@ -0,0 +1,651 @@
+package calendar
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/foxcpp/go-jmap"
+)
+
+// ErrExpansionLimit is returned by Event.Expand if a recurrence rule does
+// not produce the requested occurrences within a reasonable number of
+// iterations.
+var ErrExpansionLimit = errors.New("jmap/calendar: recurrence expansion limit exceeded")
+
+// maxExpansionPeriods is the maximum number of periods (e.g. months for the
+// monthly rule) iterated for a single rule during expansion.
+const maxExpansionPeriods = 100000
+
+// Occurrence is a single occurrence of the Event, see Event.Expand.
+type Occurrence struct {
+	// The recurrence id of the occurrence, the start before applying the
+	// recurrence override. Empty for non-recurring events.
+	RecurrenceID LocalDateTime
+
+	// The start and the end of the occurrence.
+	Start time.Time
+	End   time.Time
+
+	// The occurrence with the recurrence override applied and recurrence
+	// properties removed. Maps and slices are shared with the original
+	// Event unless replaced by the override.
+	Event Event
+}
+
+// Expand returns occurrences of the event that overlap with the [after,
+// before) time range, sorted by start.
+//
+// Occurrences are generated by RecurrenceRules, with the event start always
+// being the first one. Occurrences generated by ExcludedRecurrenceRules are
+// removed. RecurrenceOverrides are applied: they can exclude an occurrence,
+// add one that is not generated by rules or modify any of its properties,
+// including the start.
+//
+// floating is the location used for events without TimeZone. If it is nil,
+// UTC is used.
+//
+// Only the gregorian calendar is supported as RecurrenceRule.RScale.
+func (e *Event) Expand(after, before time.Time, floating *time.Location) ([]Occurrence, error) {
+	loc, err := eventLocation(e.TimeZone, floating)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(e.RecurrenceRules) == 0 && len(e.RecurrenceOverrides) == 0 {
+		occ, err := makeOccurrence(*e, "", loc, floating)
+		if err != nil {
+			return nil, err
+		}
+		if !occ.overlaps(after, before) {
+			return nil, nil
+		}
+		return []Occurrence{occ}, nil
+	}
+
+	start, err := e.Start.In(nil)
+	if err != nil {
+		return nil, fmt.Errorf("jmap/calendar: malformed start: %v", err)
+	}
+	days, exact, err := parseDuration(string(e.Duration))
+	if err != nil {
+		return nil, err
+	}
+
+	// Rules are expanded in floating time (wall clock time represented in
+	// UTC) and occurrences are resolved into the event location afterwards.
+	// Bounds are extended to account for the event duration and time zone
+	// offsets.
+	margin := 48 * time.Hour
+	lower := floatingTime(after, loc).AddDate(0, 0, -days).Add(-exact - margin)
+	upper := floatingTime(before, loc).Add(margin)
+
+	overrides := make(map[time.Time]jmap.PatchObject, len(e.RecurrenceOverrides))
+	for rid, patch := range e.RecurrenceOverrides {
+		t, err := rid.In(nil)
+		if err != nil {
+			return nil, fmt.Errorf("jmap/calendar: malformed recurrence id: %v", err)
+		}
+		overrides[t] = patch
+		// Overrides can move occurrences into the range so they need to be
+		// checked even if outside of it.
+		if t.Before(lower) {
+			lower = t
+		}
+		if !t.Before(upper) {
+			upper = t.Add(time.Second)
+		}
+	}
+
+	set := map[time.Time]bool{start: true}
+	for _, rule := range e.RecurrenceRules {
+		ts, err := expandRule(rule, start, lower, upper)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range ts {
+			set[t] = true
+		}
+	}
+	for _, rule := range e.ExcludedRecurrenceRules {
+		ts, err := expandRule(rule, start, lower, upper)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range ts {
+			delete(set, t)
+		}
+	}
+	for t, patch := range overrides {
+		if excluded, _ := patch["excluded"].(bool); excluded {
+			delete(set, t)
+			continue
+		}
+		set[t] = true
+	}
+
+	var res []Occurrence
+	for t := range set {
+		if _, ok := overrides[t]; !ok && (t.Before(lower) || !t.Before(upper)) {
+			continue
+		}
+
+		rid := NewLocalDateTime(t)
+		ev := *e
+		ev.RecurrenceRules = nil
+		ev.ExcludedRecurrenceRules = nil
+		ev.RecurrenceOverrides = nil
+		ev.RecurrenceID = rid
+		ev.RecurrenceIDTimeZone = e.TimeZone
+		ev.Start = rid
+		if patch, ok := overrides[t]; ok {
+			if err := jmap.ApplyPatch(&ev, patch); err != nil {
+				return nil, fmt.Errorf("jmap/calendar: recurrence override %s: %v", rid, err)
+			}
+		}
+
+		occ, err := makeOccurrence(ev, rid, loc, floating)
+		if err != nil {
+			return nil, err
+		}
+		if occ.overlaps(after, before) {
+			res = append(res, occ)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if !res[i].Start.Equal(res[j].Start) {
+			return res[i].Start.Before(res[j].Start)
+		}
+		return res[i].RecurrenceID < res[j].RecurrenceID
+	})
+	return res, nil
+}
+
+func eventLocation(tz string, floating *time.Location) (*time.Location, error) {
+	if tz == "" {
+		if floating == nil {
+			return time.UTC, nil
+		}
+		return floating, nil
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return nil, fmt.Errorf("jmap/calendar: unknown time zone %s: %v", tz, err)
+	}
+	return loc, nil
+}
+
+// floatingTime returns the wall clock time of t in loc, represented in UTC.
+func floatingTime(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+}
+
+func makeOccurrence(ev Event, rid LocalDateTime, loc, floating *time.Location) (Occurrence, error) {
+	if rid != "" && ev.TimeZone != ev.RecurrenceIDTimeZone {
+		// Time zone changed by the recurrence override.
+		var err error
+		loc, err = eventLocation(ev.TimeZone, floating)
+		if err != nil {
+			return Occurrence{}, err
+		}
+	}
+	start, err := ev.Start.In(loc)
+	if err != nil {
+		return Occurrence{}, fmt.Errorf("jmap/calendar: malformed start: %v", err)
+	}
+	end, err := ev.Duration.AddTo(start)
+	if err != nil {
+		return Occurrence{}, err
+	}
+	return Occurrence{
+		RecurrenceID: rid,
+		Start:        start,
+		End:          end,
+		Event:        ev,
+	}, nil
+}
+
+func (o Occurrence) overlaps(after, before time.Time) bool {
+	if !o.Start.Before(before) {
+		return false
+	}
+	if o.End.Equal(o.Start) {
+		return !o.Start.Before(after)
+	}
+	return o.End.After(after)
+}
+
+var weekdays = map[string]time.Weekday{
+	"mo": time.Monday,
+	"tu": time.Tuesday,
+	"we": time.Wednesday,
+	"th": time.Thursday,
+	"fr": time.Friday,
+	"sa": time.Saturday,
+	"su": time.Sunday,
+}
+
+type byDay struct {
+	day time.Weekday
+	nth int
+}
+
+// ruleIter contains the normalized RecurrenceRule.
+type ruleIter struct {
+	freq     string
+	interval int
+	start    time.Time
+	until    time.Time
+	count    int
+	wkst     time.Weekday
+
+	byMonth    []int
+	byWeekNo   []int
+	byYearDay  []int
+	byMonthDay []int
+	byDay      []byDay
+	byHour     []int
+	byMinute   []int
+	bySecond   []int
+	bySetPos   []int
+}
+
+func newRuleIter(rule RecurrenceRule, start time.Time) (*ruleIter, error) {
+	if rule.RScale != "" && rule.RScale != "gregorian" {
+		return nil, fmt.Errorf("jmap/calendar: unsupported recurrence rscale: %s", rule.RScale)
+	}
+
+	it := &ruleIter{
+		freq:       rule.Frequency,
+		interval:   int(rule.Interval),
+		start:      start,
+		count:      int(rule.Count),
+		wkst:       time.Monday,
+		byWeekNo:   rule.ByWeekNo,
+		byYearDay:  rule.ByYearDay,
+		byMonthDay: rule.ByMonthDay,
+		byHour:     sortedInts(rule.ByHour),
+		byMinute:   sortedInts(rule.ByMinute),
+		bySecond:   sortedInts(rule.BySecond),
+		bySetPos:   rule.BySetPosition,
+	}
+	if it.interval == 0 {
+		it.interval = 1
+	}
+	switch it.freq {
+	case FrequencyYearly, FrequencyMonthly, FrequencyWeekly, FrequencyDaily,
+		FrequencyHourly, FrequencyMinutely, FrequencySecondly:
+	default:
+		return nil, fmt.Errorf("jmap/calendar: unknown recurrence frequency: %s", it.freq)
+	}
+	if rule.FirstDayOfWeek != "" {
+		wkst, ok := weekdays[rule.FirstDayOfWeek]
+		if !ok {
+			return nil, fmt.Errorf("jmap/calendar: unknown day of week: %s", rule.FirstDayOfWeek)
+		}
+		it.wkst = wkst
+	}
+	if rule.Until != "" {
+		until, err := rule.Until.In(nil)
+		if err != nil {
+			return nil, fmt.Errorf("jmap/calendar: malformed recurrence until: %v", err)
+		}
+		it.until = until
+	}
+	for _, m := range rule.ByMonth {
+		month, err := strconv.Atoi(m)
+		if err != nil || month < 1 || month > 12 {
+			return nil, fmt.Errorf("jmap/calendar: unsupported recurrence month: %s", m)
+		}
+		it.byMonth = append(it.byMonth, month)
+	}
+	for _, nd := range rule.ByDay {
+		day, ok := weekdays[nd.Day]
+		if !ok {
+			return nil, fmt.Errorf("jmap/calendar: unknown day of week: %s", nd.Day)
+		}
+		it.byDay = append(it.byDay, byDay{day: day, nth: nd.NthOfPeriod})
+	}
+
+	// Implicit rule parts derived from the start, see RFC 5545, section
+	// 3.3.10.
+	switch it.freq {
+	case FrequencyYearly:
+		if len(it.byWeekNo) == 0 && len(it.byYearDay) == 0 && len(it.byMonthDay) == 0 && len(it.byDay) == 0 {
+			it.byMonthDay = []int{start.Day()}
+			if len(it.byMonth) == 0 {
+				it.byMonth = []int{int(start.Month())}
+			}
+		} else if len(it.byWeekNo) != 0 && len(it.byYearDay) == 0 && len(it.byMonthDay) == 0 && len(it.byDay) == 0 {
+			it.byDay = []byDay{{day: start.Weekday()}}
+		}
+	case FrequencyMonthly:
+		if len(it.byYearDay) == 0 && len(it.byMonthDay) == 0 && len(it.byDay) == 0 {
+			it.byMonthDay = []int{start.Day()}
+		}
+	case FrequencyWeekly:
+		if len(it.byDay) == 0 {
+			it.byDay = []byDay{{day: start.Weekday()}}
+		}
+	}
+	return it, nil
+}
+
+func sortedInts(s []int) []int {
+	if len(s) == 0 {
+		return nil
+	}
+	res := append([]int(nil), s...)
+	sort.Ints(res)
+	return res
+}
+
+// expandRule returns floating times generated by the rule within the
+// [lower, upper) range, not before start.
+func expandRule(rule RecurrenceRule, start, lower, upper time.Time) ([]time.Time, error) {
+	it, err := newRuleIter(rule, start)
+	if err != nil {
+		return nil, err
+	}
+
+	first := 0
+	if it.count == 0 && lower.After(start) {
+		first = it.periodBefore(lower)
+	}
+
+	var (
+		res   []time.Time
+		count = 1 // The start is always the first occurrence.
+	)
+	for k := first; ; k++ {
+		if k-first > maxExpansionPeriods {
+			return nil, ErrExpansionLimit
+		}
+		periodStart, cands := it.period(k)
+		if !periodStart.Before(upper) {
+			return res, nil
+		}
+		if !it.until.IsZero() && periodStart.After(it.until) {
+			return res, nil
+		}
+
+		for _, t := range it.setPos(cands) {
+			if t.Before(start) {
+				continue
+			}
+			if !it.until.IsZero() && t.After(it.until) {
+				return res, nil
+			}
+			if !t.Equal(start) {
+				count++
+				if it.count != 0 && count > it.count {
+					return res, nil
+				}
+			}
+			if !t.Before(lower) && t.Before(upper) {
+				res = append(res, t)
+			}
+		}
+	}
+}
+
+// periodBefore returns the index of a period that starts before t. It is
+// used to skip periods that can not contain the requested occurrences.
+func (it *ruleIter) periodBefore(t time.Time) int {
+	var units int
+	switch it.freq {
+	case FrequencyYearly:
+		units = t.Year() - it.start.Year()
+	case FrequencyMonthly:
+		units = (t.Year()-it.start.Year())*12 + int(t.Month()) - int(it.start.Month())
+	case FrequencyWeekly:
+		units = int(t.Sub(it.start)/(24*time.Hour)) / 7
+	case FrequencyDaily:
+		units = int(t.Sub(it.start) / (24 * time.Hour))
+	case FrequencyHourly:
+		units = int(t.Sub(it.start) / time.Hour)
+	case FrequencyMinutely:
+		units = int(t.Sub(it.start) / time.Minute)
+	case FrequencySecondly:
+		units = int(t.Sub(it.start) / time.Second)
+	}
+	k := units/it.interval - 1
+	if k < 0 {
+		return 0
+	}
+	return k
+}
+
+// period returns the start of the k-th period and sorted candidate times
+// within it.
+func (it *ruleIter) period(k int) (time.Time, []time.Time) {
+	s := it.start
+	step := k * it.interval
+
+	var (
+		periodStart time.Time
+		days        []time.Time
+	)
+	switch it.freq {
+	case FrequencyYearly:
+		periodStart = date(s.Year()+step, 1, 1)
+		for d := periodStart; d.Year() == periodStart.Year(); d = d.AddDate(0, 0, 1) {
+			days = append(days, d)
+		}
+	case FrequencyMonthly:
+		periodStart = date(s.Year(), s.Month()+time.Month(step), 1)
+		for d := periodStart; d.Month() == periodStart.Month(); d = d.AddDate(0, 0, 1) {
+			days = append(days, d)
+		}
+	case FrequencyWeekly:
+		offset := (int(s.Weekday()) - int(it.wkst) + 7) % 7
+		periodStart = date(s.Year(), s.Month(), s.Day()-offset+7*step)
+		for i := 0; i < 7; i++ {
+			days = append(days, periodStart.AddDate(0, 0, i))
+		}
+	case FrequencyDaily:
+		periodStart = date(s.Year(), s.Month(), s.Day()+step)
+		days = []time.Time{periodStart}
+	case FrequencyHourly:
+		periodStart = time.Date(s.Year(), s.Month(), s.Day(), s.Hour()+step, 0, 0, 0, time.UTC)
+	case FrequencyMinutely:
+		periodStart = time.Date(s.Year(), s.Month(), s.Day(), s.Hour(), s.Minute()+step, 0, 0, time.UTC)
+	case FrequencySecondly:
+		periodStart = time.Date(s.Year(), s.Month(), s.Day(), s.Hour(), s.Minute(), s.Second()+step, 0, time.UTC)
+	}
+
+	hours := it.byHour
+	minutes := it.byMinute
+	seconds := it.bySecond
+	switch it.freq {
+	case FrequencyHourly, FrequencyMinutely, FrequencySecondly:
+		days = []time.Time{date(periodStart.Year(), periodStart.Month(), periodStart.Day())}
+		hours = limit(periodStart.Hour(), hours)
+		if it.freq == FrequencyHourly {
+			minutes = orDefault(minutes, s.Minute())
+		} else {
+			minutes = limit(periodStart.Minute(), minutes)
+		}
+		if it.freq == FrequencySecondly {
+			seconds = limit(periodStart.Second(), seconds)
+		} else {
+			seconds = orDefault(seconds, s.Second())
+		}
+	default:
+		hours = orDefault(hours, s.Hour())
+		minutes = orDefault(minutes, s.Minute())
+		seconds = orDefault(seconds, s.Second())
+	}
+
+	var cands []time.Time
+	for _, d := range days {
+		if !it.matchDay(d) {
+			continue
+		}
+		for _, h := range hours {
+			for _, m := range minutes {
+				for _, sec := range seconds {
+					cands = append(cands, time.Date(d.Year(), d.Month(), d.Day(), h, m, sec, 0, time.UTC))
+				}
+			}
+		}
+	}
+	return periodStart, cands
+}
+
+func limit(v int, allowed []int) []int {
+	if len(allowed) == 0 {
+		return []int{v}
+	}
+	for _, a := range allowed {
+		if a == v {
+			return []int{v}
+		}
+	}
+	return nil
+}
+
+func orDefault(s []int, def int) []int {
+	if len(s) == 0 {
+		return []int{def}
+	}
+	return s
+}
+
+func (it *ruleIter) matchDay(d time.Time) bool {
+	if len(it.byMonth) != 0 && !containsInt(it.byMonth, int(d.Month())) {
+		return false
+	}
+
+	if len(it.byWeekNo) != 0 {
+		week, weeks := weekNo(d, it.wkst)
+		if !containsInt(it.byWeekNo, week) && !containsInt(it.byWeekNo, week-weeks-1) {
+			return false
+		}
+	}
+
+	if len(it.byYearDay) != 0 {
+		yd := d.YearDay()
+		days := date(d.Year()+1, 1, 1).Sub(date(d.Year(), 1, 1)) / (24 * time.Hour)
+		if !containsInt(it.byYearDay, yd) && !containsInt(it.byYearDay, yd-int(days)-1) {
+			return false
+		}
+	}
+
+	dim := date(d.Year(), d.Month()+1, 0).Day()
+	if len(it.byMonthDay) != 0 {
+		md := d.Day()
+		if !containsInt(it.byMonthDay, md) && !containsInt(it.byMonthDay, md-dim-1) {
+			return false
+		}
+	}
+
+	if len(it.byDay) != 0 {
+		// The n-th occurrence is counted within the month for monthly rules
+		// and yearly rules with byMonth, within the year for other yearly
+		// rules. It is not meaningful for other frequencies.
+		withinMonth := it.freq == FrequencyMonthly || it.freq == FrequencyYearly && len(it.byMonth) != 0
+		withinYear := it.freq == FrequencyYearly && len(it.byMonth) == 0 && len(it.byWeekNo) == 0
+
+		matched := false
+		for _, bd := range it.byDay {
+			if bd.day != d.Weekday() {
+				continue
+			}
+			if bd.nth == 0 || !withinMonth && !withinYear {
+				matched = true
+				break
+			}
+
+			var pos, total int
+			if withinMonth {
+				pos, total = d.Day(), dim
+			} else {
+				pos = d.YearDay()
+				total = int(date(d.Year()+1, 1, 1).Sub(date(d.Year(), 1, 1)) / (24 * time.Hour))
+			}
+			if bd.nth > 0 && (pos-1)/7+1 == bd.nth || bd.nth < 0 && -((total-pos)/7+1) == bd.nth {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+func date(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// weekNo returns the week number of d as defined in RFC 5545, section
+// 3.3.10, along with the number of weeks in the week-numbering year d belongs
+// to. Days at the start or the end of the year may belong to the last week
+// of the previous year or the first week of the next one.
+func weekNo(d time.Time, wkst time.Weekday) (int, int) {
+	week1 := func(year int) time.Time {
+		jan1 := date(year, 1, 1)
+		offset := (int(jan1.Weekday()) - int(wkst) + 7) % 7
+		start := jan1.AddDate(0, 0, -offset)
+		if offset > 3 {
+			// The first week of the year must contain at least 4 days of
+			// it.
+			start = start.AddDate(0, 0, 7)
+		}
+		return start
+	}
+	weeksBetween := func(from, to time.Time) int {
+		return int(to.Sub(from)/(24*time.Hour)) / 7
+	}
+
+	cur, next := week1(d.Year()), week1(d.Year()+1)
+	switch {
+	case d.Before(cur):
+		prev := week1(d.Year() - 1)
+		weeks := weeksBetween(prev, cur)
+		return weeks, weeks
+	case !d.Before(next):
+		return 1, weeksBetween(next, week1(d.Year()+2))
+	}
+	return weeksBetween(cur, d) + 1, weeksBetween(cur, next)
+}
+
+func containsInt(s []int, v int) bool {
+	for _, i := range s {
+		if i == v {
+			return true
+		}
+	}
+	return false
+}
+
+// setPos applies byPosition to candidates of a single period.
+func (it *ruleIter) setPos(cands []time.Time) []time.Time {
+	if len(it.bySetPos) == 0 {
+		return cands
+	}
+	var res []time.Time
+	for _, pos := range it.bySetPos {
+		i := pos - 1
+		if pos < 0 {
+			i = len(cands) + pos
+		}
+		if i >= 0 && i < len(cands) {
+			res = append(res, cands[i])
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Before(res[j]) })
+
+	uniq := res[:0]
+	for i, t := range res {
+		if i == 0 || !t.Equal(res[i-1]) {
+			uniq = append(uniq, t)
+		}
+	}
+	return uniq
+}
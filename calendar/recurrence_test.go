@@ -0,0 +1,212 @@
+package calendar
+
+import (
+	"testing"
+	"time"
+
+	"github.com/foxcpp/go-jmap"
+	"gotest.tools/assert"
+	"gotest.tools/assert/cmp"
+)
+
+func TestDurationAddTo(t *testing.T) {
+	base := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, c := range []struct {
+		d   Duration
+		exp time.Time
+	}{
+		{"", base},
+		{"P1W", base.AddDate(0, 0, 7)},
+		{"P1DT12H", base.Add(36 * time.Hour)},
+		{"PT1H30M", base.Add(90 * time.Minute)},
+		{"PT0.5S", base.Add(500 * time.Millisecond)},
+	} {
+		res, err := c.d.AddTo(base)
+		assert.NilError(t, err, c.d)
+		assert.Check(t, cmp.Equal(c.exp, res), c.d)
+	}
+
+	res, err := SignedDuration("-PT15M").AddTo(base)
+	assert.NilError(t, err)
+	assert.Check(t, cmp.Equal(base.Add(-15*time.Minute), res))
+
+	for _, d := range []Duration{"P", "PT", "1H", "P1H", "PT1D", "P1DT", "PTH"} {
+		_, err := d.AddTo(base)
+		assert.Check(t, err != nil, d)
+	}
+}
+
+func expandStarts(t *testing.T, ev Event, after, before time.Time) []string {
+	t.Helper()
+	occs, err := ev.Expand(after, before, nil)
+	assert.NilError(t, err)
+	res := make([]string, 0, len(occs))
+	for _, occ := range occs {
+		res = append(res, occ.Start.UTC().Format(time.RFC3339))
+	}
+	return res
+}
+
+func TestEventExpand(t *testing.T) {
+	jan := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	year := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("not recurring", func(t *testing.T) {
+		ev := Event{Start: "2020-01-15T10:00:00", Duration: "PT1H"}
+		assert.Check(t, cmp.DeepEqual([]string{"2020-01-15T10:00:00Z"}, expandStarts(t, ev, jan, year)))
+		// Ends exactly when the range starts.
+		assert.Check(t, cmp.Len(expandStarts(t, ev, time.Date(2020, 1, 15, 11, 0, 0, 0, time.UTC), year), 0))
+		// Started before the range and still going.
+		assert.Check(t, cmp.Len(expandStarts(t, ev, time.Date(2020, 1, 15, 10, 30, 0, 0, time.UTC), year), 1))
+	})
+	t.Run("monthly last friday", func(t *testing.T) {
+		ev := Event{
+			Start: "2020-01-31T12:00:00",
+			RecurrenceRules: []RecurrenceRule{{
+				Frequency: FrequencyMonthly,
+				ByDay:     []NDay{{Day: "fr", NthOfPeriod: -1}},
+				Count:     3,
+			}},
+		}
+		assert.Check(t, cmp.DeepEqual([]string{
+			"2020-01-31T12:00:00Z", "2020-02-28T12:00:00Z", "2020-03-27T12:00:00Z",
+		}, expandStarts(t, ev, jan, year)))
+	})
+	t.Run("last weekday of month", func(t *testing.T) {
+		ev := Event{
+			Start: "2020-01-31T12:00:00",
+			RecurrenceRules: []RecurrenceRule{{
+				Frequency:     FrequencyMonthly,
+				ByDay:         []NDay{{Day: "mo"}, {Day: "tu"}, {Day: "we"}, {Day: "th"}, {Day: "fr"}},
+				BySetPosition: []int{-1},
+				Count:         4,
+			}},
+		}
+		assert.Check(t, cmp.DeepEqual([]string{
+			"2020-01-31T12:00:00Z", "2020-02-28T12:00:00Z", "2020-03-31T12:00:00Z", "2020-04-30T12:00:00Z",
+		}, expandStarts(t, ev, jan, year)))
+	})
+	t.Run("yearly leap day", func(t *testing.T) {
+		ev := Event{
+			Start:           "2020-02-29T00:00:00",
+			ShowWithoutTime: true,
+			RecurrenceRules: []RecurrenceRule{{Frequency: FrequencyYearly}},
+		}
+		assert.Check(t, cmp.DeepEqual([]string{
+			"2020-02-29T00:00:00Z", "2024-02-29T00:00:00Z",
+		}, expandStarts(t, ev, jan, time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC))))
+	})
+	t.Run("first monday of week 1", func(t *testing.T) {
+		ev := Event{
+			Start: "2019-12-30T08:00:00",
+			RecurrenceRules: []RecurrenceRule{{
+				Frequency: FrequencyYearly,
+				ByWeekNo:  []int{1},
+				ByDay:     []NDay{{Day: "mo"}},
+				Count:     3,
+			}},
+		}
+		assert.Check(t, cmp.DeepEqual([]string{
+			"2019-12-30T08:00:00Z", "2021-01-04T08:00:00Z", "2022-01-03T08:00:00Z",
+		}, expandStarts(t, ev, time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC))))
+	})
+	t.Run("excluded rules", func(t *testing.T) {
+		ev := Event{
+			Start: "2020-01-02T07:00:00",
+			RecurrenceRules: []RecurrenceRule{{
+				Frequency: FrequencyDaily,
+				Until:     "2020-01-07T07:00:00",
+			}},
+			ExcludedRecurrenceRules: []RecurrenceRule{{
+				Frequency: FrequencyWeekly,
+				ByDay:     []NDay{{Day: "sa"}, {Day: "su"}},
+			}},
+		}
+		assert.Check(t, cmp.DeepEqual([]string{
+			"2020-01-02T07:00:00Z", "2020-01-03T07:00:00Z", "2020-01-06T07:00:00Z", "2020-01-07T07:00:00Z",
+		}, expandStarts(t, ev, jan, year)))
+	})
+	t.Run("hourly", func(t *testing.T) {
+		ev := Event{
+			Start: "2020-01-02T07:30:00",
+			RecurrenceRules: []RecurrenceRule{{
+				Frequency: FrequencyHourly,
+				Interval:  4,
+				ByHour:    []int{7, 11, 19},
+			}},
+		}
+		assert.Check(t, cmp.DeepEqual([]string{
+			"2020-01-02T07:30:00Z", "2020-01-02T11:30:00Z", "2020-01-02T19:30:00Z", "2020-01-03T07:30:00Z", "2020-01-03T11:30:00Z",
+		}, expandStarts(t, ev, jan, time.Date(2020, 1, 3, 12, 0, 0, 0, time.UTC))))
+	})
+	t.Run("unbounded far from start", func(t *testing.T) {
+		ev := Event{
+			Start:           "1990-01-01T07:00:00",
+			Duration:        "PT1H",
+			RecurrenceRules: []RecurrenceRule{{Frequency: FrequencyDaily}},
+		}
+		assert.Check(t, cmp.DeepEqual([]string{
+			"2020-01-01T07:00:00Z", "2020-01-02T07:00:00Z",
+		}, expandStarts(t, ev, time.Date(2020, 1, 1, 7, 30, 0, 0, time.UTC), time.Date(2020, 1, 3, 0, 0, 0, 0, time.UTC))))
+	})
+	t.Run("unknown frequency", func(t *testing.T) {
+		ev := Event{
+			Start:           "2020-01-01T07:00:00",
+			RecurrenceRules: []RecurrenceRule{{Frequency: "fortnightly"}},
+		}
+		_, err := ev.Expand(jan, year, nil)
+		assert.Check(t, err != nil)
+	})
+}
+
+func TestEventExpandOverrides(t *testing.T) {
+	loc, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("time zone database is not available:", err)
+	}
+
+	ev := Event{
+		Title:    "Standup",
+		TimeZone: "Europe/Berlin",
+		Start:    "2020-01-06T09:30:00",
+		Duration: "PT15M",
+		RecurrenceRules: []RecurrenceRule{{
+			Frequency: FrequencyWeekly,
+			ByDay:     []NDay{{Day: "mo"}, {Day: "we"}},
+			Count:     4,
+		}},
+		RecurrenceOverrides: map[LocalDateTime]jmap.PatchObject{
+			"2020-01-08T09:30:00": {"excluded": true},
+			"2020-01-13T09:30:00": {"start": "2020-01-13T10:00:00", "title": "Moved"},
+			"2020-01-20T14:00:00": {},
+		},
+	}
+	occs, err := ev.Expand(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2020, 2, 1, 0, 0, 0, 0, time.UTC), nil)
+	assert.NilError(t, err)
+	assert.Assert(t, cmp.Len(occs, 4))
+
+	assert.Check(t, cmp.Equal(LocalDateTime("2020-01-06T09:30:00"), occs[0].RecurrenceID))
+	assert.Check(t, occs[0].Start.Equal(time.Date(2020, 1, 6, 9, 30, 0, 0, loc)))
+	assert.Check(t, occs[0].End.Equal(time.Date(2020, 1, 6, 9, 45, 0, 0, loc)))
+	assert.Check(t, cmp.Equal("Standup", occs[0].Event.Title))
+	assert.Check(t, occs[0].Event.RecurrenceRules == nil)
+
+	assert.Check(t, cmp.Equal(LocalDateTime("2020-01-13T09:30:00"), occs[1].RecurrenceID))
+	assert.Check(t, occs[1].Start.Equal(time.Date(2020, 1, 13, 10, 0, 0, 0, loc)))
+	assert.Check(t, cmp.Equal("Moved", occs[1].Event.Title))
+
+	assert.Check(t, cmp.Equal(LocalDateTime("2020-01-15T09:30:00"), occs[2].RecurrenceID))
+	assert.Check(t, cmp.Equal(LocalDateTime("2020-01-20T14:00:00"), occs[3].RecurrenceID))
+
+	// Wall clock time is kept across daylight saving time changes.
+	ev = Event{
+		TimeZone:        "Europe/Berlin",
+		Start:           "2020-03-28T09:00:00",
+		RecurrenceRules: []RecurrenceRule{{Frequency: FrequencyDaily, Count: 2}},
+	}
+	occs, err = ev.Expand(time.Date(2020, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2020, 4, 1, 0, 0, 0, 0, time.UTC), nil)
+	assert.NilError(t, err)
+	assert.Assert(t, cmp.Len(occs, 2))
+	assert.Check(t, cmp.Equal(8, occs[0].Start.UTC().Hour()))
+	assert.Check(t, cmp.Equal(7, occs[1].Start.UTC().Hour()))
+}
@@ -0,0 +1,140 @@
+package calendar
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const localDateTimeLayout = "2006-01-02T15:04:05"
+
+// LocalDateTime is the JSCalendar date-time without a time zone, e.g.
+// "2020-01-15T13:00:00". The time zone is defined by the object it is used
+// in (e.g. Event.TimeZone).
+type LocalDateTime string
+
+// NewLocalDateTime returns the LocalDateTime with date and time of t in its
+// location.
+func NewLocalDateTime(t time.Time) LocalDateTime {
+	return LocalDateTime(t.Format(localDateTimeLayout))
+}
+
+// In returns the time the LocalDateTime represents in the location. If loc
+// is nil, it is treated as floating time and UTC is used.
+func (ldt LocalDateTime) In(loc *time.Location) (time.Time, error) {
+	if loc == nil {
+		loc = time.UTC
+	}
+	return time.ParseInLocation(localDateTimeLayout, string(ldt), loc)
+}
+
+// Duration is the JSCalendar duration in the ISO 8601 format, e.g. "PT1H"
+// or "P1DT12H".
+type Duration string
+
+// SignedDuration is the Duration that may be prefixed with "-" to represent
+// a negative one, e.g. "-PT15M".
+type SignedDuration string
+
+// AddTo returns t shifted by the duration.
+//
+// Days and weeks are nominal, they are added using t.AddDate so the wall
+// clock time is kept across daylight saving time changes in the location
+// of t. Hours, minutes and seconds are exact.
+func (d Duration) AddTo(t time.Time) (time.Time, error) {
+	days, exact, err := parseDuration(string(d))
+	if err != nil {
+		return time.Time{}, err
+	}
+	return t.AddDate(0, 0, days).Add(exact), nil
+}
+
+// AddTo returns t shifted by the duration, see Duration.AddTo.
+func (d SignedDuration) AddTo(t time.Time) (time.Time, error) {
+	s := string(d)
+	sign := 1
+	switch {
+	case strings.HasPrefix(s, "-"):
+		sign = -1
+		s = s[1:]
+	case strings.HasPrefix(s, "+"):
+		s = s[1:]
+	}
+	days, exact, err := parseDuration(s)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return t.AddDate(0, 0, sign*days).Add(time.Duration(sign) * exact), nil
+}
+
+// parseDuration parses the ISO 8601 duration into nominal days and the exact
+// time part. Empty string is the zero duration.
+func parseDuration(s string) (int, time.Duration, error) {
+	if s == "" {
+		return 0, 0, nil
+	}
+	if !strings.HasPrefix(s, "P") || len(s) == 1 {
+		return 0, 0, fmt.Errorf("jmap/calendar: malformed duration: %s", s)
+	}
+
+	var (
+		days     int
+		exact    time.Duration
+		inTime   bool
+		rest     = s[1:]
+		needPart = true
+	)
+	for rest != "" {
+		if rest[0] == 'T' {
+			if inTime {
+				return 0, 0, fmt.Errorf("jmap/calendar: malformed duration: %s", s)
+			}
+			inTime = true
+			needPart = true
+			rest = rest[1:]
+			continue
+		}
+
+		i := 0
+		for i < len(rest) && (rest[i] >= '0' && rest[i] <= '9' || rest[i] == '.') {
+			i++
+		}
+		if i == 0 || i == len(rest) {
+			return 0, 0, fmt.Errorf("jmap/calendar: malformed duration: %s", s)
+		}
+		num, unit := rest[:i], rest[i]
+		rest = rest[i+1:]
+		needPart = false
+
+		if inTime && unit == 'S' {
+			secs, err := strconv.ParseFloat(num, 64)
+			if err != nil {
+				return 0, 0, fmt.Errorf("jmap/calendar: malformed duration: %s", s)
+			}
+			exact += time.Duration(secs * float64(time.Second))
+			continue
+		}
+
+		n, err := strconv.Atoi(num)
+		if err != nil {
+			return 0, 0, fmt.Errorf("jmap/calendar: malformed duration: %s", s)
+		}
+		switch {
+		case !inTime && unit == 'W':
+			days += 7 * n
+		case !inTime && unit == 'D':
+			days += n
+		case inTime && unit == 'H':
+			exact += time.Duration(n) * time.Hour
+		case inTime && unit == 'M':
+			exact += time.Duration(n) * time.Minute
+		default:
+			return 0, 0, fmt.Errorf("jmap/calendar: malformed duration: %s", s)
+		}
+	}
+	if needPart {
+		return 0, 0, fmt.Errorf("jmap/calendar: malformed duration: %s", s)
+	}
+	return days, exact, nil
+}
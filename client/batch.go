@@ -1,51 +1,190 @@
 package client
 
 import (
+	"encoding/json"
+	"errors"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/foxcpp/go-jmap"
 )
 
+// Priority is a soft hint used to order calls in Batch and to decide which
+// calls can be dropped if the request does not fit into server limits.
+//
+// Calls with higher priority are placed earlier in the request.
+type Priority int
+
+const (
+	// PriorityLow should be used for expensive calls that are not strictly
+	// needed, e.g. prefetching of message bodies.
+	PriorityLow Priority = -10
+
+	// PriorityNormal is used for calls added using Batch.Add.
+	PriorityNormal Priority = 0
+
+	// PriorityHigh should be used for cheap calls that are needed first,
+	// e.g. state checks.
+	PriorityHigh Priority = 10
+)
+
+var ErrCannotSplit = errors.New("jmap/client: batch can't be split to fit request limits")
+
 // Batch structure is a helper that makes it easier to construct series of
 // method calls to invoke within one request.
+//
+// Zero value is an empty Batch ready to use.
 type Batch struct {
-	req *jmap.Request
+	req       *jmap.Request
+	prio      []Priority
+	deadlines []time.Time
+
+	// The number of calls ever added. Call IDs are derived from it so they
+	// stay unique after DropExpired removes calls.
+	added int
+}
+
+func (b *Batch) init() {
+	if b.req == nil {
+		b.req = &jmap.Request{}
+	}
 }
 
 // NextCallID returns call ID value suitable for use for next request object
 // added using Add.
 //
 // Note that the returned value is changed only by Add call, not by NextCallID itself.
-//		a := bt.NextCallID()
-//		b := bt.NextCallID()
-//		bt.Add(...)
-//		c := bt.NextCallID()
-//      // a == b, c != b, c != a
+//
+//	a := bt.NextCallID()
+//	b := bt.NextCallID()
+//	bt.Add(...)
+//	c := bt.NextCallID()
+//	// a == b, c != b, c != a
 func (b *Batch) NextCallID() string {
-	return strconv.Itoa(len(b.req.Calls))
+	b.init()
+	return strconv.Itoa(b.added)
 }
 
 // NthCallID returns call ID that is or will be used by the N-th call in the
-// request.
+// request, taking into account calls reordered by Shape and removed by
+// DropExpired.
 //
 // n is 1-based index, e.g. first request will have n = 1.
 func (b *Batch) NthCallID(n int) string {
-	return strconv.Itoa(n - 1)
+	b.init()
+	if n <= len(b.req.Calls) {
+		return b.req.Calls[n-1].CallID
+	}
+	return strconv.Itoa(b.added + n - len(b.req.Calls) - 1)
 }
 
-// Use adds capability to "using" list of the constructed request.
+// Use adds capability to "using" list of the constructed request if it is
+// not there already.
 func (b *Batch) Use(capability string) {
+	b.init()
+	for _, c := range b.req.Using {
+		if c == capability {
+			return
+		}
+	}
 	b.req.Using = append(b.req.Using, capability)
 }
 
 // Add adds method call to the constructed request object, using NextCallID for
 // call ID value.
 func (b *Batch) Add(methodName string, args interface{}) {
+	b.AddPriority(methodName, args, PriorityNormal)
+}
+
+// AddPriority is similar to Add but also sets the priority hint for the call.
+//
+// Priority is used only by Shape and Split.
+func (b *Batch) AddPriority(methodName string, args interface{}, prio Priority) {
+	b.init()
 	b.req.Calls = append(b.req.Calls, jmap.Invocation{
 		Name:   methodName,
 		CallID: b.NextCallID(),
 		Args:   args,
 	})
+	b.prio = append(b.prio, prio)
+	b.deadlines = append(b.deadlines, time.Time{})
+	b.added++
+}
+
+// SetDeadline sets the soft deadline hint for the call with the specified ID.
+// Zero deadline removes the hint.
+//
+// Deadline is used by Shape to order calls with the same priority, by
+// DropExpired and by Deadline. It is not sent to the server.
+func (b *Batch) SetDeadline(callID string, deadline time.Time) {
+	b.init()
+	for i, call := range b.req.Calls {
+		if call.CallID == callID {
+			b.deadlines[i] = deadline
+			return
+		}
+	}
+}
+
+// Deadline returns the earliest deadline set for calls in the batch. ok is
+// false if no call has a deadline.
+//
+// It can be used to limit the time spent waiting for the response.
+func (b *Batch) Deadline() (deadline time.Time, ok bool) {
+	for _, d := range b.deadlines {
+		if d.IsZero() {
+			continue
+		}
+		if !ok || d.Before(deadline) {
+			deadline = d
+			ok = true
+		}
+	}
+	return deadline, ok
+}
+
+// DropExpired removes calls with deadline before now from the batch,
+// together with calls referencing them. IDs of removed calls are returned.
+func (b *Batch) DropExpired(now time.Time) ([]string, error) {
+	b.init()
+	deps, err := b.dependencies()
+	if err != nil {
+		return nil, err
+	}
+
+	expired := make([]bool, len(b.req.Calls))
+	for i, d := range b.deadlines {
+		expired[i] = !d.IsZero() && d.Before(now)
+	}
+	for changed := true; changed; {
+		changed = false
+		for i, iDeps := range deps {
+			if expired[i] {
+				continue
+			}
+			for _, dep := range iDeps {
+				if expired[dep] {
+					expired[i] = true
+					changed = true
+					break
+				}
+			}
+		}
+	}
+
+	var dropped []string
+	keep := make([]int, 0, len(b.req.Calls))
+	for i, call := range b.req.Calls {
+		if expired[i] {
+			dropped = append(dropped, call.CallID)
+			continue
+		}
+		keep = append(keep, i)
+	}
+	b.reorder(keep)
+	return dropped, nil
 }
 
 // Request returns Request object constructed by Batch.
@@ -53,5 +192,334 @@ func (b *Batch) Add(methodName string, args interface{}) {
 // Value referenced by pointer should not be changed directly and is valid at
 // least until next call to Batch method.
 func (b *Batch) Request() *jmap.Request {
+	b.init()
 	return b.req
 }
+
+// Shape reorders calls in the batch so calls with higher priority are placed
+// earlier. Relative order of calls with the same priority is preserved.
+//
+// Calls with the same priority are ordered by deadline, calls with earlier
+// deadline are placed first.
+//
+// Calls referenced using jmap.ResultReference or using creation IDs
+// ("#"-prefixed IDs of records created by /set calls) are always kept before
+// calls referencing them. If a high-priority call references a low-priority
+// one, the latter is moved together with it.
+//
+// Call IDs are not changed.
+func (b *Batch) Shape() error {
+	b.init()
+	deps, err := b.dependencies()
+	if err != nil {
+		return err
+	}
+	effPrio := b.effectivePriorities(deps)
+	effDeadlines := b.effectiveDeadlines(deps)
+
+	order := make([]int, 0, len(b.req.Calls))
+	placed := make([]bool, len(b.req.Calls))
+	for len(order) != len(b.req.Calls) {
+		best := -1
+		for i := range b.req.Calls {
+			if placed[i] || !allPlaced(deps[i], placed) {
+				continue
+			}
+			if best == -1 || effPrio[i] > effPrio[best] ||
+				(effPrio[i] == effPrio[best] && deadlineBefore(effDeadlines[i], effDeadlines[best])) {
+				best = i
+			}
+		}
+		if best == -1 {
+			return errors.New("jmap/client: circular result references in batch")
+		}
+		placed[best] = true
+		order = append(order, best)
+	}
+
+	b.reorder(order)
+	return nil
+}
+
+// reorder replaces calls with the ones at the specified indexes, in the
+// specified order.
+func (b *Batch) reorder(order []int) {
+	calls := make([]jmap.Invocation, 0, len(order))
+	prio := make([]Priority, 0, len(order))
+	deadlines := make([]time.Time, 0, len(order))
+	for _, i := range order {
+		calls = append(calls, b.req.Calls[i])
+		prio = append(prio, b.prio[i])
+		deadlines = append(deadlines, b.deadlines[i])
+	}
+	b.req.Calls = calls
+	b.prio = prio
+	b.deadlines = deadlines
+}
+
+// Split splits the batch into multiple requests so each of them has at most
+// maxCalls calls and is at most maxSize octets in size when serialized.
+// Zero maxCalls or maxSize means no corresponding limit.
+//
+// Calls linked by result references or creation IDs are never split into
+// different requests.
+// Groups of linked calls are placed into requests in order of the highest
+// priority within a group.
+//
+// If dropLow is true and the batch does not fit into a single request, groups
+// where all calls have priority below PriorityNormal are dropped instead of
+// being placed into additional requests. IDs of dropped calls are returned.
+//
+// ErrCannotSplit is returned if some group of linked calls alone exceeds
+// limits.
+func (b *Batch) Split(maxCalls, maxSize int, dropLow bool) (reqs []*jmap.Request, dropped []string, err error) {
+	b.init()
+	deps, err := b.dependencies()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Union linked calls into groups, preserving call order within them.
+	groupOf := make([]int, len(b.req.Calls))
+	for i := range groupOf {
+		groupOf[i] = i
+	}
+	var find func(i int) int
+	find = func(i int) int {
+		if groupOf[i] != i {
+			groupOf[i] = find(groupOf[i])
+		}
+		return groupOf[i]
+	}
+	for i, iDeps := range deps {
+		for _, dep := range iDeps {
+			groupOf[find(i)] = find(dep)
+		}
+	}
+	groupIdx := map[int]int{}
+	var groups [][]int
+	for i := range b.req.Calls {
+		root := find(i)
+		idx, ok := groupIdx[root]
+		if !ok {
+			idx = len(groups)
+			groupIdx[root] = idx
+			groups = append(groups, nil)
+		}
+		groups[idx] = append(groups[idx], i)
+	}
+	maxPrio := func(group []int) Priority {
+		res := b.prio[group[0]]
+		for _, i := range group[1:] {
+			if b.prio[i] > res {
+				res = b.prio[i]
+			}
+		}
+		return res
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		return maxPrio(groups[i]) > maxPrio(groups[j])
+	})
+
+	fits := func(calls []jmap.Invocation) (bool, error) {
+		if maxCalls != 0 && len(calls) > maxCalls {
+			return false, nil
+		}
+		if maxSize == 0 {
+			return true, nil
+		}
+		blob, err := json.Marshal(jmap.Request{Using: b.req.Using, Calls: calls, CreatedIDs: b.req.CreatedIDs})
+		if err != nil {
+			return false, err
+		}
+		return len(blob) <= maxSize, nil
+	}
+
+	var current []jmap.Invocation
+	for _, group := range groups {
+		groupCalls := make([]jmap.Invocation, 0, len(group))
+		for _, i := range group {
+			groupCalls = append(groupCalls, b.req.Calls[i])
+		}
+
+		ok, err := fits(append(current[:len(current):len(current)], groupCalls...))
+		if err != nil {
+			return nil, nil, err
+		}
+		if ok {
+			current = append(current, groupCalls...)
+			continue
+		}
+
+		if dropLow && maxPrio(group) < PriorityNormal {
+			for _, call := range groupCalls {
+				dropped = append(dropped, call.CallID)
+			}
+			continue
+		}
+
+		if ok, err := fits(groupCalls); err != nil {
+			return nil, nil, err
+		} else if !ok {
+			return nil, nil, ErrCannotSplit
+		}
+		if len(current) != 0 {
+			reqs = append(reqs, &jmap.Request{Using: b.req.Using, Calls: current, CreatedIDs: b.req.CreatedIDs})
+		}
+		current = groupCalls
+	}
+	if len(current) != 0 {
+		reqs = append(reqs, &jmap.Request{Using: b.req.Using, Calls: current, CreatedIDs: b.req.CreatedIDs})
+	}
+	return reqs, dropped, nil
+}
+
+// dependencies returns indexes of calls referenced by each call, either
+// using result references or using creation IDs.
+func (b *Batch) dependencies() ([][]int, error) {
+	idxByID := make(map[string]int, len(b.req.Calls))
+	for i, call := range b.req.Calls {
+		idxByID[call.CallID] = i
+	}
+
+	allArgs := make([]interface{}, len(b.req.Calls))
+	creatorOf := map[string]int{}
+	for i, call := range b.req.Calls {
+		blob, err := json.Marshal(call.Args)
+		if err != nil {
+			return nil, err
+		}
+		var args interface{}
+		if err := json.Unmarshal(blob, &args); err != nil {
+			return nil, err
+		}
+		allArgs[i] = args
+
+		if !strings.HasSuffix(call.Name, "/set") {
+			continue
+		}
+		if argsMap, ok := args.(map[string]interface{}); ok {
+			if create, ok := argsMap["create"].(map[string]interface{}); ok {
+				for id := range create {
+					creatorOf[id] = i
+				}
+			}
+		}
+	}
+
+	deps := make([][]int, len(b.req.Calls))
+	for i, args := range allArgs {
+		for _, ref := range collectResultOf(args, nil) {
+			if j, ok := idxByID[ref]; ok && j != i {
+				deps[i] = append(deps[i], j)
+			}
+		}
+		if len(creatorOf) == 0 {
+			continue
+		}
+		for _, ref := range collectCreationRefs(args, nil) {
+			if j, ok := creatorOf[ref]; ok && j != i {
+				deps[i] = append(deps[i], j)
+			}
+		}
+	}
+	return deps, nil
+}
+
+// effectivePriorities returns priorities of calls raised to the priority of
+// calls that reference them (directly or indirectly).
+func (b *Batch) effectivePriorities(deps [][]int) []Priority {
+	res := make([]Priority, len(b.prio))
+	copy(res, b.prio)
+	for changed := true; changed; {
+		changed = false
+		for i, iDeps := range deps {
+			for _, dep := range iDeps {
+				if res[dep] < res[i] {
+					res[dep] = res[i]
+					changed = true
+				}
+			}
+		}
+	}
+	return res
+}
+
+// effectiveDeadlines returns deadlines of calls lowered to the deadline of
+// calls that reference them (directly or indirectly).
+func (b *Batch) effectiveDeadlines(deps [][]int) []time.Time {
+	res := make([]time.Time, len(b.deadlines))
+	copy(res, b.deadlines)
+	for changed := true; changed; {
+		changed = false
+		for i, iDeps := range deps {
+			for _, dep := range iDeps {
+				if deadlineBefore(res[i], res[dep]) {
+					res[dep] = res[i]
+					changed = true
+				}
+			}
+		}
+	}
+	return res
+}
+
+// deadlineBefore reports whether a is earlier than b. Zero deadline is later
+// than any other.
+func deadlineBefore(a, b time.Time) bool {
+	if a.IsZero() {
+		return false
+	}
+	return b.IsZero() || a.Before(b)
+}
+
+func collectResultOf(v interface{}, refs []string) []string {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if resultOf, ok := v["resultOf"].(string); ok {
+			if _, ok := v["path"]; ok {
+				refs = append(refs, resultOf)
+			}
+		}
+		for _, val := range v {
+			refs = collectResultOf(val, refs)
+		}
+	case []interface{}:
+		for _, val := range v {
+			refs = collectResultOf(val, refs)
+		}
+	}
+	return refs
+}
+
+// collectCreationRefs returns creation IDs referenced using "#"-prefixed
+// values or object keys (e.g. in mailboxIds), without the prefix.
+func collectCreationRefs(v interface{}, refs []string) []string {
+	switch v := v.(type) {
+	case string:
+		if strings.HasPrefix(v, "#") {
+			refs = append(refs, v[1:])
+		}
+	case map[string]interface{}:
+		for key, val := range v {
+			if strings.HasPrefix(key, "#") {
+				refs = append(refs, key[1:])
+			}
+			refs = collectCreationRefs(val, refs)
+		}
+	case []interface{}:
+		for _, val := range v {
+			refs = collectCreationRefs(val, refs)
+		}
+	}
+	return refs
+}
+
+func allPlaced(idxs []int, placed []bool) bool {
+	for _, i := range idxs {
+		if !placed[i] {
+			return false
+		}
+	}
+	return true
+}
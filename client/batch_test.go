@@ -0,0 +1,132 @@
+package client
+
+import (
+	"testing"
+	"time"
+
+	"github.com/foxcpp/go-jmap"
+	"gotest.tools/assert"
+	"gotest.tools/assert/cmp"
+)
+
+func callIDs(calls []jmap.Invocation) []string {
+	res := make([]string, 0, len(calls))
+	for _, call := range calls {
+		res = append(res, call.CallID)
+	}
+	return res
+}
+
+func TestBatchUse(t *testing.T) {
+	b := Batch{}
+	b.Use(jmap.CoreCapabilityName)
+	b.Use(jmap.MailCapabilityName)
+	b.Use(jmap.CoreCapabilityName)
+	assert.Check(t, cmp.DeepEqual([]string{jmap.CoreCapabilityName, jmap.MailCapabilityName}, b.Request().Using))
+}
+
+func TestBatchShape(t *testing.T) {
+	b := Batch{}
+	b.AddPriority("Email/get", map[string]interface{}{}, PriorityLow)
+	b.Add("Mailbox/get", map[string]interface{}{})
+	b.AddPriority("Email/changes", map[string]interface{}{}, PriorityHigh)
+	// Call 3 references low-priority call 0, so it should be moved too.
+	b.AddPriority("Thread/get", map[string]interface{}{
+		"#ids": jmap.ResultReference{ResultOf: "0", Name: "Email/get", Path: "/list/*/threadId"},
+	}, PriorityHigh)
+
+	assert.NilError(t, b.Shape())
+	assert.Check(t, cmp.DeepEqual([]string{"0", "2", "3", "1"}, callIDs(b.Request().Calls)))
+	assert.Check(t, cmp.Equal("2", b.NthCallID(2)))
+	assert.Check(t, cmp.Equal("4", b.NthCallID(5)))
+}
+
+func TestBatchSplit(t *testing.T) {
+	b := Batch{}
+	b.Add("Mailbox/get", map[string]interface{}{})
+	b.AddPriority("Email/query", map[string]interface{}{}, PriorityLow)
+	b.AddPriority("Email/get", map[string]interface{}{
+		"#ids": jmap.ResultReference{ResultOf: "1", Name: "Email/query", Path: "/ids"},
+	}, PriorityLow)
+	b.Add("Identity/get", map[string]interface{}{})
+
+	reqs, dropped, err := b.Split(2, 0, false)
+	assert.NilError(t, err)
+	assert.Assert(t, cmp.Len(reqs, 2))
+	assert.Check(t, cmp.DeepEqual([]string{"0", "3"}, callIDs(reqs[0].Calls)))
+	assert.Check(t, cmp.DeepEqual([]string{"1", "2"}, callIDs(reqs[1].Calls)))
+	assert.Check(t, cmp.Len(dropped, 0))
+
+	t.Run("drop low priority", func(t *testing.T) {
+		reqs, dropped, err := b.Split(2, 0, true)
+		assert.NilError(t, err)
+		assert.Assert(t, cmp.Len(reqs, 1))
+		assert.Check(t, cmp.DeepEqual([]string{"0", "3"}, callIDs(reqs[0].Calls)))
+		assert.Check(t, cmp.DeepEqual([]string{"1", "2"}, dropped))
+	})
+
+	t.Run("group too large", func(t *testing.T) {
+		_, _, err := b.Split(1, 0, false)
+		assert.Check(t, cmp.Equal(ErrCannotSplit, err))
+	})
+}
+
+func TestBatchCreationIDDependencies(t *testing.T) {
+	b := Batch{}
+	b.AddPriority("Email/set", map[string]interface{}{
+		"create": map[string]interface{}{"draft": map[string]interface{}{}},
+	}, PriorityLow)
+	b.Add("Mailbox/get", map[string]interface{}{})
+	b.AddPriority("EmailSubmission/set", map[string]interface{}{
+		"create": map[string]interface{}{
+			"send": map[string]interface{}{"emailId": "#draft"},
+		},
+		"onSuccessUpdateEmail": map[string]interface{}{"#send": map[string]interface{}{}},
+	}, PriorityHigh)
+
+	assert.NilError(t, b.Shape())
+	assert.Check(t, cmp.DeepEqual([]string{"0", "2", "1"}, callIDs(b.Request().Calls)))
+
+	reqs, _, err := b.Split(2, 0, false)
+	assert.NilError(t, err)
+	assert.Assert(t, cmp.Len(reqs, 2))
+	assert.Check(t, cmp.DeepEqual([]string{"0", "2"}, callIDs(reqs[0].Calls)))
+}
+
+func TestBatchDeadline(t *testing.T) {
+	now := time.Unix(1000, 0)
+
+	b := Batch{}
+	b.Add("Mailbox/get", map[string]interface{}{})
+	b.Add("Email/query", map[string]interface{}{})
+	b.Add("Email/get", map[string]interface{}{
+		"#ids": jmap.ResultReference{ResultOf: "1", Name: "Email/query", Path: "/ids"},
+	})
+	b.Add("Identity/get", map[string]interface{}{})
+
+	_, ok := b.Deadline()
+	assert.Check(t, !ok)
+
+	b.SetDeadline("3", now.Add(time.Minute))
+	b.SetDeadline("1", now.Add(-time.Second))
+	deadline, ok := b.Deadline()
+	assert.Check(t, ok)
+	assert.Check(t, cmp.Equal(now.Add(-time.Second), deadline))
+
+	assert.NilError(t, b.Shape())
+	assert.Check(t, cmp.DeepEqual([]string{"1", "3", "0", "2"}, callIDs(b.Request().Calls)))
+
+	dropped, err := b.DropExpired(now)
+	assert.NilError(t, err)
+	assert.Check(t, cmp.DeepEqual([]string{"1", "2"}, dropped))
+	assert.Check(t, cmp.DeepEqual([]string{"3", "0"}, callIDs(b.Request().Calls)))
+	deadline, _ = b.Deadline()
+	assert.Check(t, cmp.Equal(now.Add(time.Minute), deadline))
+
+	// IDs of removed calls are not reused.
+	assert.Check(t, cmp.Equal("3", b.NthCallID(1)))
+	assert.Check(t, cmp.Equal("4", b.NthCallID(3)))
+	assert.Check(t, cmp.Equal("4", b.NextCallID()))
+	b.Add("Email/get", map[string]interface{}{})
+	assert.Check(t, cmp.DeepEqual([]string{"3", "0", "4"}, callIDs(b.Request().Calls)))
+}
@@ -3,16 +3,24 @@ package client
 
 import (
 	"bytes"
+	"compress/gzip"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"mime"
 	"net/http"
 	"strings"
 	"sync"
+	"sync/atomic"
 
 	"github.com/foxcpp/go-jmap"
 )
 
+var ErrNoWebSocket = errors.New("jmap/client: server does not support JMAP over WebSocket")
+
 // The Client object wraps *http.Client and stores all information necessary to
 // make JMAP API requests.
 //
@@ -34,7 +42,86 @@ type Client struct {
 	// Mutex that is used for access coordination to Session object.
 	SessionLck sync.RWMutex
 
+	// If not nil, requests containing /set, /copy or /import method calls
+	// are recorded in the journal before sending and their outcome is
+	// recorded after. This allows to determine after a crash whether such
+	// requests were acknowledged by the server.
+	//
+	// Responses larger than SpillThreshold are not recorded in the journal.
+	Journal Journal
+
+	// If positive, API responses larger than SpillThreshold octets are
+	// written to a temporary file and decoded from it in a streaming
+	// fashion instead of being buffered in memory.
+	SpillThreshold int64
+
+	// Directory to create temporary files for SpillThreshold in. If empty,
+	// os.TempDir() is used.
+	SpillDir string
+
+	// Clock to use for time-dependent logic. If nil, SystemClock is used.
+	Clock Clock
+
+	// If not nil, API requests are delayed according to rate limit hints
+	// returned by the server. Use Throttle.State to inspect the current
+	// budget.
+	Throttle *Throttle
+
+	// If true, the Session object is fetched again when sessionState of an
+	// API response differs from the state of the last seen Session (e.g.
+	// because accounts or capabilities were changed on the server).
+	RefreshSession bool
+
+	// If not nil, requests rejected by the server with unknownCapability
+	// error are retried without the offending capability, provided no method
+	// call in the request requires it (see EnableMethodCapabilities). The
+	// callback is called for each removed capability before the retry.
+	//
+	// This allows to use optional extensions (e.g. S/MIME verification
+	// properties) with servers that support them only partially.
+	OnCapabilityDowngrade func(CapabilityDowngrade)
+
+	// If not nil, states of data types returned by /get, /changes and /set
+	// calls are recorded and passed as ifInState argument of subsequent /set
+	// calls that do not set it explicitly. Call returns *StateMismatchError
+	// if the server rejects such call because the data was changed.
+	States *StateTracker
+
+	// If true, API request bodies are compressed using gzip. If the server
+	// rejects a compressed request with 415 Unsupported Media Type status or
+	// with the notJSON request-level error (servers that ignore
+	// Content-Encoding try to parse gzip data as JSON), the request is
+	// retried uncompressed and compression is not used by the Client
+	// anymore.
+	CompressRequests bool
+
+	// The default page size for helpers that page through query results
+	// (e.g. mail.EmailIterator) if it is not specified explicitly. If zero,
+	// the server default is used.
+	PageSize jmap.UnsignedInt
+
+	// If not nil, it is used by NewID instead of jmap.RandomID to generate
+	// ids of client-side records, such as journal entries.
+	IDGenerator func() (jmap.ID, error)
+
+	// Set to 1 if the server rejected a compressed request.
+	noCompression int32
+
+	// Set by NewFromSnapshot.
+	readOnly bool
+
+	// Lifecycle state, see Close.
+	closeLck       sync.Mutex
+	closed         bool
+	closeCtx       context.Context
+	closeCancel    context.CancelFunc
+	background     map[int]func()
+	nextBackground int
+	backgroundWG   sync.WaitGroup
+
 	argsUnmarshallers map[string]jmap.FuncArgsUnmarshal
+	schemas           jmap.PropertySchemas
+	methodCaps        map[string]string
 }
 
 // New creates new JMAP Core client using http.DefaultClient for all requests.
@@ -67,16 +154,84 @@ func NewWithClient(cl *http.Client, sessionURL, authHeader string) (*Client, err
 // This method must not be called when there is running requests.
 // You probably want to call it before any operations.
 func (c *Client) Enable(unmarshallers map[string]jmap.FuncArgsUnmarshal) {
+	if c.argsUnmarshallers == nil {
+		c.argsUnmarshallers = make(map[string]jmap.FuncArgsUnmarshal, len(unmarshallers))
+	}
 	for k, v := range unmarshallers {
 		c.argsUnmarshallers[k] = v
 	}
 }
 
+// EnableSchemas adds property schemas to the client. Client will use them to
+// validate property names used in method calls before sending requests, see
+// jmap.PropertySchemas.Validate for details.
+//
+// This method must not be called when there is running requests.
+func (c *Client) EnableSchemas(schemas jmap.PropertySchemas) {
+	if c.schemas == nil {
+		c.schemas = make(jmap.PropertySchemas, len(schemas))
+	}
+	for k, v := range schemas {
+		c.schemas[k] = v
+	}
+}
+
+// Clone returns a new Client with the same configuration that can be
+// modified independently, e.g. to use different Authentication for another
+// tenant.
+//
+// HTTPClient is shared, so the clone reuses connections of the original.
+// The Session object is not copied and is fetched again by the clone on the
+// first request. Unmarshallers, schemas and method capabilities are copied,
+// so Enable calls on the clone do not affect the original and vice versa.
+//
+// Journal, Throttle and States are not copied since they keep the state
+// specific to the requests made by the original client. Set them explicitly
+// if needed.
+//
+// Clones of a read-only Client (see NewFromSnapshot) are read-only too and
+// share its Session object.
+//
+// This method must not be called concurrently with Enable, EnableSchemas or
+// EnableMethodCapabilities.
+func (c *Client) Clone() *Client {
+	clone := &Client{
+		HTTPClient:            c.HTTPClient,
+		Authentication:        c.Authentication,
+		SessionEndpoint:       c.SessionEndpoint,
+		SpillThreshold:        c.SpillThreshold,
+		SpillDir:              c.SpillDir,
+		Clock:                 c.Clock,
+		RefreshSession:        c.RefreshSession,
+		OnCapabilityDowngrade: c.OnCapabilityDowngrade,
+		CompressRequests:      c.CompressRequests,
+		PageSize:              c.PageSize,
+		IDGenerator:           c.IDGenerator,
+	}
+	if c.readOnly {
+		clone.readOnly = true
+		clone.Session = c.Session
+	}
+	if c.argsUnmarshallers != nil {
+		clone.Enable(c.argsUnmarshallers)
+	}
+	if c.schemas != nil {
+		clone.EnableSchemas(c.schemas)
+	}
+	if c.methodCaps != nil {
+		clone.EnableMethodCapabilities(c.methodCaps)
+	}
+	return clone
+}
+
 // UpdateSession sets c.Session and returns it.
 //
 // Session object contains information necessary to do almost all requests so
 // UpdateSession is called implicitly on first API request.
 func (c *Client) UpdateSession() (*jmap.Session, error) {
+	if c.readOnly {
+		return nil, ErrReadOnly
+	}
 	if c.SessionEndpoint == "" {
 		return nil, fmt.Errorf("jmap/client: SessionEndpoint is empty")
 	}
@@ -87,7 +242,7 @@ func (c *Client) UpdateSession() (*jmap.Session, error) {
 	}
 	req.Header.Set("Authentication", c.Authentication)
 
-	resp, err := c.HTTPClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -120,11 +275,24 @@ func (c *Client) lazyInitSession() (jmap.Session, error) {
 	return *c.Session, nil
 }
 
+// CurrentSession returns a copy of the last seen Session object, fetching
+// it if necessary.
+func (c *Client) CurrentSession() (*jmap.Session, error) {
+	session, err := c.lazyInitSession()
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
 // RawSend sends manually constructed jmap.Request object and returns parsed
 // jmap.Response object.
 //
 // It initializes c.Session if it is empty.
 func (c *Client) RawSend(r *jmap.Request) (*jmap.Response, error) {
+	if c.readOnly {
+		return nil, ErrReadOnly
+	}
 	if c.SessionEndpoint == "" {
 		return nil, fmt.Errorf("jmap/client: SessionEndpoint is empty")
 	}
@@ -134,7 +302,36 @@ func (c *Client) RawSend(r *jmap.Request) (*jmap.Response, error) {
 		return nil, err
 	}
 
-	if jmap.UnsignedInt(len(r.Calls)) > session.CoreCapability.MaxCallsInRequest {
+	if c.States != nil {
+		r, err = c.States.injectStates(r)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for {
+		resp, err := c.rawSend(r, session)
+		if err == nil && c.States != nil {
+			c.States.observe(resp)
+		}
+		if err == nil || c.OnCapabilityDowngrade == nil {
+			return resp, err
+		}
+		downgraded, ok := c.downgrade(r, &session, err)
+		if !ok {
+			return resp, err
+		}
+		r = downgraded
+	}
+}
+
+func (c *Client) rawSend(r *jmap.Request, session jmap.Session) (*jmap.Response, error) {
+	ctx, err := c.requestContext()
+	if err != nil {
+		return nil, err
+	}
+
+	if jmap.UnsignedInt(len(r.Calls)) > session.Limits().MaxCallsInRequest {
 		return nil, jmap.RequestError{
 			Type: jmap.ProblemPrefix + "limit",
 			Properties: map[string]interface{}{
@@ -143,29 +340,243 @@ func (c *Client) RawSend(r *jmap.Request) (*jmap.Response, error) {
 		}
 	}
 
+	if c.schemas != nil {
+		for _, call := range r.Calls {
+			if err := c.schemas.Validate(call); err != nil {
+				return nil, err
+			}
+		}
+	}
+
 	reqBlob, err := json.Marshal(r)
 	if err != nil {
 		return nil, err
 	}
-	req, err := http.NewRequest("POST", session.APIURL, bytes.NewReader(reqBlob))
+
+	var journalID string
+	if c.Journal != nil && isMutating(r) {
+		id, err := c.NewID()
+		if err != nil {
+			return nil, err
+		}
+		journalID = string(id)
+		if err := c.Journal.Begin(JournalEntry{
+			ID:      journalID,
+			Time:    ClockOrSystem(c.Clock).Now(),
+			State:   JournalPending,
+			Request: reqBlob,
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	clock := ClockOrSystem(c.Clock)
+	compress := c.CompressRequests && atomic.LoadInt32(&c.noCompression) == 0
+	var resp *http.Response
+	for {
+		req, err := c.newAPIRequest(session.APIURL, reqBlob, compress)
+		if err != nil {
+			c.journalComplete(journalID, JournalRejected, nil, err)
+			return nil, err
+		}
+
+		if c.Throttle != nil {
+			if delay := c.Throttle.reserve(clock.Now()); delay > 0 {
+				select {
+				case <-clock.After(delay):
+				case <-ctx.Done():
+					c.journalComplete(journalID, JournalRejected, nil, ErrClientClosed)
+					return nil, ErrClientClosed
+				}
+			}
+		}
+
+		resp, err = c.do(req)
+		if err != nil {
+			c.journalComplete(journalID, JournalUnknown, nil, err)
+			return nil, err
+		}
+		if c.Throttle != nil {
+			c.Throttle.observe(clock.Now(), resp.StatusCode, resp.Header)
+		}
+
+		if !compress || !rejectsCompression(resp) {
+			break
+		}
+		// The server does not accept compressed requests.
+		resp.Body.Close()
+		atomic.StoreInt32(&c.noCompression, 1)
+		compress = false
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		err := decodeError(resp)
+		if resp.StatusCode/100 == 5 {
+			// Proxies may report errors after the request was processed.
+			c.journalComplete(journalID, JournalUnknown, nil, err)
+		} else {
+			c.journalComplete(journalID, JournalRejected, nil, err)
+		}
+		return nil, err
+	}
+
+	var (
+		body   io.Reader = resp.Body
+		stream bool
+	)
+	if c.SpillThreshold > 0 {
+		spooled, cleanup, err := spool(resp.Body, c.SpillThreshold, c.SpillDir)
+		if err != nil {
+			c.journalComplete(journalID, JournalUnknown, nil, err)
+			return nil, err
+		}
+		defer cleanup()
+		body = spooled
+		stream = true
+
+		// Spilled responses are not loaded into memory to be journaled.
+		var respBlob []byte
+		if buf, ok := spooled.(*bytes.Buffer); ok {
+			respBlob = buf.Bytes()
+		}
+		c.journalComplete(journalID, JournalAcknowledged, respBlob, nil)
+	} else if journalID != "" {
+		respBlob, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			c.journalComplete(journalID, JournalUnknown, nil, err)
+			return nil, err
+		}
+		c.journalComplete(journalID, JournalAcknowledged, respBlob, nil)
+		body = bytes.NewReader(respBlob)
+	}
+
+	var response jmap.Response
+	if stream {
+		err = response.UnmarshalStream(body, c.argsUnmarshallers)
+	} else {
+		err = response.Unmarshal(body, c.argsUnmarshallers)
+	}
+	if err != nil {
+		return &response, err
+	}
+	if c.Throttle != nil {
+		c.Throttle.observeResponse(clock.Now(), &response)
+	}
+	if c.RefreshSession && response.SessionState != "" && response.SessionState != session.State {
+		// The response itself is valid, so failure is not reported here. The
+		// state will still mismatch on the next request and refresh will be
+		// retried.
+		c.UpdateSession() //nolint:errcheck
+	}
+	return &response, nil
+}
+
+// rejectsCompression reports whether the server rejected the compressed
+// request body. The body of a 400 response is preserved for decodeError.
+func rejectsCompression(resp *http.Response) bool {
+	switch resp.StatusCode {
+	case http.StatusUnsupportedMediaType:
+		return true
+	case http.StatusBadRequest:
+		blob, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		resp.Body = ioutil.NopCloser(bytes.NewReader(blob))
+		if err != nil {
+			return false
+		}
+		var reqErr jmap.RequestError
+		if err := json.Unmarshal(blob, &reqErr); err != nil {
+			return false
+		}
+		return reqErr.Type == jmap.ProblemPrefix+jmap.CodeNotJSON
+	}
+	return false
+}
+
+// newAPIRequest creates the HTTP request to the API endpoint, compressing
+// the body if requested.
+func (c *Client) newAPIRequest(url string, reqBlob []byte, compress bool) (*http.Request, error) {
+	if compress {
+		var buf bytes.Buffer
+		w := gzip.NewWriter(&buf)
+		if _, err := w.Write(reqBlob); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+		reqBlob = buf.Bytes()
+	}
+
+	req, err := http.NewRequest("POST", url, bytes.NewReader(reqBlob))
 	if err != nil {
 		return nil, err
 	}
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authentication", c.Authentication)
+	if compress {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	return req, nil
+}
+
+// NewID returns a new id for a client-side record using IDGenerator or
+// jmap.RandomID if it is nil.
+func (c *Client) NewID() (jmap.ID, error) {
+	if c.IDGenerator != nil {
+		return c.IDGenerator()
+	}
+	return jmap.RandomID()
+}
 
-	resp, err := c.HTTPClient.Do(req)
+// journalComplete records the request outcome in c.Journal if the request was
+// journaled.
+//
+// Errors are ignored since the outcome is already determined at this point,
+// the entry is left unresolved in this case.
+func (c *Client) journalComplete(id string, state JournalState, response []byte, err error) {
+	if id == "" {
+		return
+	}
+	c.Journal.Complete(id, ClockOrSystem(c.Clock).Now(), state, response, err) //nolint:errcheck
+}
+
+// Call sends a request with a single method call and returns decoded
+// response arguments.
+//
+// Method-level error is returned as jmap.MethodErrorArgs error value.
+func (c *Client) Call(using []string, methodName string, args interface{}) (interface{}, error) {
+	resp, err := c.RawSend(&jmap.Request{
+		Using: using,
+		Calls: []jmap.Invocation{
+			{
+				Name:   methodName,
+				CallID: "0",
+				Args:   args,
+			},
+		},
+	})
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode/100 != 2 {
-		return nil, decodeError(resp)
+	if len(resp.Responses) == 0 {
+		return nil, fmt.Errorf("jmap/client: no response for %s call", methodName)
 	}
 
-	var response jmap.Response
-	return &response, response.Unmarshal(resp.Body, c.argsUnmarshallers)
+	inv := resp.Responses[0]
+	if inv.Name == "error" {
+		errArgs := inv.Args.(jmap.MethodErrorArgs)
+		if c.States != nil && errArgs.Type == jmap.CodeStateMismatch {
+			return nil, &StateMismatchError{
+				Method:  methodName,
+				Account: callAccount(args),
+				Err:     errArgs,
+			}
+		}
+		return nil, errArgs
+	}
+	return inv.Args, nil
 }
 
 // Echo sends empty Core/echo request, testing server connectivity.
@@ -188,6 +599,9 @@ func (c *Client) Echo() error {
 // - Blob ID may become invalid after some time if it is unused.
 // - Blob ID is usable only by the uploader until it is used, even for shared accounts.
 func (c *Client) Upload(account jmap.ID, blob io.Reader) (*jmap.BlobInfo, error) {
+	if c.readOnly {
+		return nil, ErrReadOnly
+	}
 	if c.SessionEndpoint == "" {
 		return nil, fmt.Errorf("jmap/client: SessionEndpoint is empty")
 	}
@@ -197,11 +611,6 @@ func (c *Client) Upload(account jmap.ID, blob io.Reader) (*jmap.BlobInfo, error)
 		return nil, err
 	}
 
-	client := c.HTTPClient
-	if client == nil {
-		client = http.DefaultClient
-	}
-
 	tgtUrl := strings.Replace(session.UploadURL, "{accountId}", string(account), -1)
 	req, err := http.NewRequest("POST", tgtUrl, blob)
 	if err != nil {
@@ -210,7 +619,7 @@ func (c *Client) Upload(account jmap.ID, blob io.Reader) (*jmap.BlobInfo, error)
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authentication", c.Authentication)
 
-	resp, err := client.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -225,46 +634,153 @@ func (c *Client) Upload(account jmap.ID, blob io.Reader) (*jmap.BlobInfo, error)
 }
 
 // Download downloads binary data by its Blob ID from the server.
+//
+// It is equivalent to DownloadAs with "application/octet-stream" type and
+// "filename" name.
 func (c *Client) Download(account, blob jmap.ID) (io.ReadCloser, error) {
+	return c.DownloadAs(account, blob, "application/octet-stream", "filename")
+}
+
+// DownloadAs downloads binary data by its Blob ID from the server, asking
+// the server to return it with the specified Content-Type and file name (in
+// Content-Disposition header).
+//
+// The Content-Type actually returned by the server is not checked, use
+// DownloadWithOptions for that.
+func (c *Client) DownloadAs(account, blob jmap.ID, contentType, name string) (io.ReadCloser, error) {
+	body, _, err := c.DownloadWithOptions(account, blob, DownloadOptions{
+		Type: contentType,
+		Name: name,
+	})
+	return body, err
+}
+
+// DownloadOptions contains parameters for DownloadWithOptions.
+type DownloadOptions struct {
+	// The Content-Type the server should use for the response ({type}
+	// variable of the downloadUrl). Default is application/octet-stream.
+	Type string
+
+	// The file name the server should use in Content-Disposition ({name}
+	// variable of the downloadUrl). Default is "filename".
+	Name string
+
+	// If true, *ContentTypeMismatchError is returned if the media type of
+	// the response is different from Type. Parameters (e.g. charset) are
+	// not compared.
+	RequireType bool
+}
+
+// ContentTypeMismatchError is returned by DownloadWithOptions if the server
+// ignored the requested Content-Type.
+type ContentTypeMismatchError struct {
+	Requested string
+	Returned  string
+}
+
+func (ctme *ContentTypeMismatchError) Error() string {
+	return fmt.Sprintf("jmap/client: requested %s download, got %q", ctme.Requested, ctme.Returned)
+}
+
+// DownloadWithOptions downloads binary data by its Blob ID from the server.
+//
+// Returned contentType is the value of the Content-Type header of the
+// response.
+func (c *Client) DownloadWithOptions(account, blob jmap.ID, opts DownloadOptions) (body io.ReadCloser, contentType string, err error) {
+	if c.readOnly {
+		return nil, "", ErrReadOnly
+	}
 	if c.SessionEndpoint == "" {
-		return nil, fmt.Errorf("jmap/client: SessionEndpoint is empty")
+		return nil, "", fmt.Errorf("jmap/client: SessionEndpoint is empty")
+	}
+	if opts.Type == "" {
+		opts.Type = "application/octet-stream"
+	}
+	if opts.Name == "" {
+		opts.Name = "filename"
 	}
 
 	session, err := c.lazyInitSession()
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
 	urlRepl := strings.NewReplacer(
-		"{accountId}", string(account),
-		"{blobId}", string(blob),
-		"{type}", "application/octet-stream", // TODO: are any other values necessary?
-		"{name}", "filename",
+		"{accountId}", escapeURIVar(string(account)),
+		"{blobId}", escapeURIVar(string(blob)),
+		"{type}", escapeURIVar(opts.Type),
+		"{name}", escapeURIVar(opts.Name),
 	)
 	tgtUrl := urlRepl.Replace(session.DownloadURL)
 	req, err := http.NewRequest("GET", tgtUrl, nil)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", opts.Type)
 	req.Header.Set("Authentication", c.Authentication)
 
-	resp, err := c.HTTPClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 	if resp.StatusCode/100 != 2 {
 		defer resp.Body.Close()
-		return nil, decodeError(resp)
+		return nil, "", decodeError(resp)
+	}
+
+	contentType = resp.Header.Get("Content-Type")
+	if opts.RequireType && !sameMediaType(opts.Type, contentType) {
+		resp.Body.Close()
+		return nil, contentType, &ContentTypeMismatchError{Requested: opts.Type, Returned: contentType}
+	}
+	return resp.Body, contentType, nil
+}
+
+func sameMediaType(a, b string) bool {
+	aType, _, err := mime.ParseMediaType(a)
+	if err != nil {
+		return false
+	}
+	bType, _, err := mime.ParseMediaType(b)
+	if err != nil {
+		return false
 	}
+	return aType == bType
+}
 
-	return resp.Body, nil
+// WebSocketURL returns the endpoint to use for JMAP over WebSocket, as
+// advertised by the server in the urn:ietf:params:jmap:websocket capability.
+//
+// ErrNoWebSocket is returned if the server does not support WebSocket.
+func (c *Client) WebSocketURL() (string, error) {
+	session, err := c.lazyInitSession()
+	if err != nil {
+		return "", err
+	}
+	if session.WebSocketCapability == nil || session.WebSocketCapability.URL == "" {
+		return "", ErrNoWebSocket
+	}
+	return session.WebSocketCapability.URL, nil
+}
+
+// HTTPError is returned when the server responds with non-2xx status code and
+// no problem details object.
+type HTTPError struct {
+	StatusCode int
+	Status     string
+}
+
+func (he HTTPError) Error() string {
+	return fmt.Sprintf("HTTP %d %s", he.StatusCode, he.Status)
 }
 
 func decodeError(resp *http.Response) error {
-	contentType := resp.Header.Get("Content-Type")
-	if contentType != "application/json" {
-		return fmt.Errorf("HTTP %d %s", resp.StatusCode, resp.Status)
+	// RFC 7807 defines application/problem+json but many servers use plain
+	// application/json, both are accepted.
+	contentType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil || (contentType != "application/json" && contentType != "application/problem+json") {
+		return HTTPError{StatusCode: resp.StatusCode, Status: resp.Status}
 	}
 
 	var requestErr jmap.RequestError
@@ -274,3 +790,19 @@ func decodeError(resp *http.Response) error {
 
 	return requestErr
 }
+
+// escapeURIVar percent-encodes all characters except unreserved ones, as
+// required for simple string expansion of URI templates (RFC 6570).
+func escapeURIVar(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' ||
+			c == '-' || c == '.' || c == '_' || c == '~' {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
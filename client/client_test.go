@@ -0,0 +1,258 @@
+package client
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/foxcpp/go-jmap"
+	"github.com/foxcpp/go-jmap/jmaptest"
+	"gotest.tools/assert"
+)
+
+// newTestClient starts a server that serves the Session object and passes
+// API and download requests to apiHandler.
+func newTestClient(t *testing.T, apiHandler http.HandlerFunc) (*Client, *httptest.Server) {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+	mux.HandleFunc("/.well-known/jmap", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{ //nolint:errcheck
+			"capabilities": map[string]interface{}{
+				"urn:ietf:params:jmap:core": map[string]interface{}{
+					"maxSizeUpload":         50000000,
+					"maxConcurrentUpload":   4,
+					"maxSizeRequest":        10000000,
+					"maxConcurrentRequests": 4,
+					"maxCallsInRequest":     16,
+					"maxObjectsInGet":       500,
+					"maxObjectsInSet":       500,
+					"collationAlgorithms":   []string{},
+				},
+				"urn:ietf:params:jmap:mail": map[string]interface{}{},
+			},
+			"accounts": map[string]interface{}{
+				"A1": map[string]interface{}{
+					"name":       "test@example.org",
+					"isPersonal": true,
+					"accountCapabilities": map[string]interface{}{
+						"urn:ietf:params:jmap:mail": map[string]interface{}{},
+					},
+				},
+			},
+			"primaryAccounts": map[string]interface{}{
+				"urn:ietf:params:jmap:mail": "A1",
+			},
+			"username":    "test@example.org",
+			"apiUrl":      srv.URL + "/api",
+			"downloadUrl": srv.URL + "/download/{accountId}/{blobId}/{name}?accept={type}",
+			"uploadUrl":   srv.URL + "/upload/{accountId}/",
+			"state":       "1",
+		})
+	})
+	mux.HandleFunc("/api", apiHandler)
+	mux.HandleFunc("/download/", apiHandler)
+
+	c, err := NewWithClient(srv.Client(), srv.URL+"/.well-known/jmap", "")
+	assert.NilError(t, err, "NewWithClient")
+	return c, srv
+}
+
+func TestDownloadAs(t *testing.T) {
+	var uri string
+	c, srv := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		uri = r.URL.RequestURI()
+		w.Write([]byte("blob")) //nolint:errcheck
+	})
+	defer srv.Close()
+
+	rd, err := c.DownloadAs("A1", "B1", "message/rfc822", "Re: hello world.eml")
+	assert.NilError(t, err)
+	defer rd.Close()
+	blob, err := ioutil.ReadAll(rd)
+	assert.NilError(t, err)
+	assert.Equal(t, "blob", string(blob))
+	assert.Equal(t, "/download/A1/B1/Re%3A%20hello%20world.eml?accept=message%2Frfc822", uri)
+}
+
+func TestRefreshSession(t *testing.T) {
+	c, srv := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"sessionState":"1","methodResponses":[["Core/echo",{},"echo0"]]}`)) //nolint:errcheck
+	})
+	defer srv.Close()
+	c.Enable(jmap.RawUnmarshallers([]string{"Core/echo"}))
+
+	// Pretend the server configuration has changed since the session was
+	// fetched.
+	c.Session.State = "0"
+	assert.NilError(t, c.Echo())
+	assert.Equal(t, "0", c.Session.State, "session should not be refreshed by default")
+
+	c.RefreshSession = true
+	assert.NilError(t, c.Echo())
+	assert.Equal(t, "1", c.Session.State)
+}
+
+func TestClone(t *testing.T) {
+	var auths []string
+	c, srv := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		auths = append(auths, r.Header.Get("Authentication"))
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"sessionState":"1","methodResponses":[["Core/echo",{},"echo0"]]}`)) //nolint:errcheck
+	})
+	defer srv.Close()
+	c.Authentication = "Bearer tenant1"
+	c.Enable(jmap.RawUnmarshallers([]string{"Core/echo"}))
+
+	clone := c.Clone()
+	assert.Assert(t, clone.Session == nil, "session should not be shared")
+	assert.Assert(t, clone.HTTPClient == c.HTTPClient, "HTTP client should be shared")
+	clone.Authentication = "Bearer tenant2"
+	clone.Enable(jmap.RawUnmarshallers([]string{"Foo/get"}))
+
+	assert.NilError(t, clone.Echo())
+	assert.NilError(t, c.Echo())
+	assert.DeepEqual(t, []string{"Bearer tenant2", "Bearer tenant1"}, auths)
+	assert.Assert(t, clone.Session != nil && clone.Session != c.Session)
+
+	_, ok := c.argsUnmarshallers["Foo/get"]
+	assert.Assert(t, !ok, "unmarshallers of the original should not be changed")
+}
+
+func TestDownloadWithOptions(t *testing.T) {
+	var accept string
+	c, srv := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		accept = r.Header.Get("Accept")
+		if r.URL.Query().Get("accept") == "message/rfc822" {
+			w.Header().Set("Content-Type", "Message/RFC822; charset=utf-8")
+		} else {
+			w.Header().Set("Content-Type", "application/octet-stream")
+		}
+		w.Write([]byte("blob")) //nolint:errcheck
+	})
+	defer srv.Close()
+
+	rd, contentType, err := c.DownloadWithOptions("A1", "B1", DownloadOptions{
+		Type:        "message/rfc822",
+		RequireType: true,
+	})
+	assert.NilError(t, err)
+	rd.Close()
+	assert.Equal(t, "Message/RFC822; charset=utf-8", contentType)
+	assert.Equal(t, "message/rfc822", accept)
+
+	_, contentType, err = c.DownloadWithOptions("A1", "B1", DownloadOptions{
+		Type:        "text/html",
+		RequireType: true,
+	})
+	mismatch, ok := err.(*ContentTypeMismatchError)
+	assert.Assert(t, ok, "%T", err)
+	assert.Equal(t, "text/html", mismatch.Requested)
+	assert.Equal(t, "application/octet-stream", mismatch.Returned)
+	assert.Equal(t, "application/octet-stream", contentType)
+
+	rd, _, err = c.DownloadWithOptions("A1", "B1", DownloadOptions{Type: "text/html"})
+	assert.NilError(t, err, "type should not be checked without RequireType")
+	rd.Close()
+}
+
+func TestCapabilityDowngrade(t *testing.T) {
+	var usings [][]string
+	c, srv := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var req jmap.Request
+		assert.NilError(t, json.NewDecoder(r.Body).Decode(&req))
+		usings = append(usings, req.Using)
+		for _, u := range req.Using {
+			if u == "urn:ietf:params:jmap:smimeverify" || u == "https://example.com/apis/foobar" {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"type":"urn:ietf:params:jmap:error:unknownCapability","status":400,` + //nolint:errcheck
+					`"detail":"Unknown capability '` + u + `'"}`))
+				return
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"sessionState":"1","methodResponses":[["Email/get",{},"0"]]}`)) //nolint:errcheck
+	})
+	defer srv.Close()
+	c.Enable(jmap.RawUnmarshallers([]string{"Email/get"}))
+	c.EnableMethodCapabilities(map[string]string{
+		"Email":  jmap.MailCapabilityName,
+		"Foobar": "https://example.com/apis/foobar",
+	})
+
+	req := &jmap.Request{
+		Using: []string{jmap.CoreCapabilityName, jmap.MailCapabilityName, jmap.SMIMEVerifyCapabilityName},
+		Calls: []jmap.Invocation{{Name: "Email/get", CallID: "0", Args: map[string]interface{}{}}},
+	}
+
+	_, err := c.RawSend(req)
+	assert.ErrorContains(t, err, "Unknown capability", "downgrade should be disabled by default")
+
+	var downgrades []CapabilityDowngrade
+	c.OnCapabilityDowngrade = func(d CapabilityDowngrade) {
+		downgrades = append(downgrades, d)
+	}
+	usings = nil
+	_, err = c.RawSend(req)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, [][]string{
+		{jmap.CoreCapabilityName, jmap.MailCapabilityName, jmap.SMIMEVerifyCapabilityName},
+		{jmap.CoreCapabilityName, jmap.MailCapabilityName},
+	}, usings)
+	assert.Equal(t, 1, len(downgrades))
+	assert.Equal(t, jmap.SMIMEVerifyCapabilityName, downgrades[0].Capability)
+	assert.DeepEqual(t, []string{"Email/get"}, downgrades[0].Methods)
+	assert.Equal(t, 3, len(req.Using), "original request should not be modified")
+
+	t.Run("required capability", func(t *testing.T) {
+		downgrades = nil
+		_, err := c.RawSend(&jmap.Request{
+			Using: []string{jmap.CoreCapabilityName, "https://example.com/apis/foobar"},
+			Calls: []jmap.Invocation{{Name: "Foobar/get", CallID: "0", Args: map[string]interface{}{}}},
+		})
+		assert.ErrorContains(t, err, "Unknown capability")
+		assert.Equal(t, 0, len(downgrades))
+	})
+
+	t.Run("unknown data type", func(t *testing.T) {
+		downgrades = nil
+		_, err := c.RawSend(&jmap.Request{
+			Using: []string{jmap.CoreCapabilityName, jmap.SMIMEVerifyCapabilityName},
+			Calls: []jmap.Invocation{{Name: "Unmapped/get", CallID: "0", Args: map[string]interface{}{}}},
+		})
+		assert.ErrorContains(t, err, "Unknown capability")
+		assert.Equal(t, 0, len(downgrades))
+	})
+}
+
+func TestRecordedProblems(t *testing.T) {
+	srv := jmaptest.NewServer(nil)
+	defer srv.Close()
+	c, err := NewWithClient(srv.Client(), srv.SessionURL(), "")
+	assert.NilError(t, err)
+
+	for _, rec := range jmaptest.Problems {
+		rec := rec
+		t.Run(rec.Name, func(t *testing.T) {
+			var expected map[string]interface{}
+			assert.NilError(t, json.Unmarshal(rec.Body, &expected))
+
+			srv.Replay(rec)
+			err := c.Echo()
+			reqErr, ok := err.(jmap.RequestError)
+			assert.Assert(t, ok, "unexpected error: %#v", err)
+			assert.Equal(t, jmap.ErrorCode(expected["type"].(string)), reqErr.Type)
+			assert.Equal(t, rec.StatusCode, reqErr.Status)
+			assert.Assert(t, reqErr.Error() != "")
+			if limit, ok := expected["limit"]; ok {
+				assert.Equal(t, limit, reqErr.Properties["limit"])
+			}
+		})
+	}
+}
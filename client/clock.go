@@ -0,0 +1,40 @@
+package client
+
+import (
+	"time"
+)
+
+// Clock is the source of current time and timers used by time-dependent
+// logic (journal timestamps, schedulers, polling, backoff).
+//
+// It allows tests to control time instead of relying on real sleeps. See
+// jmaptest.FakeClock.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+
+	// After waits for the duration to elapse and then sends the current
+	// time on the returned channel.
+	After(d time.Duration) <-chan time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// SystemClock is the Clock implementation that uses the time package.
+var SystemClock Clock = systemClock{}
+
+// ClockOrSystem returns c if it is not nil and SystemClock otherwise.
+func ClockOrSystem(c Clock) Clock {
+	if c == nil {
+		return SystemClock
+	}
+	return c
+}
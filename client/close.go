@@ -0,0 +1,150 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+// ErrClientClosed is returned by methods that send requests to the server
+// after Client.Close is called. Requests in flight at the time of the Close
+// call fail with this error too.
+var ErrClientClosed = errors.New("jmap/client: client is closed")
+
+// requestContext returns the context that is canceled when the Client is
+// closed. It should be used for all HTTP requests.
+func (c *Client) requestContext() (context.Context, error) {
+	c.closeLck.Lock()
+	defer c.closeLck.Unlock()
+	if c.closed {
+		return nil, ErrClientClosed
+	}
+	if c.closeCtx == nil {
+		c.closeCtx, c.closeCancel = context.WithCancel(context.Background())
+	}
+	return c.closeCtx, nil
+}
+
+// BoundContext returns a copy of parent that is also canceled when the
+// Client is closed, for long-running loops that are driven by the caller
+// (e.g. mail.Outbox.Run). context.Cause of the returned context is
+// ErrClientClosed in that case.
+//
+// The returned context is canceled immediately if the Client is closed
+// already. The cancel function must be called to release resources.
+func (c *Client) BoundContext(parent context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancelCause(parent)
+	closeCtx, err := c.requestContext()
+	if err != nil {
+		cancel(err)
+		return ctx, func() { cancel(context.Canceled) }
+	}
+	stop := context.AfterFunc(closeCtx, func() { cancel(ErrClientClosed) })
+	return ctx, func() {
+		stop()
+		cancel(context.Canceled)
+	}
+}
+
+// Closed reports whether Close was called.
+func (c *Client) Closed() bool {
+	c.closeLck.Lock()
+	defer c.closeLck.Unlock()
+	return c.closed
+}
+
+// do sends the HTTP request using HTTPClient (http.DefaultClient if it is
+// nil), canceling it if the Client is closed.
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	ctx, err := c.requestContext()
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.httpClient().Do(req.WithContext(ctx))
+	if err != nil && ctx.Err() != nil {
+		return nil, ErrClientClosed
+	}
+	return resp, err
+}
+
+func (c *Client) httpClient() *http.Client {
+	if c.HTTPClient == nil {
+		return http.DefaultClient
+	}
+	return c.HTTPClient
+}
+
+// RunBackground runs the function in a new goroutine bound to the lifetime
+// of the Client: when the Client is closed, stop is called and Close waits
+// for run to return. stop must make run return promptly.
+//
+// It is used by Watch and OpenEventSource and can be used by other helpers
+// that keep working in background (e.g. mail.WatchDelivery). Loops running
+// in the caller's goroutine should use BoundContext instead.
+//
+// ErrClientClosed is returned and run is not started if the Client is
+// closed already.
+func (c *Client) RunBackground(run func(), stop func()) error {
+	c.closeLck.Lock()
+	defer c.closeLck.Unlock()
+	if c.closed {
+		return ErrClientClosed
+	}
+	if c.background == nil {
+		c.background = make(map[int]func())
+	}
+	key := c.nextBackground
+	c.nextBackground++
+	c.background[key] = stop
+	c.backgroundWG.Add(1)
+
+	go func() {
+		defer c.backgroundWG.Done()
+		defer func() {
+			c.closeLck.Lock()
+			delete(c.background, key)
+			c.closeLck.Unlock()
+		}()
+		run()
+	}()
+	return nil
+}
+
+// Close shuts down the Client:
+//   - requests in flight are canceled and new ones fail with
+//     ErrClientClosed,
+//   - Watchers, EventSources and other background work started using
+//     RunBackground are stopped, Close waits for them to finish,
+//   - idle connections of HTTPClient are closed.
+//
+// Clones of the Client are not affected, except that they share HTTPClient
+// and its idle connections. Calling Close more than once has no effect.
+func (c *Client) Close() error {
+	c.closeLck.Lock()
+	if c.closed {
+		c.closeLck.Unlock()
+		return nil
+	}
+	c.closed = true
+	stops := make([]func(), 0, len(c.background))
+	for _, stop := range c.background {
+		stops = append(stops, stop)
+	}
+	c.closeLck.Unlock()
+
+	// Stop background work first so it does not report errors caused by
+	// canceled requests.
+	for _, stop := range stops {
+		stop()
+	}
+	if c.closeCancel != nil {
+		c.closeCancel()
+	}
+	c.backgroundWG.Wait()
+
+	if c.HTTPClient != nil {
+		c.HTTPClient.CloseIdleConnections()
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package client
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gotest.tools/assert"
+	"gotest.tools/assert/cmp"
+)
+
+func TestClientClose(t *testing.T) {
+	inFlight := make(chan struct{}, 4)
+	c, srv := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		// The server notices the disconnect only after the body is read.
+		ioutil.ReadAll(r.Body) //nolint:errcheck
+		inFlight <- struct{}{}
+		<-r.Context().Done()
+	})
+	defer srv.Close()
+
+	esSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer esSrv.Close()
+
+	_, err := c.CurrentSession()
+	assert.NilError(t, err)
+	c.Session.EventSourceURL = esSrv.URL
+
+	es, err := c.OpenEventSource(context.Background(), EventSourceOptions{})
+	assert.NilError(t, err)
+
+	w := c.Watch("A1", "Mailbox", WatchOptions{SinceState: "s1"})
+	<-inFlight
+
+	echoErr := make(chan error, 1)
+	go func() {
+		echoErr <- c.Echo()
+	}()
+	<-inFlight
+
+	assert.NilError(t, c.Close())
+
+	// In-flight requests are canceled.
+	assert.Check(t, cmp.Equal(ErrClientClosed, <-echoErr))
+
+	// Background work is stopped by the time Close returns.
+	select {
+	case _, ok := <-w.C():
+		assert.Check(t, !ok)
+	default:
+		t.Error("watcher is still running")
+	}
+	assert.Check(t, w.Err())
+	_, err = es.Next(context.Background())
+	assert.Check(t, cmp.Equal(io.EOF, err))
+
+	assert.Check(t, cmp.Equal(ErrClientClosed, c.Echo()))
+	_, err = c.UpdateSession()
+	assert.Check(t, cmp.Equal(ErrClientClosed, err))
+	_, err = c.OpenEventSource(context.Background(), EventSourceOptions{})
+	assert.Check(t, cmp.Equal(ErrClientClosed, err))
+	w = c.Watch("A1", "Mailbox", WatchOptions{SinceState: "s1"})
+	_, ok := <-w.C()
+	assert.Check(t, !ok)
+	assert.Check(t, cmp.Equal(ErrClientClosed, w.Err()))
+
+	assert.NilError(t, c.Close())
+
+	// Clones are independent.
+	clone := c.Clone()
+	go func() {
+		echoErr <- clone.Echo()
+	}()
+	<-inFlight
+	assert.NilError(t, clone.Close())
+	assert.Check(t, cmp.Equal(ErrClientClosed, <-echoErr))
+}
+
+func TestBoundContext(t *testing.T) {
+	c := &Client{}
+
+	ctx, cancel := c.BoundContext(context.Background())
+	cancel()
+	assert.Check(t, cmp.Equal(context.Canceled, context.Cause(ctx)))
+
+	ctx, cancel = c.BoundContext(context.Background())
+	defer cancel()
+	assert.NilError(t, c.Close())
+	<-ctx.Done()
+	assert.Check(t, cmp.Equal(ErrClientClosed, context.Cause(ctx)))
+
+	ctx, cancel = c.BoundContext(context.Background())
+	defer cancel()
+	assert.Check(t, cmp.Equal(ErrClientClosed, context.Cause(ctx)))
+}
@@ -0,0 +1,100 @@
+package client
+
+import (
+	"sync"
+	"time"
+
+	"github.com/foxcpp/go-jmap"
+)
+
+// Coalescer merges bursts of StateChange events into a single event.
+//
+// The first event added after an idle period starts the window, all events
+// added until it elapses are merged (latest state for each account and type
+// wins) and delivered as one StateChange on the channel returned by C.
+//
+// If the consumer does not read the previous event before the next window
+// elapses, they are merged too, so at most one event is ever buffered.
+type Coalescer struct {
+	window time.Duration
+	clock  Clock
+
+	lck     sync.Mutex
+	pending *jmap.StateChange
+	out     chan jmap.StateChange
+	stop    chan struct{}
+	stopped bool
+}
+
+// NewCoalescer creates the Coalescer with the specified window. If clock is
+// nil, SystemClock is used.
+func NewCoalescer(window time.Duration, clock Clock) *Coalescer {
+	return &Coalescer{
+		window: window,
+		clock:  ClockOrSystem(clock),
+		out:    make(chan jmap.StateChange, 1),
+		stop:   make(chan struct{}),
+	}
+}
+
+// Add adds the event to the current window, starting a new one if
+// necessary.
+func (co *Coalescer) Add(sc jmap.StateChange) {
+	co.lck.Lock()
+	defer co.lck.Unlock()
+	if co.stopped {
+		return
+	}
+	if co.pending != nil {
+		co.pending.Merge(sc)
+		return
+	}
+
+	co.pending = &jmap.StateChange{}
+	co.pending.Merge(sc)
+	go co.flushAfter(co.clock.After(co.window))
+}
+
+func (co *Coalescer) flushAfter(timer <-chan time.Time) {
+	select {
+	case <-timer:
+	case <-co.stop:
+		return
+	}
+
+	co.lck.Lock()
+	defer co.lck.Unlock()
+	if co.stopped {
+		return
+	}
+	sc := *co.pending
+	co.pending = nil
+
+	// Only this goroutine sends and only while holding the lock, so the send
+	// below never blocks.
+	select {
+	case prev := <-co.out:
+		prev.Merge(sc)
+		sc = prev
+	default:
+	}
+	co.out <- sc
+}
+
+// C returns the channel on which merged events are delivered.
+func (co *Coalescer) C() <-chan jmap.StateChange {
+	return co.out
+}
+
+// Stop discards pending events and stops the Coalescer. Events added after
+// Stop are ignored.
+func (co *Coalescer) Stop() {
+	co.lck.Lock()
+	defer co.lck.Unlock()
+	if co.stopped {
+		return
+	}
+	co.stopped = true
+	co.pending = nil
+	close(co.stop)
+}
@@ -0,0 +1,69 @@
+package client
+
+import (
+	"testing"
+	"time"
+
+	"github.com/foxcpp/go-jmap"
+	"github.com/foxcpp/go-jmap/jmaptest"
+	"gotest.tools/assert"
+	"gotest.tools/assert/cmp"
+)
+
+func receive(t *testing.T, co *Coalescer) jmap.StateChange {
+	t.Helper()
+	select {
+	case sc := <-co.C():
+		return sc
+	case <-time.After(time.Second):
+		t.Fatal("no event delivered")
+		return jmap.StateChange{}
+	}
+}
+
+func (co *Coalescer) hasPending() bool {
+	co.lck.Lock()
+	defer co.lck.Unlock()
+	return co.pending != nil
+}
+
+func TestCoalescer(t *testing.T) {
+	clock := jmaptest.NewFakeClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	co := NewCoalescer(time.Second, clock)
+	defer co.Stop()
+
+	co.Add(stateChange("A1", "Email", "1"))
+	co.Add(stateChange("A1", "Mailbox", "2"))
+	co.Add(stateChange("A1", "Email", "3"))
+	co.Add(stateChange("A2", "Email", "4"))
+	assert.Check(t, cmp.Equal(1, clock.Waiters()))
+
+	select {
+	case <-co.C():
+		t.Fatal("event delivered before the window elapsed")
+	default:
+	}
+
+	clock.Advance(time.Second)
+	assert.Check(t, cmp.DeepEqual(jmap.StateChange{Changed: map[jmap.ID]map[string]string{
+		"A1": {"Email": "3", "Mailbox": "2"},
+		"A2": {"Email": "4"},
+	}}, receive(t, co)))
+
+	t.Run("slow consumer", func(t *testing.T) {
+		co.Add(stateChange("A1", "Email", "5"))
+		clock.Advance(time.Second)
+		for len(co.out) == 0 {
+			time.Sleep(time.Millisecond)
+		}
+		co.Add(stateChange("A1", "Thread", "6"))
+		clock.Advance(time.Second)
+		for co.hasPending() {
+			time.Sleep(time.Millisecond)
+		}
+
+		assert.Check(t, cmp.DeepEqual(jmap.StateChange{Changed: map[jmap.ID]map[string]string{
+			"A1": {"Email": "5", "Thread": "6"},
+		}}, receive(t, co)))
+	})
+}
@@ -0,0 +1,116 @@
+package client
+
+import (
+	"strings"
+
+	"github.com/foxcpp/go-jmap"
+)
+
+// CapabilityDowngrade describes the capability removed from the request
+// after the server rejected it with unknownCapability error.
+type CapabilityDowngrade struct {
+	// The removed capability URN.
+	Capability string
+
+	// Names of method calls in the request.
+	Methods []string
+
+	// The error returned by the server.
+	Err jmap.RequestError
+}
+
+// EnableMethodCapabilities adds mapping of data type names (the part of the
+// method name before "/", e.g. "Email") to capabilities that define them.
+//
+// It is used by capability downgrade (see Client.OnCapabilityDowngrade) to
+// determine whether the capability is strictly needed by the request.
+// Methods of the "Core" and "PushSubscription" types always require
+// urn:ietf:params:jmap:core. Calls of types without a mapping prevent the
+// downgrade.
+//
+// This method must not be called when there is running requests.
+func (c *Client) EnableMethodCapabilities(capabilities map[string]string) {
+	if c.methodCaps == nil {
+		c.methodCaps = make(map[string]string, len(capabilities))
+	}
+	for k, v := range capabilities {
+		c.methodCaps[k] = v
+	}
+}
+
+// requiredCapability reports whether any call in the request belongs to the
+// data type defined by the capability.
+//
+// Calls of data types without a known capability are assumed to require
+// any capability since it can't be determined which one defines them.
+func (c *Client) requiredCapability(r *jmap.Request, capability string) bool {
+	if capability == jmap.CoreCapabilityName {
+		return true
+	}
+	for _, call := range r.Calls {
+		typeName := call.Name
+		if slash := strings.IndexByte(typeName, '/'); slash != -1 {
+			typeName = typeName[:slash]
+		}
+		if typeName == "Core" || typeName == "PushSubscription" {
+			continue
+		}
+		typeCap, ok := c.methodCaps[typeName]
+		if !ok || typeCap == capability {
+			return true
+		}
+	}
+	return false
+}
+
+// offendingCapability returns the capability the server did not recognize
+// or empty string if it can't be determined.
+//
+// The capability is looked up in the error detail first (servers usually
+// mention it there) and then among capabilities not advertised in the
+// Session object.
+func offendingCapability(r *jmap.Request, session *jmap.Session, reqErr jmap.RequestError) string {
+	for _, capability := range r.Using {
+		if strings.Contains(reqErr.Detail, capability) {
+			return capability
+		}
+	}
+	for _, capability := range r.Using {
+		if _, ok := session.Capabilities[capability]; !ok {
+			return capability
+		}
+	}
+	return ""
+}
+
+// downgrade returns the copy of the request without the capability rejected
+// by the server if the request can be retried without it.
+func (c *Client) downgrade(r *jmap.Request, session *jmap.Session, err error) (*jmap.Request, bool) {
+	reqErr, ok := err.(jmap.RequestError)
+	if !ok || reqErr.Type != jmap.ProblemPrefix+jmap.CodeUnknownCapability {
+		return nil, false
+	}
+	capability := offendingCapability(r, session, reqErr)
+	if capability == "" || c.requiredCapability(r, capability) {
+		return nil, false
+	}
+
+	downgraded := *r
+	downgraded.Using = make([]string, 0, len(r.Using)-1)
+	for _, u := range r.Using {
+		if u != capability {
+			downgraded.Using = append(downgraded.Using, u)
+		}
+	}
+
+	methods := make([]string, 0, len(r.Calls))
+	for _, call := range r.Calls {
+		methods = append(methods, call.Name)
+	}
+	c.OnCapabilityDowngrade(CapabilityDowngrade{
+		Capability: capability,
+		Methods:    methods,
+		Err:        reqErr,
+	})
+	return &downgraded, true
+}
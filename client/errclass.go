@@ -0,0 +1,218 @@
+package client
+
+import (
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/foxcpp/go-jmap"
+)
+
+// ErrorCategory is a coarse classification of errors useful for choosing
+// what to tell the user.
+type ErrorCategory int
+
+const (
+	// The error can't be classified.
+	CategoryUnknown ErrorCategory = iota
+
+	// Authentication failed or the user lacks permissions for the operation.
+	CategoryAuth
+
+	// Some server-defined limit on the amount of data or rate of requests
+	// was reached.
+	CategoryQuota
+
+	// The operation conflicts with the current server state, usually local
+	// state should be resynchronized.
+	CategoryConflict
+
+	// Temporary failure, the operation may succeed if tried again later.
+	CategoryTransient
+
+	// The operation can't be completed and retrying will not help.
+	CategoryPermanent
+
+	// The request was malformed or used features not supported by the
+	// server. Likely a bug in the application or library.
+	CategoryClientBug
+
+	// The operation was interrupted because the Client was closed. It was
+	// not necessarily started, retry it using a new Client if needed.
+	CategoryClosed
+)
+
+func (c ErrorCategory) String() string {
+	switch c {
+	case CategoryAuth:
+		return "auth"
+	case CategoryQuota:
+		return "quota"
+	case CategoryConflict:
+		return "conflict"
+	case CategoryTransient:
+		return "transient"
+	case CategoryPermanent:
+		return "permanent"
+	case CategoryClientBug:
+		return "client-bug"
+	case CategoryClosed:
+		return "closed"
+	}
+	return "unknown"
+}
+
+// ErrorClass is the result of error classification.
+type ErrorClass struct {
+	Category ErrorCategory
+
+	// Whether the same operation may succeed if retried (possibly after
+	// resynchronizing state for CategoryConflict).
+	Retryable bool
+
+	// JMAP error code, if any.
+	Code jmap.ErrorCode
+}
+
+var codeClasses = map[jmap.ErrorCode]ErrorClass{
+	jmap.CodeAccountNotFound:                 {Category: CategoryAuth},
+	jmap.CodeAccountNotSupportedByMethod:     {Category: CategoryAuth},
+	jmap.CodeAccountReadOnly:                 {Category: CategoryAuth},
+	jmap.CodeForbidden:                       {Category: CategoryAuth},
+	jmap.CodeFromAccountNotFound:             {Category: CategoryAuth},
+	jmap.CodeFromAccountNotSupportedByMethod: {Category: CategoryAuth},
+	jmap.CodeForbiddenMailFrom:               {Category: CategoryAuth},
+	jmap.CodeForbiddenFrom:                   {Category: CategoryAuth},
+	jmap.CodeForbiddenToSend:                 {Category: CategoryAuth},
+
+	jmap.CodeOverQuota:         {Category: CategoryQuota},
+	jmap.CodeTooLarge:          {Category: CategoryQuota},
+	jmap.CodeRateLimit:         {Category: CategoryQuota, Retryable: true},
+	jmap.CodeTooManyKeywords:   {Category: CategoryQuota},
+	jmap.CodeTooManyMailboxes:  {Category: CategoryQuota},
+	jmap.CodeTooManyRecipients: {Category: CategoryQuota},
+
+	jmap.CodeStateMismatch:          {Category: CategoryConflict, Retryable: true},
+	jmap.CodeCannotCalculateChanges: {Category: CategoryConflict, Retryable: true},
+	jmap.CodeServerPartialFail:      {Category: CategoryConflict, Retryable: true},
+	jmap.CodeAnchorNotFound:         {Category: CategoryConflict, Retryable: true},
+	jmap.CodeAlreadyExists:          {Category: CategoryConflict},
+	jmap.CodeWillDestroy:            {Category: CategoryConflict},
+	jmap.CodeMailboxHasChild:        {Category: CategoryConflict},
+	jmap.CodeMailboxHasEmail:        {Category: CategoryConflict},
+
+	jmap.CodeServerUnavailable: {Category: CategoryTransient, Retryable: true},
+	jmap.CodeServerFail:        {Category: CategoryTransient, Retryable: true},
+
+	jmap.CodeNotFound:          {Category: CategoryPermanent},
+	jmap.CodeBlobNotFound:      {Category: CategoryPermanent},
+	jmap.CodeNoRecipients:      {Category: CategoryPermanent},
+	jmap.CodeInvalidRecipients: {Category: CategoryPermanent},
+
+	jmap.CodeUnknownCapability:      {Category: CategoryClientBug},
+	jmap.CodeNotJSON:                {Category: CategoryClientBug},
+	jmap.CodeNotRequest:             {Category: CategoryClientBug},
+	jmap.CodeUnknownMethod:          {Category: CategoryClientBug},
+	jmap.CodeInvalidArguments:       {Category: CategoryClientBug},
+	jmap.CodeInvalidResultReference: {Category: CategoryClientBug},
+	jmap.CodeInvalidPatch:           {Category: CategoryClientBug},
+	jmap.CodeInvalidProperties:      {Category: CategoryClientBug},
+	jmap.CodeRequestTooLarge:        {Category: CategoryClientBug},
+	jmap.CodeTooManyChanges:         {Category: CategoryClientBug},
+	jmap.CodeUnsupportedFilter:      {Category: CategoryClientBug},
+	jmap.CodeUnsupportedSort:        {Category: CategoryClientBug},
+	jmap.CodeSingleton:              {Category: CategoryClientBug},
+	jmap.CodeInvalidEmail:           {Category: CategoryClientBug},
+	"limit":                         {Category: CategoryClientBug},
+}
+
+func classifyCode(code jmap.ErrorCode) ErrorClass {
+	code = jmap.ErrorCode(strings.TrimPrefix(string(code), string(jmap.ProblemPrefix)))
+	class, ok := codeClasses[code]
+	if !ok {
+		class.Category = CategoryUnknown
+	}
+	class.Code = code
+	return class
+}
+
+func classifyStatus(status int) ErrorClass {
+	switch {
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return ErrorClass{Category: CategoryAuth}
+	case status == http.StatusTooManyRequests:
+		return ErrorClass{Category: CategoryQuota, Retryable: true}
+	case status == http.StatusRequestEntityTooLarge || status == http.StatusBadRequest:
+		return ErrorClass{Category: CategoryClientBug}
+	case status == http.StatusNotImplemented:
+		return ErrorClass{Category: CategoryPermanent}
+	case status/100 == 5:
+		return ErrorClass{Category: CategoryTransient, Retryable: true}
+	case status/100 == 4:
+		return ErrorClass{Category: CategoryPermanent}
+	}
+	return ErrorClass{Category: CategoryUnknown}
+}
+
+// Classify maps an error returned by Client methods or found in method
+// responses (jmap.MethodErrorArgs, jmap.SetError) to ErrorClass.
+//
+// Errors wrapping these (e.g. StateMismatchError) are classified according
+// to the wrapped error.
+//
+// It is intended to help applications build consistent user-facing
+// messages and retry policies.
+func Classify(err error) ErrorClass {
+	if err == nil {
+		return ErrorClass{}
+	}
+
+	var (
+		methodErr    jmap.MethodErrorArgs
+		methodErrPtr *jmap.MethodErrorArgs
+		setErr       jmap.SetError
+		setErrPtr    *jmap.SetError
+		reqErr       jmap.RequestError
+		reqErrPtr    *jmap.RequestError
+		httpErr      HTTPError
+		httpErrPtr   *HTTPError
+		netErr       net.Error
+	)
+	switch {
+	case errors.As(err, &methodErr):
+		return classifyCode(methodErr.Type)
+	case errors.As(err, &methodErrPtr):
+		return classifyCode(methodErrPtr.Type)
+	case errors.As(err, &setErr):
+		return classifyCode(setErr.Type)
+	case errors.As(err, &setErrPtr):
+		return classifyCode(setErrPtr.Type)
+	case errors.As(err, &reqErr):
+		return classifyRequestError(reqErr)
+	case errors.As(err, &reqErrPtr):
+		return classifyRequestError(*reqErrPtr)
+	case errors.As(err, &httpErr):
+		return classifyStatus(httpErr.StatusCode)
+	case errors.As(err, &httpErrPtr):
+		return classifyStatus(httpErrPtr.StatusCode)
+	case errors.Is(err, ErrClientClosed):
+		return ErrorClass{Category: CategoryClosed}
+	case errors.As(err, &netErr):
+		return ErrorClass{Category: CategoryTransient, Retryable: true}
+	case errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF):
+		return ErrorClass{Category: CategoryTransient, Retryable: true}
+	}
+	return ErrorClass{Category: CategoryUnknown}
+}
+
+func classifyRequestError(err jmap.RequestError) ErrorClass {
+	class := classifyCode(err.Type)
+	if class.Category == CategoryUnknown && err.Status != 0 {
+		code := class.Code
+		class = classifyStatus(err.Status)
+		class.Code = code
+	}
+	return class
+}
@@ -0,0 +1,62 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/foxcpp/go-jmap"
+	"gotest.tools/assert"
+	"gotest.tools/assert/cmp"
+)
+
+func TestClassify(t *testing.T) {
+	cases := []struct {
+		err      error
+		expected ErrorClass
+	}{
+		{nil, ErrorClass{}},
+		{HTTPError{StatusCode: 401}, ErrorClass{Category: CategoryAuth}},
+		{HTTPError{StatusCode: 503}, ErrorClass{Category: CategoryTransient, Retryable: true}},
+		{
+			jmap.RequestError{Type: jmap.ProblemPrefix + "limit", Status: 400},
+			ErrorClass{Category: CategoryClientBug, Code: "limit"},
+		},
+		{
+			jmap.RequestError{Type: "https://example.com/problem", Status: 429},
+			ErrorClass{Category: CategoryQuota, Retryable: true, Code: "https://example.com/problem"},
+		},
+		{
+			jmap.MethodErrorArgs{Type: jmap.CodeStateMismatch},
+			ErrorClass{Category: CategoryConflict, Retryable: true, Code: jmap.CodeStateMismatch},
+		},
+		{
+			jmap.SetError{Type: jmap.CodeOverQuota},
+			ErrorClass{Category: CategoryQuota, Code: jmap.CodeOverQuota},
+		},
+		{
+			&jmap.SetError{Type: "vendorSpecific"},
+			ErrorClass{Category: CategoryUnknown, Code: "vendorSpecific"},
+		},
+		{
+			jmap.MethodErrorArgs{Type: jmap.CodeServerFail},
+			ErrorClass{Category: CategoryTransient, Retryable: true, Code: jmap.CodeServerFail},
+		},
+		{
+			fmt.Errorf("cancel: %w", jmap.SetError{Type: jmap.CodeNotFound}),
+			ErrorClass{Category: CategoryPermanent, Code: jmap.CodeNotFound},
+		},
+		{
+			&StateMismatchError{Method: "Email/set", Err: jmap.MethodErrorArgs{Type: jmap.CodeStateMismatch}},
+			ErrorClass{Category: CategoryConflict, Retryable: true, Code: jmap.CodeStateMismatch},
+		},
+		{fmt.Errorf("get: %w", HTTPError{StatusCode: 403}), ErrorClass{Category: CategoryAuth}},
+		{ErrClientClosed, ErrorClass{Category: CategoryClosed}},
+		{fmt.Errorf("send: %w", ErrClientClosed), ErrorClass{Category: CategoryClosed}},
+		{errors.New("something"), ErrorClass{Category: CategoryUnknown}},
+	}
+
+	for _, c := range cases {
+		assert.Check(t, cmp.DeepEqual(c.expected, Classify(c.err)), "%v", c.err)
+	}
+}
@@ -0,0 +1,143 @@
+package client
+
+import (
+	"context"
+	"sync"
+
+	"github.com/foxcpp/go-jmap"
+)
+
+// BufferPolicy controls what happens when push events arrive faster than
+// they are consumed and the event buffer is full.
+type BufferPolicy int
+
+const (
+	// Stop reading from the connection until there is space in the buffer.
+	// No events are lost, TCP flow control slows down the server.
+	BufferBlock BufferPolicy = iota
+
+	// Discard the oldest buffered event to make space for the new one.
+	BufferDropOldest
+
+	// Merge the new StateChange into the newest buffered one. Since
+	// StateChange carries only the latest state per account and type,
+	// consumers still learn about all changed types, but fewer events are
+	// delivered.
+	BufferCoalesce
+)
+
+const defaultEventBufferSize = 16
+
+// eventBuffer is the bounded queue of StateChange objects.
+type eventBuffer struct {
+	policy BufferPolicy
+	size   int
+
+	lck     sync.Mutex
+	queue   []jmap.StateChange
+	dropped int64
+	err     error
+
+	// Signalled (non-blocking) when an event is added or the buffer is
+	// closed, and when an event is removed respectively.
+	avail chan struct{}
+	space chan struct{}
+}
+
+func newEventBuffer(size int, policy BufferPolicy) *eventBuffer {
+	if size <= 0 {
+		size = defaultEventBufferSize
+	}
+	return &eventBuffer{
+		policy: policy,
+		size:   size,
+		avail:  make(chan struct{}, 1),
+		space:  make(chan struct{}, 1),
+	}
+}
+
+func signal(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
+
+// push adds the event to the buffer, applying the policy if it is full.
+// For BufferBlock, it waits until there is space or done is closed.
+func (eb *eventBuffer) push(sc jmap.StateChange, done <-chan struct{}) bool {
+	eb.lck.Lock()
+	for len(eb.queue) >= eb.size {
+		switch eb.policy {
+		case BufferDropOldest:
+			eb.queue = eb.queue[1:]
+			eb.dropped++
+		case BufferCoalesce:
+			eb.queue[len(eb.queue)-1].Merge(sc)
+			eb.dropped++
+			eb.lck.Unlock()
+			signal(eb.avail)
+			return true
+		default:
+			eb.lck.Unlock()
+			select {
+			case <-eb.space:
+			case <-done:
+				return false
+			}
+			eb.lck.Lock()
+		}
+	}
+	eb.queue = append(eb.queue, sc)
+	eb.lck.Unlock()
+	signal(eb.avail)
+	return true
+}
+
+// pop removes the oldest event from the buffer, waiting for it if
+// necessary. Buffered events are returned even after close, the close
+// error is returned once the buffer is empty.
+func (eb *eventBuffer) pop(ctx context.Context) (jmap.StateChange, error) {
+	for {
+		eb.lck.Lock()
+		if len(eb.queue) != 0 {
+			sc := eb.queue[0]
+			eb.queue[0] = jmap.StateChange{}
+			eb.queue = eb.queue[1:]
+			more := len(eb.queue) != 0
+			eb.lck.Unlock()
+			signal(eb.space)
+			if more {
+				signal(eb.avail)
+			}
+			return sc, nil
+		}
+		if eb.err != nil {
+			err := eb.err
+			eb.lck.Unlock()
+			return jmap.StateChange{}, err
+		}
+		eb.lck.Unlock()
+
+		select {
+		case <-eb.avail:
+		case <-ctx.Done():
+			return jmap.StateChange{}, ctx.Err()
+		}
+	}
+}
+
+func (eb *eventBuffer) close(err error) {
+	eb.lck.Lock()
+	if eb.err == nil {
+		eb.err = err
+	}
+	eb.lck.Unlock()
+	signal(eb.avail)
+}
+
+func (eb *eventBuffer) droppedCount() int64 {
+	eb.lck.Lock()
+	defer eb.lck.Unlock()
+	return eb.dropped
+}
@@ -0,0 +1,211 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/foxcpp/go-jmap"
+)
+
+var ErrNoEventSource = errors.New("jmap/client: server does not provide eventSourceUrl")
+
+// EventSourceOptions contains parameters for the push connection.
+type EventSourceOptions struct {
+	// Data types to receive changes for, see jmap.PushType* constants. Nil
+	// means all types.
+	//
+	// OpenEventSource fails if the server does not support capabilities of
+	// known types, see jmap.Session.ValidatePushTypes.
+	Types []string
+
+	// If true, the server closes the connection after the first state
+	// event, which is useful for long-polling.
+	CloseAfterState bool
+
+	// Interval for server pings, used to keep the connection alive through
+	// proxies. Zero disables pings.
+	Ping time.Duration
+
+	// Maximum number of events kept in memory if they are not consumed fast
+	// enough. Default is 16.
+	BufferSize int
+
+	// What to do when the buffer is full.
+	Policy BufferPolicy
+}
+
+// EventSource is the connection to the server's push endpoint using the
+// text/event-stream format, as described in draft-ietf-jmap-core-17,
+// section 7.3.
+type EventSource struct {
+	body      io.ReadCloser
+	buf       *eventBuffer
+	done      chan struct{}
+	cancel    context.CancelFunc
+	closeOnce sync.Once
+}
+
+// OpenEventSource connects to the server's eventSourceUrl and starts
+// receiving StateChange events in background.
+//
+// The connection is closed if ctx is canceled or the Client is closed.
+func (c *Client) OpenEventSource(ctx context.Context, opts EventSourceOptions) (*EventSource, error) {
+	if c.readOnly {
+		return nil, ErrReadOnly
+	}
+	session, err := c.lazyInitSession()
+	if err != nil {
+		return nil, err
+	}
+	if session.EventSourceURL == "" {
+		return nil, ErrNoEventSource
+	}
+	if err := session.ValidatePushTypes(opts.Types); err != nil {
+		return nil, err
+	}
+
+	types := "*"
+	if opts.Types != nil {
+		types = strings.Join(opts.Types, ",")
+	}
+	closeAfter := "no"
+	if opts.CloseAfterState {
+		closeAfter = "state"
+	}
+	urlRepl := strings.NewReplacer(
+		"{types}", types,
+		"{closeafter}", closeAfter,
+		"{ping}", strconv.Itoa(int(opts.Ping/time.Second)),
+	)
+
+	req, err := http.NewRequest("GET", urlRepl.Replace(session.EventSourceURL), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("Authentication", c.Authentication)
+
+	// The stream is bound to both ctx and the Client lifetime.
+	closeCtx, err := c.requestContext()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	go func() {
+		select {
+		case <-closeCtx.Done():
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	resp, err := c.httpClient().Do(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		if closeCtx.Err() != nil {
+			return nil, ErrClientClosed
+		}
+		return nil, err
+	}
+	if resp.StatusCode/100 != 2 {
+		defer cancel()
+		defer resp.Body.Close()
+		return nil, decodeError(resp)
+	}
+
+	es := &EventSource{
+		body:   resp.Body,
+		buf:    newEventBuffer(opts.BufferSize, opts.Policy),
+		done:   make(chan struct{}),
+		cancel: cancel,
+	}
+	if err := c.RunBackground(es.readLoop, func() { es.Close() }); err != nil {
+		es.Close()
+		return nil, err
+	}
+	return es, nil
+}
+
+func (es *EventSource) readLoop() {
+	r := bufio.NewReader(es.body)
+	var (
+		event string
+		data  strings.Builder
+	)
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			es.buf.close(err)
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+
+		if line == "" {
+			if event == "state" {
+				var sc jmap.StateChange
+				if err := json.Unmarshal([]byte(data.String()), &sc); err != nil {
+					es.buf.close(err)
+					return
+				}
+				if !es.buf.push(sc, es.done) {
+					return
+				}
+			}
+			event = ""
+			data.Reset()
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			// Comment.
+			continue
+		}
+
+		field, value := line, ""
+		if idx := strings.IndexByte(line, ':'); idx != -1 {
+			field, value = line[:idx], strings.TrimPrefix(line[idx+1:], " ")
+		}
+		switch field {
+		case "event":
+			event = value
+		case "data":
+			if data.Len() != 0 {
+				data.WriteByte('\n')
+			}
+			data.WriteString(value)
+		}
+	}
+}
+
+// Next returns the next StateChange, waiting for it if necessary.
+//
+// Once the connection is closed and all buffered events are consumed, the
+// error that terminated the connection is returned (io.EOF if the server
+// closed it).
+func (es *EventSource) Next(ctx context.Context) (jmap.StateChange, error) {
+	return es.buf.pop(ctx)
+}
+
+// Dropped returns the number of events discarded or merged due to the
+// buffer policy.
+func (es *EventSource) Dropped() int64 {
+	return es.buf.droppedCount()
+}
+
+// Close terminates the connection.
+func (es *EventSource) Close() error {
+	var err error
+	es.closeOnce.Do(func() {
+		close(es.done)
+		err = es.body.Close()
+		es.cancel()
+		es.buf.close(io.EOF)
+	})
+	return err
+}
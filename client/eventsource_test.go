@@ -0,0 +1,133 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/foxcpp/go-jmap"
+	"gotest.tools/assert"
+	"gotest.tools/assert/cmp"
+)
+
+func stateChange(account jmap.ID, typ, state string) jmap.StateChange {
+	return jmap.StateChange{Changed: map[jmap.ID]map[string]string{
+		account: {typ: state},
+	}}
+}
+
+func TestEventBuffer(t *testing.T) {
+	t.Run("drop oldest", func(t *testing.T) {
+		eb := newEventBuffer(2, BufferDropOldest)
+		for i := 1; i <= 4; i++ {
+			assert.Check(t, eb.push(stateChange("A1", "Email", fmt.Sprint(i)), nil))
+		}
+		eb.close(io.EOF)
+
+		sc, err := eb.pop(context.Background())
+		assert.NilError(t, err)
+		assert.Check(t, cmp.Equal("3", sc.Changed["A1"]["Email"]))
+		sc, err = eb.pop(context.Background())
+		assert.NilError(t, err)
+		assert.Check(t, cmp.Equal("4", sc.Changed["A1"]["Email"]))
+		_, err = eb.pop(context.Background())
+		assert.Check(t, cmp.Equal(io.EOF, err))
+		assert.Check(t, cmp.Equal(int64(2), eb.droppedCount()))
+	})
+
+	t.Run("coalesce", func(t *testing.T) {
+		eb := newEventBuffer(1, BufferCoalesce)
+		eb.push(stateChange("A1", "Email", "1"), nil)
+		eb.push(stateChange("A1", "Mailbox", "2"), nil)
+		eb.push(stateChange("A1", "Email", "3"), nil)
+
+		sc, err := eb.pop(context.Background())
+		assert.NilError(t, err)
+		assert.Check(t, cmp.DeepEqual(map[string]string{"Email": "3", "Mailbox": "2"}, sc.Changed["A1"]))
+		assert.Check(t, cmp.Equal(int64(2), eb.droppedCount()))
+	})
+
+	t.Run("block", func(t *testing.T) {
+		eb := newEventBuffer(1, BufferBlock)
+		eb.push(stateChange("A1", "Email", "1"), nil)
+
+		pushed := make(chan bool)
+		go func() {
+			pushed <- eb.push(stateChange("A1", "Email", "2"), nil)
+		}()
+		select {
+		case <-pushed:
+			t.Fatal("push did not block on full buffer")
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		sc, err := eb.pop(context.Background())
+		assert.NilError(t, err)
+		assert.Check(t, cmp.Equal("1", sc.Changed["A1"]["Email"]))
+		assert.Check(t, <-pushed)
+		sc, err = eb.pop(context.Background())
+		assert.NilError(t, err)
+		assert.Check(t, cmp.Equal("2", sc.Changed["A1"]["Email"]))
+
+		done := make(chan struct{})
+		close(done)
+		assert.Check(t, eb.push(stateChange("A1", "Email", "3"), nil))
+		assert.Check(t, !eb.push(stateChange("A1", "Email", "4"), done), "push should give up once done is closed")
+	})
+
+	t.Run("context", func(t *testing.T) {
+		eb := newEventBuffer(1, BufferBlock)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		_, err := eb.pop(ctx)
+		assert.Check(t, cmp.Equal(context.DeadlineExceeded, err))
+	})
+}
+
+func TestEventSource(t *testing.T) {
+	c, srv := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {})
+	defer srv.Close()
+
+	var query string
+	esSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+		w.Header().Set("Content-Type", "text/event-stream")
+		io.WriteString(w, ": comment\r\n\r\n"+
+			"event: ping\ndata: {\"interval\":30}\n\n"+
+			"event: state\nid: 1\n"+
+			"data: {\"@type\":\"StateChange\",\n"+
+			"data: \"changed\":{\"A1\":{\"Email\":\"5\"}}}\n\n")
+	}))
+	defer esSrv.Close()
+
+	_, err := c.CurrentSession()
+	assert.NilError(t, err)
+	c.Session.EventSourceURL = esSrv.URL + "/?types={types}&closeafter={closeafter}&ping={ping}"
+
+	es, err := c.OpenEventSource(context.Background(), EventSourceOptions{
+		Types:           []string{"Email", "Mailbox"},
+		CloseAfterState: true,
+		Ping:            30 * time.Second,
+	})
+	assert.NilError(t, err)
+	defer es.Close()
+	assert.Check(t, cmp.Equal("types=Email,Mailbox&closeafter=state&ping=30", query))
+
+	sc, err := es.Next(context.Background())
+	assert.NilError(t, err)
+	assert.Check(t, cmp.DeepEqual(stateChange("A1", "Email", "5"), sc))
+
+	_, err = es.Next(context.Background())
+	assert.Check(t, cmp.Equal(io.EOF, err))
+
+	t.Run("unsupported type", func(t *testing.T) {
+		_, err := c.OpenEventSource(context.Background(), EventSourceOptions{
+			Types: []string{jmap.PushTypeEmailSubmission},
+		})
+		assert.Check(t, cmp.ErrorContains(err, jmap.SubmissionCapabilityName))
+	})
+}
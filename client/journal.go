@@ -0,0 +1,288 @@
+package client
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/foxcpp/go-jmap"
+	"github.com/foxcpp/go-jmap/internal/jsonlog"
+)
+
+// JournalState is the state of the journaled request.
+type JournalState string
+
+const (
+	// Request is about to be sent or is in flight. If entry is left in this
+	// state after a crash, it is unknown whether the server processed the
+	// request.
+	JournalPending JournalState = "pending"
+
+	// The server returned a response for the request.
+	JournalAcknowledged JournalState = "acknowledged"
+
+	// The server rejected the request as a whole (e.g. HTTP error or
+	// request-level error). Changes were not applied.
+	JournalRejected JournalState = "rejected"
+
+	// Connection failed after the request was (possibly) sent. It is unknown
+	// whether the server processed the request.
+	JournalUnknown JournalState = "unknown"
+)
+
+// JournalEntry is a single record in the request journal.
+type JournalEntry struct {
+	// Unique identifier of the entry.
+	ID string `json:"id"`
+
+	// Time the entry was last updated.
+	Time time.Time `json:"time"`
+
+	State JournalState `json:"state"`
+
+	// Serialized jmap.Request object.
+	Request json.RawMessage `json:"request,omitempty"`
+
+	// Serialized jmap.Response object, set for JournalAcknowledged.
+	Response json.RawMessage `json:"response,omitempty"`
+
+	// Error message, set for JournalRejected and JournalUnknown.
+	Error string `json:"error,omitempty"`
+}
+
+// Journal is the storage for write-ahead log of mutating requests.
+//
+// Client writes an entry using Begin before sending any request containing
+// /set, /copy or /import calls and updates it using Complete once the outcome
+// is known. After a crash, entries that are not JournalAcknowledged or
+// JournalRejected describe requests that may or may not have been applied
+// by the server.
+//
+// Implementations must be safe for concurrent use.
+type Journal interface {
+	// Begin durably records the request before it is sent.
+	Begin(entry JournalEntry) error
+
+	// Complete records the outcome of the request known at time t.
+	Complete(id string, t time.Time, state JournalState, response json.RawMessage, err error) error
+
+	// Unresolved returns entries in JournalPending or JournalUnknown state.
+	Unresolved() ([]JournalEntry, error)
+
+	// Forget removes the entry from the journal, e.g. after the application
+	// resolved the ambiguity.
+	Forget(id string) error
+}
+
+func isMutating(r *jmap.Request) bool {
+	for _, call := range r.Calls {
+		if strings.HasSuffix(call.Name, "/set") ||
+			strings.HasSuffix(call.Name, "/copy") ||
+			strings.HasSuffix(call.Name, "/import") {
+			return true
+		}
+	}
+	return false
+}
+
+// MemoryJournal is the Journal implementation that keeps entries in memory.
+//
+// It does not survive process restarts and so is mostly useful for testing.
+// Like FileJournal, it keeps only unresolved entries.
+type MemoryJournal struct {
+	lck     sync.Mutex
+	entries map[string]JournalEntry
+}
+
+func (mj *MemoryJournal) Begin(entry JournalEntry) error {
+	mj.lck.Lock()
+	defer mj.lck.Unlock()
+	if mj.entries == nil {
+		mj.entries = make(map[string]JournalEntry)
+	}
+	mj.entries[entry.ID] = entry
+	return nil
+}
+
+func (mj *MemoryJournal) Complete(id string, t time.Time, state JournalState, response json.RawMessage, err error) error {
+	mj.lck.Lock()
+	defer mj.lck.Unlock()
+	entry, ok := mj.entries[id]
+	if !ok {
+		return nil
+	}
+	if resolved(state) {
+		delete(mj.entries, id)
+		return nil
+	}
+	mj.entries[id] = completeEntry(entry, t, state, response, err)
+	return nil
+}
+
+func (mj *MemoryJournal) Unresolved() ([]JournalEntry, error) {
+	mj.lck.Lock()
+	defer mj.lck.Unlock()
+	return unresolved(mj.entries), nil
+}
+
+func (mj *MemoryJournal) Forget(id string) error {
+	mj.lck.Lock()
+	defer mj.lck.Unlock()
+	delete(mj.entries, id)
+	return nil
+}
+
+// FileJournal is the Journal implementation that appends entries to a file
+// as JSON lines, calling fsync after each write.
+//
+// Only unresolved entries are kept in memory since resolved ones are not
+// needed after a restart. The file is rewritten with only these entries on
+// Forget and when it grows large enough, so it does not grow without bound.
+type FileJournal struct {
+	lck     sync.Mutex
+	log     *jsonlog.Log
+	entries map[string]JournalEntry
+}
+
+// OpenFileJournal opens (creating if necessary) the journal file and loads
+// existing entries from it.
+//
+// A partially written last record (e.g. after a crash) is removed from the
+// file. An error is returned if any other record is malformed.
+func OpenFileJournal(path string) (*FileJournal, error) {
+	fj := &FileJournal{entries: make(map[string]JournalEntry)}
+	log, err := jsonlog.Open(path, func(line []byte) error {
+		var entry JournalEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			return err
+		}
+		if entry.State == "" || resolved(entry.State) {
+			// Forget record or the request outcome is known.
+			delete(fj.entries, entry.ID)
+			return nil
+		}
+		if prev, ok := fj.entries[entry.ID]; ok && entry.Request == nil {
+			entry.Request = prev.Request
+		}
+		fj.entries[entry.ID] = entry
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	fj.log = log
+	return fj, nil
+}
+
+func (fj *FileJournal) write(entry JournalEntry) error {
+	if err := fj.log.Append(entry); err != nil {
+		return err
+	}
+	if fj.log.NeedsCompaction() {
+		return fj.compact()
+	}
+	return nil
+}
+
+// compact rewrites the file so it contains only current entries.
+func (fj *FileJournal) compact() error {
+	return fj.log.Rewrite(func(add func(interface{}) error) error {
+		for _, entry := range fj.entries {
+			if err := add(entry); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (fj *FileJournal) Begin(entry JournalEntry) error {
+	fj.lck.Lock()
+	defer fj.lck.Unlock()
+	fj.entries[entry.ID] = entry
+	if err := fj.write(entry); err != nil {
+		delete(fj.entries, entry.ID)
+		return err
+	}
+	return nil
+}
+
+func (fj *FileJournal) Complete(id string, t time.Time, state JournalState, response json.RawMessage, err error) error {
+	fj.lck.Lock()
+	defer fj.lck.Unlock()
+	prev, ok := fj.entries[id]
+	if !ok {
+		return nil
+	}
+	entry := completeEntry(prev, t, state, response, err)
+	if resolved(state) {
+		delete(fj.entries, id)
+	} else {
+		fj.entries[id] = entry
+	}
+
+	// Do not write request blob again, it is already in the file.
+	record := entry
+	record.Request = nil
+	if err := fj.write(record); err != nil {
+		fj.entries[id] = prev
+		return err
+	}
+	return nil
+}
+
+func (fj *FileJournal) Unresolved() ([]JournalEntry, error) {
+	fj.lck.Lock()
+	defer fj.lck.Unlock()
+	return unresolved(fj.entries), nil
+}
+
+func (fj *FileJournal) Forget(id string) error {
+	fj.lck.Lock()
+	defer fj.lck.Unlock()
+	prev, ok := fj.entries[id]
+	if !ok {
+		return nil
+	}
+	delete(fj.entries, id)
+	if err := fj.compact(); err != nil {
+		fj.entries[id] = prev
+		return err
+	}
+	return nil
+}
+
+// Close closes the underlying file.
+func (fj *FileJournal) Close() error {
+	return fj.log.Close()
+}
+
+func completeEntry(entry JournalEntry, t time.Time, state JournalState, response json.RawMessage, err error) JournalEntry {
+	entry.Time = t
+	entry.State = state
+	entry.Response = response
+	entry.Error = ""
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	return entry
+}
+
+func resolved(state JournalState) bool {
+	return state == JournalAcknowledged || state == JournalRejected
+}
+
+func unresolved(entries map[string]JournalEntry) []JournalEntry {
+	var res []JournalEntry
+	for _, entry := range entries {
+		if entry.State == JournalPending || entry.State == JournalUnknown {
+			res = append(res, entry)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Time.Before(res[j].Time)
+	})
+	return res
+}
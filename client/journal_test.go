@@ -0,0 +1,152 @@
+package client
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/foxcpp/go-jmap"
+	"gotest.tools/assert"
+	"gotest.tools/assert/cmp"
+)
+
+func TestFileJournal(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-jmap-journal-")
+	assert.NilError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "journal")
+
+	fj, err := OpenFileJournal(path)
+	assert.NilError(t, err, "OpenFileJournal")
+	assert.NilError(t, fj.Begin(JournalEntry{ID: "a", State: JournalPending, Request: []byte(`{}`)}))
+	assert.NilError(t, fj.Begin(JournalEntry{ID: "b", State: JournalPending, Request: []byte(`{}`)}))
+	assert.NilError(t, fj.Begin(JournalEntry{ID: "c", State: JournalPending, Request: []byte(`{}`)}))
+	assert.NilError(t, fj.Complete("a", time.Time{}, JournalAcknowledged, []byte(`{}`), nil))
+	assert.NilError(t, fj.Forget("c"))
+	assert.NilError(t, fj.Close())
+
+	fj, err = OpenFileJournal(path)
+	assert.NilError(t, err, "OpenFileJournal")
+	defer fj.Close()
+
+	entries, err := fj.Unresolved()
+	assert.NilError(t, err)
+	assert.Assert(t, cmp.Len(entries, 1))
+	assert.Check(t, cmp.Equal("b", entries[0].ID))
+	assert.Check(t, cmp.Equal(`{}`, string(entries[0].Request)))
+}
+
+// recordingJournal records outcomes passed to Complete.
+type recordingJournal struct {
+	MemoryJournal
+	states    []JournalState
+	responses []json.RawMessage
+}
+
+func (rj *recordingJournal) Complete(id string, t time.Time, state JournalState, response json.RawMessage, err error) error {
+	rj.states = append(rj.states, state)
+	rj.responses = append(rj.responses, response)
+	return rj.MemoryJournal.Complete(id, t, state, response, err)
+}
+
+func TestClientJournal(t *testing.T) {
+	c, srv := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"sessionState":"1","methodResponses":[["Core/echo",{},"0"]]}`)) //nolint:errcheck
+	})
+	defer srv.Close()
+	c.Enable(jmap.RawUnmarshallers([]string{"Core/echo"}))
+
+	rj := &recordingJournal{}
+	c.Journal = rj
+
+	_, err := c.RawSend(&jmap.Request{Calls: []jmap.Invocation{{Name: "Core/echo", CallID: "0", Args: map[string]interface{}{}}}})
+	assert.NilError(t, err)
+	assert.Check(t, cmp.Len(rj.states, 0), "non-mutating request should not be journaled")
+
+	_, err = c.RawSend(&jmap.Request{Calls: []jmap.Invocation{{Name: "Email/set", CallID: "0", Args: map[string]interface{}{}}}})
+	assert.NilError(t, err)
+	assert.Check(t, cmp.DeepEqual([]JournalState{JournalAcknowledged}, rj.states))
+	assert.Check(t, cmp.Contains(string(rj.responses[0]), "methodResponses"))
+	assert.Check(t, cmp.Len(rj.entries, 0), "resolved entry should be dropped")
+
+	t.Run("spill", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "go-jmap-spill-")
+		assert.NilError(t, err)
+		defer os.RemoveAll(dir)
+		c.SpillThreshold = 10
+		c.SpillDir = dir
+		defer func() { c.SpillThreshold = 0 }()
+
+		rj.states, rj.responses = nil, nil
+		resp, err := c.RawSend(&jmap.Request{Calls: []jmap.Invocation{{Name: "Email/set", CallID: "0", Args: map[string]interface{}{}}}})
+		assert.NilError(t, err)
+		assert.Check(t, cmp.Len(resp.Responses, 1))
+		assert.Check(t, cmp.DeepEqual([]JournalState{JournalAcknowledged}, rj.states))
+		assert.Check(t, rj.responses[0] == nil, "spilled response should not be journaled")
+	})
+}
+
+func TestFileJournalCrash(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-jmap-journal-")
+	assert.NilError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "journal")
+
+	fj, err := OpenFileJournal(path)
+	assert.NilError(t, err, "OpenFileJournal")
+	assert.NilError(t, fj.Begin(JournalEntry{ID: "a", State: JournalPending, Request: []byte(`{}`)}))
+	assert.NilError(t, fj.Close())
+
+	// Simulate the crash in the middle of the write.
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0600)
+	assert.NilError(t, err)
+	_, err = f.WriteString(`{"id":"b","sta`)
+	assert.NilError(t, err)
+	assert.NilError(t, f.Close())
+
+	fj, err = OpenFileJournal(path)
+	assert.NilError(t, err, "OpenFileJournal")
+	assert.NilError(t, fj.Begin(JournalEntry{ID: "c", State: JournalPending, Request: []byte(`{}`)}))
+	assert.NilError(t, fj.Close())
+
+	fj, err = OpenFileJournal(path)
+	assert.NilError(t, err, "OpenFileJournal")
+	entries, err := fj.Unresolved()
+	assert.NilError(t, err)
+	assert.Check(t, cmp.Len(entries, 2), "record written after the crash should not be lost")
+	assert.NilError(t, fj.Close())
+
+	t.Run("corrupted record", func(t *testing.T) {
+		blob, err := ioutil.ReadFile(path)
+		assert.NilError(t, err)
+		assert.NilError(t, ioutil.WriteFile(path, append([]byte("garbage\n"), blob...), 0600))
+
+		_, err = OpenFileJournal(path)
+		assert.Check(t, cmp.ErrorContains(err, "line 1"))
+	})
+}
+
+func TestFileJournalForgetCompacts(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-jmap-journal-")
+	assert.NilError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "journal")
+
+	fj, err := OpenFileJournal(path)
+	assert.NilError(t, err, "OpenFileJournal")
+	defer fj.Close()
+	assert.NilError(t, fj.Begin(JournalEntry{ID: "a", State: JournalPending, Request: []byte(`{}`)}))
+	assert.NilError(t, fj.Begin(JournalEntry{ID: "b", State: JournalPending, Request: []byte(`{}`)}))
+	assert.NilError(t, fj.Complete("a", time.Time{}, JournalUnknown, nil, nil))
+	assert.NilError(t, fj.Complete("b", time.Time{}, JournalAcknowledged, []byte(`{}`), nil))
+	assert.NilError(t, fj.Forget("a"))
+
+	blob, err := ioutil.ReadFile(path)
+	assert.NilError(t, err)
+	assert.Check(t, cmp.Len(blob, 0))
+}
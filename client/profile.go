@@ -0,0 +1,106 @@
+package client
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/foxcpp/go-jmap"
+)
+
+// Profile sets a group of related Client options suitable for a certain
+// environment. Use Client.Apply to apply profiles.
+type Profile func(c *Client)
+
+// Apply applies profiles to the Client in order, so options set by later
+// profiles override earlier ones. Custom profiles can be used to adjust
+// presets:
+//
+//	c.Apply(client.MobileProfile, func(c *client.Client) {
+//		c.PageSize = 20
+//	})
+//
+// Apply must be called before the Client is used for requests.
+func (c *Client) Apply(profiles ...Profile) {
+	for _, p := range profiles {
+		p(c)
+	}
+}
+
+// MobileProfile configures the Client for slow, metered and unreliable
+// networks and memory-constrained devices:
+//   - request bodies are compressed,
+//   - query results are requested in small pages,
+//   - large responses are spilled to disk instead of being kept in memory,
+//   - rate limit backoff starts at 5 seconds and grows up to 10 minutes,
+//   - the Session is refreshed automatically when it changes.
+//
+// Responses are compressed regardless of the profile if HTTPClient uses
+// http.Transport with default settings.
+var MobileProfile Profile = func(c *Client) {
+	c.CompressRequests = true
+	c.PageSize = 50
+	c.SpillThreshold = 1 << 20
+	c.Throttle = &Throttle{
+		MinBackoff: 5 * time.Second,
+		MaxBackoff: 10 * time.Minute,
+	}
+	c.RefreshSession = true
+}
+
+// ServerProfile configures the Client for server-side use with many
+// concurrent requests on a fast network:
+//   - HTTPClient keeps enough idle connections for 64 concurrent requests,
+//   - query results are requested in large pages,
+//   - responses are never spilled to disk,
+//   - default rate limit backoff is used.
+//
+// HTTPClient is replaced by a copy, so http.DefaultClient is not modified.
+var ServerProfile Profile = func(c *Client) {
+	httpClient := &http.Client{}
+	if c.HTTPClient != nil {
+		*httpClient = *c.HTTPClient
+	}
+	transport, ok := httpClient.Transport.(*http.Transport)
+	if httpClient.Transport == nil {
+		transport, ok = http.DefaultTransport.(*http.Transport)
+	}
+	if ok {
+		transport = transport.Clone()
+		transport.MaxIdleConns = 0
+		transport.MaxIdleConnsPerHost = 64
+		httpClient.Transport = transport
+	}
+	c.HTTPClient = httpClient
+
+	c.PageSize = 500
+	c.SpillThreshold = 0
+	c.Throttle = &Throttle{}
+	c.RefreshSession = true
+}
+
+// TestProfile returns the profile that makes Client behavior reproducible
+// in tests:
+//   - clock is used as the Clock (e.g. jmaptest.FakeClock),
+//   - ids generated by NewID are sequential ("T1", "T2", ...),
+//   - responses are never spilled to disk,
+//   - requests are not throttled and not compressed.
+func TestProfile(clock Clock) Profile {
+	return func(c *Client) {
+		var (
+			lck  sync.Mutex
+			next int
+		)
+		c.Clock = clock
+		c.IDGenerator = func() (jmap.ID, error) {
+			lck.Lock()
+			defer lck.Unlock()
+			next++
+			return jmap.ID("T" + strconv.Itoa(next)), nil
+		}
+		c.SpillThreshold = 0
+		c.Throttle = nil
+		c.CompressRequests = false
+	}
+}
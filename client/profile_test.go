@@ -0,0 +1,122 @@
+package client
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/foxcpp/go-jmap"
+	"github.com/foxcpp/go-jmap/jmaptest"
+	"gotest.tools/assert"
+	"gotest.tools/assert/cmp"
+)
+
+func TestCompressRequests(t *testing.T) {
+	var (
+		encodings     []string
+		acceptGzip    bool
+		bodyDecodedOK bool
+	)
+	c, srv := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		enc := r.Header.Get("Content-Encoding")
+		encodings = append(encodings, enc)
+		if enc == "gzip" {
+			if !acceptGzip {
+				w.WriteHeader(http.StatusUnsupportedMediaType)
+				return
+			}
+			rd, err := gzip.NewReader(r.Body)
+			assert.NilError(t, err)
+			blob, err := ioutil.ReadAll(rd)
+			assert.NilError(t, err)
+			bodyDecodedOK = len(blob) != 0
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"methodResponses":[["Core/echo",{},"echo0"]],"sessionState":"1"}`)) //nolint:errcheck
+	})
+	defer srv.Close()
+	c.Enable(jmap.RawUnmarshallers([]string{"Core/echo"}))
+	c.CompressRequests = true
+
+	acceptGzip = true
+	assert.NilError(t, c.Echo())
+	assert.Check(t, bodyDecodedOK)
+	assert.Check(t, cmp.DeepEqual([]string{"gzip"}, encodings))
+
+	// Rejected compressed request is retried uncompressed and compression
+	// is not attempted anymore.
+	acceptGzip = false
+	encodings = nil
+	assert.NilError(t, c.Echo())
+	assert.NilError(t, c.Echo())
+	assert.Check(t, cmp.DeepEqual([]string{"gzip", "", ""}, encodings))
+}
+
+func TestCompressRequestsNotJSON(t *testing.T) {
+	var encodings []string
+	c, srv := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		enc := r.Header.Get("Content-Encoding")
+		encodings = append(encodings, enc)
+		if enc == "gzip" {
+			// The server ignores Content-Encoding.
+			w.Header().Set("Content-Type", "application/problem+json")
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"type":"urn:ietf:params:jmap:error:notJSON","status":400}`)) //nolint:errcheck
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"methodResponses":[["Core/echo",{},"echo0"]],"sessionState":"1"}`)) //nolint:errcheck
+	})
+	defer srv.Close()
+	c.Enable(jmap.RawUnmarshallers([]string{"Core/echo"}))
+	c.CompressRequests = true
+
+	assert.NilError(t, c.Echo())
+	assert.NilError(t, c.Echo())
+	assert.Check(t, cmp.DeepEqual([]string{"gzip", "", ""}, encodings))
+}
+
+func TestCompressRequestsBadRequest(t *testing.T) {
+	c, srv := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/problem+json")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"type":"urn:ietf:params:jmap:error:notRequest","status":400}`)) //nolint:errcheck
+	})
+	defer srv.Close()
+	c.CompressRequests = true
+
+	// Other errors are reported as is.
+	err := c.Echo()
+	reqErr, ok := err.(jmap.RequestError)
+	assert.Assert(t, ok, "unexpected error: %v", err)
+	assert.Check(t, cmp.Equal(jmap.ProblemPrefix+jmap.CodeNotRequest, reqErr.Type))
+}
+
+func TestProfiles(t *testing.T) {
+	c := &Client{HTTPClient: http.DefaultClient}
+	c.Apply(MobileProfile, func(c *Client) {
+		c.PageSize = 20
+	})
+	assert.Check(t, c.CompressRequests)
+	assert.Check(t, cmp.Equal(jmap.UnsignedInt(20), c.PageSize))
+	assert.Check(t, cmp.Equal(10*time.Minute, c.Throttle.MaxBackoff))
+
+	c.Apply(ServerProfile)
+	assert.Check(t, c.HTTPClient != http.DefaultClient)
+	assert.Check(t, cmp.Equal(64, c.HTTPClient.Transport.(*http.Transport).MaxIdleConnsPerHost))
+	assert.Check(t, http.DefaultClient.Transport == nil)
+	assert.Check(t, cmp.Equal(int64(0), c.SpillThreshold))
+
+	clock := jmaptest.NewFakeClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	c.Apply(TestProfile(clock))
+	assert.Check(t, c.Throttle == nil)
+	assert.Check(t, !c.CompressRequests)
+	assert.Check(t, cmp.Equal(clock.Now(), ClockOrSystem(c.Clock).Now()))
+	for _, exp := range []jmap.ID{"T1", "T2"} {
+		id, err := c.NewID()
+		assert.NilError(t, err)
+		assert.Check(t, cmp.Equal(exp, id))
+	}
+}
@@ -0,0 +1,356 @@
+package client
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/foxcpp/go-jmap"
+)
+
+// PushSubscriptionRecord describes a PushSubscription created by the
+// client, as saved in PushSubscriptionStore.
+type PushSubscriptionRecord struct {
+	// The id of the PushSubscription on the server.
+	ID jmap.ID `json:"id"`
+
+	DeviceClientID string                     `json:"deviceClientId"`
+	URL            string                     `json:"url"`
+	Keys           *jmap.PushSubscriptionKeys `json:"keys,omitempty"`
+	Types          []string                   `json:"types,omitempty"`
+
+	// Whether the verification code was accepted by the server, see
+	// VerifyPushSubscription. StateChanges are not pushed until then.
+	Verified bool `json:"verified"`
+
+	// The verification code received in the PushVerification object.
+	VerificationCode string `json:"verificationCode,omitempty"`
+
+	// The time the PushSubscription was created at.
+	Created time.Time `json:"created"`
+}
+
+// PushSubscriptionStore is the persistent storage for PushSubscriptions
+// created by the client, keyed by PushSubscriptionRecord.ID.
+//
+// It allows ResumePushSubscription to reuse the subscription after a
+// restart instead of creating a new one each time and to destroy
+// subscriptions that are no longer needed. Use OpenFilePushSubscriptionStore
+// for the file-backed implementation.
+type PushSubscriptionStore = Store[jmap.ID, PushSubscriptionRecord]
+
+// OpenFilePushSubscriptionStore opens (creating if necessary) the
+// file-backed PushSubscriptionStore.
+func OpenFilePushSubscriptionStore(path string) (*FileStore[jmap.ID, PushSubscriptionRecord], error) {
+	return OpenFileStore(path, func(rec PushSubscriptionRecord) jmap.ID { return rec.ID })
+}
+
+// MemoryPushSubscriptionStore is the PushSubscriptionStore implementation
+// that keeps records in memory.
+//
+// It does not survive process restarts and so is mostly useful for testing.
+type MemoryPushSubscriptionStore struct {
+	lck     sync.Mutex
+	records map[jmap.ID]PushSubscriptionRecord
+}
+
+func (ms *MemoryPushSubscriptionStore) Save(rec PushSubscriptionRecord) error {
+	ms.lck.Lock()
+	defer ms.lck.Unlock()
+	if ms.records == nil {
+		ms.records = make(map[jmap.ID]PushSubscriptionRecord)
+	}
+	ms.records[rec.ID] = rec
+	return nil
+}
+
+func (ms *MemoryPushSubscriptionStore) Delete(id jmap.ID) error {
+	ms.lck.Lock()
+	defer ms.lck.Unlock()
+	delete(ms.records, id)
+	return nil
+}
+
+func (ms *MemoryPushSubscriptionStore) Load() ([]PushSubscriptionRecord, error) {
+	ms.lck.Lock()
+	defer ms.lck.Unlock()
+	res := make([]PushSubscriptionRecord, 0, len(ms.records))
+	for _, rec := range ms.records {
+		res = append(res, rec)
+	}
+	return res, nil
+}
+
+// PushSubscriptionOptions describes the PushSubscription requested using
+// ResumePushSubscription.
+type PushSubscriptionOptions struct {
+	// The id identifying the client and the device it runs on. It must be
+	// stable across restarts.
+	DeviceClientID string
+
+	// The URL the server sends push messages to.
+	URL string
+
+	// Encryption keys for push messages, nil to disable encryption.
+	Keys *jmap.PushSubscriptionKeys
+
+	// Push type filter, nil to receive changes for all types.
+	Types []string
+
+	// How long to wait for the PushVerification object before the
+	// subscription is considered lost. The server sends it only once, after
+	// creation, so if the client was not running at that time, the
+	// subscription can never be verified.
+	//
+	// Defaults to DefaultPushVerificationTimeout.
+	VerificationTimeout time.Duration
+}
+
+// DefaultPushVerificationTimeout is the default value of
+// PushSubscriptionOptions.VerificationTimeout.
+const DefaultPushVerificationTimeout = 5 * time.Minute
+
+var coreUsing = []string{jmap.CoreCapabilityName}
+
+// ResumePushSubscription returns the PushSubscription matching opts,
+// reusing the one saved in the store if it still exists on the server.
+//
+// Stored subscriptions that do not match opts (e.g. the push URL or keys
+// changed), that expired or that were not verified within
+// opts.VerificationTimeout are destroyed on the server and removed from the
+// store. Subscriptions not in the store are never touched. The verification
+// state of the reused subscription is refreshed from the server and its
+// Types are updated if they differ from opts.
+//
+// If no stored subscription can be reused, a new one is created and saved.
+// The server then sends the PushVerification object to the URL, pass it to
+// VerifyPushSubscription to start receiving StateChanges.
+//
+// The client must have jmap.PushSubscriptionUnmarshallers enabled.
+func (c *Client) ResumePushSubscription(store PushSubscriptionStore, opts PushSubscriptionOptions) (*PushSubscriptionRecord, error) {
+	if opts.VerificationTimeout == 0 {
+		opts.VerificationTimeout = DefaultPushVerificationTimeout
+	}
+
+	records, err := store.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		resume *PushSubscriptionRecord
+		stale  []jmap.ID
+	)
+	if len(records) != 0 {
+		ids := make([]jmap.ID, 0, len(records))
+		for _, rec := range records {
+			ids = append(ids, rec.ID)
+		}
+		get, err := c.getPushSubscriptions(ids)
+		if err != nil {
+			return nil, err
+		}
+		onServer := make(map[jmap.ID]jmap.PushSubscription, len(get.List))
+		for _, sub := range get.List {
+			onServer[sub.ID] = sub
+		}
+
+		now := ClockOrSystem(c.Clock).Now()
+		for i := range records {
+			rec := &records[i]
+			sub, ok := onServer[rec.ID]
+			if ok && sub.Expires != nil && !time.Time(*sub.Expires).After(now) {
+				ok = false
+			}
+			if ok && sub.VerificationCode == "" && now.Sub(rec.Created) >= opts.VerificationTimeout {
+				// PushVerification was lost, it will not be sent again.
+				ok = false
+			}
+			if ok && resume == nil && rec.matches(opts) {
+				rec.Verified = sub.VerificationCode != ""
+				if !rec.Verified {
+					rec.VerificationCode = ""
+				}
+				resume = rec
+				continue
+			}
+			stale = append(stale, rec.ID)
+		}
+	}
+
+	if err := c.destroyPushSubscriptions(store, stale); err != nil {
+		return nil, err
+	}
+
+	if resume != nil {
+		if !stringsEqual(resume.Types, opts.Types) {
+			if err := c.updatePushSubscription(resume.ID, jmap.PatchObject{"types": opts.Types}); err != nil {
+				return nil, err
+			}
+			resume.Types = opts.Types
+		}
+		if err := store.Save(*resume); err != nil {
+			return nil, err
+		}
+		return resume, nil
+	}
+
+	return c.createPushSubscription(store, opts)
+}
+
+// VerifyPushSubscription sets the verification code received in the
+// PushVerification object on the PushSubscription and marks it as verified
+// in the store.
+//
+// The PushSubscription must be in the store, i.e. created using
+// ResumePushSubscription.
+//
+// The client must have jmap.PushSubscriptionUnmarshallers enabled.
+func (c *Client) VerifyPushSubscription(store PushSubscriptionStore, v jmap.PushVerification) error {
+	records, err := store.Load()
+	if err != nil {
+		return err
+	}
+	var rec *PushSubscriptionRecord
+	for i := range records {
+		if records[i].ID == v.PushSubscriptionID {
+			rec = &records[i]
+			break
+		}
+	}
+	if rec == nil {
+		return fmt.Errorf("jmap/client: push subscription %v is not in the store", v.PushSubscriptionID)
+	}
+
+	if err := c.updatePushSubscription(rec.ID, jmap.PatchObject{"verificationCode": v.VerificationCode}); err != nil {
+		return err
+	}
+	rec.Verified = true
+	rec.VerificationCode = v.VerificationCode
+	return store.Save(*rec)
+}
+
+func (rec *PushSubscriptionRecord) matches(opts PushSubscriptionOptions) bool {
+	if rec.DeviceClientID != opts.DeviceClientID || rec.URL != opts.URL {
+		return false
+	}
+	if (rec.Keys == nil) != (opts.Keys == nil) {
+		return false
+	}
+	return rec.Keys == nil || *rec.Keys == *opts.Keys
+}
+
+func stringsEqual(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *Client) createPushSubscription(store PushSubscriptionStore, opts PushSubscriptionOptions) (*PushSubscriptionRecord, error) {
+	resp, err := c.setPushSubscriptions(jmap.PushSubscriptionSetArgs{
+		Create: map[jmap.ID]jmap.PushSubscription{
+			"push": {
+				DeviceClientID: opts.DeviceClientID,
+				URL:            opts.URL,
+				Keys:           opts.Keys,
+				Types:          opts.Types,
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if setErr, ok := resp.NotCreated["push"]; ok {
+		return nil, setErr
+	}
+	created, ok := resp.Created["push"]
+	if !ok || created.ID == "" {
+		return nil, fmt.Errorf("jmap/client: no id returned for created push subscription")
+	}
+
+	rec := &PushSubscriptionRecord{
+		ID:             created.ID,
+		DeviceClientID: opts.DeviceClientID,
+		URL:            opts.URL,
+		Keys:           opts.Keys,
+		Types:          opts.Types,
+		Created:        ClockOrSystem(c.Clock).Now(),
+	}
+	if err := store.Save(*rec); err != nil {
+		return nil, err
+	}
+	return rec, nil
+}
+
+// destroyPushSubscriptions destroys the PushSubscriptions and removes them
+// from the store. Subscriptions that failed to be destroyed for reasons
+// other than notFound are kept in the store so destruction is retried
+// later.
+func (c *Client) destroyPushSubscriptions(store PushSubscriptionStore, ids []jmap.ID) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	resp, err := c.setPushSubscriptions(jmap.PushSubscriptionSetArgs{Destroy: ids})
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if setErr, ok := resp.NotDestroyed[id]; ok && setErr.Type != jmap.CodeNotFound {
+			continue
+		}
+		if err := store.Delete(id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Client) updatePushSubscription(id jmap.ID, patch jmap.PatchObject) error {
+	resp, err := c.setPushSubscriptions(jmap.PushSubscriptionSetArgs{
+		Update: map[jmap.ID]jmap.PatchObject{id: patch},
+	})
+	if err != nil {
+		return err
+	}
+	if setErr, ok := resp.NotUpdated[id]; ok {
+		return setErr
+	}
+	return nil
+}
+
+func (c *Client) getPushSubscriptions(ids []jmap.ID) (*jmap.PushSubscriptionGetResponse, error) {
+	respArgs, err := c.Call(coreUsing, "PushSubscription/get", jmap.PushSubscriptionGetArgs{
+		IDs:        ids,
+		Properties: []string{"id", "deviceClientId", "verificationCode", "expires", "types"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	resp, ok := respArgs.(jmap.PushSubscriptionGetResponse)
+	if !ok {
+		return nil, unexpectedPushResponse("PushSubscription/get", respArgs)
+	}
+	return &resp, nil
+}
+
+func (c *Client) setPushSubscriptions(args jmap.PushSubscriptionSetArgs) (*jmap.PushSubscriptionSetResponse, error) {
+	respArgs, err := c.Call(coreUsing, "PushSubscription/set", args)
+	if err != nil {
+		return nil, err
+	}
+	resp, ok := respArgs.(jmap.PushSubscriptionSetResponse)
+	if !ok {
+		return nil, unexpectedPushResponse("PushSubscription/set", respArgs)
+	}
+	return &resp, nil
+}
+
+func unexpectedPushResponse(methodName string, args interface{}) error {
+	return fmt.Errorf("jmap/client: unexpected %s response type %T, is jmap.PushSubscriptionUnmarshallers enabled?", methodName, args)
+}
@@ -0,0 +1,220 @@
+package client
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/foxcpp/go-jmap"
+	"github.com/foxcpp/go-jmap/jmaptest"
+	"gotest.tools/assert"
+	"gotest.tools/assert/cmp"
+)
+
+// pushServer keeps PushSubscriptions of the test server.
+type pushServer struct {
+	t     *testing.T
+	subs  map[jmap.ID]jmap.PushSubscription
+	next  int
+	calls []string
+}
+
+func (ps *pushServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Calls [][3]json.RawMessage `json:"methodCalls"`
+	}
+	assert.NilError(ps.t, json.NewDecoder(r.Body).Decode(&req))
+	var name string
+	assert.NilError(ps.t, json.Unmarshal(req.Calls[0][0], &name))
+	ps.calls = append(ps.calls, name)
+
+	var resp interface{}
+	switch name {
+	case "PushSubscription/get":
+		var args jmap.PushSubscriptionGetArgs
+		assert.NilError(ps.t, json.Unmarshal(req.Calls[0][1], &args))
+		get := jmap.PushSubscriptionGetResponse{List: []jmap.PushSubscription{}}
+		for _, id := range args.IDs {
+			sub, ok := ps.subs[id]
+			if !ok {
+				get.NotFound = append(get.NotFound, id)
+				continue
+			}
+			sub.URL = ""
+			sub.Keys = nil
+			get.List = append(get.List, sub)
+		}
+		resp = get
+	case "PushSubscription/set":
+		var args jmap.PushSubscriptionSetArgs
+		assert.NilError(ps.t, json.Unmarshal(req.Calls[0][1], &args))
+		set := jmap.PushSubscriptionSetResponse{
+			Created:      map[jmap.ID]jmap.PushSubscription{},
+			Updated:      map[jmap.ID]*jmap.PushSubscription{},
+			NotDestroyed: map[jmap.ID]jmap.SetError{},
+		}
+		for cid, sub := range args.Create {
+			ps.next++
+			sub.ID = jmap.ID("S" + strconv.Itoa(ps.next))
+			ps.subs[sub.ID] = sub
+			set.Created[cid] = jmap.PushSubscription{ID: sub.ID}
+		}
+		for id, patch := range args.Update {
+			sub := ps.subs[id]
+			if code, ok := patch["verificationCode"]; ok {
+				sub.VerificationCode = code.(string)
+			}
+			if types, ok := patch["types"]; ok {
+				sub.Types = nil
+				for _, typ := range types.([]interface{}) {
+					sub.Types = append(sub.Types, typ.(string))
+				}
+			}
+			ps.subs[id] = sub
+			set.Updated[id] = nil
+		}
+		for _, id := range args.Destroy {
+			if _, ok := ps.subs[id]; !ok {
+				set.NotDestroyed[id] = jmap.SetError{Type: jmap.CodeNotFound}
+				continue
+			}
+			delete(ps.subs, id)
+			set.Destroyed = append(set.Destroyed, id)
+		}
+		resp = set
+	default:
+		ps.t.Errorf("unexpected call: %s", name)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{ //nolint:errcheck
+		"methodResponses": []interface{}{[]interface{}{name, resp, "0"}},
+		"sessionState":    "1",
+	})
+}
+
+func (ps *pushServer) ids() []jmap.ID {
+	var res []jmap.ID
+	for id := range ps.subs {
+		res = append(res, id)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i] < res[j] })
+	return res
+}
+
+func TestResumePushSubscription(t *testing.T) {
+	ps := &pushServer{t: t, subs: map[jmap.ID]jmap.PushSubscription{}}
+	c, srv := newTestClient(t, ps.ServeHTTP)
+	defer srv.Close()
+	c.Enable(jmap.PushSubscriptionUnmarshallers)
+	clock := jmaptest.NewFakeClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	c.Clock = clock
+
+	dir, err := ioutil.TempDir("", "go-jmap-push-")
+	assert.NilError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "push")
+	store, err := OpenFilePushSubscriptionStore(path)
+	assert.NilError(t, err)
+
+	opts := PushSubscriptionOptions{
+		DeviceClientID: "device",
+		URL:            "https://push.example.org/1",
+		Keys:           &jmap.PushSubscriptionKeys{P256DH: "key", Auth: "auth"},
+		Types:          []string{jmap.PushTypeEmail},
+	}
+
+	rec, err := c.ResumePushSubscription(store, opts)
+	assert.NilError(t, err)
+	assert.Check(t, cmp.Equal(jmap.ID("S1"), rec.ID))
+	assert.Check(t, !rec.Verified)
+	assert.Check(t, cmp.DeepEqual([]string{"PushSubscription/set"}, ps.calls))
+	assert.Check(t, cmp.DeepEqual(*opts.Keys, *ps.subs["S1"].Keys))
+
+	assert.NilError(t, c.VerifyPushSubscription(store, jmap.PushVerification{PushSubscriptionID: "S1", VerificationCode: "code"}))
+	assert.Check(t, cmp.Equal("code", ps.subs["S1"].VerificationCode))
+	err = c.VerifyPushSubscription(store, jmap.PushVerification{PushSubscriptionID: "S9", VerificationCode: "code"})
+	assert.Check(t, cmp.ErrorContains(err, "not in the store"))
+
+	// Restart: the subscription is reused, types are updated.
+	assert.NilError(t, store.Close())
+	store, err = OpenFilePushSubscriptionStore(path)
+	assert.NilError(t, err)
+	defer store.Close()
+	ps.calls = nil
+	opts.Types = []string{jmap.PushTypeEmail, jmap.PushTypeMailbox}
+	rec, err = c.ResumePushSubscription(store, opts)
+	assert.NilError(t, err)
+	assert.Check(t, cmp.Equal(jmap.ID("S1"), rec.ID))
+	assert.Check(t, rec.Verified)
+	assert.Check(t, cmp.DeepEqual([]string{"PushSubscription/get", "PushSubscription/set"}, ps.calls))
+	assert.Check(t, cmp.DeepEqual(opts.Types, ps.subs["S1"].Types))
+
+	// The push URL changed: the old subscription is destroyed.
+	opts.URL = "https://push.example.org/2"
+	rec, err = c.ResumePushSubscription(store, opts)
+	assert.NilError(t, err)
+	assert.Check(t, cmp.Equal(jmap.ID("S2"), rec.ID))
+	assert.Check(t, cmp.DeepEqual([]jmap.ID{"S2"}, ps.ids()))
+
+	// The subscription expired: a new one is created.
+	expires := jmap.UTCDate(clock.Now().Add(time.Hour))
+	sub := ps.subs["S2"]
+	sub.Expires = &expires
+	ps.subs["S2"] = sub
+	clock.Advance(2 * time.Hour)
+	rec, err = c.ResumePushSubscription(store, opts)
+	assert.NilError(t, err)
+	assert.Check(t, cmp.Equal(jmap.ID("S3"), rec.ID))
+
+	// The server forgot the subscription.
+	delete(ps.subs, "S3")
+	rec, err = c.ResumePushSubscription(store, opts)
+	assert.NilError(t, err)
+	assert.Check(t, cmp.Equal(jmap.ID("S4"), rec.ID))
+
+	records, err := store.Load()
+	assert.NilError(t, err)
+	assert.Check(t, cmp.Len(records, 1))
+	assert.Check(t, cmp.DeepEqual([]jmap.ID{"S4"}, ps.ids()))
+}
+
+func TestResumePushSubscriptionUnverified(t *testing.T) {
+	ps := &pushServer{t: t, subs: map[jmap.ID]jmap.PushSubscription{}}
+	c, srv := newTestClient(t, ps.ServeHTTP)
+	defer srv.Close()
+	c.Enable(jmap.PushSubscriptionUnmarshallers)
+	clock := jmaptest.NewFakeClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	c.Clock = clock
+
+	store := &MemoryPushSubscriptionStore{}
+	opts := PushSubscriptionOptions{
+		DeviceClientID:      "device",
+		URL:                 "https://push.example.org/1",
+		VerificationTimeout: time.Minute,
+	}
+
+	rec, err := c.ResumePushSubscription(store, opts)
+	assert.NilError(t, err)
+	assert.Check(t, cmp.Equal(jmap.ID("S1"), rec.ID))
+
+	// PushVerification may still arrive.
+	clock.Advance(30 * time.Second)
+	rec, err = c.ResumePushSubscription(store, opts)
+	assert.NilError(t, err)
+	assert.Check(t, cmp.Equal(jmap.ID("S1"), rec.ID))
+
+	// PushVerification was lost, e.g. the process restarted before it
+	// arrived.
+	clock.Advance(time.Minute)
+	rec, err = c.ResumePushSubscription(store, opts)
+	assert.NilError(t, err)
+	assert.Check(t, cmp.Equal(jmap.ID("S2"), rec.ID))
+	assert.Check(t, cmp.DeepEqual([]jmap.ID{"S2"}, ps.ids()))
+}
@@ -0,0 +1,96 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/foxcpp/go-jmap"
+)
+
+// ErrReadOnly is returned by methods that send requests to the server if
+// the Client is created from a Session snapshot using NewFromSnapshot.
+var ErrReadOnly = errors.New("jmap/client: client is created from a Session snapshot and cannot send requests")
+
+// SessionSnapshotVersion is the version of the SessionSnapshot format
+// produced by ExportSession.
+const SessionSnapshotVersion = 1
+
+// SessionSnapshot is the portable JSON representation of the negotiated
+// Session, as produced by ExportSession.
+//
+// It contains no credentials and can be passed to other services that need
+// to know the capabilities and accounts of the user's server.
+type SessionSnapshot struct {
+	// The version of the snapshot format, see SessionSnapshotVersion.
+	Version int `json:"version"`
+
+	// The Session endpoint URL the Session was fetched from.
+	SessionEndpoint string `json:"sessionEndpoint"`
+
+	// The time the snapshot was created at.
+	Created time.Time `json:"created"`
+
+	// Effective core limits of the Session (see jmap.Session.Limits),
+	// included for consumers that do not interpret capabilities on their
+	// own.
+	Limits jmap.CoreCapability `json:"limits"`
+
+	// The Session object.
+	Session jmap.Session `json:"session"`
+}
+
+// ExportSession returns the JSON-encoded SessionSnapshot of the last seen
+// Session object, fetching it if necessary.
+//
+// Use NewFromSnapshot to construct a read-only Client from the snapshot.
+func (c *Client) ExportSession() ([]byte, error) {
+	session, err := c.CurrentSession()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(SessionSnapshot{
+		Version:         SessionSnapshotVersion,
+		SessionEndpoint: c.SessionEndpoint,
+		Created:         ClockOrSystem(c.Clock).Now().UTC(),
+		Limits:          session.Limits(),
+		Session:         *session,
+	})
+}
+
+// ParseSessionSnapshot decodes the snapshot produced by ExportSession.
+func ParseSessionSnapshot(data []byte) (*SessionSnapshot, error) {
+	var snap SessionSnapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return nil, err
+	}
+	if snap.Version < 1 || snap.Version > SessionSnapshotVersion {
+		return nil, fmt.Errorf("jmap/client: unsupported Session snapshot version: %d", snap.Version)
+	}
+	return &snap, nil
+}
+
+// NewFromSnapshot creates a read-only Client from the snapshot produced by
+// ExportSession.
+//
+// The Client has no credentials and can be used only to inspect the
+// Session, e.g. using CurrentSession or WebSocketURL. Methods that send
+// requests to the server, including UpdateSession, return ErrReadOnly.
+func NewFromSnapshot(data []byte) (*Client, error) {
+	snap, err := ParseSessionSnapshot(data)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{
+		SessionEndpoint: snap.SessionEndpoint,
+		Session:         &snap.Session,
+		readOnly:        true,
+	}, nil
+}
+
+// ReadOnly reports whether the Client is created using NewFromSnapshot and
+// cannot send requests.
+func (c *Client) ReadOnly() bool {
+	return c.readOnly
+}
@@ -0,0 +1,69 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/foxcpp/go-jmap"
+	"github.com/foxcpp/go-jmap/jmaptest"
+	"gotest.tools/assert"
+	"gotest.tools/assert/cmp"
+)
+
+func TestSessionSnapshot(t *testing.T) {
+	var requests int
+	c, srv := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+	})
+	defer srv.Close()
+	c.Clock = jmaptest.NewFakeClock(time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC))
+
+	blob, err := c.ExportSession()
+	assert.NilError(t, err)
+	assert.Check(t, !strings.Contains(string(blob), "Authentication"))
+
+	snap, err := ParseSessionSnapshot(blob)
+	assert.NilError(t, err)
+	assert.Check(t, cmp.Equal(SessionSnapshotVersion, snap.Version))
+	assert.Check(t, cmp.Equal(c.SessionEndpoint, snap.SessionEndpoint))
+	assert.Check(t, snap.Created.Equal(time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)))
+	assert.Check(t, cmp.Equal(jmap.UnsignedInt(500), snap.Limits.MaxObjectsInSet))
+
+	ro, err := NewFromSnapshot(blob)
+	assert.NilError(t, err)
+	assert.Check(t, ro.ReadOnly())
+	assert.Check(t, !c.ReadOnly())
+
+	session, err := ro.CurrentSession()
+	assert.NilError(t, err)
+	assert.Check(t, cmp.Equal("test@example.org", session.Username))
+	assert.Check(t, cmp.Equal(jmap.ID("A1"), session.PrimaryAccounts["urn:ietf:params:jmap:mail"]))
+	assert.Check(t, session.MailCapability != nil)
+	assert.Check(t, session.Accounts["A1"].HasCapability(jmap.MailCapabilityName))
+
+	_, err = ro.UpdateSession()
+	assert.Check(t, cmp.Equal(ErrReadOnly, err))
+	assert.Check(t, cmp.Equal(ErrReadOnly, ro.Echo()))
+	assert.Check(t, cmp.Equal(ErrReadOnly, ro.Clone().Echo()))
+	_, err = ro.Upload("A1", strings.NewReader("blob"))
+	assert.Check(t, cmp.Equal(ErrReadOnly, err))
+	_, err = ro.Download("A1", "B1")
+	assert.Check(t, cmp.Equal(ErrReadOnly, err))
+	_, err = ro.OpenEventSource(context.Background(), EventSourceOptions{})
+	assert.Check(t, cmp.Equal(ErrReadOnly, err))
+	assert.Check(t, cmp.Equal(0, requests))
+
+	t.Run("unsupported version", func(t *testing.T) {
+		var raw map[string]interface{}
+		assert.NilError(t, json.Unmarshal(blob, &raw))
+		raw["version"] = SessionSnapshotVersion + 1
+		blob, err := json.Marshal(raw)
+		assert.NilError(t, err)
+		_, err = NewFromSnapshot(blob)
+		assert.Check(t, err != nil)
+	})
+}
@@ -0,0 +1,48 @@
+package client
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+)
+
+// spool reads r completely. If it contains no more than threshold octets,
+// the data is kept in memory, otherwise it is written to a temporary file in
+// dir.
+//
+// The returned cleanup function must be called once the returned reader is
+// no longer needed.
+func spool(r io.Reader, threshold int64, dir string) (io.Reader, func(), error) {
+	var buf bytes.Buffer
+	n, err := io.Copy(&buf, io.LimitReader(r, threshold+1))
+	if err != nil {
+		return nil, nil, err
+	}
+	if n <= threshold {
+		return &buf, func() {}, nil
+	}
+
+	f, err := ioutil.TempFile(dir, "jmap-response-")
+	if err != nil {
+		return nil, nil, err
+	}
+	cleanup := func() {
+		f.Close()
+		os.Remove(f.Name())
+	}
+
+	if _, err := buf.WriteTo(f); err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	return f, cleanup, nil
+}
@@ -0,0 +1,76 @@
+package client
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/foxcpp/go-jmap"
+	"gotest.tools/assert"
+	"gotest.tools/assert/cmp"
+)
+
+func TestSpool(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-jmap-spill-")
+	assert.NilError(t, err)
+	defer os.RemoveAll(dir)
+
+	t.Run("in memory", func(t *testing.T) {
+		r, cleanup, err := spool(strings.NewReader("0123456789"), 10, dir)
+		assert.NilError(t, err)
+		defer cleanup()
+		_, isFile := r.(*os.File)
+		assert.Check(t, !isFile)
+		data, err := ioutil.ReadAll(r)
+		assert.NilError(t, err)
+		assert.Check(t, cmp.Equal("0123456789", string(data)))
+	})
+
+	t.Run("spilled", func(t *testing.T) {
+		r, cleanup, err := spool(strings.NewReader("0123456789A"), 10, dir)
+		assert.NilError(t, err)
+		f, isFile := r.(*os.File)
+		assert.Assert(t, isFile)
+		data, err := ioutil.ReadAll(r)
+		assert.NilError(t, err)
+		assert.Check(t, cmp.Equal("0123456789A", string(data)))
+
+		cleanup()
+		_, err = os.Stat(f.Name())
+		assert.Check(t, os.IsNotExist(err))
+	})
+}
+
+func TestClientSpill(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-jmap-spill-")
+	assert.NilError(t, err)
+	defer os.RemoveAll(dir)
+
+	c, srv := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{ //nolint:errcheck
+			"methodResponses": []interface{}{
+				[]interface{}{"Core/echo", map[string]interface{}{"data": strings.Repeat("x", 1000)}, "0"},
+			},
+			"sessionState": "1",
+		})
+	})
+	defer srv.Close()
+	c.Enable(jmap.RawUnmarshallers([]string{"Core/echo"}))
+	c.SpillThreshold = 100
+	c.SpillDir = dir
+
+	resp, err := c.RawSend(&jmap.Request{Calls: []jmap.Invocation{
+		{Name: "Core/echo", CallID: "0", Args: map[string]interface{}{}},
+	}})
+	assert.NilError(t, err)
+	assert.Assert(t, cmp.Len(resp.Responses, 1))
+	assert.Check(t, cmp.Contains(string(resp.Responses[0].Args.(json.RawMessage)), "xxxx"))
+
+	files, err := ioutil.ReadDir(dir)
+	assert.NilError(t, err)
+	assert.Check(t, cmp.Len(files, 0))
+}
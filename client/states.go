@@ -0,0 +1,190 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/foxcpp/go-jmap"
+)
+
+// StateTracker records the last seen state of each data type and makes the
+// client pass it as ifInState argument of /set calls, so the server rejects
+// changes if the data was modified by someone else since the caller last
+// looked at it.
+//
+// States are taken from the state argument of /get responses and newState
+// argument of /changes and /set responses.
+//
+// Zero value is ready to use. It is safe for concurrent use.
+type StateTracker struct {
+	lck    sync.Mutex
+	states map[jmap.ID]map[string]string
+}
+
+// State returns the last seen state of the data type in the account or empty
+// string if it is unknown.
+func (st *StateTracker) State(account jmap.ID, typeName string) string {
+	st.lck.Lock()
+	defer st.lck.Unlock()
+	return st.states[account][typeName]
+}
+
+// SetState records the state of the data type in the account, e.g. loaded
+// from a local cache. Empty state removes the record.
+func (st *StateTracker) SetState(account jmap.ID, typeName, state string) {
+	st.lck.Lock()
+	defer st.lck.Unlock()
+	if state == "" {
+		delete(st.states[account], typeName)
+		return
+	}
+	if st.states == nil {
+		st.states = make(map[jmap.ID]map[string]string)
+	}
+	if st.states[account] == nil {
+		st.states[account] = make(map[string]string)
+	}
+	st.states[account][typeName] = state
+}
+
+// StateMismatchError is returned by Client.Call instead of
+// jmap.MethodErrorArgs with stateMismatch type if Client.States is set.
+//
+// It means the data was changed since the state known to the caller, the
+// caller should resynchronize it (e.g. using /changes) and retry the call
+// if it still makes sense.
+type StateMismatchError struct {
+	// Name of the rejected method call.
+	Method string
+
+	// The account used for the call.
+	Account jmap.ID
+
+	// The error returned by the server.
+	Err jmap.MethodErrorArgs
+}
+
+func (sme *StateMismatchError) Error() string {
+	return fmt.Sprintf("jmap/client: %s rejected, data in account %s was changed since it was last fetched", sme.Method, sme.Account)
+}
+
+func (sme *StateMismatchError) Unwrap() error {
+	return sme.Err
+}
+
+func splitMethod(name string) (typeName, method string) {
+	slash := strings.IndexByte(name, '/')
+	if slash == -1 {
+		return "", name
+	}
+	return name[:slash], name[slash+1:]
+}
+
+// callAccount returns the accountId argument of the method call, if any.
+func callAccount(args interface{}) jmap.ID {
+	blob, err := json.Marshal(args)
+	if err != nil {
+		return ""
+	}
+	var res struct {
+		AccountID jmap.ID `json:"accountId"`
+	}
+	json.Unmarshal(blob, &res)
+	return res.AccountID
+}
+
+// injectStates returns the copy of the request with ifInState set for the
+// first /set call of each data type, unless already set. Following calls
+// are not changed since the state is expected to be changed by the first
+// one.
+func (st *StateTracker) injectStates(r *jmap.Request) (*jmap.Request, error) {
+	var res *jmap.Request
+	seen := map[string]bool{}
+	for i, call := range r.Calls {
+		typeName, method := splitMethod(call.Name)
+		if method != "set" {
+			continue
+		}
+
+		blob, err := json.Marshal(call.Args)
+		if err != nil {
+			return nil, err
+		}
+		var args map[string]json.RawMessage
+		if err := json.Unmarshal(blob, &args); err != nil {
+			return nil, err
+		}
+		var account jmap.ID
+		if err := json.Unmarshal(args["accountId"], &account); err != nil {
+			continue
+		}
+
+		key := string(account) + "/" + typeName
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		if _, ok := args["ifInState"]; ok {
+			continue
+		}
+		state := st.State(account, typeName)
+		if state == "" {
+			continue
+		}
+		args["ifInState"], err = json.Marshal(state)
+		if err != nil {
+			return nil, err
+		}
+
+		if res == nil {
+			res = &jmap.Request{
+				Using:      r.Using,
+				Calls:      append([]jmap.Invocation(nil), r.Calls...),
+				CreatedIDs: r.CreatedIDs,
+			}
+		}
+		res.Calls[i].Args = args
+	}
+	if res == nil {
+		return r, nil
+	}
+	return res, nil
+}
+
+// observe records states returned in the response.
+func (st *StateTracker) observe(resp *jmap.Response) {
+	for _, inv := range resp.Responses {
+		typeName, method := splitMethod(inv.Name)
+		switch method {
+		case "get", "changes", "set":
+		default:
+			continue
+		}
+
+		blob, ok := inv.Args.(json.RawMessage)
+		if !ok {
+			var err error
+			blob, err = json.Marshal(inv.Args)
+			if err != nil {
+				continue
+			}
+		}
+		var args struct {
+			AccountID jmap.ID `json:"accountId"`
+			State     string  `json:"state"`
+			NewState  string  `json:"newState"`
+		}
+		if err := json.Unmarshal(blob, &args); err != nil {
+			continue
+		}
+		state := args.State
+		if method != "get" {
+			state = args.NewState
+		}
+		if state != "" {
+			st.SetState(args.AccountID, typeName, state)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package client
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/foxcpp/go-jmap"
+	"gotest.tools/assert"
+)
+
+func TestStateTracker(t *testing.T) {
+	serverState := "s1"
+	var ifInStates []interface{}
+	c, srv := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			MethodCalls [][3]json.RawMessage `json:"methodCalls"`
+		}
+		assert.NilError(t, json.NewDecoder(r.Body).Decode(&req))
+
+		var resps []interface{}
+		for _, call := range req.MethodCalls {
+			var name string
+			assert.NilError(t, json.Unmarshal(call[0], &name))
+			var args map[string]interface{}
+			assert.NilError(t, json.Unmarshal(call[1], &args))
+
+			switch name {
+			case "Foo/get":
+				resps = append(resps, []interface{}{name, map[string]interface{}{
+					"accountId": "A1", "state": serverState, "list": []interface{}{},
+				}, call[2]})
+			case "Foo/set":
+				ifInStates = append(ifInStates, args["ifInState"])
+				if state, ok := args["ifInState"]; ok && state != serverState {
+					resps = append(resps, []interface{}{"error", map[string]interface{}{
+						"type": "stateMismatch",
+					}, call[2]})
+					continue
+				}
+				oldState := serverState
+				serverState += "+"
+				resps = append(resps, []interface{}{name, map[string]interface{}{
+					"accountId": "A1", "oldState": oldState, "newState": serverState,
+				}, call[2]})
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{ //nolint:errcheck
+			"sessionState":    "1",
+			"methodResponses": resps,
+		})
+	})
+	defer srv.Close()
+	c.Enable(jmap.RawUnmarshallers([]string{"Foo/get", "Foo/set"}))
+	c.States = &StateTracker{}
+
+	setArgs := map[string]interface{}{"accountId": "A1"}
+
+	_, err := c.Call(nil, "Foo/set", setArgs)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, []interface{}{nil}, ifInStates)
+	assert.Equal(t, "s1+", c.States.State("A1", "Foo"))
+
+	_, err = c.Call(nil, "Foo/get", map[string]interface{}{"accountId": "A1"})
+	assert.NilError(t, err)
+	assert.Equal(t, "s1+", c.States.State("A1", "Foo"))
+
+	// Someone else changed the data.
+	serverState = "s2"
+
+	ifInStates = nil
+	_, err = c.Call(nil, "Foo/set", setArgs)
+	assert.DeepEqual(t, []interface{}{"s1+"}, ifInStates)
+	sme, ok := err.(*StateMismatchError)
+	assert.Assert(t, ok, "unexpected error: %v", err)
+	assert.Equal(t, "Foo/set", sme.Method)
+	assert.Equal(t, jmap.ID("A1"), sme.Account)
+	assert.Equal(t, CategoryConflict, Classify(err).Category)
+	_, ok = setArgs["ifInState"]
+	assert.Assert(t, !ok, "original arguments should not be modified")
+
+	// Resync and retry.
+	_, err = c.Call(nil, "Foo/get", map[string]interface{}{"accountId": "A1"})
+	assert.NilError(t, err)
+	ifInStates = nil
+	_, err = c.Call(nil, "Foo/set", setArgs)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, []interface{}{"s2"}, ifInStates)
+
+	t.Run("chained calls", func(t *testing.T) {
+		ifInStates = nil
+		_, err := c.RawSend(&jmap.Request{
+			Calls: []jmap.Invocation{
+				{Name: "Foo/set", CallID: "0", Args: setArgs},
+				{Name: "Foo/set", CallID: "1", Args: setArgs},
+			},
+		})
+		assert.NilError(t, err)
+		assert.DeepEqual(t, []interface{}{"s2+", nil}, ifInStates)
+		assert.Equal(t, "s2+++", c.States.State("A1", "Foo"))
+	})
+
+	t.Run("explicit ifInState", func(t *testing.T) {
+		ifInStates = nil
+		_, err := c.Call(nil, "Foo/set", map[string]interface{}{"accountId": "A1", "ifInState": "old"})
+		assert.Assert(t, err != nil)
+		assert.DeepEqual(t, []interface{}{"old"}, ifInStates)
+	})
+}
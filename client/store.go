@@ -0,0 +1,134 @@
+package client
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/foxcpp/go-jmap/internal/jsonlog"
+)
+
+// Store is the persistent storage for records of type T identified by keys
+// of type K, used by helpers that need to continue their work after a
+// restart (e.g. mail.Outbox and ResumePushSubscription).
+//
+// Implementations must be safe for concurrent use.
+type Store[K ~string, T any] interface {
+	// Save creates or replaces the record.
+	Save(rec T) error
+
+	// Delete removes the record, if any.
+	Delete(key K) error
+
+	// Load returns all stored records.
+	Load() ([]T, error)
+}
+
+type fileStoreRecord[K ~string, T any] struct {
+	Key     K    `json:"key"`
+	Value   *T   `json:"value,omitempty"`
+	Deleted bool `json:"deleted,omitempty"`
+}
+
+// FileStore is the Store implementation that keeps records in memory and
+// appends all changes to a file as JSON lines, calling fsync after each
+// write.
+//
+// The whole store is loaded into memory on open. The file is rewritten with
+// only current records when it grows large enough.
+type FileStore[K ~string, T any] struct {
+	lck     sync.Mutex
+	log     *jsonlog.Log
+	key     func(T) K
+	records map[K]T
+}
+
+// OpenFileStore opens (creating if necessary) the store file and loads
+// existing records from it. key returns the key of the record.
+//
+// A partially written last record (e.g. after a crash) is removed from the
+// file. An error is returned if any other record is malformed.
+func OpenFileStore[K ~string, T any](path string, key func(T) K) (*FileStore[K, T], error) {
+	fs := &FileStore[K, T]{key: key, records: make(map[K]T)}
+	log, err := jsonlog.Open(path, func(line []byte) error {
+		var rec fileStoreRecord[K, T]
+		if err := json.Unmarshal(line, &rec); err != nil {
+			return err
+		}
+		if rec.Deleted || rec.Value == nil {
+			delete(fs.records, rec.Key)
+			return nil
+		}
+		fs.records[rec.Key] = *rec.Value
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	fs.log = log
+	return fs, nil
+}
+
+func (fs *FileStore[K, T]) write(rec fileStoreRecord[K, T]) error {
+	if err := fs.log.Append(rec); err != nil {
+		return err
+	}
+	if !fs.log.NeedsCompaction() {
+		return nil
+	}
+	return fs.log.Rewrite(func(add func(interface{}) error) error {
+		for key, value := range fs.records {
+			value := value
+			if err := add(fileStoreRecord[K, T]{Key: key, Value: &value}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (fs *FileStore[K, T]) Save(rec T) error {
+	fs.lck.Lock()
+	defer fs.lck.Unlock()
+	key := fs.key(rec)
+	prev, existed := fs.records[key]
+	fs.records[key] = rec
+	if err := fs.write(fileStoreRecord[K, T]{Key: key, Value: &rec}); err != nil {
+		if existed {
+			fs.records[key] = prev
+		} else {
+			delete(fs.records, key)
+		}
+		return err
+	}
+	return nil
+}
+
+func (fs *FileStore[K, T]) Delete(key K) error {
+	fs.lck.Lock()
+	defer fs.lck.Unlock()
+	prev, ok := fs.records[key]
+	if !ok {
+		return nil
+	}
+	delete(fs.records, key)
+	if err := fs.write(fileStoreRecord[K, T]{Key: key, Deleted: true}); err != nil {
+		fs.records[key] = prev
+		return err
+	}
+	return nil
+}
+
+func (fs *FileStore[K, T]) Load() ([]T, error) {
+	fs.lck.Lock()
+	defer fs.lck.Unlock()
+	res := make([]T, 0, len(fs.records))
+	for _, rec := range fs.records {
+		res = append(res, rec)
+	}
+	return res, nil
+}
+
+// Close closes the underlying file.
+func (fs *FileStore[K, T]) Close() error {
+	return fs.log.Close()
+}
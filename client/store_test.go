@@ -0,0 +1,44 @@
+package client
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"gotest.tools/assert"
+	"gotest.tools/assert/cmp"
+)
+
+type storeTestRecord struct {
+	ID    string `json:"id"`
+	Value int    `json:"value"`
+}
+
+func TestFileStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-jmap-store-")
+	assert.NilError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "store")
+	key := func(rec storeTestRecord) string { return rec.ID }
+
+	var _ Store[string, storeTestRecord] = &FileStore[string, storeTestRecord]{}
+
+	fs, err := OpenFileStore(path, key)
+	assert.NilError(t, err)
+	assert.NilError(t, fs.Save(storeTestRecord{ID: "a", Value: 1}))
+	assert.NilError(t, fs.Save(storeTestRecord{ID: "b", Value: 2}))
+	assert.NilError(t, fs.Save(storeTestRecord{ID: "a", Value: 3}))
+	assert.NilError(t, fs.Delete("b"))
+	assert.NilError(t, fs.Save(storeTestRecord{ID: "c", Value: 4}))
+	assert.NilError(t, fs.Close())
+
+	fs, err = OpenFileStore(path, key)
+	assert.NilError(t, err)
+	defer fs.Close()
+	recs, err := fs.Load()
+	assert.NilError(t, err)
+	sort.Slice(recs, func(i, j int) bool { return recs[i].ID < recs[j].ID })
+	assert.Check(t, cmp.DeepEqual([]storeTestRecord{{ID: "a", Value: 3}, {ID: "c", Value: 4}}, recs))
+}
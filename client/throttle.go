@@ -0,0 +1,195 @@
+package client
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/foxcpp/go-jmap"
+)
+
+const (
+	minRateLimitBackoff = time.Second
+	maxRateLimitBackoff = time.Minute
+)
+
+// ThrottleState describes the request budget as last reported by the
+// server.
+type ThrottleState struct {
+	// Requests are delayed until this time. Zero if the client is not
+	// currently throttled.
+	Until time.Time
+
+	// Values of X-RateLimit-Limit and X-RateLimit-Remaining headers. -1 if
+	// not reported by the server. Remaining is decremented locally for each
+	// request sent since the last report.
+	Limit     int
+	Remaining int
+
+	// Time when the server resets the budget (X-RateLimit-Reset header).
+	// Zero if not reported.
+	Reset time.Time
+}
+
+// Throttle paces API requests according to hints returned by the server so
+// the client does not run into rate limits repeatedly.
+//
+// The following hints are used:
+//   - Retry-After header (for any response, usually 429 and 503),
+//   - X-RateLimit-Limit, X-RateLimit-Remaining and X-RateLimit-Reset
+//     headers, remaining budget is spread evenly until the reset time,
+//   - rateLimit method errors and 429 responses without Retry-After, in
+//     which case exponential backoff is used.
+//
+// Zero value is ready to use. It is safe for concurrent use.
+type Throttle struct {
+	// The first and the maximum delay used for exponential backoff. If
+	// zero, 1 second and 1 minute are used respectively. Must not be changed
+	// after the Throttle is used.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+
+	lck       sync.Mutex
+	init      bool
+	until     time.Time
+	limit     int
+	remaining int
+	reset     time.Time
+	last      time.Time
+	backoff   time.Duration
+}
+
+func (t *Throttle) lazyInit() {
+	if !t.init {
+		t.limit, t.remaining = -1, -1
+		t.init = true
+	}
+}
+
+// State returns the current throttling state.
+func (t *Throttle) State() ThrottleState {
+	t.lck.Lock()
+	defer t.lck.Unlock()
+	t.lazyInit()
+	return ThrottleState{
+		Until:     t.until,
+		Limit:     t.limit,
+		Remaining: t.remaining,
+		Reset:     t.reset,
+	}
+}
+
+// reserve returns how long the request should be delayed and accounts it in
+// the budget.
+func (t *Throttle) reserve(now time.Time) time.Duration {
+	t.lck.Lock()
+	defer t.lck.Unlock()
+	t.lazyInit()
+
+	start := now
+	if t.until.After(start) {
+		start = t.until
+	}
+	if t.reset.After(now) {
+		switch {
+		case t.remaining == 0:
+			if t.reset.After(start) {
+				start = t.reset
+			}
+		case t.remaining > 0:
+			next := t.last.Add(t.reset.Sub(now) / time.Duration(t.remaining))
+			if next.After(start) {
+				start = next
+			}
+		}
+	}
+	if t.remaining > 0 {
+		t.remaining--
+	}
+	t.last = start
+	return start.Sub(now)
+}
+
+// observe updates the state using response headers and the status code.
+func (t *Throttle) observe(now time.Time, status int, header http.Header) {
+	t.lck.Lock()
+	defer t.lck.Unlock()
+	t.lazyInit()
+
+	if v, err := strconv.Atoi(header.Get("X-RateLimit-Limit")); err == nil {
+		t.limit = v
+	}
+	if v, err := strconv.Atoi(header.Get("X-RateLimit-Remaining")); err == nil {
+		t.remaining = v
+	}
+	if v, err := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		// Some servers report the reset time as a Unix timestamp, others as
+		// the number of seconds until it.
+		if v > 1000000000 {
+			t.reset = time.Unix(v, 0)
+		} else {
+			t.reset = now.Add(time.Duration(v) * time.Second)
+		}
+	}
+
+	if retryAfter, ok := parseRetryAfter(now, header.Get("Retry-After")); ok {
+		t.until = retryAfter
+		return
+	}
+	if status == http.StatusTooManyRequests {
+		t.backOff(now)
+	} else if status/100 == 2 {
+		t.backoff = 0
+	}
+}
+
+// observeResponse checks for rateLimit method errors.
+func (t *Throttle) observeResponse(now time.Time, resp *jmap.Response) {
+	for _, inv := range resp.Responses {
+		if inv.Name != "error" {
+			continue
+		}
+		if args, ok := inv.Args.(jmap.MethodErrorArgs); ok && args.Type == jmap.CodeRateLimit {
+			t.lck.Lock()
+			t.backOff(now)
+			t.lck.Unlock()
+			return
+		}
+	}
+}
+
+func (t *Throttle) backOff(now time.Time) {
+	minBackoff, maxBackoff := t.MinBackoff, t.MaxBackoff
+	if minBackoff == 0 {
+		minBackoff = minRateLimitBackoff
+	}
+	if maxBackoff == 0 {
+		maxBackoff = maxRateLimitBackoff
+	}
+
+	if t.backoff == 0 {
+		t.backoff = minBackoff
+	} else if t.backoff < maxBackoff {
+		t.backoff *= 2
+		if t.backoff > maxBackoff {
+			t.backoff = maxBackoff
+		}
+	}
+	if until := now.Add(t.backoff); until.After(t.until) {
+		t.until = until
+	}
+}
+
+func parseRetryAfter(now time.Time, value string) (time.Time, bool) {
+	if value == "" {
+		return time.Time{}, false
+	}
+	if secs, err := strconv.Atoi(value); err == nil {
+		return now.Add(time.Duration(secs) * time.Second), true
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return t, true
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,119 @@
+package client
+
+import (
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/foxcpp/go-jmap"
+	"github.com/foxcpp/go-jmap/jmaptest"
+	"gotest.tools/assert"
+	"gotest.tools/assert/cmp"
+)
+
+func TestThrottle(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("no hints", func(t *testing.T) {
+		var th Throttle
+		assert.Check(t, cmp.Equal(time.Duration(0), th.reserve(now)))
+		assert.Check(t, cmp.Equal(time.Duration(0), th.reserve(now)))
+		assert.Check(t, cmp.DeepEqual(ThrottleState{Limit: -1, Remaining: -1}, th.State()))
+	})
+
+	t.Run("retry after", func(t *testing.T) {
+		var th Throttle
+		th.observe(now, http.StatusTooManyRequests, http.Header{"Retry-After": {"5"}})
+		assert.Check(t, cmp.Equal(5*time.Second, th.reserve(now)))
+		assert.Check(t, th.State().Until.Equal(now.Add(5*time.Second)))
+
+		th.observe(now, http.StatusServiceUnavailable, http.Header{
+			"Retry-After": {now.Add(time.Minute).Format(http.TimeFormat)},
+		})
+		assert.Check(t, cmp.Equal(time.Minute, th.reserve(now)))
+	})
+
+	t.Run("budget", func(t *testing.T) {
+		var th Throttle
+		th.observe(now, http.StatusOK, http.Header{
+			"X-Ratelimit-Limit":     {"100"},
+			"X-Ratelimit-Remaining": {"4"},
+			"X-Ratelimit-Reset":     {"8"},
+		})
+		// 4 requests in 8 seconds - one per 2 seconds.
+		assert.Check(t, cmp.Equal(time.Duration(0), th.reserve(now)))
+		state := th.State()
+		assert.Check(t, cmp.Equal(100, state.Limit))
+		assert.Check(t, cmp.Equal(3, state.Remaining))
+		assert.Check(t, state.Reset.Equal(now.Add(8*time.Second)))
+
+		now := now.Add(time.Second)
+		assert.Check(t, cmp.Equal(1333333333*time.Nanosecond, th.reserve(now)))
+
+		th.observe(now, http.StatusOK, http.Header{
+			"X-Ratelimit-Remaining": {"0"},
+			"X-Ratelimit-Reset":     {"1577836810"},
+		})
+		assert.Check(t, cmp.Equal(9*time.Second, th.reserve(now)))
+	})
+
+	t.Run("backoff", func(t *testing.T) {
+		var th Throttle
+		rateLimited := &jmap.Response{Responses: []jmap.Invocation{
+			{Name: "error", CallID: "0", Args: jmap.MethodErrorArgs{Type: jmap.CodeRateLimit}},
+		}}
+		th.observeResponse(now, rateLimited)
+		assert.Check(t, cmp.Equal(time.Second, th.reserve(now)))
+		th.observeResponse(now, rateLimited)
+		assert.Check(t, cmp.Equal(2*time.Second, th.reserve(now)))
+		th.observe(now, http.StatusTooManyRequests, http.Header{})
+		assert.Check(t, cmp.Equal(4*time.Second, th.reserve(now)))
+
+		th.observe(now, http.StatusOK, http.Header{})
+		th.observeResponse(now, rateLimited)
+		assert.Check(t, cmp.Equal(4*time.Second, th.reserve(now)), "previous delay is kept")
+		assert.Check(t, cmp.Equal(time.Second, th.backoff))
+	})
+
+	t.Run("custom backoff", func(t *testing.T) {
+		th := Throttle{MinBackoff: 5 * time.Second, MaxBackoff: 8 * time.Second}
+		th.observe(now, http.StatusTooManyRequests, http.Header{})
+		assert.Check(t, cmp.Equal(5*time.Second, th.reserve(now)))
+		th.observe(now, http.StatusTooManyRequests, http.Header{})
+		assert.Check(t, cmp.Equal(8*time.Second, th.reserve(now)))
+	})
+}
+
+func TestClientThrottle(t *testing.T) {
+	var requests int32
+	c, srv := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			w.Header().Set("Retry-After", "10")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"sessionState":"1","methodResponses":[["Core/echo",{},"echo0"]]}`)) //nolint:errcheck
+	})
+	defer srv.Close()
+
+	clock := jmaptest.NewFakeClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	c.Clock = clock
+	c.Throttle = &Throttle{}
+	c.Enable(jmap.RawUnmarshallers([]string{"Core/echo"}))
+
+	err := c.Echo()
+	assert.Check(t, cmp.Equal(CategoryQuota, Classify(err).Category))
+	assert.Check(t, c.Throttle.State().Until.Equal(clock.Now().Add(10*time.Second)))
+
+	done := make(chan error)
+	go func() {
+		done <- c.Echo()
+	}()
+	clock.BlockUntil(1)
+	assert.Check(t, cmp.Equal(int32(1), atomic.LoadInt32(&requests)), "request is sent before the delay")
+	clock.Advance(10 * time.Second)
+	assert.NilError(t, <-done)
+	assert.Check(t, cmp.Equal(int32(2), atomic.LoadInt32(&requests)))
+}
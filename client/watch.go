@@ -0,0 +1,466 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/foxcpp/go-jmap"
+)
+
+// WatchEvent describes the change of a single object reported by Watcher.
+type WatchEvent struct {
+	// One of jmap.SetCreate, jmap.SetUpdate or jmap.SetDestroy.
+	Op jmap.SetOp
+
+	ID jmap.ID
+
+	// The object as returned by /get method, nil for jmap.SetDestroy.
+	Object json.RawMessage
+}
+
+// Decode unmarshals Object into v (e.g. *mail.Mailbox).
+func (e WatchEvent) Decode(v interface{}) error {
+	if e.Object == nil {
+		return nil
+	}
+	return json.Unmarshal(e.Object, v)
+}
+
+// WatchOptions contains options for Client.Watch.
+type WatchOptions struct {
+	// Capabilities to use for method calls. If nil, JMAP Core capability and
+	// the capability registered for the data type using
+	// EnableMethodCapabilities are used.
+	Using []string
+
+	// Properties to fetch for created and updated objects. If nil, all
+	// properties are fetched.
+	Properties []string
+
+	// State to report changes since. If empty, the current state is fetched
+	// when the watcher starts and only subsequent changes are reported.
+	SinceState string
+
+	// How often to check for changes. Defaults to 30 seconds. Not used if
+	// Events is set.
+	Interval time.Duration
+
+	// If not nil, changes are checked only when the EventSource reports
+	// a new state of the data type for the account instead of polling.
+	//
+	// The EventSource should not be used by anything else while the watcher
+	// is running.
+	Events *EventSource
+
+	// Clock to use for polling. If nil, SystemClock is used.
+	Clock Clock
+}
+
+// Watcher reports changes of objects of a single data type. Use
+// Client.Watch to create it.
+type Watcher struct {
+	c        *Client
+	account  jmap.ID
+	typeName string
+	opts     WatchOptions
+
+	out    chan WatchEvent
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// Delivers the event to the consumer and closes the output channel,
+	// see Watch and Client.Watch.
+	deliver func(ev WatchEvent) error
+	finish  func()
+
+	lck   sync.Mutex
+	state string
+	err   error
+}
+
+// Watch starts watching objects of the data type (e.g. "Mailbox") in the
+// account.
+//
+// Changes are discovered using /changes method of the data type and
+// created or updated objects are fetched using /get, so both methods must
+// have unmarshallers enabled in the client (e.g. mail.ResponseUnmarshallers
+// or jmap.RawUnmarshallers).
+//
+// Objects that are created and destroyed between two checks may be not
+// reported at all.
+//
+// The channel returned by Watcher.C is closed if Stop is called or an error
+// occurs, Err can be used to distinguish these cases. If the server can't
+// calculate changes since the last seen state, the watcher stops with
+// jmap.MethodErrorArgs error of type cannotCalculateChanges, in this case
+// the caller should fetch all objects again and start a new watcher.
+//
+// The watcher is stopped when the Client is closed.
+//
+// Use Watch to receive objects decoded into a Go type.
+func (c *Client) Watch(account jmap.ID, typeName string, opts WatchOptions) *Watcher {
+	w := c.newWatcher(account, typeName, opts)
+	w.out = make(chan WatchEvent)
+	w.deliver = func(ev WatchEvent) error {
+		select {
+		case w.out <- ev:
+			return nil
+		case <-w.ctx.Done():
+			return w.ctx.Err()
+		}
+	}
+	w.finish = func() { close(w.out) }
+	w.start()
+	return w
+}
+
+func (c *Client) newWatcher(account jmap.ID, typeName string, opts WatchOptions) *Watcher {
+	if opts.Interval == 0 {
+		opts.Interval = 30 * time.Second
+	}
+	opts.Clock = ClockOrSystem(opts.Clock)
+	if opts.Using == nil {
+		opts.Using = []string{jmap.CoreCapabilityName}
+		if capability, ok := c.methodCaps[typeName]; ok && capability != jmap.CoreCapabilityName {
+			opts.Using = append(opts.Using, capability)
+		}
+	}
+
+	w := &Watcher{
+		c:        c,
+		account:  account,
+		typeName: typeName,
+		opts:     opts,
+		state:    opts.SinceState,
+	}
+	w.ctx, w.cancel = context.WithCancel(context.Background())
+	return w
+}
+
+func (w *Watcher) start() {
+	if err := w.c.RunBackground(w.run, w.Stop); err != nil {
+		w.err = err
+		w.finish()
+	}
+}
+
+// C returns the channel events are delivered on.
+func (w *Watcher) C() <-chan WatchEvent {
+	return w.out
+}
+
+// State returns the state of the data type the reported events correspond
+// to. It can be saved and passed as WatchOptions.SinceState later to resume
+// watching.
+func (w *Watcher) State() string {
+	w.lck.Lock()
+	defer w.lck.Unlock()
+	return w.state
+}
+
+// Err returns the error that caused the watcher to stop, if any.
+//
+// It should be called only after the channel returned by C is closed.
+func (w *Watcher) Err() error {
+	w.lck.Lock()
+	defer w.lck.Unlock()
+	return w.err
+}
+
+// Stop stops the watcher. The channel returned by C is closed shortly after.
+func (w *Watcher) Stop() {
+	w.cancel()
+}
+
+func (w *Watcher) run() {
+	defer w.finish()
+
+	var err error
+	if w.State() == "" {
+		err = w.fetchState()
+	} else {
+		err = w.applyChanges()
+	}
+	if err != nil {
+		w.fail(err)
+		return
+	}
+
+	for {
+		if !w.wait() {
+			return
+		}
+		if err := w.applyChanges(); err != nil {
+			w.fail(err)
+			return
+		}
+	}
+}
+
+func (w *Watcher) fail(err error) {
+	if w.ctx.Err() != nil {
+		// Stopped, the error is likely caused by that.
+		return
+	}
+	w.lck.Lock()
+	defer w.lck.Unlock()
+	w.err = err
+}
+
+func (w *Watcher) setState(state string) {
+	w.lck.Lock()
+	defer w.lck.Unlock()
+	w.state = state
+}
+
+// wait blocks until it is time to check for changes. It returns false if
+// the watcher is stopped.
+func (w *Watcher) wait() bool {
+	if w.opts.Events == nil {
+		select {
+		case <-w.opts.Clock.After(w.opts.Interval):
+			return true
+		case <-w.ctx.Done():
+			return false
+		}
+	}
+
+	for {
+		sc, err := w.opts.Events.Next(w.ctx)
+		if err != nil {
+			w.fail(err)
+			return false
+		}
+		if state, ok := sc.Changed[w.account][w.typeName]; ok && state != w.State() {
+			return true
+		}
+	}
+}
+
+type watchChanges struct {
+	NewState       string    `json:"newState"`
+	HasMoreChanges bool      `json:"hasMoreChanges"`
+	Created        []jmap.ID `json:"created"`
+	Updated        []jmap.ID `json:"updated"`
+	Destroyed      []jmap.ID `json:"destroyed"`
+}
+
+type watchGet struct {
+	State string            `json:"state"`
+	List  []json.RawMessage `json:"list"`
+}
+
+// decodeArgs converts method call response arguments into v. args can be
+// either json.RawMessage or the value returned by a typed unmarshaller.
+func decodeArgs(args interface{}, v interface{}) error {
+	blob, ok := args.(json.RawMessage)
+	if !ok {
+		var err error
+		blob, err = json.Marshal(args)
+		if err != nil {
+			return err
+		}
+	}
+	return json.Unmarshal(blob, v)
+}
+
+// fetchState obtains the current state using /get call with no ids.
+func (w *Watcher) fetchState() error {
+	method := w.typeName + "/get"
+	args, err := w.c.Call(w.opts.Using, method, map[string]interface{}{
+		"accountId":  w.account,
+		"ids":        []jmap.ID{},
+		"properties": []string{"id"},
+	})
+	if err != nil {
+		return err
+	}
+	var resp watchGet
+	if err := decodeArgs(args, &resp); err != nil {
+		return err
+	}
+	if resp.State == "" {
+		return fmt.Errorf("jmap/client: %s response has no state", method)
+	}
+	w.setState(resp.State)
+	return nil
+}
+
+func (w *Watcher) applyChanges() error {
+	for {
+		more, err := w.applyChangesBatch()
+		if err != nil || !more {
+			return err
+		}
+	}
+}
+
+// applyChangesBatch fetches and reports a single batch of changes, returning
+// whether there are more.
+func (w *Watcher) applyChangesBatch() (bool, error) {
+	changesMethod, getMethod := w.typeName+"/changes", w.typeName+"/get"
+
+	session, err := w.c.CurrentSession()
+	if err != nil {
+		return false, err
+	}
+
+	b := &Batch{}
+	for _, capability := range w.opts.Using {
+		b.Use(capability)
+	}
+	changesCall := b.NextCallID()
+	b.Add(changesMethod, map[string]interface{}{
+		"accountId":  w.account,
+		"sinceState": w.State(),
+		// Objects reported by /changes are fetched by /get calls of the same
+		// request, so they should not exceed the server limit for /get.
+		"maxChanges": session.Limits().MaxObjectsInGet,
+	})
+	getCalls := map[string]bool{}
+	for _, path := range []string{"/created", "/updated"} {
+		args := map[string]interface{}{
+			"accountId": w.account,
+			"#ids": jmap.ResultReference{
+				ResultOf: changesCall,
+				Name:     changesMethod,
+				Path:     path,
+			},
+		}
+		if w.opts.Properties != nil {
+			args["properties"] = w.opts.Properties
+		}
+		getCalls[b.NextCallID()] = true
+		b.Add(getMethod, args)
+	}
+
+	resp, err := w.c.RawSend(b.Request())
+	if err != nil {
+		return false, err
+	}
+
+	var changes watchChanges
+	objects := map[jmap.ID]json.RawMessage{}
+	for _, inv := range resp.Responses {
+		if methodErr, ok := inv.Args.(jmap.MethodErrorArgs); ok {
+			return false, methodErr
+		}
+		switch {
+		case inv.CallID == changesCall:
+			if err := decodeArgs(inv.Args, &changes); err != nil {
+				return false, err
+			}
+		case getCalls[inv.CallID]:
+			var get watchGet
+			if err := decodeArgs(inv.Args, &get); err != nil {
+				return false, err
+			}
+			for _, obj := range get.List {
+				var idOnly struct {
+					ID jmap.ID `json:"id"`
+				}
+				if err := json.Unmarshal(obj, &idOnly); err != nil {
+					return false, err
+				}
+				objects[idOnly.ID] = obj
+			}
+		}
+	}
+	if changes.NewState == "" {
+		return false, fmt.Errorf("jmap/client: no %s response", changesMethod)
+	}
+
+	var events []WatchEvent
+	for _, id := range changes.Created {
+		// Not found objects are destroyed already, it will be
+		// reported by the next /changes call.
+		if obj, ok := objects[id]; ok {
+			events = append(events, WatchEvent{Op: jmap.SetCreate, ID: id, Object: obj})
+		}
+	}
+	for _, id := range changes.Updated {
+		if obj, ok := objects[id]; ok {
+			events = append(events, WatchEvent{Op: jmap.SetUpdate, ID: id, Object: obj})
+		}
+	}
+	for _, id := range changes.Destroyed {
+		events = append(events, WatchEvent{Op: jmap.SetDestroy, ID: id})
+	}
+	for _, ev := range events {
+		if err := w.deliver(ev); err != nil {
+			return false, err
+		}
+	}
+
+	w.setState(changes.NewState)
+	return changes.HasMoreChanges, nil
+}
+
+// TypedWatchEvent is WatchEvent with the object decoded into T.
+type TypedWatchEvent[T any] struct {
+	// One of jmap.SetCreate, jmap.SetUpdate or jmap.SetDestroy.
+	Op jmap.SetOp
+
+	ID jmap.ID
+
+	// The object as returned by /get method, nil for jmap.SetDestroy.
+	Object *T
+}
+
+// TypedWatcher is Watcher that decodes objects into T. Use Watch to create
+// it.
+type TypedWatcher[T any] struct {
+	w   *Watcher
+	out chan TypedWatchEvent[T]
+}
+
+// Watch is Client.Watch that decodes reported objects into T (e.g.
+// mail.Mailbox for the "Mailbox" data type).
+//
+// If an object can't be decoded, the watcher stops with the error.
+func Watch[T any](c *Client, account jmap.ID, typeName string, opts WatchOptions) *TypedWatcher[T] {
+	tw := &TypedWatcher[T]{out: make(chan TypedWatchEvent[T])}
+	w := c.newWatcher(account, typeName, opts)
+	w.deliver = func(ev WatchEvent) error {
+		typed := TypedWatchEvent[T]{Op: ev.Op, ID: ev.ID}
+		if ev.Object != nil {
+			typed.Object = new(T)
+			if err := json.Unmarshal(ev.Object, typed.Object); err != nil {
+				return fmt.Errorf("jmap/client: failed to decode %s %v: %w", typeName, ev.ID, err)
+			}
+		}
+		select {
+		case tw.out <- typed:
+			return nil
+		case <-w.ctx.Done():
+			return w.ctx.Err()
+		}
+	}
+	w.finish = func() { close(tw.out) }
+	tw.w = w
+	w.start()
+	return tw
+}
+
+// C returns the channel events are delivered on.
+func (tw *TypedWatcher[T]) C() <-chan TypedWatchEvent[T] {
+	return tw.out
+}
+
+// State is Watcher.State.
+func (tw *TypedWatcher[T]) State() string {
+	return tw.w.State()
+}
+
+// Err is Watcher.Err.
+func (tw *TypedWatcher[T]) Err() error {
+	return tw.w.Err()
+}
+
+// Stop is Watcher.Stop.
+func (tw *TypedWatcher[T]) Stop() {
+	tw.w.Stop()
+}
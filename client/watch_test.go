@@ -0,0 +1,139 @@
+package client
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/foxcpp/go-jmap"
+	"github.com/foxcpp/go-jmap/jmaptest"
+	"gotest.tools/assert"
+	"gotest.tools/assert/cmp"
+)
+
+func TestWatch(t *testing.T) {
+	// Responses to Foo/changes and two following Foo/get calls keyed by
+	// sinceState.
+	changes := map[string]string{
+		"1": `["Foo/changes",{"oldState":"1","newState":"2","created":["F1","F2"],"updated":[],"destroyed":[]},"0"],` +
+			`["Foo/get",{"state":"2","list":[{"id":"F1","name":"one"}],"notFound":["F2"]},"1"],` +
+			`["Foo/get",{"state":"2","list":[]},"2"]`,
+		"2": `["Foo/changes",{"oldState":"2","newState":"3","created":[],"updated":["F1"],"destroyed":["F2"]},"0"],` +
+			`["Foo/get",{"state":"3","list":[]},"1"],` +
+			`["Foo/get",{"state":"3","list":[{"id":"F1","name":"uno"}]},"2"]`,
+	}
+	c, srv := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Using []string         `json:"using"`
+			Calls [][3]interface{} `json:"methodCalls"`
+		}
+		assert.NilError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Check(t, cmp.DeepEqual([]string{jmap.CoreCapabilityName, "https://example.com/apis/foo"}, req.Using))
+
+		w.Header().Set("Content-Type", "application/json")
+		args := req.Calls[0][1].(map[string]interface{})
+		var resp string
+		switch req.Calls[0][0] {
+		case "Foo/get":
+			resp = `["Foo/get",{"state":"1","list":[]},"0"]`
+		case "Foo/changes":
+			assert.Equal(t, 3, len(req.Calls))
+			assert.Check(t, cmp.Equal(float64(500), args["maxChanges"]))
+			resp = changes[args["sinceState"].(string)]
+		}
+		if resp == "" {
+			resp = `["error",{"type":"cannotCalculateChanges"},"0"]`
+		}
+		w.Write([]byte(`{"sessionState":"1","methodResponses":[` + resp + `]}`)) //nolint:errcheck
+	})
+	defer srv.Close()
+	c.Enable(jmap.RawUnmarshallers([]string{"Foo/get", "Foo/changes"}))
+	c.EnableMethodCapabilities(map[string]string{"Foo": "https://example.com/apis/foo"})
+
+	clock := jmaptest.NewFakeClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	w := c.Watch("A1", "Foo", WatchOptions{Interval: time.Minute, Clock: clock})
+	defer w.Stop()
+
+	type foo struct {
+		ID   jmap.ID `json:"id"`
+		Name string  `json:"name"`
+	}
+
+	clock.BlockUntil(1)
+	assert.Equal(t, "1", w.State())
+	clock.Advance(time.Minute)
+
+	ev := <-w.C()
+	assert.Check(t, cmp.Equal(jmap.SetCreate, ev.Op))
+	var obj foo
+	assert.NilError(t, ev.Decode(&obj))
+	assert.Check(t, cmp.DeepEqual(foo{ID: "F1", Name: "one"}, obj))
+
+	clock.BlockUntil(1)
+	assert.Equal(t, "2", w.State())
+	clock.Advance(time.Minute)
+
+	ev = <-w.C()
+	assert.Check(t, cmp.Equal(jmap.SetUpdate, ev.Op))
+	assert.NilError(t, ev.Decode(&obj))
+	assert.Check(t, cmp.DeepEqual(foo{ID: "F1", Name: "uno"}, obj))
+	ev = <-w.C()
+	assert.Check(t, cmp.DeepEqual(WatchEvent{Op: jmap.SetDestroy, ID: "F2"}, ev))
+
+	clock.BlockUntil(1)
+	assert.Equal(t, "3", w.State())
+	clock.Advance(time.Minute)
+
+	_, ok := <-w.C()
+	assert.Check(t, !ok)
+	methodErr, ok := w.Err().(jmap.MethodErrorArgs)
+	assert.Assert(t, ok, "unexpected error: %v", w.Err())
+	assert.Equal(t, jmap.CodeCannotCalculateChanges, methodErr.Type)
+}
+
+func TestWatchTyped(t *testing.T) {
+	changes := map[string]string{
+		"1": `["Foo/changes",{"oldState":"1","newState":"2","created":["F1"],"updated":[],"destroyed":["F2"]},"0"],` +
+			`["Foo/get",{"state":"2","list":[{"id":"F1","name":"one"}]},"1"],` +
+			`["Foo/get",{"state":"2","list":[]},"2"]`,
+		"2": `["Foo/changes",{"oldState":"2","newState":"3","created":[],"updated":["F1"],"destroyed":[]},"0"],` +
+			`["Foo/get",{"state":"3","list":[]},"1"],` +
+			`["Foo/get",{"state":"3","list":[{"id":"F1","name":42}]},"2"]`,
+	}
+	c, srv := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Calls [][3]interface{} `json:"methodCalls"`
+		}
+		assert.NilError(t, json.NewDecoder(r.Body).Decode(&req))
+		args := req.Calls[0][1].(map[string]interface{})
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"sessionState":"1","methodResponses":[` + changes[args["sinceState"].(string)] + `]}`)) //nolint:errcheck
+	})
+	defer srv.Close()
+	c.Enable(jmap.RawUnmarshallers([]string{"Foo/get", "Foo/changes"}))
+
+	type foo struct {
+		ID   jmap.ID `json:"id"`
+		Name string  `json:"name"`
+	}
+
+	clock := jmaptest.NewFakeClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	w := Watch[foo](c, "A1", "Foo", WatchOptions{SinceState: "1", Interval: time.Minute, Clock: clock})
+	defer w.Stop()
+
+	ev := <-w.C()
+	assert.Check(t, cmp.DeepEqual(TypedWatchEvent[foo]{Op: jmap.SetCreate, ID: "F1", Object: &foo{ID: "F1", Name: "one"}}, ev))
+	ev = <-w.C()
+	assert.Check(t, cmp.DeepEqual(TypedWatchEvent[foo]{Op: jmap.SetDestroy, ID: "F2"}, ev))
+
+	clock.BlockUntil(1)
+	assert.Equal(t, "2", w.State())
+	clock.Advance(time.Minute)
+
+	// Objects that can't be decoded stop the watcher.
+	_, ok := <-w.C()
+	assert.Check(t, !ok)
+	assert.Check(t, cmp.ErrorContains(w.Err(), "failed to decode Foo F1"))
+	assert.Equal(t, "2", w.State())
+}
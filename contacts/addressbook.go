@@ -0,0 +1,225 @@
+package contacts
+
+import (
+	"encoding/json"
+
+	"github.com/foxcpp/go-jmap"
+)
+
+// AddressBookRights describes the set of rights the user has on the
+// AddressBook.
+type AddressBookRights struct {
+	// The user may fetch the ContactCards in this AddressBook.
+	MayRead bool `json:"mayRead"`
+
+	// The user may create, modify, or destroy all ContactCards in this
+	// AddressBook, or move them to or from this AddressBook.
+	MayWrite bool `json:"mayWrite"`
+
+	// The user may modify the shareWith property for this AddressBook.
+	MayShare bool `json:"mayShare"`
+
+	// The user may delete the AddressBook itself.
+	MayDelete bool `json:"mayDelete"`
+}
+
+// AddressBook is a named collection of ContactCards.
+//
+// See RFC 9610, section 2 for details.
+type AddressBook struct {
+	// The id of the AddressBook.
+	ID jmap.ID `json:"id,omitempty"`
+
+	// The user-visible name of the AddressBook.
+	Name string `json:"name,omitempty"`
+
+	// An optional longer-form description of the AddressBook.
+	Description string `json:"description,omitempty"`
+
+	// Defines the sort order of AddressBooks when presented in the client's
+	// UI so it is consistent between devices.
+	SortOrder jmap.UnsignedInt `json:"sortOrder,omitempty"`
+
+	// This SHOULD be true for exactly one AddressBook in any account and
+	// MUST NOT be true for more than one AddressBook within an account. The
+	// default AddressBook should be used by clients whenever they need to
+	// choose an AddressBook for the user within this account. Set by
+	// server, use AddressBookSetArgs.OnSuccessSetIsDefault to change it.
+	IsDefault bool `json:"isDefault,omitempty"`
+
+	// True if the user has indicated they wish to see this AddressBook in
+	// their client.
+	IsSubscribed bool `json:"isSubscribed,omitempty"`
+
+	// A map of principal id to rights for principals this AddressBook is
+	// shared with. Nil if the AddressBook is not shared.
+	ShareWith map[jmap.ID]AddressBookRights `json:"shareWith,omitempty"`
+
+	// The set of access rights the user has in relation to this
+	// AddressBook. Set by server.
+	MyRights *AddressBookRights `json:"myRights,omitempty"`
+}
+
+// AddressBookGetArgs contains arguments for AddressBook/get method call.
+type AddressBookGetArgs struct {
+	// The id of the account to use.
+	AccountID jmap.ID `json:"accountId"`
+
+	// The ids of the AddressBook objects to return. If nil, then all
+	// records are returned.
+	IDs []jmap.ID `json:"ids"`
+
+	// If not nil, only the properties listed in the array are returned for
+	// each AddressBook object.
+	Properties []string `json:"properties"`
+}
+
+// AddressBookGetResponse contains results of AddressBook/get method call.
+type AddressBookGetResponse struct {
+	// The id of the account used for the call.
+	AccountID jmap.ID `json:"accountId"`
+
+	// A string representing the state on the server for all the data of this
+	// type in the account.
+	State string `json:"state"`
+
+	// An array of the AddressBook objects requested.
+	List []AddressBook `json:"list"`
+
+	// This array contains the ids passed to the method for records that do
+	// not exist.
+	NotFound []jmap.ID `json:"notFound"`
+}
+
+// AddressBookChangesArgs contains arguments for AddressBook/changes method
+// call.
+type AddressBookChangesArgs struct {
+	// The id of the account to use.
+	AccountID jmap.ID `json:"accountId"`
+
+	// The current state of the client, as returned by AddressBook/get.
+	SinceState string `json:"sinceState"`
+
+	// The maximum number of ids to return in the response. Zero means no
+	// limit.
+	MaxChanges jmap.UnsignedInt `json:"maxChanges,omitempty"`
+}
+
+// AddressBookChangesResponse contains results of AddressBook/changes method
+// call.
+type AddressBookChangesResponse struct {
+	// The id of the account used for the call.
+	AccountID jmap.ID `json:"accountId"`
+
+	// This is the sinceState argument echoed back.
+	OldState string `json:"oldState"`
+
+	// This is the state the client will be in after applying the set of
+	// changes to the old state.
+	NewState string `json:"newState"`
+
+	// If true, the client may call AddressBook/changes again with the
+	// NewState returned to get further updates.
+	HasMoreChanges bool `json:"hasMoreChanges"`
+
+	// An array of ids for records that have been created since the old
+	// state.
+	Created []jmap.ID `json:"created"`
+
+	// An array of ids for records that have been updated since the old
+	// state.
+	Updated []jmap.ID `json:"updated"`
+
+	// An array of ids for records that have been destroyed since the old
+	// state.
+	Destroyed []jmap.ID `json:"destroyed"`
+}
+
+// AddressBookSetArgs contains arguments for AddressBook/set method call.
+type AddressBookSetArgs struct {
+	// The id of the account to use.
+	AccountID jmap.ID `json:"accountId"`
+
+	// If not empty, the method will be aborted and a stateMismatch error
+	// returned if the current state does not match this string.
+	IfInState string `json:"ifInState,omitempty"`
+
+	// A map of creation id to AddressBook objects.
+	Create map[jmap.ID]AddressBook `json:"create,omitempty"`
+
+	// A map of id to a patch object to apply to the current AddressBook
+	// object with that id.
+	Update map[jmap.ID]jmap.PatchObject `json:"update,omitempty"`
+
+	// A list of ids for AddressBook objects to permanently delete.
+	Destroy []jmap.ID `json:"destroy,omitempty"`
+
+	// If false, any attempt to destroy an AddressBook that still has
+	// ContactCards in it will be rejected with an addressBookHasContents
+	// SetError. If true, any ContactCards that were in the AddressBook will
+	// be removed from it, and if in no other AddressBooks, they will be
+	// destroyed.
+	OnDestroyRemoveContents bool `json:"onDestroyRemoveContents,omitempty"`
+
+	// If set, the AddressBook with this id (or creation id prefixed with #)
+	// becomes the default one after all creates, updates and destroys
+	// succeed.
+	OnSuccessSetIsDefault jmap.ID `json:"onSuccessSetIsDefault,omitempty"`
+}
+
+// AddressBookSetResponse contains results of AddressBook/set method call.
+type AddressBookSetResponse struct {
+	// The id of the account used for the call.
+	AccountID jmap.ID `json:"accountId"`
+
+	// The state string that would have been returned by AddressBook/get
+	// before making the requested changes.
+	OldState string `json:"oldState"`
+
+	// The state string that will now be returned by AddressBook/get.
+	NewState string `json:"newState"`
+
+	// A map of the creation id to an object containing any properties of the
+	// created AddressBook object that were not sent by the client.
+	Created map[jmap.ID]AddressBook `json:"created"`
+
+	// The keys in this map are the ids of all AddressBooks that were
+	// successfully updated. The value is an AddressBook object containing
+	// any property that changed in a way not explicitly requested, or nil if
+	// none.
+	Updated map[jmap.ID]*AddressBook `json:"updated"`
+
+	// A list of AddressBook ids for records that were successfully
+	// destroyed.
+	Destroyed []jmap.ID `json:"destroyed"`
+
+	// A map of creation id to a SetError object for each record that failed
+	// to be created.
+	NotCreated map[jmap.ID]jmap.SetError `json:"notCreated"`
+
+	// A map of AddressBook id to a SetError object for each record that
+	// failed to be updated.
+	NotUpdated map[jmap.ID]jmap.SetError `json:"notUpdated"`
+
+	// A map of AddressBook id to a SetError object for each record that
+	// failed to be destroyed.
+	NotDestroyed map[jmap.ID]jmap.SetError `json:"notDestroyed"`
+}
+
+func unmarshalAddressBookGetResponse(args json.RawMessage) (interface{}, error) {
+	resp := AddressBookGetResponse{}
+	err := json.Unmarshal(args, &resp)
+	return resp, err
+}
+
+func unmarshalAddressBookChangesResponse(args json.RawMessage) (interface{}, error) {
+	resp := AddressBookChangesResponse{}
+	err := json.Unmarshal(args, &resp)
+	return resp, err
+}
+
+func unmarshalAddressBookSetResponse(args json.RawMessage) (interface{}, error) {
+	resp := AddressBookSetResponse{}
+	err := json.Unmarshal(args, &resp)
+	return resp, err
+}
@@ -0,0 +1,429 @@
+package contacts
+
+import (
+	"encoding/json"
+
+	"github.com/foxcpp/go-jmap"
+	"github.com/foxcpp/go-jmap/internal/jsonprops"
+)
+
+// Values of Card.Kind.
+const (
+	KindIndividual  = "individual"
+	KindGroup       = "group"
+	KindOrg         = "org"
+	KindLocation    = "location"
+	KindDevice      = "device"
+	KindApplication = "application"
+)
+
+// Common contexts used in Contexts maps of JSContact objects.
+const (
+	ContextPrivate = "private"
+	ContextWork    = "work"
+)
+
+// Card is the JSContact Card object describing a person, group,
+// organization or other entity, extended with JMAP-specific properties to
+// form the ContactCard object.
+//
+// Only the commonly used JSContact properties are represented. Maps are
+// keyed by ids local to the Card, assigned by the client.
+//
+// See RFC 9553 and RFC 9610, section 3 for details.
+type Card struct {
+	// The id of the ContactCard. JMAP-specific.
+	ID jmap.ID `json:"id,omitempty"`
+
+	// The set of AddressBook ids this ContactCard belongs to. JMAP-specific.
+	AddressBookIDs map[jmap.ID]bool `json:"addressBookIds,omitempty"`
+
+	// Must be "Card" if set.
+	Type string `json:"@type,omitempty"`
+
+	// The JSContact version, "1.0" for RFC 9553.
+	Version string `json:"version,omitempty"`
+
+	// An identifier that associates the object as the same across different
+	// systems, address books, and views.
+	UID string `json:"uid,omitempty"`
+
+	// The kind of the entity the Card represents, one of Kind* constants.
+	Kind string `json:"kind,omitempty"`
+
+	// The creation date and time of the Card.
+	Created *jmap.UTCDate `json:"created,omitempty"`
+
+	// The date and time when the data in the Card was last modified.
+	Updated *jmap.UTCDate `json:"updated,omitempty"`
+
+	// The language tag (RFC 5646) of the language used for text values.
+	Language string `json:"language,omitempty"`
+
+	// The identifier for the product that created the Card.
+	ProdID string `json:"prodId,omitempty"`
+
+	// The set of Card uids that are members of the group, for KindGroup
+	// cards.
+	Members map[string]bool `json:"members,omitempty"`
+
+	// The Card uids related to this Card.
+	RelatedTo map[string]Relation `json:"relatedTo,omitempty"`
+
+	// The name of the entity.
+	Name *Name `json:"name,omitempty"`
+
+	// The nicknames of the entity.
+	Nicknames map[string]Nickname `json:"nicknames,omitempty"`
+
+	// The organizations the entity belongs to.
+	Organizations map[string]Organization `json:"organizations,omitempty"`
+
+	// The job titles or functional positions of the entity.
+	Titles map[string]Title `json:"titles,omitempty"`
+
+	// The email addresses to contact the entity.
+	Emails map[string]EmailAddress `json:"emails,omitempty"`
+
+	// The phone numbers to contact the entity.
+	Phones map[string]Phone `json:"phones,omitempty"`
+
+	// The online services associated with the entity.
+	OnlineServices map[string]OnlineService `json:"onlineServices,omitempty"`
+
+	// The postal addresses and locations of the entity.
+	Addresses map[string]Address `json:"addresses,omitempty"`
+
+	// Resources such as websites related to the entity.
+	Links map[string]Link `json:"links,omitempty"`
+
+	// Media such as photos or logos of the entity.
+	Media map[string]Link `json:"media,omitempty"`
+
+	// Memorable dates and events for the entity.
+	Anniversaries map[string]Anniversary `json:"anniversaries,omitempty"`
+
+	// The set of free-text keywords, also known as tags.
+	Keywords map[string]bool `json:"keywords,omitempty"`
+
+	// Free-text notes associated with the Card.
+	Notes map[string]Note `json:"notes,omitempty"`
+
+	// Properties not represented by other fields, e.g. JSContact properties
+	// not listed above or vendor extensions. They are kept as is so that
+	// objects can be fetched, converted and stored again without losing
+	// data. Properties set by other fields take precedence. PropertySchemas
+	// accept only vendor-specific names (see jmap.VendorProperties) here.
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+type card Card
+
+func (c Card) MarshalJSON() ([]byte, error) {
+	blob, err := json.Marshal(card(c))
+	if err != nil {
+		return nil, err
+	}
+	return jsonprops.Merge(blob, c.Extra)
+}
+
+func (c *Card) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, (*card)(c)); err != nil {
+		return err
+	}
+
+	var err error
+	c.Extra, err = jsonprops.Unknown(data, c)
+	return err
+}
+
+// Relation describes how the related Card is related to the Card.
+type Relation struct {
+	// The relation types (e.g. "friend", "spouse"). Empty if unspecified.
+	Relation map[string]bool `json:"relation,omitempty"`
+}
+
+// Values of NameComponent.Kind.
+const (
+	NameTitle      = "title"
+	NameGiven      = "given"
+	NameGiven2     = "given2"
+	NameSurname    = "surname"
+	NameSurname2   = "surname2"
+	NameCredential = "credential"
+	NameGeneration = "generation"
+	NameSeparator  = "separator"
+)
+
+// NameComponent is a single component of Name.
+type NameComponent struct {
+	// The kind of the component, one of Name* constants.
+	Kind string `json:"kind"`
+
+	// The value of the component.
+	Value string `json:"value"`
+
+	// The pronunciation of the component.
+	Phonetic string `json:"phonetic,omitempty"`
+}
+
+// Name is the name of the entity.
+type Name struct {
+	// The components making up the name.
+	Components []NameComponent `json:"components,omitempty"`
+
+	// If true, Components are ordered as they should be displayed.
+	IsOrdered bool `json:"isOrdered,omitempty"`
+
+	// The separator to insert between components if IsOrdered is true.
+	DefaultSeparator string `json:"defaultSeparator,omitempty"`
+
+	// The full name of the entity, as it should be displayed.
+	Full string `json:"full,omitempty"`
+
+	// Values to sort the name by, keyed by component kind.
+	SortAs map[string]string `json:"sortAs,omitempty"`
+}
+
+// Nickname is the nickname of the entity.
+type Nickname struct {
+	// The nickname.
+	Name string `json:"name"`
+
+	// The contexts in which to use the nickname.
+	Contexts map[string]bool `json:"contexts,omitempty"`
+
+	// The preference of the nickname in relation to others, 1 is the most
+	// preferred. Zero means unspecified.
+	Pref jmap.UnsignedInt `json:"pref,omitempty"`
+}
+
+// OrgUnit is the organizational unit within Organization.
+type OrgUnit struct {
+	// The name of the unit.
+	Name string `json:"name"`
+
+	// The value to sort the unit by.
+	SortAs string `json:"sortAs,omitempty"`
+}
+
+// Organization is the company or organization the entity belongs to.
+type Organization struct {
+	// The name of the organization.
+	Name string `json:"name,omitempty"`
+
+	// The organizational units, from the most to the least general.
+	Units []OrgUnit `json:"units,omitempty"`
+
+	// The value to sort the organization by.
+	SortAs string `json:"sortAs,omitempty"`
+
+	// The contexts in which the organization applies.
+	Contexts map[string]bool `json:"contexts,omitempty"`
+}
+
+// Title is the job title or functional position of the entity.
+type Title struct {
+	// The title.
+	Name string `json:"name"`
+
+	// Either "title" (the default) or "role".
+	Kind string `json:"kind,omitempty"`
+
+	// The key of the Organization in Card.Organizations the title relates
+	// to.
+	OrganizationID string `json:"organizationId,omitempty"`
+}
+
+// EmailAddress is the email address to contact the entity.
+type EmailAddress struct {
+	// The email address.
+	Address string `json:"address"`
+
+	// The contexts in which to use the address.
+	Contexts map[string]bool `json:"contexts,omitempty"`
+
+	// The preference of the address in relation to others, 1 is the most
+	// preferred. Zero means unspecified.
+	Pref jmap.UnsignedInt `json:"pref,omitempty"`
+
+	// A custom label for the value.
+	Label string `json:"label,omitempty"`
+}
+
+// Phone is the phone number to contact the entity.
+type Phone struct {
+	// The phone number, either as a URI or free text.
+	Number string `json:"number"`
+
+	// The set of contact features the number supports (e.g. "voice",
+	// "text", "mobile").
+	Features map[string]bool `json:"features,omitempty"`
+
+	// The contexts in which to use the number.
+	Contexts map[string]bool `json:"contexts,omitempty"`
+
+	// The preference of the number in relation to others, 1 is the most
+	// preferred. Zero means unspecified.
+	Pref jmap.UnsignedInt `json:"pref,omitempty"`
+
+	// A custom label for the value.
+	Label string `json:"label,omitempty"`
+}
+
+// OnlineService is the online service account of the entity.
+type OnlineService struct {
+	// The name of the service (e.g. "Mastodon").
+	Service string `json:"service,omitempty"`
+
+	// The identifier of the account as URI.
+	URI string `json:"uri,omitempty"`
+
+	// The name of the account on the service.
+	User string `json:"user,omitempty"`
+
+	// The contexts in which to use the service.
+	Contexts map[string]bool `json:"contexts,omitempty"`
+
+	// The preference of the service in relation to others, 1 is the most
+	// preferred. Zero means unspecified.
+	Pref jmap.UnsignedInt `json:"pref,omitempty"`
+
+	// A custom label for the value.
+	Label string `json:"label,omitempty"`
+}
+
+// Values of AddressComponent.Kind.
+const (
+	AddressRoom        = "room"
+	AddressApartment   = "apartment"
+	AddressFloor       = "floor"
+	AddressBuilding    = "building"
+	AddressNumber      = "number"
+	AddressName        = "name"
+	AddressBlock       = "block"
+	AddressSubdistrict = "subdistrict"
+	AddressDistrict    = "district"
+	AddressLocality    = "locality"
+	AddressRegion      = "region"
+	AddressPostcode    = "postcode"
+	AddressCountry     = "country"
+	AddressDirection   = "direction"
+	AddressLandmark    = "landmark"
+	AddressPostOffice  = "postOfficeBox"
+	AddressSeparator   = "separator"
+)
+
+// AddressComponent is a single component of Address.
+type AddressComponent struct {
+	// The kind of the component, one of Address* constants.
+	Kind string `json:"kind"`
+
+	// The value of the component.
+	Value string `json:"value"`
+
+	// The pronunciation of the component.
+	Phonetic string `json:"phonetic,omitempty"`
+}
+
+// Address is the postal address or location of the entity.
+type Address struct {
+	// The components making up the address.
+	Components []AddressComponent `json:"components,omitempty"`
+
+	// If true, Components are ordered as they should be displayed.
+	IsOrdered bool `json:"isOrdered,omitempty"`
+
+	// The separator to insert between components if IsOrdered is true.
+	DefaultSeparator string `json:"defaultSeparator,omitempty"`
+
+	// The full address, as it should be displayed.
+	Full string `json:"full,omitempty"`
+
+	// The Alpha-2 country code (ISO 3166-1).
+	CountryCode string `json:"countryCode,omitempty"`
+
+	// A "geo:" URI (RFC 5870) for the address.
+	Coordinates string `json:"coordinates,omitempty"`
+
+	// The IANA time zone name of the address.
+	TimeZone string `json:"timeZone,omitempty"`
+
+	// The contexts in which to use the address. "billing" and "delivery"
+	// are defined in addition to the common ones.
+	Contexts map[string]bool `json:"contexts,omitempty"`
+
+	// The preference of the address in relation to others, 1 is the most
+	// preferred. Zero means unspecified.
+	Pref jmap.UnsignedInt `json:"pref,omitempty"`
+}
+
+// Link is the resource associated with the entity, used for both
+// Card.Links and Card.Media.
+type Link struct {
+	// The kind of the resource (e.g. "contact" for links, "photo" or "logo"
+	// for media).
+	Kind string `json:"kind,omitempty"`
+
+	// The resource URI.
+	URI string `json:"uri"`
+
+	// The media type (RFC 2046) of the resource.
+	MediaType string `json:"mediaType,omitempty"`
+
+	// The contexts in which to use the resource.
+	Contexts map[string]bool `json:"contexts,omitempty"`
+
+	// The preference of the resource in relation to others, 1 is the most
+	// preferred. Zero means unspecified.
+	Pref jmap.UnsignedInt `json:"pref,omitempty"`
+
+	// A custom label for the value.
+	Label string `json:"label,omitempty"`
+}
+
+// AnniversaryDate is either JSContact PartialDate (Type is "PartialDate"
+// or empty) or Timestamp (Type is "Timestamp").
+type AnniversaryDate struct {
+	Type string `json:"@type,omitempty"`
+
+	// Fields of PartialDate. Zero means the value is unknown.
+	Year  jmap.UnsignedInt `json:"year,omitempty"`
+	Month jmap.UnsignedInt `json:"month,omitempty"`
+	Day   jmap.UnsignedInt `json:"day,omitempty"`
+
+	// The calendar system (RFC 7529) the date is expressed in. Gregorian if
+	// empty.
+	CalendarScale string `json:"calendarScale,omitempty"`
+
+	// Set for Timestamp.
+	UTC *jmap.UTCDate `json:"utc,omitempty"`
+}
+
+// Anniversary is the memorable date or event for the entity.
+type Anniversary struct {
+	// The kind of the anniversary: "birth", "death", "wedding" or
+	// vendor-specific.
+	Kind string `json:"kind"`
+
+	// The date of the anniversary.
+	Date AnniversaryDate `json:"date"`
+}
+
+// Author describes who created the Note.
+type Author struct {
+	Name string `json:"name,omitempty"`
+	URI  string `json:"uri,omitempty"`
+}
+
+// Note is the free-text note associated with the Card.
+type Note struct {
+	// The text of the note.
+	Note string `json:"note"`
+
+	// The date and time when the note was created.
+	Created *jmap.UTCDate `json:"created,omitempty"`
+
+	// The author of the note.
+	Author *Author `json:"author,omitempty"`
+}
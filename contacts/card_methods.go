@@ -0,0 +1,375 @@
+package contacts
+
+import (
+	"encoding/json"
+
+	"github.com/foxcpp/go-jmap"
+)
+
+// ContactCardGetArgs contains arguments for ContactCard/get method call.
+type ContactCardGetArgs struct {
+	// The id of the account to use.
+	AccountID jmap.ID `json:"accountId"`
+
+	// The ids of the ContactCard objects to return. If nil, then all records
+	// are returned.
+	IDs []jmap.ID `json:"ids"`
+
+	// If not nil, only the properties listed in the array are returned for
+	// each ContactCard object.
+	Properties []string `json:"properties"`
+}
+
+// ContactCardGetResponse contains results of ContactCard/get method call.
+type ContactCardGetResponse struct {
+	// The id of the account used for the call.
+	AccountID jmap.ID `json:"accountId"`
+
+	// A string representing the state on the server for all the data of this
+	// type in the account.
+	State string `json:"state"`
+
+	// An array of the ContactCard objects requested.
+	List []Card `json:"list"`
+
+	// This array contains the ids passed to the method for records that do
+	// not exist.
+	NotFound []jmap.ID `json:"notFound"`
+}
+
+// ContactCardChangesArgs contains arguments for ContactCard/changes method
+// call.
+type ContactCardChangesArgs struct {
+	// The id of the account to use.
+	AccountID jmap.ID `json:"accountId"`
+
+	// The current state of the client, as returned by ContactCard/get.
+	SinceState string `json:"sinceState"`
+
+	// The maximum number of ids to return in the response. Zero means no
+	// limit.
+	MaxChanges jmap.UnsignedInt `json:"maxChanges,omitempty"`
+}
+
+// ContactCardChangesResponse contains results of ContactCard/changes method
+// call.
+type ContactCardChangesResponse struct {
+	// The id of the account used for the call.
+	AccountID jmap.ID `json:"accountId"`
+
+	// This is the sinceState argument echoed back.
+	OldState string `json:"oldState"`
+
+	// This is the state the client will be in after applying the set of
+	// changes to the old state.
+	NewState string `json:"newState"`
+
+	// If true, the client may call ContactCard/changes again with the
+	// NewState returned to get further updates.
+	HasMoreChanges bool `json:"hasMoreChanges"`
+
+	// An array of ids for records that have been created since the old
+	// state.
+	Created []jmap.ID `json:"created"`
+
+	// An array of ids for records that have been updated since the old
+	// state.
+	Updated []jmap.ID `json:"updated"`
+
+	// An array of ids for records that have been destroyed since the old
+	// state.
+	Destroyed []jmap.ID `json:"destroyed"`
+}
+
+// ContactCardFilterCondition is the filter condition object for
+// ContactCard/query. Condition matches ContactCard only if all non-empty
+// fields match. Text fields match if the corresponding property contains
+// the given string.
+//
+// Conditions can be combined using jmap.FilterOperator.
+//
+// See RFC 9610, section 3.3 for details.
+type ContactCardFilterCondition struct {
+	// The AddressBook id the ContactCard must be in.
+	InAddressBook jmap.ID `json:"inAddressBook,omitempty"`
+
+	// The uid property of the ContactCard must be equal to the given value.
+	UID string `json:"uid,omitempty"`
+
+	// The members property of the ContactCard must contain the given uid.
+	HasMember string `json:"hasMember,omitempty"`
+
+	// The kind property of the ContactCard must be equal to the given value.
+	Kind string `json:"kind,omitempty"`
+
+	// Limits on created and updated properties of the ContactCard.
+	CreatedBefore *jmap.UTCDate `json:"createdBefore,omitempty"`
+	CreatedAfter  *jmap.UTCDate `json:"createdAfter,omitempty"`
+	UpdatedBefore *jmap.UTCDate `json:"updatedBefore,omitempty"`
+	UpdatedAfter  *jmap.UTCDate `json:"updatedAfter,omitempty"`
+
+	// Looks for the text in all properties of the ContactCard.
+	Text string `json:"text,omitempty"`
+
+	// Looks for the text in the name property or its components.
+	Name         string `json:"name,omitempty"`
+	NameGiven    string `json:"name/given,omitempty"`
+	NameSurname  string `json:"name/surname,omitempty"`
+	NameSurname2 string `json:"name/surname2,omitempty"`
+
+	// Looks for the text in the corresponding properties of the
+	// ContactCard.
+	Nickname      string `json:"nickname,omitempty"`
+	Organization  string `json:"organization,omitempty"`
+	Email         string `json:"email,omitempty"`
+	Phone         string `json:"phone,omitempty"`
+	OnlineService string `json:"onlineService,omitempty"`
+	Address       string `json:"address,omitempty"`
+	Note          string `json:"note,omitempty"`
+}
+
+// Properties that can be used in ContactCardComparator.
+const (
+	ContactCardSortCreated      = "created"
+	ContactCardSortUpdated      = "updated"
+	ContactCardSortNameGiven    = "name/given"
+	ContactCardSortNameSurname  = "name/surname"
+	ContactCardSortNameSurname2 = "name/surname2"
+)
+
+// ContactCardComparator is the sort comparator for ContactCard/query.
+// Property must be one of ContactCardSort* constants.
+type ContactCardComparator struct {
+	jmap.Comparator
+}
+
+// ContactCardQueryArgs contains arguments for ContactCard/query method call.
+type ContactCardQueryArgs struct {
+	// The id of the account to use.
+	AccountID jmap.ID `json:"accountId"`
+
+	// Determines the set of ContactCards returned in the results. Must be
+	// either ContactCardFilterCondition or jmap.FilterOperator. If nil, no
+	// filtering is performed.
+	Filter interface{} `json:"filter,omitempty"`
+
+	// Lists the names of properties to compare between two ContactCard
+	// records, and how to compare them, to determine which comes first in
+	// the sort.
+	Sort []ContactCardComparator `json:"sort,omitempty"`
+
+	// The zero-based index of the first id in the full list of results to
+	// return. Negative values are offsets from the end of the list.
+	Position jmap.Int `json:"position,omitempty"`
+
+	// A ContactCard id. If supplied, the position argument is ignored.
+	Anchor jmap.ID `json:"anchor,omitempty"`
+
+	// The index of the first result to return relative to the index of the
+	// anchor, if an anchor is given.
+	AnchorOffset jmap.Int `json:"anchorOffset,omitempty"`
+
+	// The maximum number of results to return. If zero, no limit is
+	// presumed.
+	Limit jmap.UnsignedInt `json:"limit,omitempty"`
+
+	// Does the client wish to know the total number of results in the
+	// query?
+	CalculateTotal bool `json:"calculateTotal,omitempty"`
+}
+
+// ContactCardQueryResponse contains results of ContactCard/query method
+// call.
+type ContactCardQueryResponse struct {
+	// The id of the account used for the call.
+	AccountID jmap.ID `json:"accountId"`
+
+	// A string encoding the current state of the query on the server.
+	QueryState string `json:"queryState"`
+
+	// This is true if the server supports calling ContactCard/queryChanges
+	// with these filter/sort parameters.
+	CanCalculateChanges bool `json:"canCalculateChanges"`
+
+	// The zero-based index of the first result in the ids array within the
+	// complete list of query results.
+	Position jmap.UnsignedInt `json:"position"`
+
+	// The list of ids for each ContactCard in the query results, starting
+	// at the index given by the position argument of this response and
+	// continuing until it hits the end of the results or reaches the limit
+	// number of ids.
+	IDs []jmap.ID `json:"ids"`
+
+	// The total number of ContactCards in the results (given the filter).
+	// Only set if CalculateTotal was requested.
+	Total jmap.UnsignedInt `json:"total,omitempty"`
+
+	// The limit enforced by the server on the maximum number of results to
+	// return. Only set if the server set a limit or used a different limit
+	// than that given in the request.
+	Limit jmap.UnsignedInt `json:"limit,omitempty"`
+}
+
+// ContactCardQueryChangesArgs contains arguments for
+// ContactCard/queryChanges method call.
+type ContactCardQueryChangesArgs struct {
+	// The id of the account to use.
+	AccountID jmap.ID `json:"accountId"`
+
+	// The filter argument that was used with ContactCard/query.
+	Filter interface{} `json:"filter,omitempty"`
+
+	// The sort argument that was used with ContactCard/query.
+	Sort []ContactCardComparator `json:"sort,omitempty"`
+
+	// The current state of the query in the client, as returned in the
+	// queryState argument of ContactCard/query response with the same
+	// sort/filter.
+	SinceQueryState string `json:"sinceQueryState"`
+
+	// The maximum number of changes to return in the response. If zero, no
+	// limit is presumed.
+	MaxChanges jmap.UnsignedInt `json:"maxChanges,omitempty"`
+
+	// The last (highest-index) id the client currently has cached from the
+	// query results. If supplied, the server may skip changes past this id.
+	UpToID jmap.ID `json:"upToId,omitempty"`
+
+	// Does the client wish to know the total number of results now in the
+	// query?
+	CalculateTotal bool `json:"calculateTotal,omitempty"`
+}
+
+// QueryChanges returns ContactCard/queryChanges arguments for the query
+// with the same filter and sort.
+func (args ContactCardQueryArgs) QueryChanges(sinceQueryState string) ContactCardQueryChangesArgs {
+	return ContactCardQueryChangesArgs{
+		AccountID:       args.AccountID,
+		Filter:          args.Filter,
+		Sort:            args.Sort,
+		SinceQueryState: sinceQueryState,
+		CalculateTotal:  args.CalculateTotal,
+	}
+}
+
+// ContactCardQueryChangesResponse contains results of
+// ContactCard/queryChanges method call.
+type ContactCardQueryChangesResponse struct {
+	// The id of the account used for the call.
+	AccountID jmap.ID `json:"accountId"`
+
+	// This is the sinceQueryState argument echoed back.
+	OldQueryState string `json:"oldQueryState"`
+
+	// This is the state the query will be in after applying the set of
+	// changes to the old state.
+	NewQueryState string `json:"newQueryState"`
+
+	// The total number of ContactCards in the results (given the filter).
+	// Only set if CalculateTotal was requested.
+	Total jmap.UnsignedInt `json:"total,omitempty"`
+
+	// The ids for ContactCards that have been removed from the results since
+	// the old state, or whose position may have changed.
+	Removed []jmap.ID `json:"removed"`
+
+	// ContactCards that have been added to the results since the old state,
+	// or whose position may have changed, sorted by index.
+	Added []jmap.AddedItem `json:"added"`
+}
+
+// Apply updates the cached ContactCard/query results, see
+// jmap.ApplyQueryChanges for details.
+func (resp ContactCardQueryChangesResponse) Apply(ids []jmap.ID) []jmap.ID {
+	return jmap.ApplyQueryChanges(ids, resp.Removed, resp.Added)
+}
+
+// ContactCardSetArgs contains arguments for ContactCard/set method call.
+type ContactCardSetArgs struct {
+	// The id of the account to use.
+	AccountID jmap.ID `json:"accountId"`
+
+	// If not empty, the method will be aborted and a stateMismatch error
+	// returned if the current state does not match this string.
+	IfInState string `json:"ifInState,omitempty"`
+
+	// A map of creation id to ContactCard objects.
+	Create map[jmap.ID]Card `json:"create,omitempty"`
+
+	// A map of id to a patch object to apply to the current ContactCard
+	// object with that id.
+	Update map[jmap.ID]jmap.PatchObject `json:"update,omitempty"`
+
+	// A list of ids for ContactCard objects to permanently delete.
+	Destroy []jmap.ID `json:"destroy,omitempty"`
+}
+
+// ContactCardSetResponse contains results of ContactCard/set method call.
+type ContactCardSetResponse struct {
+	// The id of the account used for the call.
+	AccountID jmap.ID `json:"accountId"`
+
+	// The state string that would have been returned by ContactCard/get
+	// before making the requested changes.
+	OldState string `json:"oldState"`
+
+	// The state string that will now be returned by ContactCard/get.
+	NewState string `json:"newState"`
+
+	// A map of the creation id to an object containing any properties of the
+	// created ContactCard object that were not sent by the client.
+	Created map[jmap.ID]Card `json:"created"`
+
+	// The keys in this map are the ids of all ContactCards that were
+	// successfully updated. The value is a ContactCard object containing any
+	// property that changed in a way not explicitly requested, or nil if
+	// none.
+	Updated map[jmap.ID]*Card `json:"updated"`
+
+	// A list of ContactCard ids for records that were successfully
+	// destroyed.
+	Destroyed []jmap.ID `json:"destroyed"`
+
+	// A map of creation id to a SetError object for each record that failed
+	// to be created.
+	NotCreated map[jmap.ID]jmap.SetError `json:"notCreated"`
+
+	// A map of ContactCard id to a SetError object for each record that
+	// failed to be updated.
+	NotUpdated map[jmap.ID]jmap.SetError `json:"notUpdated"`
+
+	// A map of ContactCard id to a SetError object for each record that
+	// failed to be destroyed.
+	NotDestroyed map[jmap.ID]jmap.SetError `json:"notDestroyed"`
+}
+
+func unmarshalContactCardGetResponse(args json.RawMessage) (interface{}, error) {
+	resp := ContactCardGetResponse{}
+	err := json.Unmarshal(args, &resp)
+	return resp, err
+}
+
+func unmarshalContactCardChangesResponse(args json.RawMessage) (interface{}, error) {
+	resp := ContactCardChangesResponse{}
+	err := json.Unmarshal(args, &resp)
+	return resp, err
+}
+
+func unmarshalContactCardQueryResponse(args json.RawMessage) (interface{}, error) {
+	resp := ContactCardQueryResponse{}
+	err := json.Unmarshal(args, &resp)
+	return resp, err
+}
+
+func unmarshalContactCardQueryChangesResponse(args json.RawMessage) (interface{}, error) {
+	resp := ContactCardQueryChangesResponse{}
+	err := json.Unmarshal(args, &resp)
+	return resp, err
+}
+
+func unmarshalContactCardSetResponse(args json.RawMessage) (interface{}, error) {
+	resp := ContactCardSetResponse{}
+	err := json.Unmarshal(args, &resp)
+	return resp, err
+}
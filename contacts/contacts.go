@@ -0,0 +1,105 @@
+// Package contacts implements data types and methods of JMAP for Contacts
+// extension as defined in RFC 9610.
+//
+// ContactCard objects use JSContact format defined in RFC 9553.
+//
+// Documentation strings for most of the protocol objects are taken from (or
+// based on) contents of RFC 9610 and RFC 9553 and are subject to the IETF
+// Trust Provisions. See https://trustee.ietf.org/trust-legal-provisions.html
+// for details.
+package contacts
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/foxcpp/go-jmap"
+)
+
+const CapabilityName = "urn:ietf:params:jmap:contacts"
+
+var ErrNoCapability = errors.New("jmap/contacts: urn:ietf:params:jmap:contacts capability is not supported for the account")
+
+// Capability is the urn:ietf:params:jmap:contacts account capability
+// object.
+type Capability struct {
+	// The maximum number of AddressBooks that can be assigned to a single
+	// ContactCard object. Nil means no limit.
+	MaxAddressBooksPerCard *jmap.UnsignedInt `json:"maxAddressBooksPerCard"`
+
+	// If true, the user may create an AddressBook in this account.
+	MayCreateAddressBook bool `json:"mayCreateAddressBook"`
+}
+
+// AccountCapability returns decoded urn:ietf:params:jmap:contacts capability
+// object of the account.
+//
+// ErrNoCapability is returned if the account does not contain contacts
+// data.
+func AccountCapability(acc *jmap.Account) (*Capability, error) {
+	raw, ok := acc.Capabilities[CapabilityName]
+	if !ok {
+		return nil, ErrNoCapability
+	}
+	res := &Capability{}
+	if err := json.Unmarshal(raw, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// ResponseUnmarshallers contains callbacks for decoding responses of all
+// methods implemented by this package.
+//
+// Pass it to client.Enable to make the client decode these responses into
+// corresponding structures.
+var ResponseUnmarshallers = map[string]jmap.FuncArgsUnmarshal{
+	"AddressBook/get":     unmarshalAddressBookGetResponse,
+	"AddressBook/changes": unmarshalAddressBookChangesResponse,
+	"AddressBook/set":     unmarshalAddressBookSetResponse,
+
+	"ContactCard/get":          unmarshalContactCardGetResponse,
+	"ContactCard/changes":      unmarshalContactCardChangesResponse,
+	"ContactCard/query":        unmarshalContactCardQueryResponse,
+	"ContactCard/queryChanges": unmarshalContactCardQueryChangesResponse,
+	"ContactCard/set":          unmarshalContactCardSetResponse,
+}
+
+// MethodCapabilities maps data types implemented by this package to
+// capabilities that define them.
+//
+// Pass it to client.EnableMethodCapabilities to let the client determine
+// which capabilities can be dropped from requests during capability
+// downgrade.
+var MethodCapabilities = map[string]string{
+	"AddressBook": CapabilityName,
+	"ContactCard": CapabilityName,
+}
+
+func init() {
+	// All data types of the package can be used in push type filters.
+	jmap.RegisterPushTypes(MethodCapabilities)
+}
+
+// PropertySchemas contains property lists for all data types implemented by
+// this package.
+//
+// Pass it to client.EnableSchemas to make the client reject requests using
+// unknown properties before sending them.
+var PropertySchemas = jmap.PropertySchemas{
+	"AddressBook": {
+		"id", "name", "description", "sortOrder", "isDefault", "isSubscribed",
+		"shareWith", "myRights",
+	},
+	"ContactCard": {
+		"id", "addressBookIds", "@type", "version", "created", "kind",
+		"language", "members", "prodId", "relatedTo", "uid", "updated",
+		"name", "nicknames", "organizations", "speakToAs", "titles", "emails",
+		"onlineServices", "phones", "preferredLanguages", "calendars",
+		"schedulingAddresses", "addresses", "cryptoKeys", "directories",
+		"links", "media", "localizations", "anniversaries", "keywords",
+		"notes", "personalInfo",
+
+		jmap.VendorProperties,
+	},
+}
@@ -0,0 +1,85 @@
+package contacts
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/foxcpp/go-jmap"
+	"gotest.tools/assert"
+	"gotest.tools/assert/cmp"
+)
+
+func TestAccountCapability(t *testing.T) {
+	acc := jmap.Account{Capabilities: map[string]json.RawMessage{
+		CapabilityName: json.RawMessage(`{"maxAddressBooksPerCard":1,"mayCreateAddressBook":true}`),
+	}}
+	capa, err := AccountCapability(&acc)
+	assert.NilError(t, err)
+	assert.Check(t, cmp.Equal(jmap.UnsignedInt(1), *capa.MaxAddressBooksPerCard))
+	assert.Check(t, capa.MayCreateAddressBook)
+
+	_, err = AccountCapability(&jmap.Account{})
+	assert.Check(t, cmp.Equal(ErrNoCapability, err))
+}
+
+func TestPushTypes(t *testing.T) {
+	assert.Check(t, cmp.Equal(CapabilityName, jmap.PushTypeCapabilities[jmap.PushTypeContactCard]))
+	assert.Check(t, cmp.Equal(CapabilityName, jmap.PushTypeCapabilities[jmap.PushTypeAddressBook]))
+
+	s := jmap.Session{Capabilities: map[string]json.RawMessage{}}
+	err := s.ValidatePushTypes([]string{jmap.PushTypeContactCard})
+	assert.Check(t, cmp.ErrorContains(err, CapabilityName))
+}
+
+func TestContactCardGetResponse(t *testing.T) {
+	blob := json.RawMessage(`{
+		"accountId": "A1",
+		"state": "s1",
+		"list": [{
+			"id": "C1",
+			"addressBookIds": {"AB1": true},
+			"@type": "Card",
+			"version": "1.0",
+			"uid": "urn:uuid:4fbe8971-0bc3-424c-9c26-36c3e1eff6b1",
+			"kind": "individual",
+			"name": {
+				"components": [
+					{"kind": "given", "value": "Robert"},
+					{"kind": "surname", "value": "Pau"}
+				],
+				"isOrdered": true
+			},
+			"emails": {
+				"e1": {"address": "rob@example.com", "contexts": {"work": true}, "pref": 1}
+			},
+			"anniversaries": {
+				"k8": {"kind": "birth", "date": {"year": 1953, "month": 4, "day": 15}}
+			}
+		}],
+		"notFound": ["C2"]
+	}`)
+	args, err := ResponseUnmarshallers["ContactCard/get"](blob)
+	assert.NilError(t, err)
+	resp := args.(ContactCardGetResponse)
+	assert.Check(t, cmp.DeepEqual([]jmap.ID{"C2"}, resp.NotFound))
+	assert.Equal(t, 1, len(resp.List))
+
+	card := resp.List[0]
+	assert.Check(t, cmp.DeepEqual(map[jmap.ID]bool{"AB1": true}, card.AddressBookIDs))
+	assert.Check(t, cmp.Equal(KindIndividual, card.Kind))
+	assert.Check(t, cmp.DeepEqual([]NameComponent{
+		{Kind: NameGiven, Value: "Robert"},
+		{Kind: NameSurname, Value: "Pau"},
+	}, card.Name.Components))
+	assert.Check(t, cmp.DeepEqual(EmailAddress{
+		Address:  "rob@example.com",
+		Contexts: map[string]bool{ContextWork: true},
+		Pref:     1,
+	}, card.Emails["e1"]))
+	assert.Check(t, cmp.Equal(jmap.UnsignedInt(1953), card.Anniversaries["k8"].Date.Year))
+
+	// Unset properties should not be sent in /set calls.
+	out, err := json.Marshal(Card{Name: &Name{Full: "Robert Pau"}})
+	assert.NilError(t, err)
+	assert.Check(t, cmp.Equal(`{"name":{"full":"Robert Pau"}}`, string(out)))
+}
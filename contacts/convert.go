@@ -0,0 +1,149 @@
+package contacts
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/foxcpp/go-jmap"
+	"github.com/foxcpp/go-jmap/client"
+	"github.com/foxcpp/go-jmap/internal/bulk"
+)
+
+// VCardDecoder converts vCard data (RFC 6350) to JSContact Cards, e.g. using
+// the mapping defined in RFC 9555.
+//
+// This package does not implement vCard parsing, decoders are provided by the
+// application, usually as wrappers around third-party libraries.
+type VCardDecoder interface {
+	// DecodeVCard reads the stream of vCards and returns them as Cards.
+	DecodeVCard(r io.Reader) ([]Card, error)
+}
+
+// VCardEncoder converts JSContact Cards to vCard data (RFC 6350).
+type VCardEncoder interface {
+	// EncodeVCard writes the cards as a stream of vCards.
+	EncodeVCard(w io.Writer, cards []Card) error
+}
+
+// VCardConverter converts JSContact Cards to and from vCard.
+type VCardConverter interface {
+	VCardDecoder
+	VCardEncoder
+}
+
+var using = []string{jmap.CoreCapabilityName, CapabilityName}
+
+// ImportResult contains results of ImportVCard.
+type ImportResult struct {
+	// Ids of created ContactCards in the order of decoded cards. Ids of cards
+	// that failed to be created are empty.
+	IDs []jmap.ID
+
+	// Errors for cards that failed to be created, keyed by their index in IDs.
+	Failed map[int]jmap.SetError
+}
+
+// ImportVCard decodes the vCard data using dec and creates the cards in the
+// AddressBook using as many ContactCard/set calls as needed to respect the
+// maxObjectsInSet limit. Ids and AddressBookIDs of decoded cards are replaced.
+//
+// Properties of decoded cards not represented by Card fields must be
+// placed in Card.Extra by dec to be stored on the server.
+//
+// If a call fails as a whole, the error is returned along with results of
+// previous calls.
+//
+// The client must have ResponseUnmarshallers enabled.
+func ImportVCard(c *client.Client, account, addressBook jmap.ID, dec VCardDecoder, r io.Reader) (*ImportResult, error) {
+	cards, err := dec.DecodeVCard(r)
+	if err != nil {
+		return nil, err
+	}
+	session, err := c.CurrentSession()
+	if err != nil {
+		return nil, err
+	}
+	chunkSize := int(session.Limits().MaxObjectsInSet)
+
+	for i := range cards {
+		cards[i].ID = ""
+		cards[i].AddressBookIDs = map[jmap.ID]bool{addressBook: true}
+	}
+
+	res := &ImportResult{}
+	res.IDs, res.Failed, err = bulk.Create(cards, chunkSize, func(create map[jmap.ID]Card) (map[jmap.ID]jmap.ID, map[jmap.ID]jmap.SetError, error) {
+		respArgs, err := c.Call(using, "ContactCard/set", ContactCardSetArgs{
+			AccountID: account,
+			Create:    create,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		resp, ok := respArgs.(ContactCardSetResponse)
+		if !ok {
+			return nil, nil, unexpectedResponse("ContactCard/set", respArgs)
+		}
+		created := make(map[jmap.ID]jmap.ID, len(resp.Created))
+		for cid, obj := range resp.Created {
+			created[cid] = obj.ID
+		}
+		return created, resp.NotCreated, nil
+	})
+	return res, err
+}
+
+// ExportVCard fetches all cards in the AddressBook and writes them as vCard
+// data using enc.
+//
+// Cards are requested using ContactCard/query and ContactCard/get calls
+// respecting the maxObjectsInGet limit, all of them are kept in memory before
+// being passed to enc. Properties not represented by Card fields are
+// available to enc in Card.Extra.
+//
+// The client must have ResponseUnmarshallers enabled.
+func ExportVCard(c *client.Client, account, addressBook jmap.ID, enc VCardEncoder, w io.Writer) error {
+	session, err := c.CurrentSession()
+	if err != nil {
+		return err
+	}
+	chunkSize := int(session.Limits().MaxObjectsInGet)
+
+	cards, err := bulk.QueryGet(chunkSize, func(position, limit int) ([]jmap.ID, error) {
+		respArgs, err := c.Call(using, "ContactCard/query", ContactCardQueryArgs{
+			AccountID: account,
+			Filter:    ContactCardFilterCondition{InAddressBook: addressBook},
+			Position:  jmap.Int(position),
+			Limit:     jmap.UnsignedInt(limit),
+		})
+		if err != nil {
+			return nil, err
+		}
+		query, ok := respArgs.(ContactCardQueryResponse)
+		if !ok {
+			return nil, unexpectedResponse("ContactCard/query", respArgs)
+		}
+		return query.IDs, nil
+	}, func(ids []jmap.ID) ([]Card, error) {
+		respArgs, err := c.Call(using, "ContactCard/get", ContactCardGetArgs{
+			AccountID: account,
+			IDs:       ids,
+		})
+		if err != nil {
+			return nil, err
+		}
+		get, ok := respArgs.(ContactCardGetResponse)
+		if !ok {
+			return nil, unexpectedResponse("ContactCard/get", respArgs)
+		}
+		return get.List, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return enc.EncodeVCard(w, cards)
+}
+
+func unexpectedResponse(methodName string, args interface{}) error {
+	return fmt.Errorf("jmap/contacts: unexpected %s response type %T, is contacts.ResponseUnmarshallers enabled?", methodName, args)
+}
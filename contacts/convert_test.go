@@ -0,0 +1,69 @@
+package contacts
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/foxcpp/go-jmap"
+	"github.com/foxcpp/go-jmap/client"
+	"github.com/foxcpp/go-jmap/internal/bulk/bulktest"
+	"gotest.tools/assert"
+	"gotest.tools/assert/cmp"
+)
+
+// jsonConverter stands in for a vCard converter in tests, it uses a JSON
+// array of Cards as the "vCard" format.
+type jsonConverter struct{}
+
+func (jsonConverter) DecodeVCard(r io.Reader) ([]Card, error) {
+	var cards []Card
+	err := json.NewDecoder(r).Decode(&cards)
+	return cards, err
+}
+
+func (jsonConverter) EncodeVCard(w io.Writer, cards []Card) error {
+	return json.NewEncoder(w).Encode(cards)
+}
+
+func TestImportExportVCard(t *testing.T) {
+	store := &bulktest.Store{
+		TypeName: "ContactCard",
+		Reject:   func(obj map[string]interface{}) bool { return obj["uid"] == "invalid" },
+	}
+	srv := bulktest.NewServer(store)
+	defer srv.Close()
+	c, err := client.NewWithClient(srv.Client(), srv.SessionURL(), "")
+	assert.NilError(t, err)
+	c.Enable(ResponseUnmarshallers)
+	// Unknown vendor properties pass validation.
+	c.EnableSchemas(PropertySchemas)
+
+	input := `[{"id":"X","uid":"a","example.com:vendor":{"a":1}},{"uid":"invalid"},{"uid":"b","addressBookIds":{"other":true}}]`
+	res, err := ImportVCard(c, "A1", "AB1", jsonConverter{}, strings.NewReader(input))
+	assert.NilError(t, err)
+	assert.Check(t, cmp.DeepEqual([]jmap.ID{"O0", "", "O2"}, res.IDs))
+	assert.Check(t, cmp.Len(res.Failed, 1))
+	assert.Check(t, cmp.Equal(jmap.CodeInvalidProperties, res.Failed[1].Type))
+	stored := store.Objects()
+	for _, obj := range stored {
+		assert.Check(t, cmp.DeepEqual(map[string]interface{}{"AB1": true}, obj["addressBookIds"]))
+	}
+	// Unknown properties are not lost.
+	assert.Check(t, cmp.DeepEqual(map[string]interface{}{"a": float64(1)}, stored[0]["example.com:vendor"]))
+
+	// Three pages are requested, the last one is empty.
+	store.Add(map[string]interface{}{"id": "O3", "uid": "c"})
+	var out bytes.Buffer
+	assert.NilError(t, ExportVCard(c, "A1", "AB1", jsonConverter{}, &out))
+	exported, err := jsonConverter{}.DecodeVCard(&out)
+	assert.NilError(t, err)
+	var keys []string
+	for _, card := range exported {
+		keys = append(keys, card.UID)
+	}
+	assert.Check(t, cmp.DeepEqual([]string{"a", "b", "c"}, keys))
+	assert.Check(t, cmp.DeepEqual(map[string]json.RawMessage{"example.com:vendor": json.RawMessage(`{"a":1}`)}, exported[0].Extra))
+}
@@ -0,0 +1,71 @@
+package jmap
+
+import "encoding/json"
+
+// CopyArgs contains arguments for generic /copy method call that copies
+// records from one account to another.
+//
+// Packages implementing specific data types provide wrappers with typed
+// Create maps.
+//
+// See section 5.4 of JMAP Core specification.
+type CopyArgs struct {
+	// The id of the account to copy records from.
+	FromAccountID ID `json:"fromAccountId"`
+
+	// If not empty, the method will be aborted and a stateMismatch error
+	// returned if the state of the from account does not match this string.
+	IfFromInState string `json:"ifFromInState,omitempty"`
+
+	// The id of the account to copy records to. This MUST be different to
+	// the FromAccountID.
+	AccountID ID `json:"accountId"`
+
+	// If not empty, the method will be aborted and a stateMismatch error
+	// returned if the state of the target account does not match this
+	// string.
+	IfInState string `json:"ifInState,omitempty"`
+
+	// A map of the creation id to a record object. The record object MUST
+	// contain an id property, which is the id (in the from account) of the
+	// record to be copied. When creating the copy, any other properties
+	// included are used instead of the current value for that property on
+	// the original.
+	Create map[ID]interface{} `json:"create"`
+
+	// If true, an attempt will be made to destroy the original records that
+	// were successfully copied: after emitting the /copy response, but before
+	// processing the next method, the server MUST make a single call to /set
+	// to destroy the original of each successfully copied record.
+	OnSuccessDestroyOriginal bool `json:"onSuccessDestroyOriginal,omitempty"`
+
+	// This argument is passed on as the ifInState argument to the implicit
+	// /set call, if made at the end of this request to destroy the originals
+	// that were successfully copied.
+	DestroyFromIfInState string `json:"destroyFromIfInState,omitempty"`
+}
+
+// CopyResponse contains results of generic /copy method call.
+type CopyResponse struct {
+	// The id of the account records were copied from.
+	FromAccountID ID `json:"fromAccountId"`
+
+	// The id of the account records were copied to.
+	AccountID ID `json:"accountId"`
+
+	// The state string that would have been returned by /get on the target
+	// account before making the requested changes.
+	OldState string `json:"oldState"`
+
+	// The state string that will now be returned by /get on the target
+	// account.
+	NewState string `json:"newState"`
+
+	// A map of the creation id to an object containing any properties of the
+	// copied record that are set by the server.
+	Created map[ID]json.RawMessage `json:"created"`
+
+	// A map of the creation id to a SetError object for each record that
+	// failed to be copied.
+	NotCreated map[ID]SetError `json:"notCreated"`
+}
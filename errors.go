@@ -33,6 +33,9 @@ type RequestError struct {
 }
 
 func (re RequestError) Error() string {
+	if re.Detail == "" {
+		return "jmap: " + string(re.Type)
+	}
 	return re.Detail
 }
 
@@ -129,3 +132,30 @@ func UnmarshalMethodErrorArgs(args json.RawMessage) (interface{}, error) {
 	err := res.UnmarshalJSONArgs(args)
 	return res, err
 }
+
+// The SetError object describes why creation, update or destruction of a
+// single record in /set method call failed.
+//
+// See section 5.3 of JMAP Core specification.
+type SetError struct {
+	// The type of error.
+	Type ErrorCode `json:"type"`
+
+	// A description of the error to help debug with an explanation of what
+	// the problem was. This is a non-localised string, and is not intended to
+	// be shown directly to end users.
+	Description string `json:"description,omitempty"`
+
+	// List of invalid properties, set for invalidProperties errors.
+	Properties []string `json:"properties,omitempty"`
+
+	// Id of the existing record, set for alreadyExists errors.
+	ExistingID ID `json:"existingId,omitempty"`
+}
+
+func (se SetError) Error() string {
+	if se.Description == "" {
+		return "jmap: " + string(se.Type)
+	}
+	return "jmap: " + string(se.Type) + ": " + se.Description
+}
@@ -0,0 +1,258 @@
+// Command mailreader is a small example mail reader built on top of go-jmap.
+//
+// It lists the newest messages in the Inbox, grouped into conversations,
+// and optionally keeps the list up to date using push notifications.
+// Fetched messages are kept in a local JSON cache so the list can be shown
+// immediately on the next start.
+//
+// Usage:
+//
+//	mailreader -url https://jmap.example.org/.well-known/jmap [-watch]
+//
+// The value of the Authentication header is taken from the JMAP_AUTH
+// environment variable, e.g. "Bearer xxxxx".
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/signal"
+	"text/tabwriter"
+	"time"
+
+	"github.com/foxcpp/go-jmap"
+	"github.com/foxcpp/go-jmap/client"
+	"github.com/foxcpp/go-jmap/mail"
+)
+
+var emailProperties = []string{"id", "threadId", "from", "subject", "receivedAt", "keywords", "preview"}
+
+// cache is the local state persisted between runs.
+type cache struct {
+	AccountID jmap.ID
+	InboxID   jmap.ID
+	Emails    []mail.Email
+}
+
+func loadCache(path string) (*cache, error) {
+	blob, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &cache{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var c cache
+	return &c, json.Unmarshal(blob, &c)
+}
+
+func (c *cache) save(path string) error {
+	blob, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, blob, 0600)
+}
+
+type reader struct {
+	c         *client.Client
+	cache     *cache
+	cachePath string
+	limit     jmap.UnsignedInt
+	out       io.Writer
+}
+
+func (r *reader) findInbox() error {
+	session, err := r.c.CurrentSession()
+	if err != nil {
+		return err
+	}
+	account, ok := session.PrimaryAccounts[jmap.MailCapabilityName]
+	if !ok {
+		return fmt.Errorf("no primary mail account")
+	}
+	if r.cache.AccountID == account && r.cache.InboxID != "" {
+		return nil
+	}
+
+	respArgs, err := r.c.Call([]string{jmap.CoreCapabilityName, jmap.MailCapabilityName}, "Mailbox/get", mail.MailboxGetArgs{
+		AccountID:  account,
+		Properties: []string{"id", "role"},
+	})
+	if err != nil {
+		return err
+	}
+	mboxes, ok := respArgs.(mail.MailboxGetResponse)
+	if !ok {
+		return fmt.Errorf("unexpected Mailbox/get response: %T", respArgs)
+	}
+	for _, mbox := range mboxes.List {
+		if mbox.Role == mail.RoleInbox {
+			*r.cache = cache{AccountID: account, InboxID: mbox.ID}
+			return nil
+		}
+	}
+	return fmt.Errorf("account has no inbox")
+}
+
+// refresh fetches the first page of the Inbox in a single request.
+func (r *reader) refresh() error {
+	b, calls := mail.InboxOverviewBatch(nil, r.cache.AccountID, r.cache.InboxID, r.limit, emailProperties)
+	resp, err := r.c.RawSend(b.Request())
+	if err != nil {
+		return err
+	}
+	for _, inv := range resp.Responses {
+		if methodErr, ok := inv.Args.(jmap.MethodErrorArgs); ok {
+			return methodErr
+		}
+		if inv.CallID != calls.EmailGet {
+			continue
+		}
+		emails, ok := inv.Args.(mail.EmailGetResponse)
+		if !ok {
+			return fmt.Errorf("unexpected Email/get response: %T", inv.Args)
+		}
+		r.cache.Emails = emails.List
+	}
+	return r.cache.save(r.cachePath)
+}
+
+func (r *reader) print() {
+	ids := make([]jmap.ID, 0, len(r.cache.Emails))
+	for _, e := range r.cache.Emails {
+		ids = append(ids, e.ID)
+	}
+	convs := mail.GroupByThread(ids, nil, r.cache.Emails)
+
+	w := tabwriter.NewWriter(r.out, 0, 8, 2, ' ', 0)
+	for _, conv := range convs {
+		latest := conv.Latest()
+		marker := " "
+		if conv.Unread != 0 {
+			marker = "*"
+		}
+		from := ""
+		if len(latest.From) != 0 {
+			from = latest.From[0].String()
+		}
+		date := ""
+		if latest.ReceivedAt != nil {
+			date = time.Time(*latest.ReceivedAt).Local().Format("Jan 02 15:04")
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s (%d)\n", marker, date, from, latest.Subject, conv.Total)
+	}
+	w.Flush()
+}
+
+// watch refreshes the list each time the server reports changes, merging
+// bursts of notifications.
+func (r *reader) watch(ctx context.Context) error {
+	es, err := r.c.OpenEventSource(ctx, client.EventSourceOptions{
+		Types:  []string{"Email", "Mailbox"},
+		Ping:   30 * time.Second,
+		Policy: client.BufferCoalesce,
+	})
+	if err != nil {
+		return err
+	}
+	defer es.Close()
+
+	co := client.NewCoalescer(time.Second, nil)
+	defer co.Stop()
+
+	errCh := make(chan error, 1)
+	go func() {
+		for {
+			sc, err := es.Next(ctx)
+			if err != nil {
+				errCh <- err
+				return
+			}
+			if _, ok := sc.Changed[r.cache.AccountID]; ok {
+				co.Add(sc)
+			}
+		}
+	}()
+
+	for {
+		select {
+		case <-co.C():
+			if err := r.refresh(); err != nil {
+				return err
+			}
+			fmt.Fprintln(r.out)
+			r.print()
+		case err := <-errCh:
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func main() {
+	sessionURL := flag.String("url", "", "JMAP session endpoint URL")
+	cachePath := flag.String("cache", "mailreader-cache.json", "path to the local cache file")
+	limit := flag.Uint("limit", 30, "number of messages to show")
+	watch := flag.Bool("watch", false, "keep running and refresh the list on changes")
+	flag.Parse()
+
+	if *sessionURL == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	cache, err := loadCache(*cachePath)
+	if err != nil {
+		log.Fatalln("cache:", err)
+	}
+	r := &reader{
+		cache:     cache,
+		cachePath: *cachePath,
+		limit:     jmap.UnsignedInt(*limit),
+		out:       os.Stdout,
+	}
+	if len(cache.Emails) != 0 {
+		r.print()
+		fmt.Println()
+	}
+
+	r.c, err = client.New(*sessionURL, os.Getenv("JMAP_AUTH"))
+	if err != nil {
+		log.Fatalln(err)
+	}
+	r.c.Enable(mail.ResponseUnmarshallers)
+	r.c.RefreshSession = true
+	r.c.Throttle = &client.Throttle{}
+
+	if err := r.findInbox(); err != nil {
+		log.Fatalln(err)
+	}
+	if err := r.refresh(); err != nil {
+		log.Fatalln(err)
+	}
+	r.print()
+
+	if *watch {
+		ctx, cancel := context.WithCancel(context.Background())
+		sigCh := make(chan os.Signal, 1)
+		signal.Notify(sigCh, os.Interrupt)
+		go func() {
+			<-sigCh
+			cancel()
+		}()
+		if err := r.watch(ctx); err != nil && err != io.EOF {
+			log.Fatalln(err)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/foxcpp/go-jmap"
+	"github.com/foxcpp/go-jmap/client"
+	"github.com/foxcpp/go-jmap/mail"
+	"gotest.tools/assert"
+	"gotest.tools/assert/cmp"
+)
+
+// newTestServer starts the server that returns the serverFail error for
+// the failing method, if it is not empty.
+func newTestServer(t *testing.T, failing string) *httptest.Server {
+	responses := map[string]interface{}{
+		"Mailbox/get": map[string]interface{}{
+			"list": []interface{}{map[string]interface{}{"id": "INBOX", "role": "inbox"}},
+		},
+		"Email/query": map[string]interface{}{"ids": []string{"E2", "E1"}},
+		"Email/get": map[string]interface{}{
+			"list": []interface{}{
+				map[string]interface{}{
+					"id": "E2", "threadId": "T1", "subject": "Re: Lunch",
+					"from":       []interface{}{map[string]interface{}{"email": "jane@example.org"}},
+					"receivedAt": "2020-01-02T12:00:00Z",
+				},
+				map[string]interface{}{
+					"id": "E1", "threadId": "T1", "subject": "Lunch",
+					"keywords":   map[string]bool{"$seen": true},
+					"receivedAt": "2020-01-01T12:00:00Z",
+				},
+			},
+		},
+	}
+
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+	mux.HandleFunc("/session", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{ //nolint:errcheck
+			"capabilities": map[string]interface{}{
+				"urn:ietf:params:jmap:core": map[string]interface{}{"maxCallsInRequest": 16},
+			},
+			"accounts": map[string]interface{}{
+				"A1": map[string]interface{}{"accountCapabilities": map[string]interface{}{
+					"urn:ietf:params:jmap:mail": map[string]interface{}{},
+				}},
+			},
+			"primaryAccounts": map[string]interface{}{"urn:ietf:params:jmap:mail": "A1"},
+			"apiUrl":          srv.URL + "/api",
+			"state":           "1",
+		})
+	})
+	mux.HandleFunc("/api", func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Calls [][3]json.RawMessage `json:"methodCalls"`
+		}
+		assert.NilError(t, json.NewDecoder(r.Body).Decode(&req))
+		resps := [][3]interface{}{}
+		for _, call := range req.Calls {
+			var name, callID string
+			json.Unmarshal(call[0], &name)   //nolint:errcheck
+			json.Unmarshal(call[2], &callID) //nolint:errcheck
+			if name == failing {
+				resps = append(resps, [3]interface{}{"error", map[string]interface{}{"type": "serverFail"}, callID})
+				continue
+			}
+			resps = append(resps, [3]interface{}{name, responses[name], callID})
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{ //nolint:errcheck
+			"methodResponses": resps,
+			"sessionState":    "1",
+		})
+	})
+	return srv
+}
+
+func TestReader(t *testing.T) {
+	srv := newTestServer(t, "")
+	defer srv.Close()
+
+	dir, err := ioutil.TempDir("", "mailreader-")
+	assert.NilError(t, err)
+	defer os.RemoveAll(dir)
+
+	c, err := client.NewWithClient(srv.Client(), srv.URL+"/session", "")
+	assert.NilError(t, err)
+	c.Enable(mail.ResponseUnmarshallers)
+
+	var out bytes.Buffer
+	r := &reader{
+		c:         c,
+		cache:     &cache{},
+		cachePath: filepath.Join(dir, "cache.json"),
+		limit:     10,
+		out:       &out,
+	}
+	assert.NilError(t, r.findInbox())
+	assert.NilError(t, r.refresh())
+	r.print()
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	assert.Assert(t, cmp.Len(lines, 1))
+	assert.Check(t, strings.HasPrefix(lines[0], "*"), "conversation should be unread: %q", lines[0])
+	assert.Check(t, cmp.Contains(lines[0], "jane@example.org"))
+	assert.Check(t, cmp.Contains(lines[0], "Re: Lunch (2)"))
+
+	cached, err := loadCache(r.cachePath)
+	assert.NilError(t, err)
+	assert.Check(t, cmp.Equal("INBOX", string(cached.InboxID)))
+	assert.Check(t, cmp.Len(cached.Emails, 2))
+}
+
+func TestReaderMethodError(t *testing.T) {
+	for _, method := range []string{"Mailbox/get", "Email/get"} {
+		srv := newTestServer(t, method)
+		defer srv.Close()
+
+		c, err := client.NewWithClient(srv.Client(), srv.URL+"/session", "")
+		assert.NilError(t, err)
+		c.Enable(mail.ResponseUnmarshallers)
+
+		r := &reader{c: c, cache: &cache{AccountID: "A1", InboxID: "INBOX"}, limit: 10}
+		if method == "Mailbox/get" {
+			r.cache = &cache{}
+			err = r.findInbox()
+		} else {
+			err = r.refresh()
+		}
+		methodErr, ok := err.(jmap.MethodErrorArgs)
+		assert.Assert(t, ok, "%s: unexpected error: %v", method, err)
+		assert.Check(t, cmp.Equal(jmap.CodeServerFail, methodErr.Type))
+	}
+}
@@ -1,7 +1,10 @@
 module github.com/foxcpp/go-jmap
 
+go 1.27
+
+require gotest.tools v2.2.0+incompatible
+
 require (
 	github.com/google/go-cmp v0.3.0 // indirect
 	github.com/pkg/errors v0.8.1 // indirect
-	gotest.tools v2.2.0+incompatible
 )
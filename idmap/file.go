@@ -0,0 +1,122 @@
+package idmap
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/foxcpp/go-jmap"
+	"github.com/foxcpp/go-jmap/internal/jsonlog"
+)
+
+type fileRecord struct {
+	NS       string  `json:"ns"`
+	ID       jmap.ID `json:"id"`
+	External string  `json:"ext,omitempty"`
+	Deleted  bool    `json:"deleted,omitempty"`
+}
+
+// File is the Mapping implementation that keeps data in memory and appends
+// all changes to a file as JSON lines, calling fsync after each write.
+//
+// The whole mapping is loaded into memory on open. The file is rewritten
+// with only current mappings when it grows large enough, so it does not grow
+// without bound.
+type File struct {
+	lck sync.RWMutex
+	log *jsonlog.Log
+	t   table
+}
+
+// OpenFile opens (creating if necessary) the mapping file and loads existing
+// mappings from it.
+//
+// A partially written last record (e.g. after a crash) is removed from the
+// file. An error is returned if any other record is malformed.
+func OpenFile(path string) (*File, error) {
+	m := &File{t: make(table)}
+	log, err := jsonlog.Open(path, func(line []byte) error {
+		var rec fileRecord
+		if err := json.Unmarshal(line, &rec); err != nil {
+			return err
+		}
+		m.t.delete(rec.NS, rec.ID)
+		if !rec.Deleted {
+			m.t.put(rec.NS, rec.ID, rec.External)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	m.log = log
+	return m, nil
+}
+
+// write appends the record to the file. The change must already be applied
+// to m.t so that compaction preserves it.
+func (m *File) write(rec fileRecord) error {
+	if err := m.log.Append(rec); err != nil {
+		return err
+	}
+	if !m.log.NeedsCompaction() {
+		return nil
+	}
+	return m.log.Rewrite(func(add func(interface{}) error) error {
+		for ns, tbl := range m.t {
+			for id, ext := range tbl.toExt {
+				if err := add(fileRecord{NS: ns, ID: id, External: ext}); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+func (m *File) Put(ns string, id jmap.ID, external string) error {
+	m.lck.Lock()
+	defer m.lck.Unlock()
+	if exists, err := m.t.check(ns, id, external); exists || err != nil {
+		return err
+	}
+	m.t.put(ns, id, external)
+	if err := m.write(fileRecord{NS: ns, ID: id, External: external}); err != nil {
+		m.t.delete(ns, id)
+		return err
+	}
+	return nil
+}
+
+func (m *File) External(ns string, id jmap.ID) (string, bool, error) {
+	m.lck.RLock()
+	defer m.lck.RUnlock()
+	ext, ok := m.t.external(ns, id)
+	return ext, ok, nil
+}
+
+func (m *File) JMAP(ns string, external string) (jmap.ID, bool, error) {
+	m.lck.RLock()
+	defer m.lck.RUnlock()
+	id, ok := m.t.jmap(ns, external)
+	return id, ok, nil
+}
+
+func (m *File) Delete(ns string, id jmap.ID) error {
+	m.lck.Lock()
+	defer m.lck.Unlock()
+	prev, ok := m.t.external(ns, id)
+	if !ok {
+		return nil
+	}
+	m.t.delete(ns, id)
+	if err := m.write(fileRecord{NS: ns, ID: id, Deleted: true}); err != nil {
+		m.t.put(ns, id, prev)
+		return err
+	}
+	return nil
+}
+
+// Close closes the underlying file.
+func (m *File) Close() error {
+	return m.log.Close()
+}
@@ -0,0 +1,160 @@
+// Package idmap implements storage for correspondences between JMAP object
+// ids and identifiers used by external systems (e.g. IMAP UIDs or database
+// primary keys).
+//
+// It is intended for bridges and migration tools that need to remember
+// which JMAP object was created for which external object and vice versa.
+package idmap
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/foxcpp/go-jmap"
+)
+
+// ErrConflict is returned by Put if either the JMAP id or the external id is
+// already mapped to a different value in the namespace.
+var ErrConflict = errors.New("jmap/idmap: id is already mapped to a different value")
+
+// Mapping is a bidirectional one-to-one mapping between JMAP ids and
+// external ids.
+//
+// Mappings are partitioned into namespaces, which can be used to separate
+// object types and accounts, e.g. "A1/Email" and "A1/Mailbox".
+//
+// Implementations must be safe for concurrent use.
+type Mapping interface {
+	// Put records that id corresponds to external. Put for an existing pair
+	// is a no-op. ErrConflict is returned if either side is already mapped
+	// to something else.
+	Put(ns string, id jmap.ID, external string) error
+
+	// External returns the external id corresponding to the JMAP id.
+	External(ns string, id jmap.ID) (external string, ok bool, err error)
+
+	// JMAP returns the JMAP id corresponding to the external id.
+	JMAP(ns string, external string) (id jmap.ID, ok bool, err error)
+
+	// Delete removes the mapping for the JMAP id, if any.
+	Delete(ns string, id jmap.ID) error
+}
+
+type nsTable struct {
+	toExt  map[jmap.ID]string
+	toJMAP map[string]jmap.ID
+}
+
+// table is the in-memory representation shared by Mapping
+// implementations. It is not safe for concurrent use.
+type table map[string]*nsTable
+
+func (t table) ns(ns string, create bool) *nsTable {
+	tbl, ok := t[ns]
+	if !ok && create {
+		tbl = &nsTable{
+			toExt:  make(map[jmap.ID]string),
+			toJMAP: make(map[string]jmap.ID),
+		}
+		t[ns] = tbl
+	}
+	return tbl
+}
+
+// check returns true if the pair is already present and ErrConflict if
+// either side is mapped to something else.
+func (t table) check(ns string, id jmap.ID, external string) (bool, error) {
+	tbl := t.ns(ns, false)
+	if tbl == nil {
+		return false, nil
+	}
+	curExt, idOk := tbl.toExt[id]
+	curID, extOk := tbl.toJMAP[external]
+	if idOk && extOk && curExt == external && curID == id {
+		return true, nil
+	}
+	if idOk || extOk {
+		return false, ErrConflict
+	}
+	return false, nil
+}
+
+func (t table) put(ns string, id jmap.ID, external string) {
+	tbl := t.ns(ns, true)
+	tbl.toExt[id] = external
+	tbl.toJMAP[external] = id
+}
+
+func (t table) delete(ns string, id jmap.ID) bool {
+	tbl := t.ns(ns, false)
+	if tbl == nil {
+		return false
+	}
+	ext, ok := tbl.toExt[id]
+	if !ok {
+		return false
+	}
+	delete(tbl.toExt, id)
+	delete(tbl.toJMAP, ext)
+	return true
+}
+
+func (t table) external(ns string, id jmap.ID) (string, bool) {
+	tbl := t.ns(ns, false)
+	if tbl == nil {
+		return "", false
+	}
+	ext, ok := tbl.toExt[id]
+	return ext, ok
+}
+
+func (t table) jmap(ns string, external string) (jmap.ID, bool) {
+	tbl := t.ns(ns, false)
+	if tbl == nil {
+		return "", false
+	}
+	id, ok := tbl.toJMAP[external]
+	return id, ok
+}
+
+// Memory is the Mapping implementation that keeps data in memory.
+//
+// Zero value is an empty Mapping ready to use.
+type Memory struct {
+	lck sync.RWMutex
+	t   table
+}
+
+func (m *Memory) Put(ns string, id jmap.ID, external string) error {
+	m.lck.Lock()
+	defer m.lck.Unlock()
+	if m.t == nil {
+		m.t = make(table)
+	}
+	if exists, err := m.t.check(ns, id, external); exists || err != nil {
+		return err
+	}
+	m.t.put(ns, id, external)
+	return nil
+}
+
+func (m *Memory) External(ns string, id jmap.ID) (string, bool, error) {
+	m.lck.RLock()
+	defer m.lck.RUnlock()
+	ext, ok := m.t.external(ns, id)
+	return ext, ok, nil
+}
+
+func (m *Memory) JMAP(ns string, external string) (jmap.ID, bool, error) {
+	m.lck.RLock()
+	defer m.lck.RUnlock()
+	id, ok := m.t.jmap(ns, external)
+	return id, ok, nil
+}
+
+func (m *Memory) Delete(ns string, id jmap.ID) error {
+	m.lck.Lock()
+	defer m.lck.Unlock()
+	m.t.delete(ns, id)
+	return nil
+}
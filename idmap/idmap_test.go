@@ -0,0 +1,138 @@
+package idmap
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/foxcpp/go-jmap"
+	"gotest.tools/assert"
+	"gotest.tools/assert/cmp"
+)
+
+func testMapping(t *testing.T, m Mapping) {
+	assert.NilError(t, m.Put("A1/Email", "M1", "INBOX:1"))
+	assert.NilError(t, m.Put("A1/Email", "M1", "INBOX:1"))
+	assert.NilError(t, m.Put("A1/Mailbox", "M1", "INBOX"))
+
+	ext, ok, err := m.External("A1/Email", "M1")
+	assert.NilError(t, err)
+	assert.Check(t, ok)
+	assert.Check(t, cmp.Equal("INBOX:1", ext))
+
+	id, ok, err := m.JMAP("A1/Mailbox", "INBOX")
+	assert.NilError(t, err)
+	assert.Check(t, ok)
+	assert.Check(t, cmp.Equal(jmap.ID("M1"), id))
+
+	assert.Check(t, cmp.Equal(ErrConflict, m.Put("A1/Email", "M1", "INBOX:2")))
+	assert.Check(t, cmp.Equal(ErrConflict, m.Put("A1/Email", "M2", "INBOX:1")))
+
+	assert.NilError(t, m.Delete("A1/Email", "M1"))
+	_, ok, err = m.JMAP("A1/Email", "INBOX:1")
+	assert.NilError(t, err)
+	assert.Check(t, !ok)
+	assert.NilError(t, m.Put("A1/Email", "M2", "INBOX:1"))
+}
+
+func TestMemory(t *testing.T) {
+	testMapping(t, &Memory{})
+}
+
+func TestFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-jmap-idmap-")
+	assert.NilError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "ids")
+
+	m, err := OpenFile(path)
+	assert.NilError(t, err)
+	testMapping(t, m)
+	assert.NilError(t, m.Close())
+
+	m, err = OpenFile(path)
+	assert.NilError(t, err)
+	defer m.Close()
+
+	id, ok, err := m.JMAP("A1/Email", "INBOX:1")
+	assert.NilError(t, err)
+	assert.Check(t, ok)
+	assert.Check(t, cmp.Equal(jmap.ID("M2"), id))
+	_, ok, err = m.External("A1/Email", "M1")
+	assert.NilError(t, err)
+	assert.Check(t, !ok)
+}
+
+func TestFileCompaction(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-jmap-idmap-")
+	assert.NilError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "ids")
+
+	m, err := OpenFile(path)
+	assert.NilError(t, err)
+	assert.NilError(t, m.Put("A1/Email", "M1", "INBOX:1"))
+	long := strings.Repeat("x", 100*1024)
+	for i := 0; i < 30; i++ {
+		assert.NilError(t, m.Put("A1/Email", "M2", long))
+		assert.NilError(t, m.Delete("A1/Email", "M2"))
+	}
+	assert.NilError(t, m.Close())
+
+	info, err := os.Stat(path)
+	assert.NilError(t, err)
+	assert.Check(t, info.Size() < 2*1024*1024, "file was not compacted: %d bytes", info.Size())
+
+	m, err = OpenFile(path)
+	assert.NilError(t, err)
+	defer m.Close()
+	ext, ok, err := m.External("A1/Email", "M1")
+	assert.NilError(t, err)
+	assert.Check(t, ok)
+	assert.Check(t, cmp.Equal("INBOX:1", ext))
+	_, ok, err = m.External("A1/Email", "M2")
+	assert.NilError(t, err)
+	assert.Check(t, !ok)
+}
+
+func TestFileCrash(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-jmap-idmap-")
+	assert.NilError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "ids")
+
+	m, err := OpenFile(path)
+	assert.NilError(t, err)
+	assert.NilError(t, m.Put("A1/Email", "M1", "INBOX:1"))
+	assert.NilError(t, m.Close())
+
+	// Simulate the crash in the middle of the write.
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0600)
+	assert.NilError(t, err)
+	_, err = f.WriteString(`{"ns":"A1/Em`)
+	assert.NilError(t, err)
+	assert.NilError(t, f.Close())
+
+	m, err = OpenFile(path)
+	assert.NilError(t, err)
+	assert.NilError(t, m.Put("A1/Email", "M2", "INBOX:2"))
+	assert.NilError(t, m.Close())
+
+	m, err = OpenFile(path)
+	assert.NilError(t, err)
+	_, ok, err := m.External("A1/Email", "M2")
+	assert.NilError(t, err)
+	assert.Check(t, ok, "mapping written after the crash should not be lost")
+	assert.NilError(t, m.Close())
+
+	t.Run("corrupted record", func(t *testing.T) {
+		blob, err := ioutil.ReadFile(path)
+		assert.NilError(t, err)
+		assert.NilError(t, ioutil.WriteFile(path, append([]byte("{\n"), blob...), 0600))
+
+		_, err = OpenFile(path)
+		assert.Check(t, cmp.ErrorContains(err, "line 1"))
+	})
+}
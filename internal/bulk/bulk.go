@@ -0,0 +1,70 @@
+// Package bulk implements chunked creation and fetching of objects shared by
+// packages for individual data types (e.g. calendar and contacts).
+package bulk
+
+import (
+	"strconv"
+
+	"github.com/foxcpp/go-jmap"
+)
+
+// Create creates objects using as many calls to set as needed to pass at
+// most chunkSize objects to each call. Creation ids are indexes of objects
+// in the slice.
+//
+// It returns ids of created objects in the order of objects (empty for
+// objects that failed to be created) and errors keyed by the index. If set
+// fails, the error is returned along with results of previous calls.
+func Create[T any](objects []T, chunkSize int, set func(create map[jmap.ID]T) (created map[jmap.ID]jmap.ID, notCreated map[jmap.ID]jmap.SetError, err error)) ([]jmap.ID, map[int]jmap.SetError, error) {
+	var (
+		ids    = make([]jmap.ID, len(objects))
+		failed map[int]jmap.SetError
+	)
+	for start := 0; start < len(objects); start += chunkSize {
+		end := start + chunkSize
+		if end > len(objects) {
+			end = len(objects)
+		}
+
+		create := make(map[jmap.ID]T, end-start)
+		for i := start; i < end; i++ {
+			create[jmap.ID(strconv.Itoa(i))] = objects[i]
+		}
+		created, notCreated, err := set(create)
+		if err != nil {
+			return ids, failed, err
+		}
+		for i := start; i < end; i++ {
+			cid := jmap.ID(strconv.Itoa(i))
+			ids[i] = created[cid]
+			if setErr, ok := notCreated[cid]; ok {
+				if failed == nil {
+					failed = make(map[int]jmap.SetError)
+				}
+				failed[i] = setErr
+			}
+		}
+	}
+	return ids, failed, nil
+}
+
+// QueryGet fetches all objects matched by query, requesting pages of at
+// most chunkSize ids and passing each page to get.
+func QueryGet[T any](chunkSize int, query func(position, limit int) ([]jmap.ID, error), get func(ids []jmap.ID) ([]T, error)) ([]T, error) {
+	var res []T
+	for position := 0; ; {
+		ids, err := query(position, chunkSize)
+		if err != nil {
+			return nil, err
+		}
+		if len(ids) == 0 {
+			return res, nil
+		}
+		objects, err := get(ids)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, objects...)
+		position += len(ids)
+	}
+}
@@ -0,0 +1,67 @@
+package bulk
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/foxcpp/go-jmap"
+	"gotest.tools/assert"
+	"gotest.tools/assert/cmp"
+)
+
+func TestCreate(t *testing.T) {
+	var calls []int
+	ids, failed, err := Create([]string{"a", "invalid", "c"}, 2, func(create map[jmap.ID]string) (map[jmap.ID]jmap.ID, map[jmap.ID]jmap.SetError, error) {
+		calls = append(calls, len(create))
+		created := map[jmap.ID]jmap.ID{}
+		notCreated := map[jmap.ID]jmap.SetError{}
+		for cid, obj := range create {
+			if obj == "invalid" {
+				notCreated[cid] = jmap.SetError{Type: jmap.CodeInvalidProperties}
+				continue
+			}
+			created[cid] = jmap.ID("O" + obj)
+		}
+		return created, notCreated, nil
+	})
+	assert.NilError(t, err)
+	assert.Check(t, cmp.DeepEqual([]int{2, 1}, calls))
+	assert.Check(t, cmp.DeepEqual([]jmap.ID{"Oa", "", "Oc"}, ids))
+	assert.Check(t, cmp.DeepEqual(map[int]jmap.SetError{1: {Type: jmap.CodeInvalidProperties}}, failed))
+
+	// Results of previous calls are returned along with the error.
+	fail := errors.New("fail")
+	calls = nil
+	ids, _, err = Create([]string{"a", "b", "c"}, 2, func(create map[jmap.ID]string) (map[jmap.ID]jmap.ID, map[jmap.ID]jmap.SetError, error) {
+		calls = append(calls, len(create))
+		if len(calls) == 2 {
+			return nil, nil, fail
+		}
+		return map[jmap.ID]jmap.ID{"0": "O0", "1": "O1"}, nil, nil
+	})
+	assert.Check(t, cmp.Equal(fail, err))
+	assert.Check(t, cmp.DeepEqual([]jmap.ID{"O0", "O1", ""}, ids))
+}
+
+func TestQueryGet(t *testing.T) {
+	all := []jmap.ID{"1", "2", "3"}
+	var positions []int
+	res, err := QueryGet(2, func(position, limit int) ([]jmap.ID, error) {
+		positions = append(positions, position)
+		end := position + limit
+		if end > len(all) {
+			end = len(all)
+		}
+		return all[position:end], nil
+	}, func(ids []jmap.ID) ([]string, error) {
+		var objs []string
+		for _, id := range ids {
+			objs = append(objs, "O"+string(id))
+		}
+		return objs, nil
+	})
+	assert.NilError(t, err)
+	// The last page is empty.
+	assert.Check(t, cmp.DeepEqual([]int{0, 2, 3}, positions))
+	assert.Check(t, cmp.DeepEqual([]string{"O1", "O2", "O3"}, res))
+}
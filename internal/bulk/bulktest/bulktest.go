@@ -0,0 +1,125 @@
+// Package bulktest provides the test server for code built on package bulk.
+package bulktest
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+
+	"github.com/foxcpp/go-jmap"
+	"github.com/foxcpp/go-jmap/jmaptest"
+)
+
+// Store is an in-memory store of objects of a single data type. It serves
+// /set calls creating objects, /query calls (only position and limit are
+// supported) and /get calls.
+//
+// Created objects get ids in the form "O" + creation id.
+type Store struct {
+	TypeName string
+
+	// If it returns true, the object is rejected with invalidProperties
+	// error.
+	Reject func(obj map[string]interface{}) bool
+
+	lck     sync.Mutex
+	objects []map[string]interface{}
+}
+
+// Add adds the object to the store.
+func (s *Store) Add(obj map[string]interface{}) {
+	s.lck.Lock()
+	defer s.lck.Unlock()
+	s.objects = append(s.objects, obj)
+}
+
+// Objects returns all objects in the store.
+func (s *Store) Objects() []map[string]interface{} {
+	s.lck.Lock()
+	defer s.lck.Unlock()
+	return append([]map[string]interface{}(nil), s.objects...)
+}
+
+// NewServer starts jmaptest.Server with API requests served by the store.
+// maxObjectsInGet and maxObjectsInSet limits are set to 2.
+func NewServer(store *Store) *jmaptest.Server {
+	srv := jmaptest.NewServer(store)
+	core := srv.Session["capabilities"].(map[string]interface{})["urn:ietf:params:jmap:core"].(map[string]interface{})
+	core["maxObjectsInGet"] = 2
+	core["maxObjectsInSet"] = 2
+	return srv
+}
+
+func (s *Store) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Calls [][3]json.RawMessage `json:"methodCalls"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var resps []interface{}
+	for _, call := range req.Calls {
+		var (
+			name, callID string
+			args         map[string]json.RawMessage
+		)
+		json.Unmarshal(call[0], &name)   //nolint:errcheck
+		json.Unmarshal(call[1], &args)   //nolint:errcheck
+		json.Unmarshal(call[2], &callID) //nolint:errcheck
+
+		s.lck.Lock()
+		name, resp := s.call(name, args)
+		s.lck.Unlock()
+		resps = append(resps, []interface{}{name, resp, callID})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{ //nolint:errcheck
+		"methodResponses": resps,
+		"sessionState":    "1",
+	})
+}
+
+func (s *Store) call(name string, args map[string]json.RawMessage) (string, interface{}) {
+	switch name {
+	case s.TypeName + "/set":
+		var create map[jmap.ID]map[string]interface{}
+		json.Unmarshal(args["create"], &create) //nolint:errcheck
+		created := map[jmap.ID]interface{}{}
+		notCreated := map[jmap.ID]interface{}{}
+		for cid, obj := range create {
+			if s.Reject != nil && s.Reject(obj) {
+				notCreated[cid] = map[string]interface{}{"type": jmap.CodeInvalidProperties}
+				continue
+			}
+			obj["id"] = "O" + string(cid)
+			s.objects = append(s.objects, obj)
+			created[cid] = map[string]interface{}{"id": obj["id"]}
+		}
+		return name, map[string]interface{}{"created": created, "notCreated": notCreated}
+	case s.TypeName + "/query":
+		var position, limit int
+		json.Unmarshal(args["position"], &position) //nolint:errcheck
+		json.Unmarshal(args["limit"], &limit)       //nolint:errcheck
+		ids := []interface{}{}
+		for i := position; i < len(s.objects) && len(ids) < limit; i++ {
+			ids = append(ids, s.objects[i]["id"])
+		}
+		return name, map[string]interface{}{"ids": ids}
+	case s.TypeName + "/get":
+		var ids []interface{}
+		json.Unmarshal(args["ids"], &ids) //nolint:errcheck
+		list := []interface{}{}
+		for _, id := range ids {
+			for _, obj := range s.objects {
+				if obj["id"] == id {
+					list = append(list, obj)
+				}
+			}
+		}
+		return name, map[string]interface{}{"list": list}
+	}
+	return "error", map[string]interface{}{"type": jmap.CodeUnknownMethod}
+}
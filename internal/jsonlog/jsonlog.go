@@ -0,0 +1,173 @@
+// Package jsonlog implements the append-only log of JSON records, one per
+// line, used by file-backed stores.
+//
+// Each append is followed by fsync. If the process crashes in the middle of
+// an append, the incomplete last line is removed on the next open. Any other
+// malformed line is reported as an error since it means that the file was
+// damaged and some records may be lost.
+//
+// The log can be rewritten atomically with the current state to drop
+// records that are no longer needed.
+package jsonlog
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// minCompactSize is the minimal size of the file for NeedsCompaction to
+// return true.
+const minCompactSize = 1024 * 1024
+
+// Log is the open log file. It is not safe for concurrent use.
+type Log struct {
+	path string
+	f    *os.File
+
+	size        int64
+	compactSize int64
+}
+
+// Open opens (creating if necessary) the log file and calls apply for each
+// complete record in it, in order.
+//
+// An incomplete last record is truncated from the file. If apply returns an
+// error for any record, the file is considered corrupted and the error is
+// returned.
+func Open(path string, apply func(line []byte) error) (*Log, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		r      = bufio.NewReader(f)
+		offset int64
+		lineNo int
+	)
+	for {
+		line, err := r.ReadBytes('\n')
+		if err == io.EOF {
+			if len(line) != 0 {
+				// Partially written last record, the write was not completed
+				// and so the record should be considered missing. It is
+				// removed so the next record is not appended to it.
+				if err := f.Truncate(offset); err != nil {
+					f.Close()
+					return nil, err
+				}
+				if err := f.Sync(); err != nil {
+					f.Close()
+					return nil, err
+				}
+			}
+			break
+		}
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		lineNo++
+		offset += int64(len(line))
+
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		if err := apply(line); err != nil {
+			f.Close()
+			return nil, fmt.Errorf("jmap/jsonlog: %s: corrupted record at line %d: %w", path, lineNo, err)
+		}
+	}
+
+	return &Log{path: path, f: f, size: offset, compactSize: offset}, nil
+}
+
+// Append writes the record to the end of the log and waits for it to be
+// written to the disk.
+func (l *Log) Append(record interface{}) error {
+	blob, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	n, err := l.f.Write(append(blob, '\n'))
+	l.size += int64(n)
+	if err != nil {
+		return err
+	}
+	return l.f.Sync()
+}
+
+// NeedsCompaction reports whether the log grew enough since it was opened
+// or rewritten for Rewrite to be worthwhile.
+func (l *Log) NeedsCompaction() bool {
+	return l.size >= minCompactSize && l.size >= 2*l.compactSize
+}
+
+// Rewrite atomically replaces the log contents with the records passed to
+// write callback.
+//
+// If Rewrite fails, the log is left unchanged.
+func (l *Log) Rewrite(write func(add func(record interface{}) error) error) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(l.path), filepath.Base(l.path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	w := bufio.NewWriter(tmp)
+	var size int64
+	err = write(func(record interface{}) error {
+		blob, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+		n, err := w.Write(append(blob, '\n'))
+		size += int64(n)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if err := tmp.Chmod(0600); err != nil {
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), l.path); err != nil {
+		return err
+	}
+	if dir, err := os.Open(filepath.Dir(l.path)); err == nil {
+		dir.Sync() //nolint:errcheck
+		dir.Close()
+	}
+
+	f, err := os.OpenFile(l.path, os.O_RDWR|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	l.f.Close()
+	l.f = f
+	l.size = size
+	l.compactSize = size
+	return nil
+}
+
+// Close closes the underlying file.
+func (l *Log) Close() error {
+	return l.f.Close()
+}
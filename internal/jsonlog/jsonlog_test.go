@@ -0,0 +1,75 @@
+package jsonlog
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gotest.tools/assert"
+	"gotest.tools/assert/cmp"
+)
+
+func readAll(t *testing.T, path string) []int {
+	var res []int
+	l, err := Open(path, func(line []byte) error {
+		var v int
+		if err := json.Unmarshal(line, &v); err != nil {
+			return err
+		}
+		res = append(res, v)
+		return nil
+	})
+	assert.NilError(t, err)
+	assert.NilError(t, l.Close())
+	return res
+}
+
+func TestLog(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-jmap-jsonlog-")
+	assert.NilError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "log")
+
+	l, err := Open(path, func([]byte) error { return nil })
+	assert.NilError(t, err)
+	assert.NilError(t, l.Append(1))
+	assert.NilError(t, l.Append(2))
+	assert.NilError(t, l.Close())
+	assert.Check(t, cmp.DeepEqual([]int{1, 2}, readAll(t, path)))
+
+	// Incomplete last line is truncated.
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0600)
+	assert.NilError(t, err)
+	_, err = f.WriteString("12")
+	assert.NilError(t, err)
+	assert.NilError(t, f.Close())
+	assert.Check(t, cmp.DeepEqual([]int{1, 2}, readAll(t, path)))
+	blob, err := ioutil.ReadFile(path)
+	assert.NilError(t, err)
+	assert.Check(t, cmp.Equal("1\n2\n", string(blob)))
+
+	l, err = Open(path, func([]byte) error { return nil })
+	assert.NilError(t, err)
+	assert.NilError(t, l.Rewrite(func(add func(interface{}) error) error {
+		return add(3)
+	}))
+	assert.NilError(t, l.Append(4))
+	assert.NilError(t, l.Close())
+	assert.Check(t, cmp.DeepEqual([]int{3, 4}, readAll(t, path)))
+}
+
+func TestLogCorrupted(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-jmap-jsonlog-")
+	assert.NilError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "log")
+	assert.NilError(t, ioutil.WriteFile(path, []byte("1\nx\n3\n"), 0600))
+
+	_, err = Open(path, func(line []byte) error {
+		var v int
+		return json.Unmarshal(line, &v)
+	})
+	assert.Check(t, cmp.ErrorContains(err, "line 2"))
+}
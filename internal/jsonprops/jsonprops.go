@@ -0,0 +1,85 @@
+// Package jsonprops helps to preserve properties of JSON objects that are not
+// represented by fields of the Go structure they are decoded into.
+package jsonprops
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+var knownCache sync.Map // reflect.Type -> map[string]bool
+
+// known returns the names of JSON properties represented by fields of the
+// struct type t.
+func known(t reflect.Type) map[string]bool {
+	if names, ok := knownCache.Load(t); ok {
+		return names.(map[string]bool)
+	}
+	names := make(map[string]bool, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name := f.Name
+		if tag, ok := f.Tag.Lookup("json"); ok {
+			tagName := strings.Split(tag, ",")[0]
+			if tagName == "-" {
+				continue
+			}
+			if tagName != "" {
+				name = tagName
+			}
+		}
+		names[name] = true
+	}
+	knownCache.Store(t, names)
+	return names
+}
+
+// Unknown returns properties of the JSON object in data that do not
+// correspond to fields of the struct v (or a pointer to it). Nil is
+// returned if there are no such properties.
+func Unknown(data []byte, v interface{}) (map[string]json.RawMessage, error) {
+	var props map[string]json.RawMessage
+	if err := json.Unmarshal(data, &props); err != nil {
+		return nil, err
+	}
+	t := reflect.TypeOf(v)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	names := known(t)
+
+	var res map[string]json.RawMessage
+	for k, v := range props {
+		if names[k] {
+			continue
+		}
+		if res == nil {
+			res = make(map[string]json.RawMessage)
+		}
+		res[k] = v
+	}
+	return res, nil
+}
+
+// Merge adds props to the JSON object in blob. Properties already present
+// in blob are not overwritten.
+func Merge(blob []byte, props map[string]json.RawMessage) ([]byte, error) {
+	if len(props) == 0 {
+		return blob, nil
+	}
+	var res map[string]json.RawMessage
+	if err := json.Unmarshal(blob, &res); err != nil {
+		return nil, err
+	}
+	for k, v := range props {
+		if _, ok := res[k]; !ok {
+			res[k] = v
+		}
+	}
+	return json.Marshal(res)
+}
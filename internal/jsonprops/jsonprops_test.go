@@ -0,0 +1,35 @@
+package jsonprops
+
+import (
+	"encoding/json"
+	"testing"
+
+	"gotest.tools/assert"
+	"gotest.tools/assert/cmp"
+)
+
+type object struct {
+	ID     string `json:"id,omitempty"`
+	Name   string
+	Hidden string `json:"-"`
+}
+
+func TestUnknownMerge(t *testing.T) {
+	props, err := Unknown([]byte(`{"id":"1","Name":"a","Hidden":"b","x-vendor":{"a":1}}`), &object{})
+	assert.NilError(t, err)
+	assert.Check(t, cmp.DeepEqual(map[string]json.RawMessage{
+		"Hidden":   json.RawMessage(`"b"`),
+		"x-vendor": json.RawMessage(`{"a":1}`),
+	}, props))
+
+	props, err = Unknown([]byte(`{"id":"1"}`), object{})
+	assert.NilError(t, err)
+	assert.Check(t, props == nil)
+
+	blob, err := Merge([]byte(`{"id":"1"}`), map[string]json.RawMessage{
+		"id":       json.RawMessage(`"2"`),
+		"x-vendor": json.RawMessage(`true`),
+	})
+	assert.NilError(t, err)
+	assert.Check(t, cmp.Equal(`{"id":"1","x-vendor":true}`, string(blob)))
+}
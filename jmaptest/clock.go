@@ -0,0 +1,87 @@
+package jmaptest
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// FakeClock is the client.Clock implementation for tests. Time moves only
+// when Advance or Set is called.
+//
+// It is safe for concurrent use.
+type FakeClock struct {
+	lck     sync.Mutex
+	now     time.Time
+	waiters []fakeWaiter
+}
+
+type fakeWaiter struct {
+	deadline time.Time
+	ch       chan time.Time
+}
+
+// NewFakeClock creates the FakeClock set to the specified time.
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+func (fc *FakeClock) Now() time.Time {
+	fc.lck.Lock()
+	defer fc.lck.Unlock()
+	return fc.now
+}
+
+func (fc *FakeClock) After(d time.Duration) <-chan time.Time {
+	fc.lck.Lock()
+	defer fc.lck.Unlock()
+
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- fc.now
+		return ch
+	}
+	fc.waiters = append(fc.waiters, fakeWaiter{deadline: fc.now.Add(d), ch: ch})
+	return ch
+}
+
+// Advance moves the clock forward, firing all timers that expire in the
+// process.
+func (fc *FakeClock) Advance(d time.Duration) {
+	fc.Set(fc.Now().Add(d))
+}
+
+// Set sets the current time, firing all timers that expire before it.
+func (fc *FakeClock) Set(now time.Time) {
+	fc.lck.Lock()
+	defer fc.lck.Unlock()
+
+	fc.now = now
+	sort.SliceStable(fc.waiters, func(i, j int) bool {
+		return fc.waiters[i].deadline.Before(fc.waiters[j].deadline)
+	})
+	remaining := fc.waiters[:0]
+	for _, w := range fc.waiters {
+		if w.deadline.After(now) {
+			remaining = append(remaining, w)
+			continue
+		}
+		w.ch <- now
+	}
+	fc.waiters = remaining
+}
+
+// Waiters returns the number of pending After calls. It can be used to wait
+// until the tested code blocks on the clock before calling Advance.
+func (fc *FakeClock) Waiters() int {
+	fc.lck.Lock()
+	defer fc.lck.Unlock()
+	return len(fc.waiters)
+}
+
+// BlockUntil waits until there are at least n pending After calls.
+func (fc *FakeClock) BlockUntil(n int) {
+	for fc.Waiters() < n {
+		time.Sleep(time.Millisecond)
+	}
+}
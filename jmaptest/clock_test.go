@@ -0,0 +1,39 @@
+package jmaptest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/foxcpp/go-jmap/client"
+	"gotest.tools/assert"
+	"gotest.tools/assert/cmp"
+)
+
+var _ client.Clock = &FakeClock{}
+
+func TestFakeClock(t *testing.T) {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	fc := NewFakeClock(start)
+
+	short := fc.After(time.Second)
+	long := fc.After(time.Minute)
+	assert.Check(t, cmp.Equal(2, fc.Waiters()))
+
+	fc.Advance(2 * time.Second)
+	select {
+	case now := <-short:
+		assert.Check(t, now.Equal(start.Add(2*time.Second)))
+	default:
+		t.Fatal("short timer did not fire")
+	}
+	select {
+	case <-long:
+		t.Fatal("long timer fired too early")
+	default:
+	}
+	assert.Check(t, cmp.Equal(1, fc.Waiters()))
+
+	fc.Advance(time.Minute)
+	<-long
+	assert.Check(t, fc.Now().Equal(start.Add(time.Minute+2*time.Second)))
+}
@@ -0,0 +1,183 @@
+// Package jmaptest contains utilities for testing code that uses JMAP.
+package jmaptest
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/foxcpp/go-jmap"
+)
+
+// DiffOptions controls which differences are reported by DiffResponses.
+type DiffOptions struct {
+	// Do not compare call IDs of method responses.
+	IgnoreCallIDs bool
+
+	// Match method responses regardless of their position in the response.
+	// Responses are matched by method name (and call ID unless IgnoreCallIDs
+	// is set).
+	IgnoreOrder bool
+
+	// Do not compare sessionState values.
+	IgnoreSessionState bool
+
+	// Do not compare createdIds maps.
+	IgnoreCreatedIDs bool
+}
+
+// DiffResponses compares two Response objects and returns human-readable
+// descriptions of all found mismatches. Empty slice is returned if objects
+// are equal.
+//
+// Method arguments are compared by their JSON representation so it does not
+// matter whether they are decoded into structures or not.
+func DiffResponses(expected, actual *jmap.Response, opts DiffOptions) []string {
+	var diffs []string
+
+	if !opts.IgnoreSessionState && expected.SessionState != actual.SessionState {
+		diffs = append(diffs, fmt.Sprintf("sessionState: expected %q, got %q", expected.SessionState, actual.SessionState))
+	}
+	if !opts.IgnoreCreatedIDs {
+		diffs = append(diffs, diffJSON("createdIds", toJSONValue(expected.CreatedIDs), toJSONValue(actual.CreatedIDs))...)
+	}
+
+	if !opts.IgnoreOrder {
+		for i := 0; i < len(expected.Responses) || i < len(actual.Responses); i++ {
+			if i >= len(actual.Responses) {
+				diffs = append(diffs, fmt.Sprintf("methodResponses[%d]: missing %s", i, invDesc(expected.Responses[i])))
+				continue
+			}
+			if i >= len(expected.Responses) {
+				diffs = append(diffs, fmt.Sprintf("methodResponses[%d]: unexpected %s", i, invDesc(actual.Responses[i])))
+				continue
+			}
+			diffs = append(diffs, diffInvocation("methodResponses["+strconv.Itoa(i)+"]", expected.Responses[i], actual.Responses[i], opts)...)
+		}
+		return diffs
+	}
+
+	used := make([]bool, len(actual.Responses))
+	for i, exp := range expected.Responses {
+		match := -1
+		for j, act := range actual.Responses {
+			if used[j] || act.Name != exp.Name || (!opts.IgnoreCallIDs && act.CallID != exp.CallID) {
+				continue
+			}
+			// Prefer exact match, otherwise use first response with the same
+			// name to report differences in arguments.
+			if match == -1 {
+				match = j
+			}
+			if len(diffInvocation("", exp, act, opts)) == 0 {
+				match = j
+				break
+			}
+		}
+		if match == -1 {
+			diffs = append(diffs, fmt.Sprintf("methodResponses[%d]: missing %s", i, invDesc(exp)))
+			continue
+		}
+		used[match] = true
+		diffs = append(diffs, diffInvocation("methodResponses["+strconv.Itoa(i)+"]", exp, actual.Responses[match], opts)...)
+	}
+	for j, act := range actual.Responses {
+		if !used[j] {
+			diffs = append(diffs, fmt.Sprintf("methodResponses[%d]: unexpected %s", j, invDesc(act)))
+		}
+	}
+	return diffs
+}
+
+func invDesc(inv jmap.Invocation) string {
+	return fmt.Sprintf("%s (call ID %q)", inv.Name, inv.CallID)
+}
+
+func diffInvocation(prefix string, expected, actual jmap.Invocation, opts DiffOptions) []string {
+	if expected.Name != actual.Name {
+		// Arguments of different methods are not comparable.
+		return []string{fmt.Sprintf("%s: expected %s, got %s", prefix, invDesc(expected), invDesc(actual))}
+	}
+
+	var diffs []string
+	if !opts.IgnoreCallIDs && expected.CallID != actual.CallID {
+		diffs = append(diffs, fmt.Sprintf("%s: expected call ID %q, got %q", prefix, expected.CallID, actual.CallID))
+	}
+	return append(diffs, diffJSON(prefix+" "+expected.Name, toJSONValue(expected.Args), toJSONValue(actual.Args))...)
+}
+
+// toJSONValue converts v into generic representation produced by
+// encoding/json (maps, slices, float64, strings, etc).
+func toJSONValue(v interface{}) interface{} {
+	blob, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("<marshal error: %v>", err)
+	}
+	var res interface{}
+	if err := json.Unmarshal(blob, &res); err != nil {
+		return fmt.Sprintf("<unmarshal error: %v>", err)
+	}
+	return res
+}
+
+func diffJSON(path string, expected, actual interface{}) []string {
+	switch exp := expected.(type) {
+	case map[string]interface{}:
+		act, ok := actual.(map[string]interface{})
+		if !ok {
+			break
+		}
+		keys := make([]string, 0, len(exp)+len(act))
+		for k := range exp {
+			keys = append(keys, k)
+		}
+		for k := range act {
+			if _, ok := exp[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+
+		var diffs []string
+		for _, k := range keys {
+			subPath := path + "/" + strings.NewReplacer("~", "~0", "/", "~1").Replace(k)
+			expVal, expOk := exp[k]
+			actVal, actOk := act[k]
+			switch {
+			case !actOk:
+				diffs = append(diffs, fmt.Sprintf("%s: missing, expected %s", subPath, jsonString(expVal)))
+			case !expOk:
+				diffs = append(diffs, fmt.Sprintf("%s: unexpected %s", subPath, jsonString(actVal)))
+			default:
+				diffs = append(diffs, diffJSON(subPath, expVal, actVal)...)
+			}
+		}
+		return diffs
+	case []interface{}:
+		act, ok := actual.([]interface{})
+		if !ok || len(act) != len(exp) {
+			break
+		}
+		var diffs []string
+		for i := range exp {
+			diffs = append(diffs, diffJSON(path+"/"+strconv.Itoa(i), exp[i], act[i])...)
+		}
+		return diffs
+	}
+
+	if reflect.DeepEqual(expected, actual) {
+		return nil
+	}
+	return []string{fmt.Sprintf("%s: expected %s, got %s", path, jsonString(expected), jsonString(actual))}
+}
+
+func jsonString(v interface{}) string {
+	blob, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(blob)
+}
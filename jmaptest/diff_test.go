@@ -0,0 +1,57 @@
+package jmaptest
+
+import (
+	"testing"
+
+	"github.com/foxcpp/go-jmap"
+	"gotest.tools/assert"
+	"gotest.tools/assert/cmp"
+)
+
+func TestDiffResponses(t *testing.T) {
+	expected := &jmap.Response{
+		SessionState: "1",
+		Responses: []jmap.Invocation{
+			{Name: "Mailbox/get", CallID: "0", Args: map[string]interface{}{"state": "1", "list": []interface{}{}}},
+			{Name: "Email/get", CallID: "1", Args: map[string]interface{}{"state": "2", "notFound": []string{"a"}}},
+		},
+	}
+
+	t.Run("equal", func(t *testing.T) {
+		assert.Check(t, cmp.Len(DiffResponses(expected, expected, DiffOptions{}), 0))
+	})
+
+	t.Run("args mismatch", func(t *testing.T) {
+		actual := &jmap.Response{
+			SessionState: "2",
+			Responses: []jmap.Invocation{
+				{Name: "Mailbox/get", CallID: "0", Args: map[string]interface{}{"state": "1", "list": []interface{}{}}},
+				{Name: "Email/get", CallID: "1", Args: map[string]interface{}{"state": "3", "notFound": []string{"a"}, "extra": 1}},
+			},
+		}
+		assert.Check(t, cmp.DeepEqual([]string{
+			`sessionState: expected "1", got "2"`,
+			`methodResponses[1] Email/get/extra: unexpected 1`,
+			`methodResponses[1] Email/get/state: expected "2", got "3"`,
+		}, DiffResponses(expected, actual, DiffOptions{})))
+	})
+
+	t.Run("ignore order and call IDs", func(t *testing.T) {
+		actual := &jmap.Response{
+			SessionState: "1",
+			Responses: []jmap.Invocation{
+				{Name: "Email/get", CallID: "a", Args: map[string]interface{}{"state": "2", "notFound": []string{"a"}}},
+				{Name: "Mailbox/get", CallID: "b", Args: map[string]interface{}{"state": "1", "list": []interface{}{}}},
+			},
+		}
+		assert.Check(t, cmp.Len(DiffResponses(expected, actual, DiffOptions{IgnoreOrder: true, IgnoreCallIDs: true}), 0))
+		assert.Check(t, cmp.Len(DiffResponses(expected, actual, DiffOptions{}), 2))
+	})
+
+	t.Run("missing response", func(t *testing.T) {
+		actual := &jmap.Response{SessionState: "1", Responses: expected.Responses[:1]}
+		assert.Check(t, cmp.DeepEqual([]string{
+			`methodResponses[1]: missing Email/get (call ID "1")`,
+		}, DiffResponses(expected, actual, DiffOptions{IgnoreOrder: true})))
+	})
+}
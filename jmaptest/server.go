@@ -0,0 +1,192 @@
+package jmaptest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+)
+
+// Recording is an HTTP response captured from a real server. It is replayed
+// byte-for-byte by Server, including headers that are not strictly needed,
+// so quirks of actual implementations can be reproduced.
+type Recording struct {
+	// Short description of the origin of the recording.
+	Name string
+
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// Recorded problem details responses (RFC 7807) for request-level errors
+// defined in section 3.6.1 of JMAP Core specification.
+//
+// The set intentionally covers variations seen in deployed servers:
+// application/json vs application/problem+json content types, charset
+// parameters, missing detail fields and extension members.
+var (
+	ProblemNotJSON = Recording{
+		Name:       "notJSON",
+		StatusCode: http.StatusBadRequest,
+		Header:     http.Header{"Content-Type": {"application/problem+json"}},
+		Body: []byte(`{"type":"urn:ietf:params:jmap:error:notJSON","status":400,` +
+			`"detail":"The request did not parse as I-JSON."}`),
+	}
+	ProblemNotRequest = Recording{
+		Name:       "notRequest",
+		StatusCode: http.StatusBadRequest,
+		Header:     http.Header{"Content-Type": {"application/problem+json; charset=utf-8"}},
+		Body: []byte(`{"type":"urn:ietf:params:jmap:error:notRequest","status":400,` +
+			`"title":"Invalid request",` +
+			`"detail":"The request parsed as JSON but did not match the type signature of the Request object."}` + "\n"),
+	}
+	ProblemUnknownCapability = Recording{
+		Name:       "unknownCapability",
+		StatusCode: http.StatusBadRequest,
+		Header:     http.Header{"Content-Type": {"application/json"}},
+		Body: []byte(`{"type":"urn:ietf:params:jmap:error:unknownCapability","status":400,` +
+			`"detail":"The request object used capability 'https://example.com/apis/foobar', which is not supported by this server."}`),
+	}
+	ProblemLimitCalls = Recording{
+		Name:       "limit maxCallsInRequest",
+		StatusCode: http.StatusBadRequest,
+		Header:     http.Header{"Content-Type": {"application/problem+json"}},
+		Body: []byte(`{"type":"urn:ietf:params:jmap:error:limit","limit":"maxCallsInRequest",` +
+			`"status":400,"detail":"Too many method calls in the request."}`),
+	}
+	ProblemLimitSize = Recording{
+		Name:       "limit maxSizeRequest",
+		StatusCode: http.StatusRequestEntityTooLarge,
+		Header:     http.Header{"Content-Type": {"application/problem+json"}},
+		Body:       []byte(`{"type":"urn:ietf:params:jmap:error:limit","limit":"maxSizeRequest","status":413}`),
+	}
+	ProblemLimitConcurrent = Recording{
+		Name:       "limit maxConcurrentRequests",
+		StatusCode: http.StatusTooManyRequests,
+		Header: http.Header{
+			"Content-Type": {"application/problem+json"},
+			"Retry-After":  {"1"},
+		},
+		Body: []byte(`{"type":"urn:ietf:params:jmap:error:limit","limit":"maxConcurrentRequests",` +
+			`"status":429,"detail":"Too many concurrent requests.","requestId":"5e0f3c"}`),
+	}
+)
+
+// Problems contains all recorded problem responses defined by this package.
+var Problems = []Recording{
+	ProblemNotJSON,
+	ProblemNotRequest,
+	ProblemUnknownCapability,
+	ProblemLimitCalls,
+	ProblemLimitSize,
+	ProblemLimitConcurrent,
+}
+
+// Server is a minimal JMAP server for client tests. It serves the Session
+// object at /.well-known/jmap and passes API requests to the handler, unless
+// there are queued recordings.
+type Server struct {
+	*httptest.Server
+
+	// The Session object served by the server. It can be modified before
+	// the first request is made. apiUrl, downloadUrl and uploadUrl are set
+	// by NewServer.
+	Session map[string]interface{}
+
+	api http.Handler
+
+	lck   sync.Mutex
+	queue []Recording
+}
+
+// NewServer starts the Server with a single account "A1" supporting JMAP
+// Core and Mail capabilities. api is used to handle requests to the API
+// endpoint, it can be nil if only recordings are used.
+func NewServer(api http.Handler) *Server {
+	s := &Server{api: api}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/jmap", s.serveSession)
+	mux.HandleFunc("/api", s.serveAPI)
+	s.Server = httptest.NewServer(mux)
+
+	s.Session = map[string]interface{}{
+		"capabilities": map[string]interface{}{
+			"urn:ietf:params:jmap:core": map[string]interface{}{
+				"maxSizeUpload":         50000000,
+				"maxConcurrentUpload":   4,
+				"maxSizeRequest":        10000000,
+				"maxConcurrentRequests": 4,
+				"maxCallsInRequest":     16,
+				"maxObjectsInGet":       500,
+				"maxObjectsInSet":       500,
+				"collationAlgorithms":   []string{},
+			},
+			"urn:ietf:params:jmap:mail": map[string]interface{}{},
+		},
+		"accounts": map[string]interface{}{
+			"A1": map[string]interface{}{
+				"name":       "test@example.org",
+				"isPersonal": true,
+				"accountCapabilities": map[string]interface{}{
+					"urn:ietf:params:jmap:mail": map[string]interface{}{},
+				},
+			},
+		},
+		"primaryAccounts": map[string]interface{}{
+			"urn:ietf:params:jmap:mail": "A1",
+		},
+		"username":    "test@example.org",
+		"apiUrl":      s.URL + "/api",
+		"downloadUrl": s.URL + "/download/{accountId}/{blobId}/{name}?accept={type}",
+		"uploadUrl":   s.URL + "/upload/{accountId}/",
+		"state":       "1",
+	}
+	return s
+}
+
+// SessionURL returns the URL of the Session resource.
+func (s *Server) SessionURL() string {
+	return s.URL + "/.well-known/jmap"
+}
+
+// Replay queues recordings to be served in order for the next API requests
+// instead of passing them to the handler.
+func (s *Server) Replay(recs ...Recording) {
+	s.lck.Lock()
+	defer s.lck.Unlock()
+	s.queue = append(s.queue, recs...)
+}
+
+func (s *Server) serveSession(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.Session) //nolint:errcheck
+}
+
+func (s *Server) serveAPI(w http.ResponseWriter, r *http.Request) {
+	s.lck.Lock()
+	var (
+		rec    Recording
+		replay bool
+	)
+	if len(s.queue) != 0 {
+		rec, replay = s.queue[0], true
+		s.queue = s.queue[1:]
+	}
+	s.lck.Unlock()
+
+	if replay {
+		for k, v := range rec.Header {
+			w.Header()[k] = v
+		}
+		w.WriteHeader(rec.StatusCode)
+		w.Write(rec.Body) //nolint:errcheck
+		return
+	}
+	if s.api == nil {
+		http.Error(w, "jmaptest: no handler and no queued recordings", http.StatusInternalServerError)
+		return
+	}
+	s.api.ServeHTTP(w, r)
+}
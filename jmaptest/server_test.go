@@ -0,0 +1,40 @@
+package jmaptest
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"gotest.tools/assert"
+	"gotest.tools/assert/cmp"
+)
+
+func TestServerReplay(t *testing.T) {
+	srv := NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("handler")) //nolint:errcheck
+	}))
+	defer srv.Close()
+	srv.Replay(ProblemNotRequest)
+
+	post := func() *http.Response {
+		resp, err := http.Post(srv.URL+"/api", "application/json", strings.NewReader("{}"))
+		assert.NilError(t, err)
+		return resp
+	}
+
+	resp := post()
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.NilError(t, err)
+	assert.Check(t, cmp.Equal(http.StatusBadRequest, resp.StatusCode))
+	assert.Check(t, cmp.Equal("application/problem+json; charset=utf-8", resp.Header.Get("Content-Type")))
+	assert.Check(t, bytes.Equal(ProblemNotRequest.Body, body))
+
+	resp = post()
+	body, err = ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.NilError(t, err)
+	assert.Check(t, cmp.Equal("handler", string(body)))
+}
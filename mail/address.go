@@ -0,0 +1,96 @@
+package mail
+
+import (
+	"fmt"
+	"mime"
+	netmail "net/mail"
+	"strings"
+)
+
+// String formats the address as RFC 5322 mailbox, suitable for use in
+// address header fields.
+//
+// The display name is quoted or encoded using RFC 2047 encoded words as
+// necessary.
+func (a EmailAddress) String() string {
+	formatted := (&netmail.Address{Name: a.Name, Address: a.Email}).String()
+	if a.Name == "" {
+		// net/mail always uses angle brackets, they are not needed for
+		// bare addr-spec.
+		formatted = strings.TrimSuffix(strings.TrimPrefix(formatted, "<"), ">")
+	}
+	return formatted
+}
+
+// String formats the group as RFC 5322 group (if Name is not empty) or a
+// comma-separated list of mailboxes.
+func (g EmailAddressGroup) String() string {
+	list := FormatAddressList(g.Addresses)
+	if g.Name == "" {
+		return list
+	}
+	name := (&netmail.Address{Name: g.Name, Address: "x@x"}).String()
+	name = strings.TrimSuffix(name, " <x@x>")
+	if list == "" {
+		return name + ":;"
+	}
+	return name + ": " + list + ";"
+}
+
+// FormatAddressList formats the addresses as RFC 5322 address-list.
+func FormatAddressList(addrs []EmailAddress) string {
+	parts := make([]string, 0, len(addrs))
+	for _, addr := range addrs {
+		parts = append(parts, addr.String())
+	}
+	return strings.Join(parts, ", ")
+}
+
+var addrParser = netmail.AddressParser{WordDecoder: &mime.WordDecoder{}}
+
+// ParseAddress parses a single RFC 5322 address, such as
+// "Joe Bloggs <joe@example.com>". Encoded words in the display name are
+// decoded.
+func ParseAddress(s string) (EmailAddress, error) {
+	addr, err := addrParser.Parse(s)
+	if err != nil {
+		return EmailAddress{}, fmt.Errorf("jmap/mail: malformed address %q: %v", s, err)
+	}
+	return EmailAddress{Name: addr.Name, Email: addr.Address}, nil
+}
+
+// ParseAddressList parses a comma-separated list of RFC 5322 addresses.
+//
+// Groups are not supported.
+func ParseAddressList(s string) ([]EmailAddress, error) {
+	addrs, err := addrParser.ParseList(s)
+	if err != nil {
+		return nil, fmt.Errorf("jmap/mail: malformed address list %q: %v", s, err)
+	}
+	res := make([]EmailAddress, 0, len(addrs))
+	for _, addr := range addrs {
+		res = append(res, EmailAddress{Name: addr.Name, Email: addr.Address})
+	}
+	return res, nil
+}
+
+// String formats the header field as it would appear in the message,
+// without the terminating CRLF.
+func (h EmailHeader) String() string {
+	return h.Name + ":" + h.Value
+}
+
+// TextHeader returns the header field with the value in Raw form
+// corresponding to the text. Non-ASCII text is encoded using RFC 2047
+// encoded words.
+//
+// Long values are not folded.
+func TextHeader(name, text string) (EmailHeader, error) {
+	if !ValidHeaderName(name) {
+		return EmailHeader{}, fmt.Errorf("jmap/mail: invalid header field name: %q", name)
+	}
+	if strings.ContainsAny(text, "\r\n") {
+		return EmailHeader{}, fmt.Errorf("jmap/mail: header field value contains line break")
+	}
+	return EmailHeader{Name: name, Value: " " + mime.QEncoding.Encode("utf-8", text)}, nil
+}
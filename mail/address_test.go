@@ -0,0 +1,60 @@
+package mail
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+	"gotest.tools/assert/cmp"
+)
+
+func TestEmailAddressString(t *testing.T) {
+	cases := []struct {
+		addr     EmailAddress
+		expected string
+	}{
+		{EmailAddress{Email: "joe@example.com"}, "joe@example.com"},
+		{EmailAddress{Name: "Joe Bloggs", Email: "joe@example.com"}, `"Joe Bloggs" <joe@example.com>`},
+		{EmailAddress{Name: `Bloggs, "Joe"`, Email: "joe@example.com"}, `"Bloggs, \"Joe\"" <joe@example.com>`},
+		{EmailAddress{Name: "Jöe", Email: "joe@example.com"}, `=?utf-8?q?J=C3=B6e?= <joe@example.com>`},
+	}
+	for _, c := range cases {
+		assert.Check(t, cmp.Equal(c.expected, c.addr.String()))
+		if c.addr.Name == "" {
+			continue
+		}
+		parsed, err := ParseAddress(c.expected)
+		assert.NilError(t, err)
+		assert.Check(t, cmp.DeepEqual(c.addr, parsed))
+	}
+}
+
+func TestEmailAddressGroupString(t *testing.T) {
+	g := EmailAddressGroup{
+		Name:      "Team",
+		Addresses: []EmailAddress{{Email: "a@example.com"}, {Name: "B", Email: "b@example.com"}},
+	}
+	assert.Check(t, cmp.Equal(`"Team": a@example.com, "B" <b@example.com>;`, g.String()))
+	assert.Check(t, cmp.Equal(`"Undisclosed recipients":;`, EmailAddressGroup{Name: "Undisclosed recipients"}.String()))
+	assert.Check(t, cmp.Equal(`a@example.com`, EmailAddressGroup{Addresses: g.Addresses[:1]}.String()))
+}
+
+func TestParseAddressList(t *testing.T) {
+	addrs, err := ParseAddressList(`Joe <joe@example.com>, =?utf-8?q?J=C3=B6e?= <j@example.com>`)
+	assert.NilError(t, err)
+	assert.Check(t, cmp.DeepEqual([]EmailAddress{
+		{Name: "Joe", Email: "joe@example.com"},
+		{Name: "Jöe", Email: "j@example.com"},
+	}, addrs))
+
+	_, err = ParseAddressList("not an address")
+	assert.Check(t, err != nil)
+}
+
+func TestTextHeader(t *testing.T) {
+	h, err := TextHeader("Subject", "Grüße")
+	assert.NilError(t, err)
+	assert.Check(t, cmp.Equal("Subject: =?utf-8?q?Gr=C3=BC=C3=9Fe?=", h.String()))
+
+	_, err = TextHeader("Subject", "a\r\nBcc: x@example.com")
+	assert.Check(t, err != nil)
+}
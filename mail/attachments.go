@@ -0,0 +1,90 @@
+package mail
+
+import (
+	"io"
+	"sync"
+
+	"github.com/foxcpp/go-jmap"
+	"github.com/foxcpp/go-jmap/client"
+)
+
+// AttachmentSink receives the contents of a downloaded attachment.
+//
+// It is called concurrently from multiple goroutines.
+type AttachmentSink func(part EmailBodyPart, content io.Reader) error
+
+// AttachmentResult is the outcome of downloading a single attachment.
+type AttachmentResult struct {
+	Part EmailBodyPart
+
+	// Error returned by the server or by the sink, nil if the attachment
+	// was successfully downloaded and consumed.
+	Err error
+}
+
+// DownloadAttachments downloads all attachments of the Email concurrently
+// and passes each of them to sink.
+//
+// The number of simultaneous downloads is bounded by maxConcurrentRequests
+// (see jmap.Session.Limits). Results are returned in the order of the Email
+// attachments property. Failure to download one attachment does not stop
+// other downloads, returned error is non-nil only if the Email itself could
+// not be fetched.
+//
+// The client must have ResponseUnmarshallers enabled.
+func DownloadAttachments(c *client.Client, account, email jmap.ID, sink AttachmentSink) ([]AttachmentResult, error) {
+	session, err := c.CurrentSession()
+	if err != nil {
+		return nil, err
+	}
+	resp, err := getEmails(c, EmailGetArgs{
+		AccountID:  account,
+		IDs:        []jmap.ID{email},
+		Properties: []string{"attachments"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.List) == 0 {
+		return nil, ErrEmailNotFound
+	}
+
+	parts := resp.List[0].Attachments
+	results := make([]AttachmentResult, len(parts))
+
+	concurrency := int(session.Limits().MaxConcurrentRequests)
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, part := range parts {
+		results[i].Part = part
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(res *AttachmentResult) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			res.Err = downloadAttachment(c, account, res.Part, sink)
+		}(&results[i])
+	}
+	wg.Wait()
+
+	return results, nil
+}
+
+func downloadAttachment(c *client.Client, account jmap.ID, part EmailBodyPart, sink AttachmentSink) error {
+	contentType := part.Type
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	name := part.Name
+	if name == "" {
+		name = "attachment"
+	}
+
+	rd, err := c.DownloadAs(account, part.BlobID, contentType, name)
+	if err != nil {
+		return err
+	}
+	defer rd.Close()
+	return sink(part, rd)
+}
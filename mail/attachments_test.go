@@ -0,0 +1,82 @@
+package mail
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"gotest.tools/assert"
+	"gotest.tools/assert/cmp"
+)
+
+func TestDownloadAttachments(t *testing.T) {
+	c, srv := newTestClient(t, func(name string, args map[string]interface{}) []testResponse {
+		return []testResponse{{name, map[string]interface{}{
+			"list": []interface{}{map[string]interface{}{
+				"id": "E1",
+				"attachments": []interface{}{
+					map[string]interface{}{"partId": "2", "blobId": "B2", "type": "image/png", "name": "a.png"},
+					map[string]interface{}{"partId": "3", "blobId": "missing", "type": "text/plain"},
+					map[string]interface{}{"partId": "4", "blobId": "B4", "type": "application/pdf", "name": "c.pdf"},
+				},
+			}},
+		}}}
+	})
+	defer srv.Close()
+
+	var inFlight, maxInFlight int32
+	dlSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			max := atomic.LoadInt32(&maxInFlight)
+			if n <= max || atomic.CompareAndSwapInt32(&maxInFlight, max, n) {
+				break
+			}
+		}
+		blob := strings.Split(r.URL.Path, "/")[2]
+		if blob == "missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte("content of " + blob)) //nolint:errcheck
+	}))
+	defer dlSrv.Close()
+	_, err := c.CurrentSession()
+	assert.NilError(t, err)
+	c.Session.DownloadURL = dlSrv.URL + "/{accountId}/{blobId}/{name}?type={type}"
+	c.Session.CoreCapability.MaxConcurrentRequests = 2
+
+	var (
+		lck      sync.Mutex
+		received = map[string]string{}
+	)
+	results, err := DownloadAttachments(c, "A1", "E1", func(part EmailBodyPart, content io.Reader) error {
+		blob, err := ioutil.ReadAll(content)
+		if err != nil {
+			return err
+		}
+		if part.Name == "c.pdf" {
+			return errors.New("disk full")
+		}
+		lck.Lock()
+		received[part.PartID] = string(blob)
+		lck.Unlock()
+		return nil
+	})
+	assert.NilError(t, err)
+	assert.Assert(t, cmp.Len(results, 3))
+
+	assert.Check(t, cmp.Equal("2", results[0].Part.PartID))
+	assert.Check(t, cmp.Nil(results[0].Err))
+	assert.Check(t, results[1].Err != nil)
+	assert.Check(t, cmp.Error(results[2].Err, "disk full"))
+	assert.Check(t, cmp.DeepEqual(map[string]string{"2": "content of B2"}, received))
+	assert.Check(t, atomic.LoadInt32(&maxInFlight) <= 2)
+}
@@ -0,0 +1,180 @@
+package mail
+
+import (
+	"strings"
+)
+
+// Walk calls fn for the part and all its sub-parts in depth-first order.
+// depth is 0 for the part Walk is called on. If fn returns false, sub-parts
+// of the part are skipped.
+func (p *EmailBodyPart) Walk(fn func(part *EmailBodyPart, depth int) bool) {
+	p.walk(fn, 0)
+}
+
+func (p *EmailBodyPart) walk(fn func(part *EmailBodyPart, depth int) bool, depth int) {
+	if !fn(p, depth) {
+		return
+	}
+	for i := range p.SubParts {
+		p.SubParts[i].walk(fn, depth+1)
+	}
+}
+
+// IsMultipart returns true if the part is a multipart/* container.
+func (p *EmailBodyPart) IsMultipart() bool {
+	return strings.HasPrefix(strings.ToLower(p.Type), "multipart/")
+}
+
+// FindPart returns the part with the specified partId or nil if there is no
+// such part.
+func (p *EmailBodyPart) FindPart(partID string) *EmailBodyPart {
+	var res *EmailBodyPart
+	p.Walk(func(part *EmailBodyPart, _ int) bool {
+		if res != nil {
+			return false
+		}
+		if part.PartID == partID {
+			res = part
+			return false
+		}
+		return true
+	})
+	return res
+}
+
+// Flatten splits the body structure into the list of parts to display as
+// plain text, parts to display as HTML and attachments, using the algorithm
+// from RFC 8621, section 4.1.4.
+//
+// The results are the same as textBody, htmlBody and attachments properties
+// computed by the server.
+func (p *EmailBodyPart) Flatten() (textBody, htmlBody, attachments []EmailBodyPart) {
+	textBody = []EmailBodyPart{}
+	htmlBody = []EmailBodyPart{}
+	parseStructure([]EmailBodyPart{*p}, "mixed", false, &htmlBody, &textBody, &attachments)
+	return textBody, htmlBody, attachments
+}
+
+func isInlineMediaType(typ string) bool {
+	return strings.HasPrefix(typ, "image/") ||
+		strings.HasPrefix(typ, "audio/") ||
+		strings.HasPrefix(typ, "video/")
+}
+
+// parseStructure is a direct translation of the reference algorithm. Nil
+// htmlBody or textBody pointer corresponds to null in the original.
+func parseStructure(parts []EmailBodyPart, multipartType string, inAlternative bool, htmlBody, textBody, attachments *[]EmailBodyPart) {
+	textLength, htmlLength := -1, -1
+	if textBody != nil {
+		textLength = len(*textBody)
+	}
+	if htmlBody != nil {
+		htmlLength = len(*htmlBody)
+	}
+
+	for i, part := range parts {
+		typ := strings.ToLower(part.Type)
+		isMultipart := strings.HasPrefix(typ, "multipart/")
+		// Is this a body part rather than an attachment?
+		isInline := !strings.EqualFold(part.Disposition, "attachment") &&
+			// Must be one of the allowed body types.
+			(typ == "text/plain" || typ == "text/html" || isInlineMediaType(typ)) &&
+			// If multipart/related, only the first part can be inline. If a
+			// text part with a filename, and not the first item in the
+			// multipart, assume it is an attachment.
+			(i == 0 || (multipartType != "related" && (isInlineMediaType(typ) || part.Name == "")))
+
+		switch {
+		case isMultipart:
+			subMultiType := strings.TrimPrefix(typ, "multipart/")
+			parseStructure(part.SubParts, subMultiType,
+				inAlternative || subMultiType == "alternative",
+				htmlBody, textBody, attachments)
+		case isInline:
+			if multipartType == "alternative" {
+				// textBody or htmlBody may be nil if this alternative is
+				// nested in a part of another one, the reference algorithm
+				// does not handle that.
+				switch typ {
+				case "text/plain":
+					if textBody != nil {
+						*textBody = append(*textBody, part)
+					}
+				case "text/html":
+					if htmlBody != nil {
+						*htmlBody = append(*htmlBody, part)
+					}
+				default:
+					*attachments = append(*attachments, part)
+				}
+				continue
+			} else if inAlternative {
+				if typ == "text/plain" {
+					htmlBody = nil
+				}
+				if typ == "text/html" {
+					textBody = nil
+				}
+			}
+			if textBody != nil {
+				*textBody = append(*textBody, part)
+			}
+			if htmlBody != nil {
+				*htmlBody = append(*htmlBody, part)
+			}
+			if (textBody == nil || htmlBody == nil) && isInlineMediaType(typ) {
+				*attachments = append(*attachments, part)
+			}
+		default:
+			*attachments = append(*attachments, part)
+		}
+	}
+
+	if multipartType == "alternative" && textBody != nil && htmlBody != nil {
+		// Found HTML part only.
+		if textLength == len(*textBody) && htmlLength != len(*htmlBody) {
+			*textBody = append(*textBody, (*htmlBody)[htmlLength:]...)
+		}
+		// Found plaintext part only.
+		if htmlLength == len(*htmlBody) && textLength != len(*textBody) {
+			*htmlBody = append(*htmlBody, (*textBody)[textLength:]...)
+		}
+	}
+}
+
+// PreferredBody returns the list of parts to display as the message body.
+// If preferHTML is true, the HTML version is returned, otherwise the plain
+// text version is returned.
+//
+// Server-computed textBody and htmlBody properties are used if they were
+// fetched, otherwise they are computed from bodyStructure. Nil is returned
+// if neither is available.
+func (e *Email) PreferredBody(preferHTML bool) []EmailBodyPart {
+	if preferHTML && e.HTMLBody != nil {
+		return e.HTMLBody
+	}
+	if !preferHTML && e.TextBody != nil {
+		return e.TextBody
+	}
+	if e.BodyStructure == nil {
+		return nil
+	}
+	textBody, htmlBody, _ := e.BodyStructure.Flatten()
+	if preferHTML {
+		return htmlBody
+	}
+	return textBody
+}
+
+// AttachmentParts returns the list of parts that should be presented as
+// attachments.
+//
+// Server-computed attachments property is used if it was fetched, otherwise
+// it is computed from bodyStructure.
+func (e *Email) AttachmentParts() []EmailBodyPart {
+	if e.Attachments != nil || e.BodyStructure == nil {
+		return e.Attachments
+	}
+	_, _, attachments := e.BodyStructure.Flatten()
+	return attachments
+}
@@ -0,0 +1,87 @@
+package mail
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+	"gotest.tools/assert/cmp"
+)
+
+func partIDs(parts []EmailBodyPart) []string {
+	ids := make([]string, 0, len(parts))
+	for _, p := range parts {
+		ids = append(ids, p.PartID)
+	}
+	return ids
+}
+
+func TestFlatten(t *testing.T) {
+	// Example from RFC 8621, section 4.1.4.
+	structure := EmailBodyPart{
+		Type: "multipart/mixed",
+		SubParts: []EmailBodyPart{
+			{PartID: "A", Type: "text/plain"},
+			{
+				Type: "multipart/alternative",
+				SubParts: []EmailBodyPart{
+					{
+						Type: "multipart/mixed",
+						SubParts: []EmailBodyPart{
+							{PartID: "B", Type: "text/plain"},
+							{PartID: "C", Type: "image/jpeg", Disposition: "inline"},
+							{PartID: "D", Type: "text/plain"},
+						},
+					},
+					{
+						Type: "multipart/related",
+						SubParts: []EmailBodyPart{
+							{PartID: "E", Type: "text/html"},
+							{PartID: "F", Type: "image/jpeg"},
+						},
+					},
+				},
+			},
+			{PartID: "G", Type: "image/jpeg", Disposition: "attachment"},
+			{PartID: "H", Type: "application/x-excel"},
+			{PartID: "J", Type: "message/rfc822"},
+			{PartID: "K", Type: "text/plain"},
+		},
+	}
+
+	textBody, htmlBody, attachments := structure.Flatten()
+	assert.Check(t, cmp.DeepEqual([]string{"A", "B", "C", "D", "K"}, partIDs(textBody)))
+	assert.Check(t, cmp.DeepEqual([]string{"A", "E", "K"}, partIDs(htmlBody)))
+	assert.Check(t, cmp.DeepEqual([]string{"C", "F", "G", "H", "J"}, partIDs(attachments)))
+
+	assert.Check(t, cmp.Equal("F", structure.FindPart("F").PartID))
+	assert.Check(t, structure.FindPart("Z") == nil)
+
+	e := Email{BodyStructure: &structure}
+	assert.Check(t, cmp.DeepEqual([]string{"A", "E", "K"}, partIDs(e.PreferredBody(true))))
+}
+
+func TestFlattenNestedAlternative(t *testing.T) {
+	structure := EmailBodyPart{
+		Type: "multipart/alternative",
+		SubParts: []EmailBodyPart{
+			{
+				Type: "multipart/mixed",
+				SubParts: []EmailBodyPart{
+					{PartID: "A", Type: "text/plain"},
+					{
+						Type: "multipart/alternative",
+						SubParts: []EmailBodyPart{
+							{PartID: "B", Type: "text/plain"},
+							{PartID: "C", Type: "text/html"},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	textBody, htmlBody, attachments := structure.Flatten()
+	assert.Check(t, cmp.DeepEqual([]string{"A", "B"}, partIDs(textBody)))
+	assert.Check(t, cmp.DeepEqual([]string{"A", "B"}, partIDs(htmlBody)))
+	assert.Check(t, cmp.Len(attachments, 0))
+}
@@ -0,0 +1,91 @@
+package mail
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"github.com/foxcpp/go-jmap"
+	"github.com/foxcpp/go-jmap/client"
+)
+
+var ErrUnsupportedCharset = errors.New("jmap/mail: charset of the body part is not supported")
+
+// findBodyPart looks up the part in bodyStructure, textBody, htmlBody and
+// attachments properties, whichever were fetched.
+func (e *Email) findBodyPart(partID string) *EmailBodyPart {
+	if e.BodyStructure != nil {
+		if part := e.BodyStructure.FindPart(partID); part != nil {
+			return part
+		}
+	}
+	for _, list := range [][]EmailBodyPart{e.TextBody, e.HTMLBody, e.Attachments} {
+		for i := range list {
+			if list[i].PartID == partID {
+				return &list[i]
+			}
+		}
+	}
+	return nil
+}
+
+// TruncatedBodyValues returns partIds of fetched body values that were
+// truncated by the server due to EmailGetArgs.MaxBodyValueBytes, sorted.
+func (e *Email) TruncatedBodyValues() []string {
+	var res []string
+	for partID, val := range e.BodyValues {
+		if val.IsTruncated {
+			res = append(res, partID)
+		}
+	}
+	sort.Strings(res)
+	return res
+}
+
+// FullBodyValue returns the complete text of the body part.
+//
+// If the value is present in e.BodyValues and is not truncated, it is
+// returned as is. Otherwise the part contents are downloaded using its blob
+// id, so the part must be present in one of bodyStructure, textBody,
+// htmlBody or attachments properties of e.
+//
+// Downloaded contents are decoded by the client and only UTF-8, US-ASCII and
+// ISO-8859-1 charsets are supported, ErrUnsupportedCharset is returned for
+// others. Use client.Download to get the raw octets in this case.
+func FullBodyValue(c *client.Client, account jmap.ID, e *Email, partID string) (string, error) {
+	if val, ok := e.BodyValues[partID]; ok && !val.IsTruncated {
+		return val.Value, nil
+	}
+
+	part := e.findBodyPart(partID)
+	if part == nil || part.BlobID == "" {
+		return "", fmt.Errorf("jmap/mail: no blob id for body part %s", partID)
+	}
+	charset := strings.ToLower(part.Charset)
+	switch charset {
+	case "", "utf-8", "utf8", "us-ascii", "iso-8859-1", "latin1":
+	default:
+		return "", ErrUnsupportedCharset
+	}
+
+	rd, err := c.Download(account, part.BlobID)
+	if err != nil {
+		return "", err
+	}
+	defer rd.Close()
+	blob, err := ioutil.ReadAll(rd)
+	if err != nil {
+		return "", err
+	}
+
+	if charset == "iso-8859-1" || charset == "latin1" {
+		runes := make([]rune, len(blob))
+		for i, b := range blob {
+			runes[i] = rune(b)
+		}
+		return string(runes), nil
+	}
+	return strings.ToValidUTF8(string(blob), "\uFFFD"), nil
+}
@@ -0,0 +1,80 @@
+package mail
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"gotest.tools/assert"
+	"gotest.tools/assert/cmp"
+)
+
+func TestEmailGetArgsBodyValues(t *testing.T) {
+	blob, err := json.Marshal(EmailGetArgs{AccountID: "A1"})
+	assert.NilError(t, err)
+	assert.Equal(t, `{"accountId":"A1","ids":null,"properties":null}`, string(blob))
+
+	blob, err = json.Marshal(EmailGetArgs{
+		AccountID:           "A1",
+		FetchTextBodyValues: true,
+		FetchHTMLBodyValues: true,
+		FetchAllBodyValues:  true,
+		MaxBodyValueBytes:   256,
+	})
+	assert.NilError(t, err)
+	assert.Equal(t, `{"accountId":"A1","ids":null,"properties":null,"fetchTextBodyValues":true,`+
+		`"fetchHTMLBodyValues":true,"fetchAllBodyValues":true,"maxBodyValueBytes":256}`, string(blob))
+}
+
+func TestFullBodyValue(t *testing.T) {
+	c, srv := newTestClient(t, func(name string, args map[string]interface{}) []testResponse {
+		t.Fatalf("unexpected call: %s", name)
+		return nil
+	})
+	defer srv.Close()
+	var downloaded []string
+	srv.Config.Handler.(*http.ServeMux).HandleFunc("/download/", func(w http.ResponseWriter, r *http.Request) {
+		downloaded = append(downloaded, r.URL.Path)
+		switch r.URL.Path {
+		case "/download/A1/B1/filename":
+			w.Write([]byte("Hello, world!")) //nolint:errcheck
+		case "/download/A1/B2/filename":
+			w.Write([]byte("Gr\xfc\xdfe")) //nolint:errcheck
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	var e Email
+	assert.NilError(t, json.Unmarshal([]byte(`{
+		"bodyStructure": {"type": "multipart/alternative", "subParts": [
+			{"partId": "1", "blobId": "B1", "type": "text/plain", "charset": "utf-8"},
+			{"partId": "2", "blobId": "B2", "type": "text/html", "charset": "ISO-8859-1"},
+			{"partId": "3", "blobId": "B3", "type": "text/calendar", "charset": "koi8-r"}
+		]},
+		"bodyValues": {
+			"1": {"value": "Hello", "isTruncated": true},
+			"2": {"value": "Grüße"}
+		}
+	}`), &e))
+	assert.DeepEqual(t, []string{"1"}, e.TruncatedBodyValues())
+
+	val, err := FullBodyValue(c, "A1", &e, "1")
+	assert.NilError(t, err)
+	assert.Check(t, cmp.Equal("Hello, world!", val))
+
+	val, err = FullBodyValue(c, "A1", &e, "2")
+	assert.NilError(t, err)
+	assert.Check(t, cmp.Equal("Grüße", val))
+	assert.Check(t, cmp.DeepEqual([]string{"/download/A1/B1/filename"}, downloaded), "not truncated value should be used as is")
+
+	delete(e.BodyValues, "2")
+	val, err = FullBodyValue(c, "A1", &e, "2")
+	assert.NilError(t, err)
+	assert.Check(t, cmp.Equal("Grüße", val))
+
+	_, err = FullBodyValue(c, "A1", &e, "3")
+	assert.Check(t, cmp.Equal(ErrUnsupportedCharset, err))
+	_, err = FullBodyValue(c, "A1", &e, "4")
+	assert.Check(t, cmp.ErrorContains(err, "no blob id"))
+}
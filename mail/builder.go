@@ -0,0 +1,242 @@
+package mail
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+
+	"github.com/foxcpp/go-jmap"
+)
+
+const (
+	textPartID = "text"
+	htmlPartID = "html"
+)
+
+// EmailBuilder constructs Email objects suitable for Email/set create.
+//
+// It produces bodyStructure and bodyValues properties for the common
+// message layout:
+//
+//	multipart/mixed
+//	  multipart/alternative
+//	    text/plain
+//	    multipart/related
+//	      text/html
+//	      inline images
+//	  attachments
+//
+// Containers that would have only one child are omitted.
+//
+// Methods return the builder itself to allow chaining. Errors are reported
+// by Build.
+type EmailBuilder struct {
+	email       Email
+	text        *string
+	html        *string
+	inline      []EmailBodyPart
+	attachments []EmailBodyPart
+	err         error
+}
+
+// NewEmailBuilder creates the builder for a draft Email. Built Email has
+// $draft and $seen keywords set.
+func NewEmailBuilder() *EmailBuilder {
+	return &EmailBuilder{
+		email: Email{
+			Keywords: map[string]bool{
+				KeywordDraft: true,
+				KeywordSeen:  true,
+			},
+		},
+	}
+}
+
+// Mailbox adds the Email to the Mailbox.
+func (b *EmailBuilder) Mailbox(id jmap.ID) *EmailBuilder {
+	if b.email.MailboxIDs == nil {
+		b.email.MailboxIDs = make(map[jmap.ID]bool)
+	}
+	b.email.MailboxIDs[id] = true
+	return b
+}
+
+// Keyword sets the keyword on the Email.
+func (b *EmailBuilder) Keyword(keyword string) *EmailBuilder {
+	b.email.Keywords[keyword] = true
+	return b
+}
+
+// NotDraft removes the $draft keyword, e.g. for messages that are imported
+// as already sent.
+func (b *EmailBuilder) NotDraft() *EmailBuilder {
+	delete(b.email.Keywords, KeywordDraft)
+	return b
+}
+
+func (b *EmailBuilder) From(addrs ...EmailAddress) *EmailBuilder {
+	b.email.From = append(b.email.From, addrs...)
+	return b
+}
+
+func (b *EmailBuilder) Sender(addr EmailAddress) *EmailBuilder {
+	b.email.Sender = []EmailAddress{addr}
+	return b
+}
+
+func (b *EmailBuilder) To(addrs ...EmailAddress) *EmailBuilder {
+	b.email.To = append(b.email.To, addrs...)
+	return b
+}
+
+func (b *EmailBuilder) CC(addrs ...EmailAddress) *EmailBuilder {
+	b.email.CC = append(b.email.CC, addrs...)
+	return b
+}
+
+func (b *EmailBuilder) BCC(addrs ...EmailAddress) *EmailBuilder {
+	b.email.BCC = append(b.email.BCC, addrs...)
+	return b
+}
+
+func (b *EmailBuilder) ReplyTo(addrs ...EmailAddress) *EmailBuilder {
+	b.email.ReplyTo = append(b.email.ReplyTo, addrs...)
+	return b
+}
+
+func (b *EmailBuilder) Subject(subject string) *EmailBuilder {
+	b.email.Subject = subject
+	return b
+}
+
+// SentAt sets the Date header field. If not set, the server will use the
+// time of creation.
+func (b *EmailBuilder) SentAt(date jmap.Date) *EmailBuilder {
+	b.email.SentAt = &date
+	return b
+}
+
+// InReplyTo sets In-Reply-To and References header fields for the reply to
+// the message with the specified Message-IDs.
+func (b *EmailBuilder) InReplyTo(messageIDs []string, references []string) *EmailBuilder {
+	b.email.InReplyTo = messageIDs
+	b.email.References = references
+	return b
+}
+
+// Header adds the header field with the specified name and value (in Text
+// form, see RFC 8621, section 4.1.2.2).
+//
+// Header fields that have dedicated methods (From, Subject, etc.) should
+// not be set using Header.
+func (b *EmailBuilder) Header(name, value string) *EmailBuilder {
+	if !ValidHeaderName(name) {
+		b.setErr(fmt.Errorf("jmap/mail: invalid header field name: %q", name))
+		return b
+	}
+	blob, err := json.Marshal(value)
+	if err != nil {
+		b.setErr(err)
+		return b
+	}
+	if b.email.HeaderProps == nil {
+		b.email.HeaderProps = make(map[string]json.RawMessage)
+	}
+	b.email.HeaderProps[HeaderProperty(name)+":asText"] = blob
+	return b
+}
+
+// TextBody sets the text/plain version of the message body.
+func (b *EmailBuilder) TextBody(text string) *EmailBuilder {
+	b.text = &text
+	return b
+}
+
+// HTMLBody sets the text/html version of the message body.
+func (b *EmailBuilder) HTMLBody(html string) *EmailBuilder {
+	b.html = &html
+	return b
+}
+
+// InlineImage adds the uploaded blob as an inline part that can be
+// referenced from the HTML body as "cid:<cid>".
+func (b *EmailBuilder) InlineImage(blobID jmap.ID, contentType, cid, name string) *EmailBuilder {
+	b.inline = append(b.inline, EmailBodyPart{
+		BlobID:      blobID,
+		Type:        contentType,
+		Name:        name,
+		Disposition: "inline",
+		CID:         cid,
+	})
+	return b
+}
+
+// Attach adds the uploaded blob as an attachment.
+func (b *EmailBuilder) Attach(blobID jmap.ID, contentType, name string) *EmailBuilder {
+	b.attachments = append(b.attachments, EmailBodyPart{
+		BlobID:      blobID,
+		Type:        contentType,
+		Name:        name,
+		Disposition: "attachment",
+	})
+	return b
+}
+
+func (b *EmailBuilder) setErr(err error) {
+	if b.err == nil {
+		b.err = err
+	}
+}
+
+// Build returns the constructed Email.
+func (b *EmailBuilder) Build() (Email, error) {
+	if b.err != nil {
+		return Email{}, b.err
+	}
+	if b.text == nil && b.html == nil {
+		return Email{}, errors.New("jmap/mail: message has no body")
+	}
+	if len(b.inline) != 0 && b.html == nil {
+		return Email{}, errors.New("jmap/mail: inline images require HTML body")
+	}
+
+	// Copy maps so that later calls to the builder do not change the
+	// returned Email and vice versa.
+	e := b.email
+	e.Keywords = maps.Clone(b.email.Keywords)
+	e.MailboxIDs = maps.Clone(b.email.MailboxIDs)
+	e.HeaderProps = maps.Clone(b.email.HeaderProps)
+	e.BodyValues = make(map[string]EmailBodyValue)
+
+	var alternatives []EmailBodyPart
+	if b.text != nil {
+		e.BodyValues[textPartID] = EmailBodyValue{Value: *b.text}
+		alternatives = append(alternatives, EmailBodyPart{PartID: textPartID, Type: "text/plain"})
+	}
+	if b.html != nil {
+		e.BodyValues[htmlPartID] = EmailBodyValue{Value: *b.html}
+		htmlPart := EmailBodyPart{PartID: htmlPartID, Type: "text/html"}
+		if len(b.inline) != 0 {
+			htmlPart = EmailBodyPart{
+				Type:     "multipart/related",
+				SubParts: append([]EmailBodyPart{htmlPart}, b.inline...),
+			}
+		}
+		alternatives = append(alternatives, htmlPart)
+	}
+
+	body := alternatives[0]
+	if len(alternatives) > 1 {
+		body = EmailBodyPart{Type: "multipart/alternative", SubParts: alternatives}
+	}
+	if len(b.attachments) != 0 {
+		body = EmailBodyPart{
+			Type:     "multipart/mixed",
+			SubParts: append([]EmailBodyPart{body}, b.attachments...),
+		}
+	}
+	e.BodyStructure = &body
+
+	return e, nil
+}
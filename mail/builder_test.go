@@ -0,0 +1,81 @@
+package mail
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/foxcpp/go-jmap"
+	"gotest.tools/assert"
+	"gotest.tools/assert/cmp"
+)
+
+func TestEmailBuilder(t *testing.T) {
+	e, err := NewEmailBuilder().
+		Mailbox("drafts").
+		From(EmailAddress{Name: "Joe", Email: "joe@example.com"}).
+		To(EmailAddress{Email: "jane@example.com"}).
+		Subject("Hi").
+		Header("X-Mailer", "go-jmap").
+		TextBody("Hello").
+		HTMLBody(`<p>Hello <img src="cid:logo"></p>`).
+		InlineImage("B1", "image/png", "logo", "logo.png").
+		Attach("B2", "application/pdf", "doc.pdf").
+		Build()
+	assert.NilError(t, err)
+
+	assert.Check(t, cmp.DeepEqual(map[string]bool{KeywordDraft: true, KeywordSeen: true}, e.Keywords))
+	assert.Check(t, cmp.Equal("Hello", e.BodyValues["text"].Value))
+	assert.Check(t, cmp.DeepEqual(json.RawMessage(`"go-jmap"`), e.HeaderProps["header:X-Mailer:asText"]))
+
+	var types []string
+	e.BodyStructure.Walk(func(p *EmailBodyPart, _ int) bool {
+		types = append(types, p.Type)
+		return true
+	})
+	assert.Check(t, cmp.DeepEqual([]string{
+		"multipart/mixed",
+		"multipart/alternative",
+		"text/plain",
+		"multipart/related",
+		"text/html",
+		"image/png",
+		"application/pdf",
+	}, types))
+
+	textBody, htmlBody, attachments := e.BodyStructure.Flatten()
+	assert.Check(t, cmp.DeepEqual([]string{"text"}, partIDs(textBody)))
+	assert.Check(t, cmp.DeepEqual([]string{"html"}, partIDs(htmlBody)))
+	assert.Check(t, cmp.Len(attachments, 2))
+
+	t.Run("text only", func(t *testing.T) {
+		e, err := NewEmailBuilder().TextBody("Hello").Build()
+		assert.NilError(t, err)
+		assert.Check(t, cmp.DeepEqual(&EmailBodyPart{PartID: "text", Type: "text/plain"}, e.BodyStructure))
+	})
+
+	t.Run("reuse", func(t *testing.T) {
+		b := NewEmailBuilder().Mailbox("M1").Header("X-A", "a").TextBody("Hello")
+		first, err := b.Build()
+		assert.NilError(t, err)
+		b.Mailbox("M2").Keyword(KeywordFlagged).Header("X-B", "b")
+		first.Keywords[KeywordAnswered] = true
+
+		second, err := b.Build()
+		assert.NilError(t, err)
+		assert.Check(t, cmp.DeepEqual(map[jmap.ID]bool{"M1": true}, first.MailboxIDs))
+		assert.Check(t, cmp.DeepEqual(map[string]bool{KeywordDraft: true, KeywordSeen: true, KeywordAnswered: true}, first.Keywords))
+		assert.Check(t, cmp.Len(first.HeaderProps, 1))
+		assert.Check(t, cmp.DeepEqual(map[jmap.ID]bool{"M1": true, "M2": true}, second.MailboxIDs))
+		assert.Check(t, cmp.DeepEqual(map[string]bool{KeywordDraft: true, KeywordSeen: true, KeywordFlagged: true}, second.Keywords))
+		assert.Check(t, cmp.Len(second.HeaderProps, 2))
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := NewEmailBuilder().Build()
+		assert.Check(t, err != nil)
+		_, err = NewEmailBuilder().TextBody("a").InlineImage("B1", "image/png", "x", "").Build()
+		assert.Check(t, err != nil)
+		_, err = NewEmailBuilder().TextBody("a").Header("Bad:Name", "x").Build()
+		assert.Check(t, err != nil)
+	})
+}
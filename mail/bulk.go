@@ -0,0 +1,180 @@
+package mail
+
+import (
+	"errors"
+
+	"github.com/foxcpp/go-jmap"
+	"github.com/foxcpp/go-jmap/client"
+)
+
+var ErrNoTrash = errors.New("jmap/mail: there is no Mailbox with the trash role")
+
+// ProgressFunc is called by bulk operations after each processed chunk.
+type ProgressFunc func(done, total int)
+
+// setChunkSize returns the maximum number of objects allowed in a single
+// /set call.
+func setChunkSize(c *client.Client) (int, error) {
+	session, err := c.CurrentSession()
+	if err != nil {
+		return 0, err
+	}
+	return int(session.Limits().MaxObjectsInSet), nil
+}
+
+func setEmails(c *client.Client, args EmailSetArgs) (*EmailSetResponse, error) {
+	respArgs, err := c.Call(mailUsing, "Email/set", args)
+	if err != nil {
+		return nil, err
+	}
+	resp, ok := respArgs.(EmailSetResponse)
+	if !ok {
+		return nil, unexpectedResponse("Email/set", respArgs)
+	}
+	return &resp, nil
+}
+
+// BulkResult contains aggregated results of a bulk operation split into
+// multiple /set calls.
+type BulkResult struct {
+	// Ids of objects that were successfully processed.
+	Done []jmap.ID
+
+	// Errors for objects that failed to be processed.
+	Failed map[jmap.ID]jmap.SetError
+}
+
+func (br *BulkResult) addFailed(errs map[jmap.ID]jmap.SetError) {
+	if len(errs) == 0 {
+		return
+	}
+	if br.Failed == nil {
+		br.Failed = make(map[jmap.ID]jmap.SetError, len(errs))
+	}
+	for id, err := range errs {
+		br.Failed[id] = err
+	}
+}
+
+// DestroyEmails destroys the specified Emails using as many Email/set calls
+// as needed to respect the maxObjectsInSet limit.
+//
+// If a call fails as a whole, the error is returned along with results of
+// previous calls.
+func DestroyEmails(c *client.Client, account jmap.ID, emails []jmap.ID, progress ProgressFunc) (*BulkResult, error) {
+	chunkSize, err := setChunkSize(c)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &BulkResult{}
+	for start := 0; start < len(emails); start += chunkSize {
+		end := start + chunkSize
+		if end > len(emails) {
+			end = len(emails)
+		}
+
+		resp, err := setEmails(c, EmailSetArgs{
+			AccountID: account,
+			Destroy:   emails[start:end],
+		})
+		if err != nil {
+			return res, err
+		}
+		res.Done = append(res.Done, resp.Destroyed...)
+		res.addFailed(resp.NotDestroyed)
+
+		if progress != nil {
+			progress(end, len(emails))
+		}
+	}
+	return res, nil
+}
+
+// UpdateEmails applies patches to Emails using as many Email/set calls as
+// needed to respect the maxObjectsInSet limit.
+//
+// If a call fails as a whole, the error is returned along with results of
+// previous calls.
+func UpdateEmails(c *client.Client, account jmap.ID, patches map[jmap.ID]jmap.PatchObject, progress ProgressFunc) (*BulkResult, error) {
+	chunkSize, err := setChunkSize(c)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &BulkResult{}
+	chunk := make(map[jmap.ID]jmap.PatchObject, chunkSize)
+	done := 0
+	flush := func() error {
+		if len(chunk) == 0 {
+			return nil
+		}
+		resp, err := setEmails(c, EmailSetArgs{
+			AccountID: account,
+			Update:    chunk,
+		})
+		if err != nil {
+			return err
+		}
+		for id := range resp.Updated {
+			res.Done = append(res.Done, id)
+		}
+		res.addFailed(resp.NotUpdated)
+
+		done += len(chunk)
+		if progress != nil {
+			progress(done, len(patches))
+		}
+		chunk = make(map[jmap.ID]jmap.PatchObject, chunkSize)
+		return nil
+	}
+
+	for id, patch := range patches {
+		chunk[id] = patch
+		if len(chunk) == chunkSize {
+			if err := flush(); err != nil {
+				return res, err
+			}
+		}
+	}
+	return res, flush()
+}
+
+// EmptyMailbox destroys all Emails in the Mailbox using as many Email/query
+// and Email/set calls as needed. progress is called after each Email/set
+// call.
+//
+// Emails that also belong to other Mailboxes are destroyed too, use
+// RemoveLabelByQuery to only remove them from the Mailbox.
+//
+// An error is returned if mailbox is empty, since the query would then
+// match Emails in all Mailboxes.
+//
+// The client must have ResponseUnmarshallers enabled.
+func EmptyMailbox(c *client.Client, account, mailbox jmap.ID, progress ProgressFunc) (*BulkResult, error) {
+	if mailbox == "" {
+		return nil, errors.New("jmap/mail: EmptyMailbox called with empty mailbox id")
+	}
+
+	emails, err := queryAllEmails(c, account, EmailFilterCondition{InMailbox: mailbox})
+	if err != nil {
+		return nil, err
+	}
+	return DestroyEmails(c, account, emails, progress)
+}
+
+// EmptyTrash destroys all Emails in the Mailbox with the trash role.
+// ErrNoTrash is returned if there is no such Mailbox.
+//
+// See EmptyMailbox for details.
+func EmptyTrash(c *client.Client, account jmap.ID, progress ProgressFunc) (*BulkResult, error) {
+	mboxes, err := roleMailboxes(c, account, RoleTrash)
+	if err != nil {
+		return nil, err
+	}
+	trash, ok := mboxes[RoleTrash]
+	if !ok {
+		return nil, ErrNoTrash
+	}
+	return EmptyMailbox(c, account, trash, progress)
+}
@@ -0,0 +1,77 @@
+package mail
+
+import (
+	"testing"
+
+	"github.com/foxcpp/go-jmap"
+	"gotest.tools/assert"
+	"gotest.tools/assert/cmp"
+)
+
+func TestEmptyTrash(t *testing.T) {
+	var destroyCalls [][]interface{}
+	c, srv := newTestClient(t, func(name string, args map[string]interface{}) []testResponse {
+		switch name {
+		case "Mailbox/get":
+			return []testResponse{{name, map[string]interface{}{
+				"list": []interface{}{
+					map[string]interface{}{"id": "INBOX", "role": "inbox"},
+					map[string]interface{}{"id": "T", "role": "trash"},
+				},
+			}}}
+		case "Email/query":
+			assert.Check(t, cmp.DeepEqual(map[string]interface{}{"inMailbox": "T"}, args["filter"]))
+			ids := []interface{}{"E1", "E2", "E3"}
+			if args["anchor"] != nil {
+				ids = []interface{}{}
+			}
+			return []testResponse{{name, map[string]interface{}{"queryState": "q1", "ids": ids}}}
+		case "Email/set":
+			destroy := args["destroy"].([]interface{})
+			destroyCalls = append(destroyCalls, destroy)
+			resp := map[string]interface{}{"destroyed": destroy}
+			if destroy[0] == "E3" {
+				resp = map[string]interface{}{
+					"notDestroyed": map[string]interface{}{"E3": map[string]interface{}{"type": "forbidden"}},
+				}
+			}
+			return []testResponse{{name, resp}}
+		}
+		t.Fatalf("unexpected call: %s", name)
+		return nil
+	})
+	defer srv.Close()
+
+	var progress [][2]int
+	res, err := EmptyTrash(c, "A1", func(done, total int) {
+		progress = append(progress, [2]int{done, total})
+	})
+	assert.NilError(t, err)
+	// maxObjectsInSet is 2 for the test server.
+	assert.Check(t, cmp.DeepEqual([][]interface{}{{"E1", "E2"}, {"E3"}}, destroyCalls))
+	assert.Check(t, cmp.DeepEqual([][2]int{{2, 3}, {3, 3}}, progress))
+	assert.Check(t, cmp.DeepEqual([]jmap.ID{"E1", "E2"}, res.Done))
+	assert.Check(t, cmp.Equal(jmap.ErrorCode("forbidden"), res.Failed["E3"].Type))
+
+	t.Run("no trash", func(t *testing.T) {
+		c, srv := newTestClient(t, func(name string, args map[string]interface{}) []testResponse {
+			return []testResponse{{"Mailbox/get", map[string]interface{}{
+				"list": []interface{}{map[string]interface{}{"id": "INBOX", "role": "inbox"}},
+			}}}
+		})
+		defer srv.Close()
+		_, err := EmptyTrash(c, "A1", nil)
+		assert.Equal(t, ErrNoTrash, err)
+	})
+}
+
+func TestEmptyMailboxNoID(t *testing.T) {
+	c, srv := newTestClient(t, func(name string, args map[string]interface{}) []testResponse {
+		t.Fatalf("unexpected call: %s", name)
+		return nil
+	})
+	defer srv.Close()
+
+	_, err := EmptyMailbox(c, "A1", "", nil)
+	assert.Check(t, cmp.ErrorContains(err, "empty mailbox id"))
+}
@@ -0,0 +1,91 @@
+package mail
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/foxcpp/go-jmap/client"
+	"gotest.tools/assert"
+)
+
+type testResponse struct {
+	Name string
+	Args interface{}
+}
+
+// newTestClient starts a server that serves the Session object with the
+// account "A1" and passes each method call to the handler. Responses
+// returned by the handler get the call id of the call.
+func newTestClient(t *testing.T, handler func(name string, args map[string]interface{}) []testResponse) (*client.Client, *httptest.Server) {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+
+	caps := map[string]interface{}{
+		"urn:ietf:params:jmap:mail":             map[string]interface{}{},
+		"urn:ietf:params:jmap:submission":       map[string]interface{}{"maxDelayedSend": 3600},
+		"urn:ietf:params:jmap:vacationresponse": map[string]interface{}{},
+	}
+	mux.HandleFunc("/.well-known/jmap", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{ //nolint:errcheck
+			"capabilities": map[string]interface{}{
+				"urn:ietf:params:jmap:core": map[string]interface{}{
+					"maxCallsInRequest": 16,
+					"maxObjectsInGet":   500,
+					"maxObjectsInSet":   2,
+				},
+				"urn:ietf:params:jmap:mail": map[string]interface{}{},
+			},
+			"accounts": map[string]interface{}{
+				"A1": map[string]interface{}{
+					"name":                "test@example.org",
+					"isPersonal":          true,
+					"accountCapabilities": caps,
+				},
+			},
+			"username":    "test@example.org",
+			"apiUrl":      srv.URL + "/api",
+			"downloadUrl": srv.URL + "/download/{accountId}/{blobId}/{name}?accept={type}",
+			"uploadUrl":   srv.URL + "/upload/{accountId}/",
+			"state":       "1",
+		})
+	})
+	mux.HandleFunc("/api", func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Calls [][3]json.RawMessage `json:"methodCalls"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		resps := [][3]interface{}{}
+		for _, call := range req.Calls {
+			var (
+				name, callID string
+				args         map[string]interface{}
+			)
+			json.Unmarshal(call[0], &name)   //nolint:errcheck
+			json.Unmarshal(call[1], &args)   //nolint:errcheck
+			json.Unmarshal(call[2], &callID) //nolint:errcheck
+			for _, resp := range handler(name, args) {
+				resps = append(resps, [3]interface{}{resp.Name, resp.Args, callID})
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{ //nolint:errcheck
+			"methodResponses": resps,
+			"sessionState":    "1",
+		})
+	})
+
+	c, err := client.NewWithClient(srv.Client(), srv.URL+"/.well-known/jmap", "")
+	assert.NilError(t, err, "NewWithClient")
+	c.Enable(ResponseUnmarshallers)
+	return c, srv
+}
@@ -0,0 +1,90 @@
+package mail
+
+import (
+	"sort"
+
+	"github.com/foxcpp/go-jmap"
+)
+
+// Conversation is a group of Emails belonging to the same Thread, as shown
+// in a conversation view.
+type Conversation struct {
+	ThreadID jmap.ID
+
+	// Emails of the Thread that were passed to GroupByThread, newest first.
+	Emails []*Email
+
+	// The total number of Emails in the Thread, according to Thread/get
+	// data. It can be bigger than len(Emails).
+	Total int
+
+	// The number of Emails in Emails without $seen keyword.
+	Unread int
+}
+
+// Latest returns the newest Email of the Conversation.
+func (c *Conversation) Latest() *Email {
+	if len(c.Emails) == 0 {
+		return nil
+	}
+	return c.Emails[0]
+}
+
+// GroupByThread groups Emails into Conversations.
+//
+// queryIDs are the ids returned by Email/query with collapseThreads=true, each
+// of them determines one Conversation (other ids referring to the same
+// Thread are ignored). threads is the list returned by Thread/get for these
+// Emails and emails should contain at least id, threadId, keywords and
+// receivedAt properties of Emails that should be included into
+// Conversations (e.g. all Emails of the Threads or only the latest ones).
+//
+// Conversations are ordered newest first by the receivedAt of their latest
+// Email, keeping the query order for equal dates. Emails for ids not
+// present in emails are skipped.
+func GroupByThread(queryIDs []jmap.ID, threads []Thread, emails []Email) []Conversation {
+	emailByID := make(map[jmap.ID]*Email, len(emails))
+	byThread := make(map[jmap.ID][]*Email)
+	for i := range emails {
+		e := &emails[i]
+		emailByID[e.ID] = e
+		byThread[e.ThreadID] = append(byThread[e.ThreadID], e)
+	}
+	threadByID := make(map[jmap.ID]*Thread, len(threads))
+	for i := range threads {
+		threadByID[threads[i].ID] = &threads[i]
+	}
+
+	res := make([]Conversation, 0, len(queryIDs))
+	seen := make(map[jmap.ID]bool, len(queryIDs))
+	for _, id := range queryIDs {
+		e, ok := emailByID[id]
+		if !ok || seen[e.ThreadID] {
+			continue
+		}
+		seen[e.ThreadID] = true
+
+		conv := Conversation{
+			ThreadID: e.ThreadID,
+			Emails:   byThread[e.ThreadID],
+		}
+		sort.SliceStable(conv.Emails, func(i, j int) bool {
+			return receivedAt(*conv.Emails[i]).After(receivedAt(*conv.Emails[j]))
+		})
+		for _, e := range conv.Emails {
+			if !e.Keywords[KeywordSeen] {
+				conv.Unread++
+			}
+		}
+		conv.Total = len(conv.Emails)
+		if thread, ok := threadByID[e.ThreadID]; ok && len(thread.EmailIDs) > conv.Total {
+			conv.Total = len(thread.EmailIDs)
+		}
+		res = append(res, conv)
+	}
+
+	sort.SliceStable(res, func(i, j int) bool {
+		return receivedAt(*res[i].Latest()).After(receivedAt(*res[j].Latest()))
+	})
+	return res
+}
@@ -0,0 +1,54 @@
+package mail
+
+import (
+	"testing"
+	"time"
+
+	"github.com/foxcpp/go-jmap"
+	"gotest.tools/assert"
+	"gotest.tools/assert/cmp"
+)
+
+func TestGroupByThread(t *testing.T) {
+	date := func(day int) *jmap.UTCDate {
+		d := jmap.UTCDate(time.Date(2020, 1, day, 0, 0, 0, 0, time.UTC))
+		return &d
+	}
+	emails := []Email{
+		{ID: "E1", ThreadID: "T1", ReceivedAt: date(1), Keywords: map[string]bool{KeywordSeen: true}},
+		{ID: "E2", ThreadID: "T1", ReceivedAt: date(5)},
+		{ID: "E3", ThreadID: "T2", ReceivedAt: date(3)},
+		{ID: "E4", ThreadID: "T2", ReceivedAt: date(7)},
+		{ID: "E5", ThreadID: "T3", ReceivedAt: date(2), Keywords: map[string]bool{KeywordSeen: true}},
+	}
+	threads := []Thread{
+		{ID: "T1", EmailIDs: []jmap.ID{"E1", "E2"}},
+		{ID: "T2", EmailIDs: []jmap.ID{"E3", "E4"}},
+		{ID: "T3", EmailIDs: []jmap.ID{"E0", "E5"}},
+	}
+
+	// E1 is in the same Thread as E2 and is ignored, E9 is unknown.
+	convs := GroupByThread([]jmap.ID{"E2", "E4", "E1", "E5", "E9"}, threads, emails)
+	assert.Assert(t, cmp.Len(convs, 3))
+
+	ids := func(emails []*Email) []jmap.ID {
+		var res []jmap.ID
+		for _, e := range emails {
+			res = append(res, e.ID)
+		}
+		return res
+	}
+
+	assert.Check(t, cmp.Equal(jmap.ID("T2"), convs[0].ThreadID))
+	assert.Check(t, cmp.DeepEqual([]jmap.ID{"E4", "E3"}, ids(convs[0].Emails)))
+	assert.Check(t, cmp.Equal(2, convs[0].Unread))
+	assert.Check(t, cmp.Equal(jmap.ID("E4"), convs[0].Latest().ID))
+
+	assert.Check(t, cmp.Equal(jmap.ID("T1"), convs[1].ThreadID))
+	assert.Check(t, cmp.DeepEqual([]jmap.ID{"E2", "E1"}, ids(convs[1].Emails)))
+	assert.Check(t, cmp.Equal(1, convs[1].Unread))
+
+	assert.Check(t, cmp.Equal(jmap.ID("T3"), convs[2].ThreadID))
+	assert.Check(t, cmp.Equal(0, convs[2].Unread))
+	assert.Check(t, cmp.Equal(2, convs[2].Total), "Total uses Thread data")
+}
@@ -0,0 +1,158 @@
+package mail
+
+import (
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/foxcpp/go-jmap"
+	"github.com/foxcpp/go-jmap/client"
+)
+
+// DedupeProperties is the list of Email properties needed by FindDuplicates.
+var DedupeProperties = []string{"id", "messageId", "blobId", "size", "receivedAt"}
+
+// AccountEmail is an Email along with the id of the account it belongs to.
+type AccountEmail struct {
+	AccountID jmap.ID
+	Email     Email
+}
+
+// DuplicateGroup is a set of Emails considered to be copies of the same
+// message.
+type DuplicateGroup struct {
+	// The key Emails were grouped by.
+	Key string
+
+	// The Emails in the group, at least two.
+	Emails []AccountEmail
+}
+
+// dedupeKey returns the key used to group duplicates.
+//
+// Message-ID alone is not safe to rely on since some software reuses them
+// for different messages, so the message size should also match. Messages
+// without Message-ID are considered duplicates only if they share the blob.
+// Blob ids are unique only within an account, so such messages must also be
+// in the same account.
+func dedupeKey(e AccountEmail) string {
+	if len(e.Email.MessageID) != 0 {
+		return "msgid:" + e.Email.MessageID[0] + ":" + strconv.FormatUint(uint64(e.Email.Size), 10)
+	}
+	if e.Email.BlobID != "" {
+		return "blob:" + string(e.AccountID) + ":" + string(e.Email.BlobID)
+	}
+	return ""
+}
+
+// FindDuplicates groups Emails (possibly from different accounts) that are
+// copies of the same message. Emails should have DedupeProperties fetched.
+//
+// Groups are returned in the order of first appearance of their members in
+// emails.
+func FindDuplicates(emails []AccountEmail) []DuplicateGroup {
+	groupIdx := map[string]int{}
+	var groups []DuplicateGroup
+	for _, e := range emails {
+		key := dedupeKey(e)
+		if key == "" {
+			continue
+		}
+		idx, ok := groupIdx[key]
+		if !ok {
+			idx = len(groups)
+			groupIdx[key] = idx
+			groups = append(groups, DuplicateGroup{Key: key})
+		}
+		groups[idx].Emails = append(groups[idx].Emails, e)
+	}
+
+	res := groups[:0]
+	for _, group := range groups {
+		if len(group.Emails) > 1 {
+			res = append(res, group)
+		}
+	}
+	return res
+}
+
+// KeepOldest is the keep policy for PlanDedupe that keeps the Email with the
+// earliest receivedAt date.
+func KeepOldest(group DuplicateGroup) int {
+	best := 0
+	for i, e := range group.Emails[1:] {
+		if receivedAt(e.Email).Before(receivedAt(group.Emails[best].Email)) {
+			best = i + 1
+		}
+	}
+	return best
+}
+
+func receivedAt(e Email) time.Time {
+	if e.ReceivedAt == nil {
+		return time.Time{}
+	}
+	return time.Time(*e.ReceivedAt)
+}
+
+// DedupePlan describes which Emails to keep and which to destroy.
+type DedupePlan struct {
+	// One Email from each duplicate group that will be kept.
+	Keep []AccountEmail
+
+	// Ids of Emails to destroy, grouped by account.
+	Destroy map[jmap.ID][]jmap.ID
+}
+
+// PlanDedupe creates the plan that keeps exactly one Email from each group
+// and destroys the rest. keep is called to choose the index of the Email to
+// keep, if it is nil, KeepOldest is used.
+func PlanDedupe(groups []DuplicateGroup, keep func(DuplicateGroup) int) DedupePlan {
+	if keep == nil {
+		keep = KeepOldest
+	}
+
+	plan := DedupePlan{Destroy: make(map[jmap.ID][]jmap.ID)}
+	for _, group := range groups {
+		keepIdx := keep(group)
+		for i, e := range group.Emails {
+			if i == keepIdx {
+				plan.Keep = append(plan.Keep, e)
+				continue
+			}
+			plan.Destroy[e.AccountID] = append(plan.Destroy[e.AccountID], e.Email.ID)
+		}
+	}
+	return plan
+}
+
+// Execute destroys Emails according to the plan using chunked Email/set
+// calls. Results are returned per account.
+//
+// Execution stops on first failed call.
+func (p DedupePlan) Execute(c *client.Client, progress ProgressFunc) (map[jmap.ID]*BulkResult, error) {
+	accounts := make([]jmap.ID, 0, len(p.Destroy))
+	total := 0
+	for acc, ids := range p.Destroy {
+		accounts = append(accounts, acc)
+		total += len(ids)
+	}
+	sort.Slice(accounts, func(i, j int) bool { return accounts[i] < accounts[j] })
+
+	results := make(map[jmap.ID]*BulkResult, len(accounts))
+	done := 0
+	for _, acc := range accounts {
+		accProgress := func(accDone, _ int) {
+			if progress != nil {
+				progress(done+accDone, total)
+			}
+		}
+		res, err := DestroyEmails(c, acc, p.Destroy[acc], accProgress)
+		results[acc] = res
+		if err != nil {
+			return results, err
+		}
+		done += len(p.Destroy[acc])
+	}
+	return results, nil
+}
@@ -0,0 +1,41 @@
+package mail
+
+import (
+	"testing"
+	"time"
+
+	"github.com/foxcpp/go-jmap"
+	"gotest.tools/assert"
+	"gotest.tools/assert/cmp"
+)
+
+func TestFindDuplicates(t *testing.T) {
+	day := func(d int) *jmap.UTCDate {
+		date := jmap.UTCDate(time.Date(2019, 1, d, 0, 0, 0, 0, time.UTC))
+		return &date
+	}
+	emails := []AccountEmail{
+		{"A1", Email{ID: "M1", MessageID: []string{"a@example.org"}, Size: 100, ReceivedAt: day(2)}},
+		{"A1", Email{ID: "M2", MessageID: []string{"b@example.org"}, Size: 100, ReceivedAt: day(1)}},
+		{"A2", Email{ID: "M3", MessageID: []string{"a@example.org"}, Size: 100, ReceivedAt: day(1)}},
+		// Same Message-ID, different size - not a duplicate.
+		{"A2", Email{ID: "M4", MessageID: []string{"a@example.org"}, Size: 200, ReceivedAt: day(1)}},
+		{"A1", Email{ID: "M5", BlobID: "B1"}},
+		{"A1", Email{ID: "M6", BlobID: "B1"}},
+		// Same blob id in another account is an unrelated message.
+		{"A2", Email{ID: "M7", BlobID: "B1"}},
+	}
+
+	groups := FindDuplicates(emails)
+	assert.Assert(t, cmp.Len(groups, 2))
+	assert.Check(t, cmp.Len(groups[0].Emails, 2))
+	assert.Check(t, cmp.Len(groups[1].Emails, 2))
+	assert.Check(t, cmp.Equal(jmap.ID("M5"), groups[1].Emails[0].Email.ID))
+
+	plan := PlanDedupe(groups, nil)
+	assert.Check(t, cmp.DeepEqual(map[jmap.ID][]jmap.ID{
+		"A1": {"M1", "M6"},
+	}, plan.Destroy))
+	assert.Assert(t, cmp.Len(plan.Keep, 2))
+	assert.Check(t, cmp.Equal(jmap.ID("M3"), plan.Keep[0].Email.ID))
+}
@@ -0,0 +1,319 @@
+package mail
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/foxcpp/go-jmap"
+	"github.com/foxcpp/go-jmap/client"
+)
+
+// DeliveryUpdate describes the change of delivery status for a single
+// recipient of the EmailSubmission.
+type DeliveryUpdate struct {
+	Submission jmap.ID
+
+	// Recipient address, key of EmailSubmission.DeliveryStatus.
+	Recipient string
+
+	// Previously seen status, zero value if the recipient is reported for
+	// the first time.
+	Previous DeliveryStatus
+
+	Status DeliveryStatus
+}
+
+// Final reports whether the delivery to the recipient is completed, either
+// successfully or not.
+func (u DeliveryUpdate) Final() bool {
+	return u.Status.Delivered == DeliveryYes || u.Status.Delivered == DeliveryNo
+}
+
+// DeliveryWatchOptions contains options for WatchDelivery.
+type DeliveryWatchOptions struct {
+	// How often to check for changes. Defaults to 30 seconds. Not used if
+	// Events is set.
+	Interval time.Duration
+
+	// If not nil, changes are checked only when the EventSource reports
+	// a new EmailSubmission state for the account instead of polling.
+	//
+	// The EventSource should not be used by anything else while the watcher
+	// is running.
+	Events *client.EventSource
+
+	// Clock to use for polling. If nil, client.SystemClock is used.
+	Clock client.Clock
+}
+
+// DeliveryWatcher reports changes of EmailSubmission delivery status. Use
+// WatchDelivery to create it.
+type DeliveryWatcher struct {
+	c       *client.Client
+	account jmap.ID
+	opts    DeliveryWatchOptions
+
+	// nil means all submissions.
+	watched map[jmap.ID]bool
+	state   string
+	status  map[jmap.ID]map[string]DeliveryStatus
+
+	out    chan DeliveryUpdate
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	lck sync.Mutex
+	err error
+}
+
+// WatchDelivery starts watching the delivery status of the specified
+// EmailSubmissions. If submissions is empty, all EmailSubmissions of the
+// account are watched.
+//
+// Current status of each recipient is reported first, then only changes are
+// reported. If specific submissions are watched, the channel returned by C is
+// closed once delivery to all their recipients is final (see
+// DeliveryUpdate.Final) or the submissions are destroyed. It is also closed
+// if Stop is called or an error occurs, Err can be used to distinguish these
+// cases. The watcher is stopped when the client is closed.
+//
+// The client must have ResponseUnmarshallers enabled.
+func WatchDelivery(c *client.Client, account jmap.ID, submissions []jmap.ID, opts DeliveryWatchOptions) *DeliveryWatcher {
+	if opts.Interval == 0 {
+		opts.Interval = 30 * time.Second
+	}
+	opts.Clock = client.ClockOrSystem(opts.Clock)
+
+	w := &DeliveryWatcher{
+		c:       c,
+		account: account,
+		opts:    opts,
+		status:  make(map[jmap.ID]map[string]DeliveryStatus),
+		out:     make(chan DeliveryUpdate),
+	}
+	if len(submissions) != 0 {
+		w.watched = make(map[jmap.ID]bool, len(submissions))
+		for _, id := range submissions {
+			w.watched[id] = true
+		}
+	}
+	w.ctx, w.cancel = context.WithCancel(context.Background())
+	if err := c.RunBackground(w.run, w.Stop); err != nil {
+		w.err = err
+		close(w.out)
+	}
+	return w
+}
+
+// C returns the channel updates are delivered on.
+func (w *DeliveryWatcher) C() <-chan DeliveryUpdate {
+	return w.out
+}
+
+// Err returns the error that caused the watcher to stop, if any.
+//
+// It should be called only after the channel returned by C is closed.
+func (w *DeliveryWatcher) Err() error {
+	w.lck.Lock()
+	defer w.lck.Unlock()
+	return w.err
+}
+
+// Stop stops the watcher. The channel returned by C is closed shortly after.
+func (w *DeliveryWatcher) Stop() {
+	w.cancel()
+}
+
+func (w *DeliveryWatcher) run() {
+	defer close(w.out)
+
+	if err := w.reload(); err != nil {
+		w.fail(err)
+		return
+	}
+
+	for !w.done() {
+		if !w.wait() {
+			return
+		}
+		if err := w.applyChanges(); err != nil {
+			w.fail(err)
+			return
+		}
+	}
+}
+
+func (w *DeliveryWatcher) fail(err error) {
+	if w.ctx.Err() != nil {
+		// Stopped, the error is likely caused by that.
+		return
+	}
+	w.lck.Lock()
+	defer w.lck.Unlock()
+	w.err = err
+}
+
+// done reports whether there is nothing left to watch.
+func (w *DeliveryWatcher) done() bool {
+	if w.watched == nil {
+		return false
+	}
+	for id := range w.watched {
+		status := w.status[id]
+		if len(status) == 0 {
+			return false
+		}
+		for _, ds := range status {
+			if ds.Delivered != DeliveryYes && ds.Delivered != DeliveryNo {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// wait blocks until it is time to check for changes. It returns false if
+// the watcher is stopped.
+func (w *DeliveryWatcher) wait() bool {
+	if w.opts.Events == nil {
+		select {
+		case <-w.opts.Clock.After(w.opts.Interval):
+			return true
+		case <-w.ctx.Done():
+			return false
+		}
+	}
+
+	for {
+		sc, err := w.opts.Events.Next(w.ctx)
+		if err != nil {
+			w.fail(err)
+			return false
+		}
+		if state, ok := sc.Changed[w.account]["EmailSubmission"]; ok && state != w.state {
+			return true
+		}
+	}
+}
+
+func (w *DeliveryWatcher) ids() []jmap.ID {
+	if w.watched == nil {
+		return nil
+	}
+	ids := make([]jmap.ID, 0, len(w.watched))
+	for id := range w.watched {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+func (w *DeliveryWatcher) reload() error {
+	resp, err := w.get(w.ids())
+	if err != nil {
+		return err
+	}
+	w.state = resp.State
+	if w.watched != nil {
+		for _, id := range resp.NotFound {
+			delete(w.watched, id)
+		}
+	}
+	return w.report(resp.List)
+}
+
+func (w *DeliveryWatcher) applyChanges() error {
+	for {
+		respArgs, err := w.c.Call(submissionUsing, "EmailSubmission/changes", EmailSubmissionChangesArgs{
+			AccountID:  w.account,
+			SinceState: w.state,
+		})
+		if err != nil {
+			if methodErr, ok := err.(jmap.MethodErrorArgs); ok && methodErr.Type == jmap.CodeCannotCalculateChanges {
+				return w.reload()
+			}
+			return err
+		}
+		changes, ok := respArgs.(EmailSubmissionChangesResponse)
+		if !ok {
+			return unexpectedResponse("EmailSubmission/changes", respArgs)
+		}
+
+		for _, id := range changes.Destroyed {
+			delete(w.status, id)
+			if w.watched != nil {
+				delete(w.watched, id)
+			}
+		}
+
+		var ids []jmap.ID
+		for _, id := range append(changes.Created, changes.Updated...) {
+			if w.watched == nil || w.watched[id] {
+				ids = append(ids, id)
+			}
+		}
+		if len(ids) != 0 {
+			resp, err := w.get(ids)
+			if err != nil {
+				return err
+			}
+			if err := w.report(resp.List); err != nil {
+				return err
+			}
+		}
+
+		w.state = changes.NewState
+		if !changes.HasMoreChanges {
+			return nil
+		}
+	}
+}
+
+func (w *DeliveryWatcher) get(ids []jmap.ID) (*EmailSubmissionGetResponse, error) {
+	respArgs, err := w.c.Call(submissionUsing, "EmailSubmission/get", EmailSubmissionGetArgs{
+		AccountID:  w.account,
+		IDs:        ids,
+		Properties: []string{"id", "deliveryStatus"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	resp, ok := respArgs.(EmailSubmissionGetResponse)
+	if !ok {
+		return nil, unexpectedResponse("EmailSubmission/get", respArgs)
+	}
+	return &resp, nil
+}
+
+// report sends updates for recipients with changed status.
+func (w *DeliveryWatcher) report(subs []EmailSubmission) error {
+	for _, sub := range subs {
+		prev := w.status[sub.ID]
+		rcpts := make([]string, 0, len(sub.DeliveryStatus))
+		for rcpt := range sub.DeliveryStatus {
+			rcpts = append(rcpts, rcpt)
+		}
+		sort.Strings(rcpts)
+
+		for _, rcpt := range rcpts {
+			status := sub.DeliveryStatus[rcpt]
+			if old, ok := prev[rcpt]; ok && old == status {
+				continue
+			}
+			select {
+			case w.out <- DeliveryUpdate{
+				Submission: sub.ID,
+				Recipient:  rcpt,
+				Previous:   prev[rcpt],
+				Status:     status,
+			}:
+			case <-w.ctx.Done():
+				return w.ctx.Err()
+			}
+		}
+		w.status[sub.ID] = sub.DeliveryStatus
+	}
+	return nil
+}
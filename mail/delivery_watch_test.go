@@ -0,0 +1,88 @@
+package mail
+
+import (
+	"testing"
+	"time"
+
+	"github.com/foxcpp/go-jmap"
+	"github.com/foxcpp/go-jmap/jmaptest"
+	"gotest.tools/assert"
+	"gotest.tools/assert/cmp"
+)
+
+func TestWatchDelivery(t *testing.T) {
+	delivered := map[string]interface{}{
+		"a@example.org": map[string]interface{}{"delivered": "queued", "displayed": "unknown"},
+		"b@example.org": map[string]interface{}{"delivered": "queued", "displayed": "unknown"},
+	}
+	state := "1"
+	c, srv := newTestClient(t, func(name string, args map[string]interface{}) []testResponse {
+		switch name {
+		case "EmailSubmission/get":
+			return []testResponse{{name, map[string]interface{}{
+				"state": state,
+				"list": []interface{}{map[string]interface{}{
+					"id":             "S1",
+					"deliveryStatus": delivered,
+				}},
+			}}}
+		case "EmailSubmission/changes":
+			return []testResponse{{name, map[string]interface{}{
+				"oldState": args["sinceState"],
+				"newState": state,
+				"updated":  []interface{}{"S1"},
+			}}}
+		}
+		t.Fatalf("unexpected call: %s", name)
+		return nil
+	})
+	defer srv.Close()
+
+	clock := jmaptest.NewFakeClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	w := WatchDelivery(c, "A1", []jmap.ID{"S1"}, DeliveryWatchOptions{
+		Interval: time.Minute,
+		Clock:    clock,
+	})
+	defer w.Stop()
+
+	for _, rcpt := range []string{"a@example.org", "b@example.org"} {
+		upd := <-w.C()
+		assert.Check(t, cmp.Equal(jmap.ID("S1"), upd.Submission))
+		assert.Check(t, cmp.Equal(rcpt, upd.Recipient))
+		assert.Check(t, cmp.Equal("", upd.Previous.Delivered))
+		assert.Check(t, cmp.Equal(DeliveryQueued, upd.Status.Delivered))
+		assert.Check(t, !upd.Final())
+	}
+
+	clock.BlockUntil(1)
+	delivered = map[string]interface{}{
+		"a@example.org": map[string]interface{}{"delivered": "yes", "displayed": "unknown"},
+		"b@example.org": map[string]interface{}{"delivered": "queued", "displayed": "unknown"},
+	}
+	state = "2"
+	clock.Advance(time.Minute)
+
+	upd := <-w.C()
+	assert.Check(t, cmp.Equal("a@example.org", upd.Recipient))
+	assert.Check(t, cmp.Equal(DeliveryQueued, upd.Previous.Delivered))
+	assert.Check(t, cmp.Equal(DeliveryYes, upd.Status.Delivered))
+	assert.Check(t, upd.Final())
+
+	clock.BlockUntil(1)
+	delivered = map[string]interface{}{
+		"a@example.org": map[string]interface{}{"delivered": "yes", "displayed": "unknown"},
+		"b@example.org": map[string]interface{}{"delivered": "no", "smtpReply": "550 No such user", "displayed": "unknown"},
+	}
+	state = "3"
+	clock.Advance(time.Minute)
+
+	upd = <-w.C()
+	assert.Check(t, cmp.Equal("b@example.org", upd.Recipient))
+	assert.Check(t, cmp.Equal(DeliveryNo, upd.Status.Delivered))
+	assert.Check(t, cmp.Equal("550 No such user", upd.Status.SMTPReply))
+
+	// All recipients are final, the watcher stops by itself.
+	_, ok := <-w.C()
+	assert.Check(t, !ok)
+	assert.NilError(t, w.Err())
+}
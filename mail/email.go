@@ -0,0 +1,508 @@
+package mail
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/foxcpp/go-jmap"
+)
+
+// EmailAddress is the parsed representation of a single mailbox in address
+// header fields.
+type EmailAddress struct {
+	// The display-name of the mailbox. If this is a quoted-string, it is
+	// decoded. If there is no display-name but there is a comment immediately
+	// following the addr-spec, this is used. Otherwise empty.
+	Name string `json:"name,omitempty"`
+
+	// The addr-spec of the mailbox.
+	Email string `json:"email"`
+}
+
+// EmailAddressGroup is the parsed representation of a group in address
+// header fields.
+type EmailAddressGroup struct {
+	// The display-name of the group or empty if the addresses are not part of
+	// a group.
+	Name string `json:"name,omitempty"`
+
+	// The mailbox values that belong to this group.
+	Addresses []EmailAddress `json:"addresses"`
+}
+
+// EmailHeader is a single raw header field of the message or body part.
+type EmailHeader struct {
+	// The header field name as defined in RFC 5322, with the same
+	// capitalization that it has in the message.
+	Name string `json:"name"`
+
+	// The header field value as defined in RFC 5322, in Raw form.
+	Value string `json:"value"`
+}
+
+// EmailBodyPart represents a single MIME entity of the message.
+//
+// See RFC 8621, section 4.1.4 for details.
+type EmailBodyPart struct {
+	// Identifies this part uniquely within the Email. This is scoped to the
+	// emailId and has no meaning outside of the JMAP Email object
+	// representation. Empty if and only if this part is of type multipart/*.
+	PartID string `json:"partId,omitempty"`
+
+	// The id representing the raw octets of the contents of the part, after
+	// decoding any known Content-Transfer-Encoding. Empty if and only if this
+	// part is of type multipart/*.
+	BlobID jmap.ID `json:"blobId,omitempty"`
+
+	// The size, in octets, of the raw data after content transfer decoding.
+	Size jmap.UnsignedInt `json:"size,omitempty"`
+
+	// List of all header fields in the part, in the order they appear in the
+	// message.
+	Headers []EmailHeader `json:"headers,omitempty"`
+
+	// Decoded filename parameter of the Content-Disposition header field or
+	// name parameter of the Content-Type header field.
+	Name string `json:"name,omitempty"`
+
+	// The value of the Content-Type header field of the part without
+	// parameters, lowercased.
+	Type string `json:"type,omitempty"`
+
+	// The value of the charset parameter of the Content-Type header field.
+	Charset string `json:"charset,omitempty"`
+
+	// The value of the Content-Disposition header field of the part without
+	// parameters, lowercased.
+	Disposition string `json:"disposition,omitempty"`
+
+	// The id from the Content-ID header field of the part, with angle
+	// brackets removed.
+	CID string `json:"cid,omitempty"`
+
+	// The list of language tags from the Content-Language header field.
+	Language []string `json:"language,omitempty"`
+
+	// The URI from the Content-Location header field.
+	Location string `json:"location,omitempty"`
+
+	// The body parts contained in this part, if it is of type multipart/*.
+	SubParts []EmailBodyPart `json:"subParts,omitempty"`
+}
+
+// EmailBodyValue contains the decoded text of a text/* body part.
+type EmailBodyValue struct {
+	// The value of the body part after decoding Content-Transfer-Encoding and
+	// the Content-Type charset, if both known to the server.
+	Value string `json:"value"`
+
+	// This is true if malformed sections were found while decoding the charset,
+	// or the charset was unknown, or the content-transfer-encoding was unknown.
+	IsEncodingProblem bool `json:"isEncodingProblem,omitempty"`
+
+	// This is true if the value has been truncated.
+	IsTruncated bool `json:"isTruncated,omitempty"`
+}
+
+// Email object is a representation of a message (RFC 5322), which allows
+// clients to avoid the complexities of MIME parsing, transfer encoding, and
+// character encoding.
+//
+// All fields are optional since Email/get may be asked to return only some
+// properties and Email/set create requires only some of them to be present.
+//
+// See RFC 8621, section 4.1 for details.
+type Email struct {
+	// The id of the Email object.
+	ID jmap.ID `json:"id,omitempty"`
+
+	// The id representing the raw octets of the message (RFC 5322) for this
+	// Email.
+	BlobID jmap.ID `json:"blobId,omitempty"`
+
+	// The id of the Thread to which this Email belongs.
+	ThreadID jmap.ID `json:"threadId,omitempty"`
+
+	// The set of Mailbox ids this Email belongs to. An Email in the mail store
+	// MUST belong to one or more Mailboxes at all times (until it is
+	// destroyed). The set is represented as an object, with each key being a
+	// Mailbox id. The value for each key in the object MUST be true.
+	MailboxIDs map[jmap.ID]bool `json:"mailboxIds,omitempty"`
+
+	// A set of keywords that apply to the Email. The set is represented as an
+	// object, with the keys being the keywords. The value for each key in the
+	// object MUST be true.
+	Keywords map[string]bool `json:"keywords,omitempty"`
+
+	// The size, in octets, of the raw data for the message (RFC 5322).
+	Size jmap.UnsignedInt `json:"size,omitempty"`
+
+	// The date the Email was received by the message store.
+	ReceivedAt *jmap.UTCDate `json:"receivedAt,omitempty"`
+
+	// List of all header fields of the message, in the order they appear in
+	// the message.
+	Headers []EmailHeader `json:"headers,omitempty"`
+
+	// The value is identical to the value of header:Message-ID:asMessageIds.
+	MessageID []string `json:"messageId,omitempty"`
+
+	// The value is identical to the value of header:In-Reply-To:asMessageIds.
+	InReplyTo []string `json:"inReplyTo,omitempty"`
+
+	// The value is identical to the value of header:References:asMessageIds.
+	References []string `json:"references,omitempty"`
+
+	// The value is identical to the value of header:Sender:asAddresses.
+	Sender []EmailAddress `json:"sender,omitempty"`
+
+	// The value is identical to the value of header:From:asAddresses.
+	From []EmailAddress `json:"from,omitempty"`
+
+	// The value is identical to the value of header:To:asAddresses.
+	To []EmailAddress `json:"to,omitempty"`
+
+	// The value is identical to the value of header:Cc:asAddresses.
+	CC []EmailAddress `json:"cc,omitempty"`
+
+	// The value is identical to the value of header:Bcc:asAddresses.
+	BCC []EmailAddress `json:"bcc,omitempty"`
+
+	// The value is identical to the value of header:Reply-To:asAddresses.
+	ReplyTo []EmailAddress `json:"replyTo,omitempty"`
+
+	// The value is identical to the value of header:Subject:asText.
+	Subject string `json:"subject,omitempty"`
+
+	// The value is identical to the value of header:Date:asDate.
+	SentAt *jmap.Date `json:"sentAt,omitempty"`
+
+	// Values of header:{header-field-name} properties in all their forms,
+	// keyed by full property name (e.g. "header:List-Id:asText").
+	//
+	// Values are kept undecoded since their type depends on the requested
+	// form.
+	HeaderProps map[string]json.RawMessage `json:"-"`
+
+	// The full MIME structure of the message body.
+	BodyStructure *EmailBodyPart `json:"bodyStructure,omitempty"`
+
+	// Map of partId to decoded text of text/* body parts.
+	BodyValues map[string]EmailBodyValue `json:"bodyValues,omitempty"`
+
+	// A list of text/plain, text/html, image/*, audio/*, and/or video/* parts
+	// to display (sequentially) as the message body, with a preference for
+	// text/plain when alternative versions are available.
+	TextBody []EmailBodyPart `json:"textBody,omitempty"`
+
+	// A list of text/plain, text/html, image/*, audio/*, and/or video/* parts
+	// to display (sequentially) as the message body, with a preference for
+	// text/html when alternative versions are available.
+	HTMLBody []EmailBodyPart `json:"htmlBody,omitempty"`
+
+	// A list of all parts in bodyStructure, traversing depth-first, which
+	// satisfy either of the following conditions:
+	// - not of type multipart/* and not included in textBody or htmlBody
+	// - of type image/*, audio/*, or video/* and not in both textBody and
+	//   htmlBody
+	Attachments []EmailBodyPart `json:"attachments,omitempty"`
+
+	// This is true if there are one or more parts in the message that a
+	// client UI should offer as downloadable.
+	HasAttachment bool `json:"hasAttachment,omitempty"`
+
+	// A plaintext fragment of the message body. This is intended to be shown
+	// as a preview line when listing messages in the mail store and may be
+	// truncated when shown.
+	Preview string `json:"preview,omitempty"`
+
+	// The S/MIME signature status of the Email as of the time it was
+	// fetched, one of SMIME* values. Only returned if explicitly requested
+	// and the urn:ietf:params:jmap:smimeverify capability is used.
+	SMIMEStatus string `json:"smimeStatus,omitempty"`
+
+	// The S/MIME signature status of the Email as of the time it was
+	// delivered.
+	SMIMEStatusAtDelivery string `json:"smimeStatusAtDelivery,omitempty"`
+
+	// Human-readable descriptions of problems found during signature
+	// verification, set for SMIMESignedFailed and
+	// SMIMEEncryptedSignedFailed statuses.
+	SMIMEErrors []string `json:"smimeErrors,omitempty"`
+
+	// The time the signature was last verified.
+	SMIMEVerifiedAt *jmap.UTCDate `json:"smimeVerifiedAt,omitempty"`
+}
+
+// Values of Email.SMIMEStatus and Email.SMIMEStatusAtDelivery, as defined in
+// RFC 9219.
+const (
+	// The message is not S/MIME signed or the status can't be determined.
+	SMIMEUnknown = "unknown"
+
+	// The message is signed, but the signature was not verified.
+	SMIMESigned = "signed"
+
+	// The signature was successfully verified.
+	SMIMESignedVerified = "signed/verified"
+
+	// The signature verification failed, see Email.SMIMEErrors for
+	// details.
+	SMIMESignedFailed = "signed/failed"
+
+	// Same as SMIMESignedVerified and SMIMESignedFailed, but the message was
+	// also encrypted.
+	SMIMEEncryptedSignedVerified = "encrypted+signed/verified"
+	SMIMEEncryptedSignedFailed   = "encrypted+signed/failed"
+)
+
+// SMIMEProperties lists Email properties defined by RFC 9219 that are
+// returned only if explicitly requested.
+var SMIMEProperties = []string{"smimeStatus", "smimeStatusAtDelivery", "smimeErrors", "smimeVerifiedAt"}
+
+// IsSMIMESigned returns whether the message is S/MIME signed according to
+// SMIMEStatus, regardless of the verification result.
+func (e *Email) IsSMIMESigned() bool {
+	switch e.SMIMEStatus {
+	case SMIMESigned, SMIMESignedVerified, SMIMESignedFailed,
+		SMIMEEncryptedSignedVerified, SMIMEEncryptedSignedFailed:
+		return true
+	}
+	return false
+}
+
+// IsSMIMEVerified returns whether the S/MIME signature of the message was
+// successfully verified according to SMIMEStatus.
+func (e *Email) IsSMIMEVerified() bool {
+	return e.SMIMEStatus == SMIMESignedVerified || e.SMIMEStatus == SMIMEEncryptedSignedVerified
+}
+
+type email Email
+
+// HeaderPropPrefix is the common prefix of all header:{header-field-name}
+// properties.
+const HeaderPropPrefix = "header:"
+
+func (e Email) MarshalJSON() ([]byte, error) {
+	blob, err := json.Marshal(email(e))
+	if err != nil || len(e.HeaderProps) == 0 {
+		return blob, err
+	}
+
+	props := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(blob, &props); err != nil {
+		return nil, err
+	}
+	for k, v := range e.HeaderProps {
+		props[k] = v
+	}
+	return json.Marshal(props)
+}
+
+func (e *Email) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, (*email)(e)); err != nil {
+		return err
+	}
+
+	var err error
+	e.HeaderProps, err = headerProps(data)
+	return err
+}
+
+// headerProps extracts header:{header-field-name} properties from the
+// serialized Email object.
+func headerProps(data []byte) (map[string]json.RawMessage, error) {
+	var props map[string]json.RawMessage
+	if err := json.Unmarshal(data, &props); err != nil {
+		return nil, err
+	}
+	var res map[string]json.RawMessage
+	for k, v := range props {
+		if !strings.HasPrefix(k, HeaderPropPrefix) {
+			continue
+		}
+		if res == nil {
+			res = make(map[string]json.RawMessage)
+		}
+		res[k] = v
+	}
+	return res, nil
+}
+
+// EmailGetArgs contains arguments for Email/get method call.
+//
+// See RFC 8621, section 4.2 for details.
+type EmailGetArgs struct {
+	// The id of the account to use.
+	AccountID jmap.ID `json:"accountId"`
+
+	// The ids of the Email objects to return. If nil, then all records of the
+	// data type are returned, if this is supported for that data type and the
+	// number of records does not exceed the maxObjectsInGet limit.
+	IDs []jmap.ID `json:"ids"`
+
+	// If not nil, only the properties listed in the array are returned for
+	// each Email object. The id property is always returned.
+	Properties []string `json:"properties"`
+
+	// A list of properties to fetch for each EmailBodyPart returned. If
+	// omitted, this defaults to the list of all EmailBodyPart properties
+	// except headers and subParts.
+	BodyProperties []string `json:"bodyProperties,omitempty"`
+
+	// If true, the bodyValues property includes any text/* part in the
+	// textBody property.
+	FetchTextBodyValues bool `json:"fetchTextBodyValues,omitempty"`
+
+	// If true, the bodyValues property includes any text/* part in the
+	// htmlBody property.
+	FetchHTMLBodyValues bool `json:"fetchHTMLBodyValues,omitempty"`
+
+	// If true, the bodyValues property includes any text/* part in the
+	// bodyStructure property.
+	FetchAllBodyValues bool `json:"fetchAllBodyValues,omitempty"`
+
+	// If greater than zero, the value property of any EmailBodyValue object
+	// returned in bodyValues MUST be truncated if necessary so it does not
+	// exceed this number of octets in size.
+	MaxBodyValueBytes jmap.UnsignedInt `json:"maxBodyValueBytes,omitempty"`
+}
+
+// EmailGetResponse contains results of Email/get method call.
+type EmailGetResponse struct {
+	// The id of the account used for the call.
+	AccountID jmap.ID `json:"accountId"`
+
+	// A string representing the state on the server for all the data of this
+	// type in the account.
+	State string `json:"state"`
+
+	// An array of the Email objects requested.
+	List []Email `json:"list"`
+
+	// This array contains the ids passed to the method for records that do
+	// not exist.
+	NotFound []jmap.ID `json:"notFound"`
+}
+
+// EmailSetArgs contains arguments for Email/set method call.
+//
+// See RFC 8621, section 4.6 for details.
+type EmailSetArgs struct {
+	// The id of the account to use.
+	AccountID jmap.ID `json:"accountId"`
+
+	// If not empty, the method will be aborted and a stateMismatch error
+	// returned if the current state does not match this string.
+	IfInState string `json:"ifInState,omitempty"`
+
+	// A map of creation id (a temporary id set by the client) to Email
+	// objects.
+	Create map[jmap.ID]Email `json:"create,omitempty"`
+
+	// A map of id to a patch object to apply to the current Email object with
+	// that id.
+	Update map[jmap.ID]jmap.PatchObject `json:"update,omitempty"`
+
+	// A list of ids for Email objects to permanently delete.
+	Destroy []jmap.ID `json:"destroy,omitempty"`
+}
+
+// EmailSetResponse contains results of Email/set method call.
+type EmailSetResponse struct {
+	// The id of the account used for the call.
+	AccountID jmap.ID `json:"accountId"`
+
+	// The state string that would have been returned by Email/get before
+	// making the requested changes, or empty string if the server doesn't
+	// know what the previous state string was.
+	OldState string `json:"oldState"`
+
+	// The state string that will now be returned by Email/get.
+	NewState string `json:"newState"`
+
+	// A map of the creation id to an object containing any properties of the
+	// created Email object that were not sent by the client.
+	Created map[jmap.ID]Email `json:"created"`
+
+	// The keys in this map are the ids of all Emails that were successfully
+	// updated. The value for each id is an Email object containing any
+	// property that changed in a way not explicitly requested by the patch,
+	// or nil if none.
+	Updated map[jmap.ID]*Email `json:"updated"`
+
+	// A list of Email ids for records that were successfully destroyed.
+	Destroyed []jmap.ID `json:"destroyed"`
+
+	// A map of creation id to a SetError object for each record that failed
+	// to be created.
+	NotCreated map[jmap.ID]jmap.SetError `json:"notCreated"`
+
+	// A map of Email id to a SetError object for each record that failed to
+	// be updated.
+	NotUpdated map[jmap.ID]jmap.SetError `json:"notUpdated"`
+
+	// A map of Email id to a SetError object for each record that failed to
+	// be destroyed.
+	NotDestroyed map[jmap.ID]jmap.SetError `json:"notDestroyed"`
+}
+
+// EmailChangesArgs contains arguments for Email/changes method call.
+type EmailChangesArgs struct {
+	// The id of the account to use.
+	AccountID jmap.ID `json:"accountId"`
+
+	// The current state of the client, as returned by Email/get.
+	SinceState string `json:"sinceState"`
+
+	// The maximum number of ids to return in the response. Zero means no
+	// limit.
+	MaxChanges jmap.UnsignedInt `json:"maxChanges,omitempty"`
+}
+
+// EmailChangesResponse contains results of Email/changes method call.
+type EmailChangesResponse struct {
+	// The id of the account used for the call.
+	AccountID jmap.ID `json:"accountId"`
+
+	// This is the sinceState argument echoed back.
+	OldState string `json:"oldState"`
+
+	// This is the state the client will be in after applying the set of
+	// changes to the old state.
+	NewState string `json:"newState"`
+
+	// If true, the client may call Email/changes again with the NewState
+	// returned to get further updates.
+	HasMoreChanges bool `json:"hasMoreChanges"`
+
+	// An array of ids for records that have been created since the old
+	// state.
+	Created []jmap.ID `json:"created"`
+
+	// An array of ids for records that have been updated since the old
+	// state.
+	Updated []jmap.ID `json:"updated"`
+
+	// An array of ids for records that have been destroyed since the old
+	// state.
+	Destroyed []jmap.ID `json:"destroyed"`
+}
+
+func unmarshalEmailGetResponse(args json.RawMessage) (interface{}, error) {
+	resp := EmailGetResponse{}
+	err := json.Unmarshal(args, &resp)
+	return resp, err
+}
+
+func unmarshalEmailSetResponse(args json.RawMessage) (interface{}, error) {
+	resp := EmailSetResponse{}
+	err := json.Unmarshal(args, &resp)
+	return resp, err
+}
+
+func unmarshalEmailChangesResponse(args json.RawMessage) (interface{}, error) {
+	resp := EmailChangesResponse{}
+	err := json.Unmarshal(args, &resp)
+	return resp, err
+}
@@ -0,0 +1,84 @@
+package mail
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/foxcpp/go-jmap"
+)
+
+// EmailCopy describes a single Email to copy using Email/copy.
+type EmailCopy struct {
+	// The id of the Email in the from account.
+	ID jmap.ID `json:"id"`
+
+	// The ids of the Mailboxes in the target account to assign the copy to.
+	MailboxIDs map[jmap.ID]bool `json:"mailboxIds"`
+
+	// Keywords to set on the copy. If nil, keywords of the original are
+	// used.
+	Keywords map[string]bool `json:"keywords,omitempty"`
+
+	// The receivedAt date to set on the copy. If nil, the value of the
+	// original is used.
+	ReceivedAt *jmap.UTCDate `json:"receivedAt,omitempty"`
+}
+
+// EmailCopyArgs contains arguments for Email/copy method call.
+//
+// The meaning of fields is the same as for jmap.CopyArgs.
+//
+// See RFC 8621, section 4.7 for details.
+type EmailCopyArgs struct {
+	FromAccountID            jmap.ID               `json:"fromAccountId"`
+	IfFromInState            string                `json:"ifFromInState,omitempty"`
+	AccountID                jmap.ID               `json:"accountId"`
+	IfInState                string                `json:"ifInState,omitempty"`
+	Create                   map[jmap.ID]EmailCopy `json:"create"`
+	OnSuccessDestroyOriginal bool                  `json:"onSuccessDestroyOriginal,omitempty"`
+	DestroyFromIfInState     string                `json:"destroyFromIfInState,omitempty"`
+}
+
+// NewEmailMove returns Email/copy arguments that move emails from one
+// account to the specified Mailbox in another account, destroying the
+// originals on success.
+//
+// Creation ids are "c0", "c1", ..., in the order of emails.
+func NewEmailMove(fromAccount, toAccount jmap.ID, emails []jmap.ID, toMailbox jmap.ID) EmailCopyArgs {
+	args := EmailCopyArgs{
+		FromAccountID:            fromAccount,
+		AccountID:                toAccount,
+		Create:                   make(map[jmap.ID]EmailCopy, len(emails)),
+		OnSuccessDestroyOriginal: true,
+	}
+	for i, id := range emails {
+		args.Create[jmap.ID("c"+strconv.Itoa(i))] = EmailCopy{
+			ID:         id,
+			MailboxIDs: map[jmap.ID]bool{toMailbox: true},
+		}
+	}
+	return args
+}
+
+// EmailCopyResponse contains results of Email/copy method call.
+//
+// If OnSuccessDestroyOriginal was set, the response is followed by an
+// Email/set response for the implicit call that destroys originals.
+type EmailCopyResponse struct {
+	FromAccountID jmap.ID `json:"fromAccountId"`
+	AccountID     jmap.ID `json:"accountId"`
+	OldState      string  `json:"oldState"`
+	NewState      string  `json:"newState"`
+
+	// A map of the creation id to an object containing the id, blobId,
+	// threadId and size properties of the copied Email.
+	Created map[jmap.ID]Email `json:"created"`
+
+	NotCreated map[jmap.ID]jmap.SetError `json:"notCreated"`
+}
+
+func unmarshalEmailCopyResponse(args json.RawMessage) (interface{}, error) {
+	resp := EmailCopyResponse{}
+	err := json.Unmarshal(args, &resp)
+	return resp, err
+}
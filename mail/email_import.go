@@ -0,0 +1,68 @@
+package mail
+
+import (
+	"encoding/json"
+
+	"github.com/foxcpp/go-jmap"
+)
+
+// EmailImport describes a single message to import using Email/import.
+//
+// See RFC 8621, section 4.8 for details.
+type EmailImport struct {
+	// The id of the blob containing the raw message (RFC 5322).
+	BlobID jmap.ID `json:"blobId"`
+
+	// The ids of the Mailboxes to assign this Email to. At least one Mailbox
+	// MUST be given.
+	MailboxIDs map[jmap.ID]bool `json:"mailboxIds"`
+
+	// The keywords to apply to the Email.
+	Keywords map[string]bool `json:"keywords,omitempty"`
+
+	// The receivedAt date to set on the Email. If nil, the time of the import
+	// is used.
+	ReceivedAt *jmap.UTCDate `json:"receivedAt,omitempty"`
+}
+
+// EmailImportArgs contains arguments for Email/import method call.
+type EmailImportArgs struct {
+	// The id of the account to use.
+	AccountID jmap.ID `json:"accountId"`
+
+	// If not empty, the method will be aborted and a stateMismatch error
+	// returned if the current Email state does not match this string.
+	IfInState string `json:"ifInState,omitempty"`
+
+	// A map of creation id (client specified) to EmailImport objects.
+	Emails map[jmap.ID]EmailImport `json:"emails"`
+}
+
+// EmailImportResponse contains results of Email/import method call.
+type EmailImportResponse struct {
+	// The id of the account used for the call.
+	AccountID jmap.ID `json:"accountId"`
+
+	// The state string that would have been returned by Email/get on this
+	// account before making the requested changes.
+	OldState string `json:"oldState"`
+
+	// The state string that will now be returned by Email/get on this
+	// account.
+	NewState string `json:"newState"`
+
+	// A map of the creation id to an object containing the id, blobId,
+	// threadId, and size properties for each successfully imported Email.
+	Created map[jmap.ID]Email `json:"created"`
+
+	// A map of the creation id to a SetError object for each Email that
+	// failed to be created. If the message is a duplicate of an existing one,
+	// the server may return the alreadyExists error with ExistingID set.
+	NotCreated map[jmap.ID]jmap.SetError `json:"notCreated"`
+}
+
+func unmarshalEmailImportResponse(args json.RawMessage) (interface{}, error) {
+	resp := EmailImportResponse{}
+	err := json.Unmarshal(args, &resp)
+	return resp, err
+}
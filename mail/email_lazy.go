@@ -0,0 +1,137 @@
+package mail
+
+import (
+	"encoding/json"
+
+	"github.com/foxcpp/go-jmap"
+)
+
+// LazyEmail is the Email with headers and bodyValues properties kept
+// undecoded until they are accessed using Headers and BodyValues methods.
+//
+// It avoids decoding (and allocating the decoded values for) these
+// properties in bulk Email/get calls where they are needed only for some of
+// the Emails.
+//
+// Other properties are decoded immediately and are available via the
+// embedded Email. Email.Headers and Email.BodyValues are nil until the
+// corresponding method is called.
+//
+// LazyEmail methods are not safe for concurrent use.
+type LazyEmail struct {
+	Email
+
+	rawHeaders    json.RawMessage
+	rawBodyValues json.RawMessage
+}
+
+// lazyEmail overrides fields of email that are decoded lazily, fields of
+// the outer struct take priority over the embedded ones.
+type lazyEmail struct {
+	email
+
+	Headers    json.RawMessage `json:"headers,omitempty"`
+	BodyValues json.RawMessage `json:"bodyValues,omitempty"`
+}
+
+func (e *LazyEmail) UnmarshalJSON(data []byte) error {
+	var lazy lazyEmail
+	if err := json.Unmarshal(data, &lazy); err != nil {
+		return err
+	}
+	props, err := headerProps(data)
+	if err != nil {
+		return err
+	}
+
+	e.Email = Email(lazy.email)
+	e.Email.HeaderProps = props
+	e.rawHeaders = lazy.Headers
+	e.rawBodyValues = lazy.BodyValues
+	return nil
+}
+
+func (e LazyEmail) MarshalJSON() ([]byte, error) {
+	if _, err := e.Headers(); err != nil {
+		return nil, err
+	}
+	if _, err := e.BodyValues(); err != nil {
+		return nil, err
+	}
+	return e.Email.MarshalJSON()
+}
+
+// Headers decodes the headers property on the first call and returns it.
+// The result is also stored in Email.Headers.
+func (e *LazyEmail) Headers() ([]EmailHeader, error) {
+	if e.rawHeaders != nil {
+		var headers []EmailHeader
+		if err := json.Unmarshal(e.rawHeaders, &headers); err != nil {
+			return nil, err
+		}
+		e.Email.Headers = headers
+		e.rawHeaders = nil
+	}
+	return e.Email.Headers, nil
+}
+
+// BodyValues decodes the bodyValues property on the first call and returns
+// it. The result is also stored in Email.BodyValues.
+func (e *LazyEmail) BodyValues() (map[string]EmailBodyValue, error) {
+	if e.rawBodyValues != nil {
+		var values map[string]EmailBodyValue
+		if err := json.Unmarshal(e.rawBodyValues, &values); err != nil {
+			return nil, err
+		}
+		e.Email.BodyValues = values
+		e.rawBodyValues = nil
+	}
+	return e.Email.BodyValues, nil
+}
+
+// Decode decodes all lazily decoded properties and returns the complete
+// Email.
+func (e *LazyEmail) Decode() (*Email, error) {
+	if _, err := e.Headers(); err != nil {
+		return nil, err
+	}
+	if _, err := e.BodyValues(); err != nil {
+		return nil, err
+	}
+	return &e.Email, nil
+}
+
+// LazyEmailGetResponse is the EmailGetResponse with Emails decoded as
+// LazyEmail.
+type LazyEmailGetResponse struct {
+	// The id of the account used for the call.
+	AccountID jmap.ID `json:"accountId"`
+
+	// A string representing the state on the server for all the data of this
+	// type in the account.
+	State string `json:"state"`
+
+	// An array of the Email objects requested.
+	List []LazyEmail `json:"list"`
+
+	// This array contains the ids passed to the method for records that do
+	// not exist.
+	NotFound []jmap.ID `json:"notFound"`
+}
+
+// LazyResponseUnmarshallers makes the client decode Email/get responses into
+// LazyEmailGetResponse. Pass it to client.Enable after
+// ResponseUnmarshallers to override the default decoding.
+//
+// Helpers in this package expect EmailGetResponse, so lazy decoding should
+// be enabled on a separate client (see client.Client.Clone) used only for
+// bulk Email/get calls.
+var LazyResponseUnmarshallers = map[string]jmap.FuncArgsUnmarshal{
+	"Email/get": unmarshalLazyEmailGetResponse,
+}
+
+func unmarshalLazyEmailGetResponse(args json.RawMessage) (interface{}, error) {
+	resp := LazyEmailGetResponse{}
+	err := json.Unmarshal(args, &resp)
+	return resp, err
+}
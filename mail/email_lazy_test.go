@@ -0,0 +1,51 @@
+package mail
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/foxcpp/go-jmap"
+	"gotest.tools/assert"
+	"gotest.tools/assert/cmp"
+)
+
+func TestLazyEmail(t *testing.T) {
+	const blob = `{
+		"id": "E1",
+		"subject": "Hello",
+		"headers": [{"name": "Subject", "value": " Hello"}],
+		"header:List-Id:asText": "list",
+		"bodyValues": {"1": {"value": "Body", "isTruncated": true}}
+	}`
+
+	resp, err := unmarshalLazyEmailGetResponse(json.RawMessage(`{"accountId":"A1","state":"1","list":[` + blob + `]}`))
+	assert.NilError(t, err)
+	list := resp.(LazyEmailGetResponse).List
+	assert.Equal(t, 1, len(list))
+	e := &list[0]
+
+	assert.Check(t, cmp.Equal(jmap.ID("E1"), e.ID))
+	assert.Check(t, cmp.Equal("Hello", e.Subject))
+	assert.Check(t, cmp.Equal(`"list"`, string(e.HeaderProps["header:List-Id:asText"])))
+	assert.Check(t, e.Email.Headers == nil)
+	assert.Check(t, e.Email.BodyValues == nil)
+
+	vals, err := e.BodyValues()
+	assert.NilError(t, err)
+	assert.Check(t, cmp.DeepEqual(map[string]EmailBodyValue{"1": {Value: "Body", IsTruncated: true}}, vals))
+	assert.Check(t, e.Email.Headers == nil, "headers should not be decoded yet")
+
+	var full Email
+	assert.NilError(t, json.Unmarshal([]byte(blob), &full))
+	decoded, err := e.Decode()
+	assert.NilError(t, err)
+	assert.Check(t, cmp.DeepEqual(&full, decoded))
+
+	var lazy LazyEmail
+	assert.NilError(t, json.Unmarshal([]byte(blob), &lazy))
+	out, err := json.Marshal(lazy)
+	assert.NilError(t, err)
+	fullOut, err := json.Marshal(full)
+	assert.NilError(t, err)
+	assert.Check(t, cmp.Equal(string(fullOut), string(out)))
+}
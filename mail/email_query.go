@@ -0,0 +1,295 @@
+package mail
+
+import (
+	"encoding/json"
+
+	"github.com/foxcpp/go-jmap"
+)
+
+// EmailFilterCondition is the filter condition object for Email/query.
+// Condition matches Email only if all non-empty fields match.
+//
+// Conditions can be combined using jmap.FilterOperator.
+//
+// See RFC 8621, section 4.4.1 for details.
+type EmailFilterCondition struct {
+	// A Mailbox id. An Email must be in this Mailbox to match the condition.
+	InMailbox jmap.ID `json:"inMailbox,omitempty"`
+
+	// A list of Mailbox ids. An Email must be in at least one Mailbox not in
+	// this list to match the condition.
+	InMailboxOtherThan []jmap.ID `json:"inMailboxOtherThan,omitempty"`
+
+	// The receivedAt date-time of the Email must be before this date-time to
+	// match the condition.
+	Before *jmap.UTCDate `json:"before,omitempty"`
+
+	// The receivedAt date-time of the Email must be the same or after this
+	// date-time to match the condition.
+	After *jmap.UTCDate `json:"after,omitempty"`
+
+	// The size property of the Email must be equal to or greater than this
+	// number to match the condition.
+	MinSize jmap.UnsignedInt `json:"minSize,omitempty"`
+
+	// The size property of the Email must be less than this number to match
+	// the condition.
+	MaxSize jmap.UnsignedInt `json:"maxSize,omitempty"`
+
+	// All Emails (including this one) in the same Thread as this Email must
+	// have the given keyword to match the condition.
+	AllInThreadHaveKeyword string `json:"allInThreadHaveKeyword,omitempty"`
+
+	// At least one Email (possibly this one) in the same Thread as this Email
+	// must have the given keyword to match the condition.
+	SomeInThreadHaveKeyword string `json:"someInThreadHaveKeyword,omitempty"`
+
+	// All Emails (including this one) in the same Thread as this Email must
+	// not have the given keyword to match the condition.
+	NoneInThreadHaveKeyword string `json:"noneInThreadHaveKeyword,omitempty"`
+
+	// This Email must have the given keyword to match the condition.
+	HasKeyword string `json:"hasKeyword,omitempty"`
+
+	// This Email must not have the given keyword to match the condition.
+	NotKeyword string `json:"notKeyword,omitempty"`
+
+	// The hasAttachment property of the Email must be identical to the value
+	// given to match the condition.
+	HasAttachment *bool `json:"hasAttachment,omitempty"`
+
+	// Looks for the text in Emails. The server MUST look up text in the From,
+	// To, Cc, Bcc, and Subject header fields of the message and SHOULD look
+	// inside any text/* or other body parts that may be converted to text by
+	// the server.
+	Text string `json:"text,omitempty"`
+
+	// Looks for the text in the From header field of the message.
+	From string `json:"from,omitempty"`
+
+	// Looks for the text in the To header field of the message.
+	To string `json:"to,omitempty"`
+
+	// Looks for the text in the Cc header field of the message.
+	CC string `json:"cc,omitempty"`
+
+	// Looks for the text in the Bcc header field of the message.
+	BCC string `json:"bcc,omitempty"`
+
+	// Looks for the text in the Subject header field of the message.
+	Subject string `json:"subject,omitempty"`
+
+	// Looks for the text in one of the body parts of the message.
+	Body string `json:"body,omitempty"`
+
+	// The array MUST contain either one or two elements. The first element is
+	// the name of the header field to match against. The second (optional)
+	// element is the text to look for in the header field value. If not
+	// supplied, the message matches simply if it has a header field of the
+	// given name.
+	Header []string `json:"header,omitempty"`
+
+	// If set, the smimeStatus property of the Email must (true) or must
+	// not (false) start with "signed" or "encrypted+signed". Requires the
+	// urn:ietf:params:jmap:smimeverify capability.
+	HasSMIME *bool `json:"hasSmime,omitempty"`
+
+	// If set, the smimeStatus property of the Email must (true) or must
+	// not (false) be "signed/verified" or "encrypted+signed/verified".
+	HasVerifiedSMIME *bool `json:"hasVerifiedSmime,omitempty"`
+
+	// Same as HasVerifiedSMIME but checks smimeStatusAtDelivery.
+	HasVerifiedSMIMEAtDelivery *bool `json:"hasVerifiedSmimeAtDelivery,omitempty"`
+}
+
+// Properties that can be used in EmailComparator.
+const (
+	SortReceivedAt              = "receivedAt"
+	SortSize                    = "size"
+	SortFrom                    = "from"
+	SortTo                      = "to"
+	SortSubject                 = "subject"
+	SortSentAt                  = "sentAt"
+	SortHasKeyword              = "hasKeyword"
+	SortAllInThreadHaveKeyword  = "allInThreadHaveKeyword"
+	SortSomeInThreadHaveKeyword = "someInThreadHaveKeyword"
+)
+
+// EmailComparator is the sort comparator for Email/query.
+//
+// See RFC 8621, section 4.4.2 for details.
+type EmailComparator struct {
+	jmap.Comparator
+
+	// The keyword to use for hasKeyword, allInThreadHaveKeyword and
+	// someInThreadHaveKeyword sort properties.
+	Keyword string `json:"keyword,omitempty"`
+}
+
+// EmailQueryArgs contains arguments for Email/query method call.
+//
+// See RFC 8621, section 4.4 for details.
+type EmailQueryArgs struct {
+	// The id of the account to use.
+	AccountID jmap.ID `json:"accountId"`
+
+	// Determines the set of Emails returned in the results. Must be either
+	// EmailFilterCondition or jmap.FilterOperator. If nil, no filtering is
+	// performed.
+	Filter interface{} `json:"filter,omitempty"`
+
+	// Lists the names of properties to compare between two Email records,
+	// and how to compare them, to determine which comes first in the sort.
+	Sort []EmailComparator `json:"sort,omitempty"`
+
+	// The zero-based index of the first id in the full list of results to
+	// return. Negative values are offsets from the end of the list.
+	Position jmap.Int `json:"position,omitempty"`
+
+	// An Email id. If supplied, the position argument is ignored. The index
+	// of this id in the results will be used in combination with the
+	// AnchorOffset argument to determine the index of the first result to
+	// return.
+	Anchor jmap.ID `json:"anchor,omitempty"`
+
+	// The index of the first result to return relative to the index of the
+	// anchor, if an anchor is given.
+	AnchorOffset jmap.Int `json:"anchorOffset,omitempty"`
+
+	// The maximum number of results to return. If zero, no limit is
+	// presumed.
+	Limit jmap.UnsignedInt `json:"limit,omitempty"`
+
+	// Does the client wish to know the total number of results in the
+	// query?
+	CalculateTotal bool `json:"calculateTotal,omitempty"`
+
+	// If true, Emails in the same Thread as a previous Email in the list
+	// (given the filter and sort order) will be removed from the list.
+	CollapseThreads bool `json:"collapseThreads,omitempty"`
+}
+
+// EmailQueryResponse contains results of Email/query method call.
+type EmailQueryResponse struct {
+	// The id of the account used for the call.
+	AccountID jmap.ID `json:"accountId"`
+
+	// A string encoding the current state of the query on the server.
+	QueryState string `json:"queryState"`
+
+	// This is true if the server supports calling Email/queryChanges with
+	// these filter/sort parameters.
+	CanCalculateChanges bool `json:"canCalculateChanges"`
+
+	// The zero-based index of the first result in the ids array within the
+	// complete list of query results.
+	Position jmap.UnsignedInt `json:"position"`
+
+	// The list of ids for each Email in the query results, starting at the
+	// index given by the position argument of this response and continuing
+	// until it hits the end of the results or reaches the limit number of
+	// ids.
+	IDs []jmap.ID `json:"ids"`
+
+	// The total number of Emails in the results (given the filter). Only
+	// set if CalculateTotal was requested.
+	Total jmap.UnsignedInt `json:"total,omitempty"`
+
+	// The limit enforced by the server on the maximum number of results to
+	// return. Only set if the server set a limit or used a different limit
+	// than that given in the request.
+	Limit jmap.UnsignedInt `json:"limit,omitempty"`
+}
+
+func unmarshalEmailQueryResponse(args json.RawMessage) (interface{}, error) {
+	resp := EmailQueryResponse{}
+	err := json.Unmarshal(args, &resp)
+	return resp, err
+}
+
+// EmailQueryChangesArgs contains arguments for Email/queryChanges method
+// call.
+//
+// See RFC 8621, section 4.5 for details.
+type EmailQueryChangesArgs struct {
+	// The id of the account to use.
+	AccountID jmap.ID `json:"accountId"`
+
+	// The filter argument that was used with Email/query.
+	Filter interface{} `json:"filter,omitempty"`
+
+	// The sort argument that was used with Email/query.
+	Sort []EmailComparator `json:"sort,omitempty"`
+
+	// The current state of the query in the client. This is the string that
+	// was returned as the queryState argument in the Email/query response
+	// with the same sort/filter.
+	SinceQueryState string `json:"sinceQueryState"`
+
+	// The maximum number of changes to return in the response. If zero, no
+	// limit is presumed.
+	MaxChanges jmap.UnsignedInt `json:"maxChanges,omitempty"`
+
+	// The last (highest-index) id the client currently has cached from the
+	// query results. If supplied, the server may skip changes past this id.
+	UpToID jmap.ID `json:"upToId,omitempty"`
+
+	// Does the client wish to know the total number of results now in the
+	// query?
+	CalculateTotal bool `json:"calculateTotal,omitempty"`
+
+	// The collapseThreads argument that was used with Email/query.
+	CollapseThreads bool `json:"collapseThreads,omitempty"`
+}
+
+// QueryChanges returns Email/queryChanges arguments for the query with the
+// same filter and sort.
+func (args EmailQueryArgs) QueryChanges(sinceQueryState string) EmailQueryChangesArgs {
+	return EmailQueryChangesArgs{
+		AccountID:       args.AccountID,
+		Filter:          args.Filter,
+		Sort:            args.Sort,
+		SinceQueryState: sinceQueryState,
+		CalculateTotal:  args.CalculateTotal,
+		CollapseThreads: args.CollapseThreads,
+	}
+}
+
+// EmailQueryChangesResponse contains results of Email/queryChanges method
+// call.
+type EmailQueryChangesResponse struct {
+	// The id of the account used for the call.
+	AccountID jmap.ID `json:"accountId"`
+
+	// This is the sinceQueryState argument echoed back; that is, the state
+	// from which the server is returning changes.
+	OldQueryState string `json:"oldQueryState"`
+
+	// This is the state the query will be in after applying the set of
+	// changes to the old state.
+	NewQueryState string `json:"newQueryState"`
+
+	// The total number of Emails in the results (given the filter). Only
+	// set if CalculateTotal was requested.
+	Total jmap.UnsignedInt `json:"total,omitempty"`
+
+	// The ids for Emails that have been removed from the results since the
+	// old state, or whose position may have changed.
+	Removed []jmap.ID `json:"removed"`
+
+	// Emails that have been added to the results since the old state, or
+	// whose position may have changed, sorted by index.
+	Added []jmap.AddedItem `json:"added"`
+}
+
+// Apply updates the cached Email/query results, see jmap.ApplyQueryChanges
+// for details.
+func (resp EmailQueryChangesResponse) Apply(ids []jmap.ID) []jmap.ID {
+	return jmap.ApplyQueryChanges(ids, resp.Removed, resp.Added)
+}
+
+func unmarshalEmailQueryChangesResponse(args json.RawMessage) (interface{}, error) {
+	resp := EmailQueryChangesResponse{}
+	err := json.Unmarshal(args, &resp)
+	return resp, err
+}
@@ -0,0 +1,66 @@
+package mail
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/foxcpp/go-jmap"
+	"gotest.tools/assert"
+	"gotest.tools/assert/cmp"
+)
+
+func TestEmailQueryArgsMarshal(t *testing.T) {
+	noAttach := false
+	args := EmailQueryArgs{
+		AccountID: "abc",
+		Filter: jmap.And(
+			EmailFilterCondition{InMailbox: "inbox"},
+			jmap.Not(EmailFilterCondition{HasKeyword: "$seen"}),
+			EmailFilterCondition{HasAttachment: &noAttach},
+		),
+		Sort: []EmailComparator{
+			{Comparator: jmap.Desc(SortReceivedAt)},
+			{Comparator: jmap.Asc(SortHasKeyword), Keyword: "$flagged"},
+		},
+		Limit: 10,
+	}
+
+	blob, err := json.Marshal(args)
+	assert.NilError(t, err, "json.Marshal")
+	assert.Check(t, cmp.Equal(`{"accountId":"abc","filter":{"operator":"AND","conditions":[`+
+		`{"inMailbox":"inbox"},`+
+		`{"operator":"NOT","conditions":[{"hasKeyword":"$seen"}]},`+
+		`{"hasAttachment":false}]},`+
+		`"sort":[{"property":"receivedAt","isAscending":false},`+
+		`{"property":"hasKeyword","isAscending":true,"keyword":"$flagged"}],`+
+		`"limit":10}`, string(blob)))
+}
+
+func TestEmailQueryChanges(t *testing.T) {
+	query := EmailQueryArgs{
+		AccountID:       "A1",
+		Filter:          EmailFilterCondition{InMailbox: "inbox"},
+		Sort:            []EmailComparator{{Comparator: jmap.Desc(SortReceivedAt)}},
+		Limit:           3,
+		CollapseThreads: true,
+	}
+	args := query.QueryChanges("q1")
+	args.UpToID = "E3"
+	blob, err := json.Marshal(args)
+	assert.NilError(t, err)
+	assert.Check(t, cmp.Equal(`{"accountId":"A1","filter":{"inMailbox":"inbox"},`+
+		`"sort":[{"property":"receivedAt","isAscending":false}],`+
+		`"sinceQueryState":"q1","upToId":"E3","collapseThreads":true}`, string(blob)))
+
+	resp, err := unmarshalEmailQueryChangesResponse(json.RawMessage(`{
+		"accountId": "A1",
+		"oldQueryState": "q1",
+		"newQueryState": "q2",
+		"removed": ["E2"],
+		"added": [{"id": "E4", "index": 0}]
+	}`))
+	assert.NilError(t, err)
+	changes := resp.(EmailQueryChangesResponse)
+	assert.Check(t, cmp.Equal("q2", changes.NewQueryState))
+	assert.Check(t, cmp.DeepEqual([]jmap.ID{"E4", "E1", "E3"}, changes.Apply([]jmap.ID{"E1", "E2", "E3"})))
+}
@@ -0,0 +1,130 @@
+package mail
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/foxcpp/go-jmap"
+	"gotest.tools/assert"
+	"gotest.tools/assert/cmp"
+)
+
+const emailGetResp = `{
+  "accountId": "abc",
+  "state": "41234123231",
+  "list": [
+    {
+      "id": "f123u457",
+      "threadId": "ef1314a",
+      "mailboxIds": { "f123": true },
+      "keywords": { "$seen": true },
+      "receivedAt": "2014-10-27T12:00:00Z",
+      "from": [{ "name": "Joe Bloggs", "email": "joe@example.com" }],
+      "subject": "Dinner on Thursday?",
+      "sentAt": "2014-10-27T08:53:11+08:00",
+      "header:List-POST:asURLs": [ "mailto:partytime@lists.example.com" ],
+      "bodyStructure": {
+        "type": "multipart/alternative",
+        "subParts": [
+          { "partId": "1", "blobId": "B841623871", "type": "text/plain" },
+          { "partId": "2", "blobId": "B319437193", "type": "text/html" }
+        ]
+      },
+      "bodyValues": {
+        "1": { "isEncodingProblem": false, "isTruncated": true, "value": "Hello" }
+      }
+    }
+  ],
+  "notFound": [ "f123u456" ]
+}`
+
+func TestEmailGetResponseUnmarshal(t *testing.T) {
+	args, err := unmarshalEmailGetResponse(json.RawMessage(emailGetResp))
+	assert.NilError(t, err, "unmarshalEmailGetResponse")
+	resp := args.(EmailGetResponse)
+
+	assert.Check(t, cmp.Equal(jmap.ID("abc"), resp.AccountID))
+	assert.Check(t, cmp.DeepEqual([]jmap.ID{"f123u456"}, resp.NotFound))
+	assert.Assert(t, cmp.Len(resp.List, 1))
+
+	e := resp.List[0]
+	assert.Check(t, cmp.Equal(jmap.ID("ef1314a"), e.ThreadID))
+	assert.Check(t, cmp.DeepEqual(map[jmap.ID]bool{"f123": true}, e.MailboxIDs))
+	assert.Check(t, cmp.DeepEqual(map[string]bool{"$seen": true}, e.Keywords))
+	assert.Check(t, cmp.DeepEqual([]EmailAddress{{Name: "Joe Bloggs", Email: "joe@example.com"}}, e.From))
+	assert.Check(t, time.Time(*e.ReceivedAt).Equal(time.Date(2014, 10, 27, 12, 0, 0, 0, time.UTC)))
+	assert.Check(t, cmp.Len(e.BodyStructure.SubParts, 2))
+	assert.Check(t, e.BodyValues["1"].IsTruncated)
+	assert.Check(t, cmp.DeepEqual(map[string]json.RawMessage{
+		"header:List-POST:asURLs": json.RawMessage(`[ "mailto:partytime@lists.example.com" ]`),
+	}, e.HeaderProps))
+}
+
+func TestEmailMarshal(t *testing.T) {
+	e := Email{
+		MailboxIDs: map[jmap.ID]bool{"inbox": true},
+		Subject:    "Hello",
+		HeaderProps: map[string]json.RawMessage{
+			"header:X-Foo:asText": json.RawMessage(`"bar"`),
+		},
+	}
+
+	blob, err := json.Marshal(e)
+	assert.NilError(t, err, "json.Marshal")
+	assert.Check(t, cmp.Equal(`{"header:X-Foo:asText":"bar","mailboxIds":{"inbox":true},"subject":"Hello"}`, string(blob)))
+
+	t.Run("without header properties", func(t *testing.T) {
+		blob, err := json.Marshal(Email{Subject: "Hello"})
+		assert.NilError(t, err, "json.Marshal")
+		assert.Check(t, cmp.Equal(`{"subject":"Hello"}`, string(blob)))
+	})
+}
+
+func TestEmailSetResponseUnmarshal(t *testing.T) {
+	blob := `{
+	  "accountId": "abc",
+	  "oldState": "1",
+	  "newState": "2",
+	  "created": { "k1": { "id": "M1", "blobId": "B1", "threadId": "T1", "size": 100 } },
+	  "updated": { "M2": null },
+	  "destroyed": null,
+	  "notCreated": null,
+	  "notUpdated": null,
+	  "notDestroyed": { "M3": { "type": "notFound" } }
+	}`
+	args, err := unmarshalEmailSetResponse(json.RawMessage(blob))
+	assert.NilError(t, err, "unmarshalEmailSetResponse")
+	resp := args.(EmailSetResponse)
+
+	assert.Check(t, cmp.Equal(jmap.ID("M1"), resp.Created["k1"].ID))
+	assert.Check(t, cmp.Equal(jmap.UnsignedInt(100), resp.Created["k1"].Size))
+	upd, ok := resp.Updated["M2"]
+	assert.Check(t, ok)
+	assert.Check(t, upd == nil)
+	assert.Check(t, cmp.Equal(jmap.CodeNotFound, resp.NotDestroyed["M3"].Type))
+}
+
+func TestEmailSMIME(t *testing.T) {
+	var e Email
+	err := json.Unmarshal([]byte(`{
+	  "id": "M1",
+	  "smimeStatus": "signed/failed",
+	  "smimeStatusAtDelivery": "signed/verified",
+	  "smimeErrors": ["Signing certificate has expired"],
+	  "smimeVerifiedAt": "2020-01-01T00:00:00Z"
+	}`), &e)
+	assert.NilError(t, err, "json.Unmarshal")
+	assert.Check(t, cmp.Equal(SMIMESignedFailed, e.SMIMEStatus))
+	assert.Check(t, cmp.Equal(SMIMESignedVerified, e.SMIMEStatusAtDelivery))
+	assert.Check(t, cmp.DeepEqual([]string{"Signing certificate has expired"}, e.SMIMEErrors))
+	assert.Check(t, time.Time(*e.SMIMEVerifiedAt).Equal(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)))
+	assert.Check(t, e.IsSMIMESigned())
+	assert.Check(t, !e.IsSMIMEVerified())
+	assert.Check(t, cmp.Len(e.HeaderProps, 0))
+
+	e.SMIMEStatus = SMIMEEncryptedSignedVerified
+	assert.Check(t, e.IsSMIMEVerified())
+	e.SMIMEStatus = SMIMEUnknown
+	assert.Check(t, !e.IsSMIMESigned())
+}
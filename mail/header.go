@@ -0,0 +1,241 @@
+package mail
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/foxcpp/go-jmap"
+)
+
+// ValidHeaderName checks whether the name is a valid header field name as
+// defined in RFC 5322 (printable US-ASCII characters except colon).
+func ValidHeaderName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, ch := range name {
+		if ch < 33 || ch > 126 || ch == ':' {
+			return false
+		}
+	}
+	return true
+}
+
+// HeaderProperty returns the name of Email property that can be used in
+// Email/get properties list to fetch the last instance of header field with
+// the specified name in Raw form.
+//
+// E.g. HeaderProperty("X-My-Header") = "header:X-My-Header".
+//
+// Fetched value can be read using Email.RawHeader.
+func HeaderProperty(name string) string {
+	return HeaderPropPrefix + name
+}
+
+// lookupHeaderProp returns the value of the header property by its name,
+// ignoring case of the header field name.
+func (e *Email) lookupHeaderProp(prop string) (json.RawMessage, bool) {
+	if val, ok := e.HeaderProps[prop]; ok {
+		return val, true
+	}
+	for k, val := range e.HeaderProps {
+		if strings.EqualFold(k, prop) {
+			return val, true
+		}
+	}
+	return nil, false
+}
+
+// RawHeader returns the value of the header field fetched using
+// HeaderProperty(name).
+//
+// ok is false if the property was not fetched or the message does not have
+// such header field.
+func (e *Email) RawHeader(name string) (value string, ok bool) {
+	blob, ok := e.lookupHeaderProp(HeaderProperty(name))
+	if !ok {
+		return "", false
+	}
+	var val *string
+	if err := json.Unmarshal(blob, &val); err != nil || val == nil {
+		return "", false
+	}
+	return *val, true
+}
+
+// HeaderFilter returns filter condition matching Emails that have the header
+// field with the specified name. If value is not empty, the header field
+// value should also contain it.
+func HeaderFilter(name, value string) EmailFilterCondition {
+	if value == "" {
+		return EmailFilterCondition{Header: []string{name}}
+	}
+	return EmailFilterCondition{Header: []string{name, value}}
+}
+
+// HeaderForm is the parsed form of header field value, as defined in RFC
+// 8621, section 4.1.2.
+type HeaderForm string
+
+const (
+	// The raw octets of the header field value from the first octet
+	// following the header field name terminating colon, up to but
+	// excluding the header field terminating CRLF. Decoded as string.
+	HeaderFormRaw HeaderForm = "asRaw"
+
+	// The header field value with white space unfolded, RFC 2047 encoded
+	// words decoded and leading and trailing white space trimmed. Decoded as
+	// string.
+	HeaderFormText HeaderForm = "asText"
+
+	// The header field value parsed as address-list. Decoded as
+	// []EmailAddress.
+	HeaderFormAddresses HeaderForm = "asAddresses"
+
+	// Similar to HeaderFormAddresses, but preserving group information.
+	// Decoded as []EmailAddressGroup.
+	HeaderFormGroupedAddresses HeaderForm = "asGroupedAddresses"
+
+	// The header field value parsed as msg-id list. Decoded as []string.
+	HeaderFormMessageIDs HeaderForm = "asMessageIds"
+
+	// The header field value parsed as date-time. Decoded as *jmap.Date.
+	HeaderFormDate HeaderForm = "asDate"
+
+	// The header field value parsed as a list of URLs in angle brackets.
+	// Decoded as []string.
+	HeaderFormURLs HeaderForm = "asURLs"
+)
+
+// HeaderSpec describes the header:{name}[:as{form}][:all] Email property.
+type HeaderSpec struct {
+	// Header field name, case-insensitive.
+	Name string
+
+	// Parsed form of the value. Empty is the same as HeaderFormRaw.
+	Form HeaderForm
+
+	// If true, values of all instances of the header field are returned as
+	// an array, in the order they appear in the message. Otherwise only the
+	// last instance is returned.
+	All bool
+}
+
+// Property returns the Email property name for the spec.
+func (hs HeaderSpec) Property() string {
+	prop := HeaderProperty(hs.Name)
+	if hs.Form != "" && hs.Form != HeaderFormRaw {
+		prop += ":" + string(hs.Form)
+	}
+	if hs.All {
+		prop += ":all"
+	}
+	return prop
+}
+
+// HeaderProperties returns the list of Email property names for specs,
+// suitable for use in EmailGetArgs.Properties.
+func HeaderProperties(specs ...HeaderSpec) []string {
+	props := make([]string, 0, len(specs))
+	for _, spec := range specs {
+		props = append(props, spec.Property())
+	}
+	return props
+}
+
+// DecodeHeader decodes the value of the header property fetched using
+// spec.Property() into v. See HeaderForm constants for Go types to use for
+// each form. If spec.All is true, v should be a slice of such type.
+//
+// ok is false if the property was not fetched. If the property was fetched
+// but the message does not have such header field, v is set to its zero
+// value (or an empty slice for spec.All) and ok is true.
+func (e *Email) DecodeHeader(spec HeaderSpec, v interface{}) (ok bool, err error) {
+	blob, ok := e.lookupHeaderProp(spec.Property())
+	if !ok && (spec.Form == "" || spec.Form == HeaderFormRaw) {
+		// The Raw form can be requested with explicit :asRaw suffix too.
+		prop := HeaderProperty(spec.Name) + ":" + string(HeaderFormRaw)
+		if spec.All {
+			prop += ":all"
+		}
+		blob, ok = e.lookupHeaderProp(prop)
+	}
+	if !ok {
+		return false, nil
+	}
+	if err := json.Unmarshal(blob, v); err != nil {
+		return true, fmt.Errorf("jmap/mail: malformed %s value: %v", spec.Property(), err)
+	}
+	return true, nil
+}
+
+// HeaderText returns the last instance of the header field in Text form.
+//
+// ok is false if the property was not fetched or the message does not have
+// such header field.
+func (e *Email) HeaderText(name string) (value string, ok bool) {
+	var val *string
+	if ok, err := e.DecodeHeader(HeaderSpec{Name: name, Form: HeaderFormText}, &val); !ok || err != nil || val == nil {
+		return "", false
+	}
+	return *val, true
+}
+
+// HeaderAddresses returns the last instance of the header field in
+// Addresses form.
+//
+// ok is false if the property was not fetched or the message does not have
+// such header field.
+func (e *Email) HeaderAddresses(name string) (value []EmailAddress, ok bool) {
+	if ok, err := e.DecodeHeader(HeaderSpec{Name: name, Form: HeaderFormAddresses}, &value); !ok || err != nil || value == nil {
+		return nil, false
+	}
+	return value, true
+}
+
+// HeaderGroupedAddresses returns the last instance of the header field in
+// GroupedAddresses form.
+//
+// ok is false if the property was not fetched or the message does not have
+// such header field.
+func (e *Email) HeaderGroupedAddresses(name string) (value []EmailAddressGroup, ok bool) {
+	if ok, err := e.DecodeHeader(HeaderSpec{Name: name, Form: HeaderFormGroupedAddresses}, &value); !ok || err != nil || value == nil {
+		return nil, false
+	}
+	return value, true
+}
+
+// HeaderMessageIDs returns the last instance of the header field in
+// MessageIds form.
+//
+// ok is false if the property was not fetched, the message does not have
+// such header field or it can't be parsed.
+func (e *Email) HeaderMessageIDs(name string) (value []string, ok bool) {
+	if ok, err := e.DecodeHeader(HeaderSpec{Name: name, Form: HeaderFormMessageIDs}, &value); !ok || err != nil || value == nil {
+		return nil, false
+	}
+	return value, true
+}
+
+// HeaderDate returns the last instance of the header field in Date form.
+//
+// ok is false if the property was not fetched, the message does not have
+// such header field or it can't be parsed.
+func (e *Email) HeaderDate(name string) (value *jmap.Date, ok bool) {
+	if ok, err := e.DecodeHeader(HeaderSpec{Name: name, Form: HeaderFormDate}, &value); !ok || err != nil || value == nil {
+		return nil, false
+	}
+	return value, true
+}
+
+// HeaderURLs returns the last instance of the header field in URLs form.
+//
+// ok is false if the property was not fetched, the message does not have
+// such header field or it can't be parsed.
+func (e *Email) HeaderURLs(name string) (value []string, ok bool) {
+	if ok, err := e.DecodeHeader(HeaderSpec{Name: name, Form: HeaderFormURLs}, &value); !ok || err != nil || value == nil {
+		return nil, false
+	}
+	return value, true
+}
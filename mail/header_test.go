@@ -0,0 +1,94 @@
+package mail
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+	"gotest.tools/assert/cmp"
+)
+
+func TestEmailRawHeader(t *testing.T) {
+	var e Email
+	assert.NilError(t, json.Unmarshal([]byte(`{
+		"id": "M1",
+		"header:x-my-header": " value",
+		"header:X-Missing": null
+	}`), &e))
+
+	val, ok := e.RawHeader("X-My-Header")
+	assert.Check(t, ok)
+	assert.Check(t, cmp.Equal(" value", val))
+
+	_, ok = e.RawHeader("X-Missing")
+	assert.Check(t, !ok)
+	_, ok = e.RawHeader("X-Not-Fetched")
+	assert.Check(t, !ok)
+}
+
+func TestHeaderFilter(t *testing.T) {
+	blob, err := json.Marshal(HeaderFilter("List-Id", "go-jmap"))
+	assert.NilError(t, err)
+	assert.Check(t, cmp.Equal(`{"header":["List-Id","go-jmap"]}`, string(blob)))
+
+	blob, err = json.Marshal(HeaderFilter("List-Id", ""))
+	assert.NilError(t, err)
+	assert.Check(t, cmp.Equal(`{"header":["List-Id"]}`, string(blob)))
+}
+
+func TestHeaderForms(t *testing.T) {
+	assert.Check(t, cmp.DeepEqual([]string{
+		"header:X-A",
+		"header:From:asAddresses",
+		"header:List-Post:asURLs:all",
+	}, HeaderProperties(
+		HeaderSpec{Name: "X-A", Form: HeaderFormRaw},
+		HeaderSpec{Name: "From", Form: HeaderFormAddresses},
+		HeaderSpec{Name: "List-Post", Form: HeaderFormURLs, All: true},
+	)))
+
+	var e Email
+	assert.NilError(t, json.Unmarshal([]byte(`{
+		"header:Subject:asText": "Hello",
+		"header:From:asAddresses": [{"name": "Joe", "email": "joe@example.com"}],
+		"header:Date:asDate": "2020-01-02T03:04:05+01:00",
+		"header:Message-ID:asMessageIds": ["a@example.com"],
+		"header:List-Post:asURLs:all": [["mailto:list@example.com"], null],
+		"header:X-Raw:asRaw": " raw",
+		"header:X-Missing:asText": null
+	}`), &e))
+
+	text, ok := e.HeaderText("subject")
+	assert.Check(t, ok)
+	assert.Check(t, cmp.Equal("Hello", text))
+
+	addrs, ok := e.HeaderAddresses("From")
+	assert.Check(t, ok)
+	assert.Check(t, cmp.DeepEqual([]EmailAddress{{Name: "Joe", Email: "joe@example.com"}}, addrs))
+
+	date, ok := e.HeaderDate("Date")
+	assert.Check(t, ok)
+	assert.Check(t, cmp.Equal(2020, time.Time(*date).Year()))
+
+	ids, ok := e.HeaderMessageIDs("Message-ID")
+	assert.Check(t, ok)
+	assert.Check(t, cmp.DeepEqual([]string{"a@example.com"}, ids))
+
+	var allURLs [][]string
+	ok, err := e.DecodeHeader(HeaderSpec{Name: "List-Post", Form: HeaderFormURLs, All: true}, &allURLs)
+	assert.NilError(t, err)
+	assert.Check(t, ok)
+	assert.Check(t, cmp.DeepEqual([][]string{{"mailto:list@example.com"}, nil}, allURLs))
+
+	var raw string
+	ok, err = e.DecodeHeader(HeaderSpec{Name: "X-Raw"}, &raw)
+	assert.NilError(t, err)
+	assert.Check(t, ok)
+	assert.Check(t, cmp.Equal(" raw", raw))
+
+	_, ok = e.HeaderText("X-Missing")
+	assert.Check(t, !ok)
+	_, ok = e.HeaderURLs("X-Not-Fetched")
+	assert.Check(t, !ok)
+}
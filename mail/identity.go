@@ -0,0 +1,149 @@
+package mail
+
+import (
+	"encoding/json"
+
+	"github.com/foxcpp/go-jmap"
+)
+
+// Identity object stores information about an email address or domain the
+// user may send from.
+//
+// See RFC 8621, section 6 for details.
+type Identity struct {
+	// The id of the Identity.
+	ID jmap.ID `json:"id,omitempty"`
+
+	// The "From" name the client SHOULD use when creating a new Email from
+	// this Identity.
+	Name string `json:"name,omitempty"`
+
+	// The "From" email address the client MUST use when creating a new Email
+	// from this Identity. If the mailbox part of the address (the section
+	// before the "@") is the single character "*" (e.g., "*@example.com"),
+	// the client may use any valid address ending in that domain.
+	Email string `json:"email,omitempty"`
+
+	// The Reply-To value the client SHOULD set when creating a new Email from
+	// this Identity.
+	ReplyTo []EmailAddress `json:"replyTo,omitempty"`
+
+	// The Bcc value the client SHOULD set when creating a new Email from this
+	// Identity.
+	BCC []EmailAddress `json:"bcc,omitempty"`
+
+	// A signature the client SHOULD insert into new plaintext messages that
+	// will be sent from this Identity.
+	TextSignature string `json:"textSignature,omitempty"`
+
+	// A signature the client SHOULD insert into new HTML messages that will
+	// be sent from this Identity. This text MUST be an HTML snippet to be
+	// inserted into the <body></body> section of the HTML.
+	HTMLSignature string `json:"htmlSignature,omitempty"`
+
+	// Is the user allowed to delete this Identity? Servers may wish to set
+	// this to false for the user's username or other default address.
+	// Set by server.
+	MayDelete bool `json:"mayDelete,omitempty"`
+}
+
+// IdentityGetArgs contains arguments for Identity/get method call.
+type IdentityGetArgs struct {
+	// The id of the account to use.
+	AccountID jmap.ID `json:"accountId"`
+
+	// The ids of the Identity objects to return. If nil, then all records
+	// are returned.
+	IDs []jmap.ID `json:"ids"`
+
+	// If not nil, only the properties listed in the array are returned for
+	// each Identity object.
+	Properties []string `json:"properties"`
+}
+
+// IdentityGetResponse contains results of Identity/get method call.
+type IdentityGetResponse struct {
+	// The id of the account used for the call.
+	AccountID jmap.ID `json:"accountId"`
+
+	// A string representing the state on the server for all the data of this
+	// type in the account.
+	State string `json:"state"`
+
+	// An array of the Identity objects requested.
+	List []Identity `json:"list"`
+
+	// This array contains the ids passed to the method for records that do
+	// not exist.
+	NotFound []jmap.ID `json:"notFound"`
+}
+
+// IdentitySetArgs contains arguments for Identity/set method call.
+type IdentitySetArgs struct {
+	// The id of the account to use.
+	AccountID jmap.ID `json:"accountId"`
+
+	// If not empty, the method will be aborted and a stateMismatch error
+	// returned if the current state does not match this string.
+	IfInState string `json:"ifInState,omitempty"`
+
+	// A map of creation id to Identity objects.
+	Create map[jmap.ID]Identity `json:"create,omitempty"`
+
+	// A map of id to a patch object to apply to the current Identity object
+	// with that id. Note that the email property is immutable.
+	Update map[jmap.ID]jmap.PatchObject `json:"update,omitempty"`
+
+	// A list of ids for Identity objects to permanently delete.
+	Destroy []jmap.ID `json:"destroy,omitempty"`
+}
+
+// IdentitySetResponse contains results of Identity/set method call.
+type IdentitySetResponse struct {
+	// The id of the account used for the call.
+	AccountID jmap.ID `json:"accountId"`
+
+	// The state string that would have been returned by Identity/get before
+	// making the requested changes.
+	OldState string `json:"oldState"`
+
+	// The state string that will now be returned by Identity/get.
+	NewState string `json:"newState"`
+
+	// A map of the creation id to an object containing any properties of the
+	// created Identity object that were not sent by the client.
+	Created map[jmap.ID]Identity `json:"created"`
+
+	// The keys in this map are the ids of all Identities that were
+	// successfully updated. The value is an Identity object containing any
+	// property that changed in a way not explicitly requested, or nil if
+	// none.
+	Updated map[jmap.ID]*Identity `json:"updated"`
+
+	// A list of Identity ids for records that were successfully destroyed.
+	Destroyed []jmap.ID `json:"destroyed"`
+
+	// A map of creation id to a SetError object for each record that failed
+	// to be created.
+	NotCreated map[jmap.ID]jmap.SetError `json:"notCreated"`
+
+	// A map of Identity id to a SetError object for each record that failed
+	// to be updated.
+	NotUpdated map[jmap.ID]jmap.SetError `json:"notUpdated"`
+
+	// A map of Identity id to a SetError object for each record that failed
+	// to be destroyed.
+	NotDestroyed map[jmap.ID]jmap.SetError `json:"notDestroyed"`
+}
+
+func unmarshalIdentityGetResponse(args json.RawMessage) (interface{}, error) {
+	resp := IdentityGetResponse{}
+	err := json.Unmarshal(args, &resp)
+	return resp, err
+}
+
+func unmarshalIdentitySetResponse(args json.RawMessage) (interface{}, error) {
+	resp := IdentitySetResponse{}
+	err := json.Unmarshal(args, &resp)
+	return resp, err
+}
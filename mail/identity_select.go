@@ -0,0 +1,84 @@
+package mail
+
+import (
+	"strings"
+)
+
+// SelectIdentity picks the Identity to reply to the original Email from.
+//
+// Recipient addresses of the original (To, Cc, Bcc, in that order) are matched
+// against Identities using the following rules, first matching rule wins:
+//
+//  1. Identity email equals the recipient address (case-insensitive).
+//  2. Identity is a wildcard ("*@example.org") for the recipient's domain.
+//  3. Identity email has the same domain as the recipient.
+//
+// If nothing matches, the default Identity is returned, which is the first
+// one that can't be deleted by the user (usually the account's primary
+// address) or just the first Identity in the list.
+//
+// nil is returned only if idents is empty.
+func SelectIdentity(idents []Identity, original *Email) *Identity {
+	if len(idents) == 0 {
+		return nil
+	}
+
+	var recipients []EmailAddress
+	if original != nil {
+		recipients = append(recipients, original.To...)
+		recipients = append(recipients, original.CC...)
+		recipients = append(recipients, original.BCC...)
+	}
+
+	// Rule 1.
+	for _, rcpt := range recipients {
+		for i, ident := range idents {
+			if strings.EqualFold(ident.Email, rcpt.Email) {
+				return &idents[i]
+			}
+		}
+	}
+
+	// Rule 2.
+	for _, rcpt := range recipients {
+		for i, ident := range idents {
+			local, domain := splitAddress(ident.Email)
+			if local == "*" && strings.EqualFold(domain, addressDomain(rcpt.Email)) {
+				return &idents[i]
+			}
+		}
+	}
+
+	// Rule 3.
+	for _, rcpt := range recipients {
+		rcptDomain := addressDomain(rcpt.Email)
+		if rcptDomain == "" {
+			continue
+		}
+		for i, ident := range idents {
+			if strings.EqualFold(addressDomain(ident.Email), rcptDomain) {
+				return &idents[i]
+			}
+		}
+	}
+
+	for i, ident := range idents {
+		if !ident.MayDelete {
+			return &idents[i]
+		}
+	}
+	return &idents[0]
+}
+
+func splitAddress(addr string) (local, domain string) {
+	at := strings.LastIndexByte(addr, '@')
+	if at == -1 {
+		return addr, ""
+	}
+	return addr[:at], addr[at+1:]
+}
+
+func addressDomain(addr string) string {
+	_, domain := splitAddress(addr)
+	return domain
+}
@@ -0,0 +1,46 @@
+package mail
+
+import (
+	"testing"
+
+	"github.com/foxcpp/go-jmap"
+	"gotest.tools/assert"
+	"gotest.tools/assert/cmp"
+)
+
+func TestSelectIdentity(t *testing.T) {
+	idents := []Identity{
+		{ID: "I1", Email: "joe@example.org", MayDelete: true},
+		{ID: "I2", Email: "joe@example.com"},
+		{ID: "I3", Email: "*@lists.example.org", MayDelete: true},
+		{ID: "I4", Email: "joe.work@corp.example", MayDelete: true},
+	}
+
+	test := func(name string, original *Email, expected jmap.ID) {
+		t.Run(name, func(t *testing.T) {
+			ident := SelectIdentity(idents, original)
+			assert.Assert(t, ident != nil)
+			assert.Check(t, cmp.Equal(expected, ident.ID))
+		})
+	}
+
+	test("exact match", &Email{
+		To: []EmailAddress{{Email: "JOE@example.org"}},
+	}, "I1")
+	test("exact match in cc wins over domain match", &Email{
+		To: []EmailAddress{{Email: "team@corp.example"}},
+		CC: []EmailAddress{{Email: "joe@example.org"}},
+	}, "I1")
+	test("wildcard", &Email{
+		To: []EmailAddress{{Email: "announce@lists.example.org"}},
+	}, "I3")
+	test("domain match", &Email{
+		BCC: []EmailAddress{{Email: "all@corp.example"}},
+	}, "I4")
+	test("default", &Email{
+		To: []EmailAddress{{Email: "someone@elsewhere.example"}},
+	}, "I2")
+	test("no original", nil, "I2")
+
+	assert.Check(t, SelectIdentity(nil, &Email{}) == nil)
+}
@@ -0,0 +1,138 @@
+package mail
+
+import (
+	"sort"
+	"strings"
+)
+
+// IMAP system flags, as defined in RFC 3501.
+const (
+	IMAPFlagSeen     = `\Seen`
+	IMAPFlagAnswered = `\Answered`
+	IMAPFlagFlagged  = `\Flagged`
+	IMAPFlagDeleted  = `\Deleted`
+	IMAPFlagDraft    = `\Draft`
+	IMAPFlagRecent   = `\Recent`
+)
+
+// Mapping of IMAP system flags to keywords as specified in RFC 8621, section
+// 4.1.1. \Deleted and \Recent have no JMAP equivalent.
+var imapSystemFlags = map[string]string{
+	`\seen`:     KeywordSeen,
+	`\answered`: KeywordAnswered,
+	`\flagged`:  KeywordFlagged,
+	`\draft`:    KeywordDraft,
+}
+
+// ValidKeyword checks whether the string can be used as a keyword: 1-255
+// characters in the range %x21-%x7e excluding "(", ")", "{", "]", "%",
+// "*", `"` and `\`.
+func ValidKeyword(keyword string) bool {
+	if keyword == "" || len(keyword) > 255 {
+		return false
+	}
+	for i := 0; i < len(keyword); i++ {
+		ch := keyword[i]
+		if ch < 0x21 || ch > 0x7e {
+			return false
+		}
+		switch ch {
+		case '(', ')', '{', ']', '%', '*', '"', '\\':
+			return false
+		}
+	}
+	return true
+}
+
+// KeywordsFromIMAPFlags converts the IMAP flag set into JMAP keywords.
+//
+// System flags are converted to corresponding keywords, other flags are
+// lowercased. \Deleted, \Recent, unknown system flags and flags that are not
+// valid keywords are dropped.
+func KeywordsFromIMAPFlags(flags []string) map[string]bool {
+	res := make(map[string]bool, len(flags))
+	for _, flag := range flags {
+		flag = strings.ToLower(flag)
+		if strings.HasPrefix(flag, `\`) {
+			if kw, ok := imapSystemFlags[flag]; ok {
+				res[kw] = true
+			}
+			continue
+		}
+		if ValidKeyword(flag) {
+			res[flag] = true
+		}
+	}
+	return res
+}
+
+// IMAPFlagsFromKeywords converts JMAP keywords into the IMAP flag set.
+//
+// Keywords that correspond to system flags are converted to them, other
+// keywords are used as is. The result is sorted.
+func IMAPFlagsFromKeywords(keywords map[string]bool) []string {
+	flags := make([]string, 0, len(keywords))
+	for kw, set := range keywords {
+		if !set {
+			continue
+		}
+		kw = strings.ToLower(kw)
+		switch kw {
+		case KeywordSeen:
+			flags = append(flags, IMAPFlagSeen)
+		case KeywordAnswered:
+			flags = append(flags, IMAPFlagAnswered)
+		case KeywordFlagged:
+			flags = append(flags, IMAPFlagFlagged)
+		case KeywordDraft:
+			flags = append(flags, IMAPFlagDraft)
+		default:
+			flags = append(flags, kw)
+		}
+	}
+	sort.Strings(flags)
+	return flags
+}
+
+// Mapping of special-use mailbox attributes (RFC 6154, RFC 8457) to
+// Mailbox roles.
+var specialUseRoles = map[string]string{
+	`\all`:       RoleAll,
+	`\archive`:   RoleArchive,
+	`\drafts`:    RoleDrafts,
+	`\flagged`:   RoleFlagged,
+	`\important`: RoleImportant,
+	`\junk`:      RoleJunk,
+	`\sent`:      RoleSent,
+	`\trash`:     RoleTrash,
+}
+
+// RoleFromSpecialUse returns the Mailbox role for the IMAP mailbox with the
+// specified name and LIST attributes, or an empty string if it has no
+// role.
+//
+// The mailbox named INBOX (case-insensitive) gets the "inbox" role.
+func RoleFromSpecialUse(name string, attrs []string) string {
+	if strings.EqualFold(name, "INBOX") {
+		return RoleInbox
+	}
+	for _, attr := range attrs {
+		if role, ok := specialUseRoles[strings.ToLower(attr)]; ok {
+			return role
+		}
+	}
+	return ""
+}
+
+// SpecialUseFromRole returns the special-use attribute for the Mailbox role,
+// e.g. `\Sent` for "sent". An empty string is returned for roles without
+// special-use equivalent, including "inbox".
+func SpecialUseFromRole(role string) string {
+	role = strings.ToLower(role)
+	for attr, r := range specialUseRoles {
+		if r == role {
+			return `\` + strings.ToUpper(attr[1:2]) + attr[2:]
+		}
+	}
+	return ""
+}
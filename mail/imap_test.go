@@ -0,0 +1,30 @@
+package mail
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+	"gotest.tools/assert/cmp"
+)
+
+func TestIMAPFlags(t *testing.T) {
+	kws := KeywordsFromIMAPFlags([]string{`\Seen`, `\Deleted`, `\Recent`, `\Draft`, `$Forwarded`, `Work`, `bad"flag`, `\Unknown`})
+	assert.Check(t, cmp.DeepEqual(map[string]bool{
+		KeywordSeen:      true,
+		KeywordDraft:     true,
+		KeywordForwarded: true,
+		"work":           true,
+	}, kws))
+
+	assert.Check(t, cmp.DeepEqual([]string{"$forwarded", `\Draft`, `\Seen`, "work"}, IMAPFlagsFromKeywords(kws)))
+}
+
+func TestSpecialUse(t *testing.T) {
+	assert.Check(t, cmp.Equal(RoleInbox, RoleFromSpecialUse("Inbox", nil)))
+	assert.Check(t, cmp.Equal(RoleSent, RoleFromSpecialUse("Sent Items", []string{`\HasNoChildren`, `\Sent`})))
+	assert.Check(t, cmp.Equal("", RoleFromSpecialUse("Work", []string{`\HasChildren`})))
+
+	assert.Check(t, cmp.Equal(`\Junk`, SpecialUseFromRole(RoleJunk)))
+	assert.Check(t, cmp.Equal(`\Important`, SpecialUseFromRole(RoleImportant)))
+	assert.Check(t, cmp.Equal("", SpecialUseFromRole(RoleInbox)))
+}
@@ -0,0 +1,208 @@
+package mail
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/foxcpp/go-jmap"
+	"github.com/foxcpp/go-jmap/client"
+)
+
+// normalizeMessageID strips angle brackets and surrounding whitespace from
+// the Message-ID, the messageId property contains ids without them.
+func normalizeMessageID(id string) string {
+	return strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(id), "<"), ">")
+}
+
+// getEmailProperties fetches the properties of Emails, calling fn for each
+// of them. Emails are fetched in chunks respecting maxObjectsInGet.
+func getEmailProperties(c *client.Client, account jmap.ID, ids []jmap.ID, properties []string, fn func(e Email)) error {
+	session, err := c.CurrentSession()
+	if err != nil {
+		return err
+	}
+	chunkSize := int(session.Limits().MaxObjectsInGet)
+
+	for start := 0; start < len(ids); start += chunkSize {
+		end := start + chunkSize
+		if end > len(ids) {
+			end = len(ids)
+		}
+		resp, err := getEmails(c, EmailGetArgs{
+			AccountID:  account,
+			IDs:        ids[start:end],
+			Properties: properties,
+		})
+		if err != nil {
+			return err
+		}
+		for _, e := range resp.List {
+			fn(e)
+		}
+	}
+	return nil
+}
+
+// MessageIDIndex finds Emails in the account by Message-ID, see
+// FindByMessageID. Unlike FindByMessageID, it remembers whether the server
+// supports the header filter and, if it does not, loads messageId of all
+// Emails in the account only once and then looks them up in memory.
+//
+// Use it instead of FindByMessageID and ImportUnique when looking up many
+// messages, e.g. during a migration. Emails created by other clients after
+// the index was loaded are not found, use Add to record Emails created
+// meanwhile by the caller.
+//
+// MessageIDIndex is not safe for concurrent use.
+type MessageIDIndex struct {
+	c       *client.Client
+	account jmap.ID
+
+	// Set after the server rejected the header filter.
+	ids map[string][]jmap.ID
+}
+
+// NewMessageIDIndex creates the MessageIDIndex for the account.
+func NewMessageIDIndex(c *client.Client, account jmap.ID) *MessageIDIndex {
+	return &MessageIDIndex{c: c, account: account}
+}
+
+// Find returns ids of Emails in the account that have the specified
+// Message-ID, with or without angle brackets.
+//
+// The client must have ResponseUnmarshallers enabled.
+func (idx *MessageIDIndex) Find(messageID string) ([]jmap.ID, error) {
+	messageID = normalizeMessageID(messageID)
+	if idx.ids != nil {
+		return idx.ids[messageID], nil
+	}
+
+	candidates, err := queryAllEmails(idx.c, idx.account, EmailFilterCondition{
+		Header: []string{"Message-ID", messageID},
+	})
+	if err != nil {
+		methodErr, ok := err.(jmap.MethodErrorArgs)
+		if !ok || methodErr.Type != jmap.CodeUnsupportedFilter {
+			return nil, err
+		}
+		if err := idx.load(); err != nil {
+			return nil, err
+		}
+		return idx.ids[messageID], nil
+	}
+
+	var res []jmap.ID
+	err = getEmailProperties(idx.c, idx.account, candidates, []string{"id", "messageId"}, func(e Email) {
+		for _, id := range e.MessageID {
+			if id == messageID {
+				res = append(res, e.ID)
+				break
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// load fetches messageId of all Emails in the account.
+func (idx *MessageIDIndex) load() error {
+	all, err := queryAllEmails(idx.c, idx.account, nil)
+	if err != nil {
+		return err
+	}
+	ids := make(map[string][]jmap.ID)
+	err = getEmailProperties(idx.c, idx.account, all, []string{"id", "messageId"}, func(e Email) {
+		for _, msgID := range e.MessageID {
+			ids[msgID] = append(ids[msgID], e.ID)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	idx.ids = ids
+	return nil
+}
+
+// Add records that the Email with the Message-ID exists in the account. It
+// has no effect if the server supports the header filter or messageID is
+// empty.
+func (idx *MessageIDIndex) Add(messageID string, email jmap.ID) {
+	if idx.ids == nil || messageID == "" {
+		return
+	}
+	messageID = normalizeMessageID(messageID)
+	idx.ids[messageID] = append(idx.ids[messageID], email)
+}
+
+// FindByMessageID returns ids of Emails in the account that have the
+// specified Message-ID, with or without angle brackets.
+//
+// Emails are searched using the header filter of Email/query. Since servers
+// match header values as substrings, the results are then verified by
+// comparing the messageId property. If the server does not support the
+// filter (unsupportedFilter error), messageId of all Emails in the account
+// is compared instead, which can be slow for large accounts. Use
+// MessageIDIndex to look up many messages.
+//
+// The client must have ResponseUnmarshallers enabled.
+func FindByMessageID(c *client.Client, account jmap.ID, messageID string) ([]jmap.ID, error) {
+	return NewMessageIDIndex(c, account).Find(messageID)
+}
+
+// ImportUnique imports the message using Email/import unless an Email with
+// the same Message-ID already exists in the account, which makes repeated
+// runs of migration tools idempotent. See FindByMessageID for how the
+// existing Emails are found.
+//
+// It returns the id of the imported Email or the existing one and whether
+// the message already existed. The alreadyExists error returned by servers
+// that detect duplicates on their own is handled the same way.
+//
+// If messageID is empty, the message is always imported.
+//
+// The client must have ResponseUnmarshallers enabled.
+func ImportUnique(c *client.Client, account jmap.ID, messageID string, imp EmailImport) (jmap.ID, bool, error) {
+	return NewMessageIDIndex(c, account).ImportUnique(messageID, imp)
+}
+
+// ImportUnique is ImportUnique that uses the index to find existing Emails.
+// The imported Email is added to the index.
+func (idx *MessageIDIndex) ImportUnique(messageID string, imp EmailImport) (jmap.ID, bool, error) {
+	c, account := idx.c, idx.account
+	if messageID != "" {
+		existing, err := idx.Find(messageID)
+		if err != nil {
+			return "", false, err
+		}
+		if len(existing) != 0 {
+			return existing[0], true, nil
+		}
+	}
+
+	respArgs, err := c.Call(mailUsing, "Email/import", EmailImportArgs{
+		AccountID: account,
+		Emails:    map[jmap.ID]EmailImport{"import": imp},
+	})
+	if err != nil {
+		return "", false, err
+	}
+	resp, ok := respArgs.(EmailImportResponse)
+	if !ok {
+		return "", false, unexpectedResponse("Email/import", respArgs)
+	}
+	if setErr, ok := resp.NotCreated["import"]; ok {
+		if setErr.Type == jmap.CodeAlreadyExists && setErr.ExistingID != "" {
+			idx.Add(messageID, setErr.ExistingID)
+			return setErr.ExistingID, true, nil
+		}
+		return "", false, setErr
+	}
+	created, ok := resp.Created["import"]
+	if !ok {
+		return "", false, fmt.Errorf("jmap/mail: no Email/import result for the message")
+	}
+	idx.Add(messageID, created.ID)
+	return created.ID, false, nil
+}
@@ -0,0 +1,125 @@
+package mail
+
+import (
+	"testing"
+
+	"github.com/foxcpp/go-jmap"
+	"gotest.tools/assert"
+	"gotest.tools/assert/cmp"
+)
+
+func TestImportUnique(t *testing.T) {
+	headerFilter := true
+	var (
+		imports int
+		headers []interface{}
+	)
+	c, srv := newTestClient(t, func(name string, args map[string]interface{}) []testResponse {
+		switch name {
+		case "Email/query":
+			filter, _ := args["filter"].(map[string]interface{})
+			if filter != nil && !headerFilter {
+				return []testResponse{{"error", map[string]interface{}{"type": "unsupportedFilter"}}}
+			}
+			ids := []interface{}{"E1", "E2"}
+			if filter != nil {
+				if args["anchor"] == nil {
+					headers = append(headers, filter["header"])
+				}
+				// Substring match.
+				ids = []interface{}{"E2"}
+			}
+			if args["anchor"] != nil {
+				ids = []interface{}{}
+			}
+			return []testResponse{{name, map[string]interface{}{"queryState": "q1", "ids": ids}}}
+		case "Email/get":
+			list := []interface{}{}
+			for _, id := range args["ids"].([]interface{}) {
+				msgID := "other@example.org"
+				if id == "E2" {
+					msgID = "xa@example.org"
+				}
+				list = append(list, map[string]interface{}{"id": id, "messageId": []string{msgID}})
+			}
+			return []testResponse{{name, map[string]interface{}{"list": list}}}
+		case "Email/import":
+			imports++
+			return []testResponse{{name, map[string]interface{}{
+				"created": map[string]interface{}{"import": map[string]interface{}{"id": "E3"}},
+			}}}
+		}
+		t.Fatalf("unexpected call: %s", name)
+		return nil
+	})
+	defer srv.Close()
+
+	imp := EmailImport{BlobID: "B1", MailboxIDs: map[jmap.ID]bool{"INBOX": true}}
+
+	id, existed, err := ImportUnique(c, "A1", "<a@example.org>", imp)
+	assert.NilError(t, err)
+	assert.Check(t, !existed)
+	assert.Check(t, cmp.Equal(jmap.ID("E3"), id))
+	assert.Check(t, cmp.Equal(1, imports))
+	assert.Check(t, cmp.DeepEqual([]interface{}{[]interface{}{"Message-ID", "a@example.org"}}, headers))
+
+	t.Run("fallback", func(t *testing.T) {
+		headerFilter = false
+		ids, err := FindByMessageID(c, "A1", "<xa@example.org>")
+		assert.NilError(t, err)
+		assert.Check(t, cmp.DeepEqual([]jmap.ID{"E2"}, ids))
+
+		id, existed, err := ImportUnique(c, "A1", "xa@example.org", imp)
+		assert.NilError(t, err)
+		assert.Check(t, existed)
+		assert.Check(t, cmp.Equal(jmap.ID("E2"), id))
+		assert.Check(t, cmp.Equal(1, imports))
+	})
+
+	t.Run("index", func(t *testing.T) {
+		queries := 0
+		c, srv := newTestClient(t, func(name string, args map[string]interface{}) []testResponse {
+			switch name {
+			case "Email/query":
+				if args["filter"] != nil {
+					return []testResponse{{"error", map[string]interface{}{"type": "unsupportedFilter"}}}
+				}
+				ids := []interface{}{"E1"}
+				if args["anchor"] != nil {
+					ids = []interface{}{}
+				} else {
+					queries++
+				}
+				return []testResponse{{name, map[string]interface{}{"queryState": "q1", "ids": ids}}}
+			case "Email/get":
+				return []testResponse{{name, map[string]interface{}{"list": []interface{}{
+					map[string]interface{}{"id": "E1", "messageId": []string{"a@example.org"}},
+				}}}}
+			case "Email/import":
+				return []testResponse{{name, map[string]interface{}{
+					"created": map[string]interface{}{"import": map[string]interface{}{"id": "E2"}},
+				}}}
+			}
+			t.Fatalf("unexpected call: %s", name)
+			return nil
+		})
+		defer srv.Close()
+
+		idx := NewMessageIDIndex(c, "A1")
+		ids, err := idx.Find("<a@example.org>")
+		assert.NilError(t, err)
+		assert.Check(t, cmp.DeepEqual([]jmap.ID{"E1"}, ids))
+
+		id, existed, err := idx.ImportUnique("b@example.org", imp)
+		assert.NilError(t, err)
+		assert.Check(t, !existed)
+		assert.Check(t, cmp.Equal(jmap.ID("E2"), id))
+
+		// The imported Email is found without querying the server again.
+		id, existed, err = idx.ImportUnique("<b@example.org>", imp)
+		assert.NilError(t, err)
+		assert.Check(t, existed)
+		assert.Check(t, cmp.Equal(jmap.ID("E2"), id))
+		assert.Check(t, cmp.Equal(1, queries))
+	})
+}
@@ -0,0 +1,77 @@
+package mail
+
+import (
+	"errors"
+
+	"github.com/foxcpp/go-jmap"
+	"github.com/foxcpp/go-jmap/client"
+)
+
+var (
+	ErrNoJunk  = errors.New("jmap/mail: there is no Mailbox with the junk role")
+	ErrNoInbox = errors.New("jmap/mail: there is no Mailbox with the inbox role")
+)
+
+// roleMailboxes returns ids of Mailboxes with the specified roles. Roles
+// without a Mailbox are missing in the returned map.
+func roleMailboxes(c *client.Client, account jmap.ID, roles ...string) (map[string]jmap.ID, error) {
+	mboxes, err := getMailboxes(c, MailboxGetArgs{
+		AccountID:  account,
+		Properties: []string{"id", "role"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[string]jmap.ID, len(roles))
+	for _, mbox := range mboxes.List {
+		for _, role := range roles {
+			if mbox.Role == role {
+				res[role] = mbox.ID
+			}
+		}
+	}
+	return res, nil
+}
+
+// ReportJunk marks Emails as spam and moves them to the Mailbox with the
+// junk role, see JunkPatch. ErrNoJunk is returned if there is no such
+// Mailbox.
+//
+// Servers commonly use the $junk keyword set this way to train spam
+// filters. Emails are updated using as many Email/set calls as needed to
+// respect the maxObjectsInSet limit, see UpdateEmails.
+func ReportJunk(c *client.Client, account jmap.ID, emails []jmap.ID, progress ProgressFunc) (*BulkResult, error) {
+	mboxes, err := roleMailboxes(c, account, RoleJunk)
+	if err != nil {
+		return nil, err
+	}
+	junk, ok := mboxes[RoleJunk]
+	if !ok {
+		return nil, ErrNoJunk
+	}
+	return UpdateEmails(c, account, JunkSetArgs(account, emails, true, junk, "").Update, progress)
+}
+
+// ReportNotJunk marks Emails as not spam and moves them from the Mailbox
+// with the junk role to the Mailbox with the inbox role, see NotJunkPatch.
+// ErrNoJunk or ErrNoInbox is returned if there is no such Mailbox.
+//
+// Note that Emails that are not in the junk Mailbox are added to the inbox
+// too.
+//
+// See ReportJunk for details.
+func ReportNotJunk(c *client.Client, account jmap.ID, emails []jmap.ID, progress ProgressFunc) (*BulkResult, error) {
+	mboxes, err := roleMailboxes(c, account, RoleJunk, RoleInbox)
+	if err != nil {
+		return nil, err
+	}
+	junk, ok := mboxes[RoleJunk]
+	if !ok {
+		return nil, ErrNoJunk
+	}
+	inbox, ok := mboxes[RoleInbox]
+	if !ok {
+		return nil, ErrNoInbox
+	}
+	return UpdateEmails(c, account, JunkSetArgs(account, emails, false, junk, inbox).Update, progress)
+}
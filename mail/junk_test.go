@@ -0,0 +1,58 @@
+package mail
+
+import (
+	"testing"
+
+	"github.com/foxcpp/go-jmap"
+	"gotest.tools/assert"
+	"gotest.tools/assert/cmp"
+)
+
+func TestReportJunk(t *testing.T) {
+	var updates []map[string]interface{}
+	mailboxes := []interface{}{
+		map[string]interface{}{"id": "INBOX", "role": "inbox"},
+		map[string]interface{}{"id": "J", "role": "junk"},
+	}
+	c, srv := newTestClient(t, func(name string, args map[string]interface{}) []testResponse {
+		switch name {
+		case "Mailbox/get":
+			return []testResponse{{name, map[string]interface{}{"list": mailboxes}}}
+		case "Email/set":
+			update := args["update"].(map[string]interface{})
+			updated := map[string]interface{}{}
+			for id, patch := range update {
+				updates = append(updates, patch.(map[string]interface{}))
+				updated[id] = nil
+			}
+			return []testResponse{{name, map[string]interface{}{"updated": updated}}}
+		}
+		t.Fatalf("unexpected call: %s", name)
+		return nil
+	})
+	defer srv.Close()
+
+	res, err := ReportJunk(c, "A1", []jmap.ID{"E1"}, nil)
+	assert.NilError(t, err)
+	assert.Check(t, cmp.DeepEqual([]jmap.ID{"E1"}, res.Done))
+	assert.Check(t, cmp.DeepEqual([]map[string]interface{}{{
+		"keywords/$junk":    true,
+		"keywords/$notjunk": nil,
+		"mailboxIds":        map[string]interface{}{"J": true},
+	}}, updates))
+
+	updates = nil
+	res, err = ReportNotJunk(c, "A1", []jmap.ID{"E1"}, nil)
+	assert.NilError(t, err)
+	assert.Check(t, cmp.DeepEqual([]jmap.ID{"E1"}, res.Done))
+	assert.Check(t, cmp.DeepEqual([]map[string]interface{}{{
+		"keywords/$junk":    nil,
+		"keywords/$notjunk": true,
+		"mailboxIds/J":      nil,
+		"mailboxIds/INBOX":  true,
+	}}, updates))
+
+	mailboxes = mailboxes[:1]
+	_, err = ReportJunk(c, "A1", []jmap.ID{"E1"}, nil)
+	assert.Check(t, cmp.Equal(ErrNoJunk, err))
+}
@@ -0,0 +1,29 @@
+package mail
+
+// Keywords with special meaning defined in RFC 8621 and the IANA "IMAP and
+// JMAP Keywords" registry.
+const (
+	// The Email is a draft the user is composing.
+	KeywordDraft = "$draft"
+
+	// The Email has been read.
+	KeywordSeen = "$seen"
+
+	// The Email has been flagged for urgent/special attention.
+	KeywordFlagged = "$flagged"
+
+	// The Email has been replied to.
+	KeywordAnswered = "$answered"
+
+	// The Email has been forwarded.
+	KeywordForwarded = "$forwarded"
+
+	// The Email is likely to be phishing.
+	KeywordPhishing = "$phishing"
+
+	// The Email is definitely spam.
+	KeywordJunk = "$junk"
+
+	// The Email is definitely not spam.
+	KeywordNotJunk = "$notjunk"
+)
@@ -0,0 +1,78 @@
+package mail
+
+import (
+	"github.com/foxcpp/go-jmap"
+	"github.com/foxcpp/go-jmap/client"
+)
+
+// Unlike IMAP folders, a JMAP Email can belong to multiple Mailboxes at the
+// same time, which makes Mailboxes behave similarly to labels. Helpers below
+// add or remove a single mailboxIds entry without touching others.
+//
+// Note that an Email must always belong to at least one Mailbox, servers
+// reject removal of the last one with invalidProperties SetError.
+
+// AddLabelPatch returns the patch that adds the Email to the Mailbox keeping
+// it in all other Mailboxes.
+func AddLabelPatch(mailbox jmap.ID) jmap.PatchObject {
+	return jmap.PatchObject{mailboxPath(mailbox): true}
+}
+
+// RemoveLabelPatch returns the patch that removes the Email from the Mailbox
+// keeping it in all other Mailboxes.
+func RemoveLabelPatch(mailbox jmap.ID) jmap.PatchObject {
+	return jmap.PatchObject{mailboxPath(mailbox): nil}
+}
+
+func samePatch(emails []jmap.ID, patch jmap.PatchObject) map[jmap.ID]jmap.PatchObject {
+	patches := make(map[jmap.ID]jmap.PatchObject, len(emails))
+	for _, id := range emails {
+		patches[id] = patch
+	}
+	return patches
+}
+
+// AddLabel adds Emails to the Mailbox, splitting the update into multiple
+// Email/set calls if necessary.
+//
+// The client must have ResponseUnmarshallers enabled.
+func AddLabel(c *client.Client, account jmap.ID, emails []jmap.ID, mailbox jmap.ID, progress ProgressFunc) (*BulkResult, error) {
+	return UpdateEmails(c, account, samePatch(emails, AddLabelPatch(mailbox)), progress)
+}
+
+// RemoveLabel removes Emails from the Mailbox, splitting the update into
+// multiple Email/set calls if necessary.
+//
+// The client must have ResponseUnmarshallers enabled.
+func RemoveLabel(c *client.Client, account jmap.ID, emails []jmap.ID, mailbox jmap.ID, progress ProgressFunc) (*BulkResult, error) {
+	return UpdateEmails(c, account, samePatch(emails, RemoveLabelPatch(mailbox)), progress)
+}
+
+// AddLabelByQuery adds all Emails matching the filter to the Mailbox.
+//
+// See AddLabel for details.
+func AddLabelByQuery(c *client.Client, account jmap.ID, filter interface{}, mailbox jmap.ID, progress ProgressFunc) (*BulkResult, error) {
+	emails, err := queryAllEmails(c, account, filter)
+	if err != nil {
+		return nil, err
+	}
+	return AddLabel(c, account, emails, mailbox, progress)
+}
+
+// RemoveLabelByQuery removes all Emails matching the filter from the
+// Mailbox.
+//
+// Emails that are not in the Mailbox are skipped.
+//
+// See RemoveLabel for details.
+func RemoveLabelByQuery(c *client.Client, account jmap.ID, filter interface{}, mailbox jmap.ID, progress ProgressFunc) (*BulkResult, error) {
+	var inMailbox interface{} = EmailFilterCondition{InMailbox: mailbox}
+	if filter != nil {
+		inMailbox = jmap.And(filter, inMailbox)
+	}
+	emails, err := queryAllEmails(c, account, inMailbox)
+	if err != nil {
+		return nil, err
+	}
+	return RemoveLabel(c, account, emails, mailbox, progress)
+}
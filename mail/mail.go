@@ -0,0 +1,74 @@
+// Package mail implements data types and methods of JMAP Mail extension as
+// defined in RFC 8621.
+//
+// Documentation strings for most of the protocol objects are taken from (or
+// based on) contents of RFC 8621 and is subject to the IETF Trust Provisions.
+// See https://trustee.ietf.org/trust-legal-provisions.html for details.
+package mail
+
+import "github.com/foxcpp/go-jmap"
+
+// ResponseUnmarshallers contains callbacks for decoding responses of all
+// methods implemented by this package.
+//
+// Pass it to client.Enable to make the client decode these responses into
+// corresponding structures.
+var ResponseUnmarshallers = map[string]jmap.FuncArgsUnmarshal{
+	"Email/get":    unmarshalEmailGetResponse,
+	"Email/set":    unmarshalEmailSetResponse,
+	"Email/query":  unmarshalEmailQueryResponse,
+	"Email/import": unmarshalEmailImportResponse,
+	"Email/copy":   unmarshalEmailCopyResponse,
+	"Mailbox/get":  unmarshalMailboxGetResponse,
+	"Mailbox/set":  unmarshalMailboxSetResponse,
+
+	"Email/changes":      unmarshalEmailChangesResponse,
+	"Email/queryChanges": unmarshalEmailQueryChangesResponse,
+
+	"Mailbox/changes": unmarshalMailboxChangesResponse,
+	"Mailbox/query":   unmarshalMailboxQueryResponse,
+
+	"Thread/get": unmarshalThreadGetResponse,
+
+	"Identity/get": unmarshalIdentityGetResponse,
+	"Identity/set": unmarshalIdentitySetResponse,
+
+	"EmailSubmission/get": unmarshalEmailSubmissionGetResponse,
+	"EmailSubmission/set": unmarshalEmailSubmissionSetResponse,
+
+	"EmailSubmission/changes": unmarshalEmailSubmissionChangesResponse,
+
+	"VacationResponse/get": unmarshalVacationResponseGetResponse,
+	"VacationResponse/set": unmarshalVacationResponseSetResponse,
+
+	"MDN/send":  unmarshalMDNSendResponse,
+	"MDN/parse": unmarshalMDNParseResponse,
+}
+
+// MethodCapabilities maps data types implemented by this package to
+// capabilities that define them.
+//
+// Pass it to client.EnableMethodCapabilities to let the client determine
+// which capabilities can be dropped from requests during capability
+// downgrade.
+var MethodCapabilities = map[string]string{
+	"Email":         jmap.MailCapabilityName,
+	"Mailbox":       jmap.MailCapabilityName,
+	"Thread":        jmap.MailCapabilityName,
+	"SearchSnippet": jmap.MailCapabilityName,
+
+	"Identity":        jmap.SubmissionCapabilityName,
+	"EmailSubmission": jmap.SubmissionCapabilityName,
+
+	"VacationResponse": VacationResponseCapabilityName,
+
+	"MDN": MDNCapabilityName,
+}
+
+func init() {
+	// Other push types of JMAP Mail use capabilities defined in package jmap
+	// and are listed in jmap.PushTypeCapabilities already.
+	jmap.RegisterPushTypes(map[string]string{
+		jmap.PushTypeVacationResponse: VacationResponseCapabilityName,
+	})
+}
@@ -0,0 +1,273 @@
+package mail
+
+import (
+	"encoding/json"
+
+	"github.com/foxcpp/go-jmap"
+)
+
+// Mailbox roles registered in the IANA "IMAP Mailbox Name Attributes"
+// registry.
+const (
+	RoleAll        = "all"
+	RoleArchive    = "archive"
+	RoleDrafts     = "drafts"
+	RoleFlagged    = "flagged"
+	RoleImportant  = "important"
+	RoleInbox      = "inbox"
+	RoleJunk       = "junk"
+	RoleSent       = "sent"
+	RoleSubscribed = "subscribed"
+	RoleTrash      = "trash"
+)
+
+// MailboxRights describes the set of rights (Access Control List (ACL)
+// properties) the user has on the Mailbox.
+type MailboxRights struct {
+	// If true, the user may use this Mailbox as part of a filter in an
+	// Email/query call, and the Mailbox may be included in the mailboxIds
+	// property of Email objects.
+	MayReadItems bool `json:"mayReadItems"`
+
+	// The user may add mail to this Mailbox (by either creating a new Email
+	// or moving an existing one).
+	MayAddItems bool `json:"mayAddItems"`
+
+	// The user may remove mail from this Mailbox (by either changing the
+	// Mailboxes of an Email or destroying the Email).
+	MayRemoveItems bool `json:"mayRemoveItems"`
+
+	// The user may add or remove the $seen keyword to/from an Email.
+	MaySetSeen bool `json:"maySetSeen"`
+
+	// The user may add or remove any keyword other than $seen to/from an
+	// Email.
+	MaySetKeywords bool `json:"maySetKeywords"`
+
+	// The user may create a Mailbox with this Mailbox as its parent.
+	MayCreateChild bool `json:"mayCreateChild"`
+
+	// The user may rename the Mailbox or make it a child of another Mailbox.
+	MayRename bool `json:"mayRename"`
+
+	// The user may delete the Mailbox itself.
+	MayDelete bool `json:"mayDelete"`
+
+	// Messages may be submitted directly to this Mailbox.
+	MaySubmit bool `json:"maySubmit"`
+
+	// The user may modify the shareWith property of the Mailbox. Defined
+	// in RFC 9670.
+	MayShare bool `json:"mayShare"`
+}
+
+// Mailbox represents a named set of Emails.
+//
+// See RFC 8621, section 2 for details.
+type Mailbox struct {
+	// The id of the Mailbox.
+	ID jmap.ID `json:"id,omitempty"`
+
+	// User-visible name for the Mailbox, e.g., "Inbox".
+	Name string `json:"name,omitempty"`
+
+	// The Mailbox id for the parent of this Mailbox, or empty if this Mailbox
+	// is at the top level.
+	ParentID jmap.ID `json:"parentId,omitempty"`
+
+	// Identifies Mailboxes that have a particular common purpose (e.g., the
+	// "inbox"), regardless of the name.
+	Role string `json:"role,omitempty"`
+
+	// Defines the sort order of Mailboxes when presented in the client's UI,
+	// so it is consistent between devices. Mailboxes with equal order SHOULD
+	// be sorted in alphabetical order by name.
+	SortOrder jmap.UnsignedInt `json:"sortOrder,omitempty"`
+
+	// The number of Emails in this Mailbox. Set by server.
+	TotalEmails jmap.UnsignedInt `json:"totalEmails,omitempty"`
+
+	// The number of Emails in this Mailbox that have neither the $seen
+	// keyword nor the $draft keyword. Set by server.
+	UnreadEmails jmap.UnsignedInt `json:"unreadEmails,omitempty"`
+
+	// The number of Threads where at least one Email in the Thread is in this
+	// Mailbox. Set by server.
+	TotalThreads jmap.UnsignedInt `json:"totalThreads,omitempty"`
+
+	// An indication of the number of "unread" Threads in the Mailbox. Set by
+	// server.
+	UnreadThreads jmap.UnsignedInt `json:"unreadThreads,omitempty"`
+
+	// The set of rights the user has on the Mailbox. Set by server.
+	MyRights *MailboxRights `json:"myRights,omitempty"`
+
+	// Has the user indicated they wish to see this Mailbox in their client?
+	IsSubscribed bool `json:"isSubscribed,omitempty"`
+
+	// A map of principal id to rights for principals this Mailbox is shared
+	// with. Nil if the Mailbox is not shared. Defined in RFC 9670, use
+	// MailboxShareWithPatch to change it.
+	ShareWith map[jmap.ID]MailboxRights `json:"shareWith,omitempty"`
+}
+
+// MailboxGetArgs contains arguments for Mailbox/get method call.
+type MailboxGetArgs struct {
+	// The id of the account to use.
+	AccountID jmap.ID `json:"accountId"`
+
+	// The ids of the Mailbox objects to return. If nil, then all records
+	// are returned.
+	IDs []jmap.ID `json:"ids"`
+
+	// If not nil, only the properties listed in the array are returned for
+	// each Mailbox object.
+	Properties []string `json:"properties"`
+}
+
+// MailboxGetResponse contains results of Mailbox/get method call.
+type MailboxGetResponse struct {
+	// The id of the account used for the call.
+	AccountID jmap.ID `json:"accountId"`
+
+	// A string representing the state on the server for all the data of this
+	// type in the account.
+	State string `json:"state"`
+
+	// An array of the Mailbox objects requested.
+	List []Mailbox `json:"list"`
+
+	// This array contains the ids passed to the method for records that do
+	// not exist.
+	NotFound []jmap.ID `json:"notFound"`
+}
+
+// MailboxSetArgs contains arguments for Mailbox/set method call.
+type MailboxSetArgs struct {
+	// The id of the account to use.
+	AccountID jmap.ID `json:"accountId"`
+
+	// If not empty, the method will be aborted and a stateMismatch error
+	// returned if the current state does not match this string.
+	IfInState string `json:"ifInState,omitempty"`
+
+	// A map of creation id to Mailbox objects.
+	Create map[jmap.ID]Mailbox `json:"create,omitempty"`
+
+	// A map of id to a patch object to apply to the current Mailbox object
+	// with that id.
+	Update map[jmap.ID]jmap.PatchObject `json:"update,omitempty"`
+
+	// A list of ids for Mailbox objects to permanently delete.
+	Destroy []jmap.ID `json:"destroy,omitempty"`
+
+	// If false, any attempt to destroy a Mailbox that still has Emails in it
+	// will be rejected with a mailboxHasEmail SetError. If true, any Emails
+	// that were in the Mailbox will be removed from it, and if in no other
+	// Mailboxes, they will be destroyed when the Mailbox is destroyed.
+	OnDestroyRemoveEmails bool `json:"onDestroyRemoveEmails,omitempty"`
+}
+
+// MailboxSetResponse contains results of Mailbox/set method call.
+type MailboxSetResponse struct {
+	// The id of the account used for the call.
+	AccountID jmap.ID `json:"accountId"`
+
+	// The state string that would have been returned by Mailbox/get before
+	// making the requested changes.
+	OldState string `json:"oldState"`
+
+	// The state string that will now be returned by Mailbox/get.
+	NewState string `json:"newState"`
+
+	// A map of the creation id to an object containing any properties of the
+	// created Mailbox object that were not sent by the client.
+	Created map[jmap.ID]Mailbox `json:"created"`
+
+	// The keys in this map are the ids of all Mailboxes that were
+	// successfully updated. The value is a Mailbox object containing any
+	// property that changed in a way not explicitly requested, or nil if
+	// none.
+	Updated map[jmap.ID]*Mailbox `json:"updated"`
+
+	// A list of Mailbox ids for records that were successfully destroyed.
+	Destroyed []jmap.ID `json:"destroyed"`
+
+	// A map of creation id to a SetError object for each record that failed
+	// to be created.
+	NotCreated map[jmap.ID]jmap.SetError `json:"notCreated"`
+
+	// A map of Mailbox id to a SetError object for each record that failed
+	// to be updated.
+	NotUpdated map[jmap.ID]jmap.SetError `json:"notUpdated"`
+
+	// A map of Mailbox id to a SetError object for each record that failed
+	// to be destroyed.
+	NotDestroyed map[jmap.ID]jmap.SetError `json:"notDestroyed"`
+}
+
+// MailboxChangesArgs contains arguments for Mailbox/changes method call.
+type MailboxChangesArgs struct {
+	// The id of the account to use.
+	AccountID jmap.ID `json:"accountId"`
+
+	// The current state of the client, as returned by Mailbox/get.
+	SinceState string `json:"sinceState"`
+
+	// The maximum number of ids to return in the response. Zero means no
+	// limit.
+	MaxChanges jmap.UnsignedInt `json:"maxChanges,omitempty"`
+}
+
+// MailboxChangesResponse contains results of Mailbox/changes method call.
+type MailboxChangesResponse struct {
+	// The id of the account used for the call.
+	AccountID jmap.ID `json:"accountId"`
+
+	// This is the sinceState argument echoed back.
+	OldState string `json:"oldState"`
+
+	// This is the state the client will be in after applying the set of
+	// changes to the old state.
+	NewState string `json:"newState"`
+
+	// If true, the client may call Mailbox/changes again with the NewState
+	// returned to get further updates.
+	HasMoreChanges bool `json:"hasMoreChanges"`
+
+	// An array of ids for records that have been created since the old
+	// state.
+	Created []jmap.ID `json:"created"`
+
+	// An array of ids for records that have been updated since the old
+	// state.
+	Updated []jmap.ID `json:"updated"`
+
+	// An array of ids for records that have been destroyed since the old
+	// state.
+	Destroyed []jmap.ID `json:"destroyed"`
+
+	// If only the "totalEmails", "unreadEmails", "totalThreads", and/or
+	// "unreadThreads" Mailbox properties have changed since the old state,
+	// this will be the list of properties that may have changed. If the
+	// server is unable to tell if only counts have changed, it is nil.
+	UpdatedProperties []string `json:"updatedProperties"`
+}
+
+func unmarshalMailboxChangesResponse(args json.RawMessage) (interface{}, error) {
+	resp := MailboxChangesResponse{}
+	err := json.Unmarshal(args, &resp)
+	return resp, err
+}
+
+func unmarshalMailboxGetResponse(args json.RawMessage) (interface{}, error) {
+	resp := MailboxGetResponse{}
+	err := json.Unmarshal(args, &resp)
+	return resp, err
+}
+
+func unmarshalMailboxSetResponse(args json.RawMessage) (interface{}, error) {
+	resp := MailboxSetResponse{}
+	err := json.Unmarshal(args, &resp)
+	return resp, err
+}
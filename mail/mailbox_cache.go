@@ -0,0 +1,200 @@
+package mail
+
+import (
+	"fmt"
+
+	"github.com/foxcpp/go-jmap"
+	"github.com/foxcpp/go-jmap/client"
+)
+
+// MailboxCache keeps the local copy of all Mailboxes of the account and
+// brings it up to date using Mailbox/changes.
+//
+// When the server indicates that only counters (totalEmails, unreadEmails,
+// totalThreads, unreadThreads) have changed, only these properties are
+// refetched, which is the common case when Emails arrive or are read.
+//
+// Zero value is an empty cache that is filled using Mailbox/get on the first
+// Refresh.
+type MailboxCache struct {
+	// State of the cached data as returned by Mailbox/get.
+	State string
+
+	Mailboxes map[jmap.ID]*Mailbox
+}
+
+var mailboxCounterProps = map[string]bool{
+	"totalEmails":   true,
+	"unreadEmails":  true,
+	"totalThreads":  true,
+	"unreadThreads": true,
+}
+
+// Refresh brings the cache up to date.
+//
+// If the server can't calculate changes since the cached state, all
+// Mailboxes are fetched again.
+//
+// The client must have ResponseUnmarshallers enabled.
+func (mc *MailboxCache) Refresh(c *client.Client, account jmap.ID) error {
+	if mc.State == "" {
+		return mc.reload(c, account)
+	}
+
+	for {
+		more, err := mc.applyChanges(c, account)
+		if err != nil {
+			if methodErr, ok := err.(jmap.MethodErrorArgs); ok && methodErr.Type == jmap.CodeCannotCalculateChanges {
+				return mc.reload(c, account)
+			}
+			return err
+		}
+		if !more {
+			return nil
+		}
+	}
+}
+
+func (mc *MailboxCache) reload(c *client.Client, account jmap.ID) error {
+	resp, err := getMailboxes(c, MailboxGetArgs{AccountID: account})
+	if err != nil {
+		return err
+	}
+	mc.Mailboxes = make(map[jmap.ID]*Mailbox, len(resp.List))
+	for i := range resp.List {
+		mc.Mailboxes[resp.List[i].ID] = &resp.List[i]
+	}
+	mc.State = resp.State
+	return nil
+}
+
+// applyChanges fetches and applies a single batch of changes, returning
+// whether there are more.
+func (mc *MailboxCache) applyChanges(c *client.Client, account jmap.ID) (bool, error) {
+	// Mailbox/changes is followed by Mailbox/get for created records and
+	// Mailbox/get for updated records restricted to updatedProperties, as
+	// suggested by RFC 8621, section 2.2.
+	b := &client.Batch{}
+	useMail(b)
+	changesCall := b.NextCallID()
+	b.Add("Mailbox/changes", MailboxChangesArgs{
+		AccountID:  account,
+		SinceState: mc.State,
+	})
+	createdCall := b.NextCallID()
+	b.Add("Mailbox/get", map[string]interface{}{
+		"accountId": account,
+		"#ids": jmap.ResultReference{
+			ResultOf: changesCall,
+			Name:     "Mailbox/changes",
+			Path:     "/created",
+		},
+	})
+	updatedCall := b.NextCallID()
+	b.Add("Mailbox/get", map[string]interface{}{
+		"accountId": account,
+		"#ids": jmap.ResultReference{
+			ResultOf: changesCall,
+			Name:     "Mailbox/changes",
+			Path:     "/updated",
+		},
+		"#properties": jmap.ResultReference{
+			ResultOf: changesCall,
+			Name:     "Mailbox/changes",
+			Path:     "/updatedProperties",
+		},
+	})
+
+	resp, err := c.RawSend(b.Request())
+	if err != nil {
+		return false, err
+	}
+
+	var (
+		changes          MailboxChangesResponse
+		created, updated MailboxGetResponse
+	)
+	for _, inv := range resp.Responses {
+		if methodErr, ok := inv.Args.(jmap.MethodErrorArgs); ok {
+			return false, methodErr
+		}
+		var ok bool
+		switch inv.CallID {
+		case changesCall:
+			changes, ok = inv.Args.(MailboxChangesResponse)
+		case createdCall:
+			created, ok = inv.Args.(MailboxGetResponse)
+		case updatedCall:
+			updated, ok = inv.Args.(MailboxGetResponse)
+		default:
+			continue
+		}
+		if !ok {
+			return false, unexpectedResponse(inv.Name, inv.Args)
+		}
+	}
+	if changes.NewState == "" {
+		return false, fmt.Errorf("jmap/mail: no Mailbox/changes response")
+	}
+
+	if mc.Mailboxes == nil {
+		mc.Mailboxes = make(map[jmap.ID]*Mailbox)
+	}
+	for _, id := range changes.Destroyed {
+		delete(mc.Mailboxes, id)
+	}
+	for i := range created.List {
+		mc.Mailboxes[created.List[i].ID] = &created.List[i]
+	}
+
+	var refetch []jmap.ID
+	for i := range updated.List {
+		mbox := &updated.List[i]
+		if changes.UpdatedProperties == nil {
+			mc.Mailboxes[mbox.ID] = mbox
+			continue
+		}
+		if !mergeMailboxCounters(mc.Mailboxes[mbox.ID], mbox, changes.UpdatedProperties) {
+			refetch = append(refetch, mbox.ID)
+		}
+	}
+	if len(refetch) != 0 {
+		resp, err := getMailboxes(c, MailboxGetArgs{AccountID: account, IDs: refetch})
+		if err != nil {
+			return false, err
+		}
+		for i := range resp.List {
+			mc.Mailboxes[resp.List[i].ID] = &resp.List[i]
+		}
+	}
+
+	mc.State = changes.NewState
+	return changes.HasMoreChanges, nil
+}
+
+// mergeMailboxCounters copies counter properties from src to dst. It returns
+// false if dst is nil or props contain anything else, in which case the
+// Mailbox should be refetched completely.
+func mergeMailboxCounters(dst, src *Mailbox, props []string) bool {
+	if dst == nil {
+		return false
+	}
+	for _, prop := range props {
+		if !mailboxCounterProps[prop] {
+			return false
+		}
+	}
+	for _, prop := range props {
+		switch prop {
+		case "totalEmails":
+			dst.TotalEmails = src.TotalEmails
+		case "unreadEmails":
+			dst.UnreadEmails = src.UnreadEmails
+		case "totalThreads":
+			dst.TotalThreads = src.TotalThreads
+		case "unreadThreads":
+			dst.UnreadThreads = src.UnreadThreads
+		}
+	}
+	return true
+}
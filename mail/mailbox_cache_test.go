@@ -0,0 +1,82 @@
+package mail
+
+import (
+	"testing"
+
+	"github.com/foxcpp/go-jmap"
+	"gotest.tools/assert"
+	"gotest.tools/assert/cmp"
+)
+
+func TestMailboxCacheRefresh(t *testing.T) {
+	var (
+		updatedProps interface{}
+		fullGets     int
+	)
+	c, srv := newTestClient(t, func(name string, args map[string]interface{}) []testResponse {
+		switch name {
+		case "Mailbox/changes":
+			return []testResponse{{name, map[string]interface{}{
+				"oldState":          args["sinceState"],
+				"newState":          "2",
+				"created":           []interface{}{"M3"},
+				"updated":           []interface{}{"M1"},
+				"destroyed":         []interface{}{"M2"},
+				"updatedProperties": updatedProps,
+			}}}
+		case "Mailbox/get":
+			if _, ok := args["#properties"]; ok {
+				return []testResponse{{name, map[string]interface{}{
+					"state": "2",
+					"list":  []interface{}{map[string]interface{}{"id": "M1", "unreadEmails": 5}},
+				}}}
+			}
+			if _, ok := args["#ids"]; ok {
+				return []testResponse{{name, map[string]interface{}{
+					"state": "2",
+					"list":  []interface{}{map[string]interface{}{"id": "M3", "name": "New"}},
+				}}}
+			}
+			fullGets++
+			return []testResponse{{name, map[string]interface{}{
+				"state": "1",
+				"list": []interface{}{
+					map[string]interface{}{"id": "M1", "name": "Inbox", "unreadEmails": 1},
+					map[string]interface{}{"id": "M2", "name": "Old"},
+				},
+			}}}
+		}
+		t.Fatalf("unexpected call: %s", name)
+		return nil
+	})
+	defer srv.Close()
+
+	t.Run("counters only", func(t *testing.T) {
+		updatedProps = []interface{}{"unreadEmails"}
+		fullGets = 0
+
+		var mc MailboxCache
+		assert.NilError(t, mc.Refresh(c, "A1"))
+		assert.Check(t, cmp.Equal("1", mc.State))
+		assert.Check(t, cmp.Len(mc.Mailboxes, 2))
+
+		assert.NilError(t, mc.Refresh(c, "A1"))
+		assert.Check(t, cmp.Equal("2", mc.State))
+		assert.Check(t, cmp.Equal(1, fullGets))
+		assert.Assert(t, cmp.Len(mc.Mailboxes, 2))
+		assert.Check(t, cmp.Equal("Inbox", mc.Mailboxes["M1"].Name))
+		assert.Check(t, cmp.Equal(jmap.UnsignedInt(5), mc.Mailboxes["M1"].UnreadEmails))
+		assert.Check(t, cmp.Equal("New", mc.Mailboxes["M3"].Name))
+	})
+
+	t.Run("other properties changed", func(t *testing.T) {
+		updatedProps = []interface{}{"unreadEmails", "name"}
+		fullGets = 0
+
+		var mc MailboxCache
+		assert.NilError(t, mc.Refresh(c, "A1"))
+		assert.NilError(t, mc.Refresh(c, "A1"))
+		assert.Check(t, cmp.Equal(2, fullGets))
+		assert.Check(t, cmp.Equal("Inbox", mc.Mailboxes["M1"].Name))
+	})
+}
@@ -0,0 +1,317 @@
+package mail
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/foxcpp/go-jmap"
+	"github.com/foxcpp/go-jmap/client"
+)
+
+var (
+	ErrMailboxNotFound     = errors.New("jmap/mail: mailbox not found")
+	ErrMailboxCycle        = errors.New("jmap/mail: mailbox can't be moved into itself or its descendant")
+	ErrTopLevelForbidden   = errors.New("jmap/mail: server does not allow top-level mailboxes to be created")
+	ErrMailboxTooDeep      = errors.New("jmap/mail: mailbox hierarchy would exceed maxMailboxDepth")
+	ErrMailboxNameTooLong  = errors.New("jmap/mail: mailbox name exceeds maxSizeMailboxName")
+	ErrMailboxNameEmpty    = errors.New("jmap/mail: mailbox name is empty")
+	ErrMailboxNameExists   = errors.New("jmap/mail: mailbox with the same name already exists under the parent")
+	ErrMailboxRenameDenied = errors.New("jmap/mail: no permission to rename or move the mailbox")
+	ErrMailboxChildDenied  = errors.New("jmap/mail: no permission to create children in the parent mailbox")
+)
+
+// MailboxMoveError is returned by MoveMailbox and RenameMailbox if the
+// operation was rejected either by local validation or by the server.
+type MailboxMoveError struct {
+	// The id of the Mailbox being moved.
+	Mailbox jmap.ID
+
+	// Human-readable explanation of the failure.
+	Reason string
+
+	// Either one of the Err* values defined by this package (for locally
+	// detected problems) or jmap.SetError returned by the server.
+	Err error
+}
+
+func (mme *MailboxMoveError) Error() string {
+	return fmt.Sprintf("jmap/mail: cannot move mailbox %s: %s", mme.Mailbox, mme.Reason)
+}
+
+func (mme *MailboxMoveError) Unwrap() error {
+	return mme.Err
+}
+
+// CheckMailboxMove verifies that the Mailbox with the specified id can be
+// placed under newParent (empty means top level) with the name newName
+// without violating the limits of mailCap or making the hierarchy cyclic.
+//
+// mailboxes should contain all Mailboxes of the account, with at least id,
+// name, parentId and myRights properties. mailCap can be nil, in which case
+// limits are not checked.
+//
+// Returned error is one of the Err* values defined by this package.
+func CheckMailboxMove(mailboxes []Mailbox, mailCap *jmap.MailCapability, id, newParent jmap.ID, newName string) error {
+	byID := make(map[jmap.ID]*Mailbox, len(mailboxes))
+	for i := range mailboxes {
+		byID[mailboxes[i].ID] = &mailboxes[i]
+	}
+
+	mbox, ok := byID[id]
+	if !ok {
+		return ErrMailboxNotFound
+	}
+	if mbox.MyRights != nil && !mbox.MyRights.MayRename {
+		return ErrMailboxRenameDenied
+	}
+
+	if newName == "" {
+		return ErrMailboxNameEmpty
+	}
+	if mailCap != nil && mailCap.MaxSizeMailboxName != 0 &&
+		jmap.UnsignedInt(len(newName)) > mailCap.MaxSizeMailboxName {
+		return ErrMailboxNameTooLong
+	}
+
+	// Depth of the new parent, 0 for top level.
+	parentDepth := 0
+	if newParent == "" {
+		if mailCap != nil && !mailCap.MayCreateTopLevelMailbox && mbox.ParentID != "" {
+			return ErrTopLevelForbidden
+		}
+	} else {
+		parent, ok := byID[newParent]
+		if !ok {
+			return ErrMailboxNotFound
+		}
+		if parent.MyRights != nil && !parent.MyRights.MayCreateChild && mbox.ParentID != newParent {
+			return ErrMailboxChildDenied
+		}
+
+		seen := make(map[jmap.ID]bool)
+		for cur := parent; cur != nil; cur = byID[cur.ParentID] {
+			if cur.ID == id {
+				return ErrMailboxCycle
+			}
+			if seen[cur.ID] {
+				// Hierarchy is already broken on the server side, don't loop
+				// forever.
+				return ErrMailboxCycle
+			}
+			seen[cur.ID] = true
+			parentDepth++
+			if cur.ParentID == "" {
+				break
+			}
+		}
+	}
+
+	for _, other := range mailboxes {
+		if other.ID != id && other.ParentID == newParent && other.Name == newName {
+			return ErrMailboxNameExists
+		}
+	}
+
+	if mailCap != nil && mailCap.MaxMailboxDepth != nil {
+		depth := parentDepth + 1 + subtreeHeight(mailboxes, id)
+		if jmap.UnsignedInt(depth) > *mailCap.MaxMailboxDepth {
+			return ErrMailboxTooDeep
+		}
+	}
+
+	return nil
+}
+
+// subtreeHeight returns the number of levels below the Mailbox with the
+// specified id.
+func subtreeHeight(mailboxes []Mailbox, id jmap.ID) int {
+	children := make(map[jmap.ID][]jmap.ID)
+	for _, mbox := range mailboxes {
+		if mbox.ParentID != "" {
+			children[mbox.ParentID] = append(children[mbox.ParentID], mbox.ID)
+		}
+	}
+
+	var height func(id jmap.ID, visited map[jmap.ID]bool) int
+	height = func(id jmap.ID, visited map[jmap.ID]bool) int {
+		if visited[id] {
+			return 0
+		}
+		visited[id] = true
+		res := 0
+		for _, child := range children[id] {
+			if h := height(child, visited) + 1; h > res {
+				res = h
+			}
+		}
+		return res
+	}
+	return height(id, make(map[jmap.ID]bool))
+}
+
+func getMailboxes(c *client.Client, args MailboxGetArgs) (*MailboxGetResponse, error) {
+	respArgs, err := c.Call(mailUsing, "Mailbox/get", args)
+	if err != nil {
+		return nil, err
+	}
+	resp, ok := respArgs.(MailboxGetResponse)
+	if !ok {
+		return nil, unexpectedResponse("Mailbox/get", respArgs)
+	}
+	return &resp, nil
+}
+
+func setMailboxes(c *client.Client, args MailboxSetArgs) (*MailboxSetResponse, error) {
+	respArgs, err := c.Call(mailUsing, "Mailbox/set", args)
+	if err != nil {
+		return nil, err
+	}
+	resp, ok := respArgs.(MailboxSetResponse)
+	if !ok {
+		return nil, unexpectedResponse("Mailbox/set", respArgs)
+	}
+	return &resp, nil
+}
+
+// MoveMailbox makes the Mailbox with the specified id a child of newParent
+// (or a top-level Mailbox if newParent is empty), optionally renaming it. If
+// newName is empty, the current name is kept.
+//
+// The move is validated using CheckMailboxMove before issuing Mailbox/set.
+// Both local validation failures and errors reported by the server are
+// returned as *MailboxMoveError.
+//
+// The client must have ResponseUnmarshallers enabled.
+func MoveMailbox(c *client.Client, account, id, newParent jmap.ID, newName string) error {
+	return relocateMailbox(c, account, id, &newParent, newName)
+}
+
+// RenameMailbox changes the name of the Mailbox keeping it under the same
+// parent.
+//
+// See MoveMailbox for details.
+func RenameMailbox(c *client.Client, account, id jmap.ID, newName string) error {
+	return relocateMailbox(c, account, id, nil, newName)
+}
+
+func relocateMailbox(c *client.Client, account, id jmap.ID, newParent *jmap.ID, newName string) error {
+	session, err := c.CurrentSession()
+	if err != nil {
+		return err
+	}
+	mailCap, err := session.EffectiveMailCapability(account)
+	if err != nil {
+		return err
+	}
+
+	resp, err := getMailboxes(c, MailboxGetArgs{
+		AccountID:  account,
+		Properties: []string{"id", "name", "parentId", "myRights"},
+	})
+	if err != nil {
+		return err
+	}
+
+	var current *Mailbox
+	for i := range resp.List {
+		if resp.List[i].ID == id {
+			current = &resp.List[i]
+			break
+		}
+	}
+	if current == nil {
+		return &MailboxMoveError{Mailbox: id, Reason: "mailbox does not exist", Err: ErrMailboxNotFound}
+	}
+
+	parent := current.ParentID
+	if newParent != nil {
+		parent = *newParent
+	}
+	name := current.Name
+	if newName != "" {
+		name = newName
+	}
+
+	if err := CheckMailboxMove(resp.List, mailCap, id, parent, name); err != nil {
+		return &MailboxMoveError{Mailbox: id, Reason: moveErrReason(err), Err: err}
+	}
+
+	patch := jmap.PatchObject{}
+	if parent != current.ParentID {
+		if parent == "" {
+			patch["parentId"] = nil
+		} else {
+			patch["parentId"] = parent
+		}
+	}
+	if name != current.Name {
+		patch["name"] = name
+	}
+	if len(patch) == 0 {
+		return nil
+	}
+
+	setResp, err := setMailboxes(c, MailboxSetArgs{
+		AccountID: account,
+		IfInState: resp.State,
+		Update:    map[jmap.ID]jmap.PatchObject{id: patch},
+	})
+	if err != nil {
+		return err
+	}
+	if setErr, ok := setResp.NotUpdated[id]; ok {
+		return &MailboxMoveError{Mailbox: id, Reason: explainMoveSetError(setErr), Err: setErr}
+	}
+	return nil
+}
+
+func moveErrReason(err error) string {
+	switch err {
+	case ErrMailboxNotFound:
+		return "mailbox or its new parent does not exist"
+	case ErrMailboxCycle:
+		return "a mailbox can't be placed inside itself or one of its sub-mailboxes"
+	case ErrTopLevelForbidden:
+		return "the server does not allow creating top-level mailboxes"
+	case ErrMailboxTooDeep:
+		return "the resulting folder hierarchy would be nested too deeply"
+	case ErrMailboxNameTooLong:
+		return "the name is too long"
+	case ErrMailboxNameEmpty:
+		return "the name can't be empty"
+	case ErrMailboxNameExists:
+		return "a mailbox with this name already exists in that location"
+	case ErrMailboxRenameDenied:
+		return "you don't have permission to rename or move this mailbox"
+	case ErrMailboxChildDenied:
+		return "you don't have permission to create sub-mailboxes in the new parent"
+	default:
+		return err.Error()
+	}
+}
+
+// explainMoveSetError converts SetError returned by the server for the
+// Mailbox/set update into a human-readable explanation.
+func explainMoveSetError(err jmap.SetError) string {
+	switch err.Type {
+	case jmap.CodeForbidden:
+		return "you don't have permission to rename or move this mailbox"
+	case jmap.CodeNotFound:
+		return "mailbox does not exist"
+	case jmap.CodeAlreadyExists:
+		return "a mailbox with this name already exists in that location"
+	case jmap.CodeInvalidProperties:
+		for _, prop := range err.Properties {
+			switch prop {
+			case "parentId":
+				return "the new parent can't contain this mailbox (it may not exist, be a sub-mailbox of it, or be nested too deeply)"
+			case "name":
+				return "the name is invalid or already used in that location"
+			}
+		}
+	}
+	if err.Description != "" {
+		return err.Description
+	}
+	return "server rejected the change: " + string(err.Type)
+}
@@ -0,0 +1,62 @@
+package mail
+
+import (
+	"testing"
+
+	"github.com/foxcpp/go-jmap"
+	"gotest.tools/assert"
+)
+
+func TestCheckMailboxMove(t *testing.T) {
+	// A
+	// └─ B
+	//    └─ C
+	// D
+	// E
+	mailboxes := []Mailbox{
+		{ID: "A", Name: "A"},
+		{ID: "B", Name: "B", ParentID: "A"},
+		{ID: "C", Name: "C", ParentID: "B"},
+		{ID: "D", Name: "D", MyRights: &MailboxRights{MayCreateChild: true}},
+		{ID: "E", Name: "E", MyRights: &MailboxRights{MayRename: true}},
+	}
+	depth := jmap.UnsignedInt(3)
+	mailCap := &jmap.MailCapability{
+		MaxMailboxDepth:          &depth,
+		MaxSizeMailboxName:       10,
+		MayCreateTopLevelMailbox: false,
+	}
+
+	cases := []struct {
+		name      string
+		id        jmap.ID
+		newParent jmap.ID
+		newName   string
+		err       error
+	}{
+		{"rename", "C", "B", "C2", nil},
+		{"move up", "C", "A", "C", nil},
+		{"into itself", "A", "A", "A", ErrMailboxCycle},
+		{"into descendant", "A", "C", "A", ErrMailboxCycle},
+		{"to top level", "B", "", "B", ErrTopLevelForbidden},
+		{"keep top level", "A", "", "A2", nil},
+		{"too deep subtree", "A", "D", "A", ErrMailboxTooDeep},
+		{"name too long", "C", "B", "0123456789A", ErrMailboxNameTooLong},
+		{"name exists", "C", "A", "B", ErrMailboxNameExists},
+		{"empty name", "C", "B", "", ErrMailboxNameEmpty},
+		{"no rights", "D", "A", "D", ErrMailboxRenameDenied},
+		{"child denied", "C", "E", "C", ErrMailboxChildDenied},
+		{"unknown parent", "C", "X", "C", ErrMailboxNotFound},
+	}
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			err := CheckMailboxMove(mailboxes, mailCap, c.id, c.newParent, c.newName)
+			assert.Equal(t, c.err, err)
+		})
+	}
+
+	t.Run("no limits", func(t *testing.T) {
+		assert.NilError(t, CheckMailboxMove(mailboxes, nil, "B", "", "B"))
+	})
+}
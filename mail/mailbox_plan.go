@@ -0,0 +1,365 @@
+package mail
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/foxcpp/go-jmap"
+	"github.com/foxcpp/go-jmap/client"
+)
+
+var (
+	ErrMailboxHasChild = errors.New("jmap/mail: mailbox has child mailboxes")
+	ErrMailboxHasEmail = errors.New("jmap/mail: mailbox has emails")
+)
+
+// Values of MailboxSetError.Op.
+const (
+	MailboxOpCreate  = "create"
+	MailboxOpUpdate  = "update"
+	MailboxOpDestroy = "destroy"
+)
+
+// MailboxSetError describes the failure of a single operation in
+// MailboxPlan.
+type MailboxSetError struct {
+	// Key passed to MailboxPlan.Create for creations, Mailbox id otherwise.
+	Mailbox jmap.ID
+
+	// One of MailboxOpCreate, MailboxOpUpdate, MailboxOpDestroy.
+	Op string
+
+	// Either ErrMailboxHasChild, ErrMailboxHasEmail or jmap.SetError
+	// returned by the server.
+	Err error
+}
+
+func (mse *MailboxSetError) Error() string {
+	return fmt.Sprintf("jmap/mail: cannot %s mailbox %s: %v", mse.Op, mse.Mailbox, mse.Err)
+}
+
+func (mse *MailboxSetError) Unwrap() error {
+	return mse.Err
+}
+
+func newMailboxSetError(mailbox jmap.ID, op string, setErr jmap.SetError) *MailboxSetError {
+	mse := &MailboxSetError{Mailbox: mailbox, Op: op, Err: setErr}
+	switch setErr.Type {
+	case jmap.CodeMailboxHasChild:
+		mse.Err = ErrMailboxHasChild
+	case jmap.CodeMailboxHasEmail:
+		mse.Err = ErrMailboxHasEmail
+	}
+	return mse
+}
+
+type mailboxCreate struct {
+	key  jmap.ID
+	mbox Mailbox
+}
+
+// MailboxPlan collects Mailbox creations, updates and destructions and
+// applies them in a single request, ordered so the server can process them:
+// parents are created before their children, and children are destroyed
+// before their parents.
+//
+// Mailboxes to be created are identified by caller-chosen keys. The key can
+// be used as ParentID of other created Mailboxes and in Move, it is replaced
+// with the creation reference automatically.
+//
+// Zero value is an empty plan ready to use.
+type MailboxPlan struct {
+	// If true, Emails in destroyed Mailboxes are removed from them (and
+	// destroyed if they are in no other Mailbox). Otherwise destruction of
+	// non-empty Mailboxes fails with ErrMailboxHasEmail.
+	OnDestroyRemoveEmails bool
+
+	creates []mailboxCreate
+	updates map[jmap.ID]jmap.PatchObject
+	destroy []jmap.ID
+}
+
+// MailboxPlanResult contains the outcome of MailboxPlan.Apply.
+type MailboxPlanResult struct {
+	// Ids of created Mailboxes, keyed by keys passed to Create.
+	Created map[jmap.ID]jmap.ID
+
+	// Failed operations, in order they were submitted to the server.
+	Errors []*MailboxSetError
+}
+
+// Create adds the Mailbox creation to the plan. mbox.ParentID can be either
+// an id of the existing Mailbox or a key of another Mailbox created by the
+// plan.
+func (p *MailboxPlan) Create(key jmap.ID, mbox Mailbox) {
+	p.creates = append(p.creates, mailboxCreate{key: key, mbox: mbox})
+}
+
+// Update adds the patch for the existing Mailbox to the plan. It is merged
+// with patches previously added for the same Mailbox.
+func (p *MailboxPlan) Update(id jmap.ID, patch jmap.PatchObject) {
+	if p.updates == nil {
+		p.updates = make(map[jmap.ID]jmap.PatchObject)
+	}
+	p.updates[id] = MergePatches(p.updates[id], patch)
+}
+
+// Rename adds the rename of the existing Mailbox to the plan.
+func (p *MailboxPlan) Rename(id jmap.ID, name string) {
+	p.Update(id, jmap.PatchObject{"name": name})
+}
+
+// Move adds the move of the existing Mailbox under newParent (empty means
+// top level) to the plan. newParent can be a key of the Mailbox created by
+// the plan.
+func (p *MailboxPlan) Move(id, newParent jmap.ID) {
+	if newParent == "" {
+		p.Update(id, jmap.PatchObject{"parentId": nil})
+		return
+	}
+	p.Update(id, jmap.PatchObject{"parentId": newParent})
+}
+
+// Destroy adds destruction of existing Mailboxes to the plan.
+func (p *MailboxPlan) Destroy(ids ...jmap.ID) {
+	p.destroy = append(p.destroy, ids...)
+}
+
+// createLevels groups creations by their depth in the hierarchy of created
+// Mailboxes.
+func (p *MailboxPlan) createLevels() ([][]mailboxCreate, error) {
+	byKey := make(map[jmap.ID]mailboxCreate, len(p.creates))
+	for _, cr := range p.creates {
+		if _, ok := byKey[cr.key]; ok {
+			return nil, fmt.Errorf("jmap/mail: duplicate mailbox creation key: %v", cr.key)
+		}
+		byKey[cr.key] = cr
+	}
+
+	depth := make(map[jmap.ID]int, len(p.creates))
+	var depthOf func(key jmap.ID, visiting map[jmap.ID]bool) (int, error)
+	depthOf = func(key jmap.ID, visiting map[jmap.ID]bool) (int, error) {
+		if d, ok := depth[key]; ok {
+			return d, nil
+		}
+		if visiting[key] {
+			return 0, ErrMailboxCycle
+		}
+		visiting[key] = true
+		d := 0
+		if parent, ok := byKey[byKey[key].mbox.ParentID]; ok {
+			pd, err := depthOf(parent.key, visiting)
+			if err != nil {
+				return 0, err
+			}
+			d = pd + 1
+		}
+		depth[key] = d
+		return d, nil
+	}
+
+	var levels [][]mailboxCreate
+	for _, cr := range p.creates {
+		d, err := depthOf(cr.key, make(map[jmap.ID]bool))
+		if err != nil {
+			return nil, err
+		}
+		for len(levels) <= d {
+			levels = append(levels, nil)
+		}
+		levels[d] = append(levels[d], cr)
+	}
+	return levels, nil
+}
+
+// destroyLevels groups destroyed Mailboxes by their depth in the existing
+// hierarchy, deepest first.
+func (p *MailboxPlan) destroyLevels(existing []Mailbox) [][]jmap.ID {
+	parents := make(map[jmap.ID]jmap.ID, len(existing))
+	for _, mbox := range existing {
+		parents[mbox.ID] = mbox.ParentID
+	}
+	depthOf := func(id jmap.ID) int {
+		d := 0
+		seen := map[jmap.ID]bool{}
+		for cur := parents[id]; cur != "" && !seen[cur]; cur = parents[cur] {
+			seen[cur] = true
+			d++
+		}
+		return d
+	}
+
+	byDepth := map[int][]jmap.ID{}
+	var depths []int
+	for _, id := range p.destroy {
+		d := depthOf(id)
+		if _, ok := byDepth[d]; !ok {
+			depths = append(depths, d)
+		}
+		byDepth[d] = append(byDepth[d], id)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(depths)))
+
+	levels := make([][]jmap.ID, 0, len(depths))
+	for _, d := range depths {
+		levels = append(levels, byDepth[d])
+	}
+	return levels
+}
+
+// creationRef returns the creation reference for the value if it is a key of
+// the Mailbox created by the plan.
+func (p *MailboxPlan) creationRef(id jmap.ID) (string, bool) {
+	for _, cr := range p.creates {
+		if cr.key == id {
+			return "#" + string(cr.key), true
+		}
+	}
+	return "", false
+}
+
+// Apply sends all operations in the plan to the server in a single request.
+//
+// Failures of individual operations are reported in MailboxPlanResult.Errors,
+// the returned error is used only for request-level and method-level
+// failures.
+//
+// The client must have ResponseUnmarshallers enabled.
+func (p *MailboxPlan) Apply(c *client.Client, account jmap.ID) (*MailboxPlanResult, error) {
+	levels, err := p.createLevels()
+	if err != nil {
+		return nil, err
+	}
+
+	var existing []Mailbox
+	if len(p.destroy) != 0 {
+		resp, err := getMailboxes(c, MailboxGetArgs{
+			AccountID:  account,
+			Properties: []string{"id", "parentId"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		existing = resp.List
+	}
+
+	b := &client.Batch{}
+	useMail(b)
+	ops := map[string]string{}
+
+	for _, level := range levels {
+		create := make(map[string]interface{}, len(level))
+		for _, cr := range level {
+			obj, err := p.createObject(cr.mbox)
+			if err != nil {
+				return nil, err
+			}
+			create[string(cr.key)] = obj
+		}
+		ops[b.NextCallID()] = MailboxOpCreate
+		b.Add("Mailbox/set", map[string]interface{}{
+			"accountId": account,
+			"create":    create,
+		})
+	}
+
+	if len(p.updates) != 0 {
+		update := make(map[string]interface{}, len(p.updates))
+		for id, patch := range p.updates {
+			resolved := make(jmap.PatchObject, len(patch))
+			for path, val := range patch {
+				if path == "parentId" {
+					if parent, ok := val.(jmap.ID); ok {
+						if ref, ok := p.creationRef(parent); ok {
+							val = ref
+						}
+					}
+				}
+				resolved[path] = val
+			}
+			update[string(id)] = resolved
+		}
+		ops[b.NextCallID()] = MailboxOpUpdate
+		b.Add("Mailbox/set", map[string]interface{}{
+			"accountId": account,
+			"update":    update,
+		})
+	}
+
+	for _, level := range p.destroyLevels(existing) {
+		ops[b.NextCallID()] = MailboxOpDestroy
+		b.Add("Mailbox/set", MailboxSetArgs{
+			AccountID:             account,
+			Destroy:               level,
+			OnDestroyRemoveEmails: p.OnDestroyRemoveEmails,
+		})
+	}
+
+	res := &MailboxPlanResult{Created: make(map[jmap.ID]jmap.ID)}
+	if len(ops) == 0 {
+		return res, nil
+	}
+
+	resp, err := c.RawSend(b.Request())
+	if err != nil {
+		return nil, err
+	}
+	for _, inv := range resp.Responses {
+		if methodErr, ok := inv.Args.(jmap.MethodErrorArgs); ok {
+			return nil, methodErr
+		}
+		setResp, ok := inv.Args.(MailboxSetResponse)
+		if !ok {
+			return nil, unexpectedResponse(inv.Name, inv.Args)
+		}
+
+		var failed []*MailboxSetError
+		switch ops[inv.CallID] {
+		case MailboxOpCreate:
+			for key, created := range setResp.Created {
+				res.Created[key] = created.ID
+			}
+			for key, setErr := range setResp.NotCreated {
+				failed = append(failed, newMailboxSetError(key, MailboxOpCreate, setErr))
+			}
+		case MailboxOpUpdate:
+			for id, setErr := range setResp.NotUpdated {
+				failed = append(failed, newMailboxSetError(id, MailboxOpUpdate, setErr))
+			}
+		case MailboxOpDestroy:
+			for id, setErr := range setResp.NotDestroyed {
+				failed = append(failed, newMailboxSetError(id, MailboxOpDestroy, setErr))
+			}
+		}
+		sort.Slice(failed, func(i, j int) bool { return failed[i].Mailbox < failed[j].Mailbox })
+		res.Errors = append(res.Errors, failed...)
+	}
+	return res, nil
+}
+
+// createObject converts the Mailbox to the creation object, replacing
+// ParentID with the creation reference if necessary.
+func (p *MailboxPlan) createObject(mbox Mailbox) (map[string]interface{}, error) {
+	parent := mbox.ParentID
+	mbox.ParentID = ""
+
+	blob, err := json.Marshal(mbox)
+	if err != nil {
+		return nil, err
+	}
+	var obj map[string]interface{}
+	if err := json.Unmarshal(blob, &obj); err != nil {
+		return nil, err
+	}
+
+	if parent != "" {
+		if ref, ok := p.creationRef(parent); ok {
+			obj["parentId"] = ref
+		} else {
+			obj["parentId"] = parent
+		}
+	}
+	return obj, nil
+}
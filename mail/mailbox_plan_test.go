@@ -0,0 +1,102 @@
+package mail
+
+import (
+	"testing"
+
+	"github.com/foxcpp/go-jmap"
+	"gotest.tools/assert"
+	"gotest.tools/assert/cmp"
+)
+
+func TestMailboxPlan(t *testing.T) {
+	var calls []map[string]interface{}
+	c, srv := newTestClient(t, func(name string, args map[string]interface{}) []testResponse {
+		switch name {
+		case "Mailbox/get":
+			return []testResponse{{name, map[string]interface{}{
+				"list": []interface{}{
+					map[string]interface{}{"id": "M1"},
+					map[string]interface{}{"id": "M2", "parentId": "M1"},
+					map[string]interface{}{"id": "M3"},
+				},
+			}}}
+		case "Mailbox/set":
+			calls = append(calls, args)
+			resp := map[string]interface{}{}
+			if create, ok := args["create"].(map[string]interface{}); ok {
+				created := map[string]interface{}{}
+				notCreated := map[string]interface{}{}
+				for key := range create {
+					if key == "bad" {
+						notCreated[key] = map[string]interface{}{"type": "invalidProperties"}
+						continue
+					}
+					created[key] = map[string]interface{}{"id": "N" + key}
+				}
+				resp["created"] = created
+				resp["notCreated"] = notCreated
+			}
+			if destroy, ok := args["destroy"].([]interface{}); ok {
+				destroyed := []interface{}{}
+				notDestroyed := map[string]interface{}{}
+				for _, id := range destroy {
+					if id == "M1" {
+						notDestroyed["M1"] = map[string]interface{}{"type": "mailboxHasEmail"}
+						continue
+					}
+					destroyed = append(destroyed, id)
+				}
+				resp["destroyed"] = destroyed
+				resp["notDestroyed"] = notDestroyed
+			}
+			return []testResponse{{name, resp}}
+		}
+		t.Fatalf("unexpected call: %s", name)
+		return nil
+	})
+	defer srv.Close()
+
+	var plan MailboxPlan
+	plan.Create("child", Mailbox{Name: "Child", ParentID: "parent"})
+	plan.Create("parent", Mailbox{Name: "Parent", ParentID: "M3"})
+	plan.Create("bad", Mailbox{Name: "Bad"})
+	plan.Rename("M3", "Renamed")
+	plan.Move("M3", "parent")
+	plan.Destroy("M1", "M2")
+
+	res, err := plan.Apply(c, "A1")
+	assert.NilError(t, err)
+	assert.Assert(t, cmp.Len(calls, 5))
+
+	assert.Check(t, cmp.DeepEqual(map[string]interface{}{
+		"bad":    map[string]interface{}{"name": "Bad"},
+		"parent": map[string]interface{}{"name": "Parent", "parentId": "M3"},
+	}, calls[0]["create"]))
+	assert.Check(t, cmp.DeepEqual(map[string]interface{}{
+		"child": map[string]interface{}{"name": "Child", "parentId": "#parent"},
+	}, calls[1]["create"]))
+	assert.Check(t, cmp.DeepEqual(map[string]interface{}{
+		"M3": map[string]interface{}{"name": "Renamed", "parentId": "#parent"},
+	}, calls[2]["update"]))
+	assert.Check(t, cmp.DeepEqual([]interface{}{"M2"}, calls[3]["destroy"]))
+	assert.Check(t, cmp.DeepEqual([]interface{}{"M1"}, calls[4]["destroy"]))
+
+	assert.Check(t, cmp.DeepEqual(map[jmap.ID]jmap.ID{"parent": "Nparent", "child": "Nchild"}, res.Created))
+	assert.Assert(t, cmp.Len(res.Errors, 2))
+	assert.Check(t, cmp.Equal(jmap.ID("bad"), res.Errors[0].Mailbox))
+	assert.Check(t, cmp.Equal(MailboxOpCreate, res.Errors[0].Op))
+	setErr, ok := res.Errors[0].Err.(jmap.SetError)
+	assert.Check(t, ok)
+	assert.Check(t, cmp.Equal(jmap.CodeInvalidProperties, setErr.Type))
+	assert.Check(t, cmp.Equal(jmap.ID("M1"), res.Errors[1].Mailbox))
+	assert.Check(t, cmp.Equal(MailboxOpDestroy, res.Errors[1].Op))
+	assert.Check(t, res.Errors[1].Err == ErrMailboxHasEmail)
+
+	t.Run("cycle", func(t *testing.T) {
+		var plan MailboxPlan
+		plan.Create("a", Mailbox{Name: "A", ParentID: "b"})
+		plan.Create("b", Mailbox{Name: "B", ParentID: "a"})
+		_, err := plan.Apply(c, "A1")
+		assert.Check(t, err == ErrMailboxCycle)
+	})
+}
@@ -0,0 +1,159 @@
+package mail
+
+import (
+	"encoding/json"
+
+	"github.com/foxcpp/go-jmap"
+)
+
+// MailboxFilterCondition is the filter condition object for Mailbox/query.
+// Condition matches Mailbox only if all non-empty fields match.
+//
+// Conditions can be combined using jmap.FilterOperator.
+//
+// See RFC 8621, section 2.3 for details.
+type MailboxFilterCondition struct {
+	// The Mailbox parentId property must match the given value exactly.
+	// Use TopLevel to match Mailboxes without a parent.
+	ParentID jmap.ID
+
+	// If true, only top-level Mailboxes (with null parentId) match the
+	// condition. ParentID is ignored in this case.
+	TopLevel bool
+
+	// The Mailbox name property contains the given string.
+	Name string
+
+	// The Mailbox role property must match the given value exactly. Use
+	// NoRole to match Mailboxes without a role.
+	Role string
+
+	// If true, only Mailboxes without a role (null role) match the
+	// condition. Role is ignored in this case.
+	NoRole bool
+
+	// If true, a Mailbox matches if it has any non-null value for its role
+	// property; if false, it matches if it has a null role.
+	HasAnyRole *bool
+
+	// The isSubscribed property of the Mailbox must be identical to the value
+	// given to match the condition.
+	IsSubscribed *bool
+}
+
+func (c MailboxFilterCondition) MarshalJSON() ([]byte, error) {
+	obj := make(map[string]interface{})
+	if c.TopLevel {
+		obj["parentId"] = nil
+	} else if c.ParentID != "" {
+		obj["parentId"] = c.ParentID
+	}
+	if c.Name != "" {
+		obj["name"] = c.Name
+	}
+	if c.NoRole {
+		obj["role"] = nil
+	} else if c.Role != "" {
+		obj["role"] = c.Role
+	}
+	if c.HasAnyRole != nil {
+		obj["hasAnyRole"] = *c.HasAnyRole
+	}
+	if c.IsSubscribed != nil {
+		obj["isSubscribed"] = *c.IsSubscribed
+	}
+	return json.Marshal(obj)
+}
+
+// Properties that can be used in MailboxComparator.
+const (
+	MailboxSortSortOrder = "sortOrder"
+	MailboxSortName      = "name"
+)
+
+// MailboxComparator is the sort comparator for Mailbox/query. Property must
+// be one of MailboxSortSortOrder or MailboxSortName.
+//
+// See RFC 8621, section 2.3 for details.
+type MailboxComparator struct {
+	jmap.Comparator
+}
+
+// MailboxQueryArgs contains arguments for Mailbox/query method call.
+//
+// See RFC 8621, section 2.3 for details.
+type MailboxQueryArgs struct {
+	// The id of the account to use.
+	AccountID jmap.ID `json:"accountId"`
+
+	// Determines the set of Mailboxes returned in the results. Must be
+	// either MailboxFilterCondition or jmap.FilterOperator. If nil, no
+	// filtering is performed.
+	Filter interface{} `json:"filter,omitempty"`
+
+	// Lists the names of properties to compare between two Mailbox records,
+	// and how to compare them, to determine which comes first in the sort.
+	Sort []MailboxComparator `json:"sort,omitempty"`
+
+	// The zero-based index of the first id in the full list of results to
+	// return. Negative values are offsets from the end of the list.
+	Position jmap.Int `json:"position,omitempty"`
+
+	// A Mailbox id. If supplied, the position argument is ignored.
+	Anchor jmap.ID `json:"anchor,omitempty"`
+
+	// The index of the first result to return relative to the index of the
+	// anchor, if an anchor is given.
+	AnchorOffset jmap.Int `json:"anchorOffset,omitempty"`
+
+	// The maximum number of results to return. If zero, no limit is
+	// presumed.
+	Limit jmap.UnsignedInt `json:"limit,omitempty"`
+
+	// Does the client wish to know the total number of results in the
+	// query?
+	CalculateTotal bool `json:"calculateTotal,omitempty"`
+
+	// If true, the Mailboxes will be sorted such that all Mailboxes appear
+	// after their parent Mailbox, and Mailboxes with the same parent are
+	// sorted according to Sort.
+	SortAsTree bool `json:"sortAsTree,omitempty"`
+
+	// If true, a Mailbox is only included in the query if all its ancestors
+	// are also included in the query according to the filter.
+	FilterAsTree bool `json:"filterAsTree,omitempty"`
+}
+
+// MailboxQueryResponse contains results of Mailbox/query method call.
+type MailboxQueryResponse struct {
+	// The id of the account used for the call.
+	AccountID jmap.ID `json:"accountId"`
+
+	// A string encoding the current state of the query on the server.
+	QueryState string `json:"queryState"`
+
+	// This is true if the server supports calling Mailbox/queryChanges with
+	// these filter/sort parameters.
+	CanCalculateChanges bool `json:"canCalculateChanges"`
+
+	// The zero-based index of the first result in the ids array within the
+	// complete list of query results.
+	Position jmap.UnsignedInt `json:"position"`
+
+	// The list of ids for each Mailbox in the query results.
+	IDs []jmap.ID `json:"ids"`
+
+	// The total number of Mailboxes in the results (given the filter). Only
+	// set if CalculateTotal was requested.
+	Total jmap.UnsignedInt `json:"total,omitempty"`
+
+	// The limit enforced by the server on the maximum number of results to
+	// return.
+	Limit jmap.UnsignedInt `json:"limit,omitempty"`
+}
+
+func unmarshalMailboxQueryResponse(args json.RawMessage) (interface{}, error) {
+	resp := MailboxQueryResponse{}
+	err := json.Unmarshal(args, &resp)
+	return resp, err
+}
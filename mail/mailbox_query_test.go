@@ -0,0 +1,36 @@
+package mail
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/foxcpp/go-jmap"
+	"gotest.tools/assert"
+	"gotest.tools/assert/cmp"
+)
+
+func TestMailboxQueryArgsMarshal(t *testing.T) {
+	yes := true
+	args := MailboxQueryArgs{
+		AccountID: "abc",
+		Filter: jmap.Or(
+			MailboxFilterCondition{TopLevel: true, NoRole: true},
+			MailboxFilterCondition{ParentID: "M1", Name: "Work", IsSubscribed: &yes},
+			MailboxFilterCondition{Role: RoleInbox, HasAnyRole: &yes},
+		),
+		Sort: []MailboxComparator{
+			{jmap.Asc(MailboxSortSortOrder)},
+			{jmap.Asc(MailboxSortName)},
+		},
+		SortAsTree: true,
+	}
+
+	blob, err := json.Marshal(args)
+	assert.NilError(t, err, "json.Marshal")
+	assert.Check(t, cmp.Equal(`{"accountId":"abc","filter":{"operator":"OR","conditions":[`+
+		`{"parentId":null,"role":null},`+
+		`{"isSubscribed":true,"name":"Work","parentId":"M1"},`+
+		`{"hasAnyRole":true,"role":"inbox"}]},`+
+		`"sort":[{"property":"sortOrder","isAscending":true},{"property":"name","isAscending":true}],`+
+		`"sortAsTree":true}`, string(blob)))
+}
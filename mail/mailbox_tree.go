@@ -0,0 +1,159 @@
+package mail
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/foxcpp/go-jmap"
+)
+
+// MailboxNode is a Mailbox placed into the hierarchy built by
+// BuildMailboxTree.
+type MailboxNode struct {
+	Mailbox *Mailbox
+
+	// Parent node, nil for top-level Mailboxes.
+	Parent *MailboxNode
+
+	// Child nodes sorted by sortOrder and then by name.
+	Children []*MailboxNode
+
+	// Depth of the node, 1 for top-level Mailboxes. This matches the
+	// definition used for maxMailboxDepth.
+	Depth int
+}
+
+// Path returns names of all Mailboxes from the top level to this one.
+func (n *MailboxNode) Path() []string {
+	var path []string
+	for cur := n; cur != nil; cur = cur.Parent {
+		path = append(path, cur.Mailbox.Name)
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path
+}
+
+// MailboxTree is the Mailbox hierarchy.
+type MailboxTree struct {
+	// Top-level nodes sorted by sortOrder and then by name.
+	Roots []*MailboxNode
+
+	// All nodes by Mailbox id.
+	ByID map[jmap.ID]*MailboxNode
+
+	// Ids of Mailboxes that refer to a parent not present in the input.
+	// They are placed at the top level.
+	Orphans []jmap.ID
+}
+
+// Walk calls fn for each node in depth-first order, parents before
+// children. If fn returns false, children of the node are skipped.
+func (t *MailboxTree) Walk(fn func(n *MailboxNode) bool) {
+	var walk func(nodes []*MailboxNode)
+	walk = func(nodes []*MailboxNode) {
+		for _, n := range nodes {
+			if fn(n) {
+				walk(n.Children)
+			}
+		}
+	}
+	walk(t.Roots)
+}
+
+// MailboxCycleError is returned by BuildMailboxTree if parentId references
+// form a cycle.
+type MailboxCycleError struct {
+	// Ids of Mailboxes forming the cycle.
+	Mailboxes []jmap.ID
+}
+
+func (mce MailboxCycleError) Error() string {
+	ids := make([]string, 0, len(mce.Mailboxes))
+	for _, id := range mce.Mailboxes {
+		ids = append(ids, string(id))
+	}
+	return fmt.Sprintf("jmap/mail: mailbox hierarchy contains a cycle: %s", strings.Join(ids, " -> "))
+}
+
+// BuildMailboxTree builds the hierarchy from the flat list of Mailboxes
+// (e.g. as returned by Mailbox/get). Mailboxes must have at least id, name,
+// parentId and sortOrder properties.
+//
+// Nodes reference the elements of the passed slice.
+func BuildMailboxTree(mailboxes []Mailbox) (*MailboxTree, error) {
+	t := &MailboxTree{
+		ByID: make(map[jmap.ID]*MailboxNode, len(mailboxes)),
+	}
+	for i := range mailboxes {
+		t.ByID[mailboxes[i].ID] = &MailboxNode{Mailbox: &mailboxes[i]}
+	}
+
+	// Detect cycles before linking nodes so Depth computation terminates.
+	// 0 - not visited, 1 - on the current path, 2 - done.
+	state := make(map[jmap.ID]int, len(mailboxes))
+	for i := range mailboxes {
+		var path []jmap.ID
+		id := mailboxes[i].ID
+		for id != "" && state[id] == 0 {
+			node, ok := t.ByID[id]
+			if !ok {
+				break
+			}
+			state[id] = 1
+			path = append(path, id)
+			id = node.Mailbox.ParentID
+		}
+		if id != "" && state[id] == 1 {
+			for j, pathID := range path {
+				if pathID == id {
+					return nil, MailboxCycleError{Mailboxes: append(path[j:len(path):len(path)], id)}
+				}
+			}
+		}
+		for _, pathID := range path {
+			state[pathID] = 2
+		}
+	}
+
+	for i := range mailboxes {
+		node := t.ByID[mailboxes[i].ID]
+		parentID := node.Mailbox.ParentID
+		if parentID == "" {
+			t.Roots = append(t.Roots, node)
+			continue
+		}
+		parent, ok := t.ByID[parentID]
+		if !ok {
+			t.Orphans = append(t.Orphans, node.Mailbox.ID)
+			t.Roots = append(t.Roots, node)
+			continue
+		}
+		node.Parent = parent
+		parent.Children = append(parent.Children, node)
+	}
+
+	var finish func(nodes []*MailboxNode, depth int)
+	finish = func(nodes []*MailboxNode, depth int) {
+		sortMailboxNodes(nodes)
+		for _, n := range nodes {
+			n.Depth = depth
+			finish(n.Children, depth+1)
+		}
+	}
+	finish(t.Roots, 1)
+
+	return t, nil
+}
+
+func sortMailboxNodes(nodes []*MailboxNode) {
+	sort.SliceStable(nodes, func(i, j int) bool {
+		a, b := nodes[i].Mailbox, nodes[j].Mailbox
+		if a.SortOrder != b.SortOrder {
+			return a.SortOrder < b.SortOrder
+		}
+		return a.Name < b.Name
+	})
+}
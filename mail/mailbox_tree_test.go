@@ -0,0 +1,53 @@
+package mail
+
+import (
+	"testing"
+
+	"github.com/foxcpp/go-jmap"
+	"gotest.tools/assert"
+	"gotest.tools/assert/cmp"
+)
+
+func TestBuildMailboxTree(t *testing.T) {
+	mailboxes := []Mailbox{
+		{ID: "C", Name: "Lists", ParentID: "A"},
+		{ID: "A", Name: "Inbox", SortOrder: 1},
+		{ID: "B", Name: "Archive", SortOrder: 2},
+		{ID: "D", Name: "Go", ParentID: "C"},
+		{ID: "E", Name: "Alpha", ParentID: "C"},
+		{ID: "F", Name: "Lost", ParentID: "X"},
+	}
+
+	tree, err := BuildMailboxTree(mailboxes)
+	assert.NilError(t, err)
+
+	var order []jmap.ID
+	var depths []int
+	tree.Walk(func(n *MailboxNode) bool {
+		order = append(order, n.Mailbox.ID)
+		depths = append(depths, n.Depth)
+		return true
+	})
+	assert.Check(t, cmp.DeepEqual([]jmap.ID{"F", "A", "C", "E", "D", "B"}, order))
+	assert.Check(t, cmp.DeepEqual([]int{1, 1, 2, 3, 3, 1}, depths))
+	assert.Check(t, cmp.DeepEqual([]jmap.ID{"F"}, tree.Orphans))
+	assert.Check(t, cmp.DeepEqual([]string{"Inbox", "Lists", "Go"}, tree.ByID["D"].Path()))
+
+	t.Run("cycle", func(t *testing.T) {
+		_, err := BuildMailboxTree([]Mailbox{
+			{ID: "A", ParentID: "C"},
+			{ID: "B", ParentID: "A"},
+			{ID: "C", ParentID: "B"},
+			{ID: "D"},
+		})
+		cycleErr, ok := err.(MailboxCycleError)
+		assert.Assert(t, ok, "expected MailboxCycleError, got %v", err)
+		assert.Check(t, cmp.DeepEqual([]jmap.ID{"A", "C", "B", "A"}, cycleErr.Mailboxes))
+	})
+
+	t.Run("self parent", func(t *testing.T) {
+		_, err := BuildMailboxTree([]Mailbox{{ID: "A", ParentID: "A"}})
+		_, ok := err.(MailboxCycleError)
+		assert.Check(t, ok)
+	})
+}
@@ -0,0 +1,220 @@
+package mail
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/foxcpp/go-jmap"
+	"github.com/foxcpp/go-jmap/client"
+)
+
+// MDNCapabilityName is the capability for Message Disposition Notifications
+// as defined in RFC 9007.
+const MDNCapabilityName = "urn:ietf:params:jmap:mdn"
+
+// KeywordMDNSent indicates that an MDN has been sent for the Email (or that
+// the user refused to send one).
+const KeywordMDNSent = "$mdnsent"
+
+var mdnUsing = []string{jmap.CoreCapabilityName, jmap.MailCapabilityName, MDNCapabilityName}
+
+// Values of Disposition.ActionMode.
+const (
+	ActionManual    = "manual-action"
+	ActionAutomatic = "automatic-action"
+)
+
+// Values of Disposition.SendingMode.
+const (
+	SendingManual    = "mdn-sent-manually"
+	SendingAutomatic = "mdn-sent-automatically"
+)
+
+// Values of Disposition.Type.
+const (
+	DispositionDeleted    = "deleted"
+	DispositionDispatched = "dispatched"
+	DispositionDisplayed  = "displayed"
+	DispositionProcessed  = "processed"
+)
+
+// Disposition describes what happened to the message, see RFC 8098,
+// section 3.2.6.
+type Disposition struct {
+	// ActionManual or ActionAutomatic.
+	ActionMode string `json:"actionMode"`
+
+	// SendingManual or SendingAutomatic.
+	SendingMode string `json:"sendingMode"`
+
+	// One of Disposition* values.
+	Type string `json:"type"`
+}
+
+// MDN represents a Message Disposition Notification (read receipt).
+//
+// See RFC 9007, section 2 for details.
+type MDN struct {
+	// The Email id of the received message to which this MDN is related.
+	// Set for MDN/send, nil for parsed MDNs if the server can't find it.
+	ForEmailID jmap.ID `json:"forEmailId,omitempty"`
+
+	// The subject used as "Subject" header field for this MDN.
+	Subject string `json:"subject,omitempty"`
+
+	// The human-readable part of the MDN, as plain text.
+	TextBody string `json:"textBody,omitempty"`
+
+	// If true, the content of the original message will appear in the
+	// third component of the multipart/report generated for the MDN.
+	IncludeOriginalMessage bool `json:"includeOriginalMessage,omitempty"`
+
+	// The name of the Mail User Agent (MUA) creating this MDN.
+	ReportingUA string `json:"reportingUA,omitempty"`
+
+	// The action performed on the message.
+	Disposition Disposition `json:"disposition"`
+
+	// The name of the gateway or Message Transfer Agent (MTA) that
+	// translated a foreign (non-Internet) message disposition notification
+	// into this MDN. Set by the server.
+	MDNGateway string `json:"mdnGateway,omitempty"`
+
+	// The original recipient address as specified by the sender of the
+	// message for which the MDN is being issued. Set by the server.
+	OriginalRecipient string `json:"originalRecipient,omitempty"`
+
+	// The recipient for which the MDN is being issued. If set, it overrides
+	// the value that would be calculated by the server from the Identity.
+	FinalRecipient string `json:"finalRecipient,omitempty"`
+
+	// The Message-ID header field of the message for which the MDN is being
+	// issued. Set by the server.
+	OriginalMessageID string `json:"originalMessageId,omitempty"`
+
+	// Additional information in the form of text messages when the "error"
+	// disposition modifier appears.
+	Error []string `json:"error,omitempty"`
+
+	// The names and values of extension fields.
+	ExtensionFields map[string]string `json:"extensionFields,omitempty"`
+}
+
+// MDNSendArgs contains arguments for MDN/send method call.
+type MDNSendArgs struct {
+	// The id of the account to use.
+	AccountID jmap.ID `json:"accountId"`
+
+	// The id of the Identity to associate with these MDNs.
+	IdentityID jmap.ID `json:"identityId"`
+
+	// A map of the creation id to MDN objects.
+	Send map[jmap.ID]MDN `json:"send"`
+
+	// A map of the creation id reference (e.g. "#k1") to an object
+	// containing properties to update on the Email object referenced by the
+	// MDN if the sending succeeds.
+	OnSuccessUpdateEmail map[string]jmap.PatchObject `json:"onSuccessUpdateEmail,omitempty"`
+}
+
+// MDNSendResponse contains results of MDN/send method call.
+type MDNSendResponse struct {
+	// The id of the account used for the call.
+	AccountID jmap.ID `json:"accountId"`
+
+	// A map of the creation id to an MDN containing any properties that were
+	// not set by the client.
+	Sent map[jmap.ID]MDN `json:"sent"`
+
+	// A map of the creation id to a SetError object for each MDN that failed
+	// to be sent.
+	NotSent map[jmap.ID]jmap.SetError `json:"notSent"`
+}
+
+// MDNParseArgs contains arguments for MDN/parse method call.
+type MDNParseArgs struct {
+	// The id of the account to use.
+	AccountID jmap.ID `json:"accountId"`
+
+	// The ids of the blobs to parse.
+	BlobIDs []jmap.ID `json:"blobIds"`
+}
+
+// MDNParseResponse contains results of MDN/parse method call.
+type MDNParseResponse struct {
+	// The id of the account used for the call.
+	AccountID jmap.ID `json:"accountId"`
+
+	// A map of blob id to parsed MDN representation for each successfully
+	// parsed blob.
+	Parsed map[jmap.ID]MDN `json:"parsed"`
+
+	// A list of ids given that corresponded to blobs that could not be
+	// parsed as MDNs.
+	NotParsable []jmap.ID `json:"notParsable"`
+
+	// A list of blob ids given that could not be found.
+	NotFound []jmap.ID `json:"notFound"`
+}
+
+func unmarshalMDNSendResponse(args json.RawMessage) (interface{}, error) {
+	resp := MDNSendResponse{}
+	err := json.Unmarshal(args, &resp)
+	return resp, err
+}
+
+func unmarshalMDNParseResponse(args json.RawMessage) (interface{}, error) {
+	resp := MDNParseResponse{}
+	err := json.Unmarshal(args, &resp)
+	return resp, err
+}
+
+// SendMDN sends the MDN for the Email referenced by mdn.ForEmailID and sets
+// the $mdnsent keyword on it.
+//
+// If the server refuses to send the MDN, jmap.SetError is returned.
+//
+// The client must have ResponseUnmarshallers enabled.
+func SendMDN(c *client.Client, account, identity jmap.ID, mdn MDN) error {
+	respArgs, err := c.Call(mdnUsing, "MDN/send", MDNSendArgs{
+		AccountID:  account,
+		IdentityID: identity,
+		Send:       map[jmap.ID]MDN{"mdn": mdn},
+		OnSuccessUpdateEmail: map[string]jmap.PatchObject{
+			"#mdn": {keywordPath(KeywordMDNSent): true},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	resp, ok := respArgs.(MDNSendResponse)
+	if !ok {
+		return unexpectedResponse("MDN/send", respArgs)
+	}
+	if setErr, ok := resp.NotSent["mdn"]; ok {
+		return setErr
+	}
+	if _, ok := resp.Sent["mdn"]; !ok {
+		return fmt.Errorf("jmap/mail: MDN is neither sent nor rejected")
+	}
+	return nil
+}
+
+// ParseMDNs parses the blobs (usually message/disposition-notification
+// parts of received reports) as MDNs.
+//
+// The client must have ResponseUnmarshallers enabled.
+func ParseMDNs(c *client.Client, account jmap.ID, blobs []jmap.ID) (*MDNParseResponse, error) {
+	respArgs, err := c.Call(mdnUsing, "MDN/parse", MDNParseArgs{
+		AccountID: account,
+		BlobIDs:   blobs,
+	})
+	if err != nil {
+		return nil, err
+	}
+	resp, ok := respArgs.(MDNParseResponse)
+	if !ok {
+		return nil, unexpectedResponse("MDN/parse", respArgs)
+	}
+	return &resp, nil
+}
@@ -0,0 +1,79 @@
+package mail
+
+import (
+	"testing"
+
+	"github.com/foxcpp/go-jmap"
+	"gotest.tools/assert"
+	"gotest.tools/assert/cmp"
+)
+
+func TestMDN(t *testing.T) {
+	var send, onSuccess map[string]interface{}
+	c, srv := newTestClient(t, func(name string, args map[string]interface{}) []testResponse {
+		switch name {
+		case "MDN/send":
+			send = args["send"].(map[string]interface{})["mdn"].(map[string]interface{})
+			onSuccess = args["onSuccessUpdateEmail"].(map[string]interface{})
+			return []testResponse{
+				{name, map[string]interface{}{
+					"sent": map[string]interface{}{"mdn": map[string]interface{}{
+						"finalRecipient": "rfc822; john@example.com",
+						"disposition":    send["disposition"],
+					}},
+				}},
+				{"Email/set", map[string]interface{}{"updated": map[string]interface{}{"E1": nil}}},
+			}
+		case "MDN/parse":
+			return []testResponse{{name, map[string]interface{}{
+				"parsed": map[string]interface{}{
+					"B1": map[string]interface{}{
+						"forEmailId":        "E2",
+						"subject":           "Read receipt for: World domination",
+						"reportingUA":       "joes-pc.cs.example.com; Foomail 97.1",
+						"disposition":       map[string]interface{}{"actionMode": "manual-action", "sendingMode": "mdn-sent-manually", "type": "displayed"},
+						"finalRecipient":    "rfc822; john@example.com",
+						"originalMessageId": "<199509192301.23456@example.org>",
+					},
+				},
+				"notParsable": []interface{}{"B2"},
+			}}}
+		}
+		t.Fatalf("unexpected call: %s", name)
+		return nil
+	})
+	defer srv.Close()
+
+	err := SendMDN(c, "A1", "I1", MDN{
+		ForEmailID:  "E1",
+		Subject:     "Read receipt for: World domination",
+		ReportingUA: "foomail",
+		Disposition: Disposition{
+			ActionMode:  ActionManual,
+			SendingMode: SendingManual,
+			Type:        DispositionDisplayed,
+		},
+	})
+	assert.NilError(t, err)
+	assert.Check(t, cmp.DeepEqual(map[string]interface{}{
+		"forEmailId":  "E1",
+		"subject":     "Read receipt for: World domination",
+		"reportingUA": "foomail",
+		"disposition": map[string]interface{}{
+			"actionMode":  "manual-action",
+			"sendingMode": "mdn-sent-manually",
+			"type":        "displayed",
+		},
+	}, send))
+	assert.Check(t, cmp.DeepEqual(map[string]interface{}{
+		"#mdn": map[string]interface{}{"keywords/$mdnsent": true},
+	}, onSuccess))
+
+	resp, err := ParseMDNs(c, "A1", []jmap.ID{"B1", "B2"})
+	assert.NilError(t, err)
+	assert.Check(t, cmp.DeepEqual([]jmap.ID{"B2"}, resp.NotParsable))
+	mdn := resp.Parsed["B1"]
+	assert.Check(t, cmp.Equal(jmap.ID("E2"), mdn.ForEmailID))
+	assert.Check(t, cmp.Equal(DispositionDisplayed, mdn.Disposition.Type))
+	assert.Check(t, cmp.Equal("<199509192301.23456@example.org>", mdn.OriginalMessageID))
+}
@@ -0,0 +1,225 @@
+package mail
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/foxcpp/go-jmap"
+	"github.com/foxcpp/go-jmap/client"
+)
+
+// MigrationMessage describes a single message to import using Migrate.
+type MigrationMessage struct {
+	// The key identifying the message in the source, e.g. the Maildir file
+	// name or the IMAP mailbox name with UIDVALIDITY and UID. Keys must be
+	// unique and stable between runs since they are used to resume the
+	// migration.
+	Key string
+
+	// The Message-ID of the message used to skip messages that already exist
+	// in the account, see MessageIDIndex.ImportUnique. If empty, the message is always
+	// imported.
+	MessageID string
+
+	// Open returns the raw message (RFC 5322). It is called only if the
+	// message needs to be uploaded.
+	Open func() (io.ReadCloser, error)
+
+	// The ids of the Mailboxes to assign the Email to.
+	MailboxIDs map[jmap.ID]bool
+
+	// The keywords to apply to the Email, see KeywordsFromIMAPFlags.
+	Keywords map[string]bool
+
+	// The receivedAt date to set on the Email. If nil, the time of the import
+	// is used.
+	ReceivedAt *jmap.UTCDate
+}
+
+// MigrationOutcome is the result of importing a single message.
+type MigrationOutcome string
+
+const (
+	// The message was imported as a new Email.
+	MigrationImported MigrationOutcome = "imported"
+
+	// An Email with the same Message-ID already existed in the account.
+	MigrationExisting MigrationOutcome = "existing"
+
+	// The message was not imported. It is retried when the migration is
+	// resumed.
+	MigrationFailed MigrationOutcome = "failed"
+)
+
+// MigrationEntry describes the result of importing a single message.
+type MigrationEntry struct {
+	// MigrationMessage.Key of the message.
+	Key string `json:"key"`
+
+	Outcome MigrationOutcome `json:"outcome"`
+
+	// The id of the uploaded blob with the raw message, if it was uploaded.
+	BlobID jmap.ID `json:"blobId,omitempty"`
+
+	// The id of the imported or existing Email.
+	EmailID jmap.ID `json:"emailId,omitempty"`
+
+	// The type of the JMAP error that caused the failure. Empty if the
+	// error is not a JMAP error, e.g. if the message could not be read.
+	ErrorCode jmap.ErrorCode `json:"errorCode,omitempty"`
+
+	// The error message.
+	Error string `json:"error,omitempty"`
+}
+
+// MigrationReport is the machine-readable result of Migrate. It can be
+// stored using encoding/json and passed to Migrate again to resume the
+// migration.
+type MigrationReport struct {
+	// The id of the account messages are imported to.
+	AccountID jmap.ID `json:"accountId"`
+
+	// Results of processed messages, in the order they were first
+	// processed.
+	Entries []MigrationEntry `json:"entries"`
+}
+
+// Count returns the number of messages with the outcome.
+func (r *MigrationReport) Count(outcome MigrationOutcome) int {
+	n := 0
+	for _, e := range r.Entries {
+		if e.Outcome == outcome {
+			n++
+		}
+	}
+	return n
+}
+
+// ErrorCounts returns the number of failed messages for each error code.
+// Failures not caused by JMAP errors are counted under the empty code.
+func (r *MigrationReport) ErrorCounts() map[jmap.ErrorCode]int {
+	res := make(map[jmap.ErrorCode]int)
+	for _, e := range r.Entries {
+		if e.Outcome == MigrationFailed {
+			res[e.ErrorCode]++
+		}
+	}
+	return res
+}
+
+// Migrate uploads and imports messages into the account, recording the
+// outcome for each message in the report.
+//
+// If the report already contains entries (e.g. it was decoded from the
+// output of an interrupted run), messages recorded as imported or existing
+// are skipped and failed messages are retried. Already uploaded blobs are
+// reused unless the server reported them as missing.
+//
+// Errors specific to a message (SetError and method errors of Email/import,
+// unreadable and too large messages) are recorded in the report and the
+// migration continues. Other errors, such as network failures, stop the
+// migration and are returned. The message being processed is not recorded
+// in this case, so the report can be saved and used to resume later.
+//
+// progress, if not nil, is called after each message. It can be used to
+// save the report periodically.
+//
+// The client must have ResponseUnmarshallers enabled.
+func Migrate(c *client.Client, account jmap.ID, messages []MigrationMessage, report *MigrationReport, progress ProgressFunc) error {
+	if report.AccountID == "" {
+		report.AccountID = account
+	} else if report.AccountID != account {
+		return fmt.Errorf("jmap/mail: migration report is for account %v, not %v", report.AccountID, account)
+	}
+
+	index := make(map[string]int, len(report.Entries))
+	for i, e := range report.Entries {
+		index[e.Key] = i
+	}
+	existing := NewMessageIDIndex(c, account)
+
+	for i, msg := range messages {
+		entry := MigrationEntry{Key: msg.Key}
+		prevIdx, seen := index[msg.Key]
+		if seen {
+			prev := report.Entries[prevIdx]
+			if prev.Outcome != MigrationFailed {
+				if progress != nil {
+					progress(i+1, len(messages))
+				}
+				continue
+			}
+			if prev.ErrorCode != jmap.CodeBlobNotFound {
+				entry.BlobID = prev.BlobID
+			}
+		}
+
+		if err := migrateMessage(c, account, existing, msg, &entry); err != nil {
+			return err
+		}
+
+		if seen {
+			report.Entries[prevIdx] = entry
+		} else {
+			index[msg.Key] = len(report.Entries)
+			report.Entries = append(report.Entries, entry)
+		}
+		if progress != nil {
+			progress(i+1, len(messages))
+		}
+	}
+	return nil
+}
+
+func migrateMessage(c *client.Client, account jmap.ID, existing *MessageIDIndex, msg MigrationMessage, entry *MigrationEntry) error {
+	if entry.BlobID == "" {
+		r, err := msg.Open()
+		if err != nil {
+			entry.setFailed("", err)
+			return nil
+		}
+		info, err := c.Upload(account, r)
+		r.Close()
+		if err != nil {
+			httpErr, ok := err.(client.HTTPError)
+			if !ok || httpErr.StatusCode != http.StatusRequestEntityTooLarge {
+				return err
+			}
+			entry.setFailed(jmap.CodeTooLarge, err)
+			return nil
+		}
+		entry.BlobID = info.BlobID
+	}
+
+	id, existed, err := existing.ImportUnique(msg.MessageID, EmailImport{
+		BlobID:     entry.BlobID,
+		MailboxIDs: msg.MailboxIDs,
+		Keywords:   msg.Keywords,
+		ReceivedAt: msg.ReceivedAt,
+	})
+	switch err := err.(type) {
+	case nil:
+	case jmap.SetError:
+		entry.setFailed(err.Type, err)
+		return nil
+	case jmap.MethodErrorArgs:
+		entry.setFailed(err.Type, err)
+		return nil
+	default:
+		return err
+	}
+
+	entry.EmailID = id
+	entry.Outcome = MigrationImported
+	if existed {
+		entry.Outcome = MigrationExisting
+	}
+	return nil
+}
+
+func (e *MigrationEntry) setFailed(code jmap.ErrorCode, err error) {
+	e.Outcome = MigrationFailed
+	e.ErrorCode = code
+	e.Error = err.Error()
+}
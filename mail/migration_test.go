@@ -0,0 +1,106 @@
+package mail
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/foxcpp/go-jmap"
+	"gotest.tools/assert"
+	"gotest.tools/assert/cmp"
+)
+
+func TestMigrate(t *testing.T) {
+	var (
+		uploads  []string
+		tooLarge = true
+		failBlob = jmap.ID("B3")
+	)
+	c, srv := newTestClient(t, func(name string, args map[string]interface{}) []testResponse {
+		if name != "Email/import" {
+			t.Fatalf("unexpected call: %s", name)
+		}
+		imp := args["emails"].(map[string]interface{})["import"].(map[string]interface{})
+		if jmap.ID(imp["blobId"].(string)) == failBlob {
+			return []testResponse{{name, map[string]interface{}{
+				"notCreated": map[string]interface{}{"import": map[string]interface{}{
+					"type": "invalidProperties", "properties": []string{"mailboxIds"},
+				}},
+			}}}
+		}
+		return []testResponse{{name, map[string]interface{}{
+			"created": map[string]interface{}{"import": map[string]interface{}{"id": "E" + imp["blobId"].(string)}},
+		}}}
+	})
+	defer srv.Close()
+	srv.Config.Handler.(*http.ServeMux).HandleFunc("/upload/A1/", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		uploads = append(uploads, string(body))
+		if string(body) == "large" && tooLarge {
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{ //nolint:errcheck
+			"accountId": "A1",
+			"blobId":    "B" + strings.TrimPrefix(string(body), "msg"),
+		})
+	})
+
+	open := func(content string) func() (io.ReadCloser, error) {
+		return func() (io.ReadCloser, error) {
+			return ioutil.NopCloser(strings.NewReader(content)), nil
+		}
+	}
+	inbox := map[jmap.ID]bool{"INBOX": true}
+	messages := []MigrationMessage{
+		{Key: "1", Open: open("msg1"), MailboxIDs: inbox},
+		{Key: "2", Open: open("large"), MailboxIDs: inbox},
+		{Key: "3", Open: open("msg3"), MailboxIDs: inbox},
+	}
+
+	report := &MigrationReport{}
+	assert.NilError(t, Migrate(c, "A1", messages, report, nil))
+	assert.Check(t, cmp.DeepEqual([]string{"msg1", "large", "msg3"}, uploads))
+	assert.Check(t, cmp.DeepEqual(&MigrationReport{
+		AccountID: "A1",
+		Entries: []MigrationEntry{
+			{Key: "1", Outcome: MigrationImported, BlobID: "B1", EmailID: "EB1"},
+			{Key: "2", Outcome: MigrationFailed, ErrorCode: jmap.CodeTooLarge, Error: "HTTP 413 413 Request Entity Too Large"},
+			{Key: "3", Outcome: MigrationFailed, BlobID: "B3", ErrorCode: jmap.CodeInvalidProperties, Error: "jmap: invalidProperties"},
+		},
+	}, report))
+	assert.Check(t, cmp.Equal(1, report.Count(MigrationImported)))
+	assert.Check(t, cmp.DeepEqual(map[jmap.ErrorCode]int{
+		jmap.CodeTooLarge:          1,
+		jmap.CodeInvalidProperties: 1,
+	}, report.ErrorCounts()))
+
+	t.Run("resume", func(t *testing.T) {
+		blob, err := json.Marshal(report)
+		assert.NilError(t, err)
+		resumed := &MigrationReport{}
+		assert.NilError(t, json.Unmarshal(blob, resumed))
+
+		uploads = nil
+		tooLarge = false
+		failBlob = ""
+		var done []int
+		err = Migrate(c, "A1", messages, resumed, func(n, total int) {
+			assert.Check(t, cmp.Equal(3, total))
+			done = append(done, n)
+		})
+		assert.NilError(t, err)
+		assert.Check(t, cmp.DeepEqual([]int{1, 2, 3}, done))
+		// Imported message is skipped, uploaded blob is reused.
+		assert.Check(t, cmp.DeepEqual([]string{"large"}, uploads))
+		assert.Check(t, cmp.Equal(3, resumed.Count(MigrationImported)))
+		assert.Check(t, cmp.Equal(MigrationEntry{Key: "3", Outcome: MigrationImported, BlobID: "B3", EmailID: "EB3"}, resumed.Entries[2]))
+
+		err = Migrate(c, "A2", messages, resumed, nil)
+		assert.Check(t, err != nil)
+	})
+}
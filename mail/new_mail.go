@@ -0,0 +1,155 @@
+package mail
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/foxcpp/go-jmap"
+	"github.com/foxcpp/go-jmap/client"
+)
+
+// NotificationProperties is the minimal set of Email properties needed to
+// show a new mail notification.
+var NotificationProperties = []string{
+	"id", "threadId", "mailboxIds", "keywords", "from", "subject",
+	"receivedAt", "preview",
+}
+
+// NewMail contains Emails created since the previously known Email state, as
+// returned by FetchNewMail.
+type NewMail struct {
+	// Created Emails, newest first.
+	Emails []Email
+
+	// Email state after fetching. Pass it to the next FetchNewMail call.
+	State string
+}
+
+// FetchNewMail fetches Emails created in the account since sinceState,
+// which should be the State returned by the previous FetchNewMail call or
+// the state of Email/get.
+//
+// It is intended to be called when a push notification reports new
+// EmailDelivery state (see jmap.StateChange.HasNewMail) to quickly show a
+// notification for new messages. Only creations are fetched, updates and
+// destructions are ignored, and each batch of changes is fetched in a single
+// request using Email/changes with back-referenced Email/get. The batch size
+// is limited to maxObjectsInGet of the server. If properties is nil,
+// NotificationProperties are requested.
+//
+// Drafts (Emails with the $draft keyword) are skipped since they are
+// created by the user, same for sent messages stored as drafts before
+// submission. "keywords" is always requested for that.
+//
+// The state used by FetchNewMail should be kept separately from the one used
+// for the full synchronization since the latter still needs to fetch all
+// changes, including updates skipped here.
+//
+// If the server cannot calculate changes from sinceState, jmap.MethodErrorArgs
+// with CodeCannotCalculateChanges type is returned. The client should do a
+// full synchronization in this case and use the resulting Email state.
+//
+// The client must have ResponseUnmarshallers enabled.
+func FetchNewMail(c *client.Client, account jmap.ID, sinceState string, properties []string) (*NewMail, error) {
+	if properties == nil {
+		properties = NotificationProperties
+	}
+	if !containsString(properties, "keywords") {
+		properties = append(properties[:len(properties):len(properties)], "keywords")
+	}
+
+	session, err := c.CurrentSession()
+	if err != nil {
+		return nil, err
+	}
+	maxChanges := session.Limits().MaxObjectsInGet
+
+	res := &NewMail{State: sinceState}
+	for {
+		changes, created, err := fetchCreatedEmails(c, account, res.State, maxChanges, properties)
+		if err != nil {
+			return nil, err
+		}
+		for _, email := range created {
+			if email.Keywords[KeywordDraft] {
+				continue
+			}
+			res.Emails = append(res.Emails, email)
+		}
+		res.State = changes.NewState
+		if !changes.HasMoreChanges {
+			break
+		}
+	}
+
+	sort.SliceStable(res.Emails, func(i, j int) bool {
+		ri, rj := res.Emails[i].ReceivedAt, res.Emails[j].ReceivedAt
+		if ri == nil || rj == nil {
+			return ri != nil
+		}
+		return time.Time(*ri).After(time.Time(*rj))
+	})
+	return res, nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+func fetchCreatedEmails(c *client.Client, account jmap.ID, sinceState string, maxChanges jmap.UnsignedInt, properties []string) (*EmailChangesResponse, []Email, error) {
+	b := &client.Batch{}
+	useMail(b)
+	changesCall := b.NextCallID()
+	b.Add("Email/changes", EmailChangesArgs{
+		AccountID:  account,
+		SinceState: sinceState,
+		MaxChanges: maxChanges,
+	})
+	getCall := b.NextCallID()
+	b.Add("Email/get", map[string]interface{}{
+		"accountId":  account,
+		"properties": properties,
+		"#ids": jmap.ResultReference{
+			ResultOf: changesCall,
+			Name:     "Email/changes",
+			Path:     "/created",
+		},
+	})
+
+	resp, err := c.RawSend(b.Request())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var (
+		changes EmailChangesResponse
+		get     EmailGetResponse
+	)
+	for _, inv := range resp.Responses {
+		if methodErr, ok := inv.Args.(jmap.MethodErrorArgs); ok {
+			return nil, nil, methodErr
+		}
+		var ok bool
+		switch inv.CallID {
+		case changesCall:
+			changes, ok = inv.Args.(EmailChangesResponse)
+		case getCall:
+			get, ok = inv.Args.(EmailGetResponse)
+		default:
+			continue
+		}
+		if !ok {
+			return nil, nil, unexpectedResponse(inv.Name, inv.Args)
+		}
+	}
+	if changes.NewState == "" {
+		return nil, nil, fmt.Errorf("jmap/mail: no Email/changes response")
+	}
+	return &changes, get.List, nil
+}
@@ -0,0 +1,86 @@
+package mail
+
+import (
+	"testing"
+
+	"github.com/foxcpp/go-jmap"
+	"gotest.tools/assert"
+	"gotest.tools/assert/cmp"
+)
+
+func TestFetchNewMail(t *testing.T) {
+	var (
+		since    interface{}
+		getProps []interface{}
+	)
+	c, srv := newTestClient(t, func(name string, args map[string]interface{}) []testResponse {
+		switch name {
+		case "Email/changes":
+			assert.Check(t, cmp.Equal(float64(500), args["maxChanges"]))
+			since = args["sinceState"]
+			switch since {
+			case "1":
+				return []testResponse{{name, map[string]interface{}{
+					"oldState":       "1",
+					"newState":       "2",
+					"hasMoreChanges": true,
+					"created":        []interface{}{"E1", "D1"},
+					"updated":        []interface{}{"E0"},
+				}}}
+			case "2":
+				return []testResponse{{name, map[string]interface{}{
+					"oldState":  "2",
+					"newState":  "3",
+					"created":   []interface{}{"E2"},
+					"destroyed": []interface{}{"E0"},
+				}}}
+			}
+			return []testResponse{{"error", map[string]interface{}{"type": "cannotCalculateChanges"}}}
+		case "Email/get":
+			ref, _ := args["#ids"].(map[string]interface{})
+			assert.Check(t, cmp.Equal("/created", ref["path"]))
+			getProps, _ = args["properties"].([]interface{})
+
+			// The test server does not resolve back-references, use the
+			// changes of the preceding call.
+			var list []interface{}
+			switch since {
+			case "1":
+				list = []interface{}{
+					map[string]interface{}{"id": "E1", "subject": "First", "receivedAt": "2020-01-01T10:00:00Z"},
+					map[string]interface{}{"id": "D1", "subject": "Draft", "keywords": map[string]interface{}{"$draft": true}},
+				}
+			case "2":
+				list = []interface{}{
+					map[string]interface{}{"id": "E2", "subject": "Second", "receivedAt": "2020-01-01T11:00:00Z"},
+				}
+			default:
+				return []testResponse{{"error", map[string]interface{}{"type": "invalidResultReference"}}}
+			}
+			return []testResponse{{name, map[string]interface{}{
+				"state": "3",
+				"list":  list,
+			}}}
+		}
+		t.Fatalf("unexpected call: %s", name)
+		return nil
+	})
+	defer srv.Close()
+
+	nm, err := FetchNewMail(c, "A1", "1", nil)
+	assert.NilError(t, err)
+	assert.Check(t, cmp.Equal("3", nm.State))
+	assert.Assert(t, cmp.Len(nm.Emails, 2))
+	assert.Check(t, cmp.Equal(jmap.ID("E2"), nm.Emails[0].ID))
+	assert.Check(t, cmp.Equal(jmap.ID("E1"), nm.Emails[1].ID))
+	assert.Check(t, cmp.Equal(len(NotificationProperties), len(getProps)))
+
+	_, err = FetchNewMail(c, "A1", "2", []string{"id", "subject"})
+	assert.NilError(t, err)
+	assert.Check(t, cmp.DeepEqual([]interface{}{"id", "subject", "keywords"}, getProps))
+
+	_, err = FetchNewMail(c, "A1", "0", nil)
+	methodErr, ok := err.(jmap.MethodErrorArgs)
+	assert.Assert(t, ok, "unexpected error: %v", err)
+	assert.Check(t, cmp.Equal(jmap.CodeCannotCalculateChanges, methodErr.Type))
+}
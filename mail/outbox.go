@@ -0,0 +1,468 @@
+package mail
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/foxcpp/go-jmap"
+	"github.com/foxcpp/go-jmap/client"
+)
+
+var (
+	ErrOutboxNotFound    = errors.New("jmap/mail: no such outbox entry")
+	ErrOutboxTooLate     = errors.New("jmap/mail: outbox entry is already submitted")
+	ErrOutboxInterrupted = errors.New("jmap/mail: submission was interrupted, the message may or may not have been sent")
+)
+
+// OutboxStatus is the state of the message in Outbox.
+type OutboxStatus string
+
+const (
+	// The message is waiting for the undo window to pass.
+	OutboxPending OutboxStatus = "pending"
+
+	// The submission request is in flight.
+	OutboxSending OutboxStatus = "sending"
+
+	// The submission failed with a transient error and will be retried.
+	OutboxRetrying OutboxStatus = "retrying"
+
+	// The message was submitted successfully, OutboxEntry.Result is set.
+	OutboxSent OutboxStatus = "sent"
+
+	// The submission failed permanently or the number of attempts was
+	// exhausted, OutboxEntry.Error is set.
+	OutboxFailed OutboxStatus = "failed"
+
+	// The message was canceled using Outbox.Cancel.
+	OutboxCanceled OutboxStatus = "canceled"
+)
+
+// Final reports whether the status will not change anymore.
+func (s OutboxStatus) Final() bool {
+	return s == OutboxSent || s == OutboxFailed || s == OutboxCanceled
+}
+
+// OutboxEntry is the message queued in Outbox.
+type OutboxEntry struct {
+	ID      string  `json:"id"`
+	Account jmap.ID `json:"accountId"`
+
+	// Arguments for SendWithOptions.
+	Draft    Email       `json:"draft"`
+	Envelope *Envelope   `json:"envelope,omitempty"`
+	Options  SendOptions `json:"options"`
+
+	Status OutboxStatus `json:"status"`
+
+	// The time of the next submission attempt for OutboxPending and
+	// OutboxRetrying.
+	Due time.Time `json:"due"`
+
+	// Number of submission attempts made so far.
+	Attempts int `json:"attempts"`
+
+	// Set for OutboxSent. For other statuses it may contain EmailID of the
+	// draft created by a failed attempt.
+	Result *SendResult `json:"result,omitempty"`
+
+	// Error message of the last failed attempt.
+	Error string `json:"error,omitempty"`
+
+	// Time of the last status change.
+	Updated time.Time `json:"updated"`
+}
+
+// OutboxStore is the persistent storage for Outbox entries, keyed by
+// OutboxEntry.ID.
+//
+// Outbox saves each entry before acting on it, so after a restart it can
+// continue with messages that were not submitted yet. Use
+// OpenFileOutboxStore for the file-backed implementation.
+type OutboxStore = client.Store[string, OutboxEntry]
+
+// OpenFileOutboxStore opens (creating if necessary) the file-backed
+// OutboxStore.
+func OpenFileOutboxStore(path string) (*client.FileStore[string, OutboxEntry], error) {
+	return client.OpenFileStore(path, func(entry OutboxEntry) string { return entry.ID })
+}
+
+// MemoryOutboxStore is the OutboxStore implementation that keeps entries in
+// memory.
+//
+// It does not survive process restarts and so is mostly useful for testing.
+type MemoryOutboxStore struct {
+	lck     sync.Mutex
+	entries map[string]OutboxEntry
+}
+
+func (ms *MemoryOutboxStore) Save(entry OutboxEntry) error {
+	ms.lck.Lock()
+	defer ms.lck.Unlock()
+	if ms.entries == nil {
+		ms.entries = make(map[string]OutboxEntry)
+	}
+	ms.entries[entry.ID] = entry
+	return nil
+}
+
+func (ms *MemoryOutboxStore) Delete(id string) error {
+	ms.lck.Lock()
+	defer ms.lck.Unlock()
+	delete(ms.entries, id)
+	return nil
+}
+
+func (ms *MemoryOutboxStore) Load() ([]OutboxEntry, error) {
+	ms.lck.Lock()
+	defer ms.lck.Unlock()
+	res := make([]OutboxEntry, 0, len(ms.entries))
+	for _, entry := range ms.entries {
+		res = append(res, entry)
+	}
+	return res, nil
+}
+
+// OutboxOptions contains options for NewOutbox.
+type OutboxOptions struct {
+	// How long messages are held locally before submission, allowing them
+	// to be canceled using Outbox.Cancel. Zero means messages are submitted
+	// as soon as possible.
+	UndoWindow time.Duration
+
+	// Maximum number of submission attempts for a message. Defaults to 5.
+	MaxAttempts int
+
+	// Delay before the first retry, doubled for each subsequent one.
+	// Defaults to 30 seconds.
+	RetryDelay time.Duration
+
+	// Called after each status change of an entry. It is called
+	// synchronously, from the goroutine running Outbox.Run for submission
+	// results and from the caller's goroutine for Enqueue and Cancel.
+	OnStatus func(OutboxEntry)
+
+	// Clock to use for scheduling. If nil, client.SystemClock is used.
+	Clock client.Clock
+}
+
+// Outbox is the queue of outgoing messages. Messages are held for the undo
+// window, then submitted using SendWithOptions. Submissions that fail with
+// transient errors (see client.Classify) are retried with exponential
+// backoff.
+//
+// Submissions that fail after the request was possibly processed by the
+// server (e.g. the connection was lost while waiting for the response) are
+// not retried since the message could be sent twice. They are marked as
+// OutboxFailed with ErrOutboxInterrupted, the same way as submissions
+// interrupted by a restart.
+//
+// Messages are submitted only while Run is running.
+type Outbox struct {
+	c     *client.Client
+	store OutboxStore
+	opts  OutboxOptions
+
+	lck     sync.Mutex
+	entries map[string]*OutboxEntry
+	wake    chan struct{}
+}
+
+// NewOutbox creates the Outbox and loads entries from the store.
+//
+// Entries left in OutboxSending state by a previous run are marked as
+// OutboxFailed with ErrOutboxInterrupted since it is unknown whether they
+// were submitted.
+func NewOutbox(c *client.Client, store OutboxStore, opts OutboxOptions) (*Outbox, error) {
+	if opts.MaxAttempts == 0 {
+		opts.MaxAttempts = 5
+	}
+	if opts.RetryDelay == 0 {
+		opts.RetryDelay = 30 * time.Second
+	}
+	opts.Clock = client.ClockOrSystem(opts.Clock)
+
+	o := &Outbox{
+		c:       c,
+		store:   store,
+		opts:    opts,
+		entries: make(map[string]*OutboxEntry),
+		wake:    make(chan struct{}, 1),
+	}
+
+	entries, err := store.Load()
+	if err != nil {
+		return nil, err
+	}
+	for i := range entries {
+		entry := entries[i]
+		o.entries[entry.ID] = &entry
+		if entry.Status == OutboxSending {
+			if err := o.transition(&entry, OutboxFailed, ErrOutboxInterrupted); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return o, nil
+}
+
+// transition changes the entry status, saves it and reports the change.
+// o.lck must be held if the entry is in o.entries.
+func (o *Outbox) transition(entry *OutboxEntry, status OutboxStatus, err error) error {
+	entry.Status = status
+	entry.Updated = o.opts.Clock.Now()
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	if err := o.store.Save(*entry); err != nil {
+		return err
+	}
+	if o.opts.OnStatus != nil {
+		o.opts.OnStatus(*entry)
+	}
+	return nil
+}
+
+// Enqueue adds the message to the queue. See SendWithOptions for the
+// description of arguments.
+//
+// The returned entry ID can be used to cancel the message within the undo
+// window.
+func (o *Outbox) Enqueue(account jmap.ID, draft Email, envelope *Envelope, opts SendOptions) (string, error) {
+	id, err := o.c.NewID()
+	if err != nil {
+		return "", err
+	}
+	entry := &OutboxEntry{
+		ID:       string(id),
+		Account:  account,
+		Draft:    draft,
+		Envelope: envelope,
+		Options:  opts,
+		Due:      o.opts.Clock.Now().Add(o.opts.UndoWindow),
+	}
+
+	o.lck.Lock()
+	defer o.lck.Unlock()
+	if err := o.transition(entry, OutboxPending, nil); err != nil {
+		return "", err
+	}
+	o.entries[entry.ID] = entry
+	o.notify()
+	return entry.ID, nil
+}
+
+// Cancel cancels submission of the message. ErrOutboxTooLate is returned if
+// the message is being submitted or was submitted already.
+//
+// Use CancelSubmission to cancel messages that were submitted with
+// SendOptions.Hold.
+func (o *Outbox) Cancel(id string) error {
+	o.lck.Lock()
+	defer o.lck.Unlock()
+	entry, ok := o.entries[id]
+	if !ok {
+		return ErrOutboxNotFound
+	}
+	switch entry.Status {
+	case OutboxPending, OutboxRetrying:
+	case OutboxCanceled:
+		return nil
+	default:
+		return ErrOutboxTooLate
+	}
+	if err := o.transition(entry, OutboxCanceled, nil); err != nil {
+		return err
+	}
+	o.notify()
+	return nil
+}
+
+// Remove deletes the entry with the final status (see OutboxStatus.Final)
+// from the queue and the store.
+func (o *Outbox) Remove(id string) error {
+	o.lck.Lock()
+	defer o.lck.Unlock()
+	entry, ok := o.entries[id]
+	if !ok {
+		return ErrOutboxNotFound
+	}
+	if !entry.Status.Final() {
+		return fmt.Errorf("jmap/mail: outbox entry %s is %s, cancel it first", id, entry.Status)
+	}
+	if err := o.store.Delete(id); err != nil {
+		return err
+	}
+	delete(o.entries, id)
+	return nil
+}
+
+// Entries returns copies of all entries in the queue, ordered by due time.
+func (o *Outbox) Entries() []OutboxEntry {
+	o.lck.Lock()
+	defer o.lck.Unlock()
+	res := make([]OutboxEntry, 0, len(o.entries))
+	for _, entry := range o.entries {
+		res = append(res, *entry)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if !res[i].Due.Equal(res[j].Due) {
+			return res[i].Due.Before(res[j].Due)
+		}
+		return res[i].ID < res[j].ID
+	})
+	return res
+}
+
+func (o *Outbox) notify() {
+	select {
+	case o.wake <- struct{}{}:
+	default:
+	}
+}
+
+// next returns ids of entries that are due and the time the next entry
+// becomes due (zero if there are none).
+func (o *Outbox) next(now time.Time) ([]string, time.Time) {
+	o.lck.Lock()
+	defer o.lck.Unlock()
+	var (
+		due  []*OutboxEntry
+		next time.Time
+	)
+	for _, entry := range o.entries {
+		if entry.Status != OutboxPending && entry.Status != OutboxRetrying {
+			continue
+		}
+		if !entry.Due.After(now) {
+			due = append(due, entry)
+			continue
+		}
+		if next.IsZero() || entry.Due.Before(next) {
+			next = entry.Due
+		}
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].Due.Before(due[j].Due) })
+	ids := make([]string, 0, len(due))
+	for _, entry := range due {
+		ids = append(ids, entry.ID)
+	}
+	return ids, next
+}
+
+// Run submits queued messages as they become due until the context is
+// canceled, the client is closed (client.ErrClientClosed is returned) or the
+// store fails.
+//
+// Run should not be called concurrently.
+func (o *Outbox) Run(ctx context.Context) error {
+	ctx, cancel := o.c.BoundContext(ctx)
+	defer cancel()
+
+	for {
+		now := o.opts.Clock.Now()
+		due, next := o.next(now)
+		for _, id := range due {
+			if ctx.Err() != nil {
+				return context.Cause(ctx)
+			}
+			if err := o.submit(id); err != nil {
+				return err
+			}
+		}
+		if len(due) != 0 {
+			continue
+		}
+
+		var timer <-chan time.Time
+		if !next.IsZero() {
+			timer = o.opts.Clock.After(next.Sub(now))
+		}
+		select {
+		case <-timer:
+		case <-o.wake:
+		case <-ctx.Done():
+			return context.Cause(ctx)
+		}
+	}
+}
+
+// submit makes a single submission attempt for the entry. The returned
+// error is the store error or client.ErrClientClosed.
+func (o *Outbox) submit(id string) error {
+	if o.c.Closed() {
+		return client.ErrClientClosed
+	}
+
+	o.lck.Lock()
+	entry, ok := o.entries[id]
+	if !ok || (entry.Status != OutboxPending && entry.Status != OutboxRetrying) {
+		// Canceled meanwhile.
+		o.lck.Unlock()
+		return nil
+	}
+	entry.Attempts++
+	if err := o.transition(entry, OutboxSending, nil); err != nil {
+		o.lck.Unlock()
+		return err
+	}
+	snapshot := *entry
+	o.lck.Unlock()
+
+	if snapshot.Result != nil && snapshot.Result.EmailID != "" {
+		// The draft was created by the previous attempt but was not
+		// submitted, remove it so it is not duplicated.
+		if _, err := o.c.Call(mailUsing, "Email/set", EmailSetArgs{
+			AccountID: snapshot.Account,
+			Destroy:   []jmap.ID{snapshot.Result.EmailID},
+		}); err != nil {
+			return o.complete(id, snapshot.Result, false, err)
+		}
+		snapshot.Result = nil
+	}
+
+	res, maybeSent, err := sendWithOptions(o.c, snapshot.Account, snapshot.Draft, snapshot.Envelope, snapshot.Options)
+	return o.complete(id, res, maybeSent, err)
+}
+
+func (o *Outbox) complete(id string, res *SendResult, maybeSent bool, sendErr error) error {
+	o.lck.Lock()
+	defer o.lck.Unlock()
+	entry := o.entries[id]
+	entry.Result = res
+
+	if sendErr == nil {
+		entry.Error = ""
+		return o.transition(entry, OutboxSent, nil)
+	}
+	if maybeSent {
+		if err := o.transition(entry, OutboxFailed, fmt.Errorf("%w: %v", ErrOutboxInterrupted, sendErr)); err != nil {
+			return err
+		}
+		if sendErr == client.ErrClientClosed {
+			return sendErr
+		}
+		return nil
+	}
+	if sendErr == client.ErrClientClosed {
+		// The request was not sent, the attempt does not count.
+		entry.Attempts--
+		status := OutboxPending
+		if entry.Attempts != 0 {
+			status = OutboxRetrying
+		}
+		if err := o.transition(entry, status, nil); err != nil {
+			return err
+		}
+		return client.ErrClientClosed
+	}
+	if !client.Classify(sendErr).Retryable || entry.Attempts >= o.opts.MaxAttempts {
+		return o.transition(entry, OutboxFailed, sendErr)
+	}
+	entry.Due = o.opts.Clock.Now().Add(o.opts.RetryDelay << uint(entry.Attempts-1))
+	return o.transition(entry, OutboxRetrying, sendErr)
+}
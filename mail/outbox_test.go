@@ -0,0 +1,233 @@
+package mail
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/foxcpp/go-jmap"
+	"github.com/foxcpp/go-jmap/client"
+	"github.com/foxcpp/go-jmap/jmaptest"
+	"gotest.tools/assert"
+	"gotest.tools/assert/cmp"
+)
+
+func TestOutbox(t *testing.T) {
+	var (
+		created   int
+		submitted int
+		destroyed []interface{}
+	)
+	c, srv := newTestClient(t, func(name string, args map[string]interface{}) []testResponse {
+		switch name {
+		case "Email/set":
+			if args["destroy"] != nil {
+				destroyed = append(destroyed, args["destroy"].([]interface{})...)
+				return []testResponse{{name, map[string]interface{}{"destroyed": args["destroy"]}}}
+			}
+			created++
+			return []testResponse{{name, map[string]interface{}{
+				"created": map[string]interface{}{"draft0": map[string]interface{}{"id": "E" + strconv.Itoa(created)}},
+			}}}
+		case "EmailSubmission/set":
+			submitted++
+			if submitted == 1 {
+				return []testResponse{{name, map[string]interface{}{
+					"notCreated": map[string]interface{}{"send1": map[string]interface{}{"type": "rateLimit"}},
+				}}}
+			}
+			return []testResponse{{name, map[string]interface{}{
+				"created": map[string]interface{}{"send1": map[string]interface{}{"id": "S1"}},
+			}}}
+		}
+		t.Fatalf("unexpected call: %s", name)
+		return nil
+	})
+	defer srv.Close()
+
+	draft, err := NewEmailBuilder().
+		From(EmailAddress{Email: "joe@example.com"}).
+		To(EmailAddress{Email: "jane@example.com"}).
+		TextBody("Hello").
+		Build()
+	assert.NilError(t, err)
+	draft.MailboxIDs = map[jmap.ID]bool{"D": true}
+	opts := SendOptions{IdentityID: "I1", KeepDraft: true}
+
+	clock := jmaptest.NewFakeClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	statuses := make(chan OutboxEntry, 16)
+	store := &MemoryOutboxStore{}
+	o, err := NewOutbox(c, store, OutboxOptions{
+		UndoWindow: time.Minute,
+		RetryDelay: time.Minute,
+		Clock:      clock,
+		OnStatus:   func(e OutboxEntry) { statuses <- e },
+	})
+	assert.NilError(t, err)
+
+	expect := func(id string, status OutboxStatus) OutboxEntry {
+		t.Helper()
+		e := <-statuses
+		assert.Check(t, cmp.Equal(id, e.ID))
+		assert.Check(t, cmp.Equal(status, e.Status))
+		return e
+	}
+
+	sent, err := o.Enqueue("A1", draft, nil, opts)
+	assert.NilError(t, err)
+	expect(sent, OutboxPending)
+	canceled, err := o.Enqueue("A1", draft, nil, opts)
+	assert.NilError(t, err)
+	expect(canceled, OutboxPending)
+	assert.NilError(t, o.Cancel(canceled))
+	expect(canceled, OutboxCanceled)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	runErr := make(chan error, 1)
+	go func() { runErr <- o.Run(ctx) }()
+
+	clock.BlockUntil(1)
+	clock.Advance(time.Minute)
+	expect(sent, OutboxSending)
+	e := expect(sent, OutboxRetrying)
+	assert.Check(t, cmp.Equal(1, e.Attempts))
+	assert.Check(t, cmp.Equal(jmap.ID("E1"), e.Result.EmailID))
+	assert.Check(t, cmp.Contains(e.Error, "rateLimit"))
+	assert.Check(t, cmp.Equal(clock.Now().Add(time.Minute), e.Due))
+
+	clock.BlockUntil(1)
+	clock.Advance(time.Minute)
+	expect(sent, OutboxSending)
+	e = expect(sent, OutboxSent)
+	assert.Check(t, cmp.Equal(2, e.Attempts))
+	assert.Check(t, cmp.DeepEqual(&SendResult{EmailID: "E2", SubmissionID: "S1"}, e.Result))
+	assert.Check(t, cmp.Equal("", e.Error))
+
+	cancel()
+	assert.Equal(t, context.Canceled, <-runErr)
+	assert.Check(t, cmp.DeepEqual([]interface{}{"E1"}, destroyed), "draft from the failed attempt should be removed")
+	assert.Check(t, cmp.Equal(2, submitted))
+	assert.Check(t, cmp.Equal(ErrOutboxTooLate, o.Cancel(sent)))
+
+	t.Run("restart", func(t *testing.T) {
+		entries, err := store.Load()
+		assert.NilError(t, err)
+		assert.Equal(t, 2, len(entries))
+
+		dir, err := ioutil.TempDir("", "go-jmap-outbox-")
+		assert.NilError(t, err)
+		defer os.RemoveAll(dir)
+		path := filepath.Join(dir, "outbox")
+
+		// Simulate a crash in the middle of submission.
+		fileStore, err := OpenFileOutboxStore(path)
+		assert.NilError(t, err)
+		for _, entry := range entries {
+			if entry.ID == sent {
+				entry.Status = OutboxSending
+			}
+			assert.NilError(t, fileStore.Save(entry))
+		}
+		assert.NilError(t, fileStore.Close())
+
+		restored, err := OpenFileOutboxStore(path)
+		assert.NilError(t, err)
+		defer restored.Close()
+		o, err := NewOutbox(c, restored, OutboxOptions{Clock: clock})
+		assert.NilError(t, err)
+		entries = o.Entries()
+		assert.Equal(t, 2, len(entries))
+		for _, entry := range entries {
+			if entry.ID == sent {
+				assert.Check(t, cmp.DeepEqual(&SendResult{EmailID: "E2", SubmissionID: "S1"}, entry.Result))
+				assert.Check(t, cmp.Equal(OutboxFailed, entry.Status))
+				assert.Check(t, cmp.Equal(ErrOutboxInterrupted.Error(), entry.Error))
+			} else {
+				assert.Check(t, cmp.Equal(OutboxCanceled, entry.Status))
+			}
+		}
+
+		assert.NilError(t, o.Remove(canceled))
+		assert.Equal(t, 1, len(o.Entries()))
+		assert.Check(t, cmp.Equal(ErrOutboxNotFound, o.Cancel(canceled)))
+	})
+}
+
+func TestOutboxAmbiguousFailure(t *testing.T) {
+	var attempts int32
+	c, srv := newTestClient(t, func(name string, args map[string]interface{}) []testResponse {
+		if name == "Email/set" {
+			// The server processed the request but the response was lost.
+			atomic.AddInt32(&attempts, 1)
+			panic(http.ErrAbortHandler)
+		}
+		t.Fatalf("unexpected call: %s", name)
+		return nil
+	})
+	defer srv.Close()
+
+	draft := Email{From: []EmailAddress{{Email: "joe@example.com"}}, MailboxIDs: map[jmap.ID]bool{"D": true}}
+	clock := jmaptest.NewFakeClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	statuses := make(chan OutboxEntry, 16)
+	o, err := NewOutbox(c, &MemoryOutboxStore{}, OutboxOptions{
+		Clock:    clock,
+		OnStatus: func(e OutboxEntry) { statuses <- e },
+	})
+	assert.NilError(t, err)
+	id, err := o.Enqueue("A1", draft, nil, SendOptions{IdentityID: "I1", KeepDraft: true})
+	assert.NilError(t, err)
+	<-statuses
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go o.Run(ctx) //nolint:errcheck
+
+	assert.Check(t, cmp.Equal(OutboxSending, (<-statuses).Status))
+	e := <-statuses
+	assert.Check(t, cmp.Equal(id, e.ID))
+	assert.Check(t, cmp.Equal(OutboxFailed, e.Status))
+	assert.Check(t, cmp.Contains(e.Error, ErrOutboxInterrupted.Error()))
+	assert.Check(t, cmp.Equal(int32(1), atomic.LoadInt32(&attempts)))
+}
+
+func TestOutboxClientClosed(t *testing.T) {
+	c, srv := newTestClient(t, func(name string, args map[string]interface{}) []testResponse {
+		t.Fatalf("unexpected call: %s", name)
+		return nil
+	})
+	defer srv.Close()
+
+	draft := Email{From: []EmailAddress{{Email: "joe@example.com"}}, MailboxIDs: map[jmap.ID]bool{"D": true}}
+	o, err := NewOutbox(c, &MemoryOutboxStore{}, OutboxOptions{
+		Clock: jmaptest.NewFakeClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)),
+	})
+	assert.NilError(t, err)
+	_, err = o.Enqueue("A1", draft, nil, SendOptions{IdentityID: "I1", KeepDraft: true})
+	assert.NilError(t, err)
+
+	assert.NilError(t, c.Close())
+	assert.Equal(t, client.ErrClientClosed, o.Run(context.Background()))
+
+	entries := o.Entries()
+	assert.Assert(t, cmp.Len(entries, 1))
+	assert.Check(t, cmp.Equal(OutboxPending, entries[0].Status))
+	assert.Check(t, cmp.Equal(0, entries[0].Attempts))
+
+	// Run waiting for due entries stops on Close too.
+	c, srv = newTestClient(t, nil)
+	defer srv.Close()
+	o, err = NewOutbox(c, &MemoryOutboxStore{}, OutboxOptions{})
+	assert.NilError(t, err)
+	runErr := make(chan error, 1)
+	go func() {
+		runErr <- o.Run(context.Background())
+	}()
+	assert.NilError(t, c.Close())
+	assert.Check(t, cmp.Equal(client.ErrClientClosed, <-runErr))
+}
@@ -0,0 +1,217 @@
+package mail
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/foxcpp/go-jmap"
+)
+
+var pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+func keywordPath(keyword string) string {
+	return "keywords/" + pointerEscaper.Replace(keyword)
+}
+
+func mailboxPath(mailbox jmap.ID) string {
+	return "mailboxIds/" + pointerEscaper.Replace(string(mailbox))
+}
+
+// KeywordPatch returns the patch that sets (if set is true) or removes the
+// keyword leaving other keywords intact.
+//
+// Keywords are case-insensitive and so the keyword is converted to lowercase.
+func KeywordPatch(keyword string, set bool) jmap.PatchObject {
+	path := keywordPath(strings.ToLower(keyword))
+	if set {
+		return jmap.PatchObject{path: true}
+	}
+	return jmap.PatchObject{path: nil}
+}
+
+// MarkReadPatch returns the patch that sets the $seen keyword.
+func MarkReadPatch() jmap.PatchObject {
+	return KeywordPatch(KeywordSeen, true)
+}
+
+// MarkUnreadPatch returns the patch that removes the $seen keyword.
+func MarkUnreadPatch() jmap.PatchObject {
+	return KeywordPatch(KeywordSeen, false)
+}
+
+// FlagPatch returns the patch that sets the $flagged keyword.
+func FlagPatch() jmap.PatchObject {
+	return KeywordPatch(KeywordFlagged, true)
+}
+
+// UnflagPatch returns the patch that removes the $flagged keyword.
+func UnflagPatch() jmap.PatchObject {
+	return KeywordPatch(KeywordFlagged, false)
+}
+
+// MovePatch returns the patch that removes the Email from the Mailbox from
+// and adds it to the Mailbox to. Membership in other Mailboxes is not
+// changed.
+func MovePatch(from, to jmap.ID) jmap.PatchObject {
+	return jmap.PatchObject{
+		mailboxPath(from): nil,
+		mailboxPath(to):   true,
+	}
+}
+
+// MoveToPatch returns the patch that makes the Mailbox the only one the
+// Email belongs to.
+func MoveToPatch(to jmap.ID) jmap.PatchObject {
+	return jmap.PatchObject{
+		"mailboxIds": map[jmap.ID]bool{to: true},
+	}
+}
+
+// MergePatches combines multiple patches into one. For paths present in
+// multiple patches, the value from the last one is used.
+//
+// Note that the result is not a valid patch if one patch sets the whole
+// property (e.g. "keywords") and another one sets its member
+// ("keywords/$seen").
+func MergePatches(patches ...jmap.PatchObject) jmap.PatchObject {
+	res := jmap.PatchObject{}
+	for _, patch := range patches {
+		for path, val := range patch {
+			res[path] = val
+		}
+	}
+	return res
+}
+
+// JunkPatch returns the patch that marks Email as spam: $junk keyword is
+// set and $notjunk is removed.
+//
+// If junkMailbox is not empty, the Email is also moved to it, removing it
+// from all other Mailboxes.
+func JunkPatch(junkMailbox jmap.ID) jmap.PatchObject {
+	patch := jmap.PatchObject{
+		keywordPath(KeywordJunk):    true,
+		keywordPath(KeywordNotJunk): nil,
+	}
+	if junkMailbox != "" {
+		patch["mailboxIds"] = map[jmap.ID]bool{junkMailbox: true}
+	}
+	return patch
+}
+
+// NotJunkPatch returns the patch that marks Email as not spam: $notjunk
+// keyword is set and $junk is removed.
+//
+// If both junkMailbox and toMailbox are not empty, the Email is also moved
+// from junkMailbox to toMailbox.
+func NotJunkPatch(junkMailbox, toMailbox jmap.ID) jmap.PatchObject {
+	patch := jmap.PatchObject{
+		keywordPath(KeywordJunk):    nil,
+		keywordPath(KeywordNotJunk): true,
+	}
+	if junkMailbox != "" && toMailbox != "" {
+		patch[mailboxPath(junkMailbox)] = nil
+		patch[mailboxPath(toMailbox)] = true
+	}
+	return patch
+}
+
+// JunkSetArgs returns Email/set arguments that apply JunkPatch (if isJunk is
+// true) or NotJunkPatch to all specified Emails in one call.
+func JunkSetArgs(account jmap.ID, emails []jmap.ID, isJunk bool, junkMailbox, toMailbox jmap.ID) EmailSetArgs {
+	args := EmailSetArgs{
+		AccountID: account,
+		Update:    make(map[jmap.ID]jmap.PatchObject, len(emails)),
+	}
+	for _, id := range emails {
+		if isJunk {
+			args.Update[id] = JunkPatch(junkMailbox)
+		} else {
+			args.Update[id] = NotJunkPatch(junkMailbox, toMailbox)
+		}
+	}
+	return args
+}
+
+// MailboxShareWithPatch returns the Mailbox/set patch that changes the
+// shareWith property from current to desired, see jmap.ShareWithDiffPatch.
+//
+// Use jmap.ShareWithPatch and jmap.UnsharePatch to share the Mailbox with a
+// single principal or revoke its access.
+func MailboxShareWithPatch(current, desired map[jmap.ID]MailboxRights) jmap.PatchObject {
+	// MailboxRights is always encoded successfully.
+	patch, _ := jmap.ShareWithDiffPatch(current, desired)
+	return patch
+}
+
+// serverPatch converts the object echoed by the server in the updated map of
+// the /set response into the patch that sets all properties present in it.
+func serverPatch(echo interface{}) (jmap.PatchObject, error) {
+	blob, err := json.Marshal(echo)
+	if err != nil {
+		return nil, err
+	}
+	var patch jmap.PatchObject
+	if err := json.Unmarshal(blob, &patch); err != nil {
+		return nil, err
+	}
+	return patch, nil
+}
+
+// ApplyUpdated brings local copies of Emails in sync after a successful
+// Email/set call: for each updated Email present in emails, the patch from
+// args is applied, followed by the properties the server changed on its own
+// (e.g. normalized keywords).
+//
+// Emails that failed to update are left intact.
+//
+// Note that properties echoed by the server with a default value (e.g. empty
+// string) can't be distinguished from missing ones and are not applied.
+func (r EmailSetResponse) ApplyUpdated(args EmailSetArgs, emails map[jmap.ID]*Email) error {
+	for id, echo := range r.Updated {
+		e, ok := emails[id]
+		if !ok || e == nil {
+			continue
+		}
+		if err := jmap.ApplyPatch(e, args.Update[id]); err != nil {
+			return fmt.Errorf("jmap/mail: email %v: %v", id, err)
+		}
+		if echo == nil {
+			continue
+		}
+		patch, err := serverPatch(echo)
+		if err != nil {
+			return fmt.Errorf("jmap/mail: email %v: %v", id, err)
+		}
+		if err := jmap.ApplyPatch(e, patch); err != nil {
+			return fmt.Errorf("jmap/mail: email %v: %v", id, err)
+		}
+	}
+	return nil
+}
+
+// ApplyUpdated brings local copies of Mailboxes in sync after a successful
+// Mailbox/set call. See EmailSetResponse.ApplyUpdated for details.
+func (r MailboxSetResponse) ApplyUpdated(args MailboxSetArgs, mailboxes map[jmap.ID]*Mailbox) error {
+	for id, echo := range r.Updated {
+		mbox, ok := mailboxes[id]
+		if !ok || mbox == nil {
+			continue
+		}
+		if err := jmap.ApplyPatch(mbox, args.Update[id]); err != nil {
+			return fmt.Errorf("jmap/mail: mailbox %v: %v", id, err)
+		}
+		if echo == nil {
+			continue
+		}
+		patch, err := serverPatch(echo)
+		if err != nil {
+			return fmt.Errorf("jmap/mail: mailbox %v: %v", id, err)
+		}
+		if err := jmap.ApplyPatch(mbox, patch); err != nil {
+			return fmt.Errorf("jmap/mail: mailbox %v: %v", id, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package mail
+
+import (
+	"testing"
+
+	"github.com/foxcpp/go-jmap"
+	"gotest.tools/assert"
+	"gotest.tools/assert/cmp"
+)
+
+func TestJunkPatch(t *testing.T) {
+	assert.Check(t, cmp.DeepEqual(jmap.PatchObject{
+		"keywords/$junk":    true,
+		"keywords/$notjunk": nil,
+		"mailboxIds":        map[jmap.ID]bool{"junk": true},
+	}, JunkPatch("junk")))
+
+	assert.Check(t, cmp.DeepEqual(jmap.PatchObject{
+		"keywords/$junk":    nil,
+		"keywords/$notjunk": true,
+		"mailboxIds/junk":   nil,
+		"mailboxIds/inbox":  true,
+	}, NotJunkPatch("junk", "inbox")))
+
+	args := JunkSetArgs("A1", []jmap.ID{"M1", "M2"}, false, "", "")
+	assert.Check(t, cmp.Len(args.Update, 2))
+	assert.Check(t, cmp.DeepEqual(jmap.PatchObject{
+		"keywords/$junk":    nil,
+		"keywords/$notjunk": true,
+	}, args.Update["M2"]))
+}
+
+func TestLabelPatches(t *testing.T) {
+	assert.Check(t, cmp.DeepEqual(jmap.PatchObject{"mailboxIds/M~11": true}, AddLabelPatch("M/1")))
+	assert.Check(t, cmp.DeepEqual(jmap.PatchObject{"mailboxIds/M1": nil}, RemoveLabelPatch("M1")))
+}
+
+func TestKeywordPatches(t *testing.T) {
+	assert.Check(t, cmp.DeepEqual(jmap.PatchObject{"keywords/$seen": true}, MarkReadPatch()))
+	assert.Check(t, cmp.DeepEqual(jmap.PatchObject{"keywords/$seen": nil}, MarkUnreadPatch()))
+	assert.Check(t, cmp.DeepEqual(jmap.PatchObject{"keywords/$flagged": true}, FlagPatch()))
+	assert.Check(t, cmp.DeepEqual(jmap.PatchObject{"keywords/$flagged": nil}, UnflagPatch()))
+	assert.Check(t, cmp.DeepEqual(jmap.PatchObject{"keywords/my~1label": true}, KeywordPatch("My/Label", true)))
+
+	assert.Check(t, cmp.DeepEqual(jmap.PatchObject{
+		"keywords/$seen": true,
+		"mailboxIds/M1":  nil,
+		"mailboxIds/M2":  true,
+	}, MergePatches(MarkReadPatch(), MovePatch("M1", "M2"))))
+	assert.Check(t, cmp.DeepEqual(jmap.PatchObject{
+		"mailboxIds": map[jmap.ID]bool{"M2": true},
+	}, MoveToPatch("M2")))
+}
+
+func TestEmailSetResponseApplyUpdated(t *testing.T) {
+	emails := map[jmap.ID]*Email{
+		"M1": {
+			ID:         "M1",
+			Subject:    "Hello",
+			Keywords:   map[string]bool{"$seen": true},
+			MailboxIDs: map[jmap.ID]bool{"inbox": true},
+		},
+		"M2": {ID: "M2", Keywords: map[string]bool{"$seen": true}},
+	}
+	args := EmailSetArgs{
+		AccountID: "A1",
+		Update: map[jmap.ID]jmap.PatchObject{
+			"M1": MergePatches(KeywordPatch("$Flagged", true), MovePatch("inbox", "archive")),
+			"M2": MarkUnreadPatch(),
+		},
+	}
+	resp := EmailSetResponse{
+		Updated: map[jmap.ID]*Email{
+			// Server added $notjunk on its own.
+			"M1": {Keywords: map[string]bool{"$seen": true, "$flagged": true, "$notjunk": true}},
+		},
+		NotUpdated: map[jmap.ID]jmap.SetError{"M2": {Type: jmap.CodeForbidden}},
+	}
+
+	assert.NilError(t, resp.ApplyUpdated(args, emails))
+	assert.Check(t, cmp.Equal("Hello", emails["M1"].Subject))
+	assert.Check(t, cmp.DeepEqual(map[string]bool{"$seen": true, "$flagged": true, "$notjunk": true}, emails["M1"].Keywords))
+	assert.Check(t, cmp.DeepEqual(map[jmap.ID]bool{"archive": true}, emails["M1"].MailboxIDs))
+	assert.Check(t, cmp.DeepEqual(map[string]bool{"$seen": true}, emails["M2"].Keywords))
+}
+
+func TestMailboxShareWithPatch(t *testing.T) {
+	readOnly := MailboxRights{MayReadItems: true, MaySetSeen: true}
+	mb := Mailbox{ShareWith: map[jmap.ID]MailboxRights{"bob": readOnly}}
+
+	desired := map[jmap.ID]MailboxRights{"alice": readOnly}
+	patch := MailboxShareWithPatch(mb.ShareWith, desired)
+	assert.Check(t, cmp.Len(patch, 2))
+	assert.Check(t, cmp.Nil(patch["shareWith/bob"]))
+
+	assert.NilError(t, jmap.ApplyPatch(&mb, patch))
+	assert.Check(t, cmp.DeepEqual(desired, mb.ShareWith))
+}
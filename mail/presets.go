@@ -0,0 +1,50 @@
+package mail
+
+// EnvelopeProperties lists Email metadata and parsed header field properties
+// (RFC 8621, sections 4.1.1 and 4.1.3). It is enough to display message
+// details or thread messages without touching the body.
+var EnvelopeProperties = []string{
+	"id", "blobId", "threadId", "mailboxIds", "keywords", "size",
+	"receivedAt", "messageId", "inReplyTo", "references", "sender", "from",
+	"to", "cc", "bcc", "replyTo", "subject", "sentAt",
+}
+
+// ListViewProperties lists Email properties usually needed to show a message
+// in a mailbox listing. preview is computed by the server so the body does
+// not need to be fetched.
+var ListViewProperties = []string{
+	"id", "threadId", "mailboxIds", "keywords", "size", "receivedAt", "from",
+	"to", "subject", "sentAt", "hasAttachment", "preview",
+}
+
+// FullProperties lists the properties returned by Email/get when the
+// properties argument is omitted (RFC 8621, section 4.2).
+//
+// Note that bodyValues is empty unless one of the Fetch*BodyValues arguments
+// of EmailGetArgs is set. Use MaxBodyValueBytes to avoid fetching large
+// bodies.
+var FullProperties = []string{
+	"id", "blobId", "threadId", "mailboxIds", "keywords", "size",
+	"receivedAt", "messageId", "inReplyTo", "references", "sender", "from",
+	"to", "cc", "bcc", "replyTo", "subject", "sentAt", "hasAttachment",
+	"preview", "bodyValues", "textBody", "htmlBody", "attachments",
+}
+
+// MergeProperties returns a new list containing properties from preset
+// followed by extra properties not already present in it.
+//
+//	props := MergeProperties(ListViewProperties, "header:List-Id:asText")
+func MergeProperties(preset []string, extra ...string) []string {
+	res := make([]string, 0, len(preset)+len(extra))
+	seen := make(map[string]bool, len(preset)+len(extra))
+	for _, list := range [][]string{preset, extra} {
+		for _, prop := range list {
+			if seen[prop] {
+				continue
+			}
+			seen[prop] = true
+			res = append(res, prop)
+		}
+	}
+	return res
+}
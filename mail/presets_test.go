@@ -0,0 +1,27 @@
+package mail
+
+import (
+	"testing"
+
+	"github.com/foxcpp/go-jmap"
+	"gotest.tools/assert"
+)
+
+func TestMergeProperties(t *testing.T) {
+	base := []string{"id", "subject"}
+	merged := MergeProperties(base, "preview", "id", "header:List-Id:asText", "preview")
+	assert.DeepEqual(t, merged, []string{"id", "subject", "preview", "header:List-Id:asText"})
+	assert.DeepEqual(t, base, []string{"id", "subject"})
+
+	merged[0] = "blobId"
+	assert.Equal(t, base[0], "id")
+}
+
+func TestPropertyPresetsKnown(t *testing.T) {
+	for _, preset := range [][]string{EnvelopeProperties, ListViewProperties, FullProperties} {
+		assert.Assert(t, jmap.PropertySchemas(PropertySchemas).Validate(jmap.Invocation{
+			Name: "Email/get",
+			Args: EmailGetArgs{AccountID: "A1", Properties: preset},
+		}) == nil)
+	}
+}
@@ -0,0 +1,223 @@
+package mail
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/foxcpp/go-jmap"
+	"github.com/foxcpp/go-jmap/client"
+)
+
+// probeSampleSize is the number of Emails checked by the collapseThreads
+// probe.
+const probeSampleSize = 50
+
+// Features describes server behaviors that are not advertised in
+// capabilities, as detected by FeatureProbe.
+type Features struct {
+	// The server honors the collapseThreads argument of Email/query.
+	//
+	// It is detected by checking that no two Emails in the first results of
+	// a collapsed query belong to the same Thread, so the server is assumed
+	// to honor it if the account has no Threads with multiple Emails.
+	CollapseThreads bool
+
+	// The server implements Email/queryChanges and can calculate changes
+	// at least for queries without filter.
+	EmailQueryChanges bool
+}
+
+// FeatureProbe detects Features of accounts by issuing cheap canary calls
+// and caches results until the Session state changes.
+//
+// Zero value is ready to use. FeatureProbe is safe for concurrent use.
+type FeatureProbe struct {
+	lck   sync.Mutex
+	cache map[jmap.ID]probedFeatures
+}
+
+type probedFeatures struct {
+	sessionState string
+	features     Features
+}
+
+// Features returns Features of the account, probing them if they are not
+// cached for the current Session. All probes are sent in a single request.
+//
+// The client must have ResponseUnmarshallers enabled.
+func (fp *FeatureProbe) Features(c *client.Client, account jmap.ID) (Features, error) {
+	session, err := c.CurrentSession()
+	if err != nil {
+		return Features{}, err
+	}
+
+	fp.lck.Lock()
+	cached, ok := fp.cache[account]
+	fp.lck.Unlock()
+	if ok && cached.sessionState == session.State {
+		return cached.features, nil
+	}
+
+	features, err := probeFeatures(c, account)
+	if err != nil {
+		return Features{}, err
+	}
+
+	fp.lck.Lock()
+	defer fp.lck.Unlock()
+	if fp.cache == nil {
+		fp.cache = make(map[jmap.ID]probedFeatures)
+	}
+	fp.cache[account] = probedFeatures{sessionState: session.State, features: features}
+	return features, nil
+}
+
+func probeFeatures(c *client.Client, account jmap.ID) (Features, error) {
+	b := &client.Batch{}
+	useMail(b)
+
+	collapseCall := b.NextCallID()
+	b.Add("Email/query", EmailQueryArgs{
+		AccountID:       account,
+		CollapseThreads: true,
+		Limit:           probeSampleSize,
+	})
+	threadsCall := b.NextCallID()
+	b.Add("Email/get", map[string]interface{}{
+		"accountId":  account,
+		"properties": []string{"threadId"},
+		"#ids": jmap.ResultReference{
+			ResultOf: collapseCall,
+			Name:     "Email/query",
+			Path:     "/ids",
+		},
+	})
+	stateCall := b.NextCallID()
+	b.Add("Email/query", EmailQueryArgs{
+		AccountID: account,
+		Limit:     1,
+	})
+	changesCall := b.NextCallID()
+	b.Add("Email/queryChanges", map[string]interface{}{
+		"accountId": account,
+		"#sinceQueryState": jmap.ResultReference{
+			ResultOf: stateCall,
+			Name:     "Email/query",
+			Path:     "/queryState",
+		},
+	})
+
+	resp, err := c.RawSend(b.Request())
+	if err != nil {
+		return Features{}, err
+	}
+
+	var (
+		features     Features
+		collapseOK   = true
+		threads      []Email
+		stateResp    *EmailQueryResponse
+		stateFailure error
+	)
+	for _, inv := range resp.Responses {
+		methodErr, isErr := inv.Args.(jmap.MethodErrorArgs)
+		switch inv.CallID {
+		case collapseCall, threadsCall:
+			// The server may reject collapseThreads as unknown argument.
+			if isErr {
+				collapseOK = false
+				continue
+			}
+			if get, ok := inv.Args.(EmailGetResponse); ok {
+				threads = get.List
+			}
+		case stateCall:
+			if isErr {
+				stateFailure = methodErr
+				continue
+			}
+			query, ok := inv.Args.(EmailQueryResponse)
+			if !ok {
+				return Features{}, unexpectedResponse(inv.Name, inv.Args)
+			}
+			stateResp = &query
+		case changesCall:
+			features.EmailQueryChanges = !isErr
+		}
+	}
+	if stateFailure != nil {
+		return Features{}, stateFailure
+	}
+	if stateResp == nil {
+		return Features{}, fmt.Errorf("jmap/mail: no Email/query response")
+	}
+	if !stateResp.CanCalculateChanges {
+		features.EmailQueryChanges = false
+	}
+
+	if collapseOK {
+		seen := make(map[jmap.ID]bool, len(threads))
+		for _, e := range threads {
+			if seen[e.ThreadID] {
+				collapseOK = false
+				break
+			}
+			seen[e.ThreadID] = true
+		}
+	}
+	features.CollapseThreads = collapseOK
+	return features, nil
+}
+
+// RefreshQuery brings the cached results of the query up to date. ids and
+// queryState are the results of the previous call (or of a query with the
+// same filter and sort). If queryState is empty, the query is run from
+// scratch.
+//
+// If the server supports Email/queryChanges (see Features), changes are
+// fetched and applied to ids. Otherwise, or if the server can't calculate
+// changes since queryState, all results are fetched again using
+// EmailIterator, so ids must contain all results of the query.
+//
+// The client must have ResponseUnmarshallers enabled.
+func (fp *FeatureProbe) RefreshQuery(c *client.Client, args EmailQueryArgs, ids []jmap.ID, queryState string) ([]jmap.ID, string, error) {
+	if queryState != "" {
+		features, err := fp.Features(c, args.AccountID)
+		if err != nil {
+			return nil, "", err
+		}
+		if features.EmailQueryChanges {
+			resp, err := queryEmailChanges(c, args.QueryChanges(queryState))
+			if err == nil {
+				return resp.Apply(ids), resp.NewQueryState, nil
+			}
+			methodErr, ok := err.(jmap.MethodErrorArgs)
+			if !ok || methodErr.Type != jmap.CodeCannotCalculateChanges {
+				return nil, "", err
+			}
+		}
+	}
+
+	args.Position = 0
+	it := NewEmailIterator(c, args)
+	var res []jmap.ID
+	for it.Next() {
+		res = append(res, it.ID())
+	}
+	if err := it.Err(); err != nil {
+		return nil, "", err
+	}
+	return res, it.QueryState(), nil
+}
+
+func queryEmailChanges(c *client.Client, args EmailQueryChangesArgs) (*EmailQueryChangesResponse, error) {
+	respArgs, err := c.Call(mailUsing, "Email/queryChanges", args)
+	if err != nil {
+		return nil, err
+	}
+	resp, ok := respArgs.(EmailQueryChangesResponse)
+	if !ok {
+		return nil, unexpectedResponse("Email/queryChanges", respArgs)
+	}
+	return &resp, nil
+}
@@ -0,0 +1,111 @@
+package mail
+
+import (
+	"testing"
+
+	"github.com/foxcpp/go-jmap"
+	"gotest.tools/assert"
+	"gotest.tools/assert/cmp"
+)
+
+type probeServer struct {
+	collapse     bool
+	queryChanges bool
+	probes       int
+	calls        []string
+}
+
+func (s *probeServer) handle(name string, args map[string]interface{}) []testResponse {
+	s.calls = append(s.calls, name)
+	switch name {
+	case "Email/query":
+		if args["collapseThreads"] == true {
+			s.probes++
+			return []testResponse{{name, map[string]interface{}{
+				"accountId": "A1", "queryState": "q1", "ids": []string{"E1", "E2"},
+			}}}
+		}
+		ids := []string{"E1", "E2", "E3"}
+		if args["anchor"] != nil {
+			ids = []string{}
+		}
+		return []testResponse{{name, map[string]interface{}{
+			"accountId": "A1", "queryState": "q2", "ids": ids, "canCalculateChanges": s.queryChanges,
+		}}}
+	case "Email/get":
+		threads := []string{"T1", "T2"}
+		if !s.collapse {
+			threads = []string{"T1", "T1"}
+		}
+		return []testResponse{{name, map[string]interface{}{
+			"accountId": "A1", "state": "s1",
+			"list": []map[string]interface{}{
+				{"id": "E1", "threadId": threads[0]},
+				{"id": "E2", "threadId": threads[1]},
+			},
+		}}}
+	case "Email/queryChanges":
+		if !s.queryChanges {
+			return []testResponse{{"error", map[string]interface{}{"type": "unknownMethod"}}}
+		}
+		if args["sinceQueryState"] == "old" {
+			return []testResponse{{"error", map[string]interface{}{"type": "cannotCalculateChanges"}}}
+		}
+		return []testResponse{{name, map[string]interface{}{
+			"accountId": "A1", "oldQueryState": "q2", "newQueryState": "q3",
+			"removed": []string{"E2"},
+			"added":   []map[string]interface{}{{"id": "E4", "index": 0}},
+		}}}
+	}
+	return []testResponse{{"error", map[string]interface{}{"type": "unknownMethod"}}}
+}
+
+func TestFeatureProbe(t *testing.T) {
+	s := &probeServer{collapse: true, queryChanges: true}
+	c, srv := newTestClient(t, s.handle)
+	defer srv.Close()
+
+	fp := &FeatureProbe{}
+	features, err := fp.Features(c, "A1")
+	assert.NilError(t, err)
+	assert.Check(t, cmp.DeepEqual(Features{CollapseThreads: true, EmailQueryChanges: true}, features))
+	assert.Check(t, cmp.DeepEqual([]string{"Email/query", "Email/get", "Email/query", "Email/queryChanges"}, s.calls))
+
+	// Cached for the same Session.
+	_, err = fp.Features(c, "A1")
+	assert.NilError(t, err)
+	assert.Check(t, cmp.Equal(1, s.probes))
+
+	s.calls = nil
+	ids, state, err := fp.RefreshQuery(c, EmailQueryArgs{AccountID: "A1"}, []jmap.ID{"E1", "E2", "E3"}, "q2")
+	assert.NilError(t, err)
+	assert.Check(t, cmp.DeepEqual([]jmap.ID{"E4", "E1", "E3"}, ids))
+	assert.Check(t, cmp.Equal("q3", state))
+	assert.Check(t, cmp.DeepEqual([]string{"Email/queryChanges"}, s.calls))
+
+	// The server cannot calculate changes, the query is run again.
+	s.calls = nil
+	ids, state, err = fp.RefreshQuery(c, EmailQueryArgs{AccountID: "A1"}, []jmap.ID{"E1"}, "old")
+	assert.NilError(t, err)
+	assert.Check(t, cmp.DeepEqual([]jmap.ID{"E1", "E2", "E3"}, ids))
+	assert.Check(t, cmp.Equal("q2", state))
+	assert.Check(t, cmp.DeepEqual([]string{"Email/queryChanges", "Email/query", "Email/query"}, s.calls))
+}
+
+func TestFeatureProbe_Unsupported(t *testing.T) {
+	s := &probeServer{}
+	c, srv := newTestClient(t, s.handle)
+	defer srv.Close()
+
+	fp := &FeatureProbe{}
+	features, err := fp.Features(c, "A1")
+	assert.NilError(t, err)
+	assert.Check(t, cmp.DeepEqual(Features{}, features))
+
+	s.calls = nil
+	ids, state, err := fp.RefreshQuery(c, EmailQueryArgs{AccountID: "A1"}, []jmap.ID{"E1"}, "q2")
+	assert.NilError(t, err)
+	assert.Check(t, cmp.DeepEqual([]jmap.ID{"E1", "E2", "E3"}, ids))
+	assert.Check(t, cmp.Equal("q2", state))
+	assert.Check(t, cmp.DeepEqual([]string{"Email/query", "Email/query"}, s.calls))
+}
@@ -0,0 +1,279 @@
+package mail
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/foxcpp/go-jmap"
+)
+
+var (
+	ErrUnsupportedSort      = errors.New("jmap/mail: sort property is not supported by the server")
+	ErrUnsupportedCollation = errors.New("jmap/mail: collation algorithm is not supported by the server")
+)
+
+// QueryValidationError is returned by QueryBuilder.Validate if the query
+// uses features not advertised by the server.
+type QueryValidationError struct {
+	// Sort property or collation name.
+	Value string
+
+	// ErrUnsupportedSort or ErrUnsupportedCollation.
+	Err error
+}
+
+func (qve *QueryValidationError) Error() string {
+	return fmt.Sprintf("%v: %s", qve.Err, qve.Value)
+}
+
+// QueryBuilder constructs the filter and sort for Email/query using chained
+// method calls:
+//
+//	args := mail.Query().
+//		From("joe@example.org").
+//		HasAttachment().
+//		InMailbox(inbox).
+//		After(time.Now().Add(-7*24*time.Hour)).
+//		SortBy(mail.SortReceivedAt, false).
+//		Args(account)
+//
+// All conditions added to the builder must match (they are combined using
+// AND operator). Use Or and Not to build more complex filters.
+type QueryBuilder struct {
+	conds []interface{}
+	sort  []EmailComparator
+}
+
+// Query returns a new empty QueryBuilder that matches all Emails.
+func Query() *QueryBuilder {
+	return &QueryBuilder{}
+}
+
+func (qb *QueryBuilder) add(cond interface{}) *QueryBuilder {
+	qb.conds = append(qb.conds, cond)
+	return qb
+}
+
+// InMailbox requires the Email to be in the Mailbox.
+func (qb *QueryBuilder) InMailbox(id jmap.ID) *QueryBuilder {
+	return qb.add(&EmailFilterCondition{InMailbox: id})
+}
+
+// InMailboxOtherThan requires the Email to be in at least one Mailbox not
+// in the list.
+func (qb *QueryBuilder) InMailboxOtherThan(ids ...jmap.ID) *QueryBuilder {
+	return qb.add(&EmailFilterCondition{InMailboxOtherThan: ids})
+}
+
+// Before requires receivedAt of the Email to be before t.
+func (qb *QueryBuilder) Before(t time.Time) *QueryBuilder {
+	date := jmap.UTCDate(t.UTC())
+	return qb.add(&EmailFilterCondition{Before: &date})
+}
+
+// After requires receivedAt of the Email to be the same or after t.
+func (qb *QueryBuilder) After(t time.Time) *QueryBuilder {
+	date := jmap.UTCDate(t.UTC())
+	return qb.add(&EmailFilterCondition{After: &date})
+}
+
+// MinSize requires the size of the Email to be equal or greater than size.
+func (qb *QueryBuilder) MinSize(size jmap.UnsignedInt) *QueryBuilder {
+	return qb.add(&EmailFilterCondition{MinSize: size})
+}
+
+// MaxSize requires the size of the Email to be less than size.
+func (qb *QueryBuilder) MaxSize(size jmap.UnsignedInt) *QueryBuilder {
+	return qb.add(&EmailFilterCondition{MaxSize: size})
+}
+
+// HasKeyword requires the Email to have the keyword.
+func (qb *QueryBuilder) HasKeyword(keyword string) *QueryBuilder {
+	return qb.add(&EmailFilterCondition{HasKeyword: keyword})
+}
+
+// NotKeyword requires the Email to not have the keyword.
+func (qb *QueryBuilder) NotKeyword(keyword string) *QueryBuilder {
+	return qb.add(&EmailFilterCondition{NotKeyword: keyword})
+}
+
+// Unread requires the Email to not have the $seen keyword.
+func (qb *QueryBuilder) Unread() *QueryBuilder {
+	return qb.NotKeyword(KeywordSeen)
+}
+
+// Flagged requires the Email to have the $flagged keyword.
+func (qb *QueryBuilder) Flagged() *QueryBuilder {
+	return qb.HasKeyword(KeywordFlagged)
+}
+
+// HasAttachment requires the Email to have attachments.
+func (qb *QueryBuilder) HasAttachment() *QueryBuilder {
+	has := true
+	return qb.add(&EmailFilterCondition{HasAttachment: &has})
+}
+
+// NoAttachment requires the Email to have no attachments.
+func (qb *QueryBuilder) NoAttachment() *QueryBuilder {
+	has := false
+	return qb.add(&EmailFilterCondition{HasAttachment: &has})
+}
+
+// Text requires the text to be present in From, To, Cc, Bcc, Subject header
+// fields or any text body part of the Email.
+func (qb *QueryBuilder) Text(text string) *QueryBuilder {
+	return qb.add(&EmailFilterCondition{Text: text})
+}
+
+// From requires the text to be present in the From header field.
+func (qb *QueryBuilder) From(text string) *QueryBuilder {
+	return qb.add(&EmailFilterCondition{From: text})
+}
+
+// To requires the text to be present in the To header field.
+func (qb *QueryBuilder) To(text string) *QueryBuilder {
+	return qb.add(&EmailFilterCondition{To: text})
+}
+
+// CC requires the text to be present in the Cc header field.
+func (qb *QueryBuilder) CC(text string) *QueryBuilder {
+	return qb.add(&EmailFilterCondition{CC: text})
+}
+
+// BCC requires the text to be present in the Bcc header field.
+func (qb *QueryBuilder) BCC(text string) *QueryBuilder {
+	return qb.add(&EmailFilterCondition{BCC: text})
+}
+
+// Subject requires the text to be present in the Subject header field.
+func (qb *QueryBuilder) Subject(text string) *QueryBuilder {
+	return qb.add(&EmailFilterCondition{Subject: text})
+}
+
+// Body requires the text to be present in any text body part of the Email.
+func (qb *QueryBuilder) Body(text string) *QueryBuilder {
+	return qb.add(&EmailFilterCondition{Body: text})
+}
+
+// Header requires the Email to have the header field, optionally containing
+// the text.
+func (qb *QueryBuilder) Header(name string, text ...string) *QueryBuilder {
+	return qb.add(&EmailFilterCondition{Header: append([]string{name}, text...)})
+}
+
+// Or requires at least one of the queries to match. Only filters of the
+// queries are used, sort is ignored.
+func (qb *QueryBuilder) Or(queries ...*QueryBuilder) *QueryBuilder {
+	conds := make([]interface{}, 0, len(queries))
+	for _, q := range queries {
+		conds = append(conds, q.filter())
+	}
+	return qb.add(jmap.Or(conds...))
+}
+
+// Not requires none of the queries to match. Only filters of the queries
+// are used, sort is ignored.
+func (qb *QueryBuilder) Not(queries ...*QueryBuilder) *QueryBuilder {
+	conds := make([]interface{}, 0, len(queries))
+	for _, q := range queries {
+		conds = append(conds, q.filter())
+	}
+	return qb.add(jmap.Not(conds...))
+}
+
+// SortBy adds the sort comparator. Comparators are applied in the order they
+// are added.
+func (qb *QueryBuilder) SortBy(property string, ascending bool) *QueryBuilder {
+	qb.sort = append(qb.sort, EmailComparator{
+		Comparator: jmap.Comparator{Property: property, IsAscending: &ascending},
+	})
+	return qb
+}
+
+// SortByKeyword adds the comparator for hasKeyword, allInThreadHaveKeyword
+// and someInThreadHaveKeyword sort properties.
+func (qb *QueryBuilder) SortByKeyword(property, keyword string, ascending bool) *QueryBuilder {
+	qb.sort = append(qb.sort, EmailComparator{
+		Comparator: jmap.Comparator{Property: property, IsAscending: &ascending},
+		Keyword:    keyword,
+	})
+	return qb
+}
+
+// Collation sets the collation algorithm for the last added comparator.
+func (qb *QueryBuilder) Collation(algo jmap.CollationAlgo) *QueryBuilder {
+	if len(qb.sort) != 0 {
+		qb.sort[len(qb.sort)-1].Collation = algo
+	}
+	return qb
+}
+
+func (qb *QueryBuilder) filter() interface{} {
+	if len(qb.conds) == 1 {
+		return qb.conds[0]
+	}
+	return jmap.And(qb.conds...)
+}
+
+// Filter returns the constructed filter or nil if no conditions were added.
+func (qb *QueryBuilder) Filter() interface{} {
+	if len(qb.conds) == 0 {
+		return nil
+	}
+	return qb.filter()
+}
+
+// Sort returns the list of added comparators.
+func (qb *QueryBuilder) Sort() []EmailComparator {
+	return qb.sort
+}
+
+// Args returns EmailQueryArgs with the filter and sort set.
+func (qb *QueryBuilder) Args(account jmap.ID) EmailQueryArgs {
+	return EmailQueryArgs{
+		AccountID: account,
+		Filter:    qb.Filter(),
+		Sort:      qb.Sort(),
+	}
+}
+
+// Validate checks that sort properties are listed in emailQuerySortOptions
+// of the account's urn:ietf:params:jmap:mail capability and collation
+// algorithms are listed in collationAlgorithms of the session.
+//
+// Returned error is *QueryValidationError if the query is not supported.
+func (qb *QueryBuilder) Validate(session *jmap.Session, account jmap.ID) error {
+	mailCap, err := session.EffectiveMailCapability(account)
+	if err != nil {
+		return err
+	}
+
+	for _, cmp := range qb.sort {
+		supported := false
+		for _, opt := range mailCap.EmailQuerySortOptions {
+			if opt == cmp.Property {
+				supported = true
+				break
+			}
+		}
+		if !supported {
+			return &QueryValidationError{Value: cmp.Property, Err: ErrUnsupportedSort}
+		}
+
+		if cmp.Collation == "" {
+			continue
+		}
+		supported = false
+		for _, algo := range session.CoreCapability.CollationAlgorithms {
+			if algo == cmp.Collation {
+				supported = true
+				break
+			}
+		}
+		if !supported {
+			return &QueryValidationError{Value: string(cmp.Collation), Err: ErrUnsupportedCollation}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package mail
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/foxcpp/go-jmap"
+	"gotest.tools/assert"
+	"gotest.tools/assert/cmp"
+)
+
+func TestQueryBuilder(t *testing.T) {
+	args := Query().
+		From("joe@example.org").
+		HasAttachment().
+		InMailbox("INBOX").
+		After(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)).
+		Or(Query().Subject("report"), Query().Unread().Flagged()).
+		Not(Query().Header("List-Id")).
+		SortBy(SortReceivedAt, false).
+		SortBy(SortSubject, true).Collation(jmap.ASCIICasemap).
+		Args("A1")
+
+	blob, err := json.Marshal(args)
+	assert.NilError(t, err)
+	assert.Check(t, cmp.Equal(`{"accountId":"A1",`+
+		`"filter":{"operator":"AND","conditions":[`+
+		`{"from":"joe@example.org"},`+
+		`{"hasAttachment":true},`+
+		`{"inMailbox":"INBOX"},`+
+		`{"after":"2020-01-01T00:00:00Z"},`+
+		`{"operator":"OR","conditions":[{"subject":"report"},{"operator":"AND","conditions":[{"notKeyword":"$seen"},{"hasKeyword":"$flagged"}]}]},`+
+		`{"operator":"NOT","conditions":[{"header":["List-Id"]}]}]},`+
+		`"sort":[{"property":"receivedAt","isAscending":false},{"property":"subject","isAscending":true,"collation":"i;ascii-casemap"}]}`,
+		string(blob)))
+
+	t.Run("single condition", func(t *testing.T) {
+		assert.Check(t, cmp.DeepEqual(&EmailFilterCondition{Text: "hello"}, Query().Text("hello").Filter()))
+		assert.Check(t, Query().Filter() == nil)
+	})
+}
+
+func TestQueryBuilderValidate(t *testing.T) {
+	session := &jmap.Session{
+		CoreCapability: jmap.CoreCapability{
+			CollationAlgorithms: []jmap.CollationAlgo{jmap.ASCIICasemap},
+		},
+		Accounts: map[jmap.ID]jmap.Account{
+			"A1": {Capabilities: map[string]json.RawMessage{
+				jmap.MailCapabilityName: json.RawMessage(`{"emailQuerySortOptions": ["receivedAt", "subject"]}`),
+			}},
+		},
+	}
+
+	assert.NilError(t, Query().SortBy(SortSubject, true).Collation(jmap.ASCIICasemap).Validate(session, "A1"))
+
+	err := Query().SortBy(SortSize, true).Validate(session, "A1")
+	qve, ok := err.(*QueryValidationError)
+	assert.Assert(t, ok, "%T", err)
+	assert.Check(t, cmp.Equal(ErrUnsupportedSort, qve.Err))
+	assert.Check(t, cmp.Equal(SortSize, qve.Value))
+
+	err = Query().SortBy(SortSubject, true).Collation(jmap.ASCIINumeric).Validate(session, "A1")
+	qve, ok = err.(*QueryValidationError)
+	assert.Assert(t, ok, "%T", err)
+	assert.Check(t, cmp.Equal(ErrUnsupportedCollation, qve.Err))
+}
@@ -0,0 +1,141 @@
+package mail
+
+import (
+	"github.com/foxcpp/go-jmap"
+	"github.com/foxcpp/go-jmap/client"
+)
+
+// EmailIterator pages through Email/query results.
+//
+// The first page is requested using Position from the passed arguments,
+// following pages are requested using the last received id as an anchor
+// so Emails added or removed concurrently do not cause results to be
+// skipped or repeated. If the anchor is no longer in the results, the
+// iterator falls back to position-based paging.
+//
+// Usage:
+//
+//	it := mail.NewEmailIterator(c, mail.EmailQueryArgs{AccountID: acc, Filter: f})
+//	for it.Next() {
+//		id := it.ID()
+//		...
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type EmailIterator struct {
+	c    *client.Client
+	args EmailQueryArgs
+
+	page       []jmap.ID
+	idx        int
+	seen       int
+	total      jmap.UnsignedInt
+	hasTotal   bool
+	queryState string
+	done       bool
+	err        error
+}
+
+// NewEmailIterator creates the iterator for the query. args.Limit is used as
+// the page size, if it is zero, c.PageSize or the server default is used.
+// Anchor and AnchorOffset are ignored.
+//
+// The client must have ResponseUnmarshallers enabled.
+func NewEmailIterator(c *client.Client, args EmailQueryArgs) *EmailIterator {
+	args.Anchor = ""
+	args.AnchorOffset = 0
+	if args.Limit == 0 {
+		args.Limit = c.PageSize
+	}
+	return &EmailIterator{c: c, args: args}
+}
+
+// Next advances the iterator to the next Email id, requesting the next page
+// if necessary. It returns false when there are no more results or an error
+// occurred.
+func (it *EmailIterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+	if it.idx+1 < len(it.page) {
+		it.idx++
+		it.seen++
+		return true
+	}
+	if it.done {
+		return false
+	}
+	if err := it.fetch(); err != nil {
+		it.err = err
+		return false
+	}
+	if len(it.page) == 0 {
+		it.done = true
+		return false
+	}
+	it.idx = 0
+	it.seen++
+	return true
+}
+
+func (it *EmailIterator) fetch() error {
+	args := it.args
+	first := it.page == nil
+	if !first {
+		args.Anchor = it.page[len(it.page)-1]
+		args.AnchorOffset = 1
+		args.Position = 0
+	}
+
+	resp, err := queryEmails(it.c, args)
+	if err != nil {
+		if mErr, ok := err.(jmap.MethodErrorArgs); ok && mErr.Type == jmap.CodeAnchorNotFound {
+			args.Anchor = ""
+			args.AnchorOffset = 0
+			args.Position = it.args.Position + jmap.Int(it.seen)
+			resp, err = queryEmails(it.c, args)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if first {
+		it.queryState = resp.QueryState
+	}
+	if it.args.CalculateTotal {
+		it.total = resp.Total
+		it.hasTotal = true
+	}
+	it.page = resp.IDs
+	if it.page == nil {
+		it.page = []jmap.ID{}
+	}
+	if it.hasTotal && resp.Position+jmap.UnsignedInt(len(resp.IDs)) >= it.total {
+		it.done = true
+	}
+	return nil
+}
+
+// ID returns the current Email id.
+func (it *EmailIterator) ID() jmap.ID {
+	return it.page[it.idx]
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (it *EmailIterator) Err() error {
+	return it.err
+}
+
+// Total returns the total number of results as reported by the server. It
+// is available only after the first call to Next and only if CalculateTotal
+// was set in the query arguments.
+func (it *EmailIterator) Total() (jmap.UnsignedInt, bool) {
+	return it.total, it.hasTotal
+}
+
+// QueryState returns the queryState of the first page.
+func (it *EmailIterator) QueryState() string {
+	return it.queryState
+}
@@ -0,0 +1,60 @@
+package mail
+
+import (
+	"testing"
+
+	"github.com/foxcpp/go-jmap"
+	"gotest.tools/assert"
+	"gotest.tools/assert/cmp"
+)
+
+func TestEmailIterator(t *testing.T) {
+	all := []string{"a", "b", "c", "d", "e", "f", "g"}
+	calls := 0
+	c, srv := newTestClient(t, func(name string, args map[string]interface{}) []testResponse {
+		calls++
+		start := 0
+		if pos, ok := args["position"].(float64); ok {
+			start = int(pos)
+		}
+		if anchor, ok := args["anchor"].(string); ok {
+			if anchor == "d" {
+				// Simulate concurrent removal of the anchor.
+				return []testResponse{{"error", map[string]interface{}{"type": "anchorNotFound"}}}
+			}
+			for i, id := range all {
+				if id == anchor {
+					start = i + int(args["anchorOffset"].(float64))
+				}
+			}
+		}
+		end := start + int(args["limit"].(float64))
+		if end > len(all) {
+			end = len(all)
+		}
+		if start > end {
+			start = end
+		}
+		return []testResponse{{"Email/query", map[string]interface{}{
+			"queryState": "q1",
+			"position":   start,
+			"ids":        all[start:end],
+			"total":      len(all),
+		}}}
+	})
+	defer srv.Close()
+
+	it := NewEmailIterator(c, EmailQueryArgs{AccountID: "A1", Limit: 2, CalculateTotal: true})
+	var ids []jmap.ID
+	for it.Next() {
+		ids = append(ids, it.ID())
+	}
+	assert.NilError(t, it.Err())
+	assert.Check(t, cmp.DeepEqual([]jmap.ID{"a", "b", "c", "d", "e", "f", "g"}, ids))
+	assert.Check(t, cmp.Equal("q1", it.QueryState()))
+	total, ok := it.Total()
+	assert.Check(t, ok)
+	assert.Check(t, cmp.Equal(jmap.UnsignedInt(7), total))
+	// Pages: [a b] [c d] anchorNotFound+[e f] [g]
+	assert.Check(t, cmp.Equal(5, calls))
+}
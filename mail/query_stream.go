@@ -0,0 +1,130 @@
+package mail
+
+import (
+	"github.com/foxcpp/go-jmap"
+	"github.com/foxcpp/go-jmap/client"
+)
+
+// nextPage advances the iterator past the rest of the current page,
+// requesting the next page if necessary, and returns the skipped ids.
+func (it *EmailIterator) nextPage() ([]jmap.ID, bool) {
+	if !it.Next() {
+		return nil, false
+	}
+	page := it.page[it.idx:]
+	it.seen += len(page) - 1
+	it.idx = len(it.page) - 1
+	return page, true
+}
+
+// StreamEmailIDs runs the query and passes its results to fn page by page,
+// without keeping the full list of ids in memory. Pages are requested the
+// same way as by EmailIterator, args.Limit is used as the page size.
+//
+// If fn returns an error, the iteration stops and the error is returned.
+// Otherwise the queryState of the first page is returned.
+//
+// The client must have ResponseUnmarshallers enabled.
+func StreamEmailIDs(c *client.Client, args EmailQueryArgs, fn func(ids []jmap.ID) error) (string, error) {
+	it := NewEmailIterator(c, args)
+	for {
+		page, ok := it.nextPage()
+		if !ok {
+			break
+		}
+		if err := fn(page); err != nil {
+			return "", err
+		}
+	}
+	if err := it.Err(); err != nil {
+		return "", err
+	}
+	return it.QueryState(), nil
+}
+
+// StreamEmails runs the query and fetches the resulting Emails using
+// Email/get with the arguments from get (AccountID and IDs are set
+// automatically), passing them to fn in chunks.
+//
+// Ids are requested in pages using StreamEmailIDs and each page is fetched
+// in chunks of at most maxObjectsInGet Emails. Up to concurrency Email/get
+// calls are in flight at a time, if it is zero, maxConcurrentRequests is
+// used (see jmap.Session.Limits). Memory usage is therefore bounded by
+// concurrency chunks regardless of the number of results, which makes
+// StreamEmails suitable for exporting very large accounts.
+//
+// fn is called from the calling goroutine, chunks are passed in the order
+// of query results. Emails destroyed after they were returned by the query
+// are silently skipped. If fn or any call returns an error, no new calls
+// are made and the error is returned after calls in flight complete.
+// Calls are run as background work of the client (see
+// client.Client.RunBackground), so Close cancels them and waits for them.
+//
+// The client must have ResponseUnmarshallers enabled.
+func StreamEmails(c *client.Client, args EmailQueryArgs, get EmailGetArgs, concurrency int, fn func(emails []Email) error) error {
+	session, err := c.CurrentSession()
+	if err != nil {
+		return err
+	}
+	limits := session.Limits()
+	if concurrency <= 0 {
+		concurrency = int(limits.MaxConcurrentRequests)
+	}
+	chunkSize := int(limits.MaxObjectsInGet)
+
+	type result struct {
+		emails []Email
+		err    error
+	}
+	var pending []chan result
+	// consume waits for the oldest call in flight and passes its results
+	// to fn.
+	consume := func() error {
+		res := <-pending[0]
+		pending = pending[1:]
+		if res.err != nil {
+			return res.err
+		}
+		return fn(res.emails)
+	}
+
+	get.AccountID = args.AccountID
+	_, err = StreamEmailIDs(c, args, func(ids []jmap.ID) error {
+		for start := 0; start < len(ids); start += chunkSize {
+			end := start + chunkSize
+			if end > len(ids) {
+				end = len(ids)
+			}
+			if len(pending) == concurrency {
+				if err := consume(); err != nil {
+					return err
+				}
+			}
+
+			chunkArgs := get
+			chunkArgs.IDs = ids[start:end]
+			out := make(chan result, 1)
+			// Calls in flight are canceled by Close, nothing else to stop.
+			if err := c.RunBackground(func() {
+				resp, err := getEmails(c, chunkArgs)
+				if err != nil {
+					out <- result{err: err}
+					return
+				}
+				out <- result{emails: resp.List}
+			}, func() {}); err != nil {
+				return err
+			}
+			pending = append(pending, out)
+		}
+		return nil
+	})
+	for err == nil && len(pending) != 0 {
+		err = consume()
+	}
+	// Wait for calls still in flight after an error.
+	for _, out := range pending {
+		<-out
+	}
+	return err
+}
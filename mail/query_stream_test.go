@@ -0,0 +1,123 @@
+package mail
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/foxcpp/go-jmap"
+	"gotest.tools/assert"
+	"gotest.tools/assert/cmp"
+)
+
+func newStreamTestServer(t *testing.T, all []string) (handler func(name string, args map[string]interface{}) []testResponse, maxInFlight func() int) {
+	var (
+		lck      sync.Mutex
+		inFlight int
+		max      int
+	)
+	handler = func(name string, args map[string]interface{}) []testResponse {
+		switch name {
+		case "Email/query":
+			start := 0
+			if anchor, ok := args["anchor"].(string); ok {
+				for i, id := range all {
+					if id == anchor {
+						start = i + 1
+					}
+				}
+			}
+			end := start + int(args["limit"].(float64))
+			if end > len(all) {
+				end = len(all)
+			}
+			return []testResponse{{name, map[string]interface{}{
+				"queryState": "q1",
+				"position":   start,
+				"ids":        all[start:end],
+			}}}
+		case "Email/get":
+			lck.Lock()
+			inFlight++
+			if inFlight > max {
+				max = inFlight
+			}
+			lck.Unlock()
+
+			var list []map[string]interface{}
+			for _, id := range args["ids"].([]interface{}) {
+				if id == "gone" {
+					continue
+				}
+				list = append(list, map[string]interface{}{"id": id, "subject": "s" + id.(string)})
+			}
+
+			lck.Lock()
+			inFlight--
+			lck.Unlock()
+			return []testResponse{{name, map[string]interface{}{"list": list}}}
+		}
+		t.Fatalf("unexpected call: %s", name)
+		return nil
+	}
+	maxInFlight = func() int {
+		lck.Lock()
+		defer lck.Unlock()
+		return max
+	}
+	return handler, maxInFlight
+}
+
+func TestStreamEmailIDs(t *testing.T) {
+	handler, _ := newStreamTestServer(t, []string{"a", "b", "c", "d", "e"})
+	c, srv := newTestClient(t, handler)
+	defer srv.Close()
+
+	var pages [][]jmap.ID
+	state, err := StreamEmailIDs(c, EmailQueryArgs{AccountID: "A1", Limit: 2}, func(ids []jmap.ID) error {
+		pages = append(pages, ids)
+		return nil
+	})
+	assert.NilError(t, err)
+	assert.Check(t, cmp.Equal("q1", state))
+	assert.Check(t, cmp.DeepEqual([][]jmap.ID{{"a", "b"}, {"c", "d"}, {"e"}}, pages))
+
+	stop := errors.New("stop")
+	calls := 0
+	_, err = StreamEmailIDs(c, EmailQueryArgs{AccountID: "A1", Limit: 2}, func(ids []jmap.ID) error {
+		calls++
+		return stop
+	})
+	assert.Check(t, cmp.Equal(stop, err))
+	assert.Check(t, cmp.Equal(1, calls))
+}
+
+func TestStreamEmails(t *testing.T) {
+	handler, maxInFlight := newStreamTestServer(t, []string{"a", "b", "gone", "d", "e", "f", "g"})
+	c, srv := newTestClient(t, handler)
+	defer srv.Close()
+
+	var (
+		subjects []string
+		chunks   int
+	)
+	err := StreamEmails(c, EmailQueryArgs{AccountID: "A1", Limit: 2}, EmailGetArgs{
+		Properties: []string{"subject"},
+	}, 2, func(emails []Email) error {
+		chunks++
+		for _, e := range emails {
+			subjects = append(subjects, e.Subject)
+		}
+		return nil
+	})
+	assert.NilError(t, err)
+	assert.Check(t, cmp.DeepEqual([]string{"sa", "sb", "sd", "se", "sf", "sg"}, subjects))
+	assert.Check(t, cmp.Equal(4, chunks))
+	assert.Check(t, maxInFlight() <= 2)
+
+	stop := errors.New("stop")
+	err = StreamEmails(c, EmailQueryArgs{AccountID: "A1", Limit: 2}, EmailGetArgs{}, 0, func(emails []Email) error {
+		return stop
+	})
+	assert.Check(t, cmp.Equal(stop, err))
+}
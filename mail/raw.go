@@ -0,0 +1,92 @@
+package mail
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/foxcpp/go-jmap"
+	"github.com/foxcpp/go-jmap/client"
+)
+
+var ErrEmailNotFound = errors.New("jmap/mail: email not found")
+
+var mailUsing = []string{jmap.CoreCapabilityName, jmap.MailCapabilityName}
+
+// unexpectedResponse returns error for the case where response arguments
+// were not decoded into the expected structure, most likely because
+// ResponseUnmarshallers were not enabled for the client.
+func unexpectedResponse(methodName string, args interface{}) error {
+	return fmt.Errorf("jmap/mail: unexpected %s response type %T, is mail.ResponseUnmarshallers enabled?", methodName, args)
+}
+
+func getEmails(c *client.Client, args EmailGetArgs) (*EmailGetResponse, error) {
+	respArgs, err := c.Call(mailUsing, "Email/get", args)
+	if err != nil {
+		return nil, err
+	}
+	resp, ok := respArgs.(EmailGetResponse)
+	if !ok {
+		return nil, unexpectedResponse("Email/get", respArgs)
+	}
+	return &resp, nil
+}
+
+// BlobIDOf returns the blobId of the Email, which refers to the raw message
+// source (RFC 5322).
+//
+// The client must have ResponseUnmarshallers enabled.
+func BlobIDOf(c *client.Client, account, email jmap.ID) (jmap.ID, error) {
+	resp, err := getEmails(c, EmailGetArgs{
+		AccountID:  account,
+		IDs:        []jmap.ID{email},
+		Properties: []string{"blobId"},
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(resp.List) == 0 {
+		return "", ErrEmailNotFound
+	}
+	return resp.List[0].BlobID, nil
+}
+
+// DownloadRaw returns the full message source (RFC 5322) of the Email.
+//
+// The blob is requested with message/rfc822 type and "<email id>.eml" name,
+// so the server sets appropriate Content-Type and Content-Disposition
+// headers.
+//
+// The client must have ResponseUnmarshallers enabled. The caller is
+// responsible for closing the returned reader.
+func DownloadRaw(c *client.Client, account, email jmap.ID) (io.ReadCloser, error) {
+	blobID, err := BlobIDOf(c, account, email)
+	if err != nil {
+		return nil, err
+	}
+	return c.DownloadAs(account, blobID, "message/rfc822", string(email)+".eml")
+}
+
+// SaveEML writes the full message source of the Email to the file at path
+// (conventionally with .eml extension), creating or truncating it.
+//
+// The client must have ResponseUnmarshallers enabled.
+func SaveEML(c *client.Client, account, email jmap.ID, path string) error {
+	src, err := DownloadRaw(c, account, email)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, src); err != nil {
+		f.Close()
+		os.Remove(path)
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,39 @@
+package mail
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gotest.tools/assert"
+	"gotest.tools/assert/cmp"
+)
+
+func TestDownloadRaw(t *testing.T) {
+	c, srv := newTestClient(t, func(name string, args map[string]interface{}) []testResponse {
+		assert.Check(t, cmp.Equal("Email/get", name))
+		return []testResponse{{name, map[string]interface{}{
+			"list": []interface{}{map[string]interface{}{"id": "E1", "blobId": "B1"}},
+		}}}
+	})
+	defer srv.Close()
+
+	var uri string
+	dlSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		uri = r.URL.RequestURI()
+		w.Write([]byte("Subject: hello\r\n\r\nbody\r\n")) //nolint:errcheck
+	}))
+	defer dlSrv.Close()
+	_, err := c.CurrentSession()
+	assert.NilError(t, err)
+	c.Session.DownloadURL = dlSrv.URL + "/{accountId}/{blobId}/{name}?type={type}"
+
+	rd, err := DownloadRaw(c, "A1", "E1")
+	assert.NilError(t, err)
+	defer rd.Close()
+	blob, err := ioutil.ReadAll(rd)
+	assert.NilError(t, err)
+	assert.Check(t, cmp.Equal("Subject: hello\r\n\r\nbody\r\n", string(blob)))
+	assert.Check(t, cmp.Equal("/A1/B1/E1.eml?type=message%2Frfc822", uri))
+}
@@ -0,0 +1,344 @@
+package mail
+
+import (
+	"html"
+	"strings"
+	"time"
+)
+
+// ReplyOptions contains options for NewReply.
+type ReplyOptions struct {
+	// Reply to all recipients of the original Email instead of only the
+	// author.
+	All bool
+
+	// Identity to reply from. If not nil, it is used for From, Reply-To and
+	// Bcc fields and its address is excluded from recipients. Its signatures
+	// are added after Text.
+	Identity *Identity
+
+	// Text to place above the quoted original.
+	Text string
+}
+
+// ForwardOptions contains options for NewForward.
+type ForwardOptions struct {
+	// Identity to forward from. See ReplyOptions.Identity.
+	Identity *Identity
+
+	// Text to place above the forwarded message.
+	Text string
+
+	// Do not carry over attachments of the original Email.
+	NoAttachments bool
+}
+
+// NewReply creates the builder with the reply draft for the original Email.
+//
+// The original should have the following properties fetched: from, to, cc,
+// replyTo, subject, sentAt, messageId, references, bodyStructure (or
+// textBody and htmlBody) and bodyValues. If HTML body of the original is
+// available, the reply has both text and HTML versions, otherwise it is
+// text-only. The text version of an HTML-only original is quoted after
+// stripping the markup.
+//
+// The returned builder can be used to adjust the draft further before
+// calling Build.
+func NewReply(original *Email, opts ReplyOptions) *EmailBuilder {
+	b := NewEmailBuilder()
+	applyIdentity(b, opts.Identity)
+
+	var self string
+	if opts.Identity != nil {
+		self = opts.Identity.Email
+	}
+
+	to := original.ReplyTo
+	if len(to) == 0 {
+		to = original.From
+	}
+	seen := map[string]bool{}
+	b.To(uniqueAddresses(to, self, seen)...)
+	if opts.All {
+		b.To(uniqueAddresses(original.To, self, seen)...)
+		b.CC(uniqueAddresses(original.CC, self, seen)...)
+	}
+
+	b.Subject(prefixSubject("Re:", original.Subject))
+
+	references := original.References
+	if len(references) == 0 {
+		// RFC 5322, section 3.6.4.
+		references = original.InReplyTo
+	}
+	references = append(append([]string(nil), references...), original.MessageID...)
+	b.InReplyTo(original.MessageID, references)
+
+	attribution := "On " + formatOriginalDate(original) + ", " + formatOriginalFrom(original) + " wrote:"
+	text, htmlIntro := draftIntro(opts.Text, opts.Identity)
+
+	b.TextBody(text + attribution + "\n" + quoteText(bodyText(original, false)))
+	if hasHTMLBody(original) {
+		b.HTMLBody(htmlIntro +
+			"<div>" + html.EscapeString(attribution) + "</div>\n" +
+			`<blockquote type="cite">` + bodyText(original, true) + "</blockquote>")
+	}
+	return b
+}
+
+// NewForward creates the builder with the draft forwarding the original Email
+// inline. Recipients are not set and should be added to the builder.
+//
+// Attachments of the original are attached to the draft by reference (blob
+// id), unless NoAttachments is set. Inline images are carried over if the
+// draft has the HTML version.
+//
+// See NewReply for properties that should be fetched for the original.
+func NewForward(original *Email, opts ForwardOptions) *EmailBuilder {
+	b := NewEmailBuilder()
+	applyIdentity(b, opts.Identity)
+	b.Subject(prefixSubject("Fwd:", original.Subject))
+
+	headers := [][2]string{
+		{"From", formatOriginalFrom(original)},
+		{"Date", formatOriginalDate(original)},
+		{"Subject", original.Subject},
+		{"To", FormatAddressList(original.To)},
+	}
+	if len(original.CC) != 0 {
+		headers = append(headers, [2]string{"Cc", FormatAddressList(original.CC)})
+	}
+
+	const separator = "---------- Forwarded message ----------"
+	text, htmlIntro := draftIntro(opts.Text, opts.Identity)
+
+	var textHdr, htmlHdr strings.Builder
+	textHdr.WriteString(separator + "\n")
+	htmlHdr.WriteString("<div>" + separator + "<br>\n")
+	for _, hdr := range headers {
+		textHdr.WriteString(hdr[0] + ": " + hdr[1] + "\n")
+		htmlHdr.WriteString(hdr[0] + ": " + html.EscapeString(hdr[1]) + "<br>\n")
+	}
+	htmlHdr.WriteString("</div>\n")
+
+	b.TextBody(text + textHdr.String() + "\n" + bodyText(original, false))
+	withHTML := hasHTMLBody(original)
+	if withHTML {
+		b.HTMLBody(htmlIntro + htmlHdr.String() + bodyText(original, true))
+	}
+
+	if !opts.NoAttachments {
+		for _, part := range original.AttachmentParts() {
+			if part.CID != "" && part.Disposition != "attachment" {
+				if withHTML {
+					b.InlineImage(part.BlobID, part.Type, part.CID, part.Name)
+				}
+				continue
+			}
+			b.Attach(part.BlobID, part.Type, part.Name)
+		}
+	}
+	return b
+}
+
+func applyIdentity(b *EmailBuilder, ident *Identity) {
+	if ident == nil {
+		return
+	}
+	b.From(EmailAddress{Name: ident.Name, Email: ident.Email})
+	b.ReplyTo(ident.ReplyTo...)
+	b.BCC(ident.BCC...)
+}
+
+// draftIntro returns text and HTML versions of the user text with the
+// Identity signatures.
+func draftIntro(text string, ident *Identity) (string, string) {
+	htmlText := textToHTML(text)
+	if ident != nil && ident.TextSignature != "" {
+		text += "\n-- \n" + ident.TextSignature
+	}
+	if ident != nil && ident.HTMLSignature != "" {
+		htmlText += "<div>-- <br>\n" + ident.HTMLSignature + "</div>"
+	} else if ident != nil && ident.TextSignature != "" {
+		htmlText += "<div>-- <br>\n" + textToHTML(ident.TextSignature) + "</div>"
+	}
+	if text != "" {
+		text += "\n\n"
+	}
+	if htmlText != "" {
+		htmlText = "<div>" + htmlText + "</div>\n<br>\n"
+	}
+	return text, htmlText
+}
+
+// prefixSubject adds the prefix (e.g. "Re:") to the subject unless it is
+// already there.
+func prefixSubject(prefix, subject string) string {
+	if len(subject) >= len(prefix) && strings.EqualFold(subject[:len(prefix)], prefix) {
+		return subject
+	}
+	return prefix + " " + subject
+}
+
+func uniqueAddresses(addrs []EmailAddress, self string, seen map[string]bool) []EmailAddress {
+	var res []EmailAddress
+	for _, addr := range addrs {
+		key := strings.ToLower(addr.Email)
+		if seen[key] || (self != "" && strings.EqualFold(addr.Email, self)) {
+			continue
+		}
+		seen[key] = true
+		res = append(res, addr)
+	}
+	return res
+}
+
+func formatOriginalFrom(original *Email) string {
+	if len(original.From) == 0 {
+		return "unknown sender"
+	}
+	return original.From[0].String()
+}
+
+func formatOriginalDate(original *Email) string {
+	switch {
+	case original.SentAt != nil:
+		return time.Time(*original.SentAt).Format("Mon, 2 Jan 2006 15:04 -0700")
+	case original.ReceivedAt != nil:
+		return time.Time(*original.ReceivedAt).Format("Mon, 2 Jan 2006 15:04 -0700")
+	}
+	return "unknown date"
+}
+
+// hasHTMLBody reports whether the Email has an actual HTML body (and not
+// just the text one that is also used as an HTML one).
+func hasHTMLBody(e *Email) bool {
+	for _, part := range e.PreferredBody(true) {
+		if part.Type == "text/html" {
+			if _, ok := e.BodyValues[part.PartID]; ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// bodyText concatenates fetched body values of the preferred body. If
+// asHTML is true, text/plain parts are converted to HTML, otherwise
+// text/html parts (present in the text body of HTML-only Emails) are
+// converted to text.
+func bodyText(e *Email, asHTML bool) string {
+	var res strings.Builder
+	for _, part := range e.PreferredBody(asHTML) {
+		val, ok := e.BodyValues[part.PartID]
+		if !ok {
+			continue
+		}
+		switch {
+		case asHTML && part.Type == "text/html":
+			res.WriteString(val.Value)
+		case asHTML:
+			res.WriteString(textToHTML(val.Value))
+		case part.Type == "text/plain":
+			res.WriteString(val.Value)
+		case part.Type == "text/html":
+			res.WriteString(htmlToText(val.Value))
+		}
+	}
+	return res.String()
+}
+
+func quoteText(text string) string {
+	lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, ">") {
+			lines[i] = ">" + line
+		} else {
+			lines[i] = "> " + line
+		}
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+func textToHTML(text string) string {
+	return strings.Replace(html.EscapeString(text), "\n", "<br>\n", -1)
+}
+
+// htmlBlockTags are HTML elements that start a new line in the text produced
+// by htmlToText.
+var htmlBlockTags = map[string]bool{
+	"br": true, "p": true, "div": true, "li": true, "tr": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"blockquote": true, "pre": true, "table": true, "ul": true, "ol": true,
+	"hr": true,
+}
+
+// htmlToText converts HTML to plain text suitable for quoting: tags are
+// removed, block elements are placed on separate lines and character
+// references are decoded. Contents of script and style elements are
+// dropped.
+//
+// It is not a complete HTML parser, the result for malformed markup is a
+// best effort.
+func htmlToText(src string) string {
+	var res strings.Builder
+	for len(src) != 0 {
+		lt := strings.IndexByte(src, '<')
+		if lt == -1 {
+			lt = len(src)
+		}
+		// Whitespace is collapsed as done by browsers.
+		text := src[:lt]
+		if collapsed := strings.Join(strings.Fields(text), " "); collapsed != "" {
+			if strings.TrimLeft(text, " \t\r\n") != text {
+				collapsed = " " + collapsed
+			}
+			if strings.TrimRight(text, " \t\r\n") != text {
+				collapsed += " "
+			}
+			res.WriteString(html.UnescapeString(collapsed))
+		}
+		src = src[lt:]
+		if len(src) == 0 {
+			break
+		}
+
+		gt := strings.IndexByte(src, '>')
+		if gt == -1 {
+			break
+		}
+		tag := src[1:gt]
+		src = src[gt+1:]
+
+		name := strings.ToLower(strings.TrimPrefix(tag, "/"))
+		if end := strings.IndexAny(name, " \t\r\n/"); end != -1 {
+			name = name[:end]
+		}
+		switch {
+		case (name == "script" || name == "style") && !strings.HasPrefix(tag, "/"):
+			end := strings.Index(strings.ToLower(src), "</"+name)
+			if end == -1 {
+				src = ""
+			} else {
+				src = src[end:]
+			}
+		case htmlBlockTags[name]:
+			res.WriteString("\n")
+		}
+	}
+
+	lines := strings.Split(res.String(), "\n")
+	out := make([]string, 0, len(lines))
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" && (len(out) == 0 || out[len(out)-1] == "") {
+			continue
+		}
+		out = append(out, line)
+	}
+	text := strings.TrimSpace(strings.Join(out, "\n"))
+	if text == "" {
+		return ""
+	}
+	return text + "\n"
+}
@@ -0,0 +1,130 @@
+package mail
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/foxcpp/go-jmap"
+	"gotest.tools/assert"
+	"gotest.tools/assert/cmp"
+)
+
+func replyOriginal() *Email {
+	sentAt := jmap.Date(time.Date(2020, 1, 2, 15, 4, 0, 0, time.UTC))
+	return &Email{
+		From:       []EmailAddress{{Name: "Jane", Email: "jane@example.org"}},
+		To:         []EmailAddress{{Email: "joe@example.com"}, {Email: "bob@example.org"}},
+		CC:         []EmailAddress{{Email: "JANE@example.org"}, {Email: "alice@example.org"}},
+		Subject:    "Dinner",
+		SentAt:     &sentAt,
+		MessageID:  []string{"2@example.org"},
+		References: []string{"1@example.org"},
+		BodyStructure: &EmailBodyPart{
+			Type: "multipart/mixed",
+			SubParts: []EmailBodyPart{
+				{Type: "multipart/alternative", SubParts: []EmailBodyPart{
+					{PartID: "1", Type: "text/plain"},
+					{PartID: "2", Type: "text/html"},
+				}},
+				{PartID: "3", BlobID: "B3", Type: "application/pdf", Name: "menu.pdf", Disposition: "attachment"},
+			},
+		},
+		BodyValues: map[string]EmailBodyValue{
+			"1": {Value: "Thursday?\n> earlier\n"},
+			"2": {Value: "<p>Thursday?</p>"},
+		},
+	}
+}
+
+func TestNewReply(t *testing.T) {
+	ident := &Identity{Email: "joe@example.com", Name: "Joe", TextSignature: "Joe"}
+	e, err := NewReply(replyOriginal(), ReplyOptions{All: true, Identity: ident, Text: "Sure."}).Build()
+	assert.NilError(t, err)
+
+	assert.Check(t, cmp.Equal("Re: Dinner", e.Subject))
+	assert.Check(t, cmp.DeepEqual([]EmailAddress{{Name: "Joe", Email: "joe@example.com"}}, e.From))
+	assert.Check(t, cmp.DeepEqual([]EmailAddress{
+		{Name: "Jane", Email: "jane@example.org"},
+		{Email: "bob@example.org"},
+	}, e.To))
+	assert.Check(t, cmp.DeepEqual([]EmailAddress{{Email: "alice@example.org"}}, e.CC))
+	assert.Check(t, cmp.DeepEqual([]string{"2@example.org"}, e.InReplyTo))
+	assert.Check(t, cmp.DeepEqual([]string{"1@example.org", "2@example.org"}, e.References))
+
+	assert.Check(t, cmp.Equal("Sure.\n-- \nJoe\n\n"+
+		"On Thu, 2 Jan 2020 15:04 +0000, \"Jane\" <jane@example.org> wrote:\n"+
+		"> Thursday?\n"+
+		">> earlier\n", e.BodyValues[textPartID].Value))
+	assert.Check(t, cmp.Contains(e.BodyValues[htmlPartID].Value, `<blockquote type="cite"><p>Thursday?</p></blockquote>`))
+	assert.Check(t, cmp.Len(e.AttachmentParts(), 0))
+
+	t.Run("author only", func(t *testing.T) {
+		orig := replyOriginal()
+		orig.Subject = "RE: Dinner"
+		orig.ReplyTo = []EmailAddress{{Email: "list@example.org"}}
+		orig.BodyValues = map[string]EmailBodyValue{"1": {Value: "Thursday?"}}
+		e, err := NewReply(orig, ReplyOptions{}).Build()
+		assert.NilError(t, err)
+		assert.Check(t, cmp.Equal("RE: Dinner", e.Subject))
+		assert.Check(t, cmp.DeepEqual([]EmailAddress{{Email: "list@example.org"}}, e.To))
+		assert.Check(t, cmp.Len(e.CC, 0))
+		_, hasHTML := e.BodyValues[htmlPartID]
+		assert.Check(t, !hasHTML)
+	})
+}
+
+func TestNewReplyHTMLOnly(t *testing.T) {
+	orig := replyOriginal()
+	orig.BodyStructure = &EmailBodyPart{PartID: "2", Type: "text/html"}
+	orig.BodyValues = map[string]EmailBodyValue{
+		"2": {Value: "<html><head><style>p { color: red; }</style></head><body>\n" +
+			"<p>Thursday at <b>7</b>?</p><div>Fish &amp; chips<br>or pizza</div></body></html>"},
+	}
+	e, err := NewReply(orig, ReplyOptions{}).Build()
+	assert.NilError(t, err)
+
+	assert.Check(t, cmp.Equal("On Thu, 2 Jan 2020 15:04 +0000, \"Jane\" <jane@example.org> wrote:\n"+
+		"> Thursday at 7?\n"+
+		"> \n"+
+		"> Fish & chips\n"+
+		"> or pizza\n", e.BodyValues[textPartID].Value))
+	assert.Check(t, cmp.Contains(e.BodyValues[htmlPartID].Value, `<blockquote type="cite"><html>`))
+}
+
+func TestHTMLToText(t *testing.T) {
+	for _, c := range []struct{ html, text string }{
+		{"", ""},
+		{"plain", "plain\n"},
+		{"a  <i>b</i>\n c", "a b c\n"},
+		{"<p>a</p>\n\n<p>b</p>", "a\n\nb\n"},
+		{"<script>if (a < b) {}</script>x", "x\n"},
+		{"<BR/>&lt;tag&gt;", "<tag>\n"},
+		{"broken <b", "broken\n"},
+	} {
+		assert.Check(t, cmp.Equal(c.text, htmlToText(c.html)), "%q", c.html)
+	}
+}
+
+func TestNewForward(t *testing.T) {
+	e, err := NewForward(replyOriginal(), ForwardOptions{Text: "FYI"}).
+		To(EmailAddress{Email: "carol@example.org"}).
+		Build()
+	assert.NilError(t, err)
+
+	assert.Check(t, cmp.Equal("Fwd: Dinner", e.Subject))
+	assert.Check(t, cmp.Len(e.InReplyTo, 0))
+	text := e.BodyValues[textPartID].Value
+	assert.Check(t, strings.HasPrefix(text, "FYI\n\n---------- Forwarded message ----------\nFrom: \"Jane\" <jane@example.org>\n"))
+	assert.Check(t, cmp.Contains(text, "Cc: JANE@example.org, alice@example.org\n\nThursday?\n"))
+	assert.Check(t, cmp.Contains(e.BodyValues[htmlPartID].Value, "From: &#34;Jane&#34; &lt;jane@example.org&gt;<br>"))
+
+	attachments := e.AttachmentParts()
+	assert.Assert(t, cmp.Len(attachments, 1))
+	assert.Check(t, cmp.Equal(jmap.ID("B3"), attachments[0].BlobID))
+	assert.Check(t, cmp.Equal("menu.pdf", attachments[0].Name))
+
+	e, err = NewForward(replyOriginal(), ForwardOptions{NoAttachments: true}).Build()
+	assert.NilError(t, err)
+	assert.Check(t, cmp.Len(e.AttachmentParts(), 0))
+}
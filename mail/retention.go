@@ -0,0 +1,232 @@
+package mail
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/foxcpp/go-jmap"
+	"github.com/foxcpp/go-jmap/client"
+)
+
+// RetentionAction is the action applied to Emails matched by
+// RetentionPolicy.
+type RetentionAction int
+
+const (
+	// Destroy matched Emails. Emails that are also in other Mailboxes are
+	// only removed from RetentionPolicy.MailboxID.
+	RetentionDestroy RetentionAction = iota
+
+	// Move matched Emails to RetentionPolicy.ArchiveMailbox.
+	RetentionArchive
+)
+
+// RetentionPolicy describes which Emails should be removed from the Mailbox.
+type RetentionPolicy struct {
+	// Mailbox to apply policy to.
+	MailboxID jmap.ID
+
+	// Emails received earlier than MaxAge ago are matched by the policy.
+	MaxAge time.Duration
+
+	Action RetentionAction
+
+	// Target Mailbox for RetentionArchive.
+	ArchiveMailbox jmap.ID
+}
+
+// Validate checks that the policy is complete. Since the policy destroys or
+// moves Emails, missing values are not replaced with defaults: e.g. empty
+// MailboxID would match Emails in all Mailboxes.
+func (p RetentionPolicy) Validate() error {
+	if p.MailboxID == "" {
+		return errors.New("jmap/mail: retention policy has no MailboxID")
+	}
+	if p.MaxAge <= 0 {
+		return errors.New("jmap/mail: retention policy MaxAge must be positive")
+	}
+	switch p.Action {
+	case RetentionDestroy:
+	case RetentionArchive:
+		if p.ArchiveMailbox == "" {
+			return errors.New("jmap/mail: retention policy has no ArchiveMailbox")
+		}
+		if p.ArchiveMailbox == p.MailboxID {
+			return errors.New("jmap/mail: retention policy ArchiveMailbox is the same as MailboxID")
+		}
+	default:
+		return errors.New("jmap/mail: unknown retention policy action")
+	}
+	return nil
+}
+
+// RetentionReport describes results of applying the RetentionPolicy.
+type RetentionReport struct {
+	Policy RetentionPolicy
+
+	// Ids of Emails matched by the policy.
+	Matched []jmap.ID
+
+	// Results of destroy or move operation, nil for dry run.
+	Result *BulkResult
+}
+
+func queryEmails(c *client.Client, args EmailQueryArgs) (*EmailQueryResponse, error) {
+	respArgs, err := c.Call(mailUsing, "Email/query", args)
+	if err != nil {
+		return nil, err
+	}
+	resp, ok := respArgs.(EmailQueryResponse)
+	if !ok {
+		return nil, unexpectedResponse("Email/query", respArgs)
+	}
+	return &resp, nil
+}
+
+// queryAllEmails returns ids of all Emails matching filter, issuing as many
+// Email/query calls as needed.
+func queryAllEmails(c *client.Client, account jmap.ID, filter interface{}) ([]jmap.ID, error) {
+	var ids []jmap.ID
+	it := NewEmailIterator(c, EmailQueryArgs{
+		AccountID: account,
+		Filter:    filter,
+	})
+	for it.Next() {
+		ids = append(ids, it.ID())
+	}
+	return ids, it.Err()
+}
+
+// ApplyRetention finds Emails matched by the policy (relative to now) and
+// applies the policy action to them. If dryRun is true, nothing is changed
+// and only the list of matched Emails is returned.
+//
+// The policy is checked using RetentionPolicy.Validate first.
+func ApplyRetention(c *client.Client, account jmap.ID, policy RetentionPolicy, now time.Time, dryRun bool) (*RetentionReport, error) {
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+
+	before := jmap.UTCDate(now.Add(-policy.MaxAge))
+	matched, err := queryAllEmails(c, account, EmailFilterCondition{
+		InMailbox: policy.MailboxID,
+		Before:    &before,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	report := &RetentionReport{Policy: policy, Matched: matched}
+	if dryRun || len(matched) == 0 {
+		return report, nil
+	}
+
+	switch policy.Action {
+	case RetentionArchive:
+		patches := make(map[jmap.ID]jmap.PatchObject, len(matched))
+		for _, id := range matched {
+			patches[id] = jmap.PatchObject{
+				mailboxPath(policy.MailboxID):      nil,
+				mailboxPath(policy.ArchiveMailbox): true,
+			}
+		}
+		report.Result, err = UpdateEmails(c, account, patches, nil)
+	case RetentionDestroy:
+		report.Result, err = destroyInMailbox(c, account, policy.MailboxID, matched)
+	}
+	return report, err
+}
+
+// destroyInMailbox destroys Emails that are only in the Mailbox and removes
+// other ones from it.
+func destroyInMailbox(c *client.Client, account, mailbox jmap.ID, emails []jmap.ID) (*BulkResult, error) {
+	var destroy []jmap.ID
+	patches := make(map[jmap.ID]jmap.PatchObject)
+	err := getEmailProperties(c, account, emails, []string{"id", "mailboxIds"}, func(e Email) {
+		for id := range e.MailboxIDs {
+			if id != mailbox {
+				patches[e.ID] = jmap.PatchObject{mailboxPath(mailbox): nil}
+				return
+			}
+		}
+		destroy = append(destroy, e.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := DestroyEmails(c, account, destroy, nil)
+	if err != nil || len(patches) == 0 {
+		return res, err
+	}
+	updated, err := UpdateEmails(c, account, patches, nil)
+	if updated != nil {
+		res.Done = append(res.Done, updated.Done...)
+		res.addFailed(updated.Failed)
+	}
+	return res, err
+}
+
+// RetentionRunner periodically enforces a set of retention policies.
+type RetentionRunner struct {
+	Client    *client.Client
+	AccountID jmap.ID
+	Policies  []RetentionPolicy
+
+	// How often to apply policies.
+	Interval time.Duration
+
+	// Do not change anything, only report matched Emails.
+	DryRun bool
+
+	// Called after each policy application.
+	Report func(*RetentionReport, error)
+
+	// Clock to use for scheduling and for computing Email age. If nil,
+	// client.SystemClock is used.
+	Clock client.Clock
+}
+
+// RunOnce applies all policies once.
+func (rr *RetentionRunner) RunOnce() {
+	now := client.ClockOrSystem(rr.Clock).Now()
+	for _, policy := range rr.Policies {
+		report, err := ApplyRetention(rr.Client, rr.AccountID, policy, now, rr.DryRun)
+		if rr.Report != nil {
+			rr.Report(report, err)
+		}
+	}
+}
+
+// Run applies all policies immediately and then every Interval until ctx is
+// cancelled or the client is closed (client.ErrClientClosed is returned).
+//
+// An error is returned without applying anything if Interval is not
+// positive or any policy is invalid (see RetentionPolicy.Validate).
+func (rr *RetentionRunner) Run(ctx context.Context) error {
+	if rr.Interval <= 0 {
+		return errors.New("jmap/mail: retention runner Interval must be positive")
+	}
+	for _, policy := range rr.Policies {
+		if err := policy.Validate(); err != nil {
+			return err
+		}
+	}
+
+	ctx, cancel := rr.Client.BoundContext(ctx)
+	defer cancel()
+
+	clock := client.ClockOrSystem(rr.Clock)
+	for {
+		if ctx.Err() != nil {
+			return context.Cause(ctx)
+		}
+		rr.RunOnce()
+		select {
+		case <-ctx.Done():
+			return context.Cause(ctx)
+		case <-clock.After(rr.Interval):
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package mail
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/foxcpp/go-jmap"
+	"github.com/foxcpp/go-jmap/client"
+	"gotest.tools/assert"
+	"gotest.tools/assert/cmp"
+)
+
+func TestApplyRetention(t *testing.T) {
+	now := time.Date(2020, 1, 31, 0, 0, 0, 0, time.UTC)
+	var setArgs []map[string]interface{}
+	c, srv := newTestClient(t, func(name string, args map[string]interface{}) []testResponse {
+		switch name {
+		case "Email/query":
+			assert.Check(t, cmp.DeepEqual(map[string]interface{}{
+				"inMailbox": "M1",
+				"before":    "2020-01-01T00:00:00Z",
+			}, args["filter"]))
+			ids := []interface{}{"E1", "E2"}
+			if args["anchor"] != nil {
+				ids = []interface{}{}
+			}
+			return []testResponse{{name, map[string]interface{}{"queryState": "q1", "ids": ids}}}
+		case "Email/get":
+			assert.Check(t, cmp.DeepEqual([]interface{}{"id", "mailboxIds"}, args["properties"]))
+			return []testResponse{{name, map[string]interface{}{"list": []interface{}{
+				map[string]interface{}{"id": "E1", "mailboxIds": map[string]interface{}{"M1": true}},
+				map[string]interface{}{"id": "E2", "mailboxIds": map[string]interface{}{"M1": true, "M3": true}},
+			}}}}
+		case "Email/set":
+			setArgs = append(setArgs, args)
+			updated := map[string]interface{}{}
+			update, _ := args["update"].(map[string]interface{})
+			for id := range update {
+				updated[id] = nil
+			}
+			return []testResponse{{name, map[string]interface{}{
+				"destroyed": args["destroy"],
+				"updated":   updated,
+			}}}
+		}
+		t.Fatalf("unexpected call: %s", name)
+		return nil
+	})
+	defer srv.Close()
+
+	policy := RetentionPolicy{MailboxID: "M1", MaxAge: 30 * 24 * time.Hour}
+
+	report, err := ApplyRetention(c, "A1", policy, now, true)
+	assert.NilError(t, err)
+	assert.Check(t, cmp.DeepEqual([]jmap.ID{"E1", "E2"}, report.Matched))
+	assert.Check(t, report.Result == nil)
+	assert.Check(t, cmp.Len(setArgs, 0))
+
+	// E2 is also in M3 and so is only removed from M1.
+	report, err = ApplyRetention(c, "A1", policy, now, false)
+	assert.NilError(t, err)
+	assert.Check(t, cmp.DeepEqual([]jmap.ID{"E1", "E2"}, report.Result.Done))
+	assert.Assert(t, cmp.Len(setArgs, 2))
+	assert.Check(t, cmp.DeepEqual([]interface{}{"E1"}, setArgs[0]["destroy"]))
+	assert.Check(t, cmp.DeepEqual(map[string]interface{}{
+		"E2": map[string]interface{}{"mailboxIds/M1": nil},
+	}, setArgs[1]["update"]))
+
+	policy.Action = RetentionArchive
+	policy.ArchiveMailbox = "M2"
+	_, err = ApplyRetention(c, "A1", policy, now, false)
+	assert.NilError(t, err)
+	assert.Assert(t, cmp.Len(setArgs, 3))
+	assert.Check(t, cmp.DeepEqual(map[string]interface{}{
+		"E1": map[string]interface{}{"mailboxIds/M1": nil, "mailboxIds/M2": true},
+		"E2": map[string]interface{}{"mailboxIds/M1": nil, "mailboxIds/M2": true},
+	}, setArgs[2]["update"]))
+}
+
+func TestRetentionValidation(t *testing.T) {
+	c, srv := newTestClient(t, func(name string, args map[string]interface{}) []testResponse {
+		t.Fatalf("unexpected call: %s", name)
+		return nil
+	})
+	defer srv.Close()
+
+	valid := RetentionPolicy{MailboxID: "M1", MaxAge: time.Hour}
+	for name, policy := range map[string]RetentionPolicy{
+		"no mailbox":        {MaxAge: time.Hour},
+		"zero age":          {MailboxID: "M1"},
+		"negative age":      {MailboxID: "M1", MaxAge: -time.Hour},
+		"no archive":        {MailboxID: "M1", MaxAge: time.Hour, Action: RetentionArchive},
+		"archive to itself": {MailboxID: "M1", MaxAge: time.Hour, Action: RetentionArchive, ArchiveMailbox: "M1"},
+		"unknown action":    {MailboxID: "M1", MaxAge: time.Hour, Action: 42},
+	} {
+		_, err := ApplyRetention(c, "A1", policy, time.Now(), false)
+		assert.Check(t, err != nil, name)
+	}
+
+	rr := RetentionRunner{Client: c, AccountID: "A1", Policies: []RetentionPolicy{valid}}
+	assert.Check(t, cmp.ErrorContains(rr.Run(context.Background()), "Interval"))
+
+	rr.Interval = time.Hour
+	rr.Policies = append(rr.Policies, RetentionPolicy{MaxAge: time.Hour})
+	assert.Check(t, cmp.ErrorContains(rr.Run(context.Background()), "MailboxID"))
+
+	rr.Policies = []RetentionPolicy{valid}
+	assert.NilError(t, c.Close())
+	assert.Check(t, cmp.Equal(client.ErrClientClosed, rr.Run(context.Background())))
+}
@@ -0,0 +1,38 @@
+package mail
+
+import "github.com/foxcpp/go-jmap"
+
+// PropertySchemas contains property lists for all data types implemented by
+// this package.
+//
+// Pass it to client.EnableSchemas to make the client reject requests using
+// unknown properties (e.g. misspelled "recievedAt") before sending them.
+var PropertySchemas = jmap.PropertySchemas{
+	"Email": {
+		"id", "blobId", "threadId", "mailboxIds", "keywords", "size",
+		"receivedAt", "headers", "messageId", "inReplyTo", "references",
+		"sender", "from", "to", "cc", "bcc", "replyTo", "subject", "sentAt",
+		"bodyStructure", "bodyValues", "textBody", "htmlBody", "attachments",
+		"hasAttachment", "preview",
+		"smimeStatus", "smimeStatusAtDelivery", "smimeErrors", "smimeVerifiedAt",
+		"header:*",
+	},
+	"Mailbox": {
+		"id", "name", "parentId", "role", "sortOrder", "totalEmails",
+		"unreadEmails", "totalThreads", "unreadThreads", "myRights",
+		"isSubscribed", "shareWith",
+	},
+	"Thread": {"id", "emailIds"},
+	"Identity": {
+		"id", "name", "email", "replyTo", "bcc", "textSignature",
+		"htmlSignature", "mayDelete",
+	},
+	"EmailSubmission": {
+		"id", "identityId", "emailId", "threadId", "envelope", "sendAt",
+		"undoStatus", "deliveryStatus", "dsnBlobIds", "mdnBlobIds",
+	},
+	"VacationResponse": {
+		"id", "isEnabled", "fromDate", "toDate", "subject", "textBody",
+		"htmlBody",
+	},
+}
@@ -0,0 +1,49 @@
+package mail
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/foxcpp/go-jmap"
+	"gotest.tools/assert"
+	"gotest.tools/assert/cmp"
+)
+
+func TestPropertySchemasComplete(t *testing.T) {
+	types := map[string]interface{}{
+		"Email":            Email{},
+		"Mailbox":          Mailbox{},
+		"Thread":           Thread{},
+		"Identity":         Identity{},
+		"EmailSubmission":  EmailSubmission{},
+		"VacationResponse": VacationResponse{},
+	}
+	for name, v := range types {
+		rt := reflect.TypeOf(v)
+		for i := 0; i < rt.NumField(); i++ {
+			tag := strings.Split(rt.Field(i).Tag.Get("json"), ",")[0]
+			if tag == "" || tag == "-" {
+				continue
+			}
+			assert.Check(t, PropertySchemas.Known(name, tag), "%s.%s is missing in PropertySchemas", name, tag)
+		}
+	}
+}
+
+func TestPropertySchemasClient(t *testing.T) {
+	c, srv := newTestClient(t, func(name string, args map[string]interface{}) []testResponse {
+		t.Fatalf("unexpected call: %s", name)
+		return nil
+	})
+	defer srv.Close()
+	c.EnableSchemas(PropertySchemas)
+
+	_, err := getEmails(c, EmailGetArgs{
+		AccountID:  "A1",
+		Properties: []string{"id", "header:X-Foo:asText", "recievedAt"},
+	})
+	propErr, ok := err.(*jmap.PropertyError)
+	assert.Assert(t, ok, "unexpected error: %v", err)
+	assert.Check(t, cmp.Equal("recievedAt", propErr.Property))
+}
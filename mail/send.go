@@ -0,0 +1,210 @@
+package mail
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/foxcpp/go-jmap"
+	"github.com/foxcpp/go-jmap/client"
+)
+
+var (
+	ErrNoIdentity = errors.New("jmap/mail: no identity matches the From address of the draft")
+	ErrNoDrafts   = errors.New("jmap/mail: draft has no mailboxIds and there is no Drafts mailbox")
+)
+
+var submissionUsing = []string{jmap.CoreCapabilityName, jmap.MailCapabilityName, jmap.SubmissionCapabilityName}
+
+// SendOptions contains optional parameters for SendWithOptions.
+type SendOptions struct {
+	// The id of the Identity to send the Email with. If empty, the Identity
+	// with email equal to the first From address of the draft is used.
+	IdentityID jmap.ID `json:"identityId,omitempty"`
+
+	// If true, the Email is left as is after the submission. Otherwise it
+	// is moved from Drafts to the Mailbox with "sent" role (if any) and
+	// $draft keyword is removed.
+	KeepDraft bool `json:"keepDraft,omitempty"`
+
+	// If not zero, the server is asked to hold the message for this duration
+	// before releasing it for delivery, allowing it to be canceled using
+	// CancelSubmission. If envelope is nil, it is constructed from the
+	// message headers.
+	Hold time.Duration `json:"hold,omitempty"`
+}
+
+// SendResult contains ids of objects created by Send.
+type SendResult struct {
+	// The id of the created Email. It is set even if the submission failed.
+	EmailID jmap.ID `json:"emailId,omitempty"`
+
+	// The id of the created EmailSubmission.
+	SubmissionID jmap.ID `json:"submissionId,omitempty"`
+
+	// The time the submission will be released for delivery, if returned by
+	// the server.
+	SendAt *jmap.UTCDate `json:"sendAt,omitempty"`
+}
+
+// Send stores the draft in the Drafts Mailbox and submits it for delivery
+// using the envelope (if envelope is nil, the server constructs it from the
+// message headers).
+//
+// It is equivalent to SendWithOptions with zero SendOptions.
+func Send(c *client.Client, account jmap.ID, draft Email, envelope *Envelope) (*SendResult, error) {
+	return SendWithOptions(c, account, draft, envelope, SendOptions{})
+}
+
+// SendWithOptions stores the draft and submits it for delivery.
+//
+// Email/set and EmailSubmission/set calls are sent in a single request with
+// the submission referring to the created Email. If the draft has no
+// mailboxIds set, it is placed in the Mailbox with the "drafts" role.
+//
+// If Email creation fails, the returned error is jmap.SetError. If the
+// submission fails, the Email remains in Drafts, its id is returned in
+// SendResult along with jmap.SetError for the submission.
+//
+// The client must have ResponseUnmarshallers enabled.
+func SendWithOptions(c *client.Client, account jmap.ID, draft Email, envelope *Envelope, opts SendOptions) (*SendResult, error) {
+	res, _, err := sendWithOptions(c, account, draft, envelope, opts)
+	return res, err
+}
+
+// sendWithOptions implements SendWithOptions. maybeSent is true if the
+// request with Email/set and EmailSubmission/set calls failed in a way that
+// does not tell whether the server processed it (e.g. the connection was
+// lost while waiting for the response).
+func sendWithOptions(c *client.Client, account jmap.ID, draft Email, envelope *Envelope, opts SendOptions) (res *SendResult, maybeSent bool, err error) {
+	if opts.Hold != 0 {
+		session, err := c.CurrentSession()
+		if err != nil {
+			return nil, false, err
+		}
+		envelope, err = holdEnvelope(session, account, draft, envelope, opts.Hold, client.ClockOrSystem(c.Clock).Now())
+		if err != nil {
+			return nil, false, err
+		}
+	}
+
+	identity := opts.IdentityID
+	if identity == "" {
+		if len(draft.From) == 0 {
+			return nil, false, ErrNoIdentity
+		}
+		idents, err := getIdentities(c, account)
+		if err != nil {
+			return nil, false, err
+		}
+		for _, ident := range idents.List {
+			if strings.EqualFold(ident.Email, draft.From[0].Email) {
+				identity = ident.ID
+				break
+			}
+		}
+		if identity == "" {
+			return nil, false, ErrNoIdentity
+		}
+	}
+
+	var draftsMailbox, sentMailbox jmap.ID
+	if len(draft.MailboxIDs) == 0 || !opts.KeepDraft {
+		mboxes, err := getMailboxes(c, MailboxGetArgs{
+			AccountID:  account,
+			Properties: []string{"id", "role"},
+		})
+		if err != nil {
+			return nil, false, err
+		}
+		for _, mbox := range mboxes.List {
+			switch mbox.Role {
+			case RoleDrafts:
+				draftsMailbox = mbox.ID
+			case RoleSent:
+				sentMailbox = mbox.ID
+			}
+		}
+	}
+	if len(draft.MailboxIDs) == 0 {
+		if draftsMailbox == "" {
+			return nil, false, ErrNoDrafts
+		}
+		draft.MailboxIDs = map[jmap.ID]bool{draftsMailbox: true}
+	}
+
+	var onSuccess jmap.PatchObject
+	if !opts.KeepDraft {
+		onSuccess = jmap.PatchObject{
+			keywordPath(KeywordDraft): nil,
+		}
+		if sentMailbox != "" {
+			for mbox := range draft.MailboxIDs {
+				onSuccess[mailboxPath(mbox)] = nil
+			}
+			onSuccess[mailboxPath(sentMailbox)] = true
+		}
+	}
+
+	b, calls := SendDraftBatch(nil, account, identity, draft, envelope, onSuccess)
+	resp, err := c.RawSend(b.Request())
+	if err != nil {
+		return nil, requestMayBeApplied(err), err
+	}
+
+	res = &SendResult{}
+	for _, inv := range resp.Responses {
+		switch args := inv.Args.(type) {
+		case jmap.MethodErrorArgs:
+			return res, false, args
+		case EmailSetResponse:
+			// Implicit Email/set response for onSuccessUpdateEmail has the
+			// same call id as the submission.
+			if inv.CallID != calls.EmailSet {
+				continue
+			}
+			if setErr, ok := args.NotCreated[calls.DraftCreationID]; ok {
+				return nil, false, setErr
+			}
+			created, ok := args.Created[calls.DraftCreationID]
+			if !ok {
+				return nil, false, fmt.Errorf("jmap/mail: draft is neither created nor rejected")
+			}
+			res.EmailID = created.ID
+		case EmailSubmissionSetResponse:
+			if setErr, ok := args.NotCreated[calls.SubmissionCreationID]; ok {
+				return res, false, setErr
+			}
+			res.SubmissionID = args.Created[calls.SubmissionCreationID].ID
+			res.SendAt = args.Created[calls.SubmissionCreationID].SendAt
+		default:
+			return res, false, unexpectedResponse(inv.Name, inv.Args)
+		}
+	}
+	if res.SubmissionID == "" {
+		return res, false, fmt.Errorf("jmap/mail: no EmailSubmission/set response")
+	}
+	return res, false, nil
+}
+
+// requestMayBeApplied reports whether the request that failed with the
+// error returned by Client.RawSend could still have been processed by the
+// server.
+//
+// Only request-level errors and non-5xx HTTP errors guarantee that the
+// request was rejected. Server errors may be reported by proxies after the
+// request was processed.
+func requestMayBeApplied(err error) bool {
+	switch err := err.(type) {
+	case jmap.RequestError:
+		return err.Status/100 == 5
+	case *jmap.RequestError:
+		return err.Status/100 == 5
+	case client.HTTPError:
+		return err.StatusCode/100 == 5
+	case *client.HTTPError:
+		return err.StatusCode/100 == 5
+	}
+	return true
+}
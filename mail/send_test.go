@@ -0,0 +1,95 @@
+package mail
+
+import (
+	"testing"
+
+	"github.com/foxcpp/go-jmap"
+	"gotest.tools/assert"
+	"gotest.tools/assert/cmp"
+)
+
+func TestSend(t *testing.T) {
+	var submission, onSuccess map[string]interface{}
+	c, srv := newTestClient(t, func(name string, args map[string]interface{}) []testResponse {
+		switch name {
+		case "Identity/get":
+			return []testResponse{{name, map[string]interface{}{
+				"list": []interface{}{map[string]interface{}{"id": "I1", "email": "Joe@example.com"}},
+			}}}
+		case "Mailbox/get":
+			return []testResponse{{name, map[string]interface{}{
+				"list": []interface{}{
+					map[string]interface{}{"id": "D", "role": "drafts"},
+					map[string]interface{}{"id": "S", "role": "sent"},
+				},
+			}}}
+		case "Email/set":
+			return []testResponse{{name, map[string]interface{}{
+				"created": map[string]interface{}{"draft0": map[string]interface{}{"id": "E1"}},
+			}}}
+		case "EmailSubmission/set":
+			submission = args["create"].(map[string]interface{})["send1"].(map[string]interface{})
+			onSuccess = args["onSuccessUpdateEmail"].(map[string]interface{})["#send1"].(map[string]interface{})
+			return []testResponse{
+				{name, map[string]interface{}{
+					"created": map[string]interface{}{"send1": map[string]interface{}{"id": "S1"}},
+				}},
+				{"Email/set", map[string]interface{}{
+					"updated": map[string]interface{}{"E1": nil},
+				}},
+			}
+		}
+		t.Fatalf("unexpected call: %s", name)
+		return nil
+	})
+	defer srv.Close()
+
+	draft, err := NewEmailBuilder().
+		From(EmailAddress{Email: "joe@example.com"}).
+		To(EmailAddress{Email: "jane@example.com"}).
+		TextBody("Hello").
+		Build()
+	assert.NilError(t, err)
+
+	res, err := Send(c, "A1", draft, nil)
+	assert.NilError(t, err)
+	assert.Check(t, cmp.DeepEqual(&SendResult{EmailID: "E1", SubmissionID: "S1"}, res))
+	assert.Check(t, cmp.Equal("I1", submission["identityId"]))
+	assert.Check(t, cmp.Equal("#draft0", submission["emailId"]))
+	assert.Check(t, cmp.DeepEqual(map[string]interface{}{
+		"keywords/$draft": nil,
+		"mailboxIds/D":    nil,
+		"mailboxIds/S":    true,
+	}, onSuccess))
+
+	t.Run("no identity", func(t *testing.T) {
+		draft.From = []EmailAddress{{Email: "other@example.com"}}
+		_, err := Send(c, "A1", draft, nil)
+		assert.Equal(t, ErrNoIdentity, err)
+	})
+
+	t.Run("submission rejected", func(t *testing.T) {
+		c, srv := newTestClient(t, func(name string, args map[string]interface{}) []testResponse {
+			switch name {
+			case "Email/set":
+				return []testResponse{{name, map[string]interface{}{
+					"created": map[string]interface{}{"draft0": map[string]interface{}{"id": "E1"}},
+				}}}
+			case "EmailSubmission/set":
+				return []testResponse{{name, map[string]interface{}{
+					"notCreated": map[string]interface{}{"send1": map[string]interface{}{"type": "forbiddenFrom"}},
+				}}}
+			}
+			t.Fatalf("unexpected call: %s", name)
+			return nil
+		})
+		defer srv.Close()
+
+		draft.MailboxIDs = map[jmap.ID]bool{"D": true}
+		res, err := SendWithOptions(c, "A1", draft, nil, SendOptions{IdentityID: "I1", KeepDraft: true})
+		assert.Check(t, cmp.Equal(jmap.ID("E1"), res.EmailID))
+		setErr, ok := err.(jmap.SetError)
+		assert.Assert(t, ok, "expected SetError, got %v", err)
+		assert.Check(t, cmp.Equal(jmap.ErrorCode("forbiddenFrom"), setErr.Type))
+	})
+}
@@ -0,0 +1,458 @@
+package mail
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/foxcpp/go-jmap"
+	"github.com/foxcpp/go-jmap/client"
+	"github.com/foxcpp/go-jmap/quota"
+)
+
+// SnapshotVersion is the version of AccountSnapshot format written by this
+// package.
+const SnapshotVersion = 1
+
+// AccountSnapshot contains account metadata (settings) that can be used
+// for backup or to provision another account.
+//
+// Message contents are not included.
+type AccountSnapshot struct {
+	// Format version, see SnapshotVersion.
+	Version int `json:"version"`
+
+	// The id of the account the snapshot was taken from.
+	AccountID jmap.ID `json:"accountId"`
+
+	// The time the snapshot was taken.
+	Taken jmap.UTCDate `json:"taken"`
+
+	// All Mailboxes of the account.
+	Mailboxes []Mailbox `json:"mailboxes"`
+
+	// All Identities of the account. Nil if the account does not support
+	// urn:ietf:params:jmap:submission.
+	Identities []Identity `json:"identities,omitempty"`
+
+	// Vacation response settings. Nil if the account does not support
+	// urn:ietf:params:jmap:vacationresponse.
+	VacationResponse *VacationResponse `json:"vacationResponse,omitempty"`
+
+	// The list of Quota objects as returned by Quota/get (RFC 9425). It is
+	// informational only and not re-applied.
+	//
+	// Quotas are included only if the account supports
+	// urn:ietf:params:jmap:quota and the client has an unmarshaller for
+	// Quota/get enabled (e.g. jmap.RawUnmarshallers).
+	Quotas json.RawMessage `json:"quotas,omitempty"`
+}
+
+// Encode writes the snapshot as a JSON document.
+func (s *AccountSnapshot) Encode(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(s)
+}
+
+// DecodeSnapshot reads the snapshot written by AccountSnapshot.Encode.
+func DecodeSnapshot(r io.Reader) (*AccountSnapshot, error) {
+	var s AccountSnapshot
+	if err := json.NewDecoder(r).Decode(&s); err != nil {
+		return nil, err
+	}
+	if s.Version != SnapshotVersion {
+		return nil, fmt.Errorf("jmap/mail: unsupported snapshot version %d", s.Version)
+	}
+	return &s, nil
+}
+
+func hasCapability(session *jmap.Session, account jmap.ID, capability string) bool {
+	acc, ok := session.Accounts[account]
+	if !ok {
+		return false
+	}
+	_, ok = acc.Capabilities[capability]
+	return ok
+}
+
+func getIdentities(c *client.Client, account jmap.ID) (*IdentityGetResponse, error) {
+	respArgs, err := c.Call([]string{jmap.CoreCapabilityName, jmap.SubmissionCapabilityName}, "Identity/get", IdentityGetArgs{
+		AccountID: account,
+	})
+	if err != nil {
+		return nil, err
+	}
+	resp, ok := respArgs.(IdentityGetResponse)
+	if !ok {
+		return nil, unexpectedResponse("Identity/get", respArgs)
+	}
+	return &resp, nil
+}
+
+func setIdentities(c *client.Client, args IdentitySetArgs) (*IdentitySetResponse, error) {
+	respArgs, err := c.Call([]string{jmap.CoreCapabilityName, jmap.SubmissionCapabilityName}, "Identity/set", args)
+	if err != nil {
+		return nil, err
+	}
+	resp, ok := respArgs.(IdentitySetResponse)
+	if !ok {
+		return nil, unexpectedResponse("Identity/set", respArgs)
+	}
+	return &resp, nil
+}
+
+var vacationUsing = []string{jmap.CoreCapabilityName, VacationResponseCapabilityName}
+
+func getVacationResponse(c *client.Client, account jmap.ID) (*VacationResponse, error) {
+	respArgs, err := c.Call(vacationUsing, "VacationResponse/get", VacationResponseGetArgs{
+		AccountID: account,
+	})
+	if err != nil {
+		return nil, err
+	}
+	resp, ok := respArgs.(VacationResponseGetResponse)
+	if !ok {
+		return nil, unexpectedResponse("VacationResponse/get", respArgs)
+	}
+	if len(resp.List) == 0 {
+		return nil, nil
+	}
+	return &resp.List[0], nil
+}
+
+func getQuotas(c *client.Client, account jmap.ID) (json.RawMessage, error) {
+	respArgs, err := c.Call([]string{jmap.CoreCapabilityName, quota.CapabilityName}, "Quota/get", map[string]interface{}{
+		"accountId": account,
+		"ids":       nil,
+	})
+	if err != nil {
+		if _, ok := err.(jmap.UnknownMethodError); ok {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	// Response may be decoded into any structure depending on enabled
+	// unmarshallers, normalize it back to JSON.
+	blob, ok := respArgs.(json.RawMessage)
+	if !ok {
+		blob, err = json.Marshal(respArgs)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var resp struct {
+		List json.RawMessage `json:"list"`
+	}
+	if err := json.Unmarshal(blob, &resp); err != nil {
+		return nil, err
+	}
+	return resp.List, nil
+}
+
+// ExportSnapshot collects mailbox tree, identities, vacation response and
+// quota usage of the account.
+//
+// The client must have ResponseUnmarshallers enabled.
+func ExportSnapshot(c *client.Client, account jmap.ID) (*AccountSnapshot, error) {
+	session, err := c.CurrentSession()
+	if err != nil {
+		return nil, err
+	}
+
+	snap := &AccountSnapshot{
+		Version:   SnapshotVersion,
+		AccountID: account,
+		Taken:     jmap.UTCDate(client.ClockOrSystem(c.Clock).Now().UTC()),
+	}
+
+	mboxes, err := getMailboxes(c, MailboxGetArgs{AccountID: account})
+	if err != nil {
+		return nil, err
+	}
+	snap.Mailboxes = mboxes.List
+
+	if hasCapability(session, account, jmap.SubmissionCapabilityName) {
+		idents, err := getIdentities(c, account)
+		if err != nil {
+			return nil, err
+		}
+		snap.Identities = idents.List
+	}
+
+	if hasCapability(session, account, VacationResponseCapabilityName) {
+		snap.VacationResponse, err = getVacationResponse(c, account)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if hasCapability(session, account, quota.CapabilityName) {
+		snap.Quotas, err = getQuotas(c, account)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return snap, nil
+}
+
+// SnapshotApplyResult contains results of AccountSnapshot.Apply.
+type SnapshotApplyResult struct {
+	// Mapping of Mailbox ids in the snapshot to ids of the corresponding
+	// Mailboxes in the target account.
+	Mailboxes map[jmap.ID]jmap.ID
+
+	// Mapping of Identity ids in the snapshot to ids of the corresponding
+	// Identities in the target account.
+	Identities map[jmap.ID]jmap.ID
+
+	// Errors for objects that failed to be created or updated. Keys are in
+	// the form "Type/id" where id is the id from the snapshot, e.g.
+	// "Mailbox/M1", or "VacationResponse" for vacation response.
+	Failed map[string]jmap.SetError
+}
+
+func (r *SnapshotApplyResult) fail(key string, err jmap.SetError) {
+	if r.Failed == nil {
+		r.Failed = make(map[string]jmap.SetError)
+	}
+	r.Failed[key] = err
+}
+
+// Apply re-creates settings stored in the snapshot in the account.
+//
+// Mailboxes are matched by role first, preferring the one under the same
+// parent, and then by name under the same parent. Missing Mailboxes are
+// created, existing ones get sortOrder and isSubscribed updated. Mailboxes
+// matched by role are also moved under the parent from the snapshot. Identities are matched by email address. Quotas are
+// not applied.
+//
+// Failures for individual objects are reported in SnapshotApplyResult,
+// children of Mailboxes that failed to be created are skipped and reported
+// as failed too. Errors for whole method calls are returned directly.
+//
+// The client must have ResponseUnmarshallers enabled.
+func (s *AccountSnapshot) Apply(c *client.Client, account jmap.ID) (*SnapshotApplyResult, error) {
+	session, err := c.CurrentSession()
+	if err != nil {
+		return nil, err
+	}
+
+	res := &SnapshotApplyResult{
+		Mailboxes:  make(map[jmap.ID]jmap.ID),
+		Identities: make(map[jmap.ID]jmap.ID),
+	}
+
+	if err := s.applyMailboxes(c, account, res); err != nil {
+		return res, err
+	}
+
+	if s.Identities != nil && hasCapability(session, account, jmap.SubmissionCapabilityName) {
+		if err := s.applyIdentities(c, account, res); err != nil {
+			return res, err
+		}
+	}
+
+	if s.VacationResponse != nil && hasCapability(session, account, VacationResponseCapabilityName) {
+		err := setVacationResponse(c, account, vacationPatch(*s.VacationResponse))
+		if setErr, ok := err.(jmap.SetError); ok {
+			res.fail("VacationResponse", setErr)
+		} else if err != nil {
+			return res, err
+		}
+	}
+
+	return res, nil
+}
+
+func (s *AccountSnapshot) applyMailboxes(c *client.Client, account jmap.ID, res *SnapshotApplyResult) error {
+	current, err := getMailboxes(c, MailboxGetArgs{
+		AccountID:  account,
+		Properties: []string{"id", "name", "parentId", "role", "sortOrder", "isSubscribed"},
+	})
+	if err != nil {
+		return err
+	}
+	existing := current.List
+
+	children := make(map[jmap.ID][]Mailbox)
+	for _, mbox := range s.Mailboxes {
+		children[mbox.ParentID] = append(children[mbox.ParentID], mbox)
+	}
+
+	// Process the tree level by level so parent ids are known when children
+	// are created.
+	level := children[""]
+	for len(level) != 0 {
+		args := MailboxSetArgs{
+			AccountID: account,
+			Create:    make(map[jmap.ID]Mailbox),
+			Update:    make(map[jmap.ID]jmap.PatchObject),
+		}
+		srcByCreationID := make(map[jmap.ID]jmap.ID)
+		srcByTargetID := make(map[jmap.ID]jmap.ID)
+
+		var next []Mailbox
+		for _, src := range level {
+			parent := jmap.ID("")
+			if src.ParentID != "" {
+				var ok bool
+				parent, ok = res.Mailboxes[src.ParentID]
+				if !ok {
+					res.fail("Mailbox/"+string(src.ID), jmap.SetError{
+						Type:        jmap.CodeInvalidProperties,
+						Description: "parent mailbox was not created",
+						Properties:  []string{"parentId"},
+					})
+					continue
+				}
+			}
+			next = append(next, children[src.ID]...)
+
+			if match := matchMailbox(existing, src, parent); match != nil {
+				res.Mailboxes[src.ID] = match.ID
+				patch := jmap.PatchObject{}
+				if match.ParentID != parent {
+					patch["parentId"] = nil
+					if parent != "" {
+						patch["parentId"] = parent
+					}
+				}
+				if match.SortOrder != src.SortOrder {
+					patch["sortOrder"] = src.SortOrder
+				}
+				if match.IsSubscribed != src.IsSubscribed {
+					patch["isSubscribed"] = src.IsSubscribed
+				}
+				if len(patch) != 0 {
+					args.Update[match.ID] = patch
+					srcByTargetID[match.ID] = src.ID
+				}
+				continue
+			}
+
+			creationID := jmap.ID(fmt.Sprintf("m%d", len(args.Create)))
+			args.Create[creationID] = Mailbox{
+				Name:         src.Name,
+				ParentID:     parent,
+				Role:         src.Role,
+				SortOrder:    src.SortOrder,
+				IsSubscribed: src.IsSubscribed,
+			}
+			srcByCreationID[creationID] = src.ID
+		}
+		level = next
+
+		if len(args.Create) == 0 && len(args.Update) == 0 {
+			continue
+		}
+		resp, err := setMailboxes(c, args)
+		if err != nil {
+			return err
+		}
+		for creationID, created := range resp.Created {
+			res.Mailboxes[srcByCreationID[creationID]] = created.ID
+			mbox := args.Create[creationID]
+			mbox.ID = created.ID
+			existing = append(existing, mbox)
+		}
+		for creationID, setErr := range resp.NotCreated {
+			res.fail("Mailbox/"+string(srcByCreationID[creationID]), setErr)
+		}
+		for id, setErr := range resp.NotUpdated {
+			res.fail("Mailbox/"+string(srcByTargetID[id]), setErr)
+		}
+	}
+
+	return nil
+}
+
+func matchMailbox(existing []Mailbox, src Mailbox, parent jmap.ID) *Mailbox {
+	if src.Role != "" {
+		var byRole *Mailbox
+		for i := range existing {
+			if existing[i].Role != src.Role {
+				continue
+			}
+			if existing[i].ParentID == parent {
+				return &existing[i]
+			}
+			if byRole == nil {
+				byRole = &existing[i]
+			}
+		}
+		if byRole != nil {
+			return byRole
+		}
+	}
+	for i := range existing {
+		if existing[i].ParentID == parent && existing[i].Name == src.Name {
+			return &existing[i]
+		}
+	}
+	return nil
+}
+
+func (s *AccountSnapshot) applyIdentities(c *client.Client, account jmap.ID, res *SnapshotApplyResult) error {
+	current, err := getIdentities(c, account)
+	if err != nil {
+		return err
+	}
+	byEmail := make(map[string]Identity, len(current.List))
+	for _, ident := range current.List {
+		byEmail[ident.Email] = ident
+	}
+
+	args := IdentitySetArgs{
+		AccountID: account,
+		Create:    make(map[jmap.ID]Identity),
+		Update:    make(map[jmap.ID]jmap.PatchObject),
+	}
+	srcByCreationID := make(map[jmap.ID]jmap.ID)
+	srcByTargetID := make(map[jmap.ID]jmap.ID)
+	for _, src := range s.Identities {
+		if match, ok := byEmail[src.Email]; ok {
+			res.Identities[src.ID] = match.ID
+			args.Update[match.ID] = jmap.PatchObject{
+				"name":          src.Name,
+				"replyTo":       src.ReplyTo,
+				"bcc":           src.BCC,
+				"textSignature": src.TextSignature,
+				"htmlSignature": src.HTMLSignature,
+			}
+			srcByTargetID[match.ID] = src.ID
+			continue
+		}
+
+		creationID := jmap.ID(fmt.Sprintf("i%d", len(args.Create)))
+		args.Create[creationID] = Identity{
+			Name:          src.Name,
+			Email:         src.Email,
+			ReplyTo:       src.ReplyTo,
+			BCC:           src.BCC,
+			TextSignature: src.TextSignature,
+			HTMLSignature: src.HTMLSignature,
+		}
+		srcByCreationID[creationID] = src.ID
+	}
+	if len(args.Create) == 0 && len(args.Update) == 0 {
+		return nil
+	}
+
+	resp, err := setIdentities(c, args)
+	if err != nil {
+		return err
+	}
+	for creationID, created := range resp.Created {
+		res.Identities[srcByCreationID[creationID]] = created.ID
+	}
+	for creationID, setErr := range resp.NotCreated {
+		res.fail("Identity/"+string(srcByCreationID[creationID]), setErr)
+	}
+	for id, setErr := range resp.NotUpdated {
+		res.fail("Identity/"+string(srcByTargetID[id]), setErr)
+	}
+	return nil
+}
@@ -0,0 +1,198 @@
+package mail
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/foxcpp/go-jmap"
+	"github.com/foxcpp/go-jmap/jmaptest"
+	"gotest.tools/assert"
+	"gotest.tools/assert/cmp"
+)
+
+func TestSnapshotEncodeDecode(t *testing.T) {
+	snap := &AccountSnapshot{
+		Version:   SnapshotVersion,
+		AccountID: "A1",
+		Taken:     jmap.UTCDate(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)),
+		Mailboxes: []Mailbox{
+			{ID: "M1", Name: "Inbox", Role: RoleInbox},
+			{ID: "M2", Name: "Lists", ParentID: "M1", IsSubscribed: true},
+		},
+		VacationResponse: &VacationResponse{ID: VacationResponseID, Subject: "Away"},
+	}
+
+	var buf bytes.Buffer
+	assert.NilError(t, snap.Encode(&buf))
+
+	decoded, err := DecodeSnapshot(&buf)
+	assert.NilError(t, err)
+	assert.Check(t, cmp.DeepEqual(snap.Mailboxes, decoded.Mailboxes))
+	assert.Check(t, cmp.Equal("Away", decoded.VacationResponse.Subject))
+	assert.Check(t, time.Time(decoded.Taken).Equal(time.Time(snap.Taken)))
+
+	t.Run("unknown version", func(t *testing.T) {
+		_, err := DecodeSnapshot(strings.NewReader(`{"version": 999}`))
+		assert.Check(t, err != nil)
+	})
+}
+
+func TestMatchMailbox(t *testing.T) {
+	existing := []Mailbox{
+		{ID: "T1", Name: "Posteingang", Role: RoleInbox},
+		{ID: "T2", Name: "Lists", ParentID: "T1"},
+	}
+
+	assert.Check(t, cmp.Equal(jmap.ID("T1"), matchMailbox(existing, Mailbox{Name: "Inbox", Role: RoleInbox}, "").ID))
+	assert.Check(t, cmp.Equal(jmap.ID("T2"), matchMailbox(existing, Mailbox{Name: "Lists"}, "T1").ID))
+	assert.Check(t, matchMailbox(existing, Mailbox{Name: "Lists"}, "") == nil)
+
+	// Mailboxes with the same role under the requested parent are preferred.
+	existing = append(existing, Mailbox{ID: "T3", Name: "Archive", Role: RoleArchive, ParentID: "T1"},
+		Mailbox{ID: "T4", Name: "Archive", Role: RoleArchive})
+	assert.Check(t, cmp.Equal(jmap.ID("T4"), matchMailbox(existing, Mailbox{Name: "Archive", Role: RoleArchive}, "").ID))
+	assert.Check(t, cmp.Equal(jmap.ID("T3"), matchMailbox(existing, Mailbox{Name: "Archive", Role: RoleArchive}, "T1").ID))
+	assert.Check(t, cmp.Equal(jmap.ID("T3"), matchMailbox(existing, Mailbox{Name: "Archive", Role: RoleArchive}, "T2").ID))
+}
+
+func TestExportSnapshot(t *testing.T) {
+	c, srv := newTestClient(t, func(name string, args map[string]interface{}) []testResponse {
+		switch name {
+		case "Mailbox/get":
+			return []testResponse{{name, map[string]interface{}{"state": "1", "list": []interface{}{
+				map[string]interface{}{"id": "M1", "name": "Inbox", "role": "inbox"},
+				map[string]interface{}{"id": "M2", "name": "Lists", "parentId": "M1"},
+			}}}}
+		case "Identity/get":
+			return []testResponse{{name, map[string]interface{}{"state": "1", "list": []interface{}{
+				map[string]interface{}{"id": "I1", "name": "Joe", "email": "joe@example.com"},
+			}}}}
+		case "VacationResponse/get":
+			return []testResponse{{name, map[string]interface{}{"state": "1", "list": []interface{}{
+				map[string]interface{}{"id": "singleton", "isEnabled": true, "subject": "Away"},
+			}}}}
+		}
+		t.Fatalf("unexpected call: %s", name)
+		return nil
+	})
+	defer srv.Close()
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	c.Clock = jmaptest.NewFakeClock(now)
+
+	snap, err := ExportSnapshot(c, "A1")
+	assert.NilError(t, err)
+	assert.Check(t, cmp.Equal(SnapshotVersion, snap.Version))
+	assert.Check(t, cmp.Equal(jmap.ID("A1"), snap.AccountID))
+	assert.Check(t, time.Time(snap.Taken).Equal(now))
+	assert.Assert(t, cmp.Len(snap.Mailboxes, 2))
+	assert.Check(t, cmp.Equal(jmap.ID("M1"), snap.Mailboxes[1].ParentID))
+	assert.Assert(t, cmp.Len(snap.Identities, 1))
+	assert.Check(t, cmp.Equal("joe@example.com", snap.Identities[0].Email))
+	assert.Assert(t, snap.VacationResponse != nil)
+	assert.Check(t, snap.VacationResponse.IsEnabled)
+	// The account has no quota capability.
+	assert.Check(t, snap.Quotas == nil)
+}
+
+func TestSnapshotApply(t *testing.T) {
+	var (
+		mboxSets  []map[string]interface{}
+		identSet  map[string]interface{}
+		vacation  map[string]interface{}
+		createdNo int
+	)
+	c, srv := newTestClient(t, func(name string, args map[string]interface{}) []testResponse {
+		switch name {
+		case "Mailbox/get":
+			return []testResponse{{name, map[string]interface{}{"state": "1", "list": []interface{}{
+				map[string]interface{}{"id": "T1", "name": "Posteingang", "role": "inbox"},
+				map[string]interface{}{"id": "T2", "name": "Archiv", "role": "archive", "parentId": "T1"},
+			}}}}
+		case "Mailbox/set":
+			mboxSets = append(mboxSets, args)
+			created := map[string]interface{}{}
+			notCreated := map[string]interface{}{}
+			create, _ := args["create"].(map[string]interface{})
+			for creationID, mbox := range create {
+				if mbox.(map[string]interface{})["name"] == "Bad" {
+					notCreated[creationID] = map[string]interface{}{"type": "invalidProperties"}
+					continue
+				}
+				createdNo++
+				created[creationID] = map[string]interface{}{"id": "N" + strconv.Itoa(createdNo)}
+			}
+			updated := map[string]interface{}{}
+			update, _ := args["update"].(map[string]interface{})
+			for id := range update {
+				updated[id] = nil
+			}
+			return []testResponse{{name, map[string]interface{}{
+				"newState": "2", "created": created, "notCreated": notCreated, "updated": updated,
+			}}}
+		case "Identity/get":
+			return []testResponse{{name, map[string]interface{}{"state": "1", "list": []interface{}{
+				map[string]interface{}{"id": "TI1", "name": "Old", "email": "joe@example.com"},
+			}}}}
+		case "Identity/set":
+			identSet = args
+			return []testResponse{{name, map[string]interface{}{
+				"newState": "2",
+				"created":  map[string]interface{}{"i0": map[string]interface{}{"id": "TI2"}},
+				"updated":  map[string]interface{}{"TI1": nil},
+			}}}
+		case "VacationResponse/set":
+			vacation = args
+			return []testResponse{{name, map[string]interface{}{
+				"newState":   "2",
+				"notUpdated": map[string]interface{}{"singleton": map[string]interface{}{"type": "forbidden"}},
+			}}}
+		}
+		t.Fatalf("unexpected call: %s", name)
+		return nil
+	})
+	defer srv.Close()
+
+	snap := &AccountSnapshot{
+		Version:   SnapshotVersion,
+		AccountID: "S",
+		Mailboxes: []Mailbox{
+			{ID: "M1", Name: "Inbox", Role: RoleInbox, SortOrder: 1},
+			{ID: "M2", Name: "Lists", ParentID: "M1"},
+			{ID: "M3", Name: "Archive", Role: RoleArchive},
+			{ID: "M4", Name: "Bad"},
+			{ID: "M5", Name: "Child", ParentID: "M4"},
+		},
+		Identities: []Identity{
+			{ID: "SI1", Name: "Joe", Email: "joe@example.com"},
+			{ID: "SI2", Name: "Jane", Email: "jane@example.com"},
+		},
+		VacationResponse: &VacationResponse{ID: VacationResponseID, IsEnabled: true, Subject: "Away"},
+	}
+
+	res, err := snap.Apply(c, "A1")
+	assert.NilError(t, err)
+
+	assert.Check(t, cmp.DeepEqual(map[jmap.ID]jmap.ID{"M1": "T1", "M2": "N1", "M3": "T2"}, res.Mailboxes))
+	assert.Assert(t, cmp.Len(mboxSets, 2))
+	assert.Check(t, cmp.DeepEqual(map[string]interface{}{
+		"T1": map[string]interface{}{"sortOrder": float64(1)},
+		// Matched by role, moved to the top level.
+		"T2": map[string]interface{}{"parentId": nil},
+	}, mboxSets[0]["update"]))
+	assert.Check(t, cmp.Equal("T1", mboxSets[1]["create"].(map[string]interface{})["m0"].(map[string]interface{})["parentId"]))
+
+	assert.Check(t, cmp.DeepEqual(map[jmap.ID]jmap.ID{"SI1": "TI1", "SI2": "TI2"}, res.Identities))
+	assert.Check(t, cmp.Equal("Joe", identSet["update"].(map[string]interface{})["TI1"].(map[string]interface{})["name"]))
+	assert.Check(t, cmp.Equal("jane@example.com", identSet["create"].(map[string]interface{})["i0"].(map[string]interface{})["email"]))
+
+	assert.Check(t, cmp.Equal("Away", vacation["update"].(map[string]interface{})["singleton"].(map[string]interface{})["subject"]))
+
+	assert.Check(t, cmp.Len(res.Failed, 3))
+	assert.Check(t, cmp.Equal(jmap.CodeInvalidProperties, res.Failed["Mailbox/M4"].Type))
+	// Children of Mailboxes that failed to be created are skipped.
+	assert.Check(t, cmp.DeepEqual([]string{"parentId"}, res.Failed["Mailbox/M5"].Properties))
+	assert.Check(t, cmp.Equal(jmap.CodeForbidden, res.Failed["VacationResponse"].Type))
+}
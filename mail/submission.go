@@ -0,0 +1,358 @@
+package mail
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/foxcpp/go-jmap"
+)
+
+// Values of EmailSubmission.UndoStatus.
+const (
+	// It may be possible to cancel this submission.
+	UndoPending = "pending"
+
+	// The message has been relayed to at least one recipient in a manner
+	// that cannot be recalled. It is no longer possible to cancel this
+	// submission.
+	UndoFinal = "final"
+
+	// The submission was canceled and will not be delivered to any
+	// recipient.
+	UndoCanceled = "canceled"
+)
+
+// Address is an SMTP address with optional parameters used in the
+// EmailSubmission envelope.
+type Address struct {
+	// The email address being represented by the object. This is a "Mailbox"
+	// as used in the Reverse-path or Forward-path of the MAIL FROM or RCPT TO
+	// command in RFC 5321.
+	Email string `json:"email"`
+
+	// Any parameters to send with the email address (either mail-parameter
+	// or rcpt-parameter as appropriate, as specified in RFC 5321). If
+	// supplied, each key in the object is a parameter name, and the value is
+	// either the parameter value (type String) or null if the parameter does
+	// not take a value.
+	Parameters map[string]interface{} `json:"parameters,omitempty"`
+}
+
+// Envelope contains information for use when sending via SMTP.
+type Envelope struct {
+	// The email address to use as the return address in the SMTP submission,
+	// plus any parameters to pass with the MAIL FROM address.
+	MailFrom Address `json:"mailFrom"`
+
+	// The email addresses to send the message to, and any RCPT TO parameters
+	// to pass with the recipient.
+	RcptTo []Address `json:"rcptTo"`
+}
+
+// Values of DeliveryStatus.Delivered.
+const (
+	// The message is in a local mail queue and the status will change once
+	// it exits the local mail queues.
+	DeliveryQueued = "queued"
+
+	// The message was successfully delivered to the mail store of the
+	// recipient.
+	DeliveryYes = "yes"
+
+	// Delivery to the recipient permanently failed.
+	DeliveryNo = "no"
+
+	// The final delivery status is unknown, (e.g., it was relayed to an
+	// external machine and no further information is available).
+	DeliveryUnknown = "unknown"
+)
+
+// DeliveryStatus represents the delivery status for a single recipient of
+// the EmailSubmission.
+type DeliveryStatus struct {
+	// The SMTP reply string returned for this recipient when the server last
+	// tried to relay the message, or in a later Delivery Status Notification
+	// (DSN).
+	SMTPReply string `json:"smtpReply"`
+
+	// Represents whether the message has been successfully delivered to the
+	// recipient. One of "queued", "yes", "no" or "unknown".
+	Delivered string `json:"delivered"`
+
+	// Represents whether the message has been displayed to the recipient.
+	// One of "unknown" or "yes".
+	Displayed string `json:"displayed"`
+}
+
+// EmailSubmission represents the submission of an Email for delivery to one
+// or more recipients.
+//
+// See RFC 8621, section 7 for details.
+type EmailSubmission struct {
+	// The id of the EmailSubmission.
+	ID jmap.ID `json:"id,omitempty"`
+
+	// The id of the Identity to associate with this submission.
+	IdentityID jmap.ID `json:"identityId,omitempty"`
+
+	// The id of the Email to send. The Email being sent does not have to be
+	// a draft.
+	EmailID jmap.ID `json:"emailId,omitempty"`
+
+	// The Thread id of the Email to send. Set by the server.
+	ThreadID jmap.ID `json:"threadId,omitempty"`
+
+	// Information for use when sending via SMTP. If nil when creating, the
+	// server will generate it from the headers of the Email.
+	Envelope *Envelope `json:"envelope,omitempty"`
+
+	// The date the submission was/will be released for delivery. Set by the
+	// server.
+	SendAt *jmap.UTCDate `json:"sendAt,omitempty"`
+
+	// This represents whether the submission may be canceled. One of
+	// UndoPending, UndoFinal, UndoCanceled.
+	UndoStatus string `json:"undoStatus,omitempty"`
+
+	// This represents the delivery status for each of the submission's
+	// recipients, if known. Keys are email addresses. Set by the server.
+	DeliveryStatus map[string]DeliveryStatus `json:"deliveryStatus,omitempty"`
+
+	// A list of blob ids for DSNs received for this submission, in order of
+	// receipt, oldest first. Set by the server.
+	DSNBlobIDs []jmap.ID `json:"dsnBlobIds,omitempty"`
+
+	// A list of blob ids for MDNs received for this submission, in order of
+	// receipt, oldest first. Set by the server.
+	MDNBlobIDs []jmap.ID `json:"mdnBlobIds,omitempty"`
+}
+
+// EmailSubmissionGetArgs contains arguments for EmailSubmission/get method
+// call.
+type EmailSubmissionGetArgs struct {
+	// The id of the account to use.
+	AccountID jmap.ID `json:"accountId"`
+
+	// The ids of the EmailSubmission objects to return. If nil, then all
+	// records are returned.
+	IDs []jmap.ID `json:"ids"`
+
+	// If not nil, only the properties listed in the array are returned for
+	// each EmailSubmission object.
+	Properties []string `json:"properties"`
+}
+
+// EmailSubmissionGetResponse contains results of EmailSubmission/get method
+// call.
+type EmailSubmissionGetResponse struct {
+	// The id of the account used for the call.
+	AccountID jmap.ID `json:"accountId"`
+
+	// A string representing the state on the server for all the data of this
+	// type in the account.
+	State string `json:"state"`
+
+	// An array of the EmailSubmission objects requested.
+	List []EmailSubmission `json:"list"`
+
+	// This array contains the ids passed to the method for records that do
+	// not exist.
+	NotFound []jmap.ID `json:"notFound"`
+}
+
+// EmailSubmissionSetArgs contains arguments for EmailSubmission/set method
+// call.
+type EmailSubmissionSetArgs struct {
+	// The id of the account to use.
+	AccountID jmap.ID `json:"accountId"`
+
+	// If not empty, the method will be aborted and a stateMismatch error
+	// returned if the current state does not match this string.
+	IfInState string `json:"ifInState,omitempty"`
+
+	// A map of creation id to EmailSubmission objects.
+	Create map[jmap.ID]EmailSubmission `json:"create,omitempty"`
+
+	// A map of id to a patch object to apply to the current EmailSubmission
+	// object with that id. Only undoStatus can be changed.
+	Update map[jmap.ID]jmap.PatchObject `json:"update,omitempty"`
+
+	// A list of ids for EmailSubmission objects to permanently delete.
+	Destroy []jmap.ID `json:"destroy,omitempty"`
+
+	// A map of EmailSubmission id to an object containing properties to
+	// update on the Email object referenced by the EmailSubmission if the
+	// create/update/destroy succeeds. For references to EmailSubmissions
+	// created in the same "/set" invocation, this is equivalent to a creation
+	// id reference, e.g. "#k1", hence string is used as a key type.
+	OnSuccessUpdateEmail map[string]jmap.PatchObject `json:"onSuccessUpdateEmail,omitempty"`
+
+	// A list of EmailSubmission ids (or creation id references) for which
+	// the Email with the corresponding emailId should be destroyed if the
+	// create/update/destroy succeeds.
+	OnSuccessDestroyEmail []string `json:"onSuccessDestroyEmail,omitempty"`
+}
+
+// EmailSubmissionSetResponse contains results of EmailSubmission/set method
+// call.
+type EmailSubmissionSetResponse struct {
+	// The id of the account used for the call.
+	AccountID jmap.ID `json:"accountId"`
+
+	// The state string that would have been returned by EmailSubmission/get
+	// before making the requested changes.
+	OldState string `json:"oldState"`
+
+	// The state string that will now be returned by EmailSubmission/get.
+	NewState string `json:"newState"`
+
+	// A map of the creation id to an object containing any properties of the
+	// created EmailSubmission object that were not sent by the client.
+	Created map[jmap.ID]EmailSubmission `json:"created"`
+
+	// The keys in this map are the ids of all EmailSubmissions that were
+	// successfully updated. The value is an EmailSubmission object
+	// containing any property that changed in a way not explicitly
+	// requested, or nil if none.
+	Updated map[jmap.ID]*EmailSubmission `json:"updated"`
+
+	// A list of EmailSubmission ids for records that were successfully
+	// destroyed.
+	Destroyed []jmap.ID `json:"destroyed"`
+
+	// A map of creation id to a SetError object for each record that failed
+	// to be created.
+	NotCreated map[jmap.ID]jmap.SetError `json:"notCreated"`
+
+	// A map of EmailSubmission id to a SetError object for each record that
+	// failed to be updated.
+	NotUpdated map[jmap.ID]jmap.SetError `json:"notUpdated"`
+
+	// A map of EmailSubmission id to a SetError object for each record that
+	// failed to be destroyed.
+	NotDestroyed map[jmap.ID]jmap.SetError `json:"notDestroyed"`
+}
+
+// EmailSubmissionChangesArgs contains arguments for EmailSubmission/changes
+// method call.
+type EmailSubmissionChangesArgs struct {
+	// The id of the account to use.
+	AccountID jmap.ID `json:"accountId"`
+
+	// The current state of the client, as returned by EmailSubmission/get.
+	SinceState string `json:"sinceState"`
+
+	// The maximum number of ids to return in the response. Zero means no
+	// limit.
+	MaxChanges jmap.UnsignedInt `json:"maxChanges,omitempty"`
+}
+
+// EmailSubmissionChangesResponse contains results of EmailSubmission/changes
+// method call.
+type EmailSubmissionChangesResponse struct {
+	// The id of the account used for the call.
+	AccountID jmap.ID `json:"accountId"`
+
+	// This is the sinceState argument echoed back.
+	OldState string `json:"oldState"`
+
+	// This is the state the client will be in after applying the set of
+	// changes to the old state.
+	NewState string `json:"newState"`
+
+	// If true, the client may call EmailSubmission/changes again with the
+	// NewState returned to get further updates.
+	HasMoreChanges bool `json:"hasMoreChanges"`
+
+	// An array of ids for records that have been created since the old
+	// state.
+	Created []jmap.ID `json:"created"`
+
+	// An array of ids for records that have been updated since the old
+	// state.
+	Updated []jmap.ID `json:"updated"`
+
+	// An array of ids for records that have been destroyed since the old
+	// state.
+	Destroyed []jmap.ID `json:"destroyed"`
+}
+
+func unmarshalEmailSubmissionGetResponse(args json.RawMessage) (interface{}, error) {
+	resp := EmailSubmissionGetResponse{}
+	err := json.Unmarshal(args, &resp)
+	return resp, err
+}
+
+func unmarshalEmailSubmissionChangesResponse(args json.RawMessage) (interface{}, error) {
+	resp := EmailSubmissionChangesResponse{}
+	err := json.Unmarshal(args, &resp)
+	return resp, err
+}
+
+func unmarshalEmailSubmissionSetResponse(args json.RawMessage) (interface{}, error) {
+	resp := EmailSubmissionSetResponse{}
+	err := json.Unmarshal(args, &resp)
+	return resp, err
+}
+
+// MAIL FROM parameters defined by the FUTURERELEASE SMTP extension (RFC
+// 4865). Parameter names are case-insensitive.
+const (
+	ParamHoldFor   = "HOLDFOR"
+	ParamHoldUntil = "HOLDUNTIL"
+)
+
+var (
+	ErrDelayedSendUnsupported = errors.New("jmap/mail: server does not support delayed sending")
+	ErrHoldTooLong            = errors.New("jmap/mail: requested hold exceeds server limits")
+)
+
+func (e *Envelope) setMailFromParam(name string, value interface{}) {
+	if e.MailFrom.Parameters == nil {
+		e.MailFrom.Parameters = make(map[string]interface{})
+	}
+	delete(e.MailFrom.Parameters, ParamHoldFor)
+	delete(e.MailFrom.Parameters, ParamHoldUntil)
+	e.MailFrom.Parameters[name] = value
+}
+
+// HoldFor requests the server to hold the message for the specified
+// duration (rounded down to seconds) before releasing it for delivery.
+//
+// It replaces any previously requested hold.
+func (e *Envelope) HoldFor(d time.Duration) {
+	e.setMailFromParam(ParamHoldFor, strconv.FormatInt(int64(d/time.Second), 10))
+}
+
+// HoldUntil requests the server to hold the message until the specified
+// time before releasing it for delivery.
+//
+// It replaces any previously requested hold.
+func (e *Envelope) HoldUntil(t time.Time) {
+	e.setMailFromParam(ParamHoldUntil, t.UTC().Format(time.RFC3339))
+}
+
+// CheckHold verifies that the server supports holding the message until
+// releaseAt, given the current time now.
+func CheckHold(subCap *jmap.SubmissionCapability, releaseAt, now time.Time) error {
+	delay := releaseAt.Sub(now)
+	if subCap.MaxDelayedSend == 0 {
+		return ErrDelayedSendUnsupported
+	}
+	if delay > time.Duration(subCap.MaxDelayedSend)*time.Second {
+		return ErrHoldTooLong
+	}
+	maxInterval, maxDate, ok := subCap.FutureRelease()
+	if !ok {
+		return nil
+	}
+	if maxInterval != 0 && delay > maxInterval {
+		return ErrHoldTooLong
+	}
+	if !maxDate.IsZero() && releaseAt.After(maxDate) {
+		return ErrHoldTooLong
+	}
+	return nil
+}
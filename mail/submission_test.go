@@ -0,0 +1,39 @@
+package mail
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/foxcpp/go-jmap"
+	"gotest.tools/assert"
+	"gotest.tools/assert/cmp"
+)
+
+func TestEnvelopeHold(t *testing.T) {
+	env := Envelope{MailFrom: Address{Email: "joe@example.com"}}
+	env.HoldFor(90 * time.Minute)
+	blob, err := json.Marshal(env.MailFrom)
+	assert.NilError(t, err)
+	assert.Check(t, cmp.Equal(`{"email":"joe@example.com","parameters":{"HOLDFOR":"5400"}}`, string(blob)))
+
+	env.HoldUntil(time.Date(2020, 1, 2, 3, 4, 5, 0, time.FixedZone("", 3600)))
+	assert.Check(t, cmp.DeepEqual(map[string]interface{}{
+		"HOLDUNTIL": "2020-01-02T02:04:05Z",
+	}, env.MailFrom.Parameters))
+}
+
+func TestCheckHold(t *testing.T) {
+	now := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	subCap := &jmap.SubmissionCapability{
+		MaxDelayedSend: 86400 * 7,
+		SubmissionExtensions: map[string][]string{
+			"FUTURERELEASE": {"172800", "2019-06-01T00:00:00Z"},
+		},
+	}
+
+	assert.NilError(t, CheckHold(subCap, now.Add(time.Hour), now))
+	assert.Check(t, cmp.Equal(ErrHoldTooLong, CheckHold(subCap, now.Add(72*time.Hour), now)))
+	assert.Check(t, cmp.Equal(ErrHoldTooLong, CheckHold(subCap, now.Add(10*24*time.Hour), now)))
+	assert.Check(t, cmp.Equal(ErrDelayedSendUnsupported, CheckHold(&jmap.SubmissionCapability{}, now.Add(time.Hour), now)))
+}
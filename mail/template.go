@@ -0,0 +1,188 @@
+package mail
+
+import (
+	"github.com/foxcpp/go-jmap"
+	"github.com/foxcpp/go-jmap/client"
+)
+
+// Batch templates below add calls for common client flows to a Batch, using
+// result references so that each flow takes a single round-trip. They can
+// be combined in a single Batch and also serve as examples of correct
+// back-reference usage.
+//
+// Each template accepts the Batch to add calls to (nil means a new one is
+// created) and returns it along with call ids to look up responses by.
+
+// InboxOverviewCalls contains call ids added by InboxOverviewBatch.
+type InboxOverviewCalls struct {
+	MailboxGet string
+	EmailQuery string
+	EmailGet   string
+}
+
+// InboxOverviewBatch adds calls that fetch all Mailboxes of the account,
+// query the first page of Emails in the inbox Mailbox (newest first) and
+// fetch the listed properties of them.
+//
+// Responses are MailboxGetResponse, EmailQueryResponse and
+// EmailGetResponse, respectively.
+func InboxOverviewBatch(b *client.Batch, account, inbox jmap.ID, pageSize jmap.UnsignedInt, properties []string) (*client.Batch, InboxOverviewCalls) {
+	if b == nil {
+		b = &client.Batch{}
+	}
+	useMail(b)
+
+	var calls InboxOverviewCalls
+	calls.MailboxGet = b.NextCallID()
+	b.Add("Mailbox/get", MailboxGetArgs{AccountID: account})
+
+	calls.EmailQuery = b.NextCallID()
+	b.Add("Email/query", EmailQueryArgs{
+		AccountID: account,
+		Filter:    &EmailFilterCondition{InMailbox: inbox},
+		Sort: []EmailComparator{
+			{Comparator: jmap.Desc(SortReceivedAt)},
+		},
+		Limit: pageSize,
+	})
+
+	calls.EmailGet = b.NextCallID()
+	b.Add("Email/get", map[string]interface{}{
+		"accountId": account,
+		"#ids": jmap.ResultReference{
+			ResultOf: calls.EmailQuery,
+			Name:     "Email/query",
+			Path:     "/ids",
+		},
+		"properties": properties,
+	})
+	return b, calls
+}
+
+// ThreadViewCalls contains call ids added by ThreadViewBatch.
+type ThreadViewCalls struct {
+	EmailGet  string
+	ThreadGet string
+
+	// Email/get call returning all Emails of the Thread.
+	ThreadEmailsGet string
+}
+
+// ThreadViewBatch adds calls that fetch the Thread the Email belongs to
+// and all Emails in it with the listed properties, including text values
+// of body parts.
+//
+// Responses are EmailGetResponse (with only threadId of the Email),
+// ThreadGetResponse and EmailGetResponse, respectively.
+func ThreadViewBatch(b *client.Batch, account, email jmap.ID, properties []string) (*client.Batch, ThreadViewCalls) {
+	if b == nil {
+		b = &client.Batch{}
+	}
+	useMail(b)
+
+	var calls ThreadViewCalls
+	calls.EmailGet = b.NextCallID()
+	b.Add("Email/get", EmailGetArgs{
+		AccountID:  account,
+		IDs:        []jmap.ID{email},
+		Properties: []string{"threadId"},
+	})
+
+	calls.ThreadGet = b.NextCallID()
+	b.Add("Thread/get", map[string]interface{}{
+		"accountId": account,
+		"#ids": jmap.ResultReference{
+			ResultOf: calls.EmailGet,
+			Name:     "Email/get",
+			Path:     "/list/*/threadId",
+		},
+	})
+
+	calls.ThreadEmailsGet = b.NextCallID()
+	b.Add("Email/get", map[string]interface{}{
+		"accountId": account,
+		"#ids": jmap.ResultReference{
+			ResultOf: calls.ThreadGet,
+			Name:     "Thread/get",
+			Path:     "/list/*/emailIds",
+		},
+		"properties":          properties,
+		"fetchTextBodyValues": true,
+	})
+	return b, calls
+}
+
+// SendDraftCalls contains call ids and creation ids used by SendDraftBatch.
+type SendDraftCalls struct {
+	EmailSet      string
+	SubmissionSet string
+
+	// Creation id of the Email in the Email/set call.
+	DraftCreationID jmap.ID
+
+	// Creation id of the EmailSubmission in the EmailSubmission/set call.
+	SubmissionCreationID jmap.ID
+}
+
+// SendDraftBatch adds calls that store the draft and submit it for delivery
+// using the specified Identity and envelope (nil means the server constructs
+// it from the message headers).
+//
+// If onSuccess is not nil, it is applied to the Email once the submission
+// succeeds (e.g. to move it from Drafts to Sent).
+//
+// Responses are EmailSetResponse with the Email under
+// SendDraftCalls.DraftCreationID and EmailSubmissionSetResponse with the
+// submission under SendDraftCalls.SubmissionCreationID. If onSuccess is
+// used, the second call also produces the implicit EmailSetResponse.
+//
+// Creation ids are derived from call ids, so SendDraftBatch can be used
+// multiple times with the same Batch.
+func SendDraftBatch(b *client.Batch, account, identity jmap.ID, draft Email, envelope *Envelope, onSuccess jmap.PatchObject) (*client.Batch, SendDraftCalls) {
+	if b == nil {
+		b = &client.Batch{}
+	}
+	for _, capability := range submissionUsing {
+		b.Use(capability)
+	}
+
+	var calls SendDraftCalls
+	calls.EmailSet = b.NextCallID()
+	calls.DraftCreationID = jmap.ID("draft" + calls.EmailSet)
+	b.Add("Email/set", EmailSetArgs{
+		AccountID: account,
+		Create:    map[jmap.ID]Email{calls.DraftCreationID: draft},
+	})
+	calls.SubmissionSet = b.NextCallID()
+	calls.SubmissionCreationID = jmap.ID("send" + calls.SubmissionSet)
+
+	// emailId is a creation id reference which is not a valid jmap.ID, so
+	// EmailSubmission can't be used for the create object.
+	create := map[string]interface{}{
+		"identityId": identity,
+		"emailId":    "#" + string(calls.DraftCreationID),
+	}
+	if envelope != nil {
+		create["envelope"] = envelope
+	}
+	submission := map[string]interface{}{
+		"accountId": account,
+		"create": map[string]interface{}{
+			string(calls.SubmissionCreationID): create,
+		},
+	}
+	if onSuccess != nil {
+		submission["onSuccessUpdateEmail"] = map[string]jmap.PatchObject{
+			"#" + string(calls.SubmissionCreationID): onSuccess,
+		}
+	}
+
+	b.Add("EmailSubmission/set", submission)
+	return b, calls
+}
+
+func useMail(b *client.Batch) {
+	for _, capability := range mailUsing {
+		b.Use(capability)
+	}
+}
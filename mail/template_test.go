@@ -0,0 +1,78 @@
+package mail
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/foxcpp/go-jmap"
+	"gotest.tools/assert"
+	"gotest.tools/assert/cmp"
+)
+
+func TestBatchTemplates(t *testing.T) {
+	b, overview := InboxOverviewBatch(nil, "A1", "INBOX", 20, []string{"subject"})
+	_, thread := ThreadViewBatch(b, "A1", "E1", []string{"subject", "bodyValues"})
+
+	assert.Check(t, cmp.DeepEqual(InboxOverviewCalls{MailboxGet: "0", EmailQuery: "1", EmailGet: "2"}, overview))
+	assert.Check(t, cmp.DeepEqual(ThreadViewCalls{EmailGet: "3", ThreadGet: "4", ThreadEmailsGet: "5"}, thread))
+
+	blob, err := json.Marshal(b.Request())
+	assert.NilError(t, err)
+	var req struct {
+		Using       []string
+		MethodCalls [][]json.RawMessage
+	}
+	assert.NilError(t, json.Unmarshal(blob, &req))
+	assert.Check(t, cmp.DeepEqual([]string{jmap.CoreCapabilityName, jmap.MailCapabilityName}, req.Using))
+	assert.Assert(t, cmp.Len(req.MethodCalls, 6))
+
+	args := func(i int) map[string]interface{} {
+		var args map[string]interface{}
+		assert.NilError(t, json.Unmarshal(req.MethodCalls[i][1], &args))
+		return args
+	}
+	assert.Check(t, cmp.DeepEqual(map[string]interface{}{"inMailbox": "INBOX"}, args(1)["filter"]))
+	assert.Check(t, cmp.DeepEqual(map[string]interface{}{
+		"resultOf": "1", "name": "Email/query", "path": "/ids",
+	}, args(2)["#ids"]))
+	assert.Check(t, cmp.DeepEqual(map[string]interface{}{
+		"resultOf": "3", "name": "Email/get", "path": "/list/*/threadId",
+	}, args(4)["#ids"]))
+	assert.Check(t, cmp.DeepEqual(map[string]interface{}{
+		"resultOf": "4", "name": "Thread/get", "path": "/list/*/emailIds",
+	}, args(5)["#ids"]))
+	assert.Check(t, cmp.Equal(true, args(5)["fetchTextBodyValues"]))
+
+	// Calls linked by references must stay together.
+	reqs, _, err := b.Split(3, 0, false)
+	assert.NilError(t, err)
+	assert.Check(t, cmp.Len(reqs, 2))
+}
+
+func TestSendDraftBatchTwice(t *testing.T) {
+	b, first := SendDraftBatch(nil, "A1", "I1", Email{Subject: "First"}, nil, nil)
+	_, second := SendDraftBatch(b, "A1", "I1", Email{Subject: "Second"}, nil, nil)
+
+	assert.Check(t, cmp.DeepEqual(SendDraftCalls{
+		EmailSet: "0", SubmissionSet: "1",
+		DraftCreationID: "draft0", SubmissionCreationID: "send1",
+	}, first))
+	assert.Check(t, cmp.DeepEqual(SendDraftCalls{
+		EmailSet: "2", SubmissionSet: "3",
+		DraftCreationID: "draft2", SubmissionCreationID: "send3",
+	}, second))
+
+	blob, err := json.Marshal(b.Request())
+	assert.NilError(t, err)
+	var req struct {
+		MethodCalls [][]json.RawMessage
+	}
+	assert.NilError(t, json.Unmarshal(blob, &req))
+	assert.Assert(t, cmp.Len(req.MethodCalls, 4))
+
+	var args struct {
+		Create map[string]map[string]interface{}
+	}
+	assert.NilError(t, json.Unmarshal(req.MethodCalls[3][1], &args))
+	assert.Check(t, cmp.Equal("#draft2", args.Create["send3"]["emailId"]))
+}
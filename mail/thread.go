@@ -0,0 +1,55 @@
+package mail
+
+import (
+	"encoding/json"
+
+	"github.com/foxcpp/go-jmap"
+)
+
+// Thread object groups related Emails together (a conversation).
+//
+// See RFC 8621, section 3 for details.
+type Thread struct {
+	// The id of the Thread.
+	ID jmap.ID `json:"id"`
+
+	// The ids of the Emails in the Thread, sorted by the receivedAt date of
+	// the Email, oldest first.
+	EmailIDs []jmap.ID `json:"emailIds"`
+}
+
+// ThreadGetArgs contains arguments for Thread/get method call.
+type ThreadGetArgs struct {
+	// The id of the account to use.
+	AccountID jmap.ID `json:"accountId"`
+
+	// The ids of the Thread objects to return.
+	IDs []jmap.ID `json:"ids"`
+
+	// If not nil, only the properties listed in the array are returned for
+	// each Thread object.
+	Properties []string `json:"properties"`
+}
+
+// ThreadGetResponse contains results of Thread/get method call.
+type ThreadGetResponse struct {
+	// The id of the account used for the call.
+	AccountID jmap.ID `json:"accountId"`
+
+	// A string representing the state on the server for all the data of this
+	// type in the account.
+	State string `json:"state"`
+
+	// An array of the Thread objects requested.
+	List []Thread `json:"list"`
+
+	// This array contains the ids passed to the method for records that do
+	// not exist.
+	NotFound []jmap.ID `json:"notFound"`
+}
+
+func unmarshalThreadGetResponse(args json.RawMessage) (interface{}, error) {
+	resp := ThreadGetResponse{}
+	err := json.Unmarshal(args, &resp)
+	return resp, err
+}
@@ -0,0 +1,189 @@
+package mail
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/foxcpp/go-jmap"
+	"github.com/foxcpp/go-jmap/client"
+)
+
+// CodeCannotUnsend is the SetError type returned for EmailSubmission/set
+// update if the submission can no longer be canceled.
+const CodeCannotUnsend jmap.ErrorCode = "cannotUnsend"
+
+var (
+	ErrCannotUnsend       = errors.New("jmap/mail: submission was already released for delivery")
+	ErrSubmissionNotFound = errors.New("jmap/mail: submission does not exist")
+	ErrNoEnvelope         = errors.New("jmap/mail: draft has no sender or recipients to construct the envelope from")
+)
+
+// CancelError is returned by CancelSubmission if the submission was not
+// canceled.
+type CancelError struct {
+	// The id of the EmailSubmission.
+	Submission jmap.ID
+
+	// ErrCannotUnsend, ErrSubmissionNotFound or jmap.SetError returned by
+	// the server for other failures.
+	Err error
+}
+
+func (ce *CancelError) Error() string {
+	return fmt.Sprintf("jmap/mail: cannot cancel submission %s: %v", ce.Submission, ce.Err)
+}
+
+func (ce *CancelError) Unwrap() error {
+	return ce.Err
+}
+
+// SendWithUndo is similar to Send, but asks the server to hold the message
+// for the window duration so the submission can be canceled using
+// CancelSubmission before it is released.
+//
+// ErrDelayedSendUnsupported or ErrHoldTooLong is returned if the server
+// can't hold the message for that long.
+func SendWithUndo(c *client.Client, account jmap.ID, draft Email, window time.Duration) (*SendResult, error) {
+	return SendWithOptions(c, account, draft, nil, SendOptions{Hold: window})
+}
+
+// draftEnvelope constructs the envelope from the message headers the same
+// way the server does it for submissions without an envelope.
+func draftEnvelope(draft Email) (*Envelope, error) {
+	env := &Envelope{}
+	switch {
+	case len(draft.Sender) != 0:
+		env.MailFrom.Email = draft.Sender[0].Email
+	case len(draft.From) != 0:
+		env.MailFrom.Email = draft.From[0].Email
+	default:
+		return nil, ErrNoEnvelope
+	}
+
+	seen := make(map[string]bool)
+	for _, list := range [][]EmailAddress{draft.To, draft.CC, draft.BCC} {
+		for _, addr := range list {
+			if addr.Email == "" || seen[addr.Email] {
+				continue
+			}
+			seen[addr.Email] = true
+			env.RcptTo = append(env.RcptTo, Address{Email: addr.Email})
+		}
+	}
+	if len(env.RcptTo) == 0 {
+		return nil, ErrNoEnvelope
+	}
+	return env, nil
+}
+
+// holdEnvelope returns a copy of envelope (or the envelope constructed from
+// the draft headers if it is nil) with the hold parameter set, after
+// checking that the server supports holding the message for that long as of
+// now.
+func holdEnvelope(session *jmap.Session, account jmap.ID, draft Email, envelope *Envelope, hold time.Duration, now time.Time) (*Envelope, error) {
+	acc, ok := session.Accounts[account]
+	if !ok {
+		return nil, fmt.Errorf("jmap/mail: unknown account %s", account)
+	}
+	subCap, err := acc.SubmissionCapability()
+	if err != nil {
+		return nil, err
+	}
+	if err := CheckHold(subCap, now.Add(hold), now); err != nil {
+		return nil, err
+	}
+
+	var env Envelope
+	if envelope == nil {
+		built, err := draftEnvelope(draft)
+		if err != nil {
+			return nil, err
+		}
+		env = *built
+	} else {
+		env = *envelope
+		params := make(map[string]interface{}, len(env.MailFrom.Parameters)+1)
+		for k, v := range env.MailFrom.Parameters {
+			params[k] = v
+		}
+		env.MailFrom.Parameters = params
+	}
+	env.HoldFor(hold)
+	return &env, nil
+}
+
+// CancelSubmission cancels delivery of the held submission and returns the
+// Email to the Drafts Mailbox, undoing the effects of SendWithUndo.
+//
+// Delivery is canceled by setting undoStatus to "canceled", destroying the
+// EmailSubmission would only remove the record without affecting delivery
+// (RFC 8621, section 7.5).
+//
+// If the account has no Mailbox with the "drafts" role, mailboxIds of the
+// Email are left unchanged (only the $draft keyword is set again) since
+// removing it from the Sent Mailbox could leave it in no Mailbox at all.
+//
+// If the submission was not canceled, *CancelError is returned.
+//
+// The client must have ResponseUnmarshallers enabled.
+func CancelSubmission(c *client.Client, account, submission jmap.ID) error {
+	mboxes, err := getMailboxes(c, MailboxGetArgs{
+		AccountID:  account,
+		Properties: []string{"id", "role"},
+	})
+	if err != nil {
+		return err
+	}
+	restore := jmap.PatchObject{
+		keywordPath(KeywordDraft): true,
+	}
+	var drafts, sent jmap.ID
+	for _, mbox := range mboxes.List {
+		switch mbox.Role {
+		case RoleDrafts:
+			drafts = mbox.ID
+		case RoleSent:
+			sent = mbox.ID
+		}
+	}
+	if drafts != "" {
+		restore[mailboxPath(drafts)] = true
+		if sent != "" {
+			restore[mailboxPath(sent)] = nil
+		}
+	}
+
+	args := EmailSubmissionSetArgs{
+		AccountID: account,
+		Update: map[jmap.ID]jmap.PatchObject{
+			submission: {"undoStatus": UndoCanceled},
+		},
+		OnSuccessUpdateEmail: map[string]jmap.PatchObject{
+			string(submission): restore,
+		},
+	}
+	respArgs, err := c.Call(submissionUsing, "EmailSubmission/set", args)
+	if err != nil {
+		return err
+	}
+	resp, ok := respArgs.(EmailSubmissionSetResponse)
+	if !ok {
+		return unexpectedResponse("EmailSubmission/set", respArgs)
+	}
+
+	if setErr, ok := resp.NotUpdated[submission]; ok {
+		cancelErr := &CancelError{Submission: submission, Err: setErr}
+		switch setErr.Type {
+		case CodeCannotUnsend:
+			cancelErr.Err = ErrCannotUnsend
+		case jmap.CodeNotFound:
+			cancelErr.Err = ErrSubmissionNotFound
+		}
+		return cancelErr
+	}
+	if _, ok := resp.Updated[submission]; !ok {
+		return fmt.Errorf("jmap/mail: submission %s is neither updated nor rejected", submission)
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package mail
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/foxcpp/go-jmap"
+	"github.com/foxcpp/go-jmap/client"
+	"gotest.tools/assert"
+	"gotest.tools/assert/cmp"
+)
+
+func TestSendWithUndo(t *testing.T) {
+	var envelope map[string]interface{}
+	c, srv := newTestClient(t, func(name string, args map[string]interface{}) []testResponse {
+		switch name {
+		case "Identity/get":
+			return []testResponse{{name, map[string]interface{}{
+				"list": []interface{}{map[string]interface{}{"id": "I1", "email": "joe@example.com"}},
+			}}}
+		case "Mailbox/get":
+			return []testResponse{{name, map[string]interface{}{
+				"list": []interface{}{map[string]interface{}{"id": "D", "role": "drafts"}},
+			}}}
+		case "Email/set":
+			return []testResponse{{name, map[string]interface{}{
+				"created": map[string]interface{}{"draft0": map[string]interface{}{"id": "E1"}},
+			}}}
+		case "EmailSubmission/set":
+			envelope = args["create"].(map[string]interface{})["send1"].(map[string]interface{})["envelope"].(map[string]interface{})
+			return []testResponse{{name, map[string]interface{}{
+				"created": map[string]interface{}{"send1": map[string]interface{}{
+					"id": "S1", "sendAt": "2020-01-01T00:00:30Z",
+				}},
+			}}}
+		}
+		t.Fatalf("unexpected call: %s", name)
+		return nil
+	})
+	defer srv.Close()
+
+	draft, err := NewEmailBuilder().
+		From(EmailAddress{Email: "joe@example.com"}).
+		To(EmailAddress{Email: "jane@example.com"}).
+		CC(EmailAddress{Email: "jane@example.com"}, EmailAddress{Email: "bob@example.com"}).
+		TextBody("Hello").
+		Build()
+	assert.NilError(t, err)
+
+	res, err := SendWithUndo(c, "A1", draft, 30*time.Second)
+	assert.NilError(t, err)
+	assert.Check(t, cmp.Equal(jmap.ID("S1"), res.SubmissionID))
+	assert.Assert(t, res.SendAt != nil)
+	assert.Check(t, time.Time(*res.SendAt).Equal(time.Date(2020, 1, 1, 0, 0, 30, 0, time.UTC)))
+	assert.Check(t, cmp.DeepEqual(map[string]interface{}{
+		"mailFrom": map[string]interface{}{
+			"email":      "joe@example.com",
+			"parameters": map[string]interface{}{"HOLDFOR": "30"},
+		},
+		"rcptTo": []interface{}{
+			map[string]interface{}{"email": "jane@example.com"},
+			map[string]interface{}{"email": "bob@example.com"},
+		},
+	}, envelope))
+
+	t.Run("too long", func(t *testing.T) {
+		_, err := SendWithUndo(c, "A1", draft, 2*time.Hour)
+		assert.Equal(t, ErrHoldTooLong, err)
+	})
+}
+
+func TestCancelSubmission(t *testing.T) {
+	var update, onSuccess map[string]interface{}
+	notUpdated := map[string]interface{}{}
+	mailboxes := []interface{}{
+		map[string]interface{}{"id": "D", "role": "drafts"},
+		map[string]interface{}{"id": "S", "role": "sent"},
+	}
+	c, srv := newTestClient(t, func(name string, args map[string]interface{}) []testResponse {
+		switch name {
+		case "Mailbox/get":
+			return []testResponse{{name, map[string]interface{}{"list": mailboxes}}}
+		case "EmailSubmission/set":
+			update = args["update"].(map[string]interface{})
+			onSuccess = args["onSuccessUpdateEmail"].(map[string]interface{})
+			if len(notUpdated) != 0 {
+				return []testResponse{{name, map[string]interface{}{"notUpdated": notUpdated}}}
+			}
+			return []testResponse{{name, map[string]interface{}{"updated": map[string]interface{}{"S1": nil}}}}
+		}
+		t.Fatalf("unexpected call: %s", name)
+		return nil
+	})
+	defer srv.Close()
+
+	assert.NilError(t, CancelSubmission(c, "A1", "S1"))
+	assert.Check(t, cmp.DeepEqual(map[string]interface{}{
+		"S1": map[string]interface{}{"undoStatus": "canceled"},
+	}, update))
+	assert.Check(t, cmp.DeepEqual(map[string]interface{}{
+		"S1": map[string]interface{}{
+			"keywords/$draft": true,
+			"mailboxIds/D":    true,
+			"mailboxIds/S":    nil,
+		},
+	}, onSuccess))
+
+	// The Email is not removed from Sent if there is no Drafts Mailbox.
+	mailboxes = mailboxes[1:]
+	assert.NilError(t, CancelSubmission(c, "A1", "S1"))
+	assert.Check(t, cmp.DeepEqual(map[string]interface{}{
+		"S1": map[string]interface{}{"keywords/$draft": true},
+	}, onSuccess))
+
+	for _, tc := range []struct {
+		typ string
+		err error
+	}{
+		{"cannotUnsend", ErrCannotUnsend},
+		{"notFound", ErrSubmissionNotFound},
+	} {
+		notUpdated["S1"] = map[string]interface{}{"type": tc.typ}
+		err := CancelSubmission(c, "A1", "S1")
+		cancelErr, ok := err.(*CancelError)
+		assert.Assert(t, ok, "%T", err)
+		assert.Check(t, tc.err == cancelErr.Err, "%s: %v", tc.typ, cancelErr.Err)
+		assert.Check(t, errors.Is(err, tc.err), "%s", tc.typ)
+	}
+
+	notUpdated["S1"] = map[string]interface{}{"type": "forbidden"}
+	err := CancelSubmission(c, "A1", "S1")
+	cancelErr, ok := err.(*CancelError)
+	assert.Assert(t, ok, "%T", err)
+	setErr, ok := cancelErr.Err.(jmap.SetError)
+	assert.Assert(t, ok, "%T", cancelErr.Err)
+	assert.Check(t, cmp.Equal(jmap.ErrorCode("forbidden"), setErr.Type))
+	assert.Check(t, cmp.Equal(client.CategoryAuth, client.Classify(err).Category))
+}
@@ -0,0 +1,151 @@
+package mail
+
+import (
+	"encoding/json"
+
+	"github.com/foxcpp/go-jmap"
+)
+
+const VacationResponseCapabilityName = "urn:ietf:params:jmap:vacationresponse"
+
+// VacationResponseID is the id of the only VacationResponse object in an
+// account.
+const VacationResponseID jmap.ID = "singleton"
+
+// VacationResponse object represents vacation-response (out-of-office)
+// settings for an account.
+//
+// There is only ever one VacationResponse object, with id equal to
+// VacationResponseID, in an account. It can't be created or destroyed.
+//
+// See RFC 8621, section 8 for details.
+type VacationResponse struct {
+	// The id of the object. It is always VacationResponseID.
+	ID jmap.ID `json:"id,omitempty"`
+
+	// Should a vacation response be sent if a message arrives between the
+	// FromDate and ToDate?
+	IsEnabled bool `json:"isEnabled"`
+
+	// If IsEnabled is true, messages that arrive on or after this date-time
+	// (but before the ToDate if defined) should receive the user's vacation
+	// response. If nil, the vacation response is effective immediately.
+	FromDate *jmap.UTCDate `json:"fromDate"`
+
+	// If IsEnabled is true, messages that arrive before this date-time (but
+	// on or after the FromDate if defined) should receive the user's vacation
+	// response. If nil, the vacation response is effective indefinitely.
+	ToDate *jmap.UTCDate `json:"toDate"`
+
+	// The subject that will be used by the message sent in response to
+	// messages when the vacation response is enabled. If empty, an
+	// appropriate subject SHOULD be set by the server.
+	Subject string `json:"subject,omitempty"`
+
+	// The plaintext body to send in response to messages when the vacation
+	// response is enabled.
+	TextBody string `json:"textBody,omitempty"`
+
+	// The HTML body to send in response to messages when the vacation
+	// response is enabled.
+	HTMLBody string `json:"htmlBody,omitempty"`
+}
+
+// VacationResponseGetArgs contains arguments for VacationResponse/get method
+// call.
+type VacationResponseGetArgs struct {
+	// The id of the account to use.
+	AccountID jmap.ID `json:"accountId"`
+
+	// The ids of the objects to return. Nil is the same as requesting
+	// VacationResponseID.
+	IDs []jmap.ID `json:"ids"`
+
+	// If not nil, only the properties listed in the array are returned.
+	Properties []string `json:"properties"`
+}
+
+// VacationResponseGetResponse contains results of VacationResponse/get
+// method call.
+type VacationResponseGetResponse struct {
+	// The id of the account used for the call.
+	AccountID jmap.ID `json:"accountId"`
+
+	// A string representing the state on the server for all the data of this
+	// type in the account.
+	State string `json:"state"`
+
+	// An array of the VacationResponse objects requested.
+	List []VacationResponse `json:"list"`
+
+	// This array contains the ids passed to the method for records that do
+	// not exist.
+	NotFound []jmap.ID `json:"notFound"`
+}
+
+// VacationResponseSetArgs contains arguments for VacationResponse/set method
+// call.
+//
+// Only updates are allowed for VacationResponse, use NewVacationResponseSet to
+// construct the arguments.
+type VacationResponseSetArgs struct {
+	// The id of the account to use.
+	AccountID jmap.ID `json:"accountId"`
+
+	// If not empty, the method will be aborted and a stateMismatch error
+	// returned if the current state does not match this string.
+	IfInState string `json:"ifInState,omitempty"`
+
+	// A map of id to a patch object. The only valid key is
+	// VacationResponseID.
+	Update map[jmap.ID]jmap.PatchObject `json:"update"`
+}
+
+// NewVacationResponseSet returns VacationResponseSetArgs that apply the patch
+// to the VacationResponse object of the account.
+func NewVacationResponseSet(account jmap.ID, patch jmap.PatchObject) VacationResponseSetArgs {
+	return VacationResponseSetArgs{
+		AccountID: account,
+		Update:    map[jmap.ID]jmap.PatchObject{VacationResponseID: patch},
+	}
+}
+
+// VacationResponseSetResponse contains results of VacationResponse/set method
+// call.
+type VacationResponseSetResponse struct {
+	// The id of the account used for the call.
+	AccountID jmap.ID `json:"accountId"`
+
+	// The state string that would have been returned by VacationResponse/get
+	// before making the requested changes.
+	OldState string `json:"oldState"`
+
+	// The state string that will now be returned by VacationResponse/get.
+	NewState string `json:"newState"`
+
+	// The keys in this map are the ids of all objects that were successfully
+	// updated. The value is an object containing any property that changed
+	// in a way not explicitly requested, or nil if none.
+	Updated map[jmap.ID]*VacationResponse `json:"updated"`
+
+	// A map of id to a SetError object for each object that failed to be
+	// updated.
+	NotUpdated map[jmap.ID]jmap.SetError `json:"notUpdated"`
+
+	// Attempts to create or destroy the object are rejected with the
+	// singleton error and reported here.
+	NotCreated   map[jmap.ID]jmap.SetError `json:"notCreated"`
+	NotDestroyed map[jmap.ID]jmap.SetError `json:"notDestroyed"`
+}
+
+func unmarshalVacationResponseGetResponse(args json.RawMessage) (interface{}, error) {
+	resp := VacationResponseGetResponse{}
+	err := json.Unmarshal(args, &resp)
+	return resp, err
+}
+
+func unmarshalVacationResponseSetResponse(args json.RawMessage) (interface{}, error) {
+	resp := VacationResponseSetResponse{}
+	err := json.Unmarshal(args, &resp)
+	return resp, err
+}
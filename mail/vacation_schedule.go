@@ -0,0 +1,132 @@
+package mail
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/foxcpp/go-jmap"
+	"github.com/foxcpp/go-jmap/client"
+)
+
+var (
+	ErrNoVacationResponse = errors.New("jmap/mail: account does not support vacation responses")
+	ErrVacationDates      = errors.New("jmap/mail: vacation response toDate must be after fromDate and in the future")
+	ErrVacationNoBody     = errors.New("jmap/mail: vacation response has neither text nor HTML body")
+	ErrVacationTextIsHTML = errors.New("jmap/mail: vacation response text body contains HTML, use htmlBody")
+)
+
+// Validate checks that the enabled VacationResponse is consistent, given the
+// current time now:
+//   - ToDate, if set, must be after FromDate and now (ErrVacationDates),
+//   - TextBody or HTMLBody must be set (ErrVacationNoBody),
+//   - TextBody must not be an HTML document (ErrVacationTextIsHTML).
+//
+// Disabled VacationResponse is always valid.
+func (vr *VacationResponse) Validate(now time.Time) error {
+	if !vr.IsEnabled {
+		return nil
+	}
+	if vr.ToDate != nil {
+		to := time.Time(*vr.ToDate)
+		if !to.After(now) {
+			return ErrVacationDates
+		}
+		if vr.FromDate != nil && !to.After(time.Time(*vr.FromDate)) {
+			return ErrVacationDates
+		}
+	}
+	if vr.TextBody == "" && vr.HTMLBody == "" {
+		return ErrVacationNoBody
+	}
+	if looksLikeHTML(vr.TextBody) {
+		return ErrVacationTextIsHTML
+	}
+	return nil
+}
+
+func looksLikeHTML(s string) bool {
+	s = strings.ToLower(strings.TrimSpace(s))
+	return strings.HasPrefix(s, "<!doctype html") || strings.HasPrefix(s, "<html")
+}
+
+// EnableVacationResponse validates the VacationResponse (see Validate) and
+// replaces the account settings with it, enabling it regardless of
+// IsEnabled.
+//
+// ErrNoVacationResponse is returned without making any calls if the account
+// does not have the vacationresponse capability. If the server rejects the
+// update, the SetError is returned.
+//
+// The client must have ResponseUnmarshallers enabled.
+func EnableVacationResponse(c *client.Client, account jmap.ID, vr VacationResponse) error {
+	if err := checkVacationCapability(c, account); err != nil {
+		return err
+	}
+	vr.IsEnabled = true
+	if err := vr.Validate(client.ClockOrSystem(c.Clock).Now()); err != nil {
+		return err
+	}
+	return setVacationResponse(c, account, vacationPatch(vr))
+}
+
+// vacationPatch returns the patch replacing all VacationResponse settings
+// with vr.
+//
+// Empty Subject, TextBody and HTMLBody are sent as null, so the server uses
+// its default subject and generates the missing body from the other one
+// (RFC 8621, section 8) instead of sending an empty one.
+func vacationPatch(vr VacationResponse) jmap.PatchObject {
+	return jmap.PatchObject{
+		"isEnabled": vr.IsEnabled,
+		"fromDate":  vr.FromDate,
+		"toDate":    vr.ToDate,
+		"subject":   nullIfEmpty(vr.Subject),
+		"textBody":  nullIfEmpty(vr.TextBody),
+		"htmlBody":  nullIfEmpty(vr.HTMLBody),
+	}
+}
+
+func nullIfEmpty(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+// DisableVacationResponse disables the vacation response of the account,
+// keeping other settings intact.
+//
+// See EnableVacationResponse for returned errors.
+func DisableVacationResponse(c *client.Client, account jmap.ID) error {
+	if err := checkVacationCapability(c, account); err != nil {
+		return err
+	}
+	return setVacationResponse(c, account, jmap.PatchObject{"isEnabled": false})
+}
+
+func checkVacationCapability(c *client.Client, account jmap.ID) error {
+	session, err := c.CurrentSession()
+	if err != nil {
+		return err
+	}
+	if !hasCapability(session, account, VacationResponseCapabilityName) {
+		return ErrNoVacationResponse
+	}
+	return nil
+}
+
+func setVacationResponse(c *client.Client, account jmap.ID, patch jmap.PatchObject) error {
+	respArgs, err := c.Call(vacationUsing, "VacationResponse/set", NewVacationResponseSet(account, patch))
+	if err != nil {
+		return err
+	}
+	resp, ok := respArgs.(VacationResponseSetResponse)
+	if !ok {
+		return unexpectedResponse("VacationResponse/set", respArgs)
+	}
+	if setErr, ok := resp.NotUpdated[VacationResponseID]; ok {
+		return setErr
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package mail
+
+import (
+	"testing"
+	"time"
+
+	"github.com/foxcpp/go-jmap"
+	"github.com/foxcpp/go-jmap/jmaptest"
+	"gotest.tools/assert"
+	"gotest.tools/assert/cmp"
+)
+
+func TestVacationResponseValidate(t *testing.T) {
+	now := time.Date(2020, 1, 10, 0, 0, 0, 0, time.UTC)
+	date := func(day int) *jmap.UTCDate {
+		d := jmap.UTCDate(time.Date(2020, 1, day, 0, 0, 0, 0, time.UTC))
+		return &d
+	}
+
+	for _, tc := range []struct {
+		name string
+		vr   VacationResponse
+		err  error
+	}{
+		{"disabled", VacationResponse{ToDate: date(1)}, nil},
+		{"valid", VacationResponse{IsEnabled: true, FromDate: date(11), ToDate: date(20), TextBody: "Away"}, nil},
+		{"html only", VacationResponse{IsEnabled: true, HTMLBody: "<p>Away</p>"}, nil},
+		{"reversed", VacationResponse{IsEnabled: true, FromDate: date(20), ToDate: date(11), TextBody: "Away"}, ErrVacationDates},
+		{"past", VacationResponse{IsEnabled: true, ToDate: date(5), TextBody: "Away"}, ErrVacationDates},
+		{"no body", VacationResponse{IsEnabled: true}, ErrVacationNoBody},
+		{"html text", VacationResponse{IsEnabled: true, TextBody: " <!DOCTYPE html><p>Away</p>"}, ErrVacationTextIsHTML},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Check(t, cmp.Equal(tc.err, tc.vr.Validate(now)))
+		})
+	}
+}
+
+func TestEnableVacationResponse(t *testing.T) {
+	var patches []map[string]interface{}
+	c, srv := newTestClient(t, func(name string, args map[string]interface{}) []testResponse {
+		if name != "VacationResponse/set" {
+			t.Fatalf("unexpected call: %s", name)
+		}
+		patch := args["update"].(map[string]interface{})["singleton"].(map[string]interface{})
+		patches = append(patches, patch)
+		if patch["subject"] == "reject" {
+			return []testResponse{{name, map[string]interface{}{
+				"notUpdated": map[string]interface{}{"singleton": map[string]interface{}{"type": "invalidProperties"}},
+			}}}
+		}
+		return []testResponse{{name, map[string]interface{}{
+			"updated": map[string]interface{}{"singleton": nil},
+		}}}
+	})
+	defer srv.Close()
+	c.Clock = jmaptest.NewFakeClock(time.Date(2020, 1, 10, 0, 0, 0, 0, time.UTC))
+
+	to := jmap.UTCDate(time.Date(2020, 1, 20, 0, 0, 0, 0, time.UTC))
+	assert.NilError(t, EnableVacationResponse(c, "A1", VacationResponse{ToDate: &to, TextBody: "Away"}))
+	assert.NilError(t, DisableVacationResponse(c, "A1"))
+	assert.Check(t, cmp.DeepEqual([]map[string]interface{}{
+		{
+			"isEnabled": true,
+			"fromDate":  nil,
+			"toDate":    "2020-01-20T00:00:00Z",
+			"subject":   nil,
+			"textBody":  "Away",
+			"htmlBody":  nil,
+		},
+		{"isEnabled": false},
+	}, patches))
+
+	err := EnableVacationResponse(c, "A1", VacationResponse{Subject: "reject", TextBody: "Away"})
+	setErr, ok := err.(jmap.SetError)
+	assert.Assert(t, ok, "unexpected error: %v", err)
+	assert.Check(t, cmp.Equal(jmap.CodeInvalidProperties, setErr.Type))
+
+	// Validation and capability checks happen before any calls.
+	patches = nil
+	assert.Check(t, cmp.Equal(ErrVacationNoBody, EnableVacationResponse(c, "A1", VacationResponse{})))
+	assert.Check(t, cmp.Equal(ErrNoVacationResponse, DisableVacationResponse(c, "A2")))
+	assert.Check(t, cmp.Len(patches, 0))
+}
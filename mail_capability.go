@@ -0,0 +1,162 @@
+package jmap
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+)
+
+const MailCapabilityName = "urn:ietf:params:jmap:mail"
+
+var ErrNoMailCapability = errors.New("jmap: urn:ietf:params:jmap:mail capability is not supported for the account")
+
+// MailCapability is the urn:ietf:params:jmap:mail capability object, as
+// defined in RFC 8621.
+//
+// It is defined per-account, but some servers also expose it (possibly
+// partially) at the session level. Use Session.EffectiveMailCapability to
+// get the limits that apply to a certain account.
+type MailCapability struct {
+	// The maximum number of Mailboxes that can be assigned to a single Email
+	// object. Nil means no limit.
+	MaxMailboxesPerEmail *UnsignedInt `json:"maxMailboxesPerEmail"`
+
+	// The maximum depth of the Mailbox hierarchy (i.e., one more than the
+	// maximum number of ancestors a Mailbox may have). Nil means no limit.
+	MaxMailboxDepth *UnsignedInt `json:"maxMailboxDepth"`
+
+	// The maximum length, in (UTF-8) octets, allowed for the name of a
+	// Mailbox. This MUST be at least 100, although it is recommended servers
+	// allow more.
+	MaxSizeMailboxName UnsignedInt `json:"maxSizeMailboxName"`
+
+	// The maximum total size of attachments, in octets, allowed for a single
+	// Email object.
+	MaxSizeAttachmentsPerEmail UnsignedInt `json:"maxSizeAttachmentsPerEmail"`
+
+	// A list of all the values the server supports for the "property" field
+	// of the Comparator object in an Email/query sort.
+	EmailQuerySortOptions []string `json:"emailQuerySortOptions"`
+
+	// If true, the user may create a Mailbox in this account with a nil
+	// parentId.
+	MayCreateTopLevelMailbox bool `json:"mayCreateTopLevelMailbox"`
+}
+
+// EffectiveMailCapability returns urn:ietf:params:jmap:mail capability
+// limits that apply to the specified account.
+//
+// Values from the session-level capability object (if any) are used as
+// defaults and overridden by any values present in the account-level object.
+func (s *Session) EffectiveMailCapability(account ID) (*MailCapability, error) {
+	acc, ok := s.Accounts[account]
+	if !ok {
+		return nil, errors.New("jmap: unknown account: " + string(account))
+	}
+	accCap, ok := acc.Capabilities[MailCapabilityName]
+	if !ok {
+		return nil, ErrNoMailCapability
+	}
+
+	res := &MailCapability{}
+	if sessCap, ok := s.Capabilities[MailCapabilityName]; ok {
+		if err := json.Unmarshal(sessCap, res); err != nil {
+			return nil, err
+		}
+	}
+	if err := json.Unmarshal(accCap, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+const SubmissionCapabilityName = "urn:ietf:params:jmap:submission"
+
+var ErrNoSubmissionCapability = errors.New("jmap: urn:ietf:params:jmap:submission capability is not supported for the account")
+
+// SubmissionCapability is the urn:ietf:params:jmap:submission account
+// capability object, as defined in RFC 8621.
+type SubmissionCapability struct {
+	// The number in seconds of the maximum delay the server supports in
+	// sending. This is 0 if the server does not support delayed send.
+	MaxDelayedSend UnsignedInt `json:"maxDelayedSend"`
+
+	// The set of SMTP submission extensions supported by the server, which
+	// the client may use when creating an EmailSubmission object. Each key in
+	// the object is the ehlo-name, and the value is a list of ehlo-args.
+	SubmissionExtensions map[string][]string `json:"submissionExtensions"`
+}
+
+// SubmissionCapability returns decoded urn:ietf:params:jmap:submission
+// capability object of the account.
+//
+// ErrNoSubmissionCapability is returned if the account does not support
+// sending mail.
+func (a *Account) SubmissionCapability() (*SubmissionCapability, error) {
+	raw, ok := a.Capabilities[SubmissionCapabilityName]
+	if !ok {
+		return nil, ErrNoSubmissionCapability
+	}
+	res := &SubmissionCapability{}
+	if err := json.Unmarshal(raw, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// FutureReleaseExtension is the name of the SMTP extension (RFC 4865) used
+// for delayed sending.
+const FutureReleaseExtension = "FUTURERELEASE"
+
+// FutureRelease returns limits of the FUTURERELEASE SMTP extension if it is
+// advertised in SubmissionExtensions: the maximum hold interval and the
+// latest date-time the release can be scheduled for.
+//
+// ok is false if the extension is not supported. Malformed limits are
+// returned as zero values.
+func (sc *SubmissionCapability) FutureRelease() (maxInterval time.Duration, maxDateTime time.Time, ok bool) {
+	args, ok := sc.SubmissionExtensions[FutureReleaseExtension]
+	if !ok {
+		return 0, time.Time{}, false
+	}
+	if len(args) > 0 {
+		if secs, err := strconv.ParseInt(args[0], 10, 64); err == nil {
+			maxInterval = time.Duration(secs) * time.Second
+		}
+	}
+	if len(args) > 1 {
+		if t, err := time.Parse(time.RFC3339, args[1]); err == nil {
+			maxDateTime = t
+		}
+	}
+	return maxInterval, maxDateTime, true
+}
+
+const SMIMEVerifyCapabilityName = "urn:ietf:params:jmap:smimeverify"
+
+var ErrNoSMIMEVerifyCapability = errors.New("jmap: urn:ietf:params:jmap:smimeverify capability is not supported for the account")
+
+// SMIMEVerifyCapability is the urn:ietf:params:jmap:smimeverify account
+// capability object, as defined in RFC 9219.
+//
+// It has no properties currently, its presence indicates that the server
+// supports S/MIME signature verification properties on Email objects.
+type SMIMEVerifyCapability struct{}
+
+// SMIMEVerifyCapability returns decoded urn:ietf:params:jmap:smimeverify
+// capability object of the account.
+//
+// ErrNoSMIMEVerifyCapability is returned if the account does not support
+// S/MIME signature verification.
+func (a *Account) SMIMEVerifyCapability() (*SMIMEVerifyCapability, error) {
+	raw, ok := a.Capabilities[SMIMEVerifyCapabilityName]
+	if !ok {
+		return nil, ErrNoSMIMEVerifyCapability
+	}
+	res := &SMIMEVerifyCapability{}
+	if err := json.Unmarshal(raw, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
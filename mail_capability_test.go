@@ -0,0 +1,76 @@
+package jmap
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+	"gotest.tools/assert/cmp"
+)
+
+func TestSessionEffectiveMailCapability(t *testing.T) {
+	s := Session{}
+	assert.NilError(t, json.Unmarshal([]byte(sessionBlob), &s), "json.Unmarshal")
+	s.Capabilities[MailCapabilityName] = json.RawMessage(`{
+		"maxMailboxesPerEmail": 5,
+		"maxSizeMailboxName": 200,
+		"mayCreateTopLevelMailbox": true
+	}`)
+
+	mailCap, err := s.EffectiveMailCapability("A13824")
+	assert.NilError(t, err, "EffectiveMailCapability")
+	assert.Check(t, mailCap.MaxMailboxesPerEmail == nil, "account-level null should override session-level value")
+	assert.Check(t, cmp.Equal(UnsignedInt(10), *mailCap.MaxMailboxDepth))
+	assert.Check(t, cmp.Equal(UnsignedInt(200), mailCap.MaxSizeMailboxName))
+	assert.Check(t, mailCap.MayCreateTopLevelMailbox)
+
+	mailCap, err = s.EffectiveMailCapability("A97813")
+	assert.NilError(t, err, "EffectiveMailCapability")
+	assert.Check(t, cmp.Equal(UnsignedInt(1), *mailCap.MaxMailboxesPerEmail))
+
+	t.Run("unknown account", func(t *testing.T) {
+		_, err := s.EffectiveMailCapability("A00000")
+		assert.Check(t, cmp.ErrorContains(err, "unknown account"))
+	})
+}
+
+func TestAccountSubmissionCapability(t *testing.T) {
+	acc := Account{
+		Capabilities: map[string]json.RawMessage{
+			SubmissionCapabilityName: json.RawMessage(`{
+				"maxDelayedSend": 44236800,
+				"submissionExtensions": {"FUTURERELEASE": ["44236800", "2019-06-01T00:00:00Z"]}
+			}`),
+		},
+	}
+
+	subCap, err := acc.SubmissionCapability()
+	assert.NilError(t, err, "SubmissionCapability")
+	assert.Check(t, cmp.Equal(UnsignedInt(44236800), subCap.MaxDelayedSend))
+	assert.Check(t, cmp.Len(subCap.SubmissionExtensions["FUTURERELEASE"], 2))
+
+	maxInterval, maxDate, ok := subCap.FutureRelease()
+	assert.Check(t, ok)
+	assert.Check(t, cmp.Equal(44236800*time.Second, maxInterval))
+	assert.Check(t, maxDate.Equal(time.Date(2019, 6, 1, 0, 0, 0, 0, time.UTC)))
+
+	_, _, ok = (&SubmissionCapability{}).FutureRelease()
+	assert.Check(t, !ok)
+
+	_, err = (&Account{}).SubmissionCapability()
+	assert.Check(t, cmp.Equal(ErrNoSubmissionCapability, err))
+}
+
+func TestAccountSMIMEVerifyCapability(t *testing.T) {
+	acc := Account{
+		Capabilities: map[string]json.RawMessage{
+			SMIMEVerifyCapabilityName: json.RawMessage(`{}`),
+		},
+	}
+	_, err := acc.SMIMEVerifyCapability()
+	assert.NilError(t, err, "SMIMEVerifyCapability")
+
+	_, err = (&Account{}).SMIMEVerifyCapability()
+	assert.Check(t, cmp.Equal(ErrNoSMIMEVerifyCapability, err))
+}
@@ -0,0 +1,29 @@
+package notes
+
+import "github.com/foxcpp/go-jmap"
+
+// Note represents a single note.
+type Note struct {
+	// The id of the Note. Immutable, server-set.
+	ID jmap.ID `json:"id,omitempty"`
+
+	// The title of the Note.
+	Title string `json:"title,omitempty"`
+
+	// The content of the Note in the format given by BodyType.
+	Body string `json:"body,omitempty"`
+
+	// The media type of Body, either "text/plain" or "text/html". If empty,
+	// "text/plain" is assumed.
+	BodyType string `json:"bodyType,omitempty"`
+
+	// A set of keywords that apply to the Note. The same keywords as for
+	// Emails can be used, e.g. "$flagged" to pin the Note.
+	Keywords map[string]bool `json:"keywords,omitempty"`
+
+	// The date-time the Note was created. Server-set.
+	Created *jmap.UTCDate `json:"created,omitempty"`
+
+	// The date-time the Note was last modified. Server-set.
+	Updated *jmap.UTCDate `json:"updated,omitempty"`
+}
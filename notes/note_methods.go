@@ -0,0 +1,258 @@
+package notes
+
+import (
+	"encoding/json"
+
+	"github.com/foxcpp/go-jmap"
+)
+
+// NoteGetArgs contains arguments for Note/get method call.
+type NoteGetArgs struct {
+	// The id of the account to use.
+	AccountID jmap.ID `json:"accountId"`
+
+	// The ids of the Note objects to return. If nil, then all records are
+	// returned.
+	IDs []jmap.ID `json:"ids"`
+
+	// If not nil, only the properties listed in the array are returned for
+	// each Note object.
+	Properties []string `json:"properties"`
+}
+
+// NoteGetResponse contains results of Note/get method call.
+type NoteGetResponse struct {
+	// The id of the account used for the call.
+	AccountID jmap.ID `json:"accountId"`
+
+	// A string representing the state on the server for all the data of this
+	// type in the account.
+	State string `json:"state"`
+
+	// An array of the Note objects requested.
+	List []Note `json:"list"`
+
+	// This array contains the ids passed to the method for records that do not
+	// exist.
+	NotFound []jmap.ID `json:"notFound"`
+}
+
+// NoteChangesArgs contains arguments for Note/changes method call.
+type NoteChangesArgs struct {
+	// The id of the account to use.
+	AccountID jmap.ID `json:"accountId"`
+
+	// The current state of the client, as returned by Note/get.
+	SinceState string `json:"sinceState"`
+
+	// The maximum number of ids to return in the response. Zero means no
+	// limit.
+	MaxChanges jmap.UnsignedInt `json:"maxChanges,omitempty"`
+}
+
+// NoteChangesResponse contains results of Note/changes method call.
+type NoteChangesResponse struct {
+	// The id of the account used for the call.
+	AccountID jmap.ID `json:"accountId"`
+
+	// This is the sinceState argument echoed back.
+	OldState string `json:"oldState"`
+
+	// This is the state the client will be in after applying the set of
+	// changes to the old state.
+	NewState string `json:"newState"`
+
+	// If true, the client may call Note/changes again with the NewState
+	// returned to get further updates.
+	HasMoreChanges bool `json:"hasMoreChanges"`
+
+	// An array of ids for records that have been created since the old state.
+	Created []jmap.ID `json:"created"`
+
+	// An array of ids for records that have been updated since the old state.
+	Updated []jmap.ID `json:"updated"`
+
+	// An array of ids for records that have been destroyed since the old
+	// state.
+	Destroyed []jmap.ID `json:"destroyed"`
+}
+
+// NoteFilterCondition is the filter condition object for Note/query. Condition
+// matches Note only if all non-empty fields match. Text fields match if the
+// corresponding property contains the given string.
+//
+// Conditions can be combined using jmap.FilterOperator.
+type NoteFilterCondition struct {
+	// Limits on created and updated properties of the Note.
+	CreatedBefore *jmap.UTCDate `json:"createdBefore,omitempty"`
+	CreatedAfter  *jmap.UTCDate `json:"createdAfter,omitempty"`
+	UpdatedBefore *jmap.UTCDate `json:"updatedBefore,omitempty"`
+	UpdatedAfter  *jmap.UTCDate `json:"updatedAfter,omitempty"`
+
+	// Looks for the text in the title and body of the Note.
+	Text string `json:"text,omitempty"`
+
+	// Looks for the text in the corresponding properties of the Note.
+	Title string `json:"title,omitempty"`
+	Body  string `json:"body,omitempty"`
+
+	// The Note must have the given keyword set.
+	HasKeyword string `json:"hasKeyword,omitempty"`
+}
+
+// Properties that can be used in NoteComparator.
+const (
+	NoteSortCreated = "created"
+	NoteSortUpdated = "updated"
+	NoteSortTitle   = "title"
+)
+
+// NoteComparator is the sort comparator for Note/query. Property must be one
+// of NoteSort* constants.
+type NoteComparator struct {
+	jmap.Comparator
+}
+
+// NoteQueryArgs contains arguments for Note/query method call.
+type NoteQueryArgs struct {
+	// The id of the account to use.
+	AccountID jmap.ID `json:"accountId"`
+
+	// Determines the set of Notes returned in the results. Must be either
+	// NoteFilterCondition or jmap.FilterOperator. If nil, no filtering is
+	// performed.
+	Filter interface{} `json:"filter,omitempty"`
+
+	// Lists the names of properties to compare between two Note records, and
+	// how to compare them, to determine which comes first in the sort.
+	Sort []NoteComparator `json:"sort,omitempty"`
+
+	// The zero-based index of the first id in the full list of results to
+	// return. Negative values are offsets from the end of the list.
+	Position jmap.Int `json:"position,omitempty"`
+
+	// A Note id. If supplied, the position argument is ignored.
+	Anchor jmap.ID `json:"anchor,omitempty"`
+
+	// The index of the first result to return relative to the index of the
+	// anchor, if an anchor is given.
+	AnchorOffset jmap.Int `json:"anchorOffset,omitempty"`
+
+	// The maximum number of results to return. If zero, no limit is presumed.
+	Limit jmap.UnsignedInt `json:"limit,omitempty"`
+
+	// Does the client wish to know the total number of results in the query?
+	CalculateTotal bool `json:"calculateTotal,omitempty"`
+}
+
+// NoteQueryResponse contains results of Note/query method call.
+type NoteQueryResponse struct {
+	// The id of the account used for the call.
+	AccountID jmap.ID `json:"accountId"`
+
+	// A string encoding the current state of the query on the server.
+	QueryState string `json:"queryState"`
+
+	// This is true if the server supports calling Note/queryChanges with these
+	// filter/sort parameters.
+	CanCalculateChanges bool `json:"canCalculateChanges"`
+
+	// The zero-based index of the first result in the ids array within the
+	// complete list of query results.
+	Position jmap.UnsignedInt `json:"position"`
+
+	// The list of ids for each Note in the query results, starting at the
+	// index given by the position argument of this response and continuing
+	// until it hits the end of the results or reaches the limit number of ids.
+	IDs []jmap.ID `json:"ids"`
+
+	// The total number of Notes in the results (given the filter). Only set if
+	// CalculateTotal was requested.
+	Total jmap.UnsignedInt `json:"total,omitempty"`
+
+	// The limit enforced by the server on the maximum number of results to
+	// return. Only set if the server set a limit or used a different limit
+	// than that given in the request.
+	Limit jmap.UnsignedInt `json:"limit,omitempty"`
+}
+
+// NoteSetArgs contains arguments for Note/set method call.
+type NoteSetArgs struct {
+	// The id of the account to use.
+	AccountID jmap.ID `json:"accountId"`
+
+	// If not empty, the method will be aborted and a stateMismatch error
+	// returned if the current state does not match this string.
+	IfInState string `json:"ifInState,omitempty"`
+
+	// A map of creation id to Note objects.
+	Create map[jmap.ID]Note `json:"create,omitempty"`
+
+	// A map of id to a patch object to apply to the current Note object with
+	// that id.
+	Update map[jmap.ID]jmap.PatchObject `json:"update,omitempty"`
+
+	// A list of ids for Note objects to permanently delete.
+	Destroy []jmap.ID `json:"destroy,omitempty"`
+}
+
+// NoteSetResponse contains results of Note/set method call.
+type NoteSetResponse struct {
+	// The id of the account used for the call.
+	AccountID jmap.ID `json:"accountId"`
+
+	// The state string that would have been returned by Note/get before making
+	// the requested changes.
+	OldState string `json:"oldState"`
+
+	// The state string that will now be returned by Note/get.
+	NewState string `json:"newState"`
+
+	// A map of the creation id to an object containing any properties of the
+	// created Note object that were not sent by the client.
+	Created map[jmap.ID]Note `json:"created"`
+
+	// The keys in this map are the ids of all Notes that were successfully
+	// updated. The value is a Note object containing any property that changed
+	// in a way not explicitly requested, or nil if none.
+	Updated map[jmap.ID]*Note `json:"updated"`
+
+	// A list of Note ids for records that were successfully destroyed.
+	Destroyed []jmap.ID `json:"destroyed"`
+
+	// A map of creation id to a SetError object for each record that failed to
+	// be created.
+	NotCreated map[jmap.ID]jmap.SetError `json:"notCreated"`
+
+	// A map of Note id to a SetError object for each record that failed to be
+	// updated.
+	NotUpdated map[jmap.ID]jmap.SetError `json:"notUpdated"`
+
+	// A map of Note id to a SetError object for each record that failed to be
+	// destroyed.
+	NotDestroyed map[jmap.ID]jmap.SetError `json:"notDestroyed"`
+}
+
+func unmarshalNoteGetResponse(args json.RawMessage) (interface{}, error) {
+	resp := NoteGetResponse{}
+	err := json.Unmarshal(args, &resp)
+	return resp, err
+}
+
+func unmarshalNoteChangesResponse(args json.RawMessage) (interface{}, error) {
+	resp := NoteChangesResponse{}
+	err := json.Unmarshal(args, &resp)
+	return resp, err
+}
+
+func unmarshalNoteQueryResponse(args json.RawMessage) (interface{}, error) {
+	resp := NoteQueryResponse{}
+	err := json.Unmarshal(args, &resp)
+	return resp, err
+}
+
+func unmarshalNoteSetResponse(args json.RawMessage) (interface{}, error) {
+	resp := NoteSetResponse{}
+	err := json.Unmarshal(args, &resp)
+	return resp, err
+}
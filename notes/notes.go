@@ -0,0 +1,74 @@
+// Package notes implements the Note data type and its methods for servers
+// that expose notes over JMAP using the urn:ietf:params:jmap:notes
+// capability (e.g. Fastmail).
+//
+// There is no published specification of this capability yet, the Note
+// object follows what such servers return and may change in future versions
+// of this package. For the same reason the package does not provide
+// PropertySchemas: properties used by a particular server can't be checked
+// against a specification, so requests with them are not rejected.
+package notes
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/foxcpp/go-jmap"
+)
+
+const CapabilityName = "urn:ietf:params:jmap:notes"
+
+var ErrNoCapability = errors.New("jmap/notes: urn:ietf:params:jmap:notes capability is not supported for the account")
+
+// Capability is the urn:ietf:params:jmap:notes account capability object.
+type Capability struct {
+	// The maximum length of the Note body in octets. Nil means no limit.
+	MaxBodySize *jmap.UnsignedInt `json:"maxBodySize"`
+
+	// Media types the server accepts for the Note body. If empty, only
+	// "text/plain" is guaranteed to be supported.
+	BodyTypes []string `json:"bodyTypes"`
+}
+
+// AccountCapability returns decoded urn:ietf:params:jmap:notes capability
+// object of the account.
+//
+// ErrNoCapability is returned if the account does not contain notes data.
+func AccountCapability(acc *jmap.Account) (*Capability, error) {
+	raw, ok := acc.Capabilities[CapabilityName]
+	if !ok {
+		return nil, ErrNoCapability
+	}
+	res := &Capability{}
+	if err := json.Unmarshal(raw, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// ResponseUnmarshallers contains callbacks for decoding responses of all
+// methods implemented by this package.
+//
+// Pass it to client.Enable to make the client decode these responses into
+// corresponding structures.
+var ResponseUnmarshallers = map[string]jmap.FuncArgsUnmarshal{
+	"Note/get":     unmarshalNoteGetResponse,
+	"Note/changes": unmarshalNoteChangesResponse,
+	"Note/query":   unmarshalNoteQueryResponse,
+	"Note/set":     unmarshalNoteSetResponse,
+}
+
+// MethodCapabilities maps data types implemented by this package to
+// capabilities that define them.
+//
+// Pass it to client.EnableMethodCapabilities to let the client determine
+// which capabilities can be dropped from requests during capability
+// downgrade.
+var MethodCapabilities = map[string]string{
+	"Note": CapabilityName,
+}
+
+func init() {
+	// All data types of the package can be used in push type filters.
+	jmap.RegisterPushTypes(MethodCapabilities)
+}
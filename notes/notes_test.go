@@ -0,0 +1,54 @@
+package notes
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/foxcpp/go-jmap"
+	"gotest.tools/assert"
+	"gotest.tools/assert/cmp"
+)
+
+func TestAccountCapability(t *testing.T) {
+	acc := jmap.Account{Capabilities: map[string]json.RawMessage{
+		CapabilityName: json.RawMessage(`{"maxBodySize":65536,"bodyTypes":["text/plain","text/html"]}`),
+	}}
+	capa, err := AccountCapability(&acc)
+	assert.NilError(t, err)
+	assert.Check(t, cmp.Equal(jmap.UnsignedInt(65536), *capa.MaxBodySize))
+	assert.Check(t, cmp.DeepEqual([]string{"text/plain", "text/html"}, capa.BodyTypes))
+
+	_, err = AccountCapability(&jmap.Account{})
+	assert.Check(t, cmp.Equal(ErrNoCapability, err))
+}
+
+func TestNoteGetResponse(t *testing.T) {
+	blob := json.RawMessage(`{
+		"accountId": "A1",
+		"state": "s1",
+		"list": [{
+			"id": "N1",
+			"title": "Shopping",
+			"body": "milk, eggs",
+			"keywords": {"$flagged": true},
+			"created": "2020-01-06T09:00:00Z",
+			"updated": "2020-01-07T10:00:00Z"
+		}],
+		"notFound": []
+	}`)
+	args, err := ResponseUnmarshallers["Note/get"](blob)
+	assert.NilError(t, err)
+	resp := args.(NoteGetResponse)
+	assert.Equal(t, 1, len(resp.List))
+
+	note := resp.List[0]
+	assert.Check(t, cmp.Equal("Shopping", note.Title))
+	assert.Check(t, cmp.Equal("milk, eggs", note.Body))
+	assert.Check(t, note.Keywords["$flagged"])
+	assert.Check(t, note.Updated != nil)
+
+	// Unset properties should not be sent in /set calls.
+	out, err := json.Marshal(Note{Title: "Ideas"})
+	assert.NilError(t, err)
+	assert.Check(t, cmp.Equal(`{"title":"Ideas"}`, string(out)))
+}
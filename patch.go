@@ -0,0 +1,101 @@
+package jmap
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// PatchObject represents an unordered set of patches to apply to the
+// object in /set method call.
+//
+// The keys are a path in RFC 6901 JSON pointer format, with an implicit
+// leading "/" (e.g. "keywords/$seen"). If the value is nil, the property is
+// reset to the default value or removed, otherwise it is set to the
+// specified value.
+//
+// See section 5.3 of JMAP Core specification.
+type PatchObject map[string]interface{}
+
+// ApplyPatch applies the patch to the object v points to, following the
+// rules defined for /set method in section 5.3 of JMAP Core specification.
+//
+// The object is converted to JSON, patched and converted back, so v can be a
+// pointer to any value that round-trips through encoding/json (e.g.
+// *mail.Email). Missing intermediate objects are created. Paths referencing
+// array members are rejected, as required by the specification.
+func ApplyPatch(v interface{}, patch PatchObject) error {
+	if len(patch) == 0 {
+		return nil
+	}
+
+	blob, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	var obj map[string]interface{}
+	if err := json.Unmarshal(blob, &obj); err != nil {
+		return err
+	}
+	if obj == nil {
+		obj = map[string]interface{}{}
+	}
+
+	// Apply shorter paths first so "keywords" followed by "keywords/$seen"
+	// works as expected if both are present.
+	paths := make([]string, 0, len(patch))
+	for path := range patch {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		if err := applyPatchPath(obj, path, patch[path]); err != nil {
+			return err
+		}
+	}
+
+	blob, err = json.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("jmap: ApplyPatch requires a non-nil pointer, got %T", v)
+	}
+	rv.Elem().Set(reflect.Zero(rv.Elem().Type()))
+	return json.Unmarshal(blob, v)
+}
+
+var pointerUnescaper = strings.NewReplacer("~1", "/", "~0", "~")
+
+func applyPatchPath(obj map[string]interface{}, path string, value interface{}) error {
+	parts := strings.Split(path, "/")
+	for i, part := range parts[:len(parts)-1] {
+		key := pointerUnescaper.Replace(part)
+		switch next := obj[key].(type) {
+		case map[string]interface{}:
+			obj = next
+		case nil:
+			if value == nil {
+				// Nothing to remove.
+				return nil
+			}
+			created := map[string]interface{}{}
+			obj[key] = created
+			obj = created
+		default:
+			return fmt.Errorf("jmap: patch path %s: %s is not an object", path, strings.Join(parts[:i+1], "/"))
+		}
+	}
+
+	key := pointerUnescaper.Replace(parts[len(parts)-1])
+	if value == nil {
+		delete(obj, key)
+		return nil
+	}
+	obj[key] = value
+	return nil
+}
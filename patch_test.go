@@ -0,0 +1,45 @@
+package jmap
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+	"gotest.tools/assert/cmp"
+)
+
+func TestApplyPatch(t *testing.T) {
+	type object struct {
+		Name     string          `json:"name,omitempty"`
+		Keywords map[string]bool `json:"keywords,omitempty"`
+		Tags     []string        `json:"tags,omitempty"`
+	}
+
+	obj := object{Name: "a", Keywords: map[string]bool{"$seen": true}, Tags: []string{"x"}}
+	err := ApplyPatch(&obj, PatchObject{
+		"name":            nil,
+		"keywords/$seen":  nil,
+		"keywords/a~1b~0": true,
+		"tags":            []string{"y", "z"},
+	})
+	assert.NilError(t, err)
+	assert.Check(t, cmp.DeepEqual(object{
+		Keywords: map[string]bool{"a/b~": true},
+		Tags:     []string{"y", "z"},
+	}, obj))
+
+	t.Run("missing intermediate object", func(t *testing.T) {
+		obj := object{}
+		assert.NilError(t, ApplyPatch(&obj, PatchObject{"keywords/$seen": true}))
+		assert.Check(t, cmp.DeepEqual(map[string]bool{"$seen": true}, obj.Keywords))
+	})
+
+	t.Run("array member", func(t *testing.T) {
+		obj := object{Tags: []string{"x"}}
+		err := ApplyPatch(&obj, PatchObject{"tags/0": "y"})
+		assert.Check(t, cmp.ErrorContains(err, "tags is not an object"))
+	})
+
+	t.Run("not a pointer", func(t *testing.T) {
+		assert.Check(t, ApplyPatch(object{}, PatchObject{"name": "b"}) != nil)
+	})
+}
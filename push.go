@@ -0,0 +1,69 @@
+package jmap
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// StateChangeType is the value of @type property of StateChange objects.
+const StateChangeType = "StateChange"
+
+// StateChange object is sent by the server to notify the client about
+// changes in the data.
+//
+// See draft-ietf-jmap-core-17, section 7.1 for details.
+type StateChange struct {
+	// Map of account id to an object encoding the state of data types that
+	// have changed for that account since the last StateChange object was
+	// pushed, for each of the accounts to which the user has access and for
+	// which something has changed.
+	//
+	// Key of the inner map is the type name (e.g. "Email") and the value is
+	// the state string that would be returned by /get for that type.
+	Changed map[ID]map[string]string `json:"changed"`
+}
+
+type stateChange StateChange
+
+func (sc StateChange) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Type string `json:"@type"`
+		stateChange
+	}{
+		Type:        StateChangeType,
+		stateChange: stateChange(sc),
+	})
+}
+
+func (sc *StateChange) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Type string `json:"@type"`
+		stateChange
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if raw.Type != StateChangeType {
+		return fmt.Errorf("jmap: unexpected push object type: %q", raw.Type)
+	}
+	*sc = StateChange(raw.stateChange)
+	return nil
+}
+
+// Merge adds changes from other to sc. For each account and type, the state
+// from other replaces the state in sc since it is assumed to be newer.
+func (sc *StateChange) Merge(other StateChange) {
+	if sc.Changed == nil {
+		sc.Changed = make(map[ID]map[string]string, len(other.Changed))
+	}
+	for account, types := range other.Changed {
+		dst, ok := sc.Changed[account]
+		if !ok {
+			dst = make(map[string]string, len(types))
+			sc.Changed[account] = dst
+		}
+		for typ, state := range types {
+			dst[typ] = state
+		}
+	}
+}
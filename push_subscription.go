@@ -0,0 +1,186 @@
+package jmap
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// PushVerificationType is the value of @type property of PushVerification
+// objects.
+const PushVerificationType = "PushVerification"
+
+// PushSubscriptionKeys contains the keys used to encrypt push messages
+// as described in RFC 8291.
+type PushSubscriptionKeys struct {
+	// The P-256 public key in uncompressed form, encoded in URL-safe Base64
+	// without padding.
+	P256DH string `json:"p256dh"`
+
+	// The authentication secret, encoded in URL-safe Base64 without
+	// padding.
+	Auth string `json:"auth"`
+}
+
+// PushSubscription object represents the subscription of the client to
+// push notifications sent by the server to the URL.
+//
+// See RFC 8620, section 7.2 for details.
+type PushSubscription struct {
+	// The id of the PushSubscription.
+	ID ID `json:"id,omitempty"`
+
+	// An id that uniquely identifies the client and device it is running
+	// on. It must be stable across restarts of the client.
+	DeviceClientID string `json:"deviceClientId,omitempty"`
+
+	// An absolute URL where the server sends push messages. It is never
+	// returned by PushSubscription/get.
+	URL string `json:"url,omitempty"`
+
+	// Client-generated encryption keys. If nil, push messages are not
+	// encrypted. Never returned by PushSubscription/get.
+	Keys *PushSubscriptionKeys `json:"keys,omitempty"`
+
+	// The verification code sent to the URL in the PushVerification
+	// object. The server does not send StateChanges to the URL until the
+	// client sets this property to the received value.
+	VerificationCode string `json:"verificationCode,omitempty"`
+
+	// The time the subscription expires. The server may choose an earlier
+	// time than requested.
+	Expires *UTCDate `json:"expires,omitempty"`
+
+	// The list of type names the client is interested in. If nil, changes
+	// to all types are pushed.
+	Types []string `json:"types,omitempty"`
+}
+
+// PushVerification object is sent by the server to the URL of a new
+// PushSubscription to verify the client controls it.
+//
+// See RFC 8620, section 7.2.2 for details.
+type PushVerification struct {
+	// The id of the PushSubscription that was created.
+	PushSubscriptionID ID `json:"pushSubscriptionId"`
+
+	// The verification code to set on the PushSubscription.
+	VerificationCode string `json:"verificationCode"`
+}
+
+type pushVerification PushVerification
+
+func (pv PushVerification) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Type string `json:"@type"`
+		pushVerification
+	}{
+		Type:             PushVerificationType,
+		pushVerification: pushVerification(pv),
+	})
+}
+
+func (pv *PushVerification) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Type string `json:"@type"`
+		pushVerification
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if raw.Type != PushVerificationType {
+		return fmt.Errorf("jmap: unexpected push object type: %q", raw.Type)
+	}
+	*pv = PushVerification(raw.pushVerification)
+	return nil
+}
+
+// PushSubscriptionGetArgs contains arguments for PushSubscription/get
+// method call.
+//
+// PushSubscriptions are not tied to an account, so there is no accountId
+// argument.
+type PushSubscriptionGetArgs struct {
+	// The ids of the PushSubscriptions to return. If nil, all
+	// PushSubscriptions created using the same credentials are returned.
+	IDs []ID `json:"ids"`
+
+	// If not nil, only the properties listed in the array are returned.
+	Properties []string `json:"properties,omitempty"`
+}
+
+// PushSubscriptionGetResponse contains results of PushSubscription/get
+// method call.
+type PushSubscriptionGetResponse struct {
+	// An array of the PushSubscription objects requested.
+	List []PushSubscription `json:"list"`
+
+	// This array contains the ids passed to the method for records that do
+	// not exist.
+	NotFound []ID `json:"notFound"`
+}
+
+// PushSubscriptionSetArgs contains arguments for PushSubscription/set
+// method call.
+type PushSubscriptionSetArgs struct {
+	// A map of creation id to PushSubscription objects.
+	Create map[ID]PushSubscription `json:"create,omitempty"`
+
+	// A map of id to a patch object to apply to the current
+	// PushSubscription object with that id.
+	Update map[ID]PatchObject `json:"update,omitempty"`
+
+	// A list of ids for PushSubscription objects to permanently delete.
+	Destroy []ID `json:"destroy,omitempty"`
+}
+
+// PushSubscriptionSetResponse contains results of PushSubscription/set
+// method call.
+type PushSubscriptionSetResponse struct {
+	// A map of the creation id to an object containing any properties of the
+	// created PushSubscription object that were not sent by the client.
+	Created map[ID]PushSubscription `json:"created"`
+
+	// The keys in this map are the ids of all PushSubscriptions that were
+	// successfully updated. The value is a PushSubscription object
+	// containing any property that changed in a way not explicitly
+	// requested, or nil if none.
+	Updated map[ID]*PushSubscription `json:"updated"`
+
+	// A list of PushSubscription ids for records that were successfully
+	// destroyed.
+	Destroyed []ID `json:"destroyed"`
+
+	// A map of creation id to a SetError object for each record that failed
+	// to be created.
+	NotCreated map[ID]SetError `json:"notCreated"`
+
+	// A map of PushSubscription id to a SetError object for each record
+	// that failed to be updated.
+	NotUpdated map[ID]SetError `json:"notUpdated"`
+
+	// A map of PushSubscription id to a SetError object for each record
+	// that failed to be destroyed.
+	NotDestroyed map[ID]SetError `json:"notDestroyed"`
+}
+
+// PushSubscriptionUnmarshallers contains callbacks for decoding responses
+// of PushSubscription methods.
+//
+// Pass it to client.Enable to make the client decode these responses into
+// corresponding structures.
+var PushSubscriptionUnmarshallers = map[string]FuncArgsUnmarshal{
+	"PushSubscription/get": unmarshalPushSubscriptionGetResponse,
+	"PushSubscription/set": unmarshalPushSubscriptionSetResponse,
+}
+
+func unmarshalPushSubscriptionGetResponse(args json.RawMessage) (interface{}, error) {
+	resp := PushSubscriptionGetResponse{}
+	err := json.Unmarshal(args, &resp)
+	return resp, err
+}
+
+func unmarshalPushSubscriptionSetResponse(args json.RawMessage) (interface{}, error) {
+	resp := PushSubscriptionSetResponse{}
+	err := json.Unmarshal(args, &resp)
+	return resp, err
+}
@@ -0,0 +1,71 @@
+package jmap
+
+import (
+	"encoding/json"
+	"testing"
+
+	"gotest.tools/assert"
+	"gotest.tools/assert/cmp"
+)
+
+func TestStateChangeJSON(t *testing.T) {
+	blob := `{"@type":"StateChange","changed":{"a3123":{"Email":"d35ecb040aab","Thread":"1a"}}}`
+	var sc StateChange
+	assert.NilError(t, json.Unmarshal([]byte(blob), &sc))
+	assert.Check(t, cmp.DeepEqual(map[ID]map[string]string{
+		"a3123": {"Email": "d35ecb040aab", "Thread": "1a"},
+	}, sc.Changed))
+
+	out, err := json.Marshal(sc)
+	assert.NilError(t, err)
+	assert.Check(t, cmp.Equal(blob, string(out)))
+
+	err = json.Unmarshal([]byte(`{"@type":"PushVerification"}`), &sc)
+	assert.Check(t, cmp.ErrorContains(err, "PushVerification"))
+}
+
+func TestPushVerificationJSON(t *testing.T) {
+	blob := `{"@type":"PushVerification","pushSubscriptionId":"P43dcfa4-1dd4-41ef-9156-2c89b3b19c60","verificationCode":"da1f097b11ca17f06424e30bf02bfa67"}`
+	var pv PushVerification
+	assert.NilError(t, json.Unmarshal([]byte(blob), &pv))
+	assert.Check(t, cmp.Equal(ID("P43dcfa4-1dd4-41ef-9156-2c89b3b19c60"), pv.PushSubscriptionID))
+	assert.Check(t, cmp.Equal("da1f097b11ca17f06424e30bf02bfa67", pv.VerificationCode))
+
+	out, err := json.Marshal(pv)
+	assert.NilError(t, err)
+	assert.Check(t, cmp.Equal(blob, string(out)))
+
+	err = json.Unmarshal([]byte(`{"@type":"StateChange"}`), &pv)
+	assert.Check(t, cmp.ErrorContains(err, "StateChange"))
+}
+
+func TestStateChangeMerge(t *testing.T) {
+	var sc StateChange
+	sc.Merge(StateChange{Changed: map[ID]map[string]string{"A1": {"Email": "1", "Mailbox": "1"}}})
+	sc.Merge(StateChange{Changed: map[ID]map[string]string{"A1": {"Email": "2"}, "A2": {"Email": "5"}}})
+	assert.Check(t, cmp.DeepEqual(map[ID]map[string]string{
+		"A1": {"Email": "2", "Mailbox": "1"},
+		"A2": {"Email": "5"},
+	}, sc.Changed))
+}
+
+func TestValidatePushTypes(t *testing.T) {
+	s := Session{}
+	assert.NilError(t, json.Unmarshal([]byte(sessionBlob), &s), "json.Unmarshal")
+
+	assert.Check(t, s.ValidatePushTypes(nil))
+	assert.Check(t, s.ValidatePushTypes(MailPushTypes))
+	assert.Check(t, s.ValidatePushTypes([]string{PushTypeContactCard, "Foo"}))
+	err := s.ValidatePushTypes([]string{PushTypeEmail, PushTypeEmailSubmission})
+	assert.Check(t, cmp.ErrorContains(err, SubmissionCapabilityName))
+}
+
+func TestStateChangeHasNewMail(t *testing.T) {
+	sc := StateChange{Changed: map[ID]map[string]string{
+		"A1": {PushTypeEmail: "2", PushTypeEmailDelivery: "1"},
+		"A2": {PushTypeEmail: "5"},
+	}}
+	assert.Check(t, sc.HasNewMail("A1"))
+	assert.Check(t, !sc.HasNewMail("A2"))
+	assert.Check(t, !sc.HasNewMail("A3"))
+}
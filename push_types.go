@@ -0,0 +1,83 @@
+package jmap
+
+import "fmt"
+
+// Data type names that can be used in push type filters (e.g.
+// client.EventSourceOptions.Types).
+const (
+	PushTypeEmail            = "Email"
+	PushTypeMailbox          = "Mailbox"
+	PushTypeThread           = "Thread"
+	PushTypeIdentity         = "Identity"
+	PushTypeEmailSubmission  = "EmailSubmission"
+	PushTypeVacationResponse = "VacationResponse"
+	PushTypeAddressBook      = "AddressBook"
+	PushTypeContactCard      = "ContactCard"
+
+	// EmailDelivery is the pseudo-type defined in RFC 8621, section 1.5.
+	// Its state changes only when a new Email is delivered to the account,
+	// as opposed to Email state that also changes when existing Emails are
+	// modified, so it can be used for new mail notifications.
+	//
+	// There is no EmailDelivery/get method, the state is only meaningful
+	// for comparison with the previously pushed one.
+	PushTypeEmailDelivery = "EmailDelivery"
+)
+
+// PushTypeCapabilities maps known push type names to capabilities that
+// define them.
+//
+// It contains types of JMAP Mail. Packages implementing other data types
+// (e.g. contacts) add their types using RegisterPushTypes when imported.
+// Applications using extensions may add their types too so
+// ValidatePushTypes can check them.
+var PushTypeCapabilities = map[string]string{
+	PushTypeEmail:           MailCapabilityName,
+	PushTypeMailbox:         MailCapabilityName,
+	PushTypeThread:          MailCapabilityName,
+	PushTypeEmailDelivery:   MailCapabilityName,
+	PushTypeIdentity:        SubmissionCapabilityName,
+	PushTypeEmailSubmission: SubmissionCapabilityName,
+}
+
+// RegisterPushTypes adds entries mapping push type names to capabilities
+// to PushTypeCapabilities.
+//
+// It is meant to be called from init functions and must not be called
+// concurrently with ValidatePushTypes.
+func RegisterPushTypes(types map[string]string) {
+	for typ, capability := range types {
+		PushTypeCapabilities[typ] = capability
+	}
+}
+
+// MailPushTypes is the push type filter for all data types of JMAP Mail,
+// including EmailDelivery.
+var MailPushTypes = []string{PushTypeEmail, PushTypeMailbox, PushTypeThread, PushTypeEmailDelivery}
+
+// ValidatePushTypes checks that the server supports capabilities required
+// for types in the push type filter, see PushTypeCapabilities. Types not
+// present in PushTypeCapabilities are not checked.
+//
+// Servers silently ignore unsupported types, so the check helps to detect
+// misconfiguration early.
+func (s *Session) ValidatePushTypes(types []string) error {
+	for _, typ := range types {
+		capability, ok := PushTypeCapabilities[typ]
+		if !ok {
+			continue
+		}
+		if _, ok := s.Capabilities[capability]; !ok {
+			return fmt.Errorf("jmap: push type %s requires %s capability that is not supported by the server", typ, capability)
+		}
+	}
+	return nil
+}
+
+// HasNewMail reports whether the StateChange includes new EmailDelivery
+// state for the account, meaning a new Email was delivered to it. The
+// EmailDelivery type must be included in the push type filter for this.
+func (sc StateChange) HasNewMail(account ID) bool {
+	_, ok := sc.Changed[account][PushTypeEmailDelivery]
+	return ok
+}
@@ -0,0 +1,125 @@
+package jmap
+
+import "sort"
+
+// FilterOperatorType is the operation FilterOperator applies to its
+// conditions.
+type FilterOperatorType string
+
+const (
+	// All of the conditions must match for the filter to match.
+	FilterAND FilterOperatorType = "AND"
+
+	// At least one of the conditions must match for the filter to match.
+	FilterOR FilterOperatorType = "OR"
+
+	// None of the conditions must match for the filter to match.
+	FilterNOT FilterOperatorType = "NOT"
+)
+
+// FilterOperator combines several filter conditions used in /query method
+// calls.
+//
+// See section 5.5 of JMAP Core specification.
+type FilterOperator struct {
+	// This MUST be one of FilterAND, FilterOR, FilterNOT.
+	Operator FilterOperatorType `json:"operator"`
+
+	// The conditions to evaluate against each record. Each element must be
+	// either FilterOperator or type-specific FilterCondition object.
+	Conditions []interface{} `json:"conditions"`
+}
+
+// And returns FilterOperator that matches only if all of conditions match.
+func And(conditions ...interface{}) FilterOperator {
+	return FilterOperator{Operator: FilterAND, Conditions: conditions}
+}
+
+// Or returns FilterOperator that matches if any of conditions match.
+func Or(conditions ...interface{}) FilterOperator {
+	return FilterOperator{Operator: FilterOR, Conditions: conditions}
+}
+
+// Not returns FilterOperator that matches only if none of conditions match.
+func Not(conditions ...interface{}) FilterOperator {
+	return FilterOperator{Operator: FilterNOT, Conditions: conditions}
+}
+
+// Comparator determines sort order of records returned by /query method
+// call.
+//
+// See section 5.5 of JMAP Core specification.
+type Comparator struct {
+	// The name of the property on the objects to compare.
+	Property string `json:"property"`
+
+	// If true, sort in ascending order, otherwise descending order.
+	//
+	// nil means the server default, which is ascending order. Use Asc and
+	// Desc to construct comparators with explicit order.
+	IsAscending *bool `json:"isAscending,omitempty"`
+
+	// The identifier, as registered in the collation registry defined in
+	// RFC 4790, for the algorithm to use when comparing the order of strings.
+	Collation CollationAlgo `json:"collation,omitempty"`
+}
+
+// Asc returns Comparator that sorts records by property in ascending order.
+func Asc(property string) Comparator {
+	asc := true
+	return Comparator{Property: property, IsAscending: &asc}
+}
+
+// Desc returns Comparator that sorts records by property in descending order.
+func Desc(property string) Comparator {
+	asc := false
+	return Comparator{Property: property, IsAscending: &asc}
+}
+
+// AddedItem describes the record added to the query results, as returned in
+// the added argument of /queryChanges response.
+//
+// See section 5.6 of JMAP Core specification.
+type AddedItem struct {
+	// The id of the record.
+	ID ID `json:"id"`
+
+	// The index of the record in the new query results.
+	Index UnsignedInt `json:"index"`
+}
+
+// ApplyQueryChanges updates the cached query results using removed and
+// added arguments of /queryChanges response and returns the new list. ids
+// is not modified.
+//
+// ids should contain the results from the start of the list (index 0) and
+// may be truncated. Records added past the end of ids are not included in
+// the returned list, so it stays a correct prefix of the new results.
+//
+// If upToId argument was used in /queryChanges call, ids should be
+// truncated right after that id before applying changes.
+func ApplyQueryChanges(ids []ID, removed []ID, added []AddedItem) []ID {
+	removedSet := make(map[ID]bool, len(removed))
+	for _, id := range removed {
+		removedSet[id] = true
+	}
+	res := make([]ID, 0, len(ids)+len(added))
+	for _, id := range ids {
+		if !removedSet[id] {
+			res = append(res, id)
+		}
+	}
+
+	sorted := append([]AddedItem(nil), added...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Index < sorted[j].Index })
+	for _, item := range sorted {
+		idx := int(item.Index)
+		if idx > len(res) {
+			break
+		}
+		res = append(res, "")
+		copy(res[idx+1:], res[idx:])
+		res[idx] = item.ID
+	}
+	return res
+}
@@ -0,0 +1,32 @@
+package jmap
+
+import (
+	"encoding/json"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestApplyQueryChanges(t *testing.T) {
+	removed := []ID{"b", "d"}
+	added := []AddedItem{{ID: "f", Index: 3}, {ID: "d", Index: 0}, {ID: "g", Index: 10}}
+
+	ids := []ID{"a", "b", "c", "d", "e"}
+	assert.DeepEqual(t, []ID{"d", "a", "c", "f", "e"}, ApplyQueryChanges(ids, removed, added))
+	assert.DeepEqual(t, []ID{"a", "b", "c", "d", "e"}, ids)
+
+	// Truncated list stays a prefix of the new results.
+	assert.DeepEqual(t, []ID{"d", "a", "c", "f"}, ApplyQueryChanges([]ID{"a", "b", "c"}, removed, added))
+
+	assert.DeepEqual(t, []ID{"x"}, ApplyQueryChanges(nil, nil, []AddedItem{{ID: "x", Index: 0}}))
+}
+
+func TestComparatorDefaultOrder(t *testing.T) {
+	b, err := json.Marshal(Comparator{Property: "name"})
+	assert.NilError(t, err)
+	assert.Equal(t, `{"property":"name"}`, string(b))
+
+	b, err = json.Marshal(Desc("name"))
+	assert.NilError(t, err)
+	assert.Equal(t, `{"property":"name","isAscending":false}`, string(b))
+}
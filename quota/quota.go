@@ -0,0 +1,125 @@
+// Package quota implements data types and methods of JMAP for Quotas
+// extension as defined in RFC 9425.
+//
+// The urn:ietf:params:jmap:quota capability has no properties, use
+// jmap.Account.HasCapability to check whether the account supports it.
+//
+// Documentation strings for most of the protocol objects are taken from (or
+// based on) contents of RFC 9425 and are subject to the IETF Trust
+// Provisions. See https://trustee.ietf.org/trust-legal-provisions.html for
+// details.
+package quota
+
+import "github.com/foxcpp/go-jmap"
+
+const CapabilityName = "urn:ietf:params:jmap:quota"
+
+// Values of Quota.ResourceType.
+const (
+	// The quota is measured in the number of objects.
+	ResourceCount = "count"
+
+	// The quota is measured in the size of objects in octets.
+	ResourceOctets = "octets"
+)
+
+// Values of Quota.Scope.
+const (
+	// The quota information applies to just the account.
+	ScopeAccount = "account"
+
+	// The quota information applies to all accounts sharing the same
+	// domain.
+	ScopeDomain = "domain"
+
+	// The quota information applies to all accounts belonging to the
+	// server.
+	ScopeGlobal = "global"
+)
+
+// Quota describes a resource limit applied to the account.
+//
+// See RFC 9425, section 4 for details.
+type Quota struct {
+	// The unique identifier for this object.
+	ID jmap.ID `json:"id"`
+
+	// The type of the resource the quota is measured in, one of Resource*
+	// constants.
+	ResourceType string `json:"resourceType"`
+
+	// The current usage of the resource.
+	Used jmap.UnsignedInt `json:"used"`
+
+	// The hard limit for the resource. Once reached, the server rejects any
+	// action that would increase the usage.
+	HardLimit jmap.UnsignedInt `json:"hardLimit"`
+
+	// The scope of the quota, one of Scope* constants.
+	Scope string `json:"scope"`
+
+	// The name of the quota, useful for displaying it to the user.
+	Name string `json:"name"`
+
+	// A list of data types (e.g. "Mail") the quota applies to.
+	Types []string `json:"types"`
+
+	// The warn limit set by the administrator. Once reached, the user is
+	// expected to be warned. Nil if not set.
+	WarnLimit *jmap.UnsignedInt `json:"warnLimit,omitempty"`
+
+	// The soft limit set by the administrator. Once reached, the server may
+	// start restricting actions. Nil if not set.
+	SoftLimit *jmap.UnsignedInt `json:"softLimit,omitempty"`
+
+	// Arbitrary, free, human-readable description of the quota.
+	Description string `json:"description,omitempty"`
+}
+
+// UsedFraction returns the fraction of HardLimit that is used, which can be
+// displayed as a usage bar. It returns 0 if HardLimit is 0.
+func (q Quota) UsedFraction() float64 {
+	if q.HardLimit == 0 {
+		return 0
+	}
+	return float64(q.Used) / float64(q.HardLimit)
+}
+
+// ResponseUnmarshallers contains callbacks for decoding responses of all
+// methods implemented by this package.
+//
+// Pass it to client.Enable to make the client decode these responses into
+// corresponding structures.
+var ResponseUnmarshallers = map[string]jmap.FuncArgsUnmarshal{
+	"Quota/get":          unmarshalQuotaGetResponse,
+	"Quota/changes":      unmarshalQuotaChangesResponse,
+	"Quota/query":        unmarshalQuotaQueryResponse,
+	"Quota/queryChanges": unmarshalQuotaQueryChangesResponse,
+}
+
+// MethodCapabilities maps data types implemented by this package to
+// capabilities that define them.
+//
+// Pass it to client.EnableMethodCapabilities to let the client determine
+// which capabilities can be dropped from requests during capability
+// downgrade.
+var MethodCapabilities = map[string]string{
+	"Quota": CapabilityName,
+}
+
+func init() {
+	// All data types of the package can be used in push type filters.
+	jmap.RegisterPushTypes(MethodCapabilities)
+}
+
+// PropertySchemas contains property lists for all data types implemented by
+// this package.
+//
+// Pass it to client.EnableSchemas to make the client reject requests using
+// unknown properties before sending them.
+var PropertySchemas = jmap.PropertySchemas{
+	"Quota": {
+		"id", "resourceType", "used", "hardLimit", "scope", "name", "types",
+		"warnLimit", "softLimit", "description",
+	},
+}
@@ -0,0 +1,275 @@
+package quota
+
+import (
+	"encoding/json"
+
+	"github.com/foxcpp/go-jmap"
+)
+
+// QuotaGetArgs contains arguments for Quota/get method call.
+type QuotaGetArgs struct {
+	// The id of the account to use.
+	AccountID jmap.ID `json:"accountId"`
+
+	// The ids of the Quota objects to return. If nil, then all records are
+	// returned.
+	IDs []jmap.ID `json:"ids"`
+
+	// If not nil, only the properties listed in the array are returned for
+	// each Quota object.
+	Properties []string `json:"properties"`
+}
+
+// QuotaGetResponse contains results of Quota/get method call.
+type QuotaGetResponse struct {
+	// The id of the account used for the call.
+	AccountID jmap.ID `json:"accountId"`
+
+	// A string representing the state on the server for all the data of this
+	// type in the account.
+	State string `json:"state"`
+
+	// An array of the Quota objects requested.
+	List []Quota `json:"list"`
+
+	// This array contains the ids passed to the method for records that do not
+	// exist.
+	NotFound []jmap.ID `json:"notFound"`
+}
+
+// QuotaChangesArgs contains arguments for Quota/changes method call.
+type QuotaChangesArgs struct {
+	// The id of the account to use.
+	AccountID jmap.ID `json:"accountId"`
+
+	// The current state of the client, as returned by Quota/get.
+	SinceState string `json:"sinceState"`
+
+	// The maximum number of ids to return in the response. Zero means no
+	// limit.
+	MaxChanges jmap.UnsignedInt `json:"maxChanges,omitempty"`
+}
+
+// QuotaChangesResponse contains results of Quota/changes method call.
+type QuotaChangesResponse struct {
+	// The id of the account used for the call.
+	AccountID jmap.ID `json:"accountId"`
+
+	// This is the sinceState argument echoed back.
+	OldState string `json:"oldState"`
+
+	// This is the state the client will be in after applying the set of
+	// changes to the old state.
+	NewState string `json:"newState"`
+
+	// If true, the client may call Quota/changes again with the NewState
+	// returned to get further updates.
+	HasMoreChanges bool `json:"hasMoreChanges"`
+
+	// An array of ids for records that have been created since the old state.
+	Created []jmap.ID `json:"created"`
+
+	// An array of ids for records that have been updated since the old state.
+	Updated []jmap.ID `json:"updated"`
+
+	// An array of ids for records that have been destroyed since the old
+	// state.
+	Destroyed []jmap.ID `json:"destroyed"`
+
+	// If only the "used" Quota property has changed since the old state,
+	// this will be the list of properties that may have changed. If the
+	// server is unable to tell if only usage has changed, it is nil.
+	UpdatedProperties []string `json:"updatedProperties"`
+}
+
+// QuotaFilterCondition is the filter condition object for Quota/query.
+// Condition matches Quota only if all non-empty fields match.
+//
+// Conditions can be combined using jmap.FilterOperator.
+//
+// See RFC 9425, section 4.3 for details.
+type QuotaFilterCondition struct {
+	// The name property of the Quota must contain the given string.
+	Name string `json:"name,omitempty"`
+
+	// The scope property of the Quota must be one of the given values.
+	Scope []string `json:"scope,omitempty"`
+
+	// The resourceType property of the Quota must be one of the given values.
+	ResourceType []string `json:"resourceType,omitempty"`
+
+	// The types property of the Quota must contain at least one of the given
+	// data types.
+	Type []string `json:"type,omitempty"`
+}
+
+// Properties that can be used in QuotaComparator.
+const (
+	QuotaSortName = "name"
+	QuotaSortUsed = "used"
+)
+
+// QuotaComparator is the sort comparator for Quota/query. Property must be one
+// of QuotaSort* constants.
+type QuotaComparator struct {
+	jmap.Comparator
+}
+
+// QuotaQueryArgs contains arguments for Quota/query method call.
+type QuotaQueryArgs struct {
+	// The id of the account to use.
+	AccountID jmap.ID `json:"accountId"`
+
+	// Determines the set of Quotas returned in the results. Must be either
+	// QuotaFilterCondition or jmap.FilterOperator. If nil, no filtering is
+	// performed.
+	Filter interface{} `json:"filter,omitempty"`
+
+	// Lists the names of properties to compare between two Quota records, and
+	// how to compare them, to determine which comes first in the sort.
+	Sort []QuotaComparator `json:"sort,omitempty"`
+
+	// The zero-based index of the first id in the full list of results to
+	// return. Negative values are offsets from the end of the list.
+	Position jmap.Int `json:"position,omitempty"`
+
+	// A Quota id. If supplied, the position argument is ignored.
+	Anchor jmap.ID `json:"anchor,omitempty"`
+
+	// The index of the first result to return relative to the index of the
+	// anchor, if an anchor is given.
+	AnchorOffset jmap.Int `json:"anchorOffset,omitempty"`
+
+	// The maximum number of results to return. If zero, no limit is presumed.
+	Limit jmap.UnsignedInt `json:"limit,omitempty"`
+
+	// Does the client wish to know the total number of results in the query?
+	CalculateTotal bool `json:"calculateTotal,omitempty"`
+}
+
+// QuotaQueryResponse contains results of Quota/query method call.
+type QuotaQueryResponse struct {
+	// The id of the account used for the call.
+	AccountID jmap.ID `json:"accountId"`
+
+	// A string encoding the current state of the query on the server.
+	QueryState string `json:"queryState"`
+
+	// This is true if the server supports calling Quota/queryChanges with
+	// these filter/sort parameters.
+	CanCalculateChanges bool `json:"canCalculateChanges"`
+
+	// The zero-based index of the first result in the ids array within the
+	// complete list of query results.
+	Position jmap.UnsignedInt `json:"position"`
+
+	// The list of ids for each Quota in the query results, starting at the
+	// index given by the position argument of this response and continuing
+	// until it hits the end of the results or reaches the limit number of ids.
+	IDs []jmap.ID `json:"ids"`
+
+	// The total number of Quotas in the results (given the filter). Only set
+	// if CalculateTotal was requested.
+	Total jmap.UnsignedInt `json:"total,omitempty"`
+
+	// The limit enforced by the server on the maximum number of results to
+	// return. Only set if the server set a limit or used a different limit
+	// than that given in the request.
+	Limit jmap.UnsignedInt `json:"limit,omitempty"`
+}
+
+// QuotaQueryChangesArgs contains arguments for Quota/queryChanges method call.
+type QuotaQueryChangesArgs struct {
+	// The id of the account to use.
+	AccountID jmap.ID `json:"accountId"`
+
+	// The filter argument that was used with Quota/query.
+	Filter interface{} `json:"filter,omitempty"`
+
+	// The sort argument that was used with Quota/query.
+	Sort []QuotaComparator `json:"sort,omitempty"`
+
+	// The current state of the query in the client, as returned in the
+	// queryState argument of Quota/query response with the same sort/filter.
+	SinceQueryState string `json:"sinceQueryState"`
+
+	// The maximum number of changes to return in the response. If zero, no
+	// limit is presumed.
+	MaxChanges jmap.UnsignedInt `json:"maxChanges,omitempty"`
+
+	// The last (highest-index) id the client currently has cached from the
+	// query results. If supplied, the server may skip changes past this id.
+	UpToID jmap.ID `json:"upToId,omitempty"`
+
+	// Does the client wish to know the total number of results now in the
+	// query?
+	CalculateTotal bool `json:"calculateTotal,omitempty"`
+}
+
+// QueryChanges returns Quota/queryChanges arguments for the query with the
+// same filter and sort.
+func (args QuotaQueryArgs) QueryChanges(sinceQueryState string) QuotaQueryChangesArgs {
+	return QuotaQueryChangesArgs{
+		AccountID:       args.AccountID,
+		Filter:          args.Filter,
+		Sort:            args.Sort,
+		SinceQueryState: sinceQueryState,
+		CalculateTotal:  args.CalculateTotal,
+	}
+}
+
+// QuotaQueryChangesResponse contains results of Quota/queryChanges method
+// call.
+type QuotaQueryChangesResponse struct {
+	// The id of the account used for the call.
+	AccountID jmap.ID `json:"accountId"`
+
+	// This is the sinceQueryState argument echoed back.
+	OldQueryState string `json:"oldQueryState"`
+
+	// This is the state the query will be in after applying the set of changes
+	// to the old state.
+	NewQueryState string `json:"newQueryState"`
+
+	// The total number of Quotas in the results (given the filter). Only set
+	// if CalculateTotal was requested.
+	Total jmap.UnsignedInt `json:"total,omitempty"`
+
+	// The ids for Quotas that have been removed from the results since the old
+	// state, or whose position may have changed.
+	Removed []jmap.ID `json:"removed"`
+
+	// Quotas that have been added to the results since the old state, or whose
+	// position may have changed, sorted by index.
+	Added []jmap.AddedItem `json:"added"`
+}
+
+// Apply updates the cached Quota/query results, see jmap.ApplyQueryChanges for
+// details.
+func (resp QuotaQueryChangesResponse) Apply(ids []jmap.ID) []jmap.ID {
+	return jmap.ApplyQueryChanges(ids, resp.Removed, resp.Added)
+}
+
+func unmarshalQuotaGetResponse(args json.RawMessage) (interface{}, error) {
+	resp := QuotaGetResponse{}
+	err := json.Unmarshal(args, &resp)
+	return resp, err
+}
+
+func unmarshalQuotaChangesResponse(args json.RawMessage) (interface{}, error) {
+	resp := QuotaChangesResponse{}
+	err := json.Unmarshal(args, &resp)
+	return resp, err
+}
+
+func unmarshalQuotaQueryResponse(args json.RawMessage) (interface{}, error) {
+	resp := QuotaQueryResponse{}
+	err := json.Unmarshal(args, &resp)
+	return resp, err
+}
+
+func unmarshalQuotaQueryChangesResponse(args json.RawMessage) (interface{}, error) {
+	resp := QuotaQueryChangesResponse{}
+	err := json.Unmarshal(args, &resp)
+	return resp, err
+}
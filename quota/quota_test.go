@@ -0,0 +1,42 @@
+package quota
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/foxcpp/go-jmap"
+	"gotest.tools/assert"
+	"gotest.tools/assert/cmp"
+)
+
+func TestQuotaGetResponse(t *testing.T) {
+	blob := json.RawMessage(`{
+		"accountId": "A1",
+		"state": "s1",
+		"list": [{
+			"id": "Q1",
+			"resourceType": "octets",
+			"used": 268435456,
+			"hardLimit": 1073741824,
+			"warnLimit": 966367641,
+			"scope": "account",
+			"name": "bob@example.com",
+			"types": ["Mail", "Calendar", "Contact"]
+		}],
+		"notFound": []
+	}`)
+	args, err := ResponseUnmarshallers["Quota/get"](blob)
+	assert.NilError(t, err)
+	resp := args.(QuotaGetResponse)
+	assert.Equal(t, 1, len(resp.List))
+
+	q := resp.List[0]
+	assert.Check(t, cmp.Equal(ResourceOctets, q.ResourceType))
+	assert.Check(t, cmp.Equal(ScopeAccount, q.Scope))
+	assert.Check(t, cmp.Equal(jmap.UnsignedInt(966367641), *q.WarnLimit))
+	assert.Check(t, q.SoftLimit == nil)
+	assert.Check(t, cmp.DeepEqual([]string{"Mail", "Calendar", "Contact"}, q.Types))
+	assert.Check(t, cmp.Equal(0.25, q.UsedFraction()))
+
+	assert.Check(t, cmp.Equal(0.0, Quota{Used: 10}.UsedFraction()))
+}
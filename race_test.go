@@ -0,0 +1,7 @@
+//go:build race
+
+package jmap
+
+// raceEnabled is true if the race detector is enabled. It changes
+// allocation counts, so TestAllocBudgets is skipped.
+const raceEnabled = true
@@ -0,0 +1,144 @@
+package jmap
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// PropertySchemas maps data type names (e.g. "Email") to the lists of their
+// known properties. Entries ending with "*" match any property with the
+// preceding prefix (e.g. "header:*").
+//
+// Packages implementing specific data types provide schemas for them (e.g.
+// mail.PropertySchemas), they can be passed to client.EnableSchemas to make
+// the client validate requests before sending.
+type PropertySchemas map[string][]string
+
+// VendorProperties is the PropertySchemas entry matching vendor-specific
+// properties, i.e. names prefixed with a domain name and ":" (e.g.
+// "example.com:color"), as allowed by JSCalendar and JSContact.
+const VendorProperties = "<vendor>:*"
+
+// PropertyError is returned by PropertySchemas.Validate if the method call
+// references a property not defined in the schema.
+type PropertyError struct {
+	// Name of the method.
+	Method string
+
+	// Call ID of the method call.
+	CallID string
+
+	// Property name as specified in the method call (e.g. full path for
+	// /set update patches).
+	Property string
+}
+
+func (e *PropertyError) Error() string {
+	return fmt.Sprintf("jmap: unknown property %q in %s call (call ID %s)", e.Property, e.Method, e.CallID)
+}
+
+// Known reports whether the property is defined for the data type.
+//
+// If there is no schema for the data type, all properties are considered
+// known.
+func (s PropertySchemas) Known(typeName, property string) bool {
+	props, ok := s[typeName]
+	if !ok {
+		return true
+	}
+	for _, prop := range props {
+		if prop == VendorProperties {
+			if colon := strings.IndexByte(property, ':'); colon > 0 && strings.IndexByte(property[:colon], '.') != -1 {
+				return true
+			}
+			continue
+		}
+		if strings.HasSuffix(prop, "*") {
+			if strings.HasPrefix(property, prop[:len(prop)-1]) {
+				return true
+			}
+			continue
+		}
+		if prop == property {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks property names used in the method call against the
+// schema of the data type it operates on:
+//
+//   - "properties" argument of /get calls,
+//   - top-level properties of objects in "create" argument of /set and
+//     /copy calls,
+//   - the first component of paths in "update" argument of /set calls.
+//
+// Calls of methods for data types without a schema are not checked.
+func (s PropertySchemas) Validate(inv Invocation) error {
+	slash := strings.IndexByte(inv.Name, '/')
+	if slash == -1 {
+		return nil
+	}
+	typeName, method := inv.Name[:slash], inv.Name[slash+1:]
+	if _, ok := s[typeName]; !ok {
+		return nil
+	}
+
+	var args struct {
+		Properties []string                              `json:"properties"`
+		Create     map[string]map[string]json.RawMessage `json:"create"`
+		Update     map[string]map[string]json.RawMessage `json:"update"`
+	}
+	switch method {
+	case "get", "set", "copy":
+	default:
+		return nil
+	}
+	blob, err := json.Marshal(inv.Args)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(blob, &args); err != nil {
+		// Malformed arguments are left for the server to report.
+		return nil
+	}
+
+	check := func(prop, reported string) error {
+		if !s.Known(typeName, prop) {
+			return &PropertyError{Method: inv.Name, CallID: inv.CallID, Property: reported}
+		}
+		return nil
+	}
+
+	if method == "get" {
+		for _, prop := range args.Properties {
+			if err := check(prop, prop); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, obj := range args.Create {
+		for prop := range obj {
+			if err := check(prop, prop); err != nil {
+				return err
+			}
+		}
+	}
+	if method == "set" {
+		for _, patch := range args.Update {
+			for path := range patch {
+				prop := path
+				if slash := strings.IndexByte(path, '/'); slash != -1 {
+					prop = path[:slash]
+				}
+				if err := check(pointerUnescaper.Replace(prop), path); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package jmap
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+	"gotest.tools/assert/cmp"
+)
+
+func TestPropertySchemasValidate(t *testing.T) {
+	schemas := PropertySchemas{
+		"Note": {"id", "title", "tags", "x-*", VendorProperties},
+	}
+
+	test := func(name string, inv Invocation, property string) {
+		t.Run(name, func(t *testing.T) {
+			err := schemas.Validate(inv)
+			if property == "" {
+				assert.NilError(t, err)
+				return
+			}
+			propErr, ok := err.(*PropertyError)
+			assert.Assert(t, ok, "unexpected error: %v", err)
+			assert.Check(t, cmp.Equal(property, propErr.Property))
+			assert.Check(t, cmp.Equal(inv.Name, propErr.Method))
+			assert.Check(t, cmp.Equal(inv.CallID, propErr.CallID))
+		})
+	}
+
+	test("get ok", Invocation{Name: "Note/get", CallID: "0", Args: map[string]interface{}{
+		"properties": []string{"id", "title", "x-color"},
+	}}, "")
+	test("get vendor", Invocation{Name: "Note/get", CallID: "0", Args: map[string]interface{}{
+		"properties": []string{"example.com:color"},
+	}}, "")
+	test("get not vendor", Invocation{Name: "Note/get", CallID: "0", Args: map[string]interface{}{
+		"properties": []string{"color:red"},
+	}}, "color:red")
+	test("get typo", Invocation{Name: "Note/get", CallID: "0", Args: map[string]interface{}{
+		"properties": []string{"id", "tilte"},
+	}}, "tilte")
+	test("set create", Invocation{Name: "Note/set", CallID: "1", Args: map[string]interface{}{
+		"create": map[string]interface{}{"n1": map[string]interface{}{"title": "a", "tag": []string{}}},
+	}}, "tag")
+	test("set update", Invocation{Name: "Note/set", CallID: "2", Args: map[string]interface{}{
+		"update": map[string]interface{}{"N1": PatchObject{"tags/0": nil, "title": "b"}},
+	}}, "")
+	test("set update typo", Invocation{Name: "Note/set", CallID: "2", Args: map[string]interface{}{
+		"update": map[string]interface{}{"N1": PatchObject{"tagz/0": nil}},
+	}}, "tagz/0")
+	test("query not checked", Invocation{Name: "Note/query", CallID: "3", Args: map[string]interface{}{
+		"properties": []string{"bogus"},
+	}}, "")
+	test("unknown type", Invocation{Name: "Other/get", CallID: "4", Args: map[string]interface{}{
+		"properties": []string{"bogus"},
+	}}, "")
+}
@@ -5,7 +5,10 @@ import (
 	"errors"
 )
 
-const CoreCapabilityName = "urn:ietf:params:jmap:core"
+const (
+	CoreCapabilityName      = "urn:ietf:params:jmap:core"
+	WebSocketCapabilityName = "urn:ietf:params:jmap:websocket"
+)
 
 type CollationAlgo string
 
@@ -84,6 +87,18 @@ type CoreCapability struct {
 	CollationAlgorithms []CollationAlgo `json:"collationAlgorithms"`
 }
 
+// WebSocketCapability is the urn:ietf:params:jmap:websocket capability
+// object, as defined in RFC 8887.
+type WebSocketCapability struct {
+	// The wss-URI (see RFC 6455) to use for initiating a JMAP-over-WebSocket
+	// handshake.
+	URL string `json:"url"`
+
+	// This is true if the server supports push notifications over the
+	// WebSocket.
+	SupportsPush bool `json:"supportsPush"`
+}
+
 // An account is a collection of data. A single account may contain an
 // arbitrary set of data types, for example a collection of mail, contacts and
 // calendars.
@@ -125,6 +140,17 @@ type Session struct {
 	// Deserialized urn:ietf:params:jmap:core capability object.
 	CoreCapability CoreCapability `json:"-"`
 
+	// Deserialized session-level urn:ietf:params:jmap:mail capability object,
+	// nil if the server does not support JMAP Mail.
+	//
+	// Limits are defined per-account so most servers report an empty object
+	// here, use EffectiveMailCapability to get limits for a specific account.
+	MailCapability *MailCapability `json:"-"`
+
+	// Deserialized urn:ietf:params:jmap:websocket capability object, nil if
+	// the server does not support JMAP over WebSocket.
+	WebSocketCapability *WebSocketCapability `json:"-"`
+
 	// A map of account id to Account object for each account the user has
 	// access to.
 	Accounts map[ID]Account `json:"accounts"`
@@ -162,6 +188,11 @@ type Session struct {
 	// (e.g. an account has been added or removed) and so they need to refetch
 	// the object.
 	State string `json:"state"`
+
+	// Problems with the session object that were worked around during
+	// decoding, e.g. missing core limits replaced with defaults (see
+	// Limits).
+	Warnings []string `json:"-"`
 }
 
 var ErrNoCoreCapability = errors.New("jmap: urn:ietf:params:jmap:core capability object is missing")
@@ -182,5 +213,22 @@ func (s *Session) UnmarshalJSON(data []byte) error {
 	if err := json.Unmarshal(coreCap, &s.CoreCapability); err != nil {
 		return err
 	}
+	_, s.Warnings = effectiveLimits(s.CoreCapability)
+
+	s.MailCapability = nil
+	if mailCap, ok := raw.Capabilities[MailCapabilityName]; ok {
+		s.MailCapability = new(MailCapability)
+		if err := json.Unmarshal(mailCap, s.MailCapability); err != nil {
+			return err
+		}
+	}
+
+	s.WebSocketCapability = nil
+	if wsCap, ok := raw.Capabilities[WebSocketCapabilityName]; ok {
+		s.WebSocketCapability = new(WebSocketCapability)
+		if err := json.Unmarshal(wsCap, s.WebSocketCapability); err != nil {
+			return err
+		}
+	}
 	return nil
 }
@@ -0,0 +1,73 @@
+package jmap
+
+import "sort"
+
+// SessionAccount is the Account along with its id.
+type SessionAccount struct {
+	ID ID
+	Account
+}
+
+// HasCapability reports whether the account supports methods of the
+// capability.
+func (a Account) HasCapability(uri string) bool {
+	_, ok := a.Capabilities[uri]
+	return ok
+}
+
+// SortedAccounts returns all accounts ordered by id, so the order is stable
+// across Session refetches.
+func (s *Session) SortedAccounts() []SessionAccount {
+	return s.FilterAccounts(nil)
+}
+
+// FilterAccounts returns accounts for which pred returns true, ordered by
+// id. If pred is nil, all accounts are returned.
+func (s *Session) FilterAccounts(pred func(SessionAccount) bool) []SessionAccount {
+	res := make([]SessionAccount, 0, len(s.Accounts))
+	for id, acc := range s.Accounts {
+		sa := SessionAccount{ID: id, Account: acc}
+		if pred == nil || pred(sa) {
+			res = append(res, sa)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].ID < res[j].ID })
+	return res
+}
+
+// AccountsWithCapability returns accounts that support methods of the
+// capability, ordered by id.
+func (s *Session) AccountsWithCapability(uri string) []SessionAccount {
+	return s.FilterAccounts(func(sa SessionAccount) bool {
+		return sa.HasCapability(uri)
+	})
+}
+
+// ReadWriteAccounts returns accounts that are not read-only, ordered by id.
+//
+// Note that individual objects in such accounts (e.g. Mailboxes) may still
+// be read-only for the user.
+func (s *Session) ReadWriteAccounts() []SessionAccount {
+	return s.FilterAccounts(func(sa SessionAccount) bool {
+		return !sa.IsReadOnly
+	})
+}
+
+// AccountByName returns the account with the specified name. If several
+// accounts share the name, the personal one is preferred, then the one with
+// the smallest id.
+func (s *Session) AccountByName(name string) (SessionAccount, bool) {
+	var (
+		res   SessionAccount
+		found bool
+	)
+	for _, sa := range s.FilterAccounts(func(sa SessionAccount) bool {
+		return sa.Name == name
+	}) {
+		if !found || (sa.IsPersonal && !res.IsPersonal) {
+			res = sa
+			found = true
+		}
+	}
+	return res, found
+}
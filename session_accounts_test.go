@@ -0,0 +1,41 @@
+package jmap
+
+import (
+	"encoding/json"
+	"testing"
+
+	"gotest.tools/assert"
+	"gotest.tools/assert/cmp"
+)
+
+func TestSessionAccounts(t *testing.T) {
+	s := Session{}
+	assert.NilError(t, json.Unmarshal([]byte(sessionBlob), &s), "json.Unmarshal")
+
+	ids := func(accs []SessionAccount) []ID {
+		res := make([]ID, 0, len(accs))
+		for _, acc := range accs {
+			res = append(res, acc.ID)
+		}
+		return res
+	}
+
+	assert.Check(t, cmp.DeepEqual([]ID{"A13824", "A97813"}, ids(s.SortedAccounts())))
+	assert.Check(t, cmp.DeepEqual([]ID{"A13824", "A97813"}, ids(s.AccountsWithCapability(MailCapabilityName))))
+	assert.Check(t, cmp.DeepEqual([]ID{"A13824"}, ids(s.AccountsWithCapability("urn:ietf:params:jmap:contacts"))))
+	assert.Check(t, cmp.DeepEqual([]ID{}, ids(s.AccountsWithCapability("https://example.com/apis/foobar"))))
+	assert.Check(t, cmp.DeepEqual([]ID{"A13824"}, ids(s.ReadWriteAccounts())))
+
+	acc, ok := s.AccountByName("jane@example.com")
+	assert.Check(t, ok)
+	assert.Check(t, cmp.Equal(ID("A97813"), acc.ID))
+	_, ok = s.AccountByName("nobody@example.com")
+	assert.Check(t, !ok)
+
+	t.Run("duplicate names", func(t *testing.T) {
+		s.Accounts["A00001"] = Account{Name: "john@example.com"}
+		acc, ok := s.AccountByName("john@example.com")
+		assert.Check(t, ok)
+		assert.Check(t, cmp.Equal(ID("A13824"), acc.ID), "personal account should be preferred")
+	})
+}
@@ -0,0 +1,51 @@
+package jmap
+
+import "fmt"
+
+// DefaultCoreLimits contains limits used in place of ones missing from the
+// urn:ietf:params:jmap:core capability object or reported as zero.
+//
+// Values are the minimums RFC 8620, section 2 recommends servers to support.
+var DefaultCoreLimits = CoreCapability{
+	MaxSizeUpload:         50000000,
+	MaxConcurrentUpload:   4,
+	MaxSizeRequest:        10000000,
+	MaxConcurrentRequests: 4,
+	MaxCallsInRequest:     16,
+	MaxObjectsInGet:       500,
+	MaxObjectsInSet:       500,
+}
+
+// Limits returns the core capability limits that should be used for
+// requests. Zero limits are replaced by corresponding values from
+// DefaultCoreLimits, see Session.Warnings for the list of replaced ones.
+//
+// All code that respects server limits should use Limits instead of reading
+// CoreCapability directly.
+func (s *Session) Limits() CoreCapability {
+	res, _ := effectiveLimits(s.CoreCapability)
+	return res
+}
+
+func effectiveLimits(cc CoreCapability) (CoreCapability, []string) {
+	var missing []string
+	for _, l := range []struct {
+		name string
+		val  *UnsignedInt
+		def  UnsignedInt
+	}{
+		{"maxSizeUpload", &cc.MaxSizeUpload, DefaultCoreLimits.MaxSizeUpload},
+		{"maxConcurrentUpload", &cc.MaxConcurrentUpload, DefaultCoreLimits.MaxConcurrentUpload},
+		{"maxSizeRequest", &cc.MaxSizeRequest, DefaultCoreLimits.MaxSizeRequest},
+		{"maxConcurrentRequests", &cc.MaxConcurrentRequests, DefaultCoreLimits.MaxConcurrentRequests},
+		{"maxCallsInRequest", &cc.MaxCallsInRequest, DefaultCoreLimits.MaxCallsInRequest},
+		{"maxObjectsInGet", &cc.MaxObjectsInGet, DefaultCoreLimits.MaxObjectsInGet},
+		{"maxObjectsInSet", &cc.MaxObjectsInSet, DefaultCoreLimits.MaxObjectsInSet},
+	} {
+		if *l.val == 0 {
+			*l.val = l.def
+			missing = append(missing, fmt.Sprintf("jmap: server did not report %s limit, using %d", l.name, l.def))
+		}
+	}
+	return cc, missing
+}
@@ -0,0 +1,34 @@
+package jmap
+
+import (
+	"encoding/json"
+	"testing"
+
+	"gotest.tools/assert"
+	"gotest.tools/assert/cmp"
+)
+
+func TestSessionLimits(t *testing.T) {
+	s := Session{}
+	assert.NilError(t, json.Unmarshal([]byte(sessionBlob), &s), "json.Unmarshal")
+
+	// The example session misspells maxConcurrentRequests.
+	assert.Check(t, cmp.DeepEqual([]string{
+		"jmap: server did not report maxConcurrentRequests limit, using 4",
+	}, s.Warnings))
+	limits := s.Limits()
+	assert.Check(t, cmp.Equal(UnsignedInt(4), limits.MaxConcurrentRequests))
+	assert.Check(t, cmp.Equal(UnsignedInt(32), limits.MaxCallsInRequest))
+	assert.Check(t, cmp.Equal(UnsignedInt(256), limits.MaxObjectsInGet))
+	assert.Check(t, cmp.Equal(UnsignedInt(0), s.CoreCapability.MaxConcurrentRequests))
+
+	s = Session{}
+	assert.NilError(t, json.Unmarshal([]byte(`{
+		"capabilities": {"urn:ietf:params:jmap:core": {}},
+		"accounts": {}
+	}`), &s))
+	assert.Check(t, cmp.Len(s.Warnings, 7))
+	limits = s.Limits()
+	limits.CollationAlgorithms = nil
+	assert.Check(t, cmp.DeepEqual(DefaultCoreLimits, limits))
+}
@@ -26,6 +26,10 @@ var sessionBlob = `{
     },
     "urn:ietf:params:jmap:mail": {},
     "urn:ietf:params:jmap:contacts": {},
+    "urn:ietf:params:jmap:websocket": {
+      "url": "wss://jmap.example.com/ws/",
+      "supportsPush": true
+    },
     "https://example.com/apis/foobar": {
       "maxFoosFinangled": 42
     }
@@ -74,6 +78,11 @@ func TestSessionUnmarshal(t *testing.T) {
 
 	assert.Check(t, cmp.Equal(UnsignedInt(50000000), s.CoreCapability.MaxSizeUpload))
 	assert.Check(t, cmp.Equal("john@example.com", s.Accounts["A13824"].Name))
+	assert.Check(t, cmp.DeepEqual(&MailCapability{}, s.MailCapability))
+	assert.Check(t, cmp.DeepEqual(&WebSocketCapability{
+		URL:          "wss://jmap.example.com/ws/",
+		SupportsPush: true,
+	}, s.WebSocketCapability))
 }
 
 func TestSessionMarshal(t *testing.T) {
@@ -0,0 +1,227 @@
+package jmap
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// SetOp is the kind of operation performed on a single record by /set
+// method call.
+type SetOp string
+
+const (
+	SetCreate  SetOp = "create"
+	SetUpdate  SetOp = "update"
+	SetDestroy SetOp = "destroy"
+)
+
+// SetResult describes the outcome of a single create, update or destroy
+// operation of /set method call.
+type SetResult struct {
+	Op SetOp
+
+	// Creation id for SetCreate, record id otherwise.
+	Key ID
+
+	// Id of the record. For SetCreate, it is the id assigned by the server
+	// or empty if creation failed.
+	ID ID
+
+	// For SetCreate, the object submitted by the caller with properties set
+	// by the server merged in.
+	//
+	// For SetUpdate, the object containing properties changed by the server
+	// in a way not explicitly requested by the patch, or nil if none.
+	Object json.RawMessage
+
+	// The patch submitted by the caller, set for SetUpdate.
+	Patch PatchObject
+
+	// Error returned by the server, nil if the operation succeeded.
+	Err *SetError
+}
+
+// Decode unmarshals Object into v.
+func (r SetResult) Decode(v interface{}) error {
+	if r.Object == nil {
+		return nil
+	}
+	return json.Unmarshal(r.Object, v)
+}
+
+type setArgsParts struct {
+	Create  map[ID]json.RawMessage `json:"create"`
+	Update  map[ID]PatchObject     `json:"update"`
+	Destroy []ID                   `json:"destroy"`
+}
+
+type setResponseParts struct {
+	Created      map[ID]json.RawMessage `json:"created"`
+	Updated      map[ID]json.RawMessage `json:"updated"`
+	Destroyed    []ID                   `json:"destroyed"`
+	NotCreated   map[ID]SetError        `json:"notCreated"`
+	NotUpdated   map[ID]SetError        `json:"notUpdated"`
+	NotDestroyed map[ID]SetError        `json:"notDestroyed"`
+}
+
+// CorrelateSet joins the results of /set method call back to the objects
+// submitted by the caller.
+//
+// args and resp are /set arguments and response objects, e.g.
+// mail.EmailSetArgs and mail.EmailSetResponse. Any types following the
+// structure defined in section 5.3 of JMAP Core specification can be used.
+//
+// Results are ordered as follows: creations, updates, then destructions.
+// Creations and updates are sorted by key, destructions follow the order of
+// args. Records present only in resp (e.g. when destroy is passed using a
+// result reference) are included too.
+//
+// Error is returned if args or resp can't be converted or if the server
+// returned no result for some of the submitted records.
+func CorrelateSet(args, resp interface{}) ([]SetResult, error) {
+	var (
+		a setArgsParts
+		r setResponseParts
+	)
+	if err := convertParts(args, &a); err != nil {
+		return nil, fmt.Errorf("jmap: /set arguments: %v", err)
+	}
+	if err := convertParts(resp, &r); err != nil {
+		return nil, fmt.Errorf("jmap: /set response: %v", err)
+	}
+
+	var res []SetResult
+
+	for _, key := range sortedKeys(a.Create, r.Created, r.NotCreated) {
+		result := SetResult{Op: SetCreate, Key: key}
+		if setErr, ok := r.NotCreated[key]; ok {
+			result.Err = &setErr
+		} else if server, ok := r.Created[key]; ok {
+			obj, id, err := mergeObjects(a.Create[key], server)
+			if err != nil {
+				return nil, fmt.Errorf("jmap: created %v: %v", key, err)
+			}
+			result.ID = id
+			result.Object = obj
+		} else {
+			return nil, fmt.Errorf("jmap: /set response has no result for created %v", key)
+		}
+		res = append(res, result)
+	}
+
+	updateKeys := make(map[ID]json.RawMessage, len(a.Update))
+	for key := range a.Update {
+		updateKeys[key] = nil
+	}
+	for _, key := range sortedKeys(updateKeys, r.Updated, r.NotUpdated) {
+		result := SetResult{Op: SetUpdate, Key: key, ID: key, Patch: a.Update[key]}
+		if setErr, ok := r.NotUpdated[key]; ok {
+			result.Err = &setErr
+		} else if server, ok := r.Updated[key]; ok {
+			if string(server) != "null" {
+				result.Object = server
+			}
+		} else {
+			return nil, fmt.Errorf("jmap: /set response has no result for updated %v", key)
+		}
+		res = append(res, result)
+	}
+
+	destroyed := make(map[ID]bool, len(r.Destroyed))
+	for _, id := range r.Destroyed {
+		destroyed[id] = true
+	}
+	destroyKeys := append([]ID(nil), a.Destroy...)
+	seen := make(map[ID]bool, len(a.Destroy))
+	for _, id := range a.Destroy {
+		seen[id] = true
+	}
+	for _, id := range r.Destroyed {
+		if !seen[id] {
+			destroyKeys = append(destroyKeys, id)
+			seen[id] = true
+		}
+	}
+	notDestroyed := make([]ID, 0, len(r.NotDestroyed))
+	for id := range r.NotDestroyed {
+		if !seen[id] {
+			notDestroyed = append(notDestroyed, id)
+		}
+	}
+	sort.Slice(notDestroyed, func(i, j int) bool { return notDestroyed[i] < notDestroyed[j] })
+	destroyKeys = append(destroyKeys, notDestroyed...)
+	for _, key := range destroyKeys {
+		result := SetResult{Op: SetDestroy, Key: key, ID: key}
+		if setErr, ok := r.NotDestroyed[key]; ok {
+			result.Err = &setErr
+		} else if !destroyed[key] {
+			return nil, fmt.Errorf("jmap: /set response has no result for destroyed %v", key)
+		}
+		res = append(res, result)
+	}
+
+	return res, nil
+}
+
+func convertParts(v interface{}, out interface{}) error {
+	blob, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(blob, out)
+}
+
+func sortedKeys(submitted, ok map[ID]json.RawMessage, failed map[ID]SetError) []ID {
+	set := make(map[ID]struct{}, len(submitted))
+	for key := range submitted {
+		set[key] = struct{}{}
+	}
+	for key := range ok {
+		set[key] = struct{}{}
+	}
+	for key := range failed {
+		set[key] = struct{}{}
+	}
+	keys := make([]ID, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	return keys
+}
+
+// mergeObjects overlays properties from server over submitted object and
+// returns the result together with the value of the "id" property.
+func mergeObjects(submitted, server json.RawMessage) (json.RawMessage, ID, error) {
+	props := map[string]json.RawMessage{}
+	if len(submitted) != 0 && string(submitted) != "null" {
+		if err := json.Unmarshal(submitted, &props); err != nil {
+			return nil, "", err
+		}
+	}
+	if len(server) != 0 && string(server) != "null" {
+		serverProps := map[string]json.RawMessage{}
+		if err := json.Unmarshal(server, &serverProps); err != nil {
+			return nil, "", err
+		}
+		for k, v := range serverProps {
+			props[k] = v
+		}
+	}
+
+	var id ID
+	if rawID, ok := props["id"]; ok {
+		var s string
+		if err := json.Unmarshal(rawID, &s); err != nil {
+			return nil, "", err
+		}
+		id = ID(s)
+	}
+
+	blob, err := json.Marshal(props)
+	if err != nil {
+		return nil, "", err
+	}
+	return blob, id, nil
+}
@@ -0,0 +1,89 @@
+package jmap
+
+import (
+	"encoding/json"
+	"testing"
+
+	"gotest.tools/assert"
+	"gotest.tools/assert/cmp"
+)
+
+func TestCorrelateSet(t *testing.T) {
+	type note struct {
+		ID    ID     `json:"id,omitempty"`
+		Title string `json:"title,omitempty"`
+		Size  int    `json:"size,omitempty"`
+	}
+	args := struct {
+		AccountID ID                 `json:"accountId"`
+		Create    map[ID]note        `json:"create"`
+		Update    map[ID]PatchObject `json:"update"`
+		Destroy   []ID               `json:"destroy"`
+	}{
+		AccountID: "A1",
+		Create: map[ID]note{
+			"n1": {Title: "first"},
+			"n2": {Title: "second"},
+		},
+		Update: map[ID]PatchObject{
+			"N3": {"title": "third"},
+			"N4": {"title": ""},
+		},
+		Destroy: []ID{"N6", "N5"},
+	}
+	var resp struct {
+		Created      map[ID]note     `json:"created"`
+		Updated      map[ID]*note    `json:"updated"`
+		Destroyed    []ID            `json:"destroyed"`
+		NotCreated   map[ID]SetError `json:"notCreated"`
+		NotUpdated   map[ID]SetError `json:"notUpdated"`
+		NotDestroyed map[ID]SetError `json:"notDestroyed"`
+	}
+	err := json.Unmarshal([]byte(`{
+		"created": {"n1": {"id": "N1", "size": 10}},
+		"updated": {"N3": null},
+		"destroyed": ["N5"],
+		"notCreated": {"n2": {"type": "overQuota"}},
+		"notUpdated": {"N4": {"type": "invalidProperties", "properties": ["title"]}},
+		"notDestroyed": {"N6": {"type": "notFound"}}
+	}`), &resp)
+	assert.NilError(t, err)
+
+	results, err := CorrelateSet(args, resp)
+	assert.NilError(t, err)
+	assert.Assert(t, cmp.Len(results, 6))
+
+	assert.Check(t, cmp.Equal(SetCreate, results[0].Op))
+	assert.Check(t, cmp.Equal(ID("n1"), results[0].Key))
+	assert.Check(t, cmp.Equal(ID("N1"), results[0].ID))
+	assert.Check(t, results[0].Err == nil)
+	var created note
+	assert.NilError(t, results[0].Decode(&created))
+	assert.Check(t, cmp.DeepEqual(note{ID: "N1", Title: "first", Size: 10}, created))
+
+	assert.Check(t, cmp.Equal(ID("n2"), results[1].Key))
+	assert.Assert(t, results[1].Err != nil)
+	assert.Check(t, cmp.Equal(CodeOverQuota, results[1].Err.Type))
+
+	assert.Check(t, cmp.Equal(SetUpdate, results[2].Op))
+	assert.Check(t, cmp.Equal(ID("N3"), results[2].ID))
+	assert.Check(t, results[2].Err == nil)
+	assert.Check(t, results[2].Object == nil)
+	assert.Check(t, cmp.DeepEqual(PatchObject{"title": "third"}, results[2].Patch))
+
+	assert.Assert(t, results[3].Err != nil)
+	assert.Check(t, cmp.DeepEqual([]string{"title"}, results[3].Err.Properties))
+
+	assert.Check(t, cmp.Equal(SetDestroy, results[4].Op))
+	assert.Check(t, cmp.Equal(ID("N6"), results[4].Key))
+	assert.Assert(t, results[4].Err != nil)
+	assert.Check(t, cmp.Equal(CodeNotFound, results[4].Err.Type))
+	assert.Check(t, cmp.Equal(ID("N5"), results[5].Key))
+	assert.Check(t, results[5].Err == nil)
+
+	t.Run("missing result", func(t *testing.T) {
+		resp.Destroyed = nil
+		_, err := CorrelateSet(args, resp)
+		assert.Check(t, cmp.ErrorContains(err, "no result for destroyed N5"))
+	})
+}
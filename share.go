@@ -0,0 +1,90 @@
+package jmap
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+var pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+func shareWithPath(principal ID) string {
+	return "shareWith/" + pointerEscaper.Replace(string(principal))
+}
+
+// ShareWithPatch returns the patch that grants the rights to the principal
+// in the shareWith property of a shared object (e.g. Mailbox or Calendar),
+// replacing rights the principal had before. rights should be the rights
+// structure of the data type, e.g. mail.MailboxRights.
+//
+// See RFC 9670 for details.
+func ShareWithPatch(principal ID, rights interface{}) PatchObject {
+	return PatchObject{shareWithPath(principal): rights}
+}
+
+// UnsharePatch returns the patch that removes the principal from the
+// shareWith property, revoking all its rights.
+func UnsharePatch(principal ID) PatchObject {
+	return PatchObject{shareWithPath(principal): nil}
+}
+
+// ShareWithRightsPatch returns the patch that changes only the listed rights
+// of the principal (e.g. {"mayWriteAll": false}) leaving other rights
+// intact. The principal must already be present in the shareWith property,
+// otherwise the server rejects the patch.
+func ShareWithRightsPatch(principal ID, rights map[string]bool) PatchObject {
+	patch := make(PatchObject, len(rights))
+	prefix := shareWithPath(principal) + "/"
+	for right, value := range rights {
+		patch[prefix+pointerEscaper.Replace(right)] = value
+	}
+	return patch
+}
+
+// ShareWithDiffPatch returns the patch that changes the shareWith property
+// from current to desired. Both must be maps of principal id to the rights
+// structure of the data type (e.g. map[ID]mail.MailboxRights) or nil.
+//
+// Principals missing in desired are removed, new principals get their full
+// rights object and for other principals only changed rights are patched,
+// so concurrent changes of other rights are preserved.
+func ShareWithDiffPatch(current, desired interface{}) (PatchObject, error) {
+	var currentRights, desiredRights map[ID]map[string]bool
+	if err := convertRights(current, &currentRights); err != nil {
+		return nil, err
+	}
+	if err := convertRights(desired, &desiredRights); err != nil {
+		return nil, err
+	}
+
+	patch := PatchObject{}
+	for principal := range currentRights {
+		if _, ok := desiredRights[principal]; !ok {
+			patch[shareWithPath(principal)] = nil
+		}
+	}
+	for principal, rights := range desiredRights {
+		old, ok := currentRights[principal]
+		if !ok {
+			patch[shareWithPath(principal)] = rights
+			continue
+		}
+		changed := map[string]bool{}
+		for right, value := range rights {
+			if old[right] != value {
+				changed[right] = value
+			}
+		}
+		for right, value := range ShareWithRightsPatch(principal, changed) {
+			patch[right] = value
+		}
+	}
+	return patch, nil
+}
+
+func convertRights(v interface{}, out *map[ID]map[string]bool) error {
+	blob, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(blob, out)
+}
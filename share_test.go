@@ -0,0 +1,62 @@
+package jmap
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+	"gotest.tools/assert/cmp"
+)
+
+type testRights struct {
+	MayReadItems bool `json:"mayReadItems"`
+	MayAddItems  bool `json:"mayAddItems"`
+	MayAdmin     bool `json:"mayAdmin"`
+}
+
+func TestShareWithPatch(t *testing.T) {
+	assert.Check(t, cmp.DeepEqual(PatchObject{
+		"shareWith/p~11": testRights{MayReadItems: true},
+	}, ShareWithPatch("p/1", testRights{MayReadItems: true})))
+	assert.Check(t, cmp.DeepEqual(PatchObject{"shareWith/p1": nil}, UnsharePatch("p1")))
+	assert.Check(t, cmp.DeepEqual(PatchObject{
+		"shareWith/p1/mayAdmin": false,
+	}, ShareWithRightsPatch("p1", map[string]bool{"mayAdmin": false})))
+}
+
+func TestShareWithDiffPatch(t *testing.T) {
+	current := map[ID]testRights{
+		"p1": {MayReadItems: true},
+		"p2": {MayReadItems: true, MayAddItems: true},
+		"p3": {MayReadItems: true},
+	}
+	desired := map[ID]testRights{
+		"p2": {MayReadItems: true, MayAdmin: true},
+		"p3": {MayReadItems: true},
+		"p4": {MayReadItems: true, MayAddItems: true},
+	}
+	patch, err := ShareWithDiffPatch(current, desired)
+	assert.NilError(t, err)
+	assert.Check(t, cmp.DeepEqual(PatchObject{
+		"shareWith/p1":             nil,
+		"shareWith/p2/mayAddItems": false,
+		"shareWith/p2/mayAdmin":    true,
+		"shareWith/p4": map[string]bool{
+			"mayReadItems": true,
+			"mayAddItems":  true,
+			"mayAdmin":     false,
+		},
+	}, patch))
+
+	// Patch applies cleanly to the object with current rights.
+	obj := map[string]interface{}{"shareWith": current}
+	assert.NilError(t, ApplyPatch(&obj, patch))
+	var result struct {
+		ShareWith map[ID]testRights `json:"shareWith"`
+	}
+	assert.NilError(t, ApplyPatch(&result, PatchObject{"shareWith": obj["shareWith"]}))
+	assert.Check(t, cmp.DeepEqual(desired, result.ShareWith))
+
+	patch, err = ShareWithDiffPatch(nil, nil)
+	assert.NilError(t, err)
+	assert.Check(t, cmp.Len(patch, 0))
+}
@@ -0,0 +1,167 @@
+package tasks
+
+import (
+	"github.com/foxcpp/go-jmap"
+	"github.com/foxcpp/go-jmap/calendar"
+)
+
+// Values of Task.Progress.
+const (
+	ProgressNeedsAction = "needs-action"
+	ProgressInProcess   = "in-process"
+	ProgressCompleted   = "completed"
+	ProgressFailed      = "failed"
+	ProgressCancelled   = "cancelled"
+)
+
+// Task is the JSCalendar Task object extended with JMAP-specific properties.
+//
+// Only the commonly used JSCalendar properties are represented. Types shared
+// with Events (locations, participants, alerts, recurrence rules, etc.) are
+// defined in the calendar package.
+//
+// See RFC 8984, section 5.2 and draft-ietf-jmap-tasks, section 4 for
+// details.
+type Task struct {
+	// The id of the Task. JMAP-specific.
+	ID jmap.ID `json:"id,omitempty"`
+
+	// The id of the TaskList this Task belongs to. JMAP-specific.
+	TaskListID jmap.ID `json:"taskListId,omitempty"`
+
+	// If true, the Task is not yet finished and scheduling messages are not
+	// sent for it. JMAP-specific.
+	IsDraft bool `json:"isDraft,omitempty"`
+
+	// Start and due date of the Task in UTC, as calculated by the server.
+	// JMAP-specific, only returned if requested explicitly.
+	UTCStart *jmap.UTCDate `json:"utcStart,omitempty"`
+	UTCDue   *jmap.UTCDate `json:"utcDue,omitempty"`
+
+	// Must be "Task" if set.
+	Type string `json:"@type,omitempty"`
+
+	// A globally unique identifier used to associate the object as the same
+	// across different systems, task lists and views.
+	UID string `json:"uid,omitempty"`
+
+	// Other objects (keyed by their uids) this Task is related to.
+	RelatedTo map[string]calendar.Relation `json:"relatedTo,omitempty"`
+
+	// The identifier for the product that last updated the object.
+	ProdID string `json:"prodId,omitempty"`
+
+	// The date and time this object was initially created.
+	Created *jmap.UTCDate `json:"created,omitempty"`
+
+	// The date and time the data in this object was last modified.
+	Updated *jmap.UTCDate `json:"updated,omitempty"`
+
+	// Initially zero, incremented by one every time a change is made to the
+	// object by the organizer.
+	Sequence jmap.UnsignedInt `json:"sequence,omitempty"`
+
+	// A short summary of the object.
+	Title string `json:"title,omitempty"`
+
+	// A longer-form text description of the object.
+	Description string `json:"description,omitempty"`
+
+	// The media type of Description, "text/plain" if empty.
+	DescriptionContentType string `json:"descriptionContentType,omitempty"`
+
+	// Indicates that the time is not important to display to the user.
+	ShowWithoutTime bool `json:"showWithoutTime,omitempty"`
+
+	// The locations associated with the Task.
+	Locations map[string]calendar.Location `json:"locations,omitempty"`
+
+	// The virtual locations associated with the Task.
+	VirtualLocations map[string]calendar.VirtualLocation `json:"virtualLocations,omitempty"`
+
+	// Links to external resources related to the object.
+	Links map[string]calendar.Link `json:"links,omitempty"`
+
+	// The language tag (RFC 5646) of the language used for text values.
+	Locale string `json:"locale,omitempty"`
+
+	// The set of free-text keywords, also known as tags.
+	Keywords map[string]bool `json:"keywords,omitempty"`
+
+	// The set of categories the object belongs to, as URIs.
+	Categories map[string]bool `json:"categories,omitempty"`
+
+	// A color to be used when displaying the object, as CSS color value.
+	Color string `json:"color,omitempty"`
+
+	// Set for occurrences of recurring Tasks, the start (or due date if
+	// there is no start) of the occurrence in the time zone of the base
+	// Task.
+	RecurrenceID calendar.LocalDateTime `json:"recurrenceId,omitempty"`
+
+	// The time zone of the RecurrenceID.
+	RecurrenceIDTimeZone string `json:"recurrenceIdTimeZone,omitempty"`
+
+	// The rules defining occurrences of the recurring Task.
+	RecurrenceRules []calendar.RecurrenceRule `json:"recurrenceRules,omitempty"`
+
+	// The rules defining occurrences excluded from the recurrence.
+	ExcludedRecurrenceRules []calendar.RecurrenceRule `json:"excludedRecurrenceRules,omitempty"`
+
+	// Patches to apply to occurrences, keyed by their recurrence ids.
+	RecurrenceOverrides map[calendar.LocalDateTime]jmap.PatchObject `json:"recurrenceOverrides,omitempty"`
+
+	// Set to true in recurrenceOverrides patches to exclude the occurrence.
+	Excluded bool `json:"excluded,omitempty"`
+
+	// The priority of the Task, 1 is the highest and 9 is the lowest. Zero
+	// means undefined.
+	Priority int `json:"priority,omitempty"`
+
+	// Level of privacy of the Task, one of calendar.Privacy* constants.
+	// "public" if empty.
+	Privacy string `json:"privacy,omitempty"`
+
+	// Methods (e.g. "imip") and URIs to send replies to the organizer to.
+	ReplyTo map[string]string `json:"replyTo,omitempty"`
+
+	// The email address of the user that made the last change to the
+	// object.
+	SentBy string `json:"sentBy,omitempty"`
+
+	// The participants of the Task, e.g. assignees.
+	Participants map[string]calendar.Participant `json:"participants,omitempty"`
+
+	// If true, TaskList default alerts are used instead of Alerts.
+	UseDefaultAlerts bool `json:"useDefaultAlerts,omitempty"`
+
+	// Alerts to display or send to the user.
+	Alerts map[string]calendar.Alert `json:"alerts,omitempty"`
+
+	// The IANA time zone name for Start and Due. If empty, the Task is in
+	// floating time.
+	TimeZone string `json:"timeZone,omitempty"`
+
+	// The date-time the Task should be due in its TimeZone.
+	Due calendar.LocalDateTime `json:"due,omitempty"`
+
+	// The date-time the Task should start in its TimeZone.
+	Start calendar.LocalDateTime `json:"start,omitempty"`
+
+	// The estimated amount of time it takes to complete the Task.
+	EstimatedDuration calendar.Duration `json:"estimatedDuration,omitempty"`
+
+	// The percent completion of the Task overall, from 0 to 100.
+	PercentComplete jmap.UnsignedInt `json:"percentComplete,omitempty"`
+
+	// The progress of the Task, one of Progress* constants. If empty, it is
+	// derived from progress of participants.
+	Progress string `json:"progress,omitempty"`
+
+	// The time the Progress was last updated.
+	ProgressUpdated *jmap.UTCDate `json:"progressUpdated,omitempty"`
+
+	// The workflow status of the Task, if the TaskList defines
+	// WorkflowStatuses it must be one of them. JMAP-specific.
+	WorkflowStatus string `json:"workflowStatus,omitempty"`
+}
@@ -0,0 +1,367 @@
+package tasks
+
+import (
+	"encoding/json"
+
+	"github.com/foxcpp/go-jmap"
+)
+
+// TaskGetArgs contains arguments for Task/get method call.
+type TaskGetArgs struct {
+	// The id of the account to use.
+	AccountID jmap.ID `json:"accountId"`
+
+	// The ids of the Task objects to return. If nil, then all records are
+	// returned.
+	IDs []jmap.ID `json:"ids"`
+
+	// If not nil, only the properties listed in the array are returned for
+	// each Task object.
+	Properties []string `json:"properties"`
+}
+
+// TaskGetResponse contains results of Task/get method call.
+type TaskGetResponse struct {
+	// The id of the account used for the call.
+	AccountID jmap.ID `json:"accountId"`
+
+	// A string representing the state on the server for all the data of this
+	// type in the account.
+	State string `json:"state"`
+
+	// An array of the Task objects requested.
+	List []Task `json:"list"`
+
+	// This array contains the ids passed to the method for records that do not
+	// exist.
+	NotFound []jmap.ID `json:"notFound"`
+}
+
+// TaskChangesArgs contains arguments for Task/changes method call.
+type TaskChangesArgs struct {
+	// The id of the account to use.
+	AccountID jmap.ID `json:"accountId"`
+
+	// The current state of the client, as returned by Task/get.
+	SinceState string `json:"sinceState"`
+
+	// The maximum number of ids to return in the response. Zero means no
+	// limit.
+	MaxChanges jmap.UnsignedInt `json:"maxChanges,omitempty"`
+}
+
+// TaskChangesResponse contains results of Task/changes method call.
+type TaskChangesResponse struct {
+	// The id of the account used for the call.
+	AccountID jmap.ID `json:"accountId"`
+
+	// This is the sinceState argument echoed back.
+	OldState string `json:"oldState"`
+
+	// This is the state the client will be in after applying the set of
+	// changes to the old state.
+	NewState string `json:"newState"`
+
+	// If true, the client may call Task/changes again with the NewState
+	// returned to get further updates.
+	HasMoreChanges bool `json:"hasMoreChanges"`
+
+	// An array of ids for records that have been created since the old state.
+	Created []jmap.ID `json:"created"`
+
+	// An array of ids for records that have been updated since the old state.
+	Updated []jmap.ID `json:"updated"`
+
+	// An array of ids for records that have been destroyed since the old
+	// state.
+	Destroyed []jmap.ID `json:"destroyed"`
+}
+
+// TaskFilterCondition is the filter condition object for Task/query. Condition
+// matches Task only if all non-empty fields match. Text fields match if the
+// corresponding property contains the given string.
+//
+// Conditions can be combined using jmap.FilterOperator.
+//
+// See draft-ietf-jmap-tasks, section 4.5 for details.
+type TaskFilterCondition struct {
+	// The Task must be in one of these TaskLists.
+	InTaskLists []jmap.ID `json:"inTaskLists,omitempty"`
+
+	// The due date of the Task must be before or after this date-time.
+	Before *jmap.UTCDate `json:"before,omitempty"`
+	After  *jmap.UTCDate `json:"after,omitempty"`
+
+	// Looks for the text in title, description, locations and participants of
+	// the Task.
+	Text string `json:"text,omitempty"`
+
+	// Looks for the text in the corresponding properties of the Task.
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	Location    string `json:"location,omitempty"`
+	Owner       string `json:"owner,omitempty"`
+	Attendee    string `json:"attendee,omitempty"`
+
+	// The Task must have the keyword.
+	HasKeyword string `json:"hasKeyword,omitempty"`
+
+	// The Task must be in the category.
+	Category string `json:"category,omitempty"`
+
+	// The progress property of the Task must be equal to the given value.
+	Progress string `json:"progress,omitempty"`
+
+	// The workflowStatus property of the Task must be equal to the given
+	// value.
+	WorkflowStatus string `json:"workflowStatus,omitempty"`
+
+	// The uid property of the Task must be equal to the given value.
+	UID string `json:"uid,omitempty"`
+}
+
+// Properties that can be used in TaskComparator.
+const (
+	TaskSortStart           = "start"
+	TaskSortDue             = "due"
+	TaskSortCreated         = "created"
+	TaskSortUpdated         = "updated"
+	TaskSortPriority        = "priority"
+	TaskSortPercentComplete = "percentComplete"
+	TaskSortTitle           = "title"
+)
+
+// TaskComparator is the sort comparator for Task/query. Property must be one
+// of TaskSort* constants.
+type TaskComparator struct {
+	jmap.Comparator
+}
+
+// TaskQueryArgs contains arguments for Task/query method call.
+type TaskQueryArgs struct {
+	// The id of the account to use.
+	AccountID jmap.ID `json:"accountId"`
+
+	// Determines the set of Tasks returned in the results. Must be either
+	// TaskFilterCondition or jmap.FilterOperator. If nil, no filtering is
+	// performed.
+	Filter interface{} `json:"filter,omitempty"`
+
+	// Lists the names of properties to compare between two Task records, and
+	// how to compare them, to determine which comes first in the sort.
+	Sort []TaskComparator `json:"sort,omitempty"`
+
+	// The zero-based index of the first id in the full list of results to
+	// return. Negative values are offsets from the end of the list.
+	Position jmap.Int `json:"position,omitempty"`
+
+	// A Task id. If supplied, the position argument is ignored.
+	Anchor jmap.ID `json:"anchor,omitempty"`
+
+	// The index of the first result to return relative to the index of the
+	// anchor, if an anchor is given.
+	AnchorOffset jmap.Int `json:"anchorOffset,omitempty"`
+
+	// The maximum number of results to return. If zero, no limit is presumed.
+	Limit jmap.UnsignedInt `json:"limit,omitempty"`
+
+	// Does the client wish to know the total number of results in the query?
+	CalculateTotal bool `json:"calculateTotal,omitempty"`
+
+	// The time zone to use for floating Tasks when evaluating the filter. If
+	// empty, the time zone of the TaskList is used.
+	TimeZone string `json:"timeZone,omitempty"`
+}
+
+// TaskQueryResponse contains results of Task/query method call.
+type TaskQueryResponse struct {
+	// The id of the account used for the call.
+	AccountID jmap.ID `json:"accountId"`
+
+	// A string encoding the current state of the query on the server.
+	QueryState string `json:"queryState"`
+
+	// This is true if the server supports calling Task/queryChanges with these
+	// filter/sort parameters.
+	CanCalculateChanges bool `json:"canCalculateChanges"`
+
+	// The zero-based index of the first result in the ids array within the
+	// complete list of query results.
+	Position jmap.UnsignedInt `json:"position"`
+
+	// The list of ids for each Task in the query results, starting at the
+	// index given by the position argument of this response and continuing
+	// until it hits the end of the results or reaches the limit number of ids.
+	IDs []jmap.ID `json:"ids"`
+
+	// The total number of Tasks in the results (given the filter). Only set if
+	// CalculateTotal was requested.
+	Total jmap.UnsignedInt `json:"total,omitempty"`
+
+	// The limit enforced by the server on the maximum number of results to
+	// return. Only set if the server set a limit or used a different limit
+	// than that given in the request.
+	Limit jmap.UnsignedInt `json:"limit,omitempty"`
+}
+
+// TaskQueryChangesArgs contains arguments for Task/queryChanges method call.
+type TaskQueryChangesArgs struct {
+	// The id of the account to use.
+	AccountID jmap.ID `json:"accountId"`
+
+	// The filter argument that was used with Task/query.
+	Filter interface{} `json:"filter,omitempty"`
+
+	// The sort argument that was used with Task/query.
+	Sort []TaskComparator `json:"sort,omitempty"`
+
+	// The current state of the query in the client, as returned in the
+	// queryState argument of Task/query response with the same sort/filter.
+	SinceQueryState string `json:"sinceQueryState"`
+
+	// The maximum number of changes to return in the response. If zero, no
+	// limit is presumed.
+	MaxChanges jmap.UnsignedInt `json:"maxChanges,omitempty"`
+
+	// The last (highest-index) id the client currently has cached from the
+	// query results. If supplied, the server may skip changes past this id.
+	UpToID jmap.ID `json:"upToId,omitempty"`
+
+	// Does the client wish to know the total number of results now in the
+	// query?
+	CalculateTotal bool `json:"calculateTotal,omitempty"`
+}
+
+// QueryChanges returns Task/queryChanges arguments for the query with the same
+// filter and sort.
+func (args TaskQueryArgs) QueryChanges(sinceQueryState string) TaskQueryChangesArgs {
+	return TaskQueryChangesArgs{
+		AccountID:       args.AccountID,
+		Filter:          args.Filter,
+		Sort:            args.Sort,
+		SinceQueryState: sinceQueryState,
+		CalculateTotal:  args.CalculateTotal,
+	}
+}
+
+// TaskQueryChangesResponse contains results of Task/queryChanges method call.
+type TaskQueryChangesResponse struct {
+	// The id of the account used for the call.
+	AccountID jmap.ID `json:"accountId"`
+
+	// This is the sinceQueryState argument echoed back.
+	OldQueryState string `json:"oldQueryState"`
+
+	// This is the state the query will be in after applying the set of changes
+	// to the old state.
+	NewQueryState string `json:"newQueryState"`
+
+	// The total number of Tasks in the results (given the filter). Only set if
+	// CalculateTotal was requested.
+	Total jmap.UnsignedInt `json:"total,omitempty"`
+
+	// The ids for Tasks that have been removed from the results since the old
+	// state, or whose position may have changed.
+	Removed []jmap.ID `json:"removed"`
+
+	// Tasks that have been added to the results since the old state, or whose
+	// position may have changed, sorted by index.
+	Added []jmap.AddedItem `json:"added"`
+}
+
+// Apply updates the cached Task/query results, see jmap.ApplyQueryChanges for
+// details.
+func (resp TaskQueryChangesResponse) Apply(ids []jmap.ID) []jmap.ID {
+	return jmap.ApplyQueryChanges(ids, resp.Removed, resp.Added)
+}
+
+// TaskSetArgs contains arguments for Task/set method call.
+type TaskSetArgs struct {
+	// The id of the account to use.
+	AccountID jmap.ID `json:"accountId"`
+
+	// If not empty, the method will be aborted and a stateMismatch error
+	// returned if the current state does not match this string.
+	IfInState string `json:"ifInState,omitempty"`
+
+	// A map of creation id to Task objects.
+	Create map[jmap.ID]Task `json:"create,omitempty"`
+
+	// A map of id to a patch object to apply to the current Task object with
+	// that id.
+	Update map[jmap.ID]jmap.PatchObject `json:"update,omitempty"`
+
+	// A list of ids for Task objects to permanently delete.
+	Destroy []jmap.ID `json:"destroy,omitempty"`
+
+	// If true, the server sends scheduling messages to participants of the
+	// changed Tasks, if needed.
+	SendSchedulingMessages bool `json:"sendSchedulingMessages,omitempty"`
+}
+
+// TaskSetResponse contains results of Task/set method call.
+type TaskSetResponse struct {
+	// The id of the account used for the call.
+	AccountID jmap.ID `json:"accountId"`
+
+	// The state string that would have been returned by Task/get before making
+	// the requested changes.
+	OldState string `json:"oldState"`
+
+	// The state string that will now be returned by Task/get.
+	NewState string `json:"newState"`
+
+	// A map of the creation id to an object containing any properties of the
+	// created Task object that were not sent by the client.
+	Created map[jmap.ID]Task `json:"created"`
+
+	// The keys in this map are the ids of all Tasks that were successfully
+	// updated. The value is a Task object containing any property that changed
+	// in a way not explicitly requested, or nil if none.
+	Updated map[jmap.ID]*Task `json:"updated"`
+
+	// A list of Task ids for records that were successfully destroyed.
+	Destroyed []jmap.ID `json:"destroyed"`
+
+	// A map of creation id to a SetError object for each record that failed to
+	// be created.
+	NotCreated map[jmap.ID]jmap.SetError `json:"notCreated"`
+
+	// A map of Task id to a SetError object for each record that failed to be
+	// updated.
+	NotUpdated map[jmap.ID]jmap.SetError `json:"notUpdated"`
+
+	// A map of Task id to a SetError object for each record that failed to be
+	// destroyed.
+	NotDestroyed map[jmap.ID]jmap.SetError `json:"notDestroyed"`
+}
+
+func unmarshalTaskGetResponse(args json.RawMessage) (interface{}, error) {
+	resp := TaskGetResponse{}
+	err := json.Unmarshal(args, &resp)
+	return resp, err
+}
+
+func unmarshalTaskChangesResponse(args json.RawMessage) (interface{}, error) {
+	resp := TaskChangesResponse{}
+	err := json.Unmarshal(args, &resp)
+	return resp, err
+}
+
+func unmarshalTaskQueryResponse(args json.RawMessage) (interface{}, error) {
+	resp := TaskQueryResponse{}
+	err := json.Unmarshal(args, &resp)
+	return resp, err
+}
+
+func unmarshalTaskQueryChangesResponse(args json.RawMessage) (interface{}, error) {
+	resp := TaskQueryChangesResponse{}
+	err := json.Unmarshal(args, &resp)
+	return resp, err
+}
+
+func unmarshalTaskSetResponse(args json.RawMessage) (interface{}, error) {
+	resp := TaskSetResponse{}
+	err := json.Unmarshal(args, &resp)
+	return resp, err
+}
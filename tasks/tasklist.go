@@ -0,0 +1,253 @@
+package tasks
+
+import (
+	"encoding/json"
+
+	"github.com/foxcpp/go-jmap"
+	"github.com/foxcpp/go-jmap/calendar"
+)
+
+// TaskListRights describes the set of rights the user has on the TaskList.
+type TaskListRights struct {
+	// The user may fetch the Tasks in this TaskList.
+	MayReadItems bool `json:"mayReadItems"`
+
+	// The user may create, modify or destroy all Tasks in this TaskList.
+	MayWriteAll bool `json:"mayWriteAll"`
+
+	// The user may create, modify or destroy a Task in this TaskList if
+	// either they are the owner of the Task or the Task has no owner.
+	MayWriteOwn bool `json:"mayWriteOwn"`
+
+	// The user may modify per-user properties (keywords, alerts, etc.) on
+	// all Tasks in the TaskList.
+	MayUpdatePrivate bool `json:"mayUpdatePrivate"`
+
+	// The user may modify their own participation status on Tasks in the
+	// TaskList.
+	MayRSVP bool `json:"mayRSVP"`
+
+	// The user may modify sharing of the TaskList.
+	MayAdmin bool `json:"mayAdmin"`
+
+	// The user may delete the TaskList itself.
+	MayDelete bool `json:"mayDelete"`
+}
+
+// Values of TaskList.Role.
+const (
+	RoleInbox = "inbox"
+	RoleTrash = "trash"
+)
+
+// TaskList is a named collection of Tasks.
+//
+// See draft-ietf-jmap-tasks, section 3 for details.
+type TaskList struct {
+	// The id of the TaskList.
+	ID jmap.ID `json:"id,omitempty"`
+
+	// Denotes the TaskList with a special purpose, one of Role* constants.
+	// Empty if none.
+	Role string `json:"role,omitempty"`
+
+	// The user-visible name of the TaskList.
+	Name string `json:"name,omitempty"`
+
+	// An optional longer-form description of the TaskList.
+	Description string `json:"description,omitempty"`
+
+	// A color to be used when displaying Tasks associated with the
+	// TaskList, as CSS color value.
+	Color string `json:"color,omitempty"`
+
+	// Colors to be used when displaying Tasks with the keyword or the
+	// category, as CSS color values.
+	KeywordColors  map[string]string `json:"keywordColors,omitempty"`
+	CategoryColors map[string]string `json:"categoryColors,omitempty"`
+
+	// Defines the sort order of TaskLists when presented in the client's UI.
+	SortOrder jmap.UnsignedInt `json:"sortOrder,omitempty"`
+
+	// True if the user has indicated they wish to see this TaskList in their
+	// client.
+	IsSubscribed bool `json:"isSubscribed,omitempty"`
+
+	// The time zone to use for Tasks without a time zone when the server
+	// needs to resolve them into absolute time.
+	TimeZone string `json:"timeZone,omitempty"`
+
+	// The allowed values of Task.WorkflowStatus. Empty if any value is
+	// allowed.
+	WorkflowStatuses []string `json:"workflowStatuses,omitempty"`
+
+	// Alerts to use for Tasks with showWithoutTime false and
+	// useDefaultAlerts true.
+	DefaultAlertsWithTime map[string]calendar.Alert `json:"defaultAlertsWithTime,omitempty"`
+
+	// Alerts to use for Tasks with showWithoutTime true and
+	// useDefaultAlerts true.
+	DefaultAlertsWithoutTime map[string]calendar.Alert `json:"defaultAlertsWithoutTime,omitempty"`
+
+	// A map of principal id to rights for principals this TaskList is
+	// shared with. Nil if the TaskList is not shared.
+	ShareWith map[jmap.ID]TaskListRights `json:"shareWith,omitempty"`
+
+	// The set of access rights the user has in relation to this TaskList.
+	// Set by server.
+	MyRights *TaskListRights `json:"myRights,omitempty"`
+}
+
+// TaskListGetArgs contains arguments for TaskList/get method call.
+type TaskListGetArgs struct {
+	// The id of the account to use.
+	AccountID jmap.ID `json:"accountId"`
+
+	// The ids of the TaskList objects to return. If nil, then all records
+	// are returned.
+	IDs []jmap.ID `json:"ids"`
+
+	// If not nil, only the properties listed in the array are returned for
+	// each TaskList object.
+	Properties []string `json:"properties"`
+}
+
+// TaskListGetResponse contains results of TaskList/get method call.
+type TaskListGetResponse struct {
+	// The id of the account used for the call.
+	AccountID jmap.ID `json:"accountId"`
+
+	// A string representing the state on the server for all the data of this
+	// type in the account.
+	State string `json:"state"`
+
+	// An array of the TaskList objects requested.
+	List []TaskList `json:"list"`
+
+	// This array contains the ids passed to the method for records that do
+	// not exist.
+	NotFound []jmap.ID `json:"notFound"`
+}
+
+// TaskListChangesArgs contains arguments for TaskList/changes method call.
+type TaskListChangesArgs struct {
+	// The id of the account to use.
+	AccountID jmap.ID `json:"accountId"`
+
+	// The current state of the client, as returned by TaskList/get.
+	SinceState string `json:"sinceState"`
+
+	// The maximum number of ids to return in the response. Zero means no
+	// limit.
+	MaxChanges jmap.UnsignedInt `json:"maxChanges,omitempty"`
+}
+
+// TaskListChangesResponse contains results of TaskList/changes method call.
+type TaskListChangesResponse struct {
+	// The id of the account used for the call.
+	AccountID jmap.ID `json:"accountId"`
+
+	// This is the sinceState argument echoed back.
+	OldState string `json:"oldState"`
+
+	// This is the state the client will be in after applying the set of
+	// changes to the old state.
+	NewState string `json:"newState"`
+
+	// If true, the client may call TaskList/changes again with the NewState
+	// returned to get further updates.
+	HasMoreChanges bool `json:"hasMoreChanges"`
+
+	// An array of ids for records that have been created since the old
+	// state.
+	Created []jmap.ID `json:"created"`
+
+	// An array of ids for records that have been updated since the old
+	// state.
+	Updated []jmap.ID `json:"updated"`
+
+	// An array of ids for records that have been destroyed since the old
+	// state.
+	Destroyed []jmap.ID `json:"destroyed"`
+}
+
+// TaskListSetArgs contains arguments for TaskList/set method call.
+type TaskListSetArgs struct {
+	// The id of the account to use.
+	AccountID jmap.ID `json:"accountId"`
+
+	// If not empty, the method will be aborted and a stateMismatch error
+	// returned if the current state does not match this string.
+	IfInState string `json:"ifInState,omitempty"`
+
+	// A map of creation id to TaskList objects.
+	Create map[jmap.ID]TaskList `json:"create,omitempty"`
+
+	// A map of id to a patch object to apply to the current TaskList object
+	// with that id.
+	Update map[jmap.ID]jmap.PatchObject `json:"update,omitempty"`
+
+	// A list of ids for TaskList objects to permanently delete.
+	Destroy []jmap.ID `json:"destroy,omitempty"`
+
+	// If false, any attempt to destroy a TaskList that still has Tasks in
+	// it will be rejected with a taskListHasTask SetError. If true, any
+	// Tasks that were in the TaskList will be destroyed.
+	OnDestroyRemoveTasks bool `json:"onDestroyRemoveTasks,omitempty"`
+}
+
+// TaskListSetResponse contains results of TaskList/set method call.
+type TaskListSetResponse struct {
+	// The id of the account used for the call.
+	AccountID jmap.ID `json:"accountId"`
+
+	// The state string that would have been returned by TaskList/get before
+	// making the requested changes.
+	OldState string `json:"oldState"`
+
+	// The state string that will now be returned by TaskList/get.
+	NewState string `json:"newState"`
+
+	// A map of the creation id to an object containing any properties of the
+	// created TaskList object that were not sent by the client.
+	Created map[jmap.ID]TaskList `json:"created"`
+
+	// The keys in this map are the ids of all TaskLists that were
+	// successfully updated. The value is a TaskList object containing any
+	// property that changed in a way not explicitly requested, or nil if
+	// none.
+	Updated map[jmap.ID]*TaskList `json:"updated"`
+
+	// A list of TaskList ids for records that were successfully destroyed.
+	Destroyed []jmap.ID `json:"destroyed"`
+
+	// A map of creation id to a SetError object for each record that failed
+	// to be created.
+	NotCreated map[jmap.ID]jmap.SetError `json:"notCreated"`
+
+	// A map of TaskList id to a SetError object for each record that failed
+	// to be updated.
+	NotUpdated map[jmap.ID]jmap.SetError `json:"notUpdated"`
+
+	// A map of TaskList id to a SetError object for each record that failed
+	// to be destroyed.
+	NotDestroyed map[jmap.ID]jmap.SetError `json:"notDestroyed"`
+}
+
+func unmarshalTaskListGetResponse(args json.RawMessage) (interface{}, error) {
+	resp := TaskListGetResponse{}
+	err := json.Unmarshal(args, &resp)
+	return resp, err
+}
+
+func unmarshalTaskListChangesResponse(args json.RawMessage) (interface{}, error) {
+	resp := TaskListChangesResponse{}
+	err := json.Unmarshal(args, &resp)
+	return resp, err
+}
+
+func unmarshalTaskListSetResponse(args json.RawMessage) (interface{}, error) {
+	resp := TaskListSetResponse{}
+	err := json.Unmarshal(args, &resp)
+	return resp, err
+}
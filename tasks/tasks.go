@@ -0,0 +1,112 @@
+// Package tasks implements data types and methods of JMAP for Tasks
+// extension as defined in draft-ietf-jmap-tasks.
+//
+// Task objects use JSCalendar format defined in RFC 8984. Types shared with
+// calendar events are defined in the calendar package.
+//
+// Documentation strings for most of the protocol objects are taken from (or
+// based on) contents of draft-ietf-jmap-tasks and RFC 8984 and are subject
+// to the IETF Trust Provisions. See
+// https://trustee.ietf.org/trust-legal-provisions.html for details.
+package tasks
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/foxcpp/go-jmap"
+)
+
+const CapabilityName = "urn:ietf:params:jmap:tasks"
+
+var ErrNoCapability = errors.New("jmap/tasks: urn:ietf:params:jmap:tasks capability is not supported for the account")
+
+// Capability is the urn:ietf:params:jmap:tasks account capability object.
+type Capability struct {
+	// The earliest date-time value the server is willing to accept for any
+	// date stored in a Task.
+	MinDateTime *jmap.UTCDate `json:"minDateTime"`
+
+	// The latest date-time value the server is willing to accept for any
+	// date stored in a Task.
+	MaxDateTime *jmap.UTCDate `json:"maxDateTime"`
+
+	// If true, the user may create a TaskList in this account.
+	MayCreateTaskList bool `json:"mayCreateTaskList"`
+}
+
+// AccountCapability returns decoded urn:ietf:params:jmap:tasks capability
+// object of the account.
+//
+// ErrNoCapability is returned if the account does not contain tasks data.
+func AccountCapability(acc *jmap.Account) (*Capability, error) {
+	raw, ok := acc.Capabilities[CapabilityName]
+	if !ok {
+		return nil, ErrNoCapability
+	}
+	res := &Capability{}
+	if err := json.Unmarshal(raw, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// ResponseUnmarshallers contains callbacks for decoding responses of all
+// methods implemented by this package.
+//
+// Pass it to client.Enable to make the client decode these responses into
+// corresponding structures.
+var ResponseUnmarshallers = map[string]jmap.FuncArgsUnmarshal{
+	"TaskList/get":     unmarshalTaskListGetResponse,
+	"TaskList/changes": unmarshalTaskListChangesResponse,
+	"TaskList/set":     unmarshalTaskListSetResponse,
+
+	"Task/get":          unmarshalTaskGetResponse,
+	"Task/changes":      unmarshalTaskChangesResponse,
+	"Task/query":        unmarshalTaskQueryResponse,
+	"Task/queryChanges": unmarshalTaskQueryChangesResponse,
+	"Task/set":          unmarshalTaskSetResponse,
+}
+
+// MethodCapabilities maps data types implemented by this package to
+// capabilities that define them.
+//
+// Pass it to client.EnableMethodCapabilities to let the client determine
+// which capabilities can be dropped from requests during capability
+// downgrade.
+var MethodCapabilities = map[string]string{
+	"TaskList": CapabilityName,
+	"Task":     CapabilityName,
+}
+
+func init() {
+	// All data types of the package can be used in push type filters.
+	jmap.RegisterPushTypes(MethodCapabilities)
+}
+
+// PropertySchemas contains property lists for all data types implemented by
+// this package.
+//
+// Pass it to client.EnableSchemas to make the client reject requests using
+// unknown properties before sending them.
+var PropertySchemas = jmap.PropertySchemas{
+	"TaskList": {
+		"id", "role", "name", "description", "color", "keywordColors",
+		"categoryColors", "sortOrder", "isSubscribed", "timeZone",
+		"workflowStatuses", "defaultAlertsWithTime",
+		"defaultAlertsWithoutTime", "shareWith", "myRights",
+	},
+	"Task": {
+		"id", "taskListId", "isDraft", "utcStart", "utcDue", "@type", "uid",
+		"relatedTo", "prodId", "created", "updated", "sequence", "method",
+		"title", "description", "descriptionContentType", "showWithoutTime",
+		"locations", "virtualLocations", "links", "locale", "keywords",
+		"categories", "color", "recurrenceId", "recurrenceIdTimeZone",
+		"recurrenceRules", "excludedRecurrenceRules", "recurrenceOverrides",
+		"excluded", "priority", "privacy", "replyTo", "sentBy",
+		"participants", "requestStatus", "useDefaultAlerts", "alerts",
+		"localizations", "timeZone", "timeZones", "due", "start",
+		"estimatedDuration", "percentComplete", "progress", "progressUpdated",
+		"workflowStatus",
+	},
+}
@@ -0,0 +1,66 @@
+package tasks
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/foxcpp/go-jmap"
+	"github.com/foxcpp/go-jmap/calendar"
+	"gotest.tools/assert"
+	"gotest.tools/assert/cmp"
+)
+
+func TestAccountCapability(t *testing.T) {
+	acc := jmap.Account{Capabilities: map[string]json.RawMessage{
+		CapabilityName: json.RawMessage(`{"minDateTime":"2000-01-01T00:00:00Z","maxDateTime":null,"mayCreateTaskList":true}`),
+	}}
+	capa, err := AccountCapability(&acc)
+	assert.NilError(t, err)
+	assert.Check(t, capa.MinDateTime != nil)
+	assert.Check(t, capa.MaxDateTime == nil)
+	assert.Check(t, capa.MayCreateTaskList)
+
+	_, err = AccountCapability(&jmap.Account{})
+	assert.Check(t, cmp.Equal(ErrNoCapability, err))
+}
+
+func TestTaskGetResponse(t *testing.T) {
+	blob := json.RawMessage(`{
+		"accountId": "A1",
+		"state": "s1",
+		"list": [{
+			"id": "T1",
+			"taskListId": "TL1",
+			"@type": "Task",
+			"uid": "2a358cee-6489-4f14-a57f-c104db4dc357",
+			"title": "Buy groceries",
+			"start": "2020-01-06T09:00:00",
+			"due": "2020-01-06T18:00:00",
+			"timeZone": "Europe/Berlin",
+			"estimatedDuration": "PT1H",
+			"percentComplete": 50,
+			"progress": "in-process",
+			"alerts": {
+				"a1": {"trigger": {"@type": "OffsetTrigger", "offset": "-PT15M", "relativeTo": "end"}}
+			}
+		}],
+		"notFound": ["T2"]
+	}`)
+	args, err := ResponseUnmarshallers["Task/get"](blob)
+	assert.NilError(t, err)
+	resp := args.(TaskGetResponse)
+	assert.Equal(t, 1, len(resp.List))
+	assert.Check(t, cmp.DeepEqual([]jmap.ID{"T2"}, resp.NotFound))
+
+	task := resp.List[0]
+	assert.Check(t, cmp.Equal(jmap.ID("TL1"), task.TaskListID))
+	assert.Check(t, cmp.Equal(calendar.LocalDateTime("2020-01-06T18:00:00"), task.Due))
+	assert.Check(t, cmp.Equal(calendar.Duration("PT1H"), task.EstimatedDuration))
+	assert.Check(t, cmp.Equal(ProgressInProcess, task.Progress))
+	assert.Check(t, cmp.Equal("end", task.Alerts["a1"].Trigger.RelativeTo))
+
+	// Unset properties should not be sent in /set calls.
+	out, err := json.Marshal(Task{Title: "Call Bob", Due: "2020-01-07T12:00:00"})
+	assert.NilError(t, err)
+	assert.Check(t, cmp.Equal(`{"title":"Call Bob","due":"2020-01-07T12:00:00"}`, string(out)))
+}
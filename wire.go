@@ -176,37 +176,120 @@ func (r *Response) Unmarshal(data io.Reader, argsUnmarshallers map[string]FuncAr
 
 	raw.Responses = make([]Invocation, 0, len(raw.RawResponses))
 	for _, rawResp := range raw.RawResponses {
-		var unmarshal FuncArgsUnmarshal
-		if rawResp.Name != "error" {
-			var ok bool
-			unmarshal, ok = argsUnmarshallers[rawResp.Name]
-			if !ok {
-				return UnknownMethodError{MethodName: rawResp.Name}
-			}
-		} else {
-			unmarshal = UnmarshalMethodErrorArgs
+		inv, err := decodeResponseInvocation(rawResp, argsUnmarshallers)
+		if err != nil {
+			return err
 		}
+		raw.Responses = append(raw.Responses, inv)
+	}
+
+	// We will not change r if something goes wrong.
+	r.CreatedIDs = raw.CreatedIDs
+	r.Responses = raw.Responses
+	r.SessionState = raw.SessionState
+
+	return nil
+}
 
-		args, err := unmarshal(rawResp.Args)
+// UnmarshalStream is similar to Unmarshal but decodes method responses one
+// by one, without keeping the whole serialized Response in memory. This
+// reduces peak memory usage for very large responses.
+//
+// Unknown top-level properties are skipped. If error is returned, Response
+// object is not changed.
+func (r *Response) UnmarshalStream(data io.Reader, argsUnmarshallers map[string]FuncArgsUnmarshal) error {
+	dec := json.NewDecoder(data)
+	if err := expectDelim(dec, '{'); err != nil {
+		return err
+	}
+
+	raw := rawResponse{}
+	for dec.More() {
+		keyTok, err := dec.Token()
 		if err != nil {
 			return err
 		}
-
-		raw.Responses = append(raw.Responses, Invocation{
-			Name:   rawResp.Name,
-			CallID: rawResp.CallID,
-			Args:   args,
-		})
+		switch keyTok {
+		case "methodResponses":
+			if err := expectDelim(dec, '['); err != nil {
+				return err
+			}
+			for dec.More() {
+				var rawResp rawInvocation
+				if err := dec.Decode(&rawResp); err != nil {
+					return err
+				}
+				inv, err := decodeResponseInvocation(rawResp, argsUnmarshallers)
+				if err != nil {
+					return err
+				}
+				raw.Responses = append(raw.Responses, inv)
+			}
+			if err := expectDelim(dec, ']'); err != nil {
+				return err
+			}
+		case "createdIds":
+			if err := dec.Decode(&raw.CreatedIDs); err != nil {
+				return err
+			}
+		case "sessionState":
+			if err := dec.Decode(&raw.SessionState); err != nil {
+				return err
+			}
+		default:
+			var skip json.RawMessage
+			if err := dec.Decode(&skip); err != nil {
+				return err
+			}
+		}
+	}
+	if err := expectDelim(dec, '}'); err != nil {
+		return err
 	}
 
-	// We will not change r if something goes wrong.
+	if raw.Responses == nil {
+		raw.Responses = []Invocation{}
+	}
 	r.CreatedIDs = raw.CreatedIDs
 	r.Responses = raw.Responses
 	r.SessionState = raw.SessionState
+	return nil
+}
 
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok != delim {
+		return fmt.Errorf("jmap: malformed Response object, expected %v, got %v", delim, tok)
+	}
 	return nil
 }
 
+func decodeResponseInvocation(rawResp rawInvocation, argsUnmarshallers map[string]FuncArgsUnmarshal) (Invocation, error) {
+	var unmarshal FuncArgsUnmarshal
+	if rawResp.Name != "error" {
+		var ok bool
+		unmarshal, ok = argsUnmarshallers[rawResp.Name]
+		if !ok {
+			return Invocation{}, UnknownMethodError{MethodName: rawResp.Name}
+		}
+	} else {
+		unmarshal = UnmarshalMethodErrorArgs
+	}
+
+	args, err := unmarshal(rawResp.Args)
+	if err != nil {
+		return Invocation{}, err
+	}
+	return Invocation{
+		Name:   rawResp.Name,
+		CallID: rawResp.CallID,
+		Args:   args,
+	}, nil
+}
+
 func (i *rawInvocation) UnmarshalJSON(data []byte) error {
 	var methodName, callId string
 	var args json.RawMessage
@@ -247,3 +330,21 @@ func (i rawInvocation) MarshalJSON() ([]byte, error) {
 }
 
 type FuncArgsUnmarshal func(args json.RawMessage) (interface{}, error)
+
+// ResultReference allows to use result of previous method call in the same
+// request as an argument value. To use it, the argument name should be
+// prefixed with "#".
+//
+// See section 3.7 of JMAP Core specification.
+type ResultReference struct {
+	// The method call id of a previous method call in the current request.
+	ResultOf string `json:"resultOf"`
+
+	// The required name of a response to that method call.
+	Name string `json:"name"`
+
+	// A pointer into the arguments of the response selected via the name and
+	// resultOf properties. This is a JSON Pointer (RFC 6901), except it also
+	// allows the use of "*" to map through an array.
+	Path string `json:"path"`
+}
@@ -182,3 +182,31 @@ func TestUnmarshalResponse(t *testing.T) {
 		}, resp.Responses))
 	})
 }
+
+func TestUnmarshalResponseStream(t *testing.T) {
+	blob := `{"sessionState":"state!","unknown":{"a":[1,2]},"createdIds":{"k1":"id1"},` +
+		`"methodResponses":[["NAME",{"arg":"foo"},"id"],["error",{"type":"unknownMethod"},"id2"]]}`
+	resp := Response{}
+	err := resp.UnmarshalStream(strings.NewReader(blob), map[string]FuncArgsUnmarshal{"NAME": unmarshalTestArgs})
+	assert.NilError(t, err, "resp.UnmarshalStream")
+	assert.Check(t, cmp.Equal("state!", resp.SessionState))
+	assert.Check(t, cmp.DeepEqual(map[ID]ID{"k1": "id1"}, resp.CreatedIDs))
+	assert.Check(t, cmp.DeepEqual([]Invocation{
+		{Name: "NAME", CallID: "id", Args: testArgs{Argument: "foo"}},
+		{Name: "error", CallID: "id2", Args: MethodErrorArgs{Type: CodeUnknownMethod}},
+	}, resp.Responses))
+
+	t.Run("unknown method", func(t *testing.T) {
+		blob := `{"sessionState":"state!","methodResponses":[["OTHER",{},"id"]]}`
+		resp := Response{}
+		err := resp.UnmarshalStream(strings.NewReader(blob), nil)
+		assert.Check(t, cmp.ErrorContains(err, "OTHER"))
+		assert.Check(t, resp.Responses == nil)
+	})
+
+	t.Run("malformed", func(t *testing.T) {
+		resp := Response{}
+		err := resp.UnmarshalStream(strings.NewReader(`[]`), nil)
+		assert.Check(t, err != nil)
+	})
+}